@@ -40,6 +40,8 @@ type baseClient struct {
 	leader atomic.Value // Store as string
 	// PD follower URLs
 	followers atomic.Value // Store as []string
+	// PD follower URLs in the local dc-location
+	localFollowers atomic.Value // Store as []string
 	// dc-location -> TSO allocator leader gRPC connection
 	clientConns sync.Map // Store as map[string]*grpc.ClientConn
 	// dc-location -> TSO allocator leader URL
@@ -58,6 +60,16 @@ type baseClient struct {
 	timeout          time.Duration
 	maxRetryTimes    int
 	enableForwarding bool
+
+	localTSOFallbackPolicy LocalTSOFallbackPolicy
+	localTSOMaxRetry       int
+
+	localDCLocation string
+
+	clusterFeaturesWatchInterval time.Duration
+
+	// connPool shares the gRPC connections with the other clients if it's set.
+	connPool *ConnPool
 }
 
 // SecurityOption records options about tls
@@ -98,6 +110,47 @@ func WithMaxErrorRetry(count int) ClientOption {
 	}
 }
 
+// LocalTSOFallbackPolicy decides how the client handles the Local TSO request
+// when the Local TSO allocator of the requested dc-location is unavailable.
+type LocalTSOFallbackPolicy int
+
+const (
+	// LocalTSORetry retries the request with backoff, and returns the error if
+	// the allocator is still unavailable. It is the default policy.
+	LocalTSORetry LocalTSOFallbackPolicy = iota
+	// LocalTSOFailFast returns the error without retrying.
+	LocalTSOFailFast
+	// LocalTSOFallbackToGlobal gets the timestamp from the Global TSO allocator
+	// instead. Use IsGlobalTSOFallback to check whether the request falls back.
+	LocalTSOFallbackToGlobal
+)
+
+// WithLocalTSOFallbackPolicy configures the client with the policy to handle
+// the Local TSO request when the allocator is unavailable.
+func WithLocalTSOFallbackPolicy(policy LocalTSOFallbackPolicy) ClientOption {
+	return func(c *baseClient) {
+		c.localTSOFallbackPolicy = policy
+	}
+}
+
+// WithLocalTSOMaxRetry configures the client with the max retry times of the
+// Local TSO request when the LocalTSORetry policy is used.
+func WithLocalTSOMaxRetry(count int) ClientOption {
+	return func(c *baseClient) {
+		c.localTSOMaxRetry = count
+	}
+}
+
+// WithLocalRegionRead configures the client to prefer the PD followers in the
+// given dc-location to serve the region lookups, which avoids the cross-DC
+// latency to the PD leader. The regions got from the followers may be a little
+// stale, and the lookups fall back to the PD leader if the followers fail.
+func WithLocalRegionRead(dcLocation string) ClientOption {
+	return func(c *baseClient) {
+		c.localDCLocation = dcLocation
+	}
+}
+
 // newBaseClient returns a new baseClient.
 func newBaseClient(ctx context.Context, urls []string, security SecurityOption, opts ...ClientOption) (*baseClient, error) {
 	ctx1, cancel := context.WithCancel(ctx)
@@ -110,6 +163,9 @@ func newBaseClient(ctx context.Context, urls []string, security SecurityOption,
 		security:             security,
 		timeout:              defaultPDTimeout,
 		maxRetryTimes:        maxInitClusterRetries,
+		localTSOMaxRetry:     defaultLocalTSOMaxRetry,
+
+		clusterFeaturesWatchInterval: defaultClusterFeaturesWatchInterval,
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -206,6 +262,15 @@ func (c *baseClient) GetFollowerAddr() []string {
 	return followerAddrs.([]string)
 }
 
+// GetLocalFollowerAddr returns the follower addresses in the local dc-location.
+func (c *baseClient) GetLocalFollowerAddr() []string {
+	followerAddrs := c.localFollowers.Load()
+	if followerAddrs == nil {
+		return []string{}
+	}
+	return followerAddrs.([]string)
+}
+
 // GetURLs returns the URLs.
 // For testing use. It should only be called when the client is closed.
 func (c *baseClient) GetURLs() []string {
@@ -365,15 +430,29 @@ func (c *baseClient) switchLeader(addrs []string) error {
 }
 
 func (c *baseClient) updateFollowers(members []*pdpb.Member, leader *pdpb.Member) {
-	var addrs []string
+	var addrs, localAddrs []string
 	for _, member := range members {
 		if member.GetMemberId() != leader.GetMemberId() {
 			if len(member.GetClientUrls()) > 0 {
 				addrs = append(addrs, member.GetClientUrls()...)
+				if c.isLocalMember(member) {
+					localAddrs = append(localAddrs, member.GetClientUrls()...)
+				}
 			}
 		}
 	}
 	c.followers.Store(addrs)
+	// There is no need to route the requests to the followers if the leader is
+	// in the local dc-location.
+	if c.isLocalMember(leader) {
+		localAddrs = nil
+	}
+	c.localFollowers.Store(localAddrs)
+}
+
+// isLocalMember checks if the member is in the local dc-location of the client.
+func (c *baseClient) isLocalMember(member *pdpb.Member) bool {
+	return len(c.localDCLocation) > 0 && member.GetDcLocation() == c.localDCLocation
 }
 
 func (c *baseClient) switchTSOAllocatorLeader(allocatorMap map[string]*pdpb.Member) error {
@@ -423,15 +502,32 @@ func (c *baseClient) getOrCreateGRPCConn(addr string) (*grpc.ClientConn, error)
 	}
 	dCtx, cancel := context.WithTimeout(c.ctx, dialTimeout)
 	defer cancel()
-	cc, err := grpcutil.GetClientConn(dCtx, addr, tlsCfg, c.gRPCDialOptions...)
+	var cc *grpc.ClientConn
+	if c.connPool != nil {
+		cc, err = c.connPool.acquire(dCtx, addr, c.security, tlsCfg, c.gRPCDialOptions...)
+	} else {
+		cc, err = grpcutil.GetClientConn(dCtx, addr, tlsCfg, c.gRPCDialOptions...)
+	}
 	if err != nil {
 		return nil, err
 	}
 	if old, ok := c.clientConns.Load(addr); ok {
-		cc.Close()
+		c.closeGRPCConn(addr, cc)
 		log.Debug("use old connection", zap.String("target", cc.Target()), zap.String("state", cc.GetState().String()))
 		return old.(*grpc.ClientConn), nil
 	}
 	c.clientConns.Store(addr, cc)
 	return cc, nil
 }
+
+// closeGRPCConn closes the connection, or releases it to the pool if the
+// connection is shared.
+func (c *baseClient) closeGRPCConn(addr string, cc *grpc.ClientConn) {
+	if c.connPool != nil {
+		c.connPool.release(addr, c.security)
+		return
+	}
+	if err := cc.Close(); err != nil {
+		log.Error("[pd] failed to close gRPC clientConn", errs.ZapError(errs.ErrCloseGRPCConn, err))
+	}
+}
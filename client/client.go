@@ -14,9 +14,11 @@
 package pd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -30,10 +32,12 @@ import (
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/pkg/traceutil"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -45,6 +49,13 @@ type Region struct {
 	PendingPeers []*metapb.Peer
 }
 
+// RegionResult is the result of getting a region by id in a batch.
+type RegionResult struct {
+	RegionID uint64
+	Region   *Region
+	Err      error
+}
+
 // Client is a PD (Placement Driver) client.
 // It should not be used after calling Close().
 type Client interface {
@@ -62,6 +73,8 @@ type Client interface {
 	// GetLocalTS gets a local timestamp from PD.
 	GetLocalTS(ctx context.Context, dcLocation string) (int64, int64, error)
 	// GetLocalTSAsync gets a local timestamp from PD, without block the caller.
+	// If the Local TSO allocator is unavailable, it is handled according to the
+	// LocalTSOFallbackPolicy of the client.
 	GetLocalTSAsync(ctx context.Context, dcLocation string) TSFuture
 	// GetRegion gets a region and its leader Peer from PD by key.
 	// The region may expire after split. Caller is responsible for caching and
@@ -75,10 +88,16 @@ type Client interface {
 	GetPrevRegion(ctx context.Context, key []byte) (*Region, error)
 	// GetRegionByID gets a region and its leader Peer from PD by id.
 	GetRegionByID(ctx context.Context, regionID uint64) (*Region, error)
+	// GetRegionsByIDs gets the regions and their leader Peers from PD by ids.
+	// The results are in the same order as the ids. The failure of an id is
+	// reported in its result and does not fail the others, the Region of the
+	// result is nil if PD finds no region for the id.
+	GetRegionsByIDs(ctx context.Context, regionIDs []uint64) []*RegionResult
 	// ScanRegion gets a list of regions, starts from the region that contains key.
 	// Limit limits the maximum number of regions returned.
 	// If a region has no leader, corresponding leader will be placed by a peer
-	// with empty value (PeerID is 0).
+	// with empty value (PeerID is 0). If the response is truncated by the limits
+	// of PD, it continues to scan the rest regions.
 	ScanRegions(ctx context.Context, key, endKey []byte, limit int) ([]*Region, error)
 	// GetStore gets a store from PD by store id.
 	// The store may expire later. Caller is responsible for caching and taking care
@@ -109,6 +128,29 @@ type Client interface {
 	SplitRegions(ctx context.Context, splitKeys [][]byte, opts ...RegionsOption) (*pdpb.SplitRegionsResponse, error)
 	// GetOperator gets the status of operator of the specified region.
 	GetOperator(ctx context.Context, regionID uint64) (*pdpb.GetOperatorResponse, error)
+	// GetClusterFeatures gets the cluster version and the feature gates enabled
+	// by it, such as the joint consensus support.
+	GetClusterFeatures(ctx context.Context) (*ClusterFeatures, error)
+	// WatchClusterFeatures watches the cluster version and the feature gates.
+	// The current ones are sent at first, then the changed ones are sent when
+	// the change is detected. The channel is closed when the ctx is done or
+	// the client is closed.
+	WatchClusterFeatures(ctx context.Context) (<-chan *ClusterFeatures, error)
+	// GetServiceVersions gets the versions of the PD leader and the cluster,
+	// with the features and the API compatibility level the leader supports.
+	GetServiceVersions(ctx context.Context) (*ServiceVersions, error)
+	// CreateKeyspace creates a keyspace with the name and the config, the id of
+	// the keyspace is allocated by PD.
+	CreateKeyspace(ctx context.Context, name string, config map[string]string) (*KeyspaceMeta, error)
+	// GetKeyspace gets the keyspace with the name.
+	GetKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error)
+	// GetAllKeyspaces gets all keyspaces in the order of the id.
+	GetAllKeyspaces(ctx context.Context) ([]*KeyspaceMeta, error)
+	// EnableKeyspace enables the keyspace with the name.
+	EnableKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error)
+	// DisableKeyspace disables the keyspace with the name. The keyspace and its
+	// id are kept, so it can be enabled again.
+	DisableKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error)
 	// Close closes the client.
 	Close()
 }
@@ -128,8 +170,9 @@ func WithExcludeTombstone() GetStoreOption {
 
 // RegionsOp represents available options when operate regions
 type RegionsOp struct {
-	group      string
-	retryLimit uint64
+	group          string
+	retryLimit     uint64
+	idempotencyKey string
 }
 
 // RegionsOption configures RegionsOp
@@ -145,6 +188,13 @@ func WithRetry(retry uint64) RegionsOption {
 	return func(op *RegionsOp) { op.retryLimit = retry }
 }
 
+// WithIdempotencyKey specify the idempotency key during Scatter/Split Regions,
+// the requests with the same key are applied once by the PD leader, so that
+// the request can be retried safely after a timeout.
+func WithIdempotencyKey(key string) RegionsOption {
+	return func(op *RegionsOp) { op.idempotencyKey = key }
+}
+
 type tsoRequest struct {
 	start      time.Time
 	clientCtx  context.Context
@@ -153,12 +203,19 @@ type tsoRequest struct {
 	physical   int64
 	logical    int64
 	dcLocation string
+	// dispatchErr is the error of dispatching the request.
+	dispatchErr error
 }
 
 type tsoDispatcher struct {
 	dispatcherCtx    context.Context
 	dispatcherCancel context.CancelFunc
 	tsoRequestCh     chan *tsoRequest
+	// clients are the clients sharing the dispatcher in a ConnPool, and driven
+	// is set if one of them handles the requests. They are protected by the
+	// lock of the pool.
+	clients []*client
+	driven  bool
 }
 
 type lastTSO struct {
@@ -175,6 +232,12 @@ const (
 	maxInitClusterRetries = 100
 	retryInterval         = 1 * time.Second
 	maxRetryTimes         = 5
+	// The TSO request is retried once if its dispatcher is not found by default.
+	defaultLocalTSOMaxRetry = 1
+	dispatchRetryInterval   = 50 * time.Millisecond
+	// The region lookup served by the local follower uses a short timeout to
+	// fall back to the leader quickly.
+	localRegionReadTimeout = 200 * time.Millisecond
 )
 
 // LeaderHealthCheckInterval might be chagned in the unit to shorten the testing time.
@@ -202,6 +265,12 @@ type client struct {
 	checkTSDeadlineCh chan struct{}
 
 	leaderNetworkFailure int32
+
+	// httpClient is used to access the API of the PD servers, it is created
+	// when it is used for the first time.
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+	httpClientErr  error
 }
 
 // NewClient creates a PD client.
@@ -235,6 +304,11 @@ func (c *client) updateTSODispatcher() {
 	c.allocators.Range(func(dcLocationKey, _ interface{}) bool {
 		dcLocation := dcLocationKey.(string)
 		if !c.checkTSODispatcher(dcLocation) {
+			if c.connPool != nil {
+				log.Info("[pd] use shared tso dispatcher", zap.String("dc-location", dcLocation))
+				c.tsoDispatcher.Store(dcLocation, c.connPool.acquireTSODispatcher(c, dcLocation))
+				return true
+			}
 			log.Info("[pd] create tso dispatcher", zap.String("dc-location", dcLocation))
 			c.createTSODispatcher(dcLocation)
 			dispatcher, _ := c.tsoDispatcher.Load(dcLocation)
@@ -249,21 +323,34 @@ func (c *client) updateTSODispatcher() {
 		return true
 	})
 	// Clean up the unused TSO dispatcher
-	c.tsoDispatcher.Range(func(dcLocationKey, _ interface{}) bool {
+	c.tsoDispatcher.Range(func(dcLocationKey, dispatcher interface{}) bool {
 		dcLocation := dcLocationKey.(string)
 		// Skip the Global TSO Allocator
 		if dcLocation == globalDCLocation {
 			return true
 		}
-		if dispatcher, exist := c.allocators.Load(dcLocation); !exist {
+		if _, exist := c.allocators.Load(dcLocation); !exist {
 			log.Info("[pd] delete unused tso dispatcher", zap.String("dc-location", dcLocation))
-			dispatcher.(*tsoDispatcher).dispatcherCancel()
+			c.stopTSODispatcher(dcLocation, dispatcher.(*tsoDispatcher), nil)
 			c.tsoDispatcher.Delete(dcLocation)
 		}
 		return true
 	})
 }
 
+// stopTSODispatcher stops the TSO dispatcher of the dc-location, and the
+// pending requests are revoked with the error if it's not nil. The dispatcher
+// shared in a ConnPool is only stopped when no client uses it.
+func (c *client) stopTSODispatcher(dcLocation string, dispatcher *tsoDispatcher, err error) {
+	if c.connPool != nil && !c.connPool.releaseTSODispatcher(c, dcLocation) {
+		return
+	}
+	if err != nil {
+		c.revokeTSORequest(err, dispatcher.tsoRequestCh)
+	}
+	dispatcher.dispatcherCancel()
+}
+
 func (c *client) leaderCheckLoop() {
 	defer c.wg.Done()
 
@@ -718,6 +805,12 @@ func (c *client) processTSORequests(stream pdpb.PD_TsoClient, dcLocation string,
 		c.finishTSORequest(requests, 0, 0, 0, err)
 		return err
 	}
+	// The request is rejected by PD, e.g. the TSO quota is exceeded. Only
+	// the requests fail, and the stream can still be used.
+	if respErr := resp.GetHeader().GetError(); respErr != nil {
+		c.finishTSORequest(requests, 0, 0, 0, errors.Errorf("[pd] get tso failed, %s", respErr.GetMessage()))
+		return nil
+	}
 	requestDurationTSO.Observe(time.Since(start).Seconds())
 	tsoBatchSize.Observe(float64(count))
 
@@ -791,18 +884,15 @@ func (c *client) Close() {
 	c.cancel()
 	c.wg.Wait()
 
-	c.tsoDispatcher.Range(func(_, dispatcher interface{}) bool {
+	c.tsoDispatcher.Range(func(dcLocation, dispatcher interface{}) bool {
 		if dispatcher != nil {
-			c.revokeTSORequest(errors.WithStack(errClosing), dispatcher.(*tsoDispatcher).tsoRequestCh)
-			dispatcher.(*tsoDispatcher).dispatcherCancel()
+			c.stopTSODispatcher(dcLocation.(string), dispatcher.(*tsoDispatcher), errors.WithStack(errClosing))
 		}
 		return true
 	})
 
-	c.clientConns.Range(func(_, cc interface{}) bool {
-		if err := cc.(*grpc.ClientConn).Close(); err != nil {
-			log.Error("[pd] failed to close gRPC clientConn", errs.ZapError(errs.ErrCloseGRPCConn, err))
-		}
+	c.clientConns.Range(func(addr, cc interface{}) bool {
+		c.closeGRPCConn(addr.(string), cc.(*grpc.ClientConn))
 		return true
 	})
 }
@@ -840,6 +930,43 @@ func (c *client) followerClient() (pdpb.PDClient, string) {
 	return nil, ""
 }
 
+// localFollowerClient gets the client of a PD follower in the local
+// dc-location, which serves the region lookups.
+func (c *client) localFollowerClient() (pdpb.PDClient, string) {
+	addrs := c.GetLocalFollowerAddr()
+	if len(addrs) < 1 {
+		return nil, ""
+	}
+	addr := addrs[rand.Intn(len(addrs))]
+	cc, err := c.getOrCreateGRPCConn(addr)
+	if err != nil {
+		return nil, ""
+	}
+	return pdpb.NewPDClient(cc), addr
+}
+
+// getRegionFromLocal gets the region from a PD follower in the local
+// dc-location. It returns nil if the lookup should fall back to the leader.
+func (c *client) getRegionFromLocal(ctx context.Context, get func(context.Context, pdpb.PDClient) (*pdpb.GetRegionResponse, error)) *pdpb.GetRegionResponse {
+	followerClient, addr := c.localFollowerClient()
+	if followerClient == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, localRegionReadTimeout)
+	defer cancel()
+	resp, err := get(grpcutil.BuildFollowerHandleContext(ctx), followerClient)
+	if err != nil {
+		log.Debug("[pd] failed to get region from local follower", zap.String("addr", addr), errs.ZapError(err))
+		return nil
+	}
+	// The follower may not have synced the region yet, or the regions synced
+	// by it may be stale.
+	if resp.GetHeader().GetError() != nil || resp.GetRegion() == nil {
+		return nil
+	}
+	return resp
+}
+
 func (c *client) getClient() pdpb.PDClient {
 	if c.enableForwarding && atomic.LoadInt32(&c.leaderNetworkFailure) == 1 {
 		followerClient, addr := c.followerClient()
@@ -870,19 +997,111 @@ func (c *client) GetLocalTSAsync(ctx context.Context, dcLocation string) TSFutur
 		span = opentracing.StartSpan("GetLocalTSAsync", opentracing.ChildOf(span.Context()))
 		ctx = opentracing.ContextWithSpan(ctx, span)
 	}
+	if dcLocation != globalDCLocation {
+		return &localTSOFuture{
+			client:     c,
+			ctx:        ctx,
+			dcLocation: dcLocation,
+			req:        c.sendTSORequest(ctx, dcLocation),
+		}
+	}
+	req := c.sendTSORequest(ctx, dcLocation)
+	if req.dispatchErr == nil {
+		return req
+	}
+	err := req.dispatchErr
+	// Wait for a while and try again
+	backoff := dispatchRetryInterval
+	for i := 0; i < defaultLocalTSOMaxRetry; i++ {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			req.done <- err
+			return req
+		}
+		if err = c.dispatchRequest(dcLocation, req); err == nil {
+			return req
+		}
+		backoff *= 2
+	}
+	req.done <- err
+	return req
+}
+
+// sendTSORequest dispatches a TSO request of the dc-location. If the request
+// can not be dispatched, the error is recorded in dispatchErr, and the request
+// is not finished so that the caller can retry it.
+func (c *client) sendTSORequest(ctx context.Context, dcLocation string) *tsoRequest {
 	req := tsoReqPool.Get().(*tsoRequest)
 	req.requestCtx = ctx
 	req.clientCtx = c.ctx
 	req.start = time.Now()
 	req.dcLocation = dcLocation
-	if err := c.dispatchRequest(dcLocation, req); err != nil {
-		// Wait for a while and try again
-		time.Sleep(50 * time.Millisecond)
-		if err = c.dispatchRequest(dcLocation, req); err != nil {
-			req.done <- err
+	req.dispatchErr = c.dispatchRequest(dcLocation, req)
+	return req
+}
+
+// localTSOFuture is the TSFuture of a Local TSO request. If the Local TSO
+// allocator is unavailable, either the request can not be dispatched or the
+// tso stream fails, the request is handled according to the
+// LocalTSOFallbackPolicy of the client when waiting for the result.
+type localTSOFuture struct {
+	client     *client
+	ctx        context.Context
+	dcLocation string
+	req        *tsoRequest
+	// fallback is set if the request is served by the Global TSO allocator.
+	fallback bool
+}
+
+// Wait implements TSFuture.
+func (f *localTSOFuture) Wait() (int64, int64, error) {
+	c := f.client
+	physical, logical, err := f.wait(f.req)
+	backoff := dispatchRetryInterval
+	for retry := 0; err != nil; retry++ {
+		// The request exceeding the quota should not be served by the other
+		// allocators.
+		if f.ctx.Err() != nil || c.ctx.Err() != nil || IsTSOQuotaExceeded(err) {
+			return 0, 0, err
+		}
+		switch c.localTSOFallbackPolicy {
+		case LocalTSOFailFast:
+			return 0, 0, err
+		case LocalTSOFallbackToGlobal:
+			log.Warn("[pd] local tso allocator is unavailable, fall back to global tso", zap.String("dc-location", f.dcLocation), errs.ZapError(err))
+			localTSOFallback.WithLabelValues(f.dcLocation).Inc()
+			f.fallback = true
+			return c.GetTSAsync(f.ctx).Wait()
+		}
+		if retry >= c.localTSOMaxRetry {
+			return 0, 0, err
 		}
+		select {
+		case <-time.After(backoff):
+		case <-f.ctx.Done():
+			return 0, 0, err
+		}
+		backoff *= 2
+		physical, logical, err = f.wait(c.sendTSORequest(f.ctx, f.dcLocation))
 	}
-	return req
+	return physical, logical, nil
+}
+
+func (f *localTSOFuture) wait(req *tsoRequest) (int64, int64, error) {
+	if err := req.dispatchErr; err != nil {
+		tsoReqPool.Put(req)
+		return 0, 0, err
+	}
+	return req.Wait()
+}
+
+// IsGlobalTSOFallback returns whether the TSFuture returned by GetLocalTSAsync
+// is served by the Global TSO allocator because the Local TSO allocator is
+// unavailable. It must be called after Wait returns.
+func IsGlobalTSOFallback(future TSFuture) bool {
+	f, ok := future.(*localTSOFuture)
+	return ok && f.fallback
 }
 
 func (c *client) dispatchRequest(dcLocation string, request *tsoRequest) error {
@@ -958,16 +1177,23 @@ func (c *client) GetRegion(ctx context.Context, key []byte) (*Region, error) {
 	if span := opentracing.SpanFromContext(ctx); span != nil {
 		span = opentracing.StartSpan("pdclient.GetRegion", opentracing.ChildOf(span.Context()))
 		defer span.Finish()
+		ctx = opentracing.ContextWithSpan(ctx, span)
 	}
 	start := time.Now()
 	defer func() { cmdDurationGetRegion.Observe(time.Since(start).Seconds()) }()
 
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	req := &pdpb.GetRegionRequest{
 		Header:    c.requestHeader(),
 		RegionKey: key,
 	}
+	if resp := c.getRegionFromLocal(ctx, func(ctx context.Context, cli pdpb.PDClient) (*pdpb.GetRegionResponse, error) {
+		return cli.GetRegion(ctx, req)
+	}); resp != nil {
+		return handleRegionResponse(resp), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
+	ctx = traceutil.InjectToOutgoingContext(ctx)
 	resp, err := c.getClient().GetRegion(ctx, req)
 	cancel()
 
@@ -1029,11 +1255,16 @@ func (c *client) GetPrevRegion(ctx context.Context, key []byte) (*Region, error)
 	start := time.Now()
 	defer func() { cmdDurationGetPrevRegion.Observe(time.Since(start).Seconds()) }()
 
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	req := &pdpb.GetRegionRequest{
 		Header:    c.requestHeader(),
 		RegionKey: key,
 	}
+	if resp := c.getRegionFromLocal(ctx, func(ctx context.Context, cli pdpb.PDClient) (*pdpb.GetRegionResponse, error) {
+		return cli.GetPrevRegion(ctx, req)
+	}); resp != nil {
+		return handleRegionResponse(resp), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
 	resp, err := c.getClient().GetPrevRegion(ctx, req)
 	cancel()
@@ -1054,11 +1285,16 @@ func (c *client) GetRegionByID(ctx context.Context, regionID uint64) (*Region, e
 	start := time.Now()
 	defer func() { cmdDurationGetRegionByID.Observe(time.Since(start).Seconds()) }()
 
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	req := &pdpb.GetRegionByIDRequest{
 		Header:   c.requestHeader(),
 		RegionId: regionID,
 	}
+	if resp := c.getRegionFromLocal(ctx, func(ctx context.Context, cli pdpb.PDClient) (*pdpb.GetRegionResponse, error) {
+		return cli.GetRegionByID(ctx, req)
+	}); resp != nil {
+		return handleRegionResponse(resp), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
 	resp, err := c.getClient().GetRegionByID(ctx, req)
 	cancel()
@@ -1071,6 +1307,49 @@ func (c *client) GetRegionByID(ctx context.Context, regionID uint64) (*Region, e
 	return handleRegionResponse(resp), nil
 }
 
+// maxGetRegionsByIDsConcurrency is the maximum count of the concurrent requests
+// sent by a GetRegionsByIDs call.
+const maxGetRegionsByIDsConcurrency = 16
+
+func (c *client) GetRegionsByIDs(ctx context.Context, regionIDs []uint64) []*RegionResult {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.GetRegionsByIDs", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+		ctx = opentracing.ContextWithSpan(ctx, span)
+	}
+	start := time.Now()
+	defer func() { cmdDurationGetRegionsByIDs.Observe(time.Since(start).Seconds()) }()
+
+	results := make([]*RegionResult, len(regionIDs))
+	concurrency := maxGetRegionsByIDsConcurrency
+	if len(regionIDs) < concurrency {
+		concurrency = len(regionIDs)
+	}
+	idxCh := make(chan int, len(regionIDs))
+	for i := range regionIDs {
+		idxCh <- i
+	}
+	close(idxCh)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range idxCh {
+				result := &RegionResult{RegionID: regionIDs[idx]}
+				if err := ctx.Err(); err != nil {
+					result.Err = errors.WithStack(err)
+				} else {
+					result.Region, result.Err = c.GetRegionByID(ctx, regionIDs[idx])
+				}
+				results[idx] = result
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
 func (c *client) ScanRegions(ctx context.Context, key, endKey []byte, limit int) ([]*Region, error) {
 	if span := opentracing.SpanFromContext(ctx); span != nil {
 		span = opentracing.StartSpan("pdclient.ScanRegions", opentracing.ChildOf(span.Context()))
@@ -1079,6 +1358,36 @@ func (c *client) ScanRegions(ctx context.Context, key, endKey []byte, limit int)
 	start := time.Now()
 	defer cmdDurationScanRegions.Observe(time.Since(start).Seconds())
 
+	var regions []*Region
+	for {
+		batch, truncated, err := c.scanRegions(ctx, key, endKey, limit)
+		if err != nil {
+			cmdFailedDurationScanRegions.Observe(time.Since(start).Seconds())
+			c.ScheduleCheckLeader()
+			return nil, errors.WithStack(err)
+		}
+		regions = append(regions, batch...)
+		// The response is truncated by the server-side limits, continue to scan
+		// from the end of the last region.
+		if !truncated || len(batch) == 0 {
+			break
+		}
+		if limit > 0 {
+			if limit -= len(batch); limit <= 0 {
+				break
+			}
+		}
+		key = batch[len(batch)-1].Meta.GetEndKey()
+		if len(key) == 0 || (len(endKey) > 0 && bytes.Compare(key, endKey) >= 0) {
+			break
+		}
+	}
+	return regions, nil
+}
+
+// scanRegions sends a ScanRegions request and returns whether the response is
+// truncated by the server.
+func (c *client) scanRegions(ctx context.Context, key, endKey []byte, limit int) ([]*Region, bool, error) {
 	var cancel context.CancelFunc
 	scanCtx := ctx
 	if _, ok := ctx.Deadline(); !ok {
@@ -1092,15 +1401,12 @@ func (c *client) ScanRegions(ctx context.Context, key, endKey []byte, limit int)
 		Limit:    int32(limit),
 	}
 	scanCtx = grpcutil.BuildForwardContext(scanCtx, c.GetLeaderAddr())
-	resp, err := c.getClient().ScanRegions(scanCtx, req)
-
+	var header metadata.MD
+	resp, err := c.getClient().ScanRegions(scanCtx, req, grpc.Header(&header))
 	if err != nil {
-		cmdFailedDurationScanRegions.Observe(time.Since(start).Seconds())
-		c.ScheduleCheckLeader()
-		return nil, errors.WithStack(err)
+		return nil, false, err
 	}
-
-	return handleRegionsResponse(resp), nil
+	return handleRegionsResponse(resp), grpcutil.IsTruncated(header), nil
 }
 
 func handleRegionsResponse(resp *pdpb.ScanRegionsResponse) []*Region {
@@ -1181,21 +1487,55 @@ func (c *client) GetAllStores(ctx context.Context, opts ...GetStoreOption) ([]*m
 	start := time.Now()
 	defer func() { cmdDurationGetAllStores.Observe(time.Since(start).Seconds()) }()
 
+	var stores []*metapb.Store
+	var continuation string
+	for {
+		batch, next, err := c.getAllStores(ctx, options, continuation)
+		if err != nil {
+			cmdFailedDurationGetAllStores.Observe(time.Since(start).Seconds())
+			c.ScheduleCheckLeader()
+			return nil, errors.WithStack(err)
+		}
+		stores = append(stores, batch...)
+		// The response is truncated by the server-side limits, continue from
+		// where the server returns.
+		if next == "" {
+			return stores, nil
+		}
+		continuation = next
+	}
+}
+
+// getAllStores sends a GetAllStoresRequest which continues from the given
+// continuation if it is not empty, and returns the continuation of the next
+// request if the response is truncated by the server.
+func (c *client) getAllStores(ctx context.Context, options *GetStoreOp, continuation string) ([]*metapb.Store, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
 	req := &pdpb.GetAllStoresRequest{
 		Header:                 c.requestHeader(),
 		ExcludeTombstoneStores: options.excludeTombstone,
 	}
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
-	resp, err := c.getClient().GetAllStores(ctx, req)
-	cancel()
-
+	if continuation != "" {
+		ctx = grpcutil.BuildContinuationContext(ctx, continuation)
+	}
+	var header metadata.MD
+	resp, err := c.getClient().GetAllStores(ctx, req, grpc.Header(&header))
 	if err != nil {
-		cmdFailedDurationGetAllStores.Observe(time.Since(start).Seconds())
-		c.ScheduleCheckLeader()
-		return nil, errors.WithStack(err)
+		return nil, "", err
+	}
+	if !grpcutil.IsTruncated(header) {
+		return resp.GetStores(), "", nil
 	}
-	return resp.GetStores(), nil
+	// A partial store list may mislead the callers, e.g. a store is considered
+	// removed, so the truncated response without continuation is treated as an
+	// error.
+	next := grpcutil.GetContinuation(header)
+	if next == "" || next == continuation {
+		return nil, "", errors.Errorf("get all stores response is truncated by PD, got %d stores", len(resp.GetStores()))
+	}
+	return resp.GetStores(), next, nil
 }
 
 func (c *client) UpdateGCSafePoint(ctx context.Context, safePoint uint64) (uint64, error) {
@@ -1330,6 +1670,9 @@ func (c *client) SplitRegions(ctx context.Context, splitKeys [][]byte, opts ...R
 		SplitKeys:  splitKeys,
 		RetryLimit: options.retryLimit,
 	}
+	if options.idempotencyKey != "" {
+		ctx = grpcutil.BuildIdempotencyContext(ctx, options.idempotencyKey)
+	}
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
 	return c.getClient().SplitRegions(ctx, req)
 }
@@ -1354,6 +1697,9 @@ func (c *client) scatterRegionsWithOptions(ctx context.Context, regionsID []uint
 		RegionsId:  regionsID,
 		RetryLimit: options.retryLimit,
 	}
+	if options.idempotencyKey != "" {
+		ctx = grpcutil.BuildIdempotencyContext(ctx, options.idempotencyKey)
+	}
 
 	ctx = grpcutil.BuildForwardContext(ctx, c.GetLeaderAddr())
 	resp, err := c.getClient().ScatterRegion(ctx, req)
@@ -1387,6 +1733,12 @@ func IsLeaderChange(err error) bool {
 	return strings.Contains(errMsg, errs.NotLeaderErr) || strings.Contains(errMsg, errs.MismatchLeaderErr)
 }
 
+// IsTSOQuotaExceeded returns whether the TSO request is rejected because the
+// TSO quota of its dc-location is exceeded. The request can be retried later.
+func IsTSOQuotaExceeded(err error) bool {
+	return strings.Contains(err.Error(), errs.TSOQuotaExceededErr)
+}
+
 func trimHTTPPrefix(str string) string {
 	str = strings.TrimPrefix(str, "http://")
 	str = strings.TrimPrefix(str, "https://")
@@ -0,0 +1,220 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+)
+
+const (
+	clusterFeaturesPath = "/pd/api/v1/cluster/features"
+	serviceVersionsPath = "/pd/api/v1/version/services"
+	// defaultClusterFeaturesWatchInterval is the interval to check whether the
+	// cluster version or the feature gates are changed.
+	defaultClusterFeaturesWatchInterval = 10 * time.Second
+)
+
+// Feature gate names, whether a feature is enabled is decided by the cluster
+// version.
+const (
+	FeatureRegionMerge    = "region-merge"
+	FeatureBatchSplit     = "batch-split"
+	FeatureJointConsensus = "joint-consensus"
+)
+
+// ClusterFeatures is the cluster version and the feature gates enabled by it.
+type ClusterFeatures struct {
+	ClusterVersion string          `json:"cluster_version"`
+	Features       map[string]bool `json:"features"`
+}
+
+// IsEnabled returns whether the feature gate is enabled. The unknown feature
+// gate is regarded as disabled.
+func (f *ClusterFeatures) IsEnabled(feature string) bool {
+	return f.Features[feature]
+}
+
+// ServiceVersions is the versions of the PD server and the cluster, with the
+// features and the API compatibility level the server supports.
+type ServiceVersions struct {
+	Version        string `json:"version"`
+	GitHash        string `json:"git_hash"`
+	ClusterVersion string `json:"cluster_version"`
+	// Features are all the feature gates the server supports, and whether each
+	// of them is enabled by the cluster version.
+	Features              map[string]bool `json:"features"`
+	APICompatibilityLevel int             `json:"api_compatibility_level"`
+}
+
+// WithClusterFeaturesWatchInterval configures the client with the interval to
+// check the cluster features when they are watched.
+func WithClusterFeaturesWatchInterval(interval time.Duration) ClientOption {
+	return func(c *baseClient) {
+		c.clusterFeaturesWatchInterval = interval
+	}
+}
+
+// getHTTPClient returns the HTTP client to access the API of the PD servers,
+// which shares the TLS config with the gRPC connections.
+func (c *client) getHTTPClient() (*http.Client, error) {
+	c.httpClientOnce.Do(func() {
+		tlsCfg, err := grpcutil.TLSConfig{
+			CAPath:   c.security.CAPath,
+			CertPath: c.security.CertPath,
+			KeyPath:  c.security.KeyPath,
+		}.ToTLSConfig()
+		if err != nil {
+			c.httpClientErr = err
+			return
+		}
+		c.httpClient = &http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsCfg},
+		}
+	})
+	return c.httpClient, c.httpClientErr
+}
+
+func (c *client) GetClusterFeatures(ctx context.Context) (*ClusterFeatures, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.GetClusterFeatures", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	start := time.Now()
+	defer func() { cmdDurationGetClusterFeatures.Observe(time.Since(start).Seconds()) }()
+
+	features, err := c.getClusterFeatures(ctx)
+	if err != nil {
+		cmdFailedDurationGetClusterFeatures.Observe(time.Since(start).Seconds())
+		c.ScheduleCheckLeader()
+		return nil, err
+	}
+	return features, nil
+}
+
+func (c *client) getClusterFeatures(ctx context.Context) (*ClusterFeatures, error) {
+	features := &ClusterFeatures{}
+	if err := c.requestAPI(ctx, http.MethodGet, clusterFeaturesPath, nil, features, errs.ErrClientGetClusterFeatures); err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+func (c *client) GetServiceVersions(ctx context.Context) (*ServiceVersions, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.GetServiceVersions", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	start := time.Now()
+	defer func() { cmdDurationGetServiceVersions.Observe(time.Since(start).Seconds()) }()
+
+	versions := &ServiceVersions{}
+	if err := c.requestAPI(ctx, http.MethodGet, serviceVersionsPath, nil, versions, errs.ErrClientGetServiceVersions); err != nil {
+		cmdFailedDurationGetServiceVersions.Observe(time.Since(start).Seconds())
+		c.ScheduleCheckLeader()
+		return nil, err
+	}
+	return versions, nil
+}
+
+// requestAPI sends the request to the API of the PD leader and decodes the
+// response into out. The failures are wrapped by errType.
+func (c *client) requestAPI(ctx context.Context, method, path string, body []byte, out interface{}, errType *errors.Error) error {
+	httpClient, err := c.getHTTPClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	leader := c.GetLeaderAddr()
+	req, err := http.NewRequestWithContext(ctx, method, leader+path, bytes.NewReader(body))
+	if err != nil {
+		return errType.Wrap(err).GenWithStackByCause(leader)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errType.Wrap(err).GenWithStackByCause(leader)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errType.Wrap(err).GenWithStackByCause(leader)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errType.Wrap(errors.Errorf("[%d] %s", resp.StatusCode, respBody)).GenWithStackByCause(leader)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return errType.Wrap(err).GenWithStackByCause(leader)
+	}
+	return nil
+}
+
+func (c *client) WatchClusterFeatures(ctx context.Context) (<-chan *ClusterFeatures, error) {
+	features, err := c.GetClusterFeatures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan *ClusterFeatures, 1)
+	ch <- features
+	c.wg.Add(1)
+	go c.watchClusterFeaturesLoop(ctx, ch, features)
+	return ch, nil
+}
+
+func (c *client) watchClusterFeaturesLoop(ctx context.Context, ch chan<- *ClusterFeatures, last *ClusterFeatures) {
+	defer c.wg.Done()
+	defer close(ch)
+
+	ticker := time.NewTicker(c.clusterFeaturesWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			return
+		}
+		features, err := c.GetClusterFeatures(ctx)
+		if err != nil {
+			log.Warn("[pd] failed to get cluster features", errs.ZapError(err))
+			continue
+		}
+		if reflect.DeepEqual(features, last) {
+			continue
+		}
+		select {
+		case ch <- features:
+			last = features
+		case <-ctx.Done():
+			return
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
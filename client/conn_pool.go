@@ -0,0 +1,244 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ConnPool shares the gRPC connections to the PD servers across the clients,
+// so that the processes which create many clients, e.g. a client per task,
+// don't open a connection per client to each PD server. The requests of the
+// clients are multiplexed on the shared connections.
+//
+// The TSO dispatchers are shared as well. The TSO requests of the clients of
+// the same cluster are batched by a dispatcher per dc-location, which sends
+// them on a single TSO stream, so the number of the TSO streams on the PD
+// servers doesn't grow with the number of the clients. The stream is driven by
+// one of the clients sharing the dispatcher, and another one takes it over
+// when that client is closed.
+//
+// The connections are keyed by the address and the security option, and they
+// are dialed with the gRPC dial options of the client which dials first, so
+// the clients sharing a pool should use the same dial options. A connection
+// is closed when all the clients using it are closed, and so is a dispatcher.
+type ConnPool struct {
+	sync.Mutex
+	conns       map[connPoolKey]*pooledConn
+	dispatchers map[tsoDispatcherKey]*tsoDispatcher
+}
+
+type connPoolKey struct {
+	addr     string
+	security SecurityOption
+}
+
+type pooledConn struct {
+	cc   *grpc.ClientConn
+	refs int
+}
+
+type tsoDispatcherKey struct {
+	clusterID  uint64
+	security   SecurityOption
+	dcLocation string
+}
+
+// NewConnPool creates a pool to share the gRPC connections across the
+// clients. Use WithConnPool to create a client with the pool.
+func NewConnPool() *ConnPool {
+	return &ConnPool{
+		conns:       make(map[connPoolKey]*pooledConn),
+		dispatchers: make(map[tsoDispatcherKey]*tsoDispatcher),
+	}
+}
+
+// WithConnPool configures the client to get the gRPC connections from the
+// pool instead of dialing its own connections.
+func WithConnPool(pool *ConnPool) ClientOption {
+	return func(c *baseClient) {
+		c.connPool = pool
+	}
+}
+
+// ConnCount returns the number of the connections in the pool.
+func (p *ConnPool) ConnCount() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.conns)
+}
+
+// TSODispatcherCount returns the number of the TSO dispatchers in the pool.
+func (p *ConnPool) TSODispatcherCount() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.dispatchers)
+}
+
+// acquire returns the connection to the address, the connection is dialed if
+// it doesn't exist. The connection should be released by release.
+func (p *ConnPool) acquire(ctx context.Context, addr string, security SecurityOption, tlsCfg *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	key := connPoolKey{addr: addr, security: security}
+	if cc := p.ref(key); cc != nil {
+		return cc, nil
+	}
+	// Dial without the lock, so that dialing an address doesn't block the
+	// clients using the other connections.
+	cc, err := grpcutil.GetClientConn(ctx, addr, tlsCfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Lock()
+	defer p.Unlock()
+	if conn, ok := p.conns[key]; ok {
+		cc.Close()
+		conn.refs++
+		connPoolReferenceGauge.Inc()
+		return conn.cc, nil
+	}
+	p.conns[key] = &pooledConn{cc: cc, refs: 1}
+	connPoolConnectionGauge.Inc()
+	connPoolReferenceGauge.Inc()
+	return cc, nil
+}
+
+func (p *ConnPool) ref(key connPoolKey) *grpc.ClientConn {
+	p.Lock()
+	defer p.Unlock()
+	conn, ok := p.conns[key]
+	if !ok {
+		return nil
+	}
+	conn.refs++
+	connPoolReferenceGauge.Inc()
+	return conn.cc
+}
+
+// release releases the connection acquired by acquire, and closes it if no
+// client uses it.
+func (p *ConnPool) release(addr string, security SecurityOption) {
+	p.Lock()
+	defer p.Unlock()
+	key := connPoolKey{addr: addr, security: security}
+	conn, ok := p.conns[key]
+	if !ok {
+		return
+	}
+	conn.refs--
+	connPoolReferenceGauge.Dec()
+	if conn.refs > 0 {
+		return
+	}
+	delete(p.conns, key)
+	connPoolConnectionGauge.Dec()
+	if err := conn.cc.Close(); err != nil {
+		log.Error("[pd] failed to close gRPC clientConn", zap.String("addr", addr), errs.ZapError(errs.ErrCloseGRPCConn, err))
+	}
+}
+
+// acquireTSODispatcher returns the TSO dispatcher of the dc-location shared by
+// the clients of the same cluster, the dispatcher is created if it doesn't
+// exist. The dispatcher should be released by releaseTSODispatcher.
+func (p *ConnPool) acquireTSODispatcher(c *client, dcLocation string) *tsoDispatcher {
+	key := tsoDispatcherKey{clusterID: c.clusterID, security: c.security, dcLocation: dcLocation}
+	p.Lock()
+	defer p.Unlock()
+	dispatcher, ok := p.dispatchers[key]
+	if !ok {
+		dispatcherCtx, dispatcherCancel := context.WithCancel(context.Background())
+		dispatcher = &tsoDispatcher{
+			dispatcherCtx:    dispatcherCtx,
+			dispatcherCancel: dispatcherCancel,
+			tsoRequestCh:     make(chan *tsoRequest, maxMergeTSORequests),
+		}
+		p.dispatchers[key] = dispatcher
+		connPoolTSODispatcherGauge.Inc()
+	}
+	dispatcher.clients = append(dispatcher.clients, c)
+	if !dispatcher.driven {
+		dispatcher.driven = true
+		go p.driveTSODispatcher(dispatcher, dcLocation)
+	}
+	return dispatcher
+}
+
+// releaseTSODispatcher releases the TSO dispatcher acquired by
+// acquireTSODispatcher. It returns true if no client uses the dispatcher, and
+// the caller should stop it.
+func (p *ConnPool) releaseTSODispatcher(c *client, dcLocation string) bool {
+	key := tsoDispatcherKey{clusterID: c.clusterID, security: c.security, dcLocation: dcLocation}
+	p.Lock()
+	defer p.Unlock()
+	dispatcher, ok := p.dispatchers[key]
+	if !ok {
+		return false
+	}
+	for i, client := range dispatcher.clients {
+		if client == c {
+			dispatcher.clients = append(dispatcher.clients[:i], dispatcher.clients[i+1:]...)
+			break
+		}
+	}
+	if len(dispatcher.clients) > 0 {
+		return false
+	}
+	delete(p.dispatchers, key)
+	connPoolTSODispatcherGauge.Dec()
+	return true
+}
+
+// driveTSODispatcher handles the TSO requests of the dispatcher with one of
+// the clients sharing it, and hands it over to another client when the client
+// is closed. It exits when the dispatcher is stopped or all the clients are
+// closed.
+func (p *ConnPool) driveTSODispatcher(dispatcher *tsoDispatcher, dcLocation string) {
+	for dispatcher.dispatcherCtx.Err() == nil {
+		c := p.getTSODispatcherDriver(dispatcher)
+		if c == nil {
+			return
+		}
+		ctx, cancel := context.WithCancel(dispatcher.dispatcherCtx)
+		go func() {
+			select {
+			case <-c.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		c.handleDispatcher(ctx, dcLocation, dispatcher.tsoRequestCh)
+		cancel()
+	}
+}
+
+// getTSODispatcherDriver returns a client which is not closed to drive the
+// dispatcher, or nil if there is no such client.
+func (p *ConnPool) getTSODispatcherDriver(dispatcher *tsoDispatcher) *client {
+	p.Lock()
+	defer p.Unlock()
+	for _, c := range dispatcher.clients {
+		if c.ctx.Err() == nil {
+			return c
+		}
+	}
+	dispatcher.driven = false
+	return nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import "fmt"
+
+// The paths of the PD HTTP API.
+const (
+	apiPrefix        = "/pd/api/v1"
+	storePrefix      = apiPrefix + "/store"
+	storesPrefix     = apiPrefix + "/stores"
+	regionPrefix     = apiPrefix + "/region"
+	regionsPrefix    = apiPrefix + "/regions"
+	configPrefix     = apiPrefix + "/config"
+	schedulersPrefix = apiPrefix + "/schedulers"
+	operatorsPrefix  = apiPrefix + "/operators"
+)
+
+// idempotencyKeyHeader is the header of the idempotency key of the mutating
+// requests.
+const idempotencyKeyHeader = "PD-Idempotency-Key"
+
+func storePath(storeID uint64) string {
+	return fmt.Sprintf("%s/%d", storePrefix, storeID)
+}
@@ -0,0 +1,475 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	pd "github.com/tikv/pd/client"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTimeout       = 30 * time.Second
+	defaultMaxRetries    = 3
+	defaultRetryInterval = 500 * time.Millisecond
+)
+
+// Client is a client of the PD HTTP API. The requests are sent to the PD
+// servers in turn until one of them succeeds, and the PD server redirects the
+// requests to the leader if necessary.
+type Client interface {
+	// GetStores returns the stores, including the tombstone stores.
+	GetStores(ctx context.Context) (*StoresInfo, error)
+	// GetStore returns the store.
+	GetStore(ctx context.Context, storeID uint64) (*StoreInfo, error)
+	// DeleteStore makes the store offline.
+	DeleteStore(ctx context.Context, storeID uint64) error
+	// SetStoreState sets the state of the store, i.e. Up or Offline.
+	SetStoreState(ctx context.Context, storeID uint64, state metapb.StoreState) error
+	// SetStoreLabels sets the labels of the store, the labels are merged with
+	// the existing ones.
+	SetStoreLabels(ctx context.Context, storeID uint64, labels map[string]string) error
+
+	// GetRegionByID returns the region, or nil if it does not exist.
+	GetRegionByID(ctx context.Context, regionID uint64) (*RegionInfo, error)
+	// GetRegionByKey returns the region which contains the key, or nil if it
+	// does not exist.
+	GetRegionByKey(ctx context.Context, key []byte) (*RegionInfo, error)
+	// GetRegions returns all the regions.
+	GetRegions(ctx context.Context) (*RegionsInfo, error)
+	// GetRegionsByStoreID returns the regions which have a peer on the store.
+	GetRegionsByStoreID(ctx context.Context, storeID uint64) (*RegionsInfo, error)
+
+	// GetConfig returns the config of the cluster, keyed by the names of the
+	// items in the config file.
+	GetConfig(ctx context.Context) (map[string]interface{}, error)
+	// GetScheduleConfig returns the schedule config.
+	GetScheduleConfig(ctx context.Context) (map[string]interface{}, error)
+	// GetReplicateConfig returns the replication config.
+	GetReplicateConfig(ctx context.Context) (map[string]interface{}, error)
+	// SetConfig updates the config items, e.g. {"max-snapshot-count": 64}.
+	SetConfig(ctx context.Context, config map[string]interface{}) error
+
+	// GetSchedulers returns the names of the running schedulers.
+	GetSchedulers(ctx context.Context) ([]string, error)
+	// CreateScheduler creates a scheduler, the storeID is only for the
+	// schedulers which take a store, e.g. evict-leader-scheduler.
+	CreateScheduler(ctx context.Context, name string, storeID uint64) error
+	// DeleteScheduler removes the scheduler.
+	DeleteScheduler(ctx context.Context, name string) error
+	// PauseScheduler pauses the scheduler for the duration, and 0 resumes it.
+	PauseScheduler(ctx context.Context, name string, delay time.Duration) error
+
+	// GetOperators returns the descriptions of the running operators.
+	GetOperators(ctx context.Context) ([]string, error)
+	// GetOperatorByRegionID returns the description of the operator of the
+	// region and its status, including the recently finished one.
+	GetOperatorByRegionID(ctx context.Context, regionID uint64) (string, error)
+	// CreateOperator creates an operator, see `pd-ctl operator add` for the
+	// input, e.g. {"name": "transfer-leader", "region_id": 1, "to_store_id": 2}.
+	CreateOperator(ctx context.Context, input map[string]interface{}) error
+	// DeleteOperatorByRegionID cancels the operator of the region.
+	DeleteOperatorByRegionID(ctx context.Context, regionID uint64) error
+
+	// Close closes the client.
+	Close()
+}
+
+type client struct {
+	urls          []string
+	httpClient    *http.Client
+	timeout       time.Duration
+	maxRetries    int
+	retryInterval time.Duration
+
+	mu sync.RWMutex
+	// lastURL is the last URL which serves the requests successfully, the
+	// requests are sent to it first.
+	lastURL string
+}
+
+// ClientOption configures the client.
+type ClientOption func(c *client)
+
+// WithHTTPClient configures the client with the http client, which overrides
+// the TLS config of the security option.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout configures the timeout of each request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *client) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxRetries configures the max retry times of a request. The requests
+// are retried on the network errors, and the GET requests are retried on the
+// server errors as well.
+func WithMaxRetries(count int) ClientOption {
+	return func(c *client) {
+		c.maxRetries = count
+	}
+}
+
+// WithRetryInterval configures the interval between the retries.
+func WithRetryInterval(interval time.Duration) ClientOption {
+	return func(c *client) {
+		c.retryInterval = interval
+	}
+}
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey returns a context with the idempotency key of the
+// mutating requests. The requests with the same key are applied once by the PD
+// leader, so that they can be retried safely after the timeouts.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// NewClient creates a PD HTTP API client with the addresses of the PD servers.
+// The addresses without the scheme are regarded as https if the security
+// option is set, otherwise as http.
+func NewClient(pdAddrs []string, security pd.SecurityOption, opts ...ClientOption) (Client, error) {
+	if len(pdAddrs) == 0 {
+		return nil, errs.ErrClientURLEmpty.FastGenByArgs()
+	}
+	c := &client{
+		timeout:       defaultTimeout,
+		maxRetries:    defaultMaxRetries,
+		retryInterval: defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		tlsCfg, err := grpcutil.TLSConfig{
+			CAPath:   security.CAPath,
+			CertPath: security.CertPath,
+			KeyPath:  security.KeyPath,
+		}.ToTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsCfg
+		c.httpClient = &http.Client{Transport: transport}
+	}
+	scheme := "http://"
+	if security.CAPath != "" {
+		scheme = "https://"
+	}
+	for _, addr := range pdAddrs {
+		if !strings.Contains(addr, "://") {
+			addr = scheme + addr
+		}
+		c.urls = append(c.urls, strings.TrimSuffix(addr, "/"))
+	}
+	return c, nil
+}
+
+// Close closes the idle connections of the client.
+func (c *client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// getURLs returns the URLs of the PD servers, the last available one first.
+func (c *client) getURLs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	urls := make([]string, 0, len(c.urls))
+	if c.lastURL != "" {
+		urls = append(urls, c.lastURL)
+	}
+	for _, u := range c.urls {
+		if u != c.lastURL {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+func (c *client) setLastURL(u string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastURL = u
+}
+
+// request sends the request to the PD servers in turn, and decodes the JSON
+// response into out if it is not nil.
+func (c *client) request(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+		}
+	}
+	var lastErr error
+	for i := 0; i <= c.maxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(c.retryInterval):
+			case <-ctx.Done():
+				return errors.WithStack(ctx.Err())
+			}
+		}
+		for _, u := range c.getURLs() {
+			retryable, err := c.do(ctx, method, u+path, body, out)
+			if err == nil {
+				c.setLastURL(u)
+				return nil
+			}
+			lastErr = err
+			if !retryable || ctx.Err() != nil {
+				return err
+			}
+			log.Debug("[pd] http request failed, try the next server", zap.String("method", method), zap.String("url", u+path), errs.ZapError(err))
+		}
+	}
+	return lastErr
+}
+
+// do sends the request to a PD server, and returns whether the request can be
+// retried if it fails. The mutating requests may have been applied by the
+// server if they fail after being sent, so they are retried only if they carry
+// an idempotency key or the connection to the server can not be established.
+func (c *client) do(ctx context.Context, method, reqURL string, body []byte, out interface{}) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return false, errs.ErrClientHTTPRequest.Wrap(err).GenWithStackByArgs(reqURL)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	idempotent := method == http.MethodGet
+	if key, ok := ctx.Value(idempotencyKeyCtxKey{}).(string); ok && key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+		idempotent = true
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return idempotent || isDialError(err), errs.ErrClientHTTPRequest.Wrap(err).GenWithStackByArgs(reqURL)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return idempotent, errs.ErrClientHTTPRequest.Wrap(err).GenWithStackByArgs(reqURL)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Only the GET requests are retried on the server errors, the other
+		// requests may have taken effect.
+		retryable := method == http.MethodGet && resp.StatusCode >= http.StatusInternalServerError
+		return retryable, errs.ErrClientHTTPResponse.FastGenByArgs(reqURL, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return false, nil
+}
+
+// isDialError returns true if the error occurs when connecting to the server,
+// which means the request is not sent yet.
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// GetStores implements Client.
+func (c *client) GetStores(ctx context.Context) (*StoresInfo, error) {
+	var stores StoresInfo
+	path := fmt.Sprintf("%s?state=%d&state=%d&state=%d", storesPrefix, metapb.StoreState_Up, metapb.StoreState_Offline, metapb.StoreState_Tombstone)
+	if err := c.request(ctx, http.MethodGet, path, nil, &stores); err != nil {
+		return nil, err
+	}
+	return &stores, nil
+}
+
+// GetStore implements Client.
+func (c *client) GetStore(ctx context.Context, storeID uint64) (*StoreInfo, error) {
+	var store StoreInfo
+	if err := c.request(ctx, http.MethodGet, storePath(storeID), nil, &store); err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// DeleteStore implements Client.
+func (c *client) DeleteStore(ctx context.Context, storeID uint64) error {
+	return c.request(ctx, http.MethodDelete, storePath(storeID), nil, nil)
+}
+
+// SetStoreState implements Client.
+func (c *client) SetStoreState(ctx context.Context, storeID uint64, state metapb.StoreState) error {
+	path := fmt.Sprintf("%s/state?state=%s", storePath(storeID), url.QueryEscape(state.String()))
+	return c.request(ctx, http.MethodPost, path, nil, nil)
+}
+
+// SetStoreLabels implements Client.
+func (c *client) SetStoreLabels(ctx context.Context, storeID uint64, labels map[string]string) error {
+	return c.request(ctx, http.MethodPost, storePath(storeID)+"/label", labels, nil)
+}
+
+// GetRegionByID implements Client.
+func (c *client) GetRegionByID(ctx context.Context, regionID uint64) (*RegionInfo, error) {
+	var region *RegionInfo
+	if err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s/id/%d", regionPrefix, regionID), nil, &region); err != nil {
+		return nil, err
+	}
+	return region, nil
+}
+
+// GetRegionByKey implements Client.
+func (c *client) GetRegionByKey(ctx context.Context, key []byte) (*RegionInfo, error) {
+	var region *RegionInfo
+	if err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s/key/%s", regionPrefix, url.QueryEscape(string(key))), nil, &region); err != nil {
+		return nil, err
+	}
+	return region, nil
+}
+
+// GetRegions implements Client.
+func (c *client) GetRegions(ctx context.Context) (*RegionsInfo, error) {
+	var regions RegionsInfo
+	if err := c.request(ctx, http.MethodGet, regionsPrefix, nil, &regions); err != nil {
+		return nil, err
+	}
+	return &regions, nil
+}
+
+// GetRegionsByStoreID implements Client.
+func (c *client) GetRegionsByStoreID(ctx context.Context, storeID uint64) (*RegionsInfo, error) {
+	var regions RegionsInfo
+	if err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s/store/%d", regionsPrefix, storeID), nil, &regions); err != nil {
+		return nil, err
+	}
+	return &regions, nil
+}
+
+func (c *client) getConfig(ctx context.Context, path string) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := c.request(ctx, http.MethodGet, path, nil, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// GetConfig implements Client.
+func (c *client) GetConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, configPrefix)
+}
+
+// GetScheduleConfig implements Client.
+func (c *client) GetScheduleConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, configPrefix+"/schedule")
+}
+
+// GetReplicateConfig implements Client.
+func (c *client) GetReplicateConfig(ctx context.Context) (map[string]interface{}, error) {
+	return c.getConfig(ctx, configPrefix+"/replicate")
+}
+
+// SetConfig implements Client.
+func (c *client) SetConfig(ctx context.Context, config map[string]interface{}) error {
+	return c.request(ctx, http.MethodPost, configPrefix, config, nil)
+}
+
+// GetSchedulers implements Client.
+func (c *client) GetSchedulers(ctx context.Context) ([]string, error) {
+	var schedulers []string
+	if err := c.request(ctx, http.MethodGet, schedulersPrefix, nil, &schedulers); err != nil {
+		return nil, err
+	}
+	return schedulers, nil
+}
+
+// CreateScheduler implements Client.
+func (c *client) CreateScheduler(ctx context.Context, name string, storeID uint64) error {
+	input := map[string]interface{}{"name": name}
+	if storeID != 0 {
+		input["store_id"] = storeID
+	}
+	return c.request(ctx, http.MethodPost, schedulersPrefix, input, nil)
+}
+
+// DeleteScheduler implements Client.
+func (c *client) DeleteScheduler(ctx context.Context, name string) error {
+	return c.request(ctx, http.MethodDelete, schedulersPrefix+"/"+url.PathEscape(name), nil, nil)
+}
+
+// PauseScheduler implements Client.
+func (c *client) PauseScheduler(ctx context.Context, name string, delay time.Duration) error {
+	input := map[string]interface{}{"delay": int64(delay.Seconds())}
+	return c.request(ctx, http.MethodPost, schedulersPrefix+"/"+url.PathEscape(name), input, nil)
+}
+
+// GetOperators implements Client.
+func (c *client) GetOperators(ctx context.Context) ([]string, error) {
+	var operators []string
+	if err := c.request(ctx, http.MethodGet, operatorsPrefix, nil, &operators); err != nil {
+		return nil, err
+	}
+	return operators, nil
+}
+
+// GetOperatorByRegionID implements Client.
+func (c *client) GetOperatorByRegionID(ctx context.Context, regionID uint64) (string, error) {
+	var op string
+	if err := c.request(ctx, http.MethodGet, fmt.Sprintf("%s/%d", operatorsPrefix, regionID), nil, &op); err != nil {
+		return "", err
+	}
+	return op, nil
+}
+
+// CreateOperator implements Client.
+func (c *client) CreateOperator(ctx context.Context, input map[string]interface{}) error {
+	return c.request(ctx, http.MethodPost, operatorsPrefix, input, nil)
+}
+
+// DeleteOperatorByRegionID implements Client.
+func (c *client) DeleteOperatorByRegionID(ctx context.Context, regionID uint64) error {
+	return c.request(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", operatorsPrefix, regionID), nil, nil)
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	pd "github.com/tikv/pd/client"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/testutil"
+	"go.uber.org/goleak"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m, testutil.LeakOptions...)
+}
+
+var _ = Suite(&testHTTPClientSuite{})
+
+type testHTTPClientSuite struct{}
+
+func (s *testHTTPClientSuite) TestRetry(c *C) {
+	var failures, requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		c.Assert(r.URL.Path, Equals, schedulersPrefix)
+		w.Write([]byte(`["balance-leader-scheduler"]`))
+	}))
+	defer srv.Close()
+	// The unavailable server is skipped.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	cli, err := NewClient([]string{down.URL, srv.URL}, pd.SecurityOption{}, WithRetryInterval(time.Millisecond))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	ctx := context.Background()
+
+	// The GET requests are retried on the server errors.
+	atomic.StoreInt32(&failures, 2)
+	schedulers, err := cli.GetSchedulers(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(schedulers, DeepEquals, []string{"balance-leader-scheduler"})
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(3))
+
+	// The other requests are not retried on the server errors.
+	atomic.StoreInt32(&failures, 1)
+	atomic.StoreInt32(&requests, 0)
+	err = cli.DeleteScheduler(ctx, "balance-leader-scheduler")
+	c.Assert(errs.ErrClientHTTPResponse.Equal(err), IsTrue)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(1))
+
+	// Fails after the max retries.
+	atomic.StoreInt32(&failures, 10)
+	atomic.StoreInt32(&requests, 0)
+	_, err = cli.GetSchedulers(ctx)
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(defaultMaxRetries+1))
+}
+
+func (s *testHTTPClientSuite) TestNoRetryAppliedPost(c *C) {
+	var requests int32
+	// The server applies the request but the connection is broken before the
+	// response is sent.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		panic(http.ErrAbortHandler)
+	})
+	srv1 := httptest.NewServer(handler)
+	defer srv1.Close()
+	srv2 := httptest.NewServer(handler)
+	defer srv2.Close()
+
+	cli, err := NewClient([]string{srv1.URL, srv2.URL}, pd.SecurityOption{}, WithRetryInterval(time.Millisecond))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	ctx := context.Background()
+
+	// The failed POST request is not sent again.
+	err = cli.CreateOperator(ctx, map[string]interface{}{"name": "transfer-leader", "region_id": 1, "to_store_id": 2})
+	c.Assert(err, ErrorMatches, ".*ErrClientHTTPRequest.*")
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(1))
+
+	// The POST request with an idempotency key is retried.
+	atomic.StoreInt32(&requests, 0)
+	err = cli.CreateOperator(WithIdempotencyKey(ctx, "key"), map[string]interface{}{"name": "transfer-leader", "region_id": 1, "to_store_id": 2})
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&requests) > 1, IsTrue)
+
+	// The GET request is retried.
+	atomic.StoreInt32(&requests, 0)
+	_, err = cli.GetSchedulers(ctx)
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&requests) > 1, IsTrue)
+}
+
+func (s *testHTTPClientSuite) TestNoAddress(c *C) {
+	_, err := NewClient(nil, pd.SecurityOption{})
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/typeutil"
+)
+
+// The types below are compatible with the responses of the PD HTTP API, they
+// are defined here so that the client does not depend on the server packages.
+
+// MetaStore is the meta of a store.
+type MetaStore struct {
+	*metapb.Store
+	StateName string `json:"state_name"`
+}
+
+// StoreStatus is the status of a store.
+type StoreStatus struct {
+	Capacity           typeutil.ByteSize  `json:"capacity"`
+	Available          typeutil.ByteSize  `json:"available"`
+	UsedSize           typeutil.ByteSize  `json:"used_size"`
+	ReservedSpace      typeutil.ByteSize  `json:"reserved_space,omitempty"`
+	EffectiveAvailable typeutil.ByteSize  `json:"effective_available,omitempty"`
+	LeaderCount        int                `json:"leader_count"`
+	LeaderWeight       float64            `json:"leader_weight"`
+	LeaderScore        float64            `json:"leader_score"`
+	LeaderSize         int64              `json:"leader_size"`
+	RegionCount        int                `json:"region_count"`
+	RegionWeight       float64            `json:"region_weight"`
+	RegionScore        float64            `json:"region_score"`
+	RegionSize         int64              `json:"region_size"`
+	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
+	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
+	ApplyingSnapCount  uint32             `json:"applying_snap_count,omitempty"`
+	IsBusy             bool               `json:"is_busy,omitempty"`
+	StartTS            *time.Time         `json:"start_ts,omitempty"`
+	LastHeartbeatTS    *time.Time         `json:"last_heartbeat_ts,omitempty"`
+	Uptime             *typeutil.Duration `json:"uptime,omitempty"`
+}
+
+// StoreInfo is the information of a store.
+type StoreInfo struct {
+	Store  *MetaStore   `json:"store"`
+	Status *StoreStatus `json:"status"`
+}
+
+// StoresInfo is the information of the stores.
+type StoresInfo struct {
+	Count  int          `json:"count"`
+	Stores []*StoreInfo `json:"stores"`
+}
+
+// MetaPeer is a peer of a region.
+type MetaPeer struct {
+	*metapb.Peer
+	RoleName  string `json:"role_name"`
+	IsLearner bool   `json:"is_learner,omitempty"`
+}
+
+// PDPeerStats is the stats of a down peer.
+type PDPeerStats struct {
+	*pdpb.PeerStats
+	Peer MetaPeer `json:"peer"`
+}
+
+// ReplicationStatus is the replication mode status of a region.
+type ReplicationStatus struct {
+	State   string `json:"state"`
+	StateID uint64 `json:"state_id"`
+}
+
+// RegionInfo is the information of a region, the keys are encoded in hex.
+type RegionInfo struct {
+	ID          uint64              `json:"id"`
+	StartKey    string              `json:"start_key"`
+	EndKey      string              `json:"end_key"`
+	RegionEpoch *metapb.RegionEpoch `json:"epoch,omitempty"`
+	Peers       []MetaPeer          `json:"peers,omitempty"`
+
+	Leader          MetaPeer      `json:"leader,omitempty"`
+	DownPeers       []PDPeerStats `json:"down_peers,omitempty"`
+	PendingPeers    []MetaPeer    `json:"pending_peers,omitempty"`
+	WrittenBytes    uint64        `json:"written_bytes"`
+	ReadBytes       uint64        `json:"read_bytes"`
+	WrittenKeys     uint64        `json:"written_keys"`
+	ReadKeys        uint64        `json:"read_keys"`
+	ApproximateSize int64         `json:"approximate_size"`
+	ApproximateKeys int64         `json:"approximate_keys"`
+	// FirstSeen and LastHeartbeat are unix timestamps in seconds.
+	FirstSeen     int64 `json:"first_seen,omitempty"`
+	LastHeartbeat int64 `json:"last_heartbeat,omitempty"`
+
+	ReplicationStatus *ReplicationStatus `json:"replication_status,omitempty"`
+}
+
+// RegionsInfo is the information of the regions.
+type RegionsInfo struct {
+	Count   int          `json:"count"`
+	Regions []RegionInfo `json:"regions"`
+}
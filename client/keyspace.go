@@ -0,0 +1,107 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/tikv/pd/pkg/errs"
+)
+
+const keyspacesPath = "/pd/api/v1/keyspaces"
+
+// The states of the keyspace.
+const (
+	KeyspaceStateEnabled  = "enabled"
+	KeyspaceStateDisabled = "disabled"
+)
+
+// KeyspaceMeta is the meta of a keyspace.
+type KeyspaceMeta struct {
+	ID             uint32            `json:"id"`
+	Name           string            `json:"name"`
+	State          string            `json:"state"`
+	CreatedAt      int64             `json:"created_at"`
+	StateChangedAt int64             `json:"state_changed_at"`
+	Config         map[string]string `json:"config,omitempty"`
+}
+
+func (c *client) CreateKeyspace(ctx context.Context, name string, config map[string]string) (*KeyspaceMeta, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.CreateKeyspace", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	body, err := json.Marshal(map[string]interface{}{"name": name, "config": config})
+	if err != nil {
+		return nil, errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	meta := &KeyspaceMeta{}
+	if err := c.requestAPI(ctx, http.MethodPost, keyspacesPath, body, meta, errs.ErrClientRequestKeyspace); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (c *client) GetKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.GetKeyspace", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	meta := &KeyspaceMeta{}
+	if err := c.requestAPI(ctx, http.MethodGet, keyspacesPath+"/"+url.PathEscape(name), nil, meta, errs.ErrClientRequestKeyspace); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (c *client) GetAllKeyspaces(ctx context.Context) ([]*KeyspaceMeta, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.GetAllKeyspaces", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	var keyspaces []*KeyspaceMeta
+	if err := c.requestAPI(ctx, http.MethodGet, keyspacesPath, nil, &keyspaces, errs.ErrClientRequestKeyspace); err != nil {
+		return nil, err
+	}
+	return keyspaces, nil
+}
+
+func (c *client) EnableKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.EnableKeyspace", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	return c.updateKeyspaceState(ctx, name, "enable")
+}
+
+func (c *client) DisableKeyspace(ctx context.Context, name string) (*KeyspaceMeta, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span = opentracing.StartSpan("pdclient.DisableKeyspace", opentracing.ChildOf(span.Context()))
+		defer span.Finish()
+	}
+	return c.updateKeyspaceState(ctx, name, "disable")
+}
+
+func (c *client) updateKeyspaceState(ctx context.Context, name, action string) (*KeyspaceMeta, error) {
+	meta := &KeyspaceMeta{}
+	path := keyspacesPath + "/" + url.PathEscape(name) + "/" + action
+	if err := c.requestAPI(ctx, http.MethodPost, path, nil, meta, errs.ErrClientRequestKeyspace); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
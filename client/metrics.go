@@ -58,6 +58,22 @@ var (
 			Name:      "forwarded_status",
 			Help:      "The status to indicate if the request is forwarded",
 		}, []string{"host", "delegate"})
+
+	localTSOFallback = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd_client",
+			Subsystem: "request",
+			Name:      "local_tso_fallback_total",
+			Help:      "Counter of the Local TSO requests which fall back to the Global TSO.",
+		}, []string{"dc"})
+
+	connPoolGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd_client",
+			Subsystem: "conn_pool",
+			Name:      "count",
+			Help:      "The number of the shared gRPC connections, the clients referencing them and the shared TSO dispatchers.",
+		}, []string{"type"})
 )
 
 var (
@@ -69,6 +85,7 @@ var (
 	cmdDurationGetAllMembers            = cmdDuration.WithLabelValues("get_member_info")
 	cmdDurationGetPrevRegion            = cmdDuration.WithLabelValues("get_prev_region")
 	cmdDurationGetRegionByID            = cmdDuration.WithLabelValues("get_region_byid")
+	cmdDurationGetRegionsByIDs          = cmdDuration.WithLabelValues("get_regions_byids")
 	cmdDurationScanRegions              = cmdDuration.WithLabelValues("scan_regions")
 	cmdDurationGetStore                 = cmdDuration.WithLabelValues("get_store")
 	cmdDurationGetAllStores             = cmdDuration.WithLabelValues("get_all_stores")
@@ -78,6 +95,8 @@ var (
 	cmdDurationScatterRegions           = cmdDuration.WithLabelValues("scatter_regions")
 	cmdDurationGetOperator              = cmdDuration.WithLabelValues("get_operator")
 	cmdDurationSplitRegions             = cmdDuration.WithLabelValues("split_regions")
+	cmdDurationGetClusterFeatures       = cmdDuration.WithLabelValues("get_cluster_features")
+	cmdDurationGetServiceVersions       = cmdDuration.WithLabelValues("get_service_versions")
 
 	cmdFailDurationGetRegion                  = cmdFailedDuration.WithLabelValues("get_region")
 	cmdFailDurationTSO                        = cmdFailedDuration.WithLabelValues("tso")
@@ -89,7 +108,13 @@ var (
 	cmdFailedDurationGetAllStores             = cmdFailedDuration.WithLabelValues("get_all_stores")
 	cmdFailedDurationUpdateGCSafePoint        = cmdFailedDuration.WithLabelValues("update_gc_safe_point")
 	cmdFailedDurationUpdateServiceGCSafePoint = cmdFailedDuration.WithLabelValues("update_service_gc_safe_point")
+	cmdFailedDurationGetClusterFeatures       = cmdFailedDuration.WithLabelValues("get_cluster_features")
+	cmdFailedDurationGetServiceVersions       = cmdFailedDuration.WithLabelValues("get_service_versions")
 	requestDurationTSO                        = requestDuration.WithLabelValues("tso")
+
+	connPoolConnectionGauge    = connPoolGauge.WithLabelValues("connections")
+	connPoolReferenceGauge     = connPoolGauge.WithLabelValues("references")
+	connPoolTSODispatcherGauge = connPoolGauge.WithLabelValues("tso_dispatchers")
 )
 
 func init() {
@@ -98,4 +123,6 @@ func init() {
 	prometheus.MustRegister(requestDuration)
 	prometheus.MustRegister(tsoBatchSize)
 	prometheus.MustRegister(requestForwarded)
+	prometheus.MustRegister(localTSOFallback)
+	prometheus.MustRegister(connPoolGauge)
 }
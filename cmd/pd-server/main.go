@@ -29,6 +29,7 @@ import (
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/metricutil"
 	"github.com/tikv/pd/pkg/swaggerserver"
+	"github.com/tikv/pd/pkg/traceutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/api"
 	"github.com/tikv/pd/server/config"
@@ -90,6 +91,11 @@ func main() {
 
 	metricutil.Push(&cfg.Metric)
 
+	tracerCloser, err := traceutil.InitGlobalTracer(&cfg.Trace, "pd-server")
+	if err != nil {
+		log.Fatal("initialize tracer error", errs.ZapError(err))
+	}
+
 	err = join.PrepareJoinCluster(cfg)
 	if err != nil {
 		log.Fatal("join meet error", errs.ZapError(err))
@@ -125,6 +131,9 @@ func main() {
 	log.Info("Got signal to exit", zap.String("signal", sig.String()))
 
 	svr.Close()
+	if tracerCloser != nil {
+		tracerCloser.Close()
+	}
 	switch sig {
 	case syscall.SIGTERM:
 		exit(0)
@@ -14,6 +14,7 @@
 package serverapi
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
@@ -21,6 +22,7 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 	"github.com/urfave/negroni"
@@ -32,6 +34,9 @@ const (
 	RedirectorHeader    = "PD-Redirector"
 	AllowFollowerHandle = "PD-Allow-follower-handle"
 	FollowerHandle      = "PD-Follower-handle"
+	// ForwardedCNHeader is the certificate CN of the caller of the request
+	// redirected to the leader, which is checked by the leader.
+	ForwardedCNHeader = "PD-Forwarded-CN"
 )
 
 const (
@@ -79,6 +84,37 @@ func IsServiceAllowed(s *server.Server, group server.ServiceGroup) bool {
 	return false
 }
 
+type certCNValidator struct {
+	s *server.Server
+}
+
+// NewCertCNValidator checks if the client certificate CN is allowed to call the
+// mutating requests.
+func NewCertCNValidator(s *server.Server) negroni.Handler {
+	return &certCNValidator{s: s}
+}
+
+func (h *certCNValidator) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		next(w, r)
+		return
+	}
+	cn := grpcutil.GetCommonName(r.TLS)
+	// The allowlists are only up to date on the leader, so the request to be
+	// redirected is checked by the leader against the CN of the caller.
+	if len(r.Header.Get(AllowFollowerHandle)) == 0 && !h.s.GetMember().IsLeader() && len(r.Header.Get(RedirectorHeader)) == 0 {
+		r.Header.Set(ForwardedCNHeader, cn)
+		next(w, r)
+		return
+	}
+	if !h.s.IsRequestCNAllowed(cn, r.Header.Values(ForwardedCNHeader), true) {
+		http.Error(w, fmt.Sprintf("certificate CN %q is not allowed", cn), http.StatusForbidden)
+		return
+	}
+	next(w, r)
+}
+
 type redirector struct {
 	s *server.Server
 }
@@ -83,6 +83,18 @@ func (c *ttlCache) get(key interface{}) (interface{}, bool) {
 	return item.value, true
 }
 
+// getWithExpiration retrieves an item and its expiration time from cache.
+func (c *ttlCache) getWithExpiration(key interface{}) (interface{}, time.Time, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok || item.expire.Before(time.Now()) {
+		return nil, time.Time{}, false
+	}
+	return item.value, item.expire, true
+}
+
 // GetKeys returns all keys that are not expired.
 func (c *ttlCache) getKeys() []interface{} {
 	c.RLock()
@@ -257,6 +269,11 @@ func (c *TTLString) Get(id string) (interface{}, bool) {
 	return c.ttlCache.get(id)
 }
 
+// GetWithExpiration returns the value and the expiration time by key id
+func (c *TTLString) GetWithExpiration(id string) (interface{}, time.Time, bool) {
+	return c.ttlCache.getWithExpiration(id)
+}
+
 // GetAllID returns all key ids
 func (c *TTLString) GetAllID() []string {
 	keys := c.ttlCache.getKeys()
@@ -0,0 +1,223 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskguard
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"go.uber.org/zap"
+)
+
+// Level is the level of the free space of the disk.
+type Level int32
+
+const (
+	// LevelNormal means the free space is enough.
+	LevelNormal Level = iota
+	// LevelWarning means the free space is below the warning threshold, and
+	// the non-critical writes are refused.
+	LevelWarning
+	// LevelCritical means the free space is below the critical threshold, and
+	// the region storage writes are refused as well, so that the space is kept
+	// for etcd to persist the critical data, e.g. the TSO and the cluster meta.
+	LevelCritical
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelNormal:
+		return "normal"
+	case LevelWarning:
+		return "warning"
+	case LevelCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// WriteClass classifies the writes by how critical they are.
+type WriteClass int
+
+const (
+	// NonCriticalWrite is the write which can be dropped without affecting
+	// the correctness, e.g. the persistence of the statistics.
+	NonCriticalWrite WriteClass = iota
+	// RegionWrite is the write of the region meta, which can be recovered by
+	// the region heartbeats.
+	RegionWrite
+)
+
+func (c WriteClass) String() string {
+	switch c {
+	case NonCriticalWrite:
+		return "non-critical"
+	case RegionWrite:
+		return "region"
+	}
+	return "unknown"
+}
+
+// refusedLevel returns the min level at which the writes of the class are
+// refused.
+func (c WriteClass) refusedLevel() Level {
+	if c == RegionWrite {
+		return LevelCritical
+	}
+	return LevelWarning
+}
+
+// Status is the status of the free space of the disk.
+type Status struct {
+	Dir       string            `json:"dir"`
+	Level     string            `json:"level"`
+	Available typeutil.ByteSize `json:"available"`
+	Warning   typeutil.ByteSize `json:"warning"`
+	Critical  typeutil.ByteSize `json:"critical"`
+	// Since is the time when the level changes to the current one.
+	Since     time.Time `json:"since"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+// Guard watches the free space of the disk of the data dir, which is shared
+// by the etcd data, the etcd WAL and the region storage. It refuses the writes
+// by their classes when the free space is low, so that etcd is not corrupted
+// by running out of the space. A nil Guard never refuses any write.
+type Guard struct {
+	dir      string
+	warning  uint64
+	critical uint64
+	// getAvailable is replaced in tests.
+	getAvailable func(dir string) (uint64, error)
+
+	level int32
+
+	mu        sync.RWMutex
+	available uint64
+	since     time.Time
+	lastCheck time.Time
+}
+
+// NewGuard creates a Guard of the dir with the thresholds of the free space in
+// bytes, and 0 disables the threshold.
+func NewGuard(dir string, warning, critical uint64) *Guard {
+	return &Guard{
+		dir:          dir,
+		warning:      warning,
+		critical:     critical,
+		getAvailable: getAvailable,
+		since:        time.Now(),
+	}
+}
+
+// Run checks the free space periodically until the context is done.
+func (g *Guard) Run(ctx context.Context, interval time.Duration) {
+	if g.warning == 0 && g.critical == 0 {
+		log.Info("disk guard is disabled", zap.String("dir", g.dir))
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := g.Check(); err != nil {
+			if err == errNotSupported {
+				log.Warn("disk guard is not supported on the platform", zap.String("dir", g.dir))
+				return
+			}
+			log.Warn("failed to check the free space of the disk", zap.String("dir", g.dir), errs.ZapError(err))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Check checks the free space of the disk and updates the level.
+func (g *Guard) Check() (Level, error) {
+	available, err := g.getAvailable(g.dir)
+	if err != nil {
+		return g.Level(), err
+	}
+	level := LevelNormal
+	switch {
+	case g.critical > 0 && available < g.critical:
+		level = LevelCritical
+	case g.warning > 0 && available < g.warning:
+		level = LevelWarning
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if old := g.Level(); old != level {
+		g.since = now
+		fields := []zap.Field{
+			zap.String("dir", g.dir),
+			zap.Stringer("old-level", old),
+			zap.Stringer("new-level", level),
+			zap.String("available", units.BytesSize(float64(available))),
+		}
+		if level > old {
+			log.Warn("the free space of the disk decreases", fields...)
+		} else {
+			log.Info("the free space of the disk recovers", fields...)
+		}
+	}
+	g.available, g.lastCheck = available, now
+	atomic.StoreInt32(&g.level, int32(level))
+	diskAvailableGauge.Set(float64(available))
+	diskLevelGauge.Set(float64(level))
+	return level, nil
+}
+
+// Level returns the current level of the free space.
+func (g *Guard) Level() Level {
+	if g == nil {
+		return LevelNormal
+	}
+	return Level(atomic.LoadInt32(&g.level))
+}
+
+// CheckWrite returns an error if the writes of the class are refused at the
+// current level.
+func (g *Guard) CheckWrite(class WriteClass) error {
+	if g.Level() < class.refusedLevel() {
+		return nil
+	}
+	refusedWriteCounter.WithLabelValues(class.String()).Inc()
+	return errs.ErrDiskSpaceInsufficient.FastGenByArgs(g.dir, class)
+}
+
+// GetStatus returns the status of the free space of the disk.
+func (g *Guard) GetStatus() *Status {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return &Status{
+		Dir:       g.dir,
+		Level:     g.Level().String(),
+		Available: typeutil.ByteSize(g.available),
+		Warning:   typeutil.ByteSize(g.warning),
+		Critical:  typeutil.ByteSize(g.critical),
+		Since:     g.since,
+		LastCheck: g.lastCheck,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskguard
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testDiskGuardSuite{})
+
+type testDiskGuardSuite struct{}
+
+func (s *testDiskGuardSuite) TestLevel(c *C) {
+	var available uint64
+	g := NewGuard(c.MkDir(), 100, 10)
+	g.getAvailable = func(string) (uint64, error) { return available, nil }
+
+	testCases := []struct {
+		available   uint64
+		level       Level
+		nonCritical bool
+		region      bool
+	}{
+		{1000, LevelNormal, true, true},
+		{50, LevelWarning, false, true},
+		{5, LevelCritical, false, false},
+		{100, LevelNormal, true, true},
+	}
+	for _, t := range testCases {
+		available = t.available
+		level, err := g.Check()
+		c.Assert(err, IsNil)
+		c.Assert(level, Equals, t.level)
+		c.Assert(g.Level(), Equals, t.level)
+		c.Assert(g.CheckWrite(NonCriticalWrite) == nil, Equals, t.nonCritical)
+		c.Assert(g.CheckWrite(RegionWrite) == nil, Equals, t.region)
+		status := g.GetStatus()
+		c.Assert(status.Level, Equals, t.level.String())
+		c.Assert(uint64(status.Available), Equals, t.available)
+	}
+
+	available = 5
+	_, err := g.Check()
+	c.Assert(err, IsNil)
+	c.Assert(errs.ErrDiskSpaceInsufficient.Equal(g.CheckWrite(RegionWrite)), IsTrue)
+
+	// The nil guard never refuses any write.
+	var nilGuard *Guard
+	c.Assert(nilGuard.Level(), Equals, LevelNormal)
+	c.Assert(nilGuard.CheckWrite(NonCriticalWrite), IsNil)
+}
+
+func (s *testDiskGuardSuite) TestGetAvailable(c *C) {
+	available, err := getAvailable(c.MkDir())
+	c.Assert(err, IsNil)
+	c.Assert(available, Greater, uint64(0))
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskguard
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	diskAvailableGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "data_dir_available_bytes",
+			Help:      "The free space of the disk of the data dir.",
+		})
+
+	diskLevelGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "data_dir_space_level",
+			Help:      "The level of the free space of the data dir, 0 is normal, 1 is warning and 2 is critical.",
+		})
+
+	refusedWriteCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "disk_guard_refused_writes",
+			Help:      "Counter of the writes refused for the insufficient free space of the data dir.",
+		}, []string{"type"})
+)
+
+func init() {
+	prometheus.MustRegister(diskAvailableGauge)
+	prometheus.MustRegister(diskLevelGauge)
+	prometheus.MustRegister(refusedWriteCounter)
+}
@@ -20,6 +20,8 @@ const (
 	NotLeaderErr = "is not leader"
 	// MismatchLeaderErr indicates the the non-leader member received the requests which should be received by leader.
 	MismatchLeaderErr = "mismatch leader id"
+	// TSOQuotaExceededErr indicates the TSO request is rejected because the quota of its dc-location is exceeded.
+	TSOQuotaExceededErr = "PD:tso:ErrTSOQuotaExceeded"
 )
 
 // common error in multiple packages
@@ -42,6 +44,7 @@ var (
 	ErrGenerateTimestamp  = errors.Normalize("generate timestamp failed, %s", errors.RFCCodeText("PD:tso:ErrGenerateTimestamp"))
 	ErrInvalidTimestamp   = errors.Normalize("invalid timestamp", errors.RFCCodeText("PD:tso:ErrInvalidTimestamp"))
 	ErrLogicOverflow      = errors.Normalize("logic part overflow", errors.RFCCodeText("PD:tso:ErrLogicOverflow"))
+	ErrTSOQuotaExceeded   = errors.Normalize("tso quota of %s exceeded", errors.RFCCodeText("PD:tso:ErrTSOQuotaExceeded"))
 )
 
 // member errors
@@ -58,15 +61,21 @@ var (
 	ErrStoreTombstone      = errors.Normalize("store %v has been removed", errors.RFCCodeText("PD:core:ErrStoreTombstone"))
 	ErrStoreDestroyed      = errors.Normalize("store %v has been physically destroyed", errors.RFCCodeText("PD:core:ErrStoreDestroyed"))
 	ErrStoreUnhealthy      = errors.Normalize("store %v is unhealthy", errors.RFCCodeText("PD:core:ErrStoreUnhealthy"))
+	ErrStoreNotTombstone   = errors.Normalize("store %v is not tombstone", errors.RFCCodeText("PD:core:ErrStoreNotTombstone"))
 )
 
 // client errors
 var (
-	ErrClientCreateTSOStream = errors.Normalize("create TSO stream failed", errors.RFCCodeText("PD:client:ErrClientCreateTSOStream"))
-	ErrClientGetTSOTimeout   = errors.Normalize("get TSO timeout", errors.RFCCodeText("PD:client:ErrClientGetTSOTimeout"))
-	ErrClientGetTSO          = errors.Normalize("get TSO failed, %v", errors.RFCCodeText("PD:client:ErrClientGetTSO"))
-	ErrClientGetLeader       = errors.Normalize("get leader from %v error", errors.RFCCodeText("PD:client:ErrClientGetLeader"))
-	ErrClientGetMember       = errors.Normalize("get member failed", errors.RFCCodeText("PD:client:ErrClientGetMember"))
+	ErrClientCreateTSOStream    = errors.Normalize("create TSO stream failed", errors.RFCCodeText("PD:client:ErrClientCreateTSOStream"))
+	ErrClientGetTSOTimeout      = errors.Normalize("get TSO timeout", errors.RFCCodeText("PD:client:ErrClientGetTSOTimeout"))
+	ErrClientGetTSO             = errors.Normalize("get TSO failed, %v", errors.RFCCodeText("PD:client:ErrClientGetTSO"))
+	ErrClientGetLeader          = errors.Normalize("get leader from %v error", errors.RFCCodeText("PD:client:ErrClientGetLeader"))
+	ErrClientGetMember          = errors.Normalize("get member failed", errors.RFCCodeText("PD:client:ErrClientGetMember"))
+	ErrClientGetClusterFeatures = errors.Normalize("get cluster features from %v failed", errors.RFCCodeText("PD:client:ErrClientGetClusterFeatures"))
+	ErrClientGetServiceVersions = errors.Normalize("get service versions from %v failed", errors.RFCCodeText("PD:client:ErrClientGetServiceVersions"))
+	ErrClientRequestKeyspace    = errors.Normalize("request keyspace from %v failed", errors.RFCCodeText("PD:client:ErrClientRequestKeyspace"))
+	ErrClientHTTPRequest        = errors.Normalize("send http request %s failed", errors.RFCCodeText("PD:client:ErrClientHTTPRequest"))
+	ErrClientHTTPResponse       = errors.Normalize("http request %s failed with status %d, %s", errors.RFCCodeText("PD:client:ErrClientHTTPResponse"))
 )
 
 // schedule errors
@@ -75,6 +84,7 @@ var (
 	ErrUnknownOperatorStep      = errors.Normalize("unknown operator step found", errors.RFCCodeText("PD:schedule:ErrUnknownOperatorStep"))
 	ErrMergeOperator            = errors.Normalize("merge operator error, %s", errors.RFCCodeText("PD:schedule:ErrMergeOperator"))
 	ErrCreateOperator           = errors.Normalize("unable to create operator, %s", errors.RFCCodeText("PD:schedule:ErrCreateOperator"))
+	ErrInvalidOperator          = errors.Normalize("invalid operator, %s", errors.RFCCodeText("PD:schedule:ErrInvalidOperator"))
 )
 
 // scheduler errors
@@ -95,12 +105,18 @@ var (
 	ErrLoadRule      = errors.Normalize("load rule failed", errors.RFCCodeText("PD:placement:ErrLoadRule"))
 	ErrLoadRuleGroup = errors.Normalize("load rule group failed", errors.RFCCodeText("PD:placement:ErrLoadRuleGroup"))
 	ErrBuildRuleList = errors.Normalize("build rule list failed, %s", errors.RFCCodeText("PD:placement:ErrBuildRuleList"))
+	ErrRuleCompacted = errors.Normalize("rule revision %d has been compacted, the compact revision is %d", errors.RFCCodeText("PD:placement:ErrRuleCompacted"))
 )
 
 // cluster errors
 var (
-	ErrNotBootstrapped = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
-	ErrStoreIsUp       = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrNotBootstrapped           = errors.Normalize("TiKV cluster not bootstrapped, please start TiKV first", errors.RFCCodeText("PD:cluster:ErrNotBootstrapped"))
+	ErrStoreIsUp                 = errors.Normalize("store is still up, please remove store gracefully", errors.RFCCodeText("PD:cluster:ErrStoreIsUp"))
+	ErrReplicasRolloutInProgress = errors.Normalize("a rollout of max-replicas is in progress", errors.RFCCodeText("PD:cluster:ErrReplicasRolloutInProgress"))
+	ErrReplicasRolloutNotFound   = errors.Normalize("no rollout of max-replicas is in progress", errors.RFCCodeText("PD:cluster:ErrReplicasRolloutNotFound"))
+	ErrRegionNotQuarantined      = errors.Normalize("the heartbeat of region %d is not quarantined", errors.RFCCodeText("PD:cluster:ErrRegionNotQuarantined"))
+	ErrStoreRemovalQueued        = errors.Normalize("store %v is already in the removal queue", errors.RFCCodeText("PD:cluster:ErrStoreRemovalQueued"))
+	ErrTombstonePurgeAckDisabled = errors.Normalize("the acknowledgement of the tombstone stores is disabled", errors.RFCCodeText("PD:cluster:ErrTombstonePurgeAckDisabled"))
 )
 
 // versioninfo errors
@@ -136,6 +152,16 @@ var (
 	ErrLeaderNil             = errors.Normalize("leader is nil", errors.RFCCodeText("PD:server:ErrLeaderNil"))
 	ErrCancelStartEtcd       = errors.Normalize("etcd start canceled", errors.RFCCodeText("PD:server:ErrCancelStartEtcd"))
 	ErrConfigItem            = errors.Normalize("cannot set invalid configuration", errors.RFCCodeText("PD:server:ErrConfiguration"))
+	ErrProfileNotFound       = errors.Normalize("schedule profile %s not found", errors.RFCCodeText("PD:server:ErrProfileNotFound"))
+	ErrProfileBuiltin        = errors.Normalize("schedule profile %s is builtin", errors.RFCCodeText("PD:server:ErrProfileBuiltin"))
+)
+
+// keyspace errors
+var (
+	ErrKeyspaceNotFound    = errors.Normalize("keyspace %v not found", errors.RFCCodeText("PD:keyspace:ErrKeyspaceNotFound"))
+	ErrKeyspaceExists      = errors.Normalize("keyspace %s already exists", errors.RFCCodeText("PD:keyspace:ErrKeyspaceExists"))
+	ErrKeyspaceInvalidName = errors.Normalize("invalid keyspace name %s", errors.RFCCodeText("PD:keyspace:ErrKeyspaceInvalidName"))
+	ErrKeyspaceIDExhausted = errors.Normalize("keyspace id is exhausted", errors.RFCCodeText("PD:keyspace:ErrKeyspaceIDExhausted"))
 )
 
 // logutil errors
@@ -173,22 +199,24 @@ var (
 
 // etcd errors
 var (
-	ErrNewEtcdClient     = errors.Normalize("new etcd client failed", errors.RFCCodeText("PD:etcd:ErrNewEtcdClient"))
-	ErrStartEtcd         = errors.Normalize("start etcd failed", errors.RFCCodeText("PD:etcd:ErrStartEtcd"))
-	ErrEtcdURLMap        = errors.Normalize("etcd url map error", errors.RFCCodeText("PD:etcd:ErrEtcdURLMap"))
-	ErrEtcdGrantLease    = errors.Normalize("etcd lease failed", errors.RFCCodeText("PD:etcd:ErrEtcdGrantLease"))
-	ErrEtcdTxnInternal   = errors.Normalize("internal etcd transaction error occurred", errors.RFCCodeText("PD:etcd:ErrEtcdTxnInternal"))
-	ErrEtcdTxnConflict   = errors.Normalize("etcd transaction failed, conflicted and rolled back", errors.RFCCodeText("PD:etcd:ErrEtcdTxnConflict"))
-	ErrEtcdKVPut         = errors.Normalize("etcd KV put failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVPut"))
-	ErrEtcdKVDelete      = errors.Normalize("etcd KV delete failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVDelete"))
-	ErrEtcdKVGet         = errors.Normalize("etcd KV get failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVGet"))
-	ErrEtcdKVGetResponse = errors.Normalize("etcd invalid get value response %v, must only one", errors.RFCCodeText("PD:etcd:ErrEtcdKVGetResponse"))
-	ErrEtcdGetCluster    = errors.Normalize("etcd get cluster from remote peer failed", errors.RFCCodeText("PD:etcd:ErrEtcdGetCluster"))
-	ErrEtcdMoveLeader    = errors.Normalize("etcd move leader error", errors.RFCCodeText("PD:etcd:ErrEtcdMoveLeader"))
-	ErrEtcdTLSConfig     = errors.Normalize("etcd TLS config error", errors.RFCCodeText("PD:etcd:ErrEtcdTLSConfig"))
-	ErrEtcdWatcherCancel = errors.Normalize("watcher canceled", errors.RFCCodeText("PD:etcd:ErrEtcdWatcherCancel"))
-	ErrCloseEtcdClient   = errors.Normalize("close etcd client failed", errors.RFCCodeText("PD:etcd:ErrCloseEtcdClient"))
-	ErrEtcdMemberList    = errors.Normalize("etcd member list failed", errors.RFCCodeText("PD:etcd:ErrEtcdMemberList"))
+	ErrNewEtcdClient       = errors.Normalize("new etcd client failed", errors.RFCCodeText("PD:etcd:ErrNewEtcdClient"))
+	ErrStartEtcd           = errors.Normalize("start etcd failed", errors.RFCCodeText("PD:etcd:ErrStartEtcd"))
+	ErrEtcdURLMap          = errors.Normalize("etcd url map error", errors.RFCCodeText("PD:etcd:ErrEtcdURLMap"))
+	ErrEtcdGrantLease      = errors.Normalize("etcd lease failed", errors.RFCCodeText("PD:etcd:ErrEtcdGrantLease"))
+	ErrEtcdLeaseTimeToLive = errors.Normalize("etcd lease time to live failed", errors.RFCCodeText("PD:etcd:ErrEtcdLeaseTimeToLive"))
+	ErrEtcdTxnInternal     = errors.Normalize("internal etcd transaction error occurred", errors.RFCCodeText("PD:etcd:ErrEtcdTxnInternal"))
+	ErrEtcdTxnConflict     = errors.Normalize("etcd transaction failed, conflicted and rolled back", errors.RFCCodeText("PD:etcd:ErrEtcdTxnConflict"))
+	ErrEtcdKVPut           = errors.Normalize("etcd KV put failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVPut"))
+	ErrEtcdKVDelete        = errors.Normalize("etcd KV delete failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVDelete"))
+	ErrEtcdKVGet           = errors.Normalize("etcd KV get failed", errors.RFCCodeText("PD:etcd:ErrEtcdKVGet"))
+	ErrEtcdKVGetResponse   = errors.Normalize("etcd invalid get value response %v, must only one", errors.RFCCodeText("PD:etcd:ErrEtcdKVGetResponse"))
+	ErrEtcdGetCluster      = errors.Normalize("etcd get cluster from remote peer failed", errors.RFCCodeText("PD:etcd:ErrEtcdGetCluster"))
+	ErrEtcdMoveLeader      = errors.Normalize("etcd move leader error", errors.RFCCodeText("PD:etcd:ErrEtcdMoveLeader"))
+	ErrEtcdTLSConfig       = errors.Normalize("etcd TLS config error", errors.RFCCodeText("PD:etcd:ErrEtcdTLSConfig"))
+	ErrEtcdWatcherCancel   = errors.Normalize("watcher canceled", errors.RFCCodeText("PD:etcd:ErrEtcdWatcherCancel"))
+	ErrCloseEtcdClient     = errors.Normalize("close etcd client failed", errors.RFCCodeText("PD:etcd:ErrCloseEtcdClient"))
+	ErrEtcdMemberList      = errors.Normalize("etcd member list failed", errors.RFCCodeText("PD:etcd:ErrEtcdMemberList"))
+	ErrEtcdFenced          = errors.Normalize("etcd write is rejected, the fencing token is stale", errors.RFCCodeText("PD:etcd:ErrEtcdFenced"))
 )
 
 // dashboard errors
@@ -211,6 +239,11 @@ var (
 	ErrPrometheusQuery        = errors.Normalize("query error", errors.RFCCodeText("PD:prometheus:ErrPrometheusQuery"))
 )
 
+// statsd errors
+var (
+	ErrStatsDPushMetrics = errors.Normalize("push metrics to statsd failed", errors.RFCCodeText("PD:statsd:ErrStatsDPushMetrics"))
+)
+
 // http errors
 var (
 	ErrSendRequest    = errors.Normalize("send HTTP request failed", errors.RFCCodeText("PD:http:ErrSendRequest"))
@@ -267,6 +300,17 @@ var (
 	ErrLevelDBOpen  = errors.Normalize("leveldb open file error", errors.RFCCodeText("PD:leveldb:ErrLevelDBOpen"))
 )
 
+// disk guard errors
+var (
+	ErrDiskSpaceInsufficient = errors.Normalize("the free space of %s is insufficient, the %s write is refused", errors.RFCCodeText("PD:diskguard:ErrDiskSpaceInsufficient"))
+)
+
+// idempotency errors
+var (
+	ErrIdempotencyKeyReused    = errors.Normalize("idempotency key %s is reused by a different request", errors.RFCCodeText("PD:idempotency:ErrIdempotencyKeyReused"))
+	ErrIdempotencyRequestPanic = errors.Normalize("the request with idempotency key %s panicked", errors.RFCCodeText("PD:idempotency:ErrIdempotencyRequestPanic"))
+)
+
 // semver
 var (
 	ErrSemverNewVersion = errors.Normalize("new version error", errors.RFCCodeText("PD:semver:ErrSemverNewVersion"))
@@ -274,7 +318,13 @@ var (
 
 // log
 var (
-	ErrInitLogger = errors.Normalize("init logger error", errors.RFCCodeText("PD:log:ErrInitLogger"))
+	ErrInitLogger        = errors.Normalize("init logger error", errors.RFCCodeText("PD:log:ErrInitLogger"))
+	ErrInvalidRedactMode = errors.Normalize("invalid redact mode %s", errors.RFCCodeText("PD:log:ErrInvalidRedactMode"))
+)
+
+// trace
+var (
+	ErrInitTracer = errors.Normalize("init tracer error", errors.RFCCodeText("PD:trace:ErrInitTracer"))
 )
 
 // encryption
@@ -16,6 +16,7 @@ package grpcutil
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"net/url"
 
 	"github.com/pingcap/log"
@@ -24,11 +25,33 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 // ForwardMetadataKey is used to record the forwarded host of PD.
 const ForwardMetadataKey = "pd-forwarded-host"
 
+// ForwardedCNMetadataKey is used to record the certificate CN of the caller of
+// the request forwarded by a PD member, which is checked by the leader.
+const ForwardedCNMetadataKey = "pd-forwarded-cn"
+
+// FollowerHandleMetadataKey is set if the request is allowed to be handled by
+// the PD follower.
+const FollowerHandleMetadataKey = "pd-allow-follower-handle"
+
+// TruncatedMetadataKey is set in the response header if the response is
+// truncated by the server-side limits.
+const TruncatedMetadataKey = "pd-response-truncated"
+
+// ContinuationMetadataKey is set in the header of a truncated response to
+// where the next request continues, and in the metadata of the next request to
+// continue from there.
+const ContinuationMetadataKey = "pd-continuation"
+
+// IdempotencyKeyMetadataKey is used to record the idempotency key of the
+// mutating request, the retried requests with the same key are applied once.
+const IdempotencyKeyMetadataKey = "pd-idempotency-key"
+
 // TLSConfig is the configuration for supporting tls.
 type TLSConfig struct {
 	// CAPath is the path of file that contains list of trusted SSL CAs. if set, following four settings shouldn't be empty
@@ -77,6 +100,46 @@ func (s TLSConfig) GetOneAllowedCN() (string, error) {
 	}
 }
 
+// LoadCommonName returns the CN of the certificate in CertPath, or empty string if
+// the certificate is not configured.
+func (s TLSConfig) LoadCommonName() (string, error) {
+	if len(s.CertPath) == 0 {
+		return "", nil
+	}
+	pair, err := tls.LoadX509KeyPair(s.CertPath, s.KeyPath)
+	if err != nil {
+		return "", errs.ErrSecurityConfig.FastGenByArgs(err.Error())
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return "", errs.ErrSecurityConfig.FastGenByArgs(err.Error())
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// GetCommonName returns the CN of the certificate provided by the peer of the TLS
+// connection, or empty string if the peer doesn't provide one.
+func GetCommonName(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+// GetPeerCommonName returns the CN of the certificate provided by the client of
+// the gRPC request.
+func GetPeerCommonName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	return GetCommonName(&tlsInfo.State)
+}
+
 // GetClientConn returns a gRPC client connection.
 // creates a client connection to the given target. By default, it's
 // a non-blocking dial (the function won't wait for connections to be
@@ -110,16 +173,113 @@ func GetClientConn(ctx context.Context, addr string, tlsCfg *tls.Config, do ...g
 // BuildForwardContext creates a context with receiver metadata information.
 // It is used in client side.
 func BuildForwardContext(ctx context.Context, addr string) context.Context {
-	md := metadata.Pairs(ForwardMetadataKey, addr)
-	return metadata.NewOutgoingContext(ctx, md)
+	return metadata.AppendToOutgoingContext(ctx, ForwardMetadataKey, addr)
 }
 
-// ResetForwardContext is going to reset the forwarded host in metadata.
+// BuildFollowerHandleContext creates a context which allows the request to be
+// handled by the PD follower. It is used in client side.
+func BuildFollowerHandleContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, FollowerHandleMetadataKey, "true")
+}
+
+// BuildIdempotencyContext creates a context with the idempotency key of the
+// request. It is used in client side.
+func BuildIdempotencyContext(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, IdempotencyKeyMetadataKey, key)
+}
+
+// GetIdempotencyKey returns the idempotency key of the request, or empty if
+// it is not set. It is used in server side.
+func GetIdempotencyKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if keys := md.Get(IdempotencyKeyMetadataKey); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// IsFollowerHandleEnabled checks if the request is allowed to be handled by the
+// PD follower. It is used in server side.
+func IsFollowerHandleEnabled(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false
+	}
+	return len(md.Get(FollowerHandleMetadataKey)) > 0
+}
+
+// ResetForwardContext is going to reset the forwarded host in metadata, and
+// records the certificate CN of the caller in it.
 func ResetForwardContext(ctx context.Context) context.Context {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		log.Error("failed to get forwarding metadata")
 	}
 	md.Set(ForwardMetadataKey, "")
+	md.Set(ForwardedCNMetadataKey, GetPeerCommonName(ctx))
 	return metadata.NewOutgoingContext(ctx, md)
 }
+
+// BuildForwardedCNContext creates a context with the certificate CN of the
+// caller of the forwarded request. It is used in server side.
+func BuildForwardedCNContext(ctx context.Context, cn string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ForwardedCNMetadataKey, cn)
+}
+
+// GetForwardedCNs returns the certificate CN of the caller of the forwarded
+// request, or nil if it is not set. It is used in server side.
+func GetForwardedCNs(ctx context.Context) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	return md.Get(ForwardedCNMetadataKey)
+}
+
+// SetTruncatedHeader marks the response truncated in the response header, with
+// the continuation of the next request if it is not empty. It is used in server
+// side.
+func SetTruncatedHeader(ctx context.Context, continuation string) error {
+	header := metadata.Pairs(TruncatedMetadataKey, "true")
+	if continuation != "" {
+		header.Set(ContinuationMetadataKey, continuation)
+	}
+	return grpc.SetHeader(ctx, header)
+}
+
+// IsTruncated checks if the response is truncated according to the response
+// header. It is used in client side.
+func IsTruncated(header metadata.MD) bool {
+	return len(header.Get(TruncatedMetadataKey)) > 0
+}
+
+// GetContinuation returns the continuation in the response header, or empty if
+// it is not set. It is used in client side.
+func GetContinuation(header metadata.MD) string {
+	if continuations := header.Get(ContinuationMetadataKey); len(continuations) > 0 {
+		return continuations[0]
+	}
+	return ""
+}
+
+// BuildContinuationContext creates a context which continues the truncated
+// response. It is used in client side.
+func BuildContinuationContext(ctx context.Context, continuation string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ContinuationMetadataKey, continuation)
+}
+
+// GetRequestContinuation returns the continuation of the request, or empty if
+// it is not set. It is used in server side.
+func GetRequestContinuation(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if continuations := md.Get(ContinuationMetadataKey); len(continuations) > 0 {
+		return continuations[0]
+	}
+	return ""
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+
+	gogoproto "github.com/gogo/protobuf/proto"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+var registerProtoFilesOnce sync.Once
+
+// gogoProtoFileNames maps the import paths of the proto files to the names
+// which they are registered with in the gogo/protobuf registry, if differ.
+var gogoProtoFileNames = map[string]string{
+	"gogoproto/gogo.proto": "gogo.proto",
+}
+
+// rustprotoFile stands in for rustproto.proto, which the kvproto files import
+// for the options of the Rust code generation but no Go package registers. It
+// only declares the options used by kvproto.
+var rustprotoFile = &descriptor.FileDescriptorProto{
+	Name:       proto.String("rustproto.proto"),
+	Package:    proto.String("rustproto"),
+	Dependency: []string{"google/protobuf/descriptor.proto"},
+	Extension: []*descriptor.FieldDescriptorProto{{
+		Name:     proto.String("lite_runtime_all"),
+		Number:   proto.Int32(17035),
+		Label:    descriptor.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptor.FieldDescriptorProto_TYPE_BOOL.Enum(),
+		Extendee: proto.String(".google.protobuf.FileOptions"),
+		JsonName: proto.String("liteRuntimeAll"),
+	}},
+	Syntax: proto.String("proto2"),
+}
+
+// RegisterReflection registers the gRPC reflection service on the server.
+// The reflection looks up the proto files in the golang/protobuf registry,
+// while some dependencies of the services are not registered there, e.g.
+// gogoproto/gogo.proto is only registered to the gogo/protobuf registry, so
+// they are registered first, otherwise the clients fail to resolve the
+// services.
+func RegisterReflection(gs *grpc.Server) {
+	registerProtoFilesOnce.Do(func() { registerProtoFiles(gs) })
+	reflection.Register(gs)
+}
+
+func registerProtoFiles(gs *grpc.Server) {
+	var pending []string
+	for _, info := range gs.GetServiceInfo() {
+		if name, ok := info.Metadata.(string); ok {
+			pending = append(pending, name)
+		}
+	}
+	visited := make(map[string]struct{})
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := visited[name]; ok {
+			continue
+		}
+		visited[name] = struct{}{}
+		if gz := proto.FileDescriptor(name); gz != nil {
+			fd, err := decodeFileDescriptor(gz)
+			if err != nil {
+				log.Warn("failed to decode the proto file", zap.String("file", name), errs.ZapError(err))
+				continue
+			}
+			pending = append(pending, fd.GetDependency()...)
+			continue
+		}
+		fd, err := loadProtoFile(name)
+		if err != nil {
+			log.Warn("failed to load the proto file", zap.String("file", name), errs.ZapError(err))
+			continue
+		}
+		if fd == nil {
+			log.Warn("the proto file is not registered", zap.String("file", name))
+			continue
+		}
+		gz, err := encodeFileDescriptor(fd)
+		if err != nil {
+			log.Warn("failed to encode the proto file", zap.String("file", name), errs.ZapError(err))
+			continue
+		}
+		proto.RegisterFile(name, gz)
+		pending = append(pending, fd.GetDependency()...)
+	}
+}
+
+// loadProtoFile returns the proto file which is not registered to the
+// golang/protobuf registry, or nil if it is not found either.
+func loadProtoFile(name string) (*descriptor.FileDescriptorProto, error) {
+	if name == rustprotoFile.GetName() {
+		return rustprotoFile, nil
+	}
+	gogoName, ok := gogoProtoFileNames[name]
+	if !ok {
+		gogoName = name
+	}
+	gz := gogoproto.FileDescriptor(gogoName)
+	if gz == nil {
+		return nil, nil
+	}
+	fd, err := decodeFileDescriptor(gz)
+	if err != nil {
+		return nil, err
+	}
+	// The name must be the import path to be linked with the files depending
+	// on it.
+	fd.Name = proto.String(name)
+	return fd, nil
+}
+
+func decodeFileDescriptor(gz []byte) (*descriptor.FileDescriptorProto, error) {
+	r, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	fd := new(descriptor.FileDescriptorProto)
+	if err := proto.Unmarshal(b, fd); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
+
+func encodeFileDescriptor(fd *descriptor.FileDescriptorProto) ([]byte, error) {
+	b, err := proto.Marshal(fd)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/errs"
+)
+
+const (
+	// DefaultTTL is the default duration to remember the results of the requests.
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxEntries is the default max number of the remembered requests.
+	DefaultMaxEntries = 10000
+)
+
+// Cache remembers the results of the mutating requests by their idempotency
+// keys for a while, so that the requests retried by the clients after timeouts
+// are not applied twice. The results are kept in memory, so the retried
+// requests may be applied again after the PD leader changes.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	// now is replaced in tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// order is the keys in the order of the requests, the oldest finished
+	// request is evicted first when the cache is full.
+	order     *list.List
+	lastPurge time.Time
+}
+
+type entry struct {
+	key         string
+	elem        *list.Element
+	fingerprint [sha256.Size]byte
+	// done is closed when the first request finishes.
+	done   chan struct{}
+	value  interface{}
+	err    error
+	expire time.Time
+}
+
+func (e *entry) finished() bool {
+	return !e.expire.IsZero()
+}
+
+// NewCache creates a Cache which remembers at most maxEntries results for the ttl.
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*entry),
+		order:      list.New(),
+	}
+}
+
+// Do calls fn and returns its result if the key is not seen in the TTL.
+// Otherwise it returns the result of the first request with the key, and
+// waits for it if it is still running until ctx is done. The content
+// identifies the request, the reuse of the key by a request with different
+// content is rejected.
+//
+// The result is not remembered if fn returns an error or panics, so that the
+// failed request can be retried, but the concurrent requests waiting for it
+// get the same error. The empty key disables the deduplication, and so does
+// the cache which is full of the running requests.
+func (c *Cache) Do(ctx context.Context, key string, content []byte, fn func() (interface{}, error)) (interface{}, error) {
+	if key == "" {
+		return fn()
+	}
+	fingerprint := sha256.Sum256(content)
+	c.mu.Lock()
+	now := c.now()
+	c.purgeLocked(now, false)
+	if e, ok := c.entries[key]; ok && (!e.finished() || now.Before(e.expire)) {
+		c.mu.Unlock()
+		if e.fingerprint != fingerprint {
+			return nil, errs.ErrIdempotencyKeyReused.FastGenByArgs(key)
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		duplicatedRequestCounter.Inc()
+		return e.value, e.err
+	}
+	if !c.makeRoomLocked(now) {
+		c.mu.Unlock()
+		return fn()
+	}
+	e := &entry{key: key, fingerprint: fingerprint, done: make(chan struct{})}
+	c.addLocked(e)
+	c.mu.Unlock()
+
+	panicked := true
+	defer func() {
+		c.mu.Lock()
+		if panicked {
+			e.err = errs.ErrIdempotencyRequestPanic.FastGenByArgs(key)
+		}
+		if e.err != nil {
+			c.removeLocked(e)
+		} else {
+			e.expire = c.now().Add(c.ttl)
+		}
+		c.mu.Unlock()
+		close(e.done)
+	}()
+	e.value, e.err = fn()
+	panicked = false
+	return e.value, e.err
+}
+
+func (c *Cache) addLocked(e *entry) {
+	if origin, ok := c.entries[e.key]; ok {
+		c.removeLocked(origin)
+	}
+	e.elem = c.order.PushBack(e)
+	c.entries[e.key] = e
+}
+
+func (c *Cache) removeLocked(e *entry) {
+	// The entry may have been replaced by a newer one with the same key.
+	if c.entries[e.key] == e {
+		delete(c.entries, e.key)
+	}
+	c.order.Remove(e.elem)
+}
+
+// makeRoomLocked evicts the oldest finished request if the cache is full. It
+// returns false if all the remembered requests are still running.
+func (c *Cache) makeRoomLocked(now time.Time) bool {
+	if c.maxEntries <= 0 || len(c.entries) < c.maxEntries {
+		return true
+	}
+	c.purgeLocked(now, true)
+	if len(c.entries) < c.maxEntries {
+		return true
+	}
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		if e := elem.Value.(*entry); e.finished() {
+			c.removeLocked(e)
+			evictedRequestCounter.Inc()
+			return true
+		}
+	}
+	return false
+}
+
+// purgeLocked removes the expired entries, at most once in the TTL unless it
+// is forced.
+func (c *Cache) purgeLocked(now time.Time, force bool) {
+	if !force && now.Sub(c.lastPurge) < c.ttl {
+		return
+	}
+	c.lastPurge = now
+	for _, e := range c.entries {
+		if e.finished() && !now.Before(e.expire) {
+			c.removeLocked(e)
+		}
+	}
+}
+
+// Len returns the number of the remembered requests.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
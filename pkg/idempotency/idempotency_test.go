@@ -0,0 +1,209 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testIdempotencySuite{})
+
+type testIdempotencySuite struct{}
+
+func (s *testIdempotencySuite) TestDo(c *C) {
+	now := time.Now()
+	cache := NewCache(time.Minute, DefaultMaxEntries)
+	cache.now = func() time.Time { return now }
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	v, err := cache.Do(context.Background(), "k1", []byte("a"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 1)
+	// The retry gets the result of the first request.
+	v, err = cache.Do(context.Background(), "k1", []byte("a"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 1)
+	c.Assert(calls, Equals, 1)
+	// The key can't be reused by the different request.
+	_, err = cache.Do(context.Background(), "k1", []byte("b"), fn)
+	c.Assert(errs.ErrIdempotencyKeyReused.Equal(err), IsTrue)
+	c.Assert(calls, Equals, 1)
+	// The empty key disables the deduplication.
+	v, err = cache.Do(context.Background(), "", []byte("a"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 2)
+
+	// The result is forgotten after the TTL.
+	now = now.Add(time.Minute)
+	v, err = cache.Do(context.Background(), "k1", []byte("b"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 3)
+	now = now.Add(2 * time.Minute)
+	cache.Do(context.Background(), "k2", []byte("a"), fn)
+	c.Assert(cache.Len(), Equals, 1)
+}
+
+func (s *testIdempotencySuite) TestError(c *C) {
+	cache := NewCache(time.Minute, DefaultMaxEntries)
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("failed")
+		}
+		return calls, nil
+	}
+	_, err := cache.Do(context.Background(), "k1", []byte("a"), fn)
+	c.Assert(err, NotNil)
+	c.Assert(cache.Len(), Equals, 0)
+	// The failed request can be retried.
+	v, err := cache.Do(context.Background(), "k1", []byte("a"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 2)
+}
+
+func (s *testIdempotencySuite) TestConcurrent(c *C) {
+	cache := NewCache(time.Minute, DefaultMaxEntries)
+	start, calls := make(chan struct{}), 0
+	fn := func() (interface{}, error) {
+		<-start
+		calls++
+		return calls, nil
+	}
+	var wg sync.WaitGroup
+	results := make([]interface{}, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.Do(context.Background(), "k1", []byte("a"), fn)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	// The concurrent retries wait for the first request.
+	c.Assert(calls, Equals, 1)
+	for _, v := range results {
+		c.Assert(v, Equals, 1)
+	}
+}
+
+func (s *testIdempotencySuite) TestMaxEntries(c *C) {
+	now := time.Now()
+	cache := NewCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	cache.Do(context.Background(), "k1", []byte("a"), fn)
+	cache.Do(context.Background(), "k2", []byte("a"), fn)
+	// The oldest request is evicted.
+	cache.Do(context.Background(), "k3", []byte("a"), fn)
+	c.Assert(cache.Len(), Equals, 2)
+	v, _ := cache.Do(context.Background(), "k2", []byte("a"), fn)
+	c.Assert(v, Equals, 2)
+	v, _ = cache.Do(context.Background(), "k1", []byte("a"), fn)
+	c.Assert(v, Equals, 4)
+	c.Assert(cache.Len(), Equals, 2)
+
+	// The running requests are not evicted, the deduplication is disabled
+	// instead if the cache is full of them.
+	cache = NewCache(time.Minute, 1)
+	start, started := make(chan struct{}), make(chan struct{})
+	go cache.Do(context.Background(), "k1", []byte("a"), func() (interface{}, error) {
+		close(started)
+		<-start
+		return 0, nil
+	})
+	<-started
+	v, err := cache.Do(context.Background(), "k2", []byte("a"), fn)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 5)
+	c.Assert(cache.Len(), Equals, 1)
+	close(start)
+}
+
+func (s *testIdempotencySuite) TestCancelRetry(c *C) {
+	cache := NewCache(time.Minute, DefaultMaxEntries)
+	start, started := make(chan struct{}), make(chan struct{})
+	go cache.Do(context.Background(), "k1", []byte("a"), func() (interface{}, error) {
+		close(started)
+		<-start
+		return 1, nil
+	})
+	<-started
+	// The retry stops waiting for the first request when it is canceled.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := cache.Do(ctx, "k1", []byte("a"), func() (interface{}, error) {
+		c.Fatal("the retry should not be handled")
+		return nil, nil
+	})
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	close(start)
+}
+
+func (s *testIdempotencySuite) TestPanic(c *C) {
+	cache := NewCache(time.Minute, DefaultMaxEntries)
+	start, started := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer func() { recover() }()
+		cache.Do(context.Background(), "k1", []byte("a"), func() (interface{}, error) {
+			close(started)
+			<-start
+			panic("fn panics")
+		})
+	}()
+	<-started
+	errCh := make(chan error)
+	go func() {
+		_, err := cache.Do(context.Background(), "k1", []byte("a"), func() (interface{}, error) {
+			return 1, nil
+		})
+		errCh <- err
+	}()
+	// Wait for the retry to wait for the first request.
+	time.Sleep(100 * time.Millisecond)
+	close(start)
+	// The retry waiting for the panicked request gets an error instead of hanging.
+	select {
+	case err := <-errCh:
+		c.Assert(errs.ErrIdempotencyRequestPanic.Equal(err), IsTrue)
+	case <-time.After(3 * time.Second):
+		c.Fatal("the retry hangs")
+	}
+	// The panicked request is not remembered.
+	v, err := cache.Do(context.Background(), "k1", []byte("a"), func() (interface{}, error) {
+		return 2, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 2)
+}
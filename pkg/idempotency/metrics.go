@@ -0,0 +1,37 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var duplicatedRequestCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "server",
+		Name:      "duplicated_requests_total",
+		Help:      "Counter of the retried requests which are answered by the results of the requests with the same idempotency keys.",
+	})
+
+var evictedRequestCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "server",
+		Name:      "evicted_idempotent_requests_total",
+		Help:      "Counter of the remembered requests which are evicted before they expire as the cache is full.",
+	})
+
+func init() {
+	prometheus.MustRegister(duplicatedRequestCounter)
+	prometheus.MustRegister(evictedRequestCounter)
+}
@@ -15,6 +15,8 @@ package logutil
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path"
@@ -294,22 +296,62 @@ func LogPanic() {
 	}
 }
 
+// RedactMode is the mode to redact the user data, such as the keys, in the log.
+type RedactMode string
+
+const (
+	// RedactModeOff does not redact the user data.
+	RedactModeOff RedactMode = "off"
+	// RedactModeOn replaces the user data with "?", it is the mode used when
+	// redact-info-log is enabled.
+	RedactModeOn RedactMode = "on"
+	// RedactModeMark wraps the user data with "‹" and "›", so that it can be
+	// located and removed before the log is shared.
+	RedactModeMark RedactMode = "mark"
+	// RedactModeHash replaces the user data with its hash, so that the logs of
+	// the same key can still be correlated.
+	RedactModeHash RedactMode = "hash"
+)
+
+// ParseRedactMode parses the redact mode from string.
+func ParseRedactMode(mode string) (RedactMode, error) {
+	switch m := RedactMode(strings.ToLower(mode)); m {
+	case RedactModeOff, RedactModeOn, RedactModeMark, RedactModeHash:
+		return m, nil
+	}
+	return RedactModeOff, errs.ErrInvalidRedactMode.FastGenByArgs(mode)
+}
+
 var (
-	enabledRedactLog atomic.Value
+	redactMode atomic.Value
 )
 
 func init() {
-	SetRedactLog(false)
+	SetRedactMode(RedactModeOff)
 }
 
 // IsRedactLogEnabled indicates whether the log desensitization is enabled
 func IsRedactLogEnabled() bool {
-	return enabledRedactLog.Load().(bool)
+	return GetRedactMode() != RedactModeOff
 }
 
 // SetRedactLog sets enabledRedactLog
 func SetRedactLog(enabled bool) {
-	enabledRedactLog.Store(enabled)
+	if enabled {
+		SetRedactMode(RedactModeOn)
+	} else {
+		SetRedactMode(RedactModeOff)
+	}
+}
+
+// GetRedactMode returns the current redact mode.
+func GetRedactMode() RedactMode {
+	return redactMode.Load().(RedactMode)
+}
+
+// SetRedactMode sets the redact mode, it can be changed at runtime.
+func SetRedactMode(mode RedactMode) {
+	redactMode.Store(mode)
 }
 
 // ZapRedactByteString receives []byte argument and return omitted information zap.Field if redact log enabled
@@ -329,28 +371,63 @@ func ZapRedactStringer(key string, arg fmt.Stringer) zap.Field {
 
 // RedactBytes receives []byte argument and return omitted information if redact log enabled
 func RedactBytes(arg []byte) []byte {
-	if IsRedactLogEnabled() {
+	switch GetRedactMode() {
+	case RedactModeOn:
 		return []byte("?")
+	case RedactModeMark:
+		return []byte(markRedacted(string(arg)))
+	case RedactModeHash:
+		return []byte(hashRedacted(arg))
 	}
 	return arg
 }
 
 // RedactString receives string argument and return omitted information if redact log enabled
 func RedactString(arg string) string {
-	if IsRedactLogEnabled() {
+	switch GetRedactMode() {
+	case RedactModeOn:
 		return "?"
+	case RedactModeMark:
+		return markRedacted(arg)
+	case RedactModeHash:
+		return hashRedacted([]byte(arg))
 	}
 	return arg
 }
 
 // RedactStringer receives stringer argument and return omitted information if redact log enabled
 func RedactStringer(arg fmt.Stringer) fmt.Stringer {
-	if IsRedactLogEnabled() {
+	switch mode := GetRedactMode(); mode {
+	case RedactModeOn:
 		return stringer{}
+	case RedactModeMark, RedactModeHash:
+		return redactedStringer{arg: arg, mode: mode}
 	}
 	return arg
 }
 
+// markRedacted wraps the data with the markers, the markers in the data are
+// doubled so that the data can be recovered.
+func markRedacted(arg string) string {
+	var b strings.Builder
+	b.Grow(len(arg) + 6)
+	b.WriteString("‹")
+	for _, c := range arg {
+		if c == '‹' || c == '›' {
+			b.WriteRune(c)
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString("›")
+	return b.String()
+}
+
+// hashRedacted returns the first 8 bytes of the SHA-256 of the data in hex.
+func hashRedacted(arg []byte) string {
+	sum := sha256.Sum256(arg)
+	return hex.EncodeToString(sum[:8])
+}
+
 type stringer struct {
 }
 
@@ -358,3 +435,18 @@ type stringer struct {
 func (s stringer) String() string {
 	return "?"
 }
+
+// redactedStringer redacts the string of the stringer lazily, so that the
+// String is not called if the log is not printed.
+type redactedStringer struct {
+	arg  fmt.Stringer
+	mode RedactMode
+}
+
+// String implement fmt.Stringer
+func (s redactedStringer) String() string {
+	if s.mode == RedactModeMark {
+		return markRedacted(s.arg.String())
+	}
+	return hashRedacted([]byte(s.arg.String()))
+}
@@ -162,3 +162,40 @@ func (s *testLogSuite) TestRedactLog(c *C) {
 		}
 	}
 }
+
+type testStringer string
+
+func (s testStringer) String() string {
+	return string(s)
+}
+
+func (s *testLogSuite) TestRedactLogMode(c *C) {
+	defer SetRedactMode(RedactModeOff)
+
+	_, err := ParseRedactMode("foo")
+	c.Assert(err, NotNil)
+	mode, err := ParseRedactMode("Hash")
+	c.Assert(err, IsNil)
+	c.Assert(mode, Equals, RedactModeHash)
+
+	SetRedactLog(true)
+	c.Assert(GetRedactMode(), Equals, RedactModeOn)
+	c.Assert(RedactStringer(testStringer("foo")).String(), Equals, "?")
+
+	SetRedactMode(RedactModeMark)
+	c.Assert(IsRedactLogEnabled(), IsTrue)
+	c.Assert(RedactString("foo"), Equals, "‹foo›")
+	c.Assert(RedactBytes([]byte("f‹o›o")), DeepEquals, []byte("‹f‹‹o››o›"))
+	c.Assert(RedactStringer(testStringer("foo")).String(), Equals, "‹foo›")
+
+	SetRedactMode(RedactModeHash)
+	hash := RedactString("foo")
+	c.Assert(hash, HasLen, 16)
+	c.Assert(hash, Not(Equals), RedactString("bar"))
+	c.Assert(string(RedactBytes([]byte("foo"))), Equals, hash)
+	c.Assert(RedactStringer(testStringer("foo")).String(), Equals, hash)
+
+	SetRedactLog(false)
+	c.Assert(IsRedactLogEnabled(), IsFalse)
+	c.Assert(RedactString("foo"), Equals, "foo")
+}
@@ -15,12 +15,14 @@ package metricutil
 
 import (
 	"os"
+	"strings"
 	"time"
 	"unicode"
 
 	"github.com/pingcap/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 )
@@ -32,6 +34,14 @@ type MetricConfig struct {
 	PushJob      string            `toml:"job" json:"job"`
 	PushAddress  string            `toml:"address" json:"address"`
 	PushInterval typeutil.Duration `toml:"interval" json:"interval"`
+	// PushPrefix is prepended to the names of the metrics pushed to Pushgateway.
+	PushPrefix string `toml:"prefix" json:"prefix"`
+	// PushFamilies are the name prefixes of the metric families pushed to
+	// Pushgateway. Empty means all the metric families are pushed.
+	PushFamilies []string `toml:"families" json:"families"`
+	// StatsD is the configuration of pushing metrics to StatsD. It works along
+	// with the Pushgateway push.
+	StatsD StatsDConfig `toml:"statsd" json:"statsd"`
 }
 
 func runesHasLowerNeighborAt(runes []rune, idx int) bool {
@@ -60,9 +70,9 @@ func camelCaseToSnakeCase(str string) string {
 }
 
 // prometheusPushClient pushes metrics to Prometheus Pushgateway.
-func prometheusPushClient(job, addr string, interval time.Duration) {
+func prometheusPushClient(job, addr string, interval time.Duration, gatherer prometheus.Gatherer) {
 	pusher := push.New(addr, job).
-		Gatherer(prometheus.DefaultGatherer).
+		Gatherer(gatherer).
 		Grouping("instance", instanceName())
 
 	for {
@@ -75,8 +85,11 @@ func prometheusPushClient(job, addr string, interval time.Duration) {
 	}
 }
 
-// Push metrics in background.
+// Push metrics in background. The metrics can be pushed to Pushgateway and
+// StatsD at the same time.
 func Push(cfg *MetricConfig) {
+	pushStatsD(&cfg.StatsD)
+
 	if cfg.PushInterval.Duration == zeroDuration || len(cfg.PushAddress) == 0 {
 		log.Info("disable Prometheus push client")
 		return
@@ -85,7 +98,56 @@ func Push(cfg *MetricConfig) {
 	log.Info("start Prometheus push client")
 
 	interval := cfg.PushInterval.Duration
-	go prometheusPushClient(cfg.PushJob, cfg.PushAddress, interval)
+	gatherer := newFilteredGatherer(prometheus.DefaultGatherer, cfg.PushFamilies, cfg.PushPrefix)
+	go prometheusPushClient(cfg.PushJob, cfg.PushAddress, interval, gatherer)
+}
+
+// filteredGatherer gathers the selected metric families and renames them with
+// the prefix.
+type filteredGatherer struct {
+	gatherer prometheus.Gatherer
+	families []string
+	prefix   string
+}
+
+func newFilteredGatherer(gatherer prometheus.Gatherer, families []string, prefix string) prometheus.Gatherer {
+	if len(families) == 0 && prefix == "" {
+		return gatherer
+	}
+	return &filteredGatherer{
+		gatherer: gatherer,
+		families: families,
+		prefix:   prefix,
+	}
+}
+
+// Gather implements prometheus.Gatherer.
+func (g *filteredGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := g.gatherer.Gather()
+	selected := make([]*dto.MetricFamily, 0, len(mfs))
+	for _, mf := range mfs {
+		if !g.isSelected(mf.GetName()) {
+			continue
+		}
+		if g.prefix != "" {
+			name := g.prefix + mf.GetName()
+			mf.Name = &name
+		}
+		selected = append(selected, mf)
+	}
+	return selected, err
+}
+
+func (g *filteredGatherer) isSelected(name string) bool {
+	if len(g.families) == 0 {
+		return true
+	}
+	for _, family := range g.families {
+		if strings.HasPrefix(name, family) {
+			return true
+		}
+	}
+	return false
 }
 
 func instanceName() string {
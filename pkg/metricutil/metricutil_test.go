@@ -14,10 +14,14 @@
 package metricutil
 
 import (
+	"net"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/typeutil"
 )
 
@@ -84,3 +88,66 @@ func (s *testMetricsSuite) TestCoverage(c *C) {
 		Push(cfg)
 	}
 }
+
+func (s *testMetricsSuite) TestFilteredGatherer(c *C) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewCounter(prometheus.CounterOpts{Name: "pd_a_total", Help: "a"}),
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "pd_b", Help: "b"}),
+		prometheus.NewGauge(prometheus.GaugeOpts{Name: "tikv_c", Help: "c"}),
+	)
+	gatherer := newFilteredGatherer(registry, []string{"pd_a", "tikv_"}, "x_")
+	mfs, err := gatherer.Gather()
+	c.Assert(err, IsNil)
+	names := make([]string, 0, len(mfs))
+	for _, mf := range mfs {
+		names = append(names, mf.GetName())
+	}
+	c.Assert(names, DeepEquals, []string{"x_pd_a_total", "x_tikv_c"})
+
+	c.Assert(newFilteredGatherer(registry, nil, ""), Equals, prometheus.Gatherer(registry))
+}
+
+func (s *testMetricsSuite) TestStatsD(c *C) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "pd_ops_total", Help: "ops"}, []string{"type"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "pd_region_count", Help: "regions"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "pd_duration_seconds", Help: "duration"})
+	registry.MustRegister(counter, gauge, histogram)
+
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+	client, err := newStatsDClient(server.LocalAddr().String(), "pd.", registry)
+	c.Assert(err, IsNil)
+
+	receive := func() []string {
+		buf := make([]byte, maxStatsDPacketSize)
+		c.Assert(server.SetReadDeadline(time.Now().Add(5*time.Second)), IsNil)
+		n, _, err := server.ReadFrom(buf)
+		c.Assert(err, IsNil)
+		lines := strings.Split(string(buf[:n]), "\n")
+		sort.Strings(lines)
+		return lines
+	}
+
+	counter.WithLabelValues("add-peer").Add(3)
+	gauge.Set(10)
+	histogram.Observe(0.5)
+	c.Assert(client.push(), IsNil)
+	c.Assert(receive(), DeepEquals, []string{
+		"pd.pd_duration_seconds.count:1|c",
+		"pd.pd_duration_seconds.sum:0.5|c",
+		"pd.pd_ops_total.add-peer:3|c",
+		"pd.pd_region_count:10|g",
+	})
+
+	// The counters are pushed as the increments.
+	counter.WithLabelValues("add-peer").Add(2)
+	gauge.Set(8)
+	c.Assert(client.push(), IsNil)
+	c.Assert(receive(), DeepEquals, []string{
+		"pd.pd_ops_total.add-peer:2|c",
+		"pd.pd_region_count:8|g",
+	})
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metricutil
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"go.uber.org/zap"
+)
+
+// maxStatsDPacketSize is the max size of a UDP packet sent to StatsD, which
+// avoids the IP fragmentation on the common networks.
+const maxStatsDPacketSize = 1432
+
+// StatsDConfig is the configuration of pushing metrics to StatsD.
+type StatsDConfig struct {
+	// Address is the UDP address of StatsD, empty means disabled.
+	Address  string            `toml:"address" json:"address"`
+	Interval typeutil.Duration `toml:"interval" json:"interval"`
+	// Prefix is prepended to the names of the metrics, e.g. "pd.".
+	Prefix string `toml:"prefix" json:"prefix"`
+	// Families are the name prefixes of the metric families pushed to StatsD.
+	// Empty means all the metric families are pushed.
+	Families []string `toml:"families" json:"families"`
+}
+
+// statsDClient converts the Prometheus metrics into the StatsD format and
+// pushes them. Since StatsD doesn't support labels, the label values are
+// appended to the metric name.
+type statsDClient struct {
+	gatherer prometheus.Gatherer
+	prefix   string
+	conn     net.Conn
+	// lastCounters records the last values of the counters, because StatsD
+	// expects the increments of counters.
+	lastCounters map[string]float64
+}
+
+func newStatsDClient(addr, prefix string, gatherer prometheus.Gatherer) (*statsDClient, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &statsDClient{
+		gatherer:     gatherer,
+		prefix:       prefix,
+		conn:         conn,
+		lastCounters: make(map[string]float64),
+	}, nil
+}
+
+func (c *statsDClient) push() error {
+	mfs, err := c.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	var packet bytes.Buffer
+	for _, line := range c.format(mfs) {
+		if packet.Len() > 0 && packet.Len()+len(line)+1 > maxStatsDPacketSize {
+			if _, err := c.conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = c.conn.Write(packet.Bytes())
+	}
+	return err
+}
+
+// format converts the metric families into StatsD lines. The counters are
+// pushed as the increments since the last push, the gauges are pushed as they
+// are, and the histograms and summaries are pushed as their sum and count.
+func (c *statsDClient) format(mfs []*dto.MetricFamily) []string {
+	var lines []string
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			name := c.metricName(mf.GetName(), m.GetLabel())
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				lines = c.appendCounter(lines, name, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				lines = appendGauge(lines, name, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				lines = appendGauge(lines, name, m.GetUntyped().GetValue())
+			case dto.MetricType_HISTOGRAM:
+				lines = c.appendCounter(lines, name+".sum", m.GetHistogram().GetSampleSum())
+				lines = c.appendCounter(lines, name+".count", float64(m.GetHistogram().GetSampleCount()))
+			case dto.MetricType_SUMMARY:
+				lines = c.appendCounter(lines, name+".sum", m.GetSummary().GetSampleSum())
+				lines = c.appendCounter(lines, name+".count", float64(m.GetSummary().GetSampleCount()))
+			}
+		}
+	}
+	return lines
+}
+
+func (c *statsDClient) appendCounter(lines []string, name string, value float64) []string {
+	last, ok := c.lastCounters[name]
+	c.lastCounters[name] = value
+	// The counter is reset, e.g. the collector is re-registered.
+	if !ok || value < last {
+		last = 0
+	}
+	if value == last {
+		return lines
+	}
+	return append(lines, name+":"+formatStatsDValue(value-last)+"|c")
+}
+
+func appendGauge(lines []string, name string, value float64) []string {
+	return append(lines, name+":"+formatStatsDValue(value)+"|g")
+}
+
+func (c *statsDClient) metricName(name string, labels []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(c.prefix)
+	b.WriteString(sanitizeStatsDName(name))
+	for _, label := range labels {
+		b.WriteByte('.')
+		b.WriteString(sanitizeStatsDName(label.GetValue()))
+	}
+	return b.String()
+}
+
+// sanitizeStatsDName replaces the characters which have special meanings in
+// the StatsD protocol.
+func sanitizeStatsDName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '\n', ' ', '.':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+func formatStatsDValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// statsDPushClient pushes metrics to StatsD periodically.
+func statsDPushClient(client *statsDClient, interval time.Duration) {
+	for {
+		if err := client.push(); err != nil {
+			log.Error("could not push metrics to StatsD", errs.ZapError(errs.ErrStatsDPushMetrics, err))
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func pushStatsD(cfg *StatsDConfig) {
+	if cfg.Interval.Duration == zeroDuration || len(cfg.Address) == 0 {
+		log.Info("disable StatsD push client")
+		return
+	}
+	gatherer := newFilteredGatherer(prometheus.DefaultGatherer, cfg.Families, "")
+	client, err := newStatsDClient(cfg.Address, cfg.Prefix, gatherer)
+	if err != nil {
+		log.Error("could not create StatsD client", zap.String("address", cfg.Address), errs.ZapError(errs.ErrStatsDPushMetrics, err))
+		return
+	}
+
+	log.Info("start StatsD push client")
+
+	go statsDPushClient(client, cfg.Interval.Duration)
+}
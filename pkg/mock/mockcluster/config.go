@@ -40,6 +40,11 @@ func (mc *Cluster) SetEnableOneWayMerge(v bool) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableOneWayMerge = v })
 }
 
+// SetEnableHotBucketSplit updates the EnableHotBucketSplit configuration.
+func (mc *Cluster) SetEnableHotBucketSplit(v bool) {
+	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.EnableHotBucketSplit = v })
+}
+
 // SetMaxSnapshotCount updates the MaxSnapshotCount configuration.
 func (mc *Cluster) SetMaxSnapshotCount(v int) {
 	mc.updateScheduleConfig(func(s *config.ScheduleConfig) { s.MaxSnapshotCount = uint64(v) })
@@ -133,6 +138,11 @@ func (mc *Cluster) SetIsolationLevel(v string) {
 	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.IsolationLevel = v })
 }
 
+// SetSnapshotLocalityLabel updates the SnapshotLocalityLabel configuration.
+func (mc *Cluster) SetSnapshotLocalityLabel(v string) {
+	mc.updateReplicationConfig(func(r *config.ReplicationConfig) { r.SnapshotLocalityLabel = v })
+}
+
 func (mc *Cluster) updateScheduleConfig(f func(*config.ScheduleConfig)) {
 	s := mc.GetScheduleConfig().Clone()
 	f(s)
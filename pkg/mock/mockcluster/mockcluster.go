@@ -49,6 +49,7 @@ type Cluster struct {
 	*config.PersistOptions
 	ID               uint64
 	suspectRegions   map[uint64]struct{}
+	pinnedRegions    *core.PinnedRegions
 	disabledFeatures map[versioninfo.Feature]struct{}
 }
 
@@ -61,6 +62,7 @@ func NewCluster(ctx context.Context, opts *config.PersistOptions) *Cluster {
 		HotStat:          statistics.NewHotStat(ctx, mockQuit),
 		PersistOptions:   opts,
 		suspectRegions:   map[uint64]struct{}{},
+		pinnedRegions:    core.NewPinnedRegions(),
 		disabledFeatures: make(map[versioninfo.Feature]struct{}),
 	}
 	if clus.PersistOptions.GetReplicationConfig().EnablePlacementRules {
@@ -111,6 +113,21 @@ func (mc *Cluster) IsRegionHot(region *core.RegionInfo) bool {
 	return mc.HotCache.IsRegionHot(region, mc.GetHotRegionCacheHitsThreshold())
 }
 
+// IsRegionPinned checks if the region is pinned to be exempted from balance.
+func (mc *Cluster) IsRegionPinned(region *core.RegionInfo) bool {
+	return mc.pinnedRegions.IsPinned(region)
+}
+
+// PinRegion pins the region to be exempted from balance.
+func (mc *Cluster) PinRegion(regionID uint64, ttl time.Duration) {
+	mc.pinnedRegions.PinRegion(regionID, ttl)
+}
+
+// PinKeyRange pins the regions in the key range to be exempted from balance.
+func (mc *Cluster) PinKeyRange(startKey, endKey []byte, ttl time.Duration) {
+	mc.pinnedRegions.PinKeyRange(startKey, endKey, ttl)
+}
+
 // RegionReadStats returns hot region's read stats.
 // The result only includes peers that are hot enough.
 func (mc *Cluster) RegionReadStats() map[uint64][]*statistics.HotPeerStat {
@@ -118,6 +135,11 @@ func (mc *Cluster) RegionReadStats() map[uint64][]*statistics.HotPeerStat {
 	return mc.HotCache.RegionStats(statistics.ReadFlow, mc.GetHotRegionCacheHitsThreshold())
 }
 
+// RegionBucketsStat returns the bucket statistics of the region.
+func (mc *Cluster) RegionBucketsStat(region *core.RegionInfo) *statistics.BucketsStat {
+	return mc.HotStat.Buckets.Get(region)
+}
+
 // RegionWriteStats returns hot region's write stats.
 // The result only includes peers that are hot enough.
 func (mc *Cluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
@@ -529,6 +551,19 @@ func (mc *Cluster) UpdateStorageWrittenStats(storeID, bytesWritten, keysWritten
 	mc.PutStore(newStore)
 }
 
+// UpdateStoreCPUUsage updates the CPU usage reported by the store.
+func (mc *Cluster) UpdateStoreCPUUsage(storeID uint64, usage uint64) {
+	store := mc.GetStore(storeID)
+	newStats := proto.Clone(store.GetStoreStats()).(*pdpb.StoreStats)
+	newStats.CpuUsages = []*pdpb.RecordPair{{Key: "cpu", Value: usage}}
+	now := time.Now().Second()
+	interval := &pdpb.TimeInterval{StartTimestamp: uint64(now - statistics.StoreHeartBeatReportInterval), EndTimestamp: uint64(now)}
+	newStats.Interval = interval
+	newStore := store.Clone(core.SetStoreStats(newStats))
+	mc.Set(storeID, newStats)
+	mc.PutStore(newStore)
+}
+
 // UpdateStorageReadStats updates store written bytes.
 func (mc *Cluster) UpdateStorageReadStats(storeID, bytesRead, keysRead uint64) {
 	store := mc.GetStore(storeID)
@@ -34,3 +34,21 @@ func (alloc *IDAllocator) Alloc() (uint64, error) {
 func (alloc *IDAllocator) Rebase() error {
 	return nil
 }
+
+// EnsureAbove implements the IDAllocator interface.
+func (alloc *IDAllocator) EnsureAbove(id uint64) (bool, error) {
+	for {
+		base := atomic.LoadUint64(&alloc.base)
+		if base >= id {
+			return false, nil
+		}
+		if atomic.CompareAndSwapUint64(&alloc.base, base, id) {
+			return true, nil
+		}
+	}
+}
+
+// EnsureAboveAsync implements the IDAllocator interface.
+func (alloc *IDAllocator) EnsureAboveAsync(observe func() uint64) {
+	alloc.EnsureAbove(observe())
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceutil
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+)
+
+// TraceConfig is the tracing configuration.
+type TraceConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// ExporterEndpoint is the address of the jaeger agent which the spans are reported to, e.g. "127.0.0.1:6831".
+	ExporterEndpoint string `toml:"exporter-endpoint" json:"exporter-endpoint"`
+	// SamplingRate is the probability that a trace started by PD is sampled. Traces propagated
+	// from the other components follow the sampling decision of their parents.
+	SamplingRate float64 `toml:"sampling-rate" json:"sampling-rate"`
+}
+
+// InitGlobalTracer sets the global tracer according to the config. The returned closer
+// flushes the buffered spans, it is nil if the tracing is disabled.
+func InitGlobalTracer(cfg *TraceConfig, serviceName string) (io.Closer, error) {
+	if !cfg.Enable {
+		return nil, nil
+	}
+	jcfg := jaegercfg.Configuration{
+		ServiceName: serviceName,
+		Sampler: &jaegercfg.SamplerConfig{
+			Type:  jaeger.SamplerTypeProbabilistic,
+			Param: cfg.SamplingRate,
+		},
+		Reporter: &jaegercfg.ReporterConfig{
+			LocalAgentHostPort: cfg.ExporterEndpoint,
+		},
+	}
+	tracer, closer, err := jcfg.NewTracer()
+	if err != nil {
+		return nil, errs.ErrInitTracer.Wrap(err).GenWithStackByCause()
+	}
+	opentracing.SetGlobalTracer(tracer)
+	log.Info("tracing is enabled",
+		zap.String("exporter-endpoint", cfg.ExporterEndpoint),
+		zap.Float64("sampling-rate", cfg.SamplingRate))
+	return closer, nil
+}
+
+// metadataCarrier makes the gRPC metadata satisfy the opentracing TextMap carrier.
+type metadataCarrier metadata.MD
+
+// Set implements opentracing.TextMapWriter.
+func (c metadataCarrier) Set(key, val string) {
+	key = strings.ToLower(key)
+	c[key] = append(c[key], val)
+}
+
+// ForeachKey implements opentracing.TextMapReader.
+func (c metadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, vs := range c {
+		for _, v := range vs {
+			if err := handler(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StartSpanFromContext starts a span as the child of the span in the context, or the
+// span carried by the incoming gRPC metadata if the context doesn't contain one.
+func StartSpanFromContext(ctx context.Context, operationName string) (opentracing.Span, context.Context) {
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		return opentracing.StartSpanFromContext(ctx, operationName)
+	}
+	tracer := opentracing.GlobalTracer()
+	var opts []opentracing.StartSpanOption
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if spanCtx, err := tracer.Extract(opentracing.TextMap, metadataCarrier(md)); err == nil {
+			opts = append(opts, opentracing.ChildOf(spanCtx))
+		}
+	}
+	span := tracer.StartSpan(operationName, opts...)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
+
+// InjectToOutgoingContext injects the span in the context into the outgoing gRPC
+// metadata, so that the server can continue the trace.
+func InjectToOutgoingContext(ctx context.Context) context.Context {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, metadataCarrier(md)); err != nil {
+		return ctx
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	. "github.com/pingcap/check"
+	"google.golang.org/grpc/metadata"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTraceSuite{})
+
+type testTraceSuite struct{}
+
+func (s *testTraceSuite) TestPropagateThroughMetadata(c *C) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	parent := tracer.StartSpan("client")
+	ctx := opentracing.ContextWithSpan(context.Background(), parent)
+	ctx = InjectToOutgoingContext(ctx)
+	md, ok := metadata.FromOutgoingContext(ctx)
+	c.Assert(ok, IsTrue)
+
+	// The server side only sees the metadata.
+	serverCtx := metadata.NewIncomingContext(context.Background(), md)
+	span, spanCtx := StartSpanFromContext(serverCtx, "server")
+	c.Assert(opentracing.SpanFromContext(spanCtx), Equals, span)
+	span.Finish()
+	parent.Finish()
+
+	spans := tracer.FinishedSpans()
+	c.Assert(spans, HasLen, 2)
+	c.Assert(spans[0].OperationName, Equals, "server")
+	c.Assert(spans[0].ParentID, Equals, parent.Context().(mocktracer.MockSpanContext).SpanID)
+	c.Assert(spans[0].SpanContext.TraceID, Equals, parent.Context().(mocktracer.MockSpanContext).TraceID)
+}
+
+func (s *testTraceSuite) TestDisabled(c *C) {
+	closer, err := InitGlobalTracer(&TraceConfig{}, "pd")
+	c.Assert(err, IsNil)
+	c.Assert(closer, IsNil)
+}
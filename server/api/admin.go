@@ -14,13 +14,17 @@
 package api
 
 import (
+	"encoding/hex"
 	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
 	"github.com/unrolled/render"
 )
 
@@ -94,6 +98,94 @@ func (h *adminHandler) ResetTS(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, "Reset ts successfully.")
 }
 
+// RegionMetaInput is the metadata of a region to be registered.
+type RegionMetaInput struct {
+	ID            uint64              `json:"id"`
+	StartKey      string              `json:"start_key"`
+	EndKey        string              `json:"end_key"`
+	RegionEpoch   *metapb.RegionEpoch `json:"region_epoch"`
+	Peers         []*metapb.Peer      `json:"peers"`
+	LeaderStoreID uint64              `json:"leader_store_id,omitempty"`
+}
+
+func (input *RegionMetaInput) toRegionInfo() (*core.RegionInfo, error) {
+	startKey, err := hex.DecodeString(input.StartKey)
+	if err != nil {
+		return nil, errors.Errorf("start key of region %d is not in hex format", input.ID)
+	}
+	endKey, err := hex.DecodeString(input.EndKey)
+	if err != nil {
+		return nil, errors.Errorf("end key of region %d is not in hex format", input.ID)
+	}
+	meta := &metapb.Region{
+		Id:          input.ID,
+		StartKey:    startKey,
+		EndKey:      endKey,
+		RegionEpoch: input.RegionEpoch,
+		Peers:       input.Peers,
+	}
+	var leader *metapb.Peer
+	if input.LeaderStoreID != 0 {
+		for _, peer := range input.Peers {
+			if peer.GetStoreId() == input.LeaderStoreID {
+				leader = peer
+				break
+			}
+		}
+		if leader == nil {
+			return nil, errors.Errorf("leader store %d of region %d has no peer", input.LeaderStoreID, input.ID)
+		}
+	}
+	return core.NewRegionInfo(meta, leader), nil
+}
+
+// @Tags admin
+// @Summary Register the metadata of a batch of regions directly, which is used by the restore tools to warm up the region routing. The whole batch is rejected if any region is invalid, overlaps with others or is stale.
+// @Accept json
+// @Param body body []RegionMetaInput true "The metadata of the regions"
+// @Produce json
+// @Success 200 {object} string "The regions are registered, with the regions which failed to be registered."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /admin/regions [post]
+func (h *adminHandler) BatchPutRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var inputs []*RegionMetaInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &inputs); err != nil {
+		return
+	}
+	if len(inputs) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "no region is specified")
+		return
+	}
+	regions := make([]*core.RegionInfo, 0, len(inputs))
+	for _, input := range inputs {
+		region, err := input.toRegionInfo()
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		regions = append(regions, region)
+	}
+	failures, err := rc.HandleBatchPutRegions(regions)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res := struct {
+		Count    int               `json:"count"`
+		Failures map[uint64]string `json:"failures,omitempty"`
+	}{
+		Count: len(regions) - len(failures),
+	}
+	if len(failures) > 0 {
+		res.Failures = make(map[uint64]string, len(failures))
+		for id, err := range failures {
+			res.Failures[id] = err.Error()
+		}
+	}
+	h.rd.JSON(w, http.StatusOK, &res)
+}
+
 // Intentionally no swagger mark as it is supposed to be only used in
 // server-to-server.
 func (h *adminHandler) persistFile(w http.ResponseWriter, r *http.Request) {
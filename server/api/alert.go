@@ -0,0 +1,60 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/tso"
+	"github.com/unrolled/render"
+)
+
+type alertHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newAlertHandler(svr *server.Server, rd *render.Render) *alertHandler {
+	return &alertHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags alert
+// @Summary Get the recent alerts of the PD server, e.g. failing to update the TSO or the insufficient disk space. The latest one comes first.
+// @Param severity query string false "Only return the alerts with the severity" Enums(warning, critical)
+// @Produce json
+// @Success 200 {array} tso.Alert
+// @Failure 400 {string} string "The input is invalid."
+// @Router /alerts [get]
+func (h *alertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	severity := tso.AlertSeverity(r.URL.Query().Get("severity"))
+	if severity != "" && severity != tso.AlertWarning && severity != tso.AlertCritical {
+		h.rd.JSON(w, http.StatusBadRequest, "invalid severity")
+		return
+	}
+	alerts := h.svr.GetAlerts()
+	if severity != "" {
+		filtered := alerts[:0]
+		for _, alert := range alerts {
+			if alert.Severity == severity {
+				filtered = append(filtered, alert)
+			}
+		}
+		alerts = filtered
+	}
+	h.rd.JSON(w, http.StatusOK, alerts)
+}
@@ -14,12 +14,41 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/versioninfo"
 	"github.com/unrolled/render"
+	"go.uber.org/zap"
 )
 
+// The kinds of the records in the cluster dump.
+const (
+	DumpKindConfig    = "config"
+	DumpKindStore     = "store"
+	DumpKindRule      = "rule"
+	DumpKindScheduler = "scheduler"
+	DumpKindOperator  = "operator"
+	DumpKindRegion    = "region"
+	// DumpKindEnd marks the end of the dump, the data is the count of the
+	// records of each kind. A dump without it is incomplete.
+	DumpKindEnd = "end"
+)
+
+// dumpRegionBatch is the number of regions scanned and flushed at a time.
+const dumpRegionBatch = 1024
+
+// DumpRecord is a record of the cluster dump. The dump is streamed as
+// newline-delimited JSON, one record per line.
+type DumpRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
 type clusterHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -55,3 +84,129 @@ func (h *clusterHandler) GetClusterStatus(w http.ResponseWriter, r *http.Request
 	}
 	h.rd.JSON(w, http.StatusOK, status)
 }
+
+// ClusterFeatures is the cluster version and the feature gates enabled by it.
+type ClusterFeatures struct {
+	ClusterVersion string          `json:"cluster_version"`
+	Features       map[string]bool `json:"features"`
+}
+
+// @Tags cluster
+// @Summary Get the cluster version and whether each feature gate is enabled by it.
+// @Produce json
+// @Success 200 {object} ClusterFeatures
+// @Router /cluster/features [get]
+func (h *clusterHandler) GetClusterFeatures(w http.ResponseWriter, r *http.Request) {
+	clusterVersion := h.svr.GetClusterVersion()
+	h.rd.JSON(w, http.StatusOK, &ClusterFeatures{
+		ClusterVersion: clusterVersion.String(),
+		Features:       versioninfo.FeatureGates(clusterVersion),
+	})
+}
+
+// @Tags cluster
+// @Summary Dump the scheduling state of the cluster for offline analysis, including the config, stores, placement rules, schedulers, operators and regions. The records are streamed as newline-delimited JSON.
+// @Param sample query integer false "Only dump one of every sample regions" default(1)
+// @Produce json
+// @Success 200 {array} DumpRecord
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /cluster/dump [get]
+func (h *clusterHandler) Dump(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	sample := 1
+	if sampleStr := r.URL.Query().Get("sample"); sampleStr != "" {
+		var err error
+		sample, err = strconv.Atoi(sampleStr)
+		if err != nil || sample < 1 {
+			h.rd.JSON(w, http.StatusBadRequest, "sample should be a positive integer")
+			return
+		}
+	}
+	handler := h.svr.GetHandler()
+	schedulers, err := handler.GetSchedulers()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	operators, err := handler.GetOperators()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	counts := make(map[string]int)
+	write := func(kind string, data interface{}) bool {
+		raw, err := json.Marshal(data)
+		if err == nil {
+			err = enc.Encode(&DumpRecord{Kind: kind, Data: raw})
+		}
+		if err != nil {
+			// The status has been sent, so the client detects the failure by
+			// the missing end record.
+			log.Error("failed to dump the cluster", zap.String("kind", kind), errs.ZapError(err))
+			return false
+		}
+		counts[kind]++
+		return true
+	}
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	if !write(DumpKindConfig, h.svr.GetConfig()) {
+		return
+	}
+	opt := h.svr.GetPersistOptions()
+	for _, store := range rc.GetStores() {
+		if !write(DumpKindStore, newStoreInfo(opt, store)) {
+			return
+		}
+	}
+	if rc.GetOpts().IsPlacementRulesEnabled() {
+		for _, rule := range rc.GetRuleManager().GetAllRules() {
+			if !write(DumpKindRule, rule) {
+				return
+			}
+		}
+	}
+	for _, scheduler := range schedulers {
+		if !write(DumpKindScheduler, scheduler) {
+			return
+		}
+	}
+	for _, op := range operators {
+		if !write(DumpKindOperator, op) {
+			return
+		}
+	}
+	flush()
+
+	var (
+		startKey []byte
+		count    int
+	)
+	for {
+		regions := rc.ScanRegions(startKey, nil, dumpRegionBatch)
+		for _, region := range regions {
+			if count%sample == 0 && !write(DumpKindRegion, NewRegionInfo(region)) {
+				return
+			}
+			count++
+		}
+		flush()
+		if len(regions) < dumpRegionBatch {
+			break
+		}
+		startKey = regions[len(regions)-1].GetEndKey()
+		if len(startKey) == 0 {
+			break
+		}
+	}
+	write(DumpKindEnd, counts)
+}
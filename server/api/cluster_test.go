@@ -14,7 +14,10 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -22,6 +25,7 @@ import (
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/versioninfo"
 )
 
 var _ = Suite(&testClusterSuite{})
@@ -72,6 +76,35 @@ func (s *testClusterSuite) TestCluster(c *C) {
 	c.Assert(int(r.MaxReplicas), Equals, s.svr.GetRaftCluster().GetRuleManager().GetRule("pd", "default").Count)
 }
 
+func (s *testClusterSuite) TestClusterFeatures(c *C) {
+	url := fmt.Sprintf("%s/cluster/features", s.urlPrefix)
+	c.Assert(s.svr.SetClusterVersion("4.0.0"), IsNil)
+	features := &ClusterFeatures{}
+	c.Assert(readJSON(testDialClient, url, features), IsNil)
+	c.Assert(features.ClusterVersion, Equals, "4.0.0")
+	c.Assert(features.Features, DeepEquals, map[string]bool{
+		"region-merge":    true,
+		"batch-split":     true,
+		"joint-consensus": false,
+	})
+
+	c.Assert(s.svr.SetClusterVersion("5.0.0"), IsNil)
+	c.Assert(readJSON(testDialClient, url, features), IsNil)
+	c.Assert(features.ClusterVersion, Equals, "5.0.0")
+	c.Assert(features.Features["joint-consensus"], IsTrue)
+}
+
+func (s *testClusterSuite) TestServiceVersions(c *C) {
+	url := fmt.Sprintf("%s/version/services", s.urlPrefix)
+	c.Assert(s.svr.SetClusterVersion("4.0.0"), IsNil)
+	versions := &ServiceVersions{}
+	c.Assert(readJSON(testDialClient, url, versions), IsNil)
+	c.Assert(versions.Version, Equals, versioninfo.PDReleaseVersion)
+	c.Assert(versions.ClusterVersion, Equals, "4.0.0")
+	c.Assert(versions.Features, DeepEquals, versioninfo.FeatureGates(*versioninfo.MustParseVersion("4.0.0")))
+	c.Assert(versions.APICompatibilityLevel, Equals, versioninfo.APICompatibilityLevel)
+}
+
 func (s *testClusterSuite) testGetClusterStatus(c *C) {
 	url := fmt.Sprintf("%s/cluster/status", s.urlPrefix)
 	status := cluster.Status{}
@@ -91,3 +124,75 @@ func (s *testClusterSuite) testGetClusterStatus(c *C) {
 	c.Assert(status.RaftBootstrapTime.After(now), IsTrue)
 	c.Assert(status.IsInitialized, IsTrue)
 }
+
+var _ = Suite(&testClusterDumpSuite{})
+
+type testClusterDumpSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testClusterDumpSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testClusterDumpSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testClusterDumpSuite) readDump(c *C, url string) map[string][]json.RawMessage {
+	resp, err := testDialClient.Get(url)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	records := make(map[string][]json.RawMessage)
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var record DumpRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		records[record.Kind] = append(records[record.Kind], record.Data)
+	}
+	return records
+}
+
+func (s *testClusterDumpSuite) TestDump(c *C) {
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(10, 1, []byte(""), []byte("b")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(11, 1, []byte("b"), []byte("d")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(12, 1, []byte("d"), []byte("")))
+	schedulers, err := s.svr.GetHandler().GetSchedulers()
+	c.Assert(err, IsNil)
+
+	records := s.readDump(c, s.urlPrefix+"/cluster/dump")
+	c.Assert(records[DumpKindConfig], HasLen, 1)
+	cfg := &config.Config{}
+	c.Assert(json.Unmarshal(records[DumpKindConfig][0], cfg), IsNil)
+	c.Assert(cfg.ClusterVersion, Equals, s.svr.GetConfig().ClusterVersion)
+	c.Assert(records[DumpKindStore], HasLen, 1)
+	c.Assert(records[DumpKindScheduler], HasLen, len(schedulers))
+	c.Assert(records[DumpKindRegion], HasLen, 3)
+	region := &RegionInfo{}
+	c.Assert(json.Unmarshal(records[DumpKindRegion][1], region), IsNil)
+	c.Assert(region.ID, Equals, uint64(11))
+	c.Assert(records[DumpKindEnd], HasLen, 1)
+	counts := make(map[string]int)
+	c.Assert(json.Unmarshal(records[DumpKindEnd][0], &counts), IsNil)
+	c.Assert(counts[DumpKindRegion], Equals, 3)
+
+	// Only one of every 2 regions is dumped.
+	records = s.readDump(c, s.urlPrefix+"/cluster/dump?sample=2")
+	c.Assert(records[DumpKindRegion], HasLen, 2)
+	c.Assert(json.Unmarshal(records[DumpKindRegion][1], region), IsNil)
+	c.Assert(region.ID, Equals, uint64(12))
+
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/cluster/dump?sample=0", nil), NotNil)
+}
@@ -20,6 +20,7 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -71,6 +72,115 @@ func (h *confHandler) GetDefault(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, config)
 }
 
+// configDiffItem shows the values of a config item in different places.
+type configDiffItem struct {
+	Key       string      `json:"key"`
+	Default   interface{} `json:"default,omitempty"`
+	Persisted interface{} `json:"persisted,omitempty"`
+	Effective interface{} `json:"effective,omitempty"`
+}
+
+// @Tags config
+// @Summary Get the config items whose default, persisted or effective values are different.
+// @Produce json
+// @Success 200 {array} configDiffItem
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/diff [get]
+func (h *confHandler) GetDiff(w http.ResponseWriter, r *http.Request) {
+	defaultCfg := config.NewConfig()
+	if err := defaultCfg.Adjust(nil, false); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defaults, err := flattenConfig(defaultCfg)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	persistedCfg, err := h.svr.GetPersistedConfig()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	persisted, err := flattenConfig(persistedCfg)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	effective, err := flattenConfig(h.svr.GetConfig())
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, diffConfig(defaults, persisted, effective))
+}
+
+// flattenConfig converts the config to a map whose keys are the json paths of
+// the config items, such as "schedule.leader-schedule-limit".
+func flattenConfig(cfg interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	items := make(map[string]interface{})
+	flattenConfigMap(items, "", m)
+	return items, nil
+}
+
+func flattenConfigMap(items map[string]interface{}, prefix string, m map[string]interface{}) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok && len(sub) > 0 {
+			flattenConfigMap(items, key, sub)
+			continue
+		}
+		items[key] = v
+	}
+}
+
+// diffConfig returns the items whose values are different among the places
+// they appear, ordered by key.
+func diffConfig(defaults, persisted, effective map[string]interface{}) []configDiffItem {
+	keys := make(map[string]struct{})
+	for _, m := range []map[string]interface{}{defaults, persisted, effective} {
+		for k := range m {
+			keys[k] = struct{}{}
+		}
+	}
+	items := make([]configDiffItem, 0)
+	for k := range keys {
+		var values []interface{}
+		for _, m := range []map[string]interface{}{defaults, persisted, effective} {
+			if v, ok := m[k]; ok {
+				values = append(values, v)
+			}
+		}
+		if len(values) < 2 {
+			continue
+		}
+		for _, v := range values[1:] {
+			if !reflect.DeepEqual(v, values[0]) {
+				items = append(items, configDiffItem{
+					Key:       k,
+					Default:   defaults[k],
+					Persisted: persisted[k],
+					Effective: effective[k],
+				})
+				break
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items
+}
+
 // FIXME: details of input json body params
 // @Tags config
 // @Summary Update a config item.
@@ -156,6 +266,8 @@ func (h *confHandler) updateConfig(cfg *config.Config, key string, value interfa
 		return h.updatePDServerConfig(cfg, kp[len(kp)-1], value)
 	case "log":
 		return h.updateLogLevel(kp, value)
+	case "security":
+		return h.updateRedactInfoLogMode(kp, value)
 	case "cluster-version":
 		return h.updateClusterVersion(value)
 	case "label-property": // TODO: support changing label-property
@@ -287,6 +399,16 @@ func (h *confHandler) updateLogLevel(kp []string, value interface{}) error {
 	return errors.Errorf("input value %v is illegal", value)
 }
 
+func (h *confHandler) updateRedactInfoLogMode(kp []string, value interface{}) error {
+	if len(kp) != 2 || kp[1] != "redact-info-log-mode" {
+		return errors.Errorf("only support changing redact-info-log-mode")
+	}
+	if mode, ok := value.(string); ok {
+		return h.svr.SetRedactInfoLogMode(mode)
+	}
+	return errors.Errorf("input value %v is illegal", value)
+}
+
 func (h *confHandler) updateClusterVersion(value interface{}) error {
 	if version, ok := value.(string); ok {
 		err := h.svr.SetClusterVersion(version)
@@ -367,6 +489,51 @@ func (h *confHandler) SetSchedule(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, "The config is updated.")
 }
 
+// @Tags config
+// @Summary Override a schedule config item temporarily, e.g. raise max-store-down-time for a maintenance window. The override is persisted with the ttl, and the config item is restored automatically when the override expires.
+// @Param key query string true "The schedule config item, e.g. max-store-down-time."
+// @Param value query string true "The value to override with."
+// @Param ttl query string true "How long the override lasts, e.g. 3h."
+// @Produce json
+// @Success 200 {string} string "The config is overridden."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/schedule/override [post]
+func (h *confHandler) OverrideSchedule(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key, value := query.Get("key"), query.Get("value")
+	if !strings.HasPrefix(key, "schedule.") {
+		key = "schedule." + key
+	}
+	if err := config.ValidateTTLConfig(key, value); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ttl, err := time.ParseDuration(query.Get("ttl"))
+	if err != nil || ttl < time.Second {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid ttl %q, it should be at least 1s", query.Get("ttl")))
+		return
+	}
+	if err := h.svr.SaveTTLConfig(map[string]interface{}{key: value}, ttl); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The config is overridden.")
+}
+
+// @Tags config
+// @Summary Get the config items which are overridden temporarily and their expiration time.
+// @Produce json
+// @Success 200 {array} config.TTLConfigItem
+// @Router /config/schedule/override [get]
+func (h *confHandler) GetScheduleOverrides(w http.ResponseWriter, r *http.Request) {
+	items := h.svr.GetPersistOptions().GetTTLConfigs()
+	if items == nil {
+		items = []*config.TTLConfigItem{}
+	}
+	h.rd.JSON(w, http.StatusOK, items)
+}
+
 // @Tags config
 // @Summary Get replication config.
 // @Produce json
@@ -0,0 +1,150 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+	"github.com/unrolled/render"
+)
+
+type profileHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newProfileHandler(svr *server.Server, rd *render.Render) *profileHandler {
+	return &profileHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags config
+// @Summary List the builtin and custom schedule profiles.
+// @Produce json
+// @Success 200 {array} config.ScheduleProfile
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/profiles [get]
+func (h *profileHandler) List(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.svr.GetScheduleProfiles()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, profiles)
+}
+
+// @Tags config
+// @Summary Get a schedule profile by name.
+// @Param name path string true "The name of the profile"
+// @Produce json
+// @Success 200 {object} config.ScheduleProfile
+// @Failure 404 {string} string "The profile does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/profile/{name} [get]
+func (h *profileHandler) Get(w http.ResponseWriter, r *http.Request) {
+	profile, err := h.svr.GetScheduleProfile(mux.Vars(r)["name"])
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, profile)
+}
+
+// @Tags config
+// @Summary Create or update a custom schedule profile. The builtin profiles cannot be changed.
+// @Accept json
+// @Param body body config.ScheduleProfile true "The profile, e.g. {\"name\": \"night\", \"items\": {\"region-schedule-limit\": 4096}}"
+// @Produce json
+// @Success 200 {string} string "The profile is saved."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/profile [post]
+func (h *profileHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var profile config.ScheduleProfile
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &profile); err != nil {
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.svr.SaveScheduleProfile(&profile); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The profile is saved.")
+}
+
+// @Tags config
+// @Summary Delete a custom schedule profile.
+// @Param name path string true "The name of the profile"
+// @Produce json
+// @Success 200 {string} string "The profile is deleted."
+// @Failure 400 {string} string "The profile is builtin."
+// @Failure 404 {string} string "The profile does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/profile/{name} [delete]
+func (h *profileHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.svr.DeleteScheduleProfile(mux.Vars(r)["name"]); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The profile is deleted.")
+}
+
+// @Tags config
+// @Summary Apply all the items of a schedule profile to the schedule config at once.
+// @Param name path string true "The name of the profile"
+// @Produce json
+// @Success 200 {string} string "The profile is applied."
+// @Failure 400 {string} string "The profile conflicts with the current config."
+// @Failure 404 {string} string "The profile does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/profile/{name}/apply [post]
+func (h *profileHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	profile, err := h.svr.GetScheduleProfile(name)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	// Check in advance so that an invalid profile is reported as a bad request.
+	if err := profile.ApplyTo(h.svr.GetScheduleConfig()); err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.svr.ApplyScheduleProfile(name); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The profile is applied.")
+}
+
+func (h *profileHandler) respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errs.ErrProfileNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+	case errs.ErrProfileBuiltin.Equal(err):
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/config"
+)
+
+var _ = Suite(&testProfileSuite{})
+
+type testProfileSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testProfileSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+}
+
+func (s *testProfileSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testProfileSuite) TestProfile(c *C) {
+	var profiles []*config.ScheduleProfile
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/profiles", &profiles), IsNil)
+	c.Assert(profiles, HasLen, 4)
+
+	// Apply a builtin profile.
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile/maintenance/apply", nil), IsNil)
+	sc := &config.ScheduleConfig{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/schedule", sc), IsNil)
+	c.Assert(sc.Profile, Equals, config.MaintenanceProfile)
+	c.Assert(sc.RegionScheduleLimit, Equals, uint64(0))
+	c.Assert(sc.MergeScheduleLimit, Equals, uint64(0))
+	c.Assert(sc.EnableLocationReplacement, IsFalse)
+
+	// Save and apply a custom profile.
+	profile := &config.ScheduleProfile{
+		Name:  "night",
+		Items: map[string]interface{}{"region-schedule-limit": 4096, "leader-schedule-limit": 16},
+	}
+	data, err := json.Marshal(profile)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile", data), IsNil)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/profiles", &profiles), IsNil)
+	c.Assert(profiles, HasLen, 5)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile/night/apply", nil), IsNil)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/schedule", sc), IsNil)
+	c.Assert(sc.Profile, Equals, "night")
+	c.Assert(sc.RegionScheduleLimit, Equals, uint64(4096))
+	c.Assert(sc.LeaderScheduleLimit, Equals, uint64(16))
+	// The items not in the profile are kept.
+	c.Assert(sc.EnableLocationReplacement, IsFalse)
+
+	// The invalid profiles are rejected.
+	for _, p := range []*config.ScheduleProfile{
+		{Name: "default", Items: map[string]interface{}{"region-schedule-limit": 1}},
+		{Name: "bad/name", Items: map[string]interface{}{"region-schedule-limit": 1}},
+		{Name: "empty"},
+		{Name: "unknown", Items: map[string]interface{}{"unknown-item": 1}},
+		{Name: "schedulers", Items: map[string]interface{}{"schedulers-v2": nil}},
+	} {
+		data, err = json.Marshal(p)
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile", data), NotNil)
+	}
+
+	// Switch back to the default profile.
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile/default/apply", nil), IsNil)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/config/schedule", sc), IsNil)
+	c.Assert(sc.Profile, Equals, config.DefaultProfile)
+	c.Assert(sc.RegionScheduleLimit, Equals, config.NewTestOptions().GetScheduleConfig().RegionScheduleLimit)
+	c.Assert(sc.EnableLocationReplacement, IsTrue)
+
+	// Delete the custom profile.
+	res, err := doDelete(testDialClient, s.urlPrefix+"/config/profile/night")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	res, err = doDelete(testDialClient, s.urlPrefix+"/config/profile/night")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	res, err = doDelete(testDialClient, s.urlPrefix+"/config/profile/default")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/config/profile/night/apply", nil), NotNil)
+}
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
@@ -308,6 +309,27 @@ func assertTTLConfig(c *C, options *config.PersistOptions, checker Checker) {
 	c.Assert(options.GetMergeScheduleLimit(), checker, uint64(999))
 }
 
+func (s *testConfigSuite) TestConfigDiff(c *C) {
+	addr := fmt.Sprintf("%s/config", s.urlPrefix)
+	postData, err := json.Marshal(map[string]interface{}{"schedule.max-snapshot-count": 10})
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(err, IsNil)
+
+	var items []configDiffItem
+	err = readJSON(testDialClient, fmt.Sprintf("%s/config/diff", s.urlPrefix), &items)
+	c.Assert(err, IsNil)
+	var found bool
+	for _, item := range items {
+		if item.Key == "schedule.max-snapshot-count" {
+			found = true
+			c.Assert(item.Default, Equals, float64(3))
+			c.Assert(item.Persisted, Equals, float64(10))
+			c.Assert(item.Effective, Equals, float64(10))
+		}
+	}
+	c.Assert(found, IsTrue)
+}
 func (s *testConfigSuite) TestConfigTTL(c *C) {
 	addr := fmt.Sprintf("%s/config?ttlSecond=1", s.urlPrefix)
 	postData, err := json.Marshal(ttlConfig)
@@ -324,3 +346,95 @@ func (s *testConfigSuite) TestConfigTTL(c *C) {
 	c.Assert(err, Not(IsNil))
 	c.Assert(err.Error(), Equals, "\"unsupported ttl config schedule.invalid-ttl-config\"\n")
 }
+
+func (s *testConfigSuite) TestScheduleOverride(c *C) {
+	addr := fmt.Sprintf("%s/config/schedule/override", s.urlPrefix)
+	options := s.svr.GetPersistOptions()
+	origin := options.GetMaxStoreDownTime()
+	c.Assert(origin, Not(Equals), 2*time.Hour)
+
+	err := postJSON(testDialClient, addr+"?key=max-store-down-time&value=2h&ttl=1s", nil)
+	c.Assert(err, IsNil)
+	c.Assert(options.GetMaxStoreDownTime(), Equals, 2*time.Hour)
+	var items []*config.TTLConfigItem
+	c.Assert(readJSON(testDialClient, addr, &items), IsNil)
+	c.Assert(items, HasLen, 1)
+	c.Assert(items[0].Key, Equals, "schedule.max-store-down-time")
+	c.Assert(items[0].Value, Equals, "2h")
+	c.Assert(items[0].ExpireAt.After(time.Now()), IsTrue)
+	// The persisted config is not changed.
+	c.Assert(s.svr.GetScheduleConfig().MaxStoreDownTime.Duration, Equals, origin)
+
+	time.Sleep(2 * time.Second)
+	c.Assert(options.GetMaxStoreDownTime(), Equals, origin)
+	c.Assert(readJSON(testDialClient, addr, &items), IsNil)
+	c.Assert(items, HasLen, 0)
+
+	for _, query := range []string{
+		"key=max-store-down-time&value=2&ttl=1h",
+		"key=max-store-down-time&value=2h&ttl=0s",
+		"key=max-store-down-time&value=2h",
+		"key=schedule.leader-schedule-limit&value=-1&ttl=1h",
+		"key=max-replicas&value=5&ttl=1h",
+	} {
+		err = postJSON(testDialClient, addr+"?"+query, nil)
+		c.Assert(err, NotNil, Commentf("query: %s", query))
+	}
+}
+
+func (s *testConfigSuite) TestRedactInfoLogMode(c *C) {
+	addr := fmt.Sprintf("%s/config", s.urlPrefix)
+	defer logutil.SetRedactMode(logutil.RedactModeOff)
+	for _, input := range []map[string]interface{}{
+		{"security.redact-info-log-mode": "hash"},
+		{"redact-info-log-mode": "mark"},
+	} {
+		postData, err := json.Marshal(input)
+		c.Assert(err, IsNil)
+		c.Assert(postJSON(testDialClient, addr, postData), IsNil)
+		cfg := &config.Config{}
+		c.Assert(readJSON(testDialClient, addr, cfg), IsNil)
+		for _, mode := range input {
+			c.Assert(cfg.Security.RedactInfoLogMode, Equals, mode)
+			c.Assert(logutil.GetRedactMode(), Equals, logutil.RedactMode(mode.(string)))
+		}
+	}
+
+	postData, err := json.Marshal(map[string]interface{}{"redact-info-log-mode": "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, addr, postData), NotNil)
+	c.Assert(logutil.GetRedactMode(), Equals, logutil.RedactModeMark)
+
+	postData, err = json.Marshal(map[string]interface{}{"redact-info-log-mode": "off"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, addr, postData), IsNil)
+}
+
+func (s *testConfigSuite) TestCertAllowedCN(c *C) {
+	svr, cleanup := mustNewServer(c)
+	defer cleanup()
+	mustWaitLeader(c, []*server.Server{svr})
+	addr := fmt.Sprintf("%s%s/api/v1/config", svr.GetAddr(), apiPrefix)
+
+	postData, err := json.Marshal(map[string]interface{}{"pd-server.client-allowed-cn": "tikv"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, addr, postData), IsNil)
+	c.Assert(svr.IsCNAllowed("tikv", false), IsTrue)
+	c.Assert(svr.IsCNAllowed("tidb", false), IsFalse)
+	// The admin allowlist is not set.
+	c.Assert(svr.IsCNAllowed("tidb", true), IsTrue)
+
+	postData, err = json.Marshal(map[string]interface{}{"pd-server.admin-allowed-cn": "pd-ctl,admin"})
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, addr, postData), IsNil)
+	c.Assert(svr.IsCNAllowed("admin", true), IsTrue)
+	c.Assert(svr.IsCNAllowed("tikv", true), IsFalse)
+
+	// The request without certificate can only read.
+	cfg := &config.Config{}
+	c.Assert(readJSON(testDialClient, addr, cfg), IsNil)
+	c.Assert(cfg.PDServerCfg.AdminAllowedCN, DeepEquals, typeutil.StringSlice{"pd-ctl", "admin"})
+	err = postJSON(testDialClient, addr, postData)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "not allowed"), IsTrue)
+}
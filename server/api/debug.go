@@ -0,0 +1,266 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/election"
+	"github.com/tikv/pd/server/schedulers"
+	"github.com/unrolled/render"
+)
+
+type debugHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newDebugHandler(svr *server.Server, rd *render.Render) *debugHandler {
+	return &debugHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Tags debug
+// @Summary Get the inputs and the result of the adaptive tolerant size ratio of the balance schedulers.
+// @Produce json
+// @Success 200 {object} schedulers.TolerantRatioInfo
+// @Router /debug/tolerant-ratio [get]
+func (h *debugHandler) GetTolerantRatio(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, schedulers.GetTolerantRatioInfo(rc))
+}
+
+// @Tags debug
+// @Summary Get the score components of the stores used by a balance scheduler, to debug the imbalance which does not converge.
+// @Param scheduler query string false "The type of the scheduler, balance-region or balance-leader" default(balance-region)
+// @Produce json
+// @Success 200 {object} schedulers.StoreScoresInfo
+// @Failure 400 {string} string "The scheduler is not supported."
+// @Router /debug/store-scores [get]
+func (h *debugHandler) GetStoreScores(w http.ResponseWriter, r *http.Request) {
+	schedulerType := r.URL.Query().Get("scheduler")
+	if schedulerType == "" {
+		schedulerType = schedulers.BalanceRegionType
+	}
+	rc := getCluster(r)
+	info, err := schedulers.GetStoreScores(rc, rc.GetOperatorController(), schedulerType)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, info)
+}
+
+// LeadershipInfo is the information of the PD leader election for debugging.
+type LeadershipInfo struct {
+	*election.LeadershipInfo
+	// Leader is decoded from the value of the leader key.
+	Leader *pdpb.Member `json:"leader,omitempty"`
+}
+
+// @Tags debug
+// @Summary Get the leader key, the lease and the recent election history of the PD leadership, to debug the flapping leadership without reading etcd directly. The local state is of the member which handles the request.
+// @Produce json
+// @Success 200 {object} LeadershipInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /debug/leadership [get]
+func (h *debugHandler) GetLeadership(w http.ResponseWriter, r *http.Request) {
+	info, err := h.svr.GetMember().GetLeadership().GetLeadershipInfo()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := &LeadershipInfo{LeadershipInfo: info}
+	if info.LeaderValue != "" {
+		leader := &pdpb.Member{}
+		if err := proto.Unmarshal([]byte(info.LeaderValue), leader); err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Leader = leader
+	}
+	h.rd.JSON(w, http.StatusOK, resp)
+}
+
+// @Tags debug
+// @Summary Get the health of the region heartbeat streams of the stores, to debug why the operators are not dispatched to a store.
+// @Produce json
+// @Success 200 {array} hbstream.StreamStatus
+// @Router /debug/heartbeat-streams [get]
+func (h *debugHandler) GetHeartbeatStreams(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetHBStreams().GetStreamStatus())
+}
+
+// @Tags debug
+// @Summary Get the statistics of the TSO requests handled by the server, including the batch sizes, the wait time between the requests of a stream and the streams of each connection, to tune the batching of the clients.
+// @Produce json
+// @Success 200 {object} server.TSOStats
+// @Router /debug/tso [get]
+func (h *debugHandler) GetTSOStats(w http.ResponseWriter, r *http.Request) {
+	h.rd.JSON(w, http.StatusOK, h.svr.GetTSOStats())
+}
+
+const (
+	defaultProfileSeconds = 30
+	maxProfileSeconds     = 300
+	// profileMutexFraction is the mutex profile fraction used during the
+	// capture if the mutex profiling is not enabled.
+	profileMutexFraction = 10
+)
+
+// @Tags debug
+// @Summary Capture the CPU, heap, goroutine and mutex profiles, with the config and the metrics of the member which handles the request, into a tar.gz bundle, to collect the diagnostics in one step.
+// @Param seconds query integer false "The duration of the CPU profile in seconds, up to 300" default(30)
+// @Produce application/gzip
+// @Success 200 {file} file "The tar.gz bundle."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /debug/profile [post]
+func (h *debugHandler) CaptureProfile(w http.ResponseWriter, r *http.Request) {
+	seconds := defaultProfileSeconds
+	if s := r.URL.Query().Get("seconds"); s != "" {
+		var err error
+		seconds, err = strconv.Atoi(s)
+		if err != nil || seconds <= 0 || seconds > maxProfileSeconds {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("seconds should be an integer in [1, %d]", maxProfileSeconds))
+			return
+		}
+	}
+	now := time.Now()
+	files, err := h.captureProfileFiles(r, time.Duration(seconds)*time.Second)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeTarGz(&buf, files, now); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"pd-profile-%s.tar.gz\"", now.Format("20060102150405")))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Error("failed to write the profile bundle", errs.ZapError(errs.ErrWriteHTTPBody, err))
+	}
+}
+
+type profileFile struct {
+	name    string
+	content []byte
+}
+
+func (h *debugHandler) captureProfileFiles(r *http.Request, duration time.Duration) ([]profileFile, error) {
+	// The mutex profile is empty unless the mutex profiling is enabled, so it's
+	// enabled during the capture.
+	if runtime.SetMutexProfileFraction(-1) == 0 {
+		runtime.SetMutexProfileFraction(profileMutexFraction)
+		defer runtime.SetMutexProfileFraction(0)
+	}
+	var cpu bytes.Buffer
+	if err := pprof.StartCPUProfile(&cpu); err != nil {
+		return nil, errors.Annotate(err, "failed to start the CPU profile")
+	}
+	select {
+	case <-time.After(duration):
+	case <-r.Context().Done():
+	}
+	pprof.StopCPUProfile()
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+	files := []profileFile{{name: "cpu.pprof", content: cpu.Bytes()}}
+
+	for _, name := range []string{"heap", "goroutine", "mutex"} {
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			return nil, errors.Annotatef(err, "failed to write the %s profile", name)
+		}
+		files = append(files, profileFile{name: name + ".pprof", content: buf.Bytes()})
+	}
+	// The goroutine stacks are also kept in text, which can be read without pprof.
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return nil, errors.Annotate(err, "failed to write the goroutine stacks")
+	}
+	files = append(files, profileFile{name: "goroutine.txt", content: goroutines.Bytes()})
+
+	cfg, err := json.MarshalIndent(h.svr.GetConfig(), "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	files = append(files, profileFile{name: "config.json", content: cfg})
+
+	metrics, err := gatherMetrics()
+	if err != nil {
+		return nil, err
+	}
+	return append(files, profileFile{name: "metrics.txt", content: metrics}), nil
+}
+
+func gatherMetrics() ([]byte, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, errors.Annotate(err, "failed to gather the metrics")
+	}
+	var buf bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTarGz(buf *bytes.Buffer, files []profileFile, modTime time.Time) error {
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(file.content)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := tw.Write(file.content); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(gw.Close())
+}
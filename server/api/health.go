@@ -70,3 +70,28 @@ func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	h.rd.JSON(w, http.StatusOK, healths)
 }
+
+type readyHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newReadyHandler(svr *server.Server, rd *render.Render) *readyHandler {
+	return &readyHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Summary Whether the PD server is ready to serve. It can be used as the readiness probe.
+// @Produce json
+// @Success 200 {string} string "The server is ready."
+// @Failure 503 {string} string "The server is not ready."
+// @Router /ready [get]
+func (h *readyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.svr.IsReady() {
+		h.rd.JSON(w, http.StatusServiceUnavailable, "The server is not ready.")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The server is ready.")
+}
@@ -16,9 +16,11 @@ package api
 import (
 	"encoding/json"
 	"io"
+	"net/http"
 	"strings"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
 )
@@ -69,3 +71,18 @@ func (s *testHealthAPISuite) TestHealthSlice(c *C) {
 	c.Assert(err, IsNil)
 	checkSliceResponse(c, buf, cfgs, follow.GetConfig().Name)
 }
+
+func (s *testHealthAPISuite) TestReady(c *C) {
+	svr, clean := mustNewServer(c, func(cfg *config.Config) { cfg.InitWait = true })
+	defer clean()
+	mustWaitLeader(c, []*server.Server{svr})
+
+	addr := svr.GetConfig().ClientUrls + apiPrefix + "/api/v1/ready"
+	testutil.WaitUntil(c, func(c *C) bool {
+		resp, err := testDialClient.Get(addr)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	c.Assert(svr.IsReady(), IsTrue)
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type keyspaceHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newKeyspaceHandler(svr *server.Server, rd *render.Render) *keyspaceHandler {
+	return &keyspaceHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// CreateKeyspaceParams is the params to create a keyspace.
+type CreateKeyspaceParams struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config"`
+}
+
+// @Tags keyspace
+// @Summary List all keyspaces in the order of the id.
+// @Produce json
+// @Success 200 {array} keyspace.Meta
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces [get]
+func (h *keyspaceHandler) List(w http.ResponseWriter, r *http.Request) {
+	keyspaces, err := h.svr.GetKeyspaceManager().GetKeyspaces()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, keyspaces)
+}
+
+// @Tags keyspace
+// @Summary Create a keyspace, whose id is allocated by PD.
+// @Accept json
+// @Param body body CreateKeyspaceParams true "The keyspace, e.g. {\"name\": \"ks1\", \"config\": {\"k\": \"v\"}}"
+// @Produce json
+// @Success 200 {object} keyspace.Meta
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 409 {string} string "The keyspace already exists."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces [post]
+func (h *keyspaceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var params CreateKeyspaceParams
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &params); err != nil {
+		return
+	}
+	meta, err := h.svr.GetKeyspaceManager().CreateKeyspace(params.Name, params.Config)
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, meta)
+}
+
+// @Tags keyspace
+// @Summary Get a keyspace by name.
+// @Param name path string true "The name of the keyspace"
+// @Produce json
+// @Success 200 {object} keyspace.Meta
+// @Failure 404 {string} string "The keyspace does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces/{name} [get]
+func (h *keyspaceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	meta, err := h.svr.GetKeyspaceManager().GetKeyspace(mux.Vars(r)["name"])
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, meta)
+}
+
+// @Tags keyspace
+// @Summary Get a keyspace by id.
+// @Param id path integer true "The id of the keyspace"
+// @Produce json
+// @Success 200 {object} keyspace.Meta
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The keyspace does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces/id/{id} [get]
+func (h *keyspaceHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	meta, err := h.svr.GetKeyspaceManager().GetKeyspaceByID(uint32(id))
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, meta)
+}
+
+// @Tags keyspace
+// @Summary Enable a keyspace.
+// @Param name path string true "The name of the keyspace"
+// @Produce json
+// @Success 200 {object} keyspace.Meta
+// @Failure 404 {string} string "The keyspace does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces/{name}/enable [post]
+func (h *keyspaceHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	meta, err := h.svr.GetKeyspaceManager().EnableKeyspace(mux.Vars(r)["name"])
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, meta)
+}
+
+// @Tags keyspace
+// @Summary Disable a keyspace. The keyspace and its id are kept.
+// @Param name path string true "The name of the keyspace"
+// @Produce json
+// @Success 200 {object} keyspace.Meta
+// @Failure 404 {string} string "The keyspace does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /keyspaces/{name}/disable [post]
+func (h *keyspaceHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	meta, err := h.svr.GetKeyspaceManager().DisableKeyspace(mux.Vars(r)["name"])
+	if err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, meta)
+}
+
+func (h *keyspaceHandler) respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errs.ErrKeyspaceNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+	case errs.ErrKeyspaceExists.Equal(err):
+		h.rd.JSON(w, http.StatusConflict, err.Error())
+	case errs.ErrKeyspaceInvalidName.Equal(err), errs.ErrKeyspaceIDExhausted.Equal(err):
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
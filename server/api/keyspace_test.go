@@ -0,0 +1,103 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/keyspace"
+)
+
+var _ = Suite(&testKeyspaceSuite{})
+
+type testKeyspaceSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testKeyspaceSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+}
+
+func (s *testKeyspaceSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testKeyspaceSuite) TestKeyspace(c *C) {
+	var keyspaces []*keyspace.Meta
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces", &keyspaces), IsNil)
+	c.Assert(keyspaces, HasLen, 0)
+
+	createKeyspace := func(name string) (*keyspace.Meta, int) {
+		data, err := json.Marshal(&CreateKeyspaceParams{Name: name, Config: map[string]string{"k": "v"}})
+		c.Assert(err, IsNil)
+		meta := &keyspace.Meta{}
+		var code int
+		err = postJSON(testDialClient, s.urlPrefix+"/keyspaces", data, func(res []byte, statusCode int) {
+			c.Assert(json.Unmarshal(res, meta), IsNil)
+			code = statusCode
+		})
+		if err != nil {
+			return nil, 0
+		}
+		return meta, code
+	}
+	ks1, code := createKeyspace("ks1")
+	c.Assert(code, Equals, http.StatusOK)
+	c.Assert(ks1.ID, Equals, uint32(1))
+	c.Assert(ks1.Config, DeepEquals, map[string]string{"k": "v"})
+	ks2, code := createKeyspace("ks2")
+	c.Assert(code, Equals, http.StatusOK)
+	c.Assert(ks2.ID, Equals, uint32(2))
+	for _, name := range []string{"ks1", "bad name"} {
+		_, code = createKeyspace(name)
+		c.Assert(code, Equals, 0)
+	}
+
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces", &keyspaces), IsNil)
+	c.Assert(keyspaces, DeepEquals, []*keyspace.Meta{ks1, ks2})
+	meta := &keyspace.Meta{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces/ks2", meta), IsNil)
+	c.Assert(meta, DeepEquals, ks2)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces/id/1", meta), IsNil)
+	c.Assert(meta, DeepEquals, ks1)
+
+	for _, url := range []string{"/keyspaces/ks3", "/keyspaces/id/3", "/keyspaces/id/abc"} {
+		resp, err := testDialClient.Get(s.urlPrefix + url)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Not(Equals), http.StatusOK)
+	}
+	resp, err := testDialClient.Get(s.urlPrefix + "/keyspaces/ks3")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/keyspaces/ks1/disable", nil), IsNil)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces/ks1", meta), IsNil)
+	c.Assert(meta.State, Equals, keyspace.StateDisabled)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/keyspaces/ks1/enable", nil), IsNil)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/keyspaces/ks1", meta), IsNil)
+	c.Assert(meta.State, Equals, keyspace.StateEnabled)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/keyspaces/ks3/disable", nil), NotNil)
+}
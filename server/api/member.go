@@ -27,6 +27,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/member"
 	"github.com/unrolled/render"
 	"go.uber.org/zap"
 )
@@ -58,6 +59,34 @@ func (h *memberHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, members)
 }
 
+// @Tags member
+// @Summary List the leader fitness of all PD servers in the cluster. The fitness is reported only if enable-leader-fitness-transfer is on.
+// @Produce json
+// @Success 200 {array} member.LeaderFitness
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /members/fitness [get]
+func (h *memberHandler) GetLeaderFitness(w http.ResponseWriter, r *http.Request) {
+	res, err := etcdutil.ListEtcdMembers(h.svr.GetClient())
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	fitness := make([]*member.LeaderFitness, 0, len(res.Members))
+	for _, m := range res.Members {
+		f, err := h.svr.GetMember().GetMemberLeaderFitness(m.GetID())
+		if err != nil {
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if f == nil {
+			// The member has not reported its fitness yet.
+			f = &member.LeaderFitness{MemberID: m.GetID(), Name: m.GetName()}
+		}
+		fitness = append(fitness, f)
+	}
+	h.rd.JSON(w, http.StatusOK, fitness)
+}
+
 func getMembers(svr *server.Server) (*pdpb.GetMembersResponse, error) {
 	req := &pdpb.GetMembersRequest{Header: &pdpb.RequestHeader{ClusterId: svr.ClusterID()}}
 	members, err := svr.GetMembers(context.Background(), req)
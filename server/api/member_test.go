@@ -14,7 +14,9 @@
 package api
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -106,6 +108,63 @@ func (s *testMemberAPISuite) TestMemberLeader(c *C) {
 	c.Assert(got.GetMemberId(), Equals, leader.GetMemberId())
 }
 
+func (s *testMemberAPISuite) TestDebugLeadership(c *C) {
+	leader := s.servers[0].GetLeader()
+	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/debug/leadership"
+	var info LeadershipInfo
+	c.Assert(readJSON(testDialClient, addr, &info), IsNil)
+	c.Assert(info.Leader.GetMemberId(), Equals, leader.GetMemberId())
+	c.Assert(info.LeaderKey, Equals, s.servers[0].GetMember().GetLeaderPath())
+	c.Assert(info.LeaseID, Not(Equals), int64(0))
+	c.Assert(info.LeaseRemainingTTL, Greater, int64(0))
+	c.Assert(info.LeaseTTL, GreaterEqual, info.LeaseRemainingTTL)
+	// The request is handled by the leader.
+	c.Assert(info.Held, IsTrue)
+	c.Assert(info.LocalLeaseExpireTime, NotNil)
+	c.Assert(info.LastCampaignTime, NotNil)
+	c.Assert(info.History, Not(HasLen), 0)
+	last := info.History[len(info.History)-1]
+	c.Assert(last.Event, Equals, "campaign-succeeded")
+	c.Assert(last.LeaseID, Equals, info.LeaseID)
+}
+
+func (s *testMemberAPISuite) TestDebugProfile(c *C) {
+	addr := s.cfgs[0].ClientUrls + apiPrefix + "/api/v1/debug/profile"
+	for _, seconds := range []string{"0", "301", "abc"} {
+		resp, err := testDialClient.Post(addr+"?seconds="+seconds, "", nil)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	}
+
+	resp, err := testDialClient.Post(addr+"?seconds=1", "", nil)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "application/gzip")
+	gr, err := gzip.NewReader(resp.Body)
+	c.Assert(err, IsNil)
+	tr := tar.NewReader(gr)
+	files := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		files[header.Name], err = io.ReadAll(tr)
+		c.Assert(err, IsNil)
+	}
+	for _, name := range []string{"cpu.pprof", "heap.pprof", "goroutine.pprof", "mutex.pprof", "goroutine.txt", "config.json", "metrics.txt"} {
+		c.Assert(files[name], Not(HasLen), 0, Commentf("file %s", name))
+	}
+	cfg := &config.Config{}
+	c.Assert(json.Unmarshal(files["config.json"], cfg), IsNil)
+	// The request is handled by the leader.
+	c.Assert(cfg.Name, Equals, s.servers[0].GetLeader().GetName())
+	c.Assert(strings.Contains(string(files["metrics.txt"]), "pd_"), IsTrue)
+}
+
 func (s *testMemberAPISuite) TestChangeLeaderPeerUrls(c *C) {
 	leader := s.servers[0].GetLeader()
 	addr := s.cfgs[rand.Intn(len(s.cfgs))].ClientUrls + apiPrefix + "/api/v1/leader"
@@ -14,7 +14,10 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/tikv/pd/pkg/errs"
@@ -52,3 +55,100 @@ type clusterCtxKey struct{}
 func getCluster(r *http.Request) *cluster.RaftCluster {
 	return r.Context().Value(clusterCtxKey{}).(*cluster.RaftCluster)
 }
+
+// idempotencyKeyHeader is the header of the idempotency key of the mutating
+// request, the retried requests with the same key are applied once.
+const idempotencyKeyHeader = "PD-Idempotency-Key"
+
+// errServerError means the response is a server error, which is not
+// remembered so that the request can be retried.
+var errServerError = errors.New("server error")
+
+type idempotencyMiddleware struct {
+	s  *server.Server
+	rd *render.Render
+}
+
+func newIdempotencyMiddleware(s *server.Server) idempotencyMiddleware {
+	return idempotencyMiddleware{
+		s:  s,
+		rd: render.New(render.Options{IndentJSON: true}),
+	}
+}
+
+// Middleware handles the mutating requests with the idempotency keys once,
+// the retries get the response of the first request.
+func (m idempotencyMiddleware) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			m.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		content := append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...)
+		resp, err := m.s.GetIdempotentRequests().Do(r.Context(), key, content, func() (interface{}, error) {
+			rec := newResponseRecorder()
+			h.ServeHTTP(rec, r)
+			if rec.code >= http.StatusInternalServerError {
+				return rec, errServerError
+			}
+			return rec, nil
+		})
+		if errs.ErrIdempotencyKeyReused.Equal(err) {
+			m.rd.JSON(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		// There is no response to replay if the request is canceled while
+		// waiting for the first one, or the first one panicked.
+		rec, ok := resp.(*responseRecorder)
+		if !ok {
+			m.rd.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		rec.replay(w)
+	})
+}
+
+// responseRecorder records the response to replay it for the retries.
+type responseRecorder struct {
+	header      http.Header
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code, r.wroteHeader = code, true
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) replay(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(r.code)
+	w.Write(r.body.Bytes())
+}
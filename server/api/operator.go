@@ -15,10 +15,12 @@ package api
 
 import (
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -66,8 +68,11 @@ func (h *operatorHandler) Get(w http.ResponseWriter, r *http.Request) {
 // @Tags operator
 // @Summary List pending operators.
 // @Param kind query string false "Specify the operator kind." Enums(admin, leader, region)
+// @Param offset query integer false "The offset of the page, the total count is returned in the X-Total-Count header."
+// @Param limit query integer false "The size of the page."
 // @Produce json
 // @Success 200 {array} operator.Operator
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /operators [get]
 func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
@@ -77,6 +82,11 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 		err     error
 	)
 
+	page, err := parseListPage(r)
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	kinds, ok := r.URL.Query()["kind"]
 	if !ok {
 		results, err = h.GetOperators()
@@ -104,17 +114,22 @@ func (h *operatorHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	h.r.JSON(w, http.StatusOK, results)
+	// Sort the operators so that the pages are stable.
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].RegionID() < results[j].RegionID()
+	})
+	start, end := page.apply(w, len(results))
+	h.r.JSON(w, http.StatusOK, results[start:end])
 }
 
 // FIXME: details of input json body params
 // @Tags operator
-// @Summary Create an operator.
+// @Summary Create an operator. The operators moving the peers bypass the store limit if "force" is true, which is used for the urgent repairs. The merge-region operator also skips the size check of the source region if "force" is true.
 // @Accept json
 // @Param body body object true "json params"
 // @Produce json
 // @Success 200 {string} string "The operator is created."
-// @Failure 400 {string} string "The input is invalid."
+// @Failure 400 {string} string "The input is invalid, or the operator is unable to be executed."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /operators [post]
 func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
@@ -128,6 +143,7 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 		h.r.JSON(w, http.StatusBadRequest, "missing operator name")
 		return
 	}
+	force, _ := input["force"].(bool)
 
 	switch name {
 	case "transfer-leader":
@@ -142,7 +158,7 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if err := h.AddTransferLeaderOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "transfer-region":
@@ -160,8 +176,8 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "missing store ids to transfer region to")
 			return
 		}
-		if err := h.AddTransferRegionOperator(uint64(regionID), storeIDs); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		if err := h.AddTransferRegionOperator(uint64(regionID), storeIDs, force); err != nil {
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "transfer-peer":
@@ -180,8 +196,8 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
 			return
 		}
-		if err := h.AddTransferPeerOperator(uint64(regionID), uint64(fromID), uint64(toID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		if err := h.AddTransferPeerOperator(uint64(regionID), uint64(fromID), uint64(toID), force); err != nil {
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "add-peer":
@@ -195,8 +211,8 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
 			return
 		}
-		if err := h.AddAddPeerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		if err := h.AddAddPeerOperator(uint64(regionID), uint64(storeID), force); err != nil {
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "add-learner":
@@ -210,8 +226,8 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
 			return
 		}
-		if err := h.AddAddLearnerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		if err := h.AddAddLearnerOperator(uint64(regionID), uint64(storeID), force); err != nil {
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "remove-peer":
@@ -225,8 +241,8 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "invalid store id to transfer peer to")
 			return
 		}
-		if err := h.AddRemovePeerOperator(uint64(regionID), uint64(storeID)); err != nil {
-			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		if err := h.AddRemovePeerOperator(uint64(regionID), uint64(storeID), force); err != nil {
+			h.respondOperatorError(w, err)
 			return
 		}
 	case "merge-region":
@@ -240,7 +256,7 @@ func (h *operatorHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusBadRequest, "invalid target region id to merge to")
 			return
 		}
-		if err := h.AddMergeRegionOperator(uint64(regionID), uint64(targetID)); err != nil {
+		if err := h.AddMergeRegionOperator(uint64(regionID), uint64(targetID), force); err != nil {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -338,6 +354,16 @@ func (h *operatorHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, "The pending operator is canceled.")
 }
 
+// respondOperatorError responds 400 if the operator is rejected by the
+// validation, since it can never be executed as the input is.
+func (h *operatorHandler) respondOperatorError(w http.ResponseWriter, err error) {
+	if errs.ErrInvalidOperator.Equal(err) {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusInternalServerError, err.Error())
+}
+
 func parseStoreIDsAndPeerRole(ids interface{}, roles interface{}) (map[uint64]placement.PeerRoleType, bool) {
 	items, ok := ids.([]interface{})
 	if !ok {
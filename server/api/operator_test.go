@@ -14,13 +14,16 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"strings"
 
 	. "github.com/pingcap/check"
+	pingcaperrors "github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -115,6 +118,60 @@ func (s *testOperatorSuite) TestAddRemovePeer(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testOperatorSuite) TestIdempotencyKey(c *C) {
+	for _, id := range []uint64{1, 2, 4} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, nil)
+	}
+	r := newTestRegionInfo(40, 1, []byte("x"), []byte("y"))
+	mustRegionHeartbeat(c, s.svr, r)
+	defer s.svr.GetHandler().RemoveOperator(40)
+
+	post := func(key, body string) int {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/operators", s.urlPrefix), bytes.NewBufferString(body))
+		c.Assert(err, IsNil)
+		req.Header.Set(idempotencyKeyHeader, key)
+		resp, err := testDialClient.Do(req)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	body := `{"name":"add-peer", "region_id": 40, "store_id": 4}`
+	c.Assert(post("key-1", body), Equals, http.StatusOK)
+	// The retry gets the response of the first request, instead of being
+	// rejected by the existing operator.
+	c.Assert(post("key-1", body), Equals, http.StatusOK)
+	c.Assert(post("key-2", body), Equals, http.StatusInternalServerError)
+	err := s.svr.GetHandler().AddAddPeerOperator(40, 4, false)
+	c.Assert(pingcaperrors.Cause(err), Equals, server.ErrAddOperator)
+	c.Assert(err, ErrorMatches, ".*already-have.*")
+	// The key can't be reused by the different request.
+	c.Assert(post("key-1", `{"name":"add-peer", "region_id": 40, "store_id": 2}`), Equals, http.StatusUnprocessableEntity)
+	// The failed request is not remembered.
+	c.Assert(post("key-2", body), Equals, http.StatusInternalServerError)
+}
+
+func (s *testOperatorSuite) TestValidateOperator(c *C) {
+	for _, id := range []uint64{11, 12} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, nil)
+	}
+	r := newTestRegionInfo(50, 11, []byte("y"), []byte("z"), core.SetRegionConfVer(10), core.SetRegionVersion(10))
+	mustRegionHeartbeat(c, s.svr, r)
+	defer s.svr.GetHandler().RemoveOperator(50)
+	c.Assert(s.svr.GetRaftCluster().RemoveStore(12, false), IsNil)
+
+	resp, err := testDialClient.Post(fmt.Sprintf("%s/operators", s.urlPrefix), "application/json",
+		bytes.NewBufferString(`{"name":"add-peer", "region_id": 50, "store_id": 12}`))
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(body), "step 0 (add learner peer"), IsTrue)
+	c.Assert(strings.Contains(string(body), "store 12 is unavailable: offline"), IsTrue)
+	_, err = s.svr.GetHandler().GetOperator(50)
+	c.Assert(err, NotNil)
+}
+
 func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 	r1 := newTestRegionInfo(10, 1, []byte(""), []byte("b"), core.SetWrittenBytes(1000), core.SetReadBytes(1000), core.SetRegionConfVer(1), core.SetRegionVersion(1))
 	mustRegionHeartbeat(c, s.svr, r1)
@@ -139,6 +196,18 @@ func (s *testOperatorSuite) TestMergeRegionOperator(c *C) {
 
 	c.Assert(strings.Contains(err.Error(), "not adjacent"), IsTrue)
 	c.Assert(err, NotNil)
+
+	// The source region exceeding the max merge region size is merged only if
+	// it is forced.
+	r2 = newTestRegionInfo(20, 1, []byte("b"), []byte("c"), core.SetApproximateSize(1000), core.SetRegionConfVer(2), core.SetRegionVersion(4))
+	mustRegionHeartbeat(c, s.svr, r2)
+	err = postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"merge-region", "source_region_id": 20, "target_region_id": 30}`))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "exceeds the max merge region size"), IsTrue)
+	err = postJSON(testDialClient, fmt.Sprintf("%s/operators", s.urlPrefix), []byte(`{"name":"merge-region", "source_region_id": 20, "target_region_id": 30, "force": true}`))
+	c.Assert(err, IsNil)
+	s.svr.GetHandler().RemoveOperator(20)
+	s.svr.GetHandler().RemoveOperator(30)
 }
 
 type testTransferRegionOperatorSuite struct {
@@ -0,0 +1,181 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+const defaultPinTTL = time.Hour
+
+type pinnedRegionHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newPinnedRegionHandler(svr *server.Server, rd *render.Render) *pinnedRegionHandler {
+	return &pinnedRegionHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// PinnedRegion is a region, or the regions in a key range, which is exempted
+// from balance.
+type PinnedRegion struct {
+	RegionID uint64    `json:"region_id,omitempty"`
+	StartKey string    `json:"start_key,omitempty"`
+	EndKey   string    `json:"end_key,omitempty"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// pinRegionsInput specifies the regions to be pinned, by either the region IDs
+// or the hex-encoded key range.
+type pinRegionsInput struct {
+	RegionIDs []uint64 `json:"region_ids"`
+	StartKey  string   `json:"start_key"`
+	EndKey    string   `json:"end_key"`
+	// TTL is the lifetime of the pin in seconds.
+	TTL int64 `json:"ttl"`
+}
+
+func (input *pinRegionsInput) parseKeyRange() (startKey, endKey []byte, err error) {
+	if startKey, err = hex.DecodeString(input.StartKey); err != nil {
+		return nil, nil, errors.New("start key is not in hex format")
+	}
+	if endKey, err = hex.DecodeString(input.EndKey); err != nil {
+		return nil, nil, errors.New("end key is not in hex format")
+	}
+	return startKey, endKey, nil
+}
+
+func (h *pinnedRegionHandler) readPinInput(w http.ResponseWriter, r *http.Request) (*pinRegionsInput, bool) {
+	var input pinRegionsInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return nil, false
+	}
+	if len(input.RegionIDs) == 0 && input.StartKey == "" && input.EndKey == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "either region_ids or key range should be specified")
+		return nil, false
+	}
+	if len(input.RegionIDs) > 0 && (input.StartKey != "" || input.EndKey != "") {
+		h.rd.JSON(w, http.StatusBadRequest, "region_ids and key range cannot be specified at the same time")
+		return nil, false
+	}
+	if input.TTL < 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "ttl should not be negative")
+		return nil, false
+	}
+	return &input, true
+}
+
+// @Tags region
+// @Summary List the pinned regions and key ranges, which are exempted from balance.
+// @Produce json
+// @Success 200 {array} PinnedRegion
+// @Router /regions/pinned [get]
+func (h *pinnedRegionHandler) List(w http.ResponseWriter, r *http.Request) {
+	pins := getCluster(r).GetPinnedRegions().GetAll()
+	res := make([]PinnedRegion, 0, len(pins))
+	for _, pin := range pins {
+		res = append(res, PinnedRegion{
+			RegionID: pin.RegionID,
+			StartKey: hex.EncodeToString(pin.StartKey),
+			EndKey:   hex.EncodeToString(pin.EndKey),
+			ExpireAt: pin.ExpireAt,
+		})
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+// @Tags region
+// @Summary Pin the regions or the regions in a key range. The balance schedulers will not move their leaders or peers, while the replicas are still repaired.
+// @Accept json
+// @Param body body object true "json params, e.g. {\"region_ids\": [1, 2], \"ttl\": 3600} or {\"start_key\": \"\", \"end_key\": \"\", \"ttl\": 3600}"
+// @Produce json
+// @Success 200 {string} string "The regions are pinned."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/pinned [post]
+func (h *pinnedRegionHandler) Pin(w http.ResponseWriter, r *http.Request) {
+	input, ok := h.readPinInput(w, r)
+	if !ok {
+		return
+	}
+	ttl := defaultPinTTL
+	if input.TTL > 0 {
+		ttl = time.Duration(input.TTL) * time.Second
+	}
+	pinned := getCluster(r).GetPinnedRegions()
+	if len(input.RegionIDs) > 0 {
+		for _, id := range input.RegionIDs {
+			pinned.PinRegion(id, ttl)
+		}
+	} else {
+		startKey, endKey, err := input.parseKeyRange()
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		pinned.PinKeyRange(startKey, endKey, ttl)
+	}
+	h.rd.JSON(w, http.StatusOK, "The regions are pinned.")
+}
+
+// @Tags region
+// @Summary Unpin the region or the key range.
+// @Param region_id query integer false "The region to unpin"
+// @Param start_key query string false "The start key of the key range to unpin, in hex format"
+// @Param end_key query string false "The end key of the key range to unpin, in hex format"
+// @Produce json
+// @Success 200 {string} string "The regions are unpinned."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The regions are not pinned."
+// @Router /regions/pinned [delete]
+func (h *pinnedRegionHandler) Unpin(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := &pinRegionsInput{
+		StartKey: query.Get("start_key"),
+		EndKey:   query.Get("end_key"),
+	}
+	pinned := getCluster(r).GetPinnedRegions()
+	var found bool
+	if idStr := query.Get("region_id"); idStr != "" {
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		found = pinned.UnpinRegion(id)
+	} else {
+		startKey, endKey, err := input.parseKeyRange()
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		found = pinned.UnpinKeyRange(startKey, endKey)
+	}
+	if !found {
+		h.rd.JSON(w, http.StatusNotFound, "The regions are not pinned.")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The regions are unpinned.")
+}
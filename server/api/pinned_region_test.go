@@ -0,0 +1,92 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+)
+
+var _ = Suite(&testPinnedRegionSuite{})
+
+type testPinnedRegionSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testPinnedRegionSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/regions/pinned", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testPinnedRegionSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testPinnedRegionSuite) TestPinnedRegion(c *C) {
+	r1 := newTestRegionInfo(11, 1, []byte("a"), []byte("b"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	r2 := newTestRegionInfo(12, 1, []byte("b"), []byte("c"))
+	mustRegionHeartbeat(c, s.svr, r2)
+	rc := s.svr.GetRaftCluster()
+
+	input := map[string]interface{}{"region_ids": []uint64{11}, "ttl": 60}
+	data, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, data), IsNil)
+	input = map[string]interface{}{
+		"start_key": hex.EncodeToString([]byte("bb")),
+		"end_key":   hex.EncodeToString([]byte("c")),
+	}
+	data, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, data), IsNil)
+	c.Assert(rc.IsRegionPinned(r1), IsTrue)
+	c.Assert(rc.IsRegionPinned(r2), IsTrue)
+
+	// invalid input
+	input = map[string]interface{}{"ttl": 60}
+	data, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, data), NotNil)
+
+	var pins []PinnedRegion
+	c.Assert(readJSON(testDialClient, s.urlPrefix, &pins), IsNil)
+	c.Assert(pins, HasLen, 2)
+	c.Assert(pins[0].RegionID, Equals, uint64(11))
+	c.Assert(pins[1].StartKey, Equals, hex.EncodeToString([]byte("bb")))
+
+	res, err := doDelete(testDialClient, s.urlPrefix+"?region_id=11")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	res, err = doDelete(testDialClient, s.urlPrefix+"?region_id=11")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	res, err = doDelete(testDialClient, fmt.Sprintf("%s?start_key=%s&end_key=%s", s.urlPrefix, hex.EncodeToString([]byte("bb")), hex.EncodeToString([]byte("c"))))
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(rc.IsRegionPinned(r1), IsFalse)
+	c.Assert(rc.IsRegionPinned(r2), IsFalse)
+}
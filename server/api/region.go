@@ -14,6 +14,7 @@
 package api
 
 import (
+	"bytes"
 	"container/heap"
 	"encoding/hex"
 	"fmt"
@@ -21,6 +22,8 @@ import (
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/failpoint"
@@ -31,6 +34,7 @@ import (
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/statistics"
 	"github.com/unrolled/render"
@@ -109,6 +113,9 @@ type RegionInfo struct {
 	ReadKeys        uint64        `json:"read_keys"`
 	ApproximateSize int64         `json:"approximate_size"`
 	ApproximateKeys int64         `json:"approximate_keys"`
+	// FirstSeen and LastHeartbeat are unix timestamps in seconds.
+	FirstSeen     int64 `json:"first_seen,omitempty"`
+	LastHeartbeat int64 `json:"last_heartbeat,omitempty"`
 
 	ReplicationStatus *ReplicationStatus `json:"replication_status,omitempty"`
 }
@@ -141,8 +148,8 @@ func InitRegion(r *core.RegionInfo, s *RegionInfo) *RegionInfo {
 	}
 
 	s.ID = r.GetID()
-	s.StartKey = core.HexRegionKeyStr(r.GetStartKey())
-	s.EndKey = core.HexRegionKeyStr(r.GetEndKey())
+	s.StartKey = core.RedactedHexRegionKeyStr(r.GetStartKey())
+	s.EndKey = core.RedactedHexRegionKeyStr(r.GetEndKey())
 	s.RegionEpoch = r.GetRegionEpoch()
 	s.Peers = fromPeerSlice(r.GetPeers())
 	s.Leader = fromPeer(r.GetLeader())
@@ -154,6 +161,8 @@ func InitRegion(r *core.RegionInfo, s *RegionInfo) *RegionInfo {
 	s.ReadKeys = r.GetKeysRead()
 	s.ApproximateSize = r.GetApproximateSize()
 	s.ApproximateKeys = r.GetApproximateKeys()
+	s.FirstSeen = r.GetFirstSeen()
+	s.LastHeartbeat = r.GetLastHeartbeat()
 	s.ReplicationStatus = fromPBReplicationStatus(r.GetReplicationStatus())
 
 	return s
@@ -215,11 +224,31 @@ func (h *regionHandler) GetRegionByID(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, NewRegionInfo(regionInfo))
 }
 
+// RegionSiblingsInfo is a region with its adjacent regions.
+type RegionSiblingsInfo struct {
+	Region *RegionInfo `json:"region"`
+	Prev   *RegionInfo `json:"prev,omitempty"`
+	Next   *RegionInfo `json:"next,omitempty"`
+}
+
+// The boundary semantics of searching region by key.
+const (
+	// boundaryInclusive means a key equal to the start key of a region belongs to the region.
+	boundaryInclusive = "inclusive"
+	// boundaryExclusive means a key equal to the start key of a region belongs to the
+	// previous region, i.e. the key is treated as an exclusive end key.
+	boundaryExclusive = "exclusive"
+)
+
 // @Tags region
 // @Summary Search for a region by a key.
 // @Param key path string true "Region key"
+// @Param boundary query string false "Whether a key equal to the start key of a region belongs to the region (inclusive) or the previous one (exclusive)" Enums(inclusive, exclusive)
+// @Param include_siblings query bool false "Whether to include the adjacent regions"
 // @Produce json
 // @Success 200 {object} RegionInfo
+// @Success 200 {object} RegionSiblingsInfo
+// @Failure 400 {string} string "The input is invalid."
 // @Router /region/key/{key} [get]
 func (h *regionHandler) GetRegionByKey(w http.ResponseWriter, r *http.Request) {
 	rc := getCluster(r)
@@ -230,8 +259,37 @@ func (h *regionHandler) GetRegionByKey(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	boundary := r.URL.Query().Get("boundary")
+	if boundary == "" {
+		boundary = boundaryInclusive
+	}
+	if boundary != boundaryInclusive && boundary != boundaryExclusive {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid boundary %s", boundary))
+		return
+	}
+	includeSiblings := false
+	if value := r.URL.Query().Get("include_siblings"); value != "" {
+		includeSiblings, err = strconv.ParseBool(value)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	regionInfo := rc.GetRegionByKey([]byte(key))
-	h.rd.JSON(w, http.StatusOK, NewRegionInfo(regionInfo))
+	if boundary == boundaryExclusive && regionInfo != nil && bytes.Equal(regionInfo.GetStartKey(), []byte(key)) {
+		regionInfo = rc.GetPrevRegionByKey([]byte(key))
+	}
+	if !includeSiblings {
+		h.rd.JSON(w, http.StatusOK, NewRegionInfo(regionInfo))
+		return
+	}
+	info := &RegionSiblingsInfo{Region: NewRegionInfo(regionInfo)}
+	if regionInfo != nil {
+		prev, next := rc.GetAdjacentRegions(regionInfo)
+		info.Prev, info.Next = NewRegionInfo(prev), NewRegionInfo(next)
+	}
+	h.rd.JSON(w, http.StatusOK, info)
 }
 
 type regionsHandler struct {
@@ -298,6 +356,97 @@ func (h *regionsHandler) ScanRegions(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// regionFieldGetters are the fields which can be projected by the range query.
+var regionFieldGetters = map[string]func(r *core.RegionInfo) interface{}{
+	"id":             func(r *core.RegionInfo) interface{} { return r.GetID() },
+	"start_key":      func(r *core.RegionInfo) interface{} { return core.RedactedHexRegionKeyStr(r.GetStartKey()) },
+	"end_key":        func(r *core.RegionInfo) interface{} { return core.RedactedHexRegionKeyStr(r.GetEndKey()) },
+	"epoch":          func(r *core.RegionInfo) interface{} { return r.GetRegionEpoch() },
+	"peers":          func(r *core.RegionInfo) interface{} { return fromPeerSlice(r.GetPeers()) },
+	"leader":         func(r *core.RegionInfo) interface{} { return fromPeer(r.GetLeader()) },
+	"down_peers":     func(r *core.RegionInfo) interface{} { return fromPeerStatsSlice(r.GetDownPeers()) },
+	"pending_peers":  func(r *core.RegionInfo) interface{} { return fromPeerSlice(r.GetPendingPeers()) },
+	"written_bytes":  func(r *core.RegionInfo) interface{} { return r.GetBytesWritten() },
+	"read_bytes":     func(r *core.RegionInfo) interface{} { return r.GetBytesRead() },
+	"written_keys":   func(r *core.RegionInfo) interface{} { return r.GetKeysWritten() },
+	"read_keys":      func(r *core.RegionInfo) interface{} { return r.GetKeysRead() },
+	"size":           func(r *core.RegionInfo) interface{} { return r.GetApproximateSize() },
+	"keys":           func(r *core.RegionInfo) interface{} { return r.GetApproximateKeys() },
+	"first_seen":     func(r *core.RegionInfo) interface{} { return r.GetFirstSeen() },
+	"last_heartbeat": func(r *core.RegionInfo) interface{} { return r.GetLastHeartbeat() },
+}
+
+// defaultRegionFields are the fields returned by the range query if no fields are specified.
+var defaultRegionFields = []string{"id", "start_key", "end_key"}
+
+// RegionsFieldsInfo contains some regions with the requested fields only.
+type RegionsFieldsInfo struct {
+	Count   int                      `json:"count"`
+	Regions []map[string]interface{} `json:"regions"`
+}
+
+// @Tags region
+// @Summary List regions in a given range [startKey, endKey) with the requested fields only.
+// @Param start_key query string false "Region start key"
+// @Param end_key query string false "Region end key"
+// @Param fields query string false "Comma separated fields, e.g. leader,peers,size. The id is always returned."
+// @Param limit query integer false "Limit count" default(10240)
+// @Produce json
+// @Success 200 {object} RegionsFieldsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/range [get]
+func (h *regionsHandler) ScanRegionsFields(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	query := r.URL.Query()
+	startKey, endKey := query.Get("start_key"), query.Get("end_key")
+	if len(endKey) > 0 && startKey >= endKey {
+		h.rd.JSON(w, http.StatusBadRequest, "the start key should be less than the end key")
+		return
+	}
+
+	fields := defaultRegionFields
+	if fieldsStr := query.Get("fields"); fieldsStr != "" {
+		fields = []string{"id"}
+		for _, field := range strings.Split(fieldsStr, ",") {
+			field = strings.TrimSpace(field)
+			if _, ok := regionFieldGetters[field]; !ok {
+				h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("unknown region field %q", field))
+				return
+			}
+			if field != "id" {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	limit := maxRegionLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if limit > maxRegionLimit {
+		limit = maxRegionLimit
+	}
+
+	regions := rc.ScanRegions([]byte(startKey), []byte(endKey), limit)
+	regionsInfo := &RegionsFieldsInfo{
+		Count:   len(regions),
+		Regions: make([]map[string]interface{}, 0, len(regions)),
+	}
+	for _, region := range regions {
+		projection := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			projection[field] = regionFieldGetters[field](region)
+		}
+		regionsInfo.Regions = append(regionsInfo.Regions, projection)
+	}
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
 // @Tags region
 // @Summary Get count of regions.
 // @Produce json
@@ -432,6 +581,55 @@ func (h *regionsHandler) GetOfflinePeer(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// @Tags region
+// @Summary List all regions that has peer on the tombstone or unknown store.
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/invalid-peer [get]
+func (h *regionsHandler) GetInvalidPeerRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetRegionsByType(statistics.InvalidPeer)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
+// @Tags region
+// @Summary List all regions that have fewer live voters than the quorum, with the stores of the lost voters.
+// @Produce json
+// @Success 200 {array} statistics.LostQuorumRegion
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/lost-quorum [get]
+func (h *regionsHandler) GetLostQuorumRegions(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	regions, err := handler.GetLostQuorumRegions()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, regions)
+}
+
+// @Tags region
+// @Summary Report the regions whose peers violate the isolation level of the placement rules, with the counts per rule and the trend. It is updated by the patrol of the regions.
+// @Produce json
+// @Success 200 {object} statistics.IsolationViolationReport
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /regions/check/isolation-violation [get]
+func (h *regionsHandler) GetIsolationViolationReport(w http.ResponseWriter, r *http.Request) {
+	handler := h.svr.GetHandler()
+	report, err := handler.GetIsolationViolationReport()
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, report)
+}
+
 // @Tags region
 // @Summary List all empty regions.
 // @Produce json
@@ -449,6 +647,43 @@ func (h *regionsHandler) GetEmptyRegion(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, regionsInfo)
 }
 
+// defaultStaleHeartbeatThreshold is the default threshold of the stale region
+// query, it is much longer than the region heartbeat interval of TiKV.
+const defaultStaleHeartbeatThreshold = 10 * time.Minute
+
+// @Tags region
+// @Summary List the regions which are not heartbeated within the threshold. They may be the routing entries for the deleted data.
+// @Param threshold query string false "The duration without heartbeat, e.g. 30m" default(10m)
+// @Produce json
+// @Success 200 {object} RegionsInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/check/stale-region [get]
+func (h *regionsHandler) GetStaleRegions(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	threshold := defaultStaleHeartbeatThreshold
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		var err error
+		threshold, err = time.ParseDuration(thresholdStr)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if threshold <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "threshold should be positive")
+			return
+		}
+	}
+	now := time.Now()
+	var regions []*core.RegionInfo
+	for _, region := range rc.GetRegions() {
+		if region.IsHeartbeatStale(now, threshold) {
+			regions = append(regions, region)
+		}
+	}
+	regionsInfo := convertToAPIRegions(regions)
+	h.rd.JSON(w, http.StatusOK, regionsInfo)
+}
+
 type histItem struct {
 	Start int64 `json:"start"`
 	End   int64 `json:"end"`
@@ -552,6 +787,45 @@ func calHist(bound int, list *[]int64) *[]*histItem {
 	return &histItems
 }
 
+// RegionRangeAnomaly is a key range which is covered by no region or more
+// than one region.
+type RegionRangeAnomaly struct {
+	// Kind is "gap" or "overlap".
+	Kind     string `json:"kind"`
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+	// Regions are the regions around the gap or the overlapped regions.
+	Regions []RegionInfo `json:"regions"`
+}
+
+// RegionRangeAnomalies are the anomalies found in the region tree.
+type RegionRangeAnomalies struct {
+	Count     int                  `json:"count"`
+	Anomalies []RegionRangeAnomaly `json:"anomalies"`
+}
+
+// @Tags region
+// @Summary Check the region tree for the key ranges which are covered by no region or more than one region. There should be none, otherwise the routing is corrupted.
+// @Produce json
+// @Success 200 {object} RegionRangeAnomalies
+// @Router /regions/check-gaps [get]
+func (h *regionsHandler) CheckRegionGaps(w http.ResponseWriter, r *http.Request) {
+	anomalies := getCluster(r).CheckRegionRanges()
+	res := &RegionRangeAnomalies{
+		Count:     len(anomalies),
+		Anomalies: make([]RegionRangeAnomaly, 0, len(anomalies)),
+	}
+	for _, anomaly := range anomalies {
+		res.Anomalies = append(res.Anomalies, RegionRangeAnomaly{
+			Kind:     anomaly.Kind,
+			StartKey: core.RedactedHexRegionKeyStr(anomaly.StartKey),
+			EndKey:   core.RedactedHexRegionKeyStr(anomaly.EndKey),
+			Regions:  convertToAPIRegions(anomaly.Regions).Regions,
+		})
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
 // @Tags region
 // @Summary List sibling regions of a specific region.
 // @Param id path integer true "Region Id"
@@ -719,9 +993,9 @@ func (h *regionsHandler) GetTopNRegions(w http.ResponseWriter, r *http.Request,
 }
 
 // @Tags region
-// @Summary Scatter regions by given key ranges or regions id distributed by given group with given retry limit
+// @Summary Scatter regions by given key ranges or regions id distributed by given group with given retry limit. If partitioned is true, the regions of each table partition are scattered independently and the distribution of the partitions is returned.
 // @Accept json
-// @Param body body object true "json params"
+// @Param body body object true "json params, e.g. {\"start_key\": \"\", \"end_key\": \"\", \"group\": \"\", \"partitioned\": true}"
 // @Produce json
 // @Success 200 {string} string "Scatter regions by given key ranges or regions id distributed by given group with given retry limit"
 // @Failure 400 {string} string "The input is invalid."
@@ -742,8 +1016,11 @@ func (h *regionsHandler) ScatterRegions(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		retryLimit = 5
 	}
+	partitioned, _ := input["partitioned"].(bool)
+	scatterer := rc.GetRegionScatter()
 	var ops []*operator.Operator
 	var failures map[uint64]error
+	var regions []*core.RegionInfo
 	var err error
 	if ok1 && ok2 {
 		startKey, _, err := parseKey("start_key", input)
@@ -756,14 +1033,32 @@ func (h *regionsHandler) ScatterRegions(w http.ResponseWriter, r *http.Request)
 			h.rd.JSON(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		ops, failures, err = rc.GetRegionScatter().ScatterRegionsByRange(startKey, endKey, group, retryLimit)
+		if partitioned {
+			regions = rc.ScanRegions(startKey, endKey, -1)
+			ops, failures, err = scatterer.ScatterPartitionedRegionsByRange(startKey, endKey, group, retryLimit)
+		} else {
+			ops, failures, err = scatterer.ScatterRegionsByRange(startKey, endKey, group, retryLimit)
+		}
 		if err != nil {
 			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 	} else {
-		regionsID := input["regions_id"].([]uint64)
-		ops, failures, err = rc.GetRegionScatter().ScatterRegionsByID(regionsID, group, retryLimit)
+		regionsID, ok := parseRegionsID(input["regions_id"])
+		if !ok {
+			h.rd.JSON(w, http.StatusBadRequest, "invalid regions_id")
+			return
+		}
+		if partitioned {
+			for _, id := range regionsID {
+				if region := rc.GetRegion(id); region != nil {
+					regions = append(regions, region)
+				}
+			}
+			ops, failures, err = scatterer.ScatterPartitionedRegionsByID(regionsID, group, retryLimit)
+		} else {
+			ops, failures, err = scatterer.ScatterRegionsByID(regionsID, group, retryLimit)
+		}
 		if err != nil {
 			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 			return
@@ -771,8 +1066,10 @@ func (h *regionsHandler) ScatterRegions(w http.ResponseWriter, r *http.Request)
 	}
 	// If there existed any operator failed to be added into Operator Controller, add its regions into unProcessedRegions
 	for _, op := range ops {
-		if ok := rc.GetOperatorController().AddOperator(op); !ok {
-			failures[op.RegionID()] = fmt.Errorf("region %v failed to add operator", op.RegionID())
+		op.SetSource(operator.SourceHTTP)
+		op.SetReason("scatter the regions")
+		if ok, reason := rc.GetOperatorController().AddOperatorWithReason(op); !ok {
+			failures[op.RegionID()] = fmt.Errorf("region %v failed to add operator, rejected for %s", op.RegionID(), reason)
 		}
 	}
 	percentage := 100
@@ -787,15 +1084,35 @@ func (h *regionsHandler) ScatterRegions(w http.ResponseWriter, r *http.Request)
 		}()))
 	}
 	s := struct {
-		ProcessedPercentage int `json:"processed-percentage"`
+		ProcessedPercentage int                               `json:"processed-percentage"`
+		Partitions          []*schedule.PartitionDistribution `json:"partitions,omitempty"`
 	}{
 		ProcessedPercentage: percentage,
 	}
+	if partitioned {
+		s.Partitions = scatterer.GetPartitionDistributions(regions, group)
+	}
 	h.rd.JSON(w, http.StatusOK, &s)
 }
 
+func parseRegionsID(ids interface{}) ([]uint64, bool) {
+	items, ok := ids.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	regionsID := make([]uint64, 0, len(items))
+	for _, item := range items {
+		id, ok := item.(float64)
+		if !ok {
+			return nil, false
+		}
+		regionsID = append(regionsID, uint64(id))
+	}
+	return regionsID, true
+}
+
 // @Tags region
-// @Summary Split regions with given split keys
+// @Summary Split regions with given split keys, the new regions are scattered within the group if scatter is true or enable-scatter-after-split is on.
 // @Accept json
 // @Param body body object true "json params"
 // @Produce json
@@ -830,9 +1147,16 @@ func (h *regionsHandler) SplitRegions(w http.ResponseWriter, r *http.Request) {
 		}
 		splitKeys = append(splitKeys, key)
 	}
+	scatter, ok := input["scatter"].(bool)
+	if !ok {
+		scatter = rc.GetOpts().IsScatterAfterSplitEnabled()
+	}
+	group, _ := input["group"].(string)
 	s := struct {
 		ProcessedPercentage int      `json:"processed-percentage"`
 		NewRegionsID        []uint64 `json:"regions-id"`
+		// ScatteredPercentage is only returned when the new regions are scattered.
+		ScatteredPercentage *int `json:"scattered-percentage,omitempty"`
 	}{}
 	percentage, newRegionsID := rc.GetRegionSplitter().SplitRegions(r.Context(), splitKeys, retryLimit)
 	s.ProcessedPercentage = percentage
@@ -844,9 +1168,54 @@ func (h *regionsHandler) SplitRegions(w http.ResponseWriter, r *http.Request) {
 			s.NewRegionsID = []uint64{uint64(rawID)}
 		}
 	})
+	if scatter {
+		scattered := rc.ScatterSplitRegions(s.NewRegionsID, group, retryLimit, operator.SourceHTTP)
+		s.ScatteredPercentage = &scattered
+	}
 	h.rd.JSON(w, http.StatusOK, &s)
 }
 
+// @Tags region
+// @Summary Merge the small regions in a key range aggressively, e.g. the empty regions left by dropping tables, only receive hex format for the keys.
+// @Accept json
+// @Param body body object true "json params, the max_region_size is in MB"
+// @Produce json
+// @Success 200 {object} schedule.RangeMergeProgress
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/merge-range [post]
+func (h *regionsHandler) MergeRange(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	startKey, _, err := parseKey("start_key", input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	endKey, _, err := parseKey("end_key", input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "start_key should be less than end_key")
+		return
+	}
+	var opts schedule.RangeMergeOptions
+	if size, ok := input["max_region_size"].(float64); ok {
+		opts.MaxRegionSize = int64(size)
+	}
+	if keys, ok := input["max_region_keys"].(float64); ok {
+		opts.MaxRegionKeys = int64(keys)
+	}
+	if running, ok := input["max_running"].(float64); ok {
+		opts.MaxRunning = int(running)
+	}
+	h.rd.JSON(w, http.StatusOK, rc.GetRangeMerger().MergeRange(startKey, endKey, opts))
+}
+
 // RegionHeap implements heap.Interface, used for selecting top n regions.
 type RegionHeap struct {
 	regions []*core.RegionInfo
@@ -906,3 +1275,68 @@ func TopNRegions(regions []*core.RegionInfo, less func(a, b *core.RegionInfo) bo
 	}
 	return res
 }
+
+// @Tags region
+// @Summary Prevent the regions with a key prefix from being merged for a while, only receive hex format for the prefix.
+// @Accept json
+// @Param body body object true "json params, the ttl is in seconds"
+// @Produce json
+// @Success 200 {string} string "The prefix is added to the merge blacklist."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/merge-blacklist [post]
+func (h *regionsHandler) AddMergeBlacklist(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	prefix, rawPrefix, err := parseKey("prefix", input)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(prefix) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "prefix should not be empty")
+		return
+	}
+	ttl, ok := input["ttl"].(float64)
+	if !ok || ttl <= 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "ttl should be a positive number of seconds")
+		return
+	}
+	rc.GetMergeChecker().AddMergeBlacklist(prefix, time.Duration(ttl*float64(time.Second)))
+	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("Prefix %s is added to the merge blacklist.", rawPrefix))
+}
+
+// @Tags region
+// @Summary List the key prefixes whose regions are prevented from being merged.
+// @Produce json
+// @Success 200 {array} checker.MergeBlacklistItem
+// @Router /regions/merge-blacklist [get]
+func (h *regionsHandler) GetMergeBlacklist(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetMergeChecker().GetMergeBlacklist())
+}
+
+// @Tags region
+// @Summary Remove a key prefix from the merge blacklist, only receive hex format for the prefix.
+// @Param prefix path string true "The key prefix in hex format"
+// @Produce json
+// @Success 200 {string} string "The prefix is removed from the merge blacklist."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The prefix is not found."
+// @Router /regions/merge-blacklist/{prefix} [delete]
+func (h *regionsHandler) RemoveMergeBlacklist(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	rawPrefix := mux.Vars(r)["prefix"]
+	prefix, err := hex.DecodeString(rawPrefix)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("prefix %s is not in hex format", rawPrefix))
+		return
+	}
+	if !rc.GetMergeChecker().RemoveMergeBlacklist(prefix) {
+		h.rd.JSON(w, http.StatusNotFound, fmt.Sprintf("prefix %s is not found", rawPrefix))
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, fmt.Sprintf("Prefix %s is removed from the merge blacklist.", rawPrefix))
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type regionQuarantineHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newRegionQuarantineHandler(svr *server.Server, rd *render.Render) *regionQuarantineHandler {
+	return &regionQuarantineHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// QuarantinedRegion is a suspicious region heartbeat which doesn't update the
+// routing until it is confirmed.
+type QuarantinedRegion struct {
+	// Reported is the region reported by the latest quarantined heartbeat.
+	Reported *RegionInfo `json:"reported"`
+	// Origin is the region in PD's record.
+	Origin          *RegionInfo `json:"origin"`
+	Reason          string      `json:"reason"`
+	FirstReportTime time.Time   `json:"first_report_time"`
+	LastReportTime  time.Time   `json:"last_report_time"`
+	ReportCount     int         `json:"report_count"`
+}
+
+// @Tags region
+// @Summary List the quarantined region heartbeats, whose epochs jump far ahead of PD's record along with radically different peer sets.
+// @Produce json
+// @Success 200 {array} QuarantinedRegion
+// @Router /regions/quarantined [get]
+func (h *regionQuarantineHandler) List(w http.ResponseWriter, r *http.Request) {
+	regions := getCluster(r).GetQuarantinedRegions()
+	res := make([]QuarantinedRegion, 0, len(regions))
+	for _, region := range regions {
+		res = append(res, QuarantinedRegion{
+			Reported:        NewRegionInfo(region.Region),
+			Origin:          NewRegionInfo(region.Origin),
+			Reason:          region.Reason,
+			FirstReportTime: region.FirstReportTime,
+			LastReportTime:  region.LastReportTime,
+			ReportCount:     region.ReportCount,
+		})
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+// @Tags region
+// @Summary Confirm the quarantined region heartbeat, which updates the routing.
+// @Param id path integer true "Region Id"
+// @Produce json
+// @Success 200 {string} string "The region heartbeat is confirmed."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The region heartbeat is not quarantined."
+// @Router /regions/quarantined/{id}/confirm [post]
+func (h *regionQuarantineHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := getCluster(r).ConfirmQuarantinedRegion(id); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The region heartbeat is confirmed.")
+}
+
+// @Tags region
+// @Summary Discard the quarantined region heartbeat, it is quarantined again if it is reported again.
+// @Param id path integer true "Region Id"
+// @Produce json
+// @Success 200 {string} string "The region heartbeat is discarded."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The region heartbeat is not quarantined."
+// @Router /regions/quarantined/{id} [delete]
+func (h *regionQuarantineHandler) Discard(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := getCluster(r).DiscardQuarantinedRegion(id); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The region heartbeat is discarded.")
+}
+
+func (h *regionQuarantineHandler) respondError(w http.ResponseWriter, err error) {
+	if errs.ErrRegionNotQuarantined.Equal(err) {
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,88 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testRegionQuarantineSuite{})
+
+type testRegionQuarantineSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionQuarantineSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/regions/quarantined", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+	cfg := s.svr.GetPersistOptions().GetPDServerConfig().Clone()
+	cfg.EnableHeartbeatQuarantine = true
+	s.svr.GetPersistOptions().SetPDServerConfig(cfg)
+}
+
+func (s *testRegionQuarantineSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionQuarantineSuite) TestRegionQuarantine(c *C) {
+	r := newTestRegionInfo(21, 1, []byte("a"), []byte("b"))
+	mustRegionHeartbeat(c, s.svr, r)
+	suspicious := r.Clone(
+		core.SetPeers([]*metapb.Peer{{Id: 121, StoreId: 2}}),
+		core.WithLeader(&metapb.Peer{Id: 121, StoreId: 2}),
+		core.SetRegionVersion(5000),
+	)
+	mustRegionHeartbeat(c, s.svr, suspicious)
+	rc := s.svr.GetRaftCluster()
+	c.Assert(rc.GetRegion(21).GetLeader().GetStoreId(), Equals, uint64(1))
+
+	var regions []QuarantinedRegion
+	c.Assert(readJSON(testDialClient, s.urlPrefix, &regions), IsNil)
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].Reported.ID, Equals, uint64(21))
+	c.Assert(regions[0].Reported.RegionEpoch.GetVersion(), Equals, uint64(5000))
+	c.Assert(regions[0].Origin.RegionEpoch.GetVersion(), Equals, uint64(1))
+	c.Assert(regions[0].ReportCount, Equals, 1)
+
+	// discard
+	resp, err := doDelete(testDialClient, fmt.Sprintf("%s/%d", s.urlPrefix, 21))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	resp.Body.Close()
+	resp, err = doDelete(testDialClient, fmt.Sprintf("%s/%d", s.urlPrefix, 21))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+	resp.Body.Close()
+
+	// confirm
+	mustRegionHeartbeat(c, s.svr, suspicious)
+	c.Assert(postJSON(testDialClient, fmt.Sprintf("%s/%d/confirm", s.urlPrefix, 21), nil), IsNil)
+	c.Assert(rc.GetRegion(21).GetLeader().GetStoreId(), Equals, uint64(2))
+	c.Assert(readJSON(testDialClient, s.urlPrefix, &regions), IsNil)
+	c.Assert(regions, HasLen, 0)
+	c.Assert(postJSON(testDialClient, fmt.Sprintf("%s/%d/confirm", s.urlPrefix, 21), nil), NotNil)
+}
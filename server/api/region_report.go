@@ -0,0 +1,135 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/server/statistics"
+)
+
+// BucketsReportInput is the flow of the buckets of a region reported by the
+// leader. The region heartbeat can't carry the buckets, so they are reported
+// separately. It is experimental and may be removed once the region heartbeat
+// carries the buckets.
+type BucketsReportInput struct {
+	RegionID uint64 `json:"region_id"`
+	Version  uint64 `json:"version"`
+	// Keys are the hex encoded boundaries of the buckets, the i-th bucket is
+	// [Keys[i], Keys[i+1]).
+	Keys []string `json:"keys"`
+	// Flows is the flow of each bucket keyed by the statistics kind, e.g. "read_bytes".
+	Flows map[string][]uint64 `json:"flows"`
+	// Interval is the report interval in seconds.
+	Interval uint64 `json:"interval"`
+}
+
+func (input *BucketsReportInput) toBucketsReport() (*statistics.BucketsReport, error) {
+	report := &statistics.BucketsReport{
+		RegionID: input.RegionID,
+		Version:  input.Version,
+		Keys:     make([][]byte, 0, len(input.Keys)),
+		Interval: time.Duration(input.Interval) * time.Second,
+	}
+	for _, k := range input.Keys {
+		key, err := hex.DecodeString(k)
+		if err != nil {
+			return nil, errors.Errorf("bucket key of region %d is not in hex format", input.RegionID)
+		}
+		report.Keys = append(report.Keys, key)
+	}
+	for name, flows := range input.Flows {
+		kind, ok := parseRegionStatKind(name)
+		if !ok {
+			return nil, errors.Errorf("region %d reports unknown flow kind %s", input.RegionID, name)
+		}
+		report.Flows[kind] = flows
+	}
+	return report, nil
+}
+
+func parseRegionStatKind(name string) (statistics.RegionStatKind, bool) {
+	for kind := statistics.RegionStatKind(0); kind < statistics.RegionStatCount; kind++ {
+		if kind.String() == name {
+			return kind, true
+		}
+	}
+	return 0, false
+}
+
+type reportResult struct {
+	Count    int               `json:"count"`
+	Failures map[uint64]string `json:"failures,omitempty"`
+}
+
+func (res *reportResult) add(regionID uint64, err error) {
+	if err == nil {
+		res.Count++
+		return
+	}
+	if res.Failures == nil {
+		res.Failures = make(map[uint64]string)
+	}
+	res.Failures[regionID] = err.Error()
+}
+
+// @Tags region
+// @Summary Report the flow of the buckets of regions. Experimental, the hot region scheduler splits the hot regions by the buckets only if enable-hot-bucket-split is set.
+// @Accept json
+// @Param body body []BucketsReportInput true "The buckets of the regions"
+// @Produce json
+// @Success 200 {object} string "The count of the accepted reports, with the regions whose reports are rejected."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/buckets [post]
+func (h *regionsHandler) ReportBuckets(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var inputs []*BucketsReportInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &inputs); err != nil {
+		return
+	}
+	res := &reportResult{}
+	for _, input := range inputs {
+		report, err := input.toBucketsReport()
+		if err == nil {
+			err = rc.HandleBucketsReport(report)
+		}
+		res.add(input.RegionID, err)
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
+
+// @Tags region
+// @Summary Report the apply progress of the peers of regions, which is used to calculate the replication lag of the peers.
+// @Accept json
+// @Param body body []statistics.ApplyStateReport true "The apply states of the regions"
+// @Produce json
+// @Success 200 {object} string "The count of the accepted reports, with the regions whose reports are rejected."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /regions/apply-states [post]
+func (h *regionsHandler) ReportApplyStates(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var reports []*statistics.ApplyStateReport
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &reports); err != nil {
+		return
+	}
+	res := &reportResult{}
+	for _, report := range reports {
+		res.add(report.RegionID, rc.HandleApplyStateReport(report))
+	}
+	h.rd.JSON(w, http.StatusOK, res)
+}
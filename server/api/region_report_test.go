@@ -0,0 +1,104 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/statistics"
+)
+
+var _ = Suite(&testRegionReportSuite{})
+
+type testRegionReportSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionReportSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionReportSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionReportSuite) postReports(c *C, url string, reports interface{}) *reportResult {
+	data, err := json.Marshal(reports)
+	c.Assert(err, IsNil)
+	res := &reportResult{}
+	err = postJSON(testDialClient, url, data, func(body []byte, code int) {
+		c.Assert(json.Unmarshal(body, res), IsNil)
+	})
+	c.Assert(err, IsNil)
+	return res
+}
+
+func (s *testRegionReportSuite) TestReportBuckets(c *C) {
+	r1 := newTestRegionInfo(10, 1, []byte("a"), []byte("c"))
+	r2 := newTestRegionInfo(11, 1, []byte("c"), []byte("e"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+
+	hexKeys := func(keys ...string) []string {
+		res := make([]string, 0, len(keys))
+		for _, k := range keys {
+			res = append(res, hex.EncodeToString([]byte(k)))
+		}
+		return res
+	}
+	reports := []*BucketsReportInput{
+		{
+			RegionID: 10,
+			Version:  1,
+			Keys:     hexKeys("a", "b", "c"),
+			Flows:    map[string][]uint64{"read_bytes": {100, 900}},
+			Interval: 10,
+		},
+		// The buckets don't match the range of the region.
+		{RegionID: 11, Version: 1, Keys: hexKeys("c", "d"), Interval: 10},
+		// The region doesn't exist.
+		{RegionID: 12, Version: 1, Keys: hexKeys("e", "f"), Interval: 10},
+		{RegionID: 11, Version: 1, Keys: hexKeys("c", "e"), Flows: map[string][]uint64{"unknown": {1}}, Interval: 10},
+	}
+	res := s.postReports(c, s.urlPrefix+"/regions/buckets", reports)
+	c.Assert(res.Count, Equals, 1)
+	c.Assert(res.Failures, HasLen, 2)
+	c.Assert(res.Failures[11], Matches, ".*unknown flow kind.*")
+	c.Assert(res.Failures[12], Matches, ".*not found.*")
+
+	rc := s.svr.GetRaftCluster()
+	stat := rc.RegionBucketsStat(rc.GetRegion(10))
+	c.Assert(stat, NotNil)
+	c.Assert(stat.Loads[statistics.RegionReadBytes], DeepEquals, []float64{10, 90})
+	c.Assert(stat.SplitKey(statistics.RegionReadBytes), DeepEquals, []byte("b"))
+
+	// The buckets are dropped after the region is merged.
+	merged := r2.Clone(core.WithStartKey([]byte("a")), core.WithIncVersion())
+	mustRegionHeartbeat(c, s.svr, merged)
+	c.Assert(rc.GetRegion(10), IsNil)
+	c.Assert(rc.RegionBucketsStat(r1), IsNil)
+}
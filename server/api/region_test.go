@@ -19,9 +19,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"sort"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
@@ -29,6 +31,9 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/statistics"
 )
 
 var _ = Suite(&testRegionStructSuite{})
@@ -148,6 +153,60 @@ func (s *testRegionSuite) TestRegion(c *C) {
 	c.Assert(r2, DeepEquals, NewRegionInfo(r))
 }
 
+var _ = Suite(&testRegionBoundarySuite{})
+
+type testRegionBoundarySuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionBoundarySuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionBoundarySuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionBoundarySuite) TestRegionByKeyBoundary(c *C) {
+	r1 := newTestRegionInfo(100, 1, []byte("x1"), []byte("x2"))
+	r2 := newTestRegionInfo(101, 1, []byte("x2"), []byte("x3"))
+	r3 := newTestRegionInfo(102, 1, []byte("x3"), []byte("x4"))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	mustRegionHeartbeat(c, s.svr, r3)
+
+	url := fmt.Sprintf("%s/region/key/%s", s.urlPrefix, "x2")
+	region := &RegionInfo{}
+	c.Assert(readJSON(testDialClient, url, region), IsNil)
+	c.Assert(region.ID, Equals, r2.GetID())
+	c.Assert(readJSON(testDialClient, url+"?boundary=inclusive", region), IsNil)
+	c.Assert(region.ID, Equals, r2.GetID())
+	c.Assert(readJSON(testDialClient, url+"?boundary=exclusive", region), IsNil)
+	c.Assert(region.ID, Equals, r1.GetID())
+	// The key is not a start key.
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/region/key/%s?boundary=exclusive", s.urlPrefix, "x25"), region), IsNil)
+	c.Assert(region.ID, Equals, r2.GetID())
+	c.Assert(readJSON(testDialClient, url+"?boundary=other", region), NotNil)
+
+	siblings := &RegionSiblingsInfo{}
+	c.Assert(readJSON(testDialClient, url+"?include_siblings=true", siblings), IsNil)
+	c.Assert(siblings.Region.ID, Equals, r2.GetID())
+	c.Assert(siblings.Prev.ID, Equals, r1.GetID())
+	c.Assert(siblings.Next.ID, Equals, r3.GetID())
+	siblings = &RegionSiblingsInfo{}
+	c.Assert(readJSON(testDialClient, url+"?boundary=exclusive&include_siblings=true", siblings), IsNil)
+	c.Assert(siblings.Region.ID, Equals, r1.GetID())
+	c.Assert(siblings.Next.ID, Equals, r2.GetID())
+}
+
 func (s *testRegionSuite) TestRegionCheck(c *C) {
 	r := newTestRegionInfo(2, 1, []byte("a"), []byte("b"))
 	downPeer := &metapb.Peer{Id: 13, StoreId: 2}
@@ -177,6 +236,23 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	r4.Adjust()
 	c.Assert(r4, DeepEquals, &RegionsInfo{Count: 0, Regions: []RegionInfo{}})
 
+	// The stores of the voters are unknown, so the region loses the quorum.
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "lost-quorum")
+	var lostQuorum []*statistics.LostQuorumRegion
+	c.Assert(readJSON(testDialClient, url, &lostQuorum), IsNil)
+	c.Assert(lostQuorum, HasLen, 1)
+	c.Assert(lostQuorum[0].ID, Equals, r.GetID())
+	c.Assert(lostQuorum[0].StartKey, Equals, core.HexRegionKeyStr(r.GetStartKey()))
+	c.Assert(lostQuorum[0].EndKey, Equals, core.HexRegionKeyStr(r.GetEndKey()))
+	c.Assert(lostQuorum[0].LostStores, DeepEquals, []uint64{1, 2})
+
+	// The stores are unknown, so the isolation is not violated.
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "isolation-violation")
+	isolation := &statistics.IsolationViolationReport{}
+	c.Assert(readJSON(testDialClient, url, isolation), IsNil)
+	c.Assert(isolation.Count, Equals, 0)
+	c.Assert(isolation.Regions, HasLen, 0)
+
 	r = r.Clone(core.SetApproximateSize(1))
 	mustRegionHeartbeat(c, s.svr, r)
 	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "empty-region")
@@ -200,6 +276,37 @@ func (s *testRegionSuite) TestRegionCheck(c *C) {
 	c.Assert(readJSON(testDialClient, url, &r7), IsNil)
 	histKeys := []*histItem{{Start: 1000, End: 1999, Count: 1}}
 	c.Assert(r7, DeepEquals, histKeys)
+
+	// The region without heartbeat since it is loaded is not stale.
+	url = fmt.Sprintf("%s/regions/check/%s", s.urlPrefix, "stale-region")
+	r8 := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, r8), IsNil)
+	c.Assert(containsRegion(r8, r.GetID()), IsFalse)
+	// The region not heartbeated within the threshold is stale.
+	r = r.Clone(core.SetLastHeartbeat(time.Now().Add(-time.Hour).Unix()))
+	mustRegionHeartbeat(c, s.svr, r)
+	r8 = &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url, r8), IsNil)
+	c.Assert(containsRegion(r8, r.GetID()), IsTrue)
+	// The heartbeat which doesn't change the region refreshes the timestamp.
+	r = r.Clone(core.SetLastHeartbeat(time.Now().Unix()))
+	mustRegionHeartbeat(c, s.svr, r)
+	r9 := &RegionsInfo{}
+	c.Assert(readJSON(testDialClient, url+"?threshold=5m", r9), IsNil)
+	c.Assert(containsRegion(r9, r.GetID()), IsFalse)
+	res, err := testDialClient.Get(url + "?threshold=abc")
+	c.Assert(err, IsNil)
+	res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+}
+
+func containsRegion(regions *RegionsInfo, regionID uint64) bool {
+	for _, region := range regions.Regions {
+		if region.ID == regionID {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *testRegionSuite) TestRegions(c *C) {
@@ -361,6 +468,40 @@ func (s *testRegionSuite) TestSplitRegions(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *testRegionSuite) TestSplitRegionsAndScatter(c *C) {
+	url := fmt.Sprintf("%s/regions/split", s.urlPrefix)
+	split := func(scatter string, expected *int) {
+		// The split key is out of any region, so no region is created.
+		body := fmt.Sprintf(`{"retry_limit":%v, "split_keys": ["%s"]%s}`, 0, hex.EncodeToString([]byte{0xFF, 0xFF, 0xFF, 0xFF}), scatter)
+		checkOpt := func(res []byte, code int) {
+			s := &struct {
+				ScatteredPercentage *int `json:"scattered-percentage"`
+			}{}
+			c.Assert(json.Unmarshal(res, s), IsNil)
+			c.Assert(s.ScatteredPercentage, DeepEquals, expected)
+		}
+		c.Assert(postJSON(testDialClient, url, []byte(body), checkOpt), IsNil)
+	}
+	hundred := 100
+
+	// The new regions are not scattered by default.
+	split("", nil)
+	split(`, "scatter": true, "group": "split"`, &hundred)
+
+	// The config is overridden by the request.
+	opt := s.svr.GetRaftCluster().GetOpts()
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.EnableScatterAfterSplit = true
+	opt.SetScheduleConfig(cfg)
+	defer func() {
+		cfg := opt.GetScheduleConfig().Clone()
+		cfg.EnableScatterAfterSplit = false
+		opt.SetScheduleConfig(cfg)
+	}()
+	split(`, "scatter": false`, nil)
+	split("", &hundred)
+}
+
 func (s *testRegionSuite) checkTopRegions(c *C, url string, regionIDs []uint64) {
 	regions := &RegionsInfo{}
 	err := readJSON(testDialClient, url, regions)
@@ -371,6 +512,31 @@ func (s *testRegionSuite) checkTopRegions(c *C, url string, regionIDs []uint64)
 	}
 }
 
+func (s *testRegionSuite) TestMergeBlacklist(c *C) {
+	url := fmt.Sprintf("%s/regions/merge-blacklist", s.urlPrefix)
+	prefix := hex.EncodeToString([]byte("t_1_i"))
+	body := fmt.Sprintf(`{"prefix": "%s", "ttl": 3600}`, prefix)
+	c.Assert(postJSON(testDialClient, url, []byte(body)), IsNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"prefix": "zz", "ttl": 3600}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"prefix": "", "ttl": 3600}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(fmt.Sprintf(`{"prefix": "%s"}`, prefix))), NotNil)
+
+	var items []*checker.MergeBlacklistItem
+	c.Assert(readJSON(testDialClient, url, &items), IsNil)
+	c.Assert(items, HasLen, 1)
+	c.Assert(items[0].Prefix, Equals, prefix)
+	c.Assert(items[0].ExpireAt.After(time.Now().Add(50*time.Minute)), IsTrue)
+
+	res, err := doDelete(testDialClient, url+"/"+prefix)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	res, err = doDelete(testDialClient, url+"/"+prefix)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(readJSON(testDialClient, url, &items), IsNil)
+	c.Assert(items, HasLen, 0)
+}
+
 func (s *testRegionSuite) TestTopN(c *C) {
 	writtenBytes := []uint64{10, 10, 9, 5, 3, 2, 2, 1, 0, 0}
 	for n := 0; n <= len(writtenBytes)+1; n++ {
@@ -465,6 +631,41 @@ func (s *testGetRegionSuite) TestScanRegionByKey(c *C) {
 	}
 }
 
+func (s *testGetRegionSuite) TestScanRegionsFields(c *C) {
+	// The regions are before the ones in TestScanRegionByKey.
+	r1 := newTestRegionInfo(12, 1, []byte("0a"), []byte("0b"), core.SetApproximateSize(10))
+	r2 := newTestRegionInfo(13, 1, []byte("0b"), []byte("0c"), core.SetApproximateSize(20))
+	r3 := newTestRegionInfo(14, 2, []byte("0c"), []byte("0d"), core.SetApproximateSize(30))
+	mustRegionHeartbeat(c, s.svr, r1)
+	mustRegionHeartbeat(c, s.svr, r2)
+	mustRegionHeartbeat(c, s.svr, r3)
+
+	url := fmt.Sprintf("%s/regions/range?start_key=%s&end_key=%s&fields=leader,size", s.urlPrefix, "0a", "0c")
+	regions := &RegionsFieldsInfo{}
+	c.Assert(readJSON(testDialClient, url, regions), IsNil)
+	c.Assert(regions.Count, Equals, 2)
+	for i, id := range []uint64{12, 13} {
+		region := regions.Regions[i]
+		c.Assert(region, HasLen, 3)
+		c.Assert(region["id"], Equals, float64(id))
+		c.Assert(region["size"], Equals, float64(10*(i+1)))
+		c.Assert(region["leader"].(map[string]interface{})["store_id"], Equals, float64(1))
+	}
+
+	// Only the id and keys are returned by default.
+	url = fmt.Sprintf("%s/regions/range?start_key=%s&end_key=%s", s.urlPrefix, "0b", "0d")
+	regions = &RegionsFieldsInfo{}
+	c.Assert(readJSON(testDialClient, url, regions), IsNil)
+	c.Assert(regions.Count, Equals, 2)
+	c.Assert(regions.Regions[0], DeepEquals, map[string]interface{}{"id": float64(13), "start_key": core.HexRegionKeyStr([]byte("0b")), "end_key": core.HexRegionKeyStr([]byte("0c"))})
+
+	// Invalid requests.
+	url = fmt.Sprintf("%s/regions/range?start_key=%s&fields=unknown", s.urlPrefix, "0a")
+	c.Assert(readJSON(testDialClient, url, regions), NotNil)
+	url = fmt.Sprintf("%s/regions/range?start_key=%s&end_key=%s", s.urlPrefix, "0c", "0a")
+	c.Assert(readJSON(testDialClient, url, regions), NotNil)
+}
+
 // Create n regions (0..n) of n stores (0..n).
 // Each region contains np peers, the first peer is the leader.
 // (copied from server/cluster_test.go)
@@ -531,3 +732,65 @@ func BenchmarkHexRegionKeyStr(b *testing.B) {
 		_ = core.HexRegionKeyStr(key)
 	}
 }
+
+var _ = Suite(&testRegionMergeRangeSuite{})
+
+type testRegionMergeRangeSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testRegionMergeRangeSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testRegionMergeRangeSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testRegionMergeRangeSuite) TestMergeRange(c *C) {
+	for _, id := range []uint64{1, 2, 3} {
+		mustPutStore(c, s.svr, id, metapb.StoreState_Up, nil)
+	}
+	keys := []string{"a", "b", "c", "d"}
+	for i := 0; i < len(keys)-1; i++ {
+		id := uint64(i+1) * 10
+		size := int64(1)
+		if keys[i] == "c" {
+			size = 100
+		}
+		r := newTestRegionInfo(id, 1, []byte(keys[i]), []byte(keys[i+1]), core.SetApproximateSize(size))
+		r.GetMeta().Peers = append(r.GetMeta().Peers, &metapb.Peer{Id: id + 1, StoreId: 2}, &metapb.Peer{Id: id + 2, StoreId: 3})
+		mustRegionHeartbeat(c, s.svr, r)
+	}
+
+	url := fmt.Sprintf("%s/regions/merge-range", s.urlPrefix)
+	var progress schedule.RangeMergeProgress
+	checkProgress := func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, &progress), IsNil)
+	}
+	body := fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("a")), hex.EncodeToString([]byte("d")))
+	c.Assert(postJSON(testDialClient, url, []byte(body), checkProgress), IsNil)
+	c.Assert(progress, DeepEquals, schedule.RangeMergeProgress{Regions: 3, SmallRegions: 2, RunningMerges: 1, NewMerges: 1})
+	oc := s.svr.GetRaftCluster().GetOperatorController()
+	c.Assert(oc.GetOperator(10).Desc(), Equals, schedule.RangeMergeDesc)
+	c.Assert(oc.GetOperator(20).Desc(), Equals, schedule.RangeMergeDesc)
+	c.Assert(oc.GetOperator(30), IsNil)
+
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s", "max_region_size": 100}`, hex.EncodeToString([]byte("a")), hex.EncodeToString([]byte("d")))
+	c.Assert(postJSON(testDialClient, url, []byte(body), checkProgress), IsNil)
+	c.Assert(progress, DeepEquals, schedule.RangeMergeProgress{Regions: 3, SmallRegions: 3, RunningMerges: 1})
+
+	body = fmt.Sprintf(`{"start_key":"%s", "end_key": "%s"}`, hex.EncodeToString([]byte("d")), hex.EncodeToString([]byte("a")))
+	c.Assert(postJSON(testDialClient, url, []byte(body)), NotNil)
+	body = fmt.Sprintf(`{"start_key":"%s"}`, hex.EncodeToString([]byte("a")))
+	c.Assert(postJSON(testDialClient, url, []byte(body)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"start_key":"a", "end_key": "z"}`)), NotNil)
+}
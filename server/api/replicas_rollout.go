@@ -0,0 +1,130 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/unrolled/render"
+)
+
+type replicasRolloutHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newReplicasRolloutHandler(svr *server.Server, rd *render.Render) *replicasRolloutHandler {
+	return &replicasRolloutHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+type replicasRolloutInput struct {
+	MaxReplicas int `json:"max-replicas"`
+	// BatchSize is the count of the regions rolled out in a batch.
+	BatchSize int `json:"batch-size"`
+}
+
+// @Tags config
+// @Summary Get the progress of the latest rollout of max-replicas.
+// @Produce json
+// @Success 200 {object} cluster.ReplicasRollout
+// @Failure 404 {string} string "There is no rollout."
+// @Router /config/replicate/rollout [get]
+func (h *replicasRolloutHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rollout := getCluster(r).GetReplicasRollout()
+	if rollout == nil {
+		h.rd.JSON(w, http.StatusNotFound, "no rollout of max-replicas")
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rollout)
+}
+
+// @Tags config
+// @Summary Start a staged rollout of max-replicas, which changes the count of the replicas batch by batch. It requires placement rules to be enabled.
+// @Accept json
+// @Param body body object true "json params, e.g. {\"max-replicas\": 5, \"batch-size\": 1000}"
+// @Produce json
+// @Success 200 {string} string "The rollout is started."
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 409 {string} string "A rollout is in progress."
+// @Router /config/replicate/rollout [post]
+func (h *replicasRolloutHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var input replicasRolloutInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if err := getCluster(r).StartReplicasRollout(input.MaxReplicas, input.BatchSize); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The rollout is started.")
+}
+
+// @Tags config
+// @Summary Pause the rollout of max-replicas, the next batch is not started until it is resumed.
+// @Produce json
+// @Success 200 {string} string "The rollout is paused."
+// @Failure 404 {string} string "There is no rollout in progress."
+// @Router /config/replicate/rollout/pause [post]
+func (h *replicasRolloutHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	if err := getCluster(r).PauseReplicasRollout(); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The rollout is paused.")
+}
+
+// @Tags config
+// @Summary Resume the paused rollout of max-replicas.
+// @Produce json
+// @Success 200 {string} string "The rollout is resumed."
+// @Failure 404 {string} string "There is no rollout in progress."
+// @Router /config/replicate/rollout/resume [post]
+func (h *replicasRolloutHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	if err := getCluster(r).ResumeReplicasRollout(); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The rollout is resumed.")
+}
+
+// @Tags config
+// @Summary Abort the rollout of max-replicas, the regions which are rolled out go back to the old count.
+// @Produce json
+// @Success 200 {string} string "The rollout is aborted."
+// @Failure 404 {string} string "There is no rollout in progress."
+// @Router /config/replicate/rollout [delete]
+func (h *replicasRolloutHandler) Abort(w http.ResponseWriter, r *http.Request) {
+	if err := getCluster(r).AbortReplicasRollout(); err != nil {
+		h.respondError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The rollout is aborted.")
+}
+
+func (h *replicasRolloutHandler) respondError(w http.ResponseWriter, err error) {
+	switch {
+	case errs.ErrReplicasRolloutNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+	case errs.ErrReplicasRolloutInProgress.Equal(err):
+		h.rd.JSON(w, http.StatusConflict, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	}
+}
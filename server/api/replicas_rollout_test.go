@@ -0,0 +1,81 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+)
+
+var _ = Suite(&testReplicasRolloutSuite{})
+
+type testReplicasRolloutSuite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+func (s *testReplicasRolloutSuite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s/api/v1/config/replicate", addr, apiPrefix)
+
+	mustBootstrapCluster(c, s.svr)
+}
+
+func (s *testReplicasRolloutSuite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func (s *testReplicasRolloutSuite) TestReplicasRollout(c *C) {
+	url := s.urlPrefix + "/rollout"
+	rollout := &cluster.ReplicasRollout{}
+	c.Assert(readJSON(testDialClient, url, rollout), NotNil)
+	c.Assert(postJSON(testDialClient, url+"/pause", nil), NotNil)
+
+	// The count is not changed.
+	c.Assert(postJSON(testDialClient, url, []byte(`{"max-replicas": 3}`)), NotNil)
+	c.Assert(postJSON(testDialClient, url, []byte(`{"max-replicas": 5, "batch-size": 100}`)), IsNil)
+	c.Assert(readJSON(testDialClient, url, rollout), IsNil)
+	c.Assert(rollout.From, Equals, 3)
+	c.Assert(rollout.To, Equals, 5)
+	c.Assert(rollout.BatchSize, Equals, 100)
+	c.Assert(rollout.State, Equals, cluster.ReplicasRolloutRunning)
+
+	// Another rollout and the change of max-replicas are rejected.
+	c.Assert(postJSON(testDialClient, url, []byte(`{"max-replicas": 4}`)), NotNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix, []byte(`{"max-replicas": 4}`)), NotNil)
+
+	c.Assert(postJSON(testDialClient, url+"/pause", nil), IsNil)
+	c.Assert(readJSON(testDialClient, url, rollout), IsNil)
+	c.Assert(rollout.State, Equals, cluster.ReplicasRolloutPaused)
+	c.Assert(postJSON(testDialClient, url+"/resume", nil), IsNil)
+	c.Assert(readJSON(testDialClient, url, rollout), IsNil)
+	c.Assert(rollout.State, Equals, cluster.ReplicasRolloutRunning)
+
+	res, err := doDelete(testDialClient, url)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(readJSON(testDialClient, url, rollout), IsNil)
+	c.Assert(rollout.State, Equals, cluster.ReplicasRolloutAborted)
+	res, err = doDelete(testDialClient, url)
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+}
@@ -54,6 +54,8 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	apiPrefix := "/api/v1"
 	apiRouter := rootRouter.PathPrefix(apiPrefix).Subrouter()
+	apiRouter.Use(newIdempotencyMiddleware(svr).Middleware)
+	registerV2Routes(rootRouter, svr, rd)
 
 	clusterRouter := apiRouter.NewRoute().Subrouter()
 	clusterRouter.Use(newClusterMiddleware(svr).Middleware)
@@ -69,6 +71,8 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/schedulers", schedulerHandler.Post).Methods("POST")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
 	apiRouter.HandleFunc("/schedulers/{name}", schedulerHandler.PauseOrResume).Methods("POST")
+	apiRouter.HandleFunc("/schedulers/{name}/stats", schedulerHandler.GetStats).Methods("GET")
+	apiRouter.HandleFunc("/schedulers/{name}/evaluate", schedulerHandler.Evaluate).Methods("POST")
 
 	schedulerConfigHandler := newSchedulerConfigHandler(svr, rd)
 	apiRouter.PathPrefix("/scheduler-config").Handler(schedulerConfigHandler)
@@ -76,13 +80,18 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterHandler := newClusterHandler(svr, rd)
 	apiRouter.Handle("/cluster", clusterHandler).Methods("GET")
 	apiRouter.HandleFunc("/cluster/status", clusterHandler.GetClusterStatus).Methods("GET")
+	apiRouter.HandleFunc("/cluster/features", clusterHandler.GetClusterFeatures).Methods("GET")
+	clusterRouter.HandleFunc("/cluster/dump", clusterHandler.Dump).Methods("GET")
 
 	confHandler := newConfHandler(svr, rd)
 	apiRouter.HandleFunc("/config", confHandler.Get).Methods("GET")
 	apiRouter.HandleFunc("/config", confHandler.Post).Methods("POST")
 	apiRouter.HandleFunc("/config/default", confHandler.GetDefault).Methods("GET")
+	apiRouter.HandleFunc("/config/diff", confHandler.GetDiff).Methods("GET")
 	apiRouter.HandleFunc("/config/schedule", confHandler.GetSchedule).Methods("GET")
 	apiRouter.HandleFunc("/config/schedule", confHandler.SetSchedule).Methods("POST")
+	apiRouter.HandleFunc("/config/schedule/override", confHandler.GetScheduleOverrides).Methods("GET")
+	apiRouter.HandleFunc("/config/schedule/override", confHandler.OverrideSchedule).Methods("POST")
 	apiRouter.HandleFunc("/config/replicate", confHandler.GetReplication).Methods("GET")
 	apiRouter.HandleFunc("/config/replicate", confHandler.SetReplication).Methods("POST")
 	apiRouter.HandleFunc("/config/label-property", confHandler.GetLabelProperty).Methods("GET")
@@ -92,6 +101,28 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/config/replication-mode", confHandler.GetReplicationMode).Methods("GET")
 	apiRouter.HandleFunc("/config/replication-mode", confHandler.SetReplicationMode).Methods("POST")
 
+	keyspaceHandler := newKeyspaceHandler(svr, rd)
+	apiRouter.HandleFunc("/keyspaces", keyspaceHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/keyspaces", keyspaceHandler.Create).Methods("POST")
+	apiRouter.HandleFunc("/keyspaces/id/{id}", keyspaceHandler.GetByID).Methods("GET")
+	apiRouter.HandleFunc("/keyspaces/{name}", keyspaceHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/keyspaces/{name}/enable", keyspaceHandler.Enable).Methods("POST")
+	apiRouter.HandleFunc("/keyspaces/{name}/disable", keyspaceHandler.Disable).Methods("POST")
+
+	profileHandler := newProfileHandler(svr, rd)
+	apiRouter.HandleFunc("/config/profiles", profileHandler.List).Methods("GET")
+	apiRouter.HandleFunc("/config/profile", profileHandler.Set).Methods("POST")
+	apiRouter.HandleFunc("/config/profile/{name}", profileHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/config/profile/{name}", profileHandler.Delete).Methods("DELETE")
+	apiRouter.HandleFunc("/config/profile/{name}/apply", profileHandler.Apply).Methods("POST")
+
+	replicasRolloutHandler := newReplicasRolloutHandler(svr, rd)
+	clusterRouter.HandleFunc("/config/replicate/rollout", replicasRolloutHandler.Get).Methods("GET")
+	clusterRouter.HandleFunc("/config/replicate/rollout", replicasRolloutHandler.Start).Methods("POST")
+	clusterRouter.HandleFunc("/config/replicate/rollout", replicasRolloutHandler.Abort).Methods("DELETE")
+	clusterRouter.HandleFunc("/config/replicate/rollout/pause", replicasRolloutHandler.Pause).Methods("POST")
+	clusterRouter.HandleFunc("/config/replicate/rollout/resume", replicasRolloutHandler.Resume).Methods("POST")
+
 	rulesHandler := newRulesHandler(svr, rd)
 	clusterRouter.HandleFunc("/config/rules", rulesHandler.GetAll).Methods("GET")
 	clusterRouter.HandleFunc("/config/rules", rulesHandler.SetAll).Methods("POST")
@@ -99,6 +130,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterRouter.HandleFunc("/config/rules/group/{group}", rulesHandler.GetAllByGroup).Methods("GET")
 	clusterRouter.HandleFunc("/config/rules/region/{region}", rulesHandler.GetAllByRegion).Methods("GET")
 	clusterRouter.HandleFunc("/config/rules/key/{key}", rulesHandler.GetAllByKey).Methods("GET")
+	clusterRouter.HandleFunc("/config/rules/watch", rulesHandler.Watch).Methods("GET")
 	clusterRouter.HandleFunc("/config/rule/{group}/{id}", rulesHandler.Get).Methods("GET")
 	clusterRouter.HandleFunc("/config/rule", rulesHandler.Set).Methods("POST")
 	clusterRouter.HandleFunc("/config/rule/{group}/{id}", rulesHandler.Delete).Methods("DELETE")
@@ -124,13 +156,23 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterRouter.HandleFunc("/store/{id}/label", storeHandler.SetLabels).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/weight", storeHandler.SetWeight).Methods("POST")
 	clusterRouter.HandleFunc("/store/{id}/limit", storeHandler.SetLimit).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/snapshot", storeHandler.GetSnapshotStats).Methods("GET")
+	clusterRouter.HandleFunc("/store/{id}/history", storeHandler.GetHistory).Methods("GET")
+	clusterRouter.HandleFunc("/store/{id}/purge", storeHandler.GetPurgeStatus).Methods("GET")
+	clusterRouter.HandleFunc("/store/{id}/tombstone-ack", storeHandler.AckTombstone).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/restart", storeHandler.PrepareRestart).Methods("POST")
+	clusterRouter.HandleFunc("/store/{id}/restart", storeHandler.CancelRestart).Methods("DELETE")
 	storesHandler := newStoresHandler(handler, rd)
 	clusterRouter.Handle("/stores", storesHandler).Methods("GET")
+	clusterRouter.HandleFunc("/stores", storesHandler.EnqueueRemovals).Methods("DELETE")
+	clusterRouter.HandleFunc("/stores/removal-queue", storesHandler.GetRemovalQueue).Methods("GET")
 	clusterRouter.HandleFunc("/stores/remove-tombstone", storesHandler.RemoveTombStone).Methods("DELETE")
+	clusterRouter.HandleFunc("/stores/remove", storesHandler.RemoveStores).Methods("POST")
 	clusterRouter.HandleFunc("/stores/limit", storesHandler.GetAllLimit).Methods("GET")
 	clusterRouter.HandleFunc("/stores/limit", storesHandler.SetAllLimit).Methods("POST")
 	clusterRouter.HandleFunc("/stores/limit/scene", storesHandler.SetStoreLimitScene).Methods("POST")
 	clusterRouter.HandleFunc("/stores/limit/scene", storesHandler.GetStoreLimitScene).Methods("GET")
+	clusterRouter.HandleFunc("/stores/rolling-restart", storesHandler.GetRollingRestart).Methods("GET")
 
 	labelsHandler := newLabelsHandler(svr, rd)
 	clusterRouter.HandleFunc("/labels", labelsHandler.Get).Methods("GET")
@@ -151,6 +193,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	regionsHandler := newRegionsHandler(svr, rd)
 	clusterRouter.HandleFunc("/regions/key", regionsHandler.ScanRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/range", regionsHandler.ScanRegionsFields).Methods("GET")
 	clusterRouter.HandleFunc("/regions/count", regionsHandler.GetRegionCount).Methods("GET")
 	clusterRouter.HandleFunc("/regions/store/{id}", regionsHandler.GetStoreRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/writeflow", regionsHandler.GetTopWriteFlow).Methods("GET")
@@ -165,19 +208,42 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	clusterRouter.HandleFunc("/regions/check/learner-peer", regionsHandler.GetLearnerPeerRegions).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/empty-region", regionsHandler.GetEmptyRegion).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/offline-peer", regionsHandler.GetOfflinePeer).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/invalid-peer", regionsHandler.GetInvalidPeerRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/lost-quorum", regionsHandler.GetLostQuorumRegions).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/isolation-violation", regionsHandler.GetIsolationViolationReport).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check/stale-region", regionsHandler.GetStaleRegions).Methods("GET")
 
 	clusterRouter.HandleFunc("/regions/check/hist-size", regionsHandler.GetSizeHistogram).Methods("GET")
 	clusterRouter.HandleFunc("/regions/check/hist-keys", regionsHandler.GetKeysHistogram).Methods("GET")
 	clusterRouter.HandleFunc("/regions/sibling/{id}", regionsHandler.GetRegionSiblings).Methods("GET")
+	clusterRouter.HandleFunc("/regions/check-gaps", regionsHandler.CheckRegionGaps).Methods("GET")
 	clusterRouter.HandleFunc("/regions/accelerate-schedule", regionsHandler.AccelerateRegionsScheduleInRange).Methods("POST")
 	clusterRouter.HandleFunc("/regions/scatter", regionsHandler.ScatterRegions).Methods("POST")
 	clusterRouter.HandleFunc("/regions/split", regionsHandler.SplitRegions).Methods("POST")
+	clusterRouter.HandleFunc("/regions/merge-range", regionsHandler.MergeRange).Methods("POST")
+	clusterRouter.HandleFunc("/regions/buckets", regionsHandler.ReportBuckets).Methods("POST")
+	clusterRouter.HandleFunc("/regions/apply-states", regionsHandler.ReportApplyStates).Methods("POST")
+	clusterRouter.HandleFunc("/regions/merge-blacklist", regionsHandler.AddMergeBlacklist).Methods("POST")
+	clusterRouter.HandleFunc("/regions/merge-blacklist", regionsHandler.GetMergeBlacklist).Methods("GET")
+	clusterRouter.HandleFunc("/regions/merge-blacklist/{prefix}", regionsHandler.RemoveMergeBlacklist).Methods("DELETE")
+
+	pinnedRegionHandler := newPinnedRegionHandler(svr, rd)
+	clusterRouter.HandleFunc("/regions/pinned", pinnedRegionHandler.List).Methods("GET")
+	clusterRouter.HandleFunc("/regions/pinned", pinnedRegionHandler.Pin).Methods("POST")
+	clusterRouter.HandleFunc("/regions/pinned", pinnedRegionHandler.Unpin).Methods("DELETE")
+
+	regionQuarantineHandler := newRegionQuarantineHandler(svr, rd)
+	clusterRouter.HandleFunc("/regions/quarantined", regionQuarantineHandler.List).Methods("GET")
+	clusterRouter.HandleFunc("/regions/quarantined/{id}/confirm", regionQuarantineHandler.Confirm).Methods("POST")
+	clusterRouter.HandleFunc("/regions/quarantined/{id}", regionQuarantineHandler.Discard).Methods("DELETE")
 
 	apiRouter.Handle("/version", newVersionHandler(rd)).Methods("GET")
+	apiRouter.Handle("/version/services", newServiceVersionsHandler(svr, rd)).Methods("GET")
 	apiRouter.Handle("/status", newStatusHandler(svr, rd)).Methods("GET")
 
 	memberHandler := newMemberHandler(svr, rd)
 	apiRouter.HandleFunc("/members", memberHandler.ListMembers).Methods("GET")
+	apiRouter.HandleFunc("/members/fitness", memberHandler.GetLeaderFitness).Methods("GET")
 	apiRouter.HandleFunc("/members/name/{name}", memberHandler.DeleteByName).Methods("DELETE")
 	apiRouter.HandleFunc("/members/id/{id}", memberHandler.DeleteByID).Methods("DELETE")
 	apiRouter.HandleFunc("/members/name/{name}", memberHandler.SetMemberPropertyByName).Methods("POST")
@@ -189,6 +255,16 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	statsHandler := newStatsHandler(svr, rd)
 	clusterRouter.HandleFunc("/stats/region", statsHandler.Region).Methods("GET")
+	clusterRouter.HandleFunc("/stats/replication-lag", statsHandler.ReplicationLag).Methods("GET")
+	clusterRouter.HandleFunc("/stats/cluster", statsHandler.Cluster).Methods("GET")
+
+	debugHandler := newDebugHandler(svr, rd)
+	clusterRouter.HandleFunc("/debug/tolerant-ratio", debugHandler.GetTolerantRatio).Methods("GET")
+	clusterRouter.HandleFunc("/debug/store-scores", debugHandler.GetStoreScores).Methods("GET")
+	apiRouter.HandleFunc("/debug/leadership", debugHandler.GetLeadership).Methods("GET")
+	apiRouter.HandleFunc("/debug/heartbeat-streams", debugHandler.GetHeartbeatStreams).Methods("GET")
+	apiRouter.HandleFunc("/debug/tso", debugHandler.GetTSOStats).Methods("GET")
+	apiRouter.HandleFunc("/debug/profile", debugHandler.CaptureProfile).Methods("POST")
 
 	trendHandler := newTrendHandler(svr, rd)
 	apiRouter.HandleFunc("/trend", trendHandler.Handle).Methods("GET")
@@ -196,6 +272,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	adminHandler := newAdminHandler(svr, rd)
 	clusterRouter.HandleFunc("/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
 	clusterRouter.HandleFunc("/admin/reset-ts", adminHandler.ResetTS).Methods("POST")
+	clusterRouter.HandleFunc("/admin/regions", adminHandler.BatchPutRegions).Methods("POST")
 	apiRouter.HandleFunc("/admin/persist-file/{file_name}", adminHandler.persistFile).Methods("POST")
 	clusterRouter.HandleFunc("/admin/replication_mode/wait-async", adminHandler.UpdateWaitAsyncTime).Methods("POST")
 
@@ -216,6 +293,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	apiRouter.HandleFunc("/plugin", pluginHandler.UnloadPlugin).Methods("DELETE")
 
 	apiRouter.Handle("/health", newHealthHandler(svr, rd)).Methods("GET")
+	apiRouter.Handle("/ready", newReadyHandler(svr, rd)).Methods("GET")
 	apiRouter.Handle("/diagnose", newDiagnoseHandler(svr, rd)).Methods("GET")
 	apiRouter.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
 	// metric query use to query metric data, the protocol is compatible with prometheus.
@@ -226,6 +304,10 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	tsoHandler := newTSOHandler(svr, rd)
 	apiRouter.HandleFunc("/tso/allocator/transfer/{name}", tsoHandler.TransferLocalTSOAllocator).Methods("POST")
 
+	// alert API
+	alertHandler := newAlertHandler(svr, rd)
+	apiRouter.HandleFunc("/alerts", alertHandler.GetAlerts).Methods("GET")
+
 	// profile API
 	apiRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
 	apiRouter.HandleFunc("/debug/pprof/trace", pprof.Trace)
@@ -14,11 +14,13 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
@@ -45,8 +47,11 @@ func newRulesHandler(svr *server.Server, rd *render.Render) *ruleHandler {
 
 // @Tags rule
 // @Summary List all rules of cluster.
+// @Param offset query integer false "The offset of the page, the total count is returned in the X-Total-Count header."
+// @Param limit query integer false "The size of the page."
 // @Produce json
 // @Success 200 {array} placement.Rule
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 412 {string} string "Placement rules feature is disabled."
 // @Router /config/rules [get]
 func (h *ruleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -55,8 +60,14 @@ func (h *ruleHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
 		return
 	}
+	page, err := parseListPage(r)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	rules := cluster.GetRuleManager().GetAllRules()
-	h.rd.JSON(w, http.StatusOK, rules)
+	start, end := page.apply(w, len(rules))
+	h.rd.JSON(w, http.StatusOK, rules[start:end])
 }
 
 // @Tags rule
@@ -99,8 +110,11 @@ func (h *ruleHandler) SetAll(w http.ResponseWriter, r *http.Request) {
 // @Tags rule
 // @Summary List all rules of cluster by group.
 // @Param group path string true "The name of group"
+// @Param offset query integer false "The offset of the page, the total count is returned in the X-Total-Count header."
+// @Param limit query integer false "The size of the page."
 // @Produce json
 // @Success 200 {array} placement.Rule
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 412 {string} string "Placement rules feature is disabled."
 // @Router /config/rules/group/{group} [get]
 func (h *ruleHandler) GetAllByGroup(w http.ResponseWriter, r *http.Request) {
@@ -109,9 +123,15 @@ func (h *ruleHandler) GetAllByGroup(w http.ResponseWriter, r *http.Request) {
 		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
 		return
 	}
+	page, err := parseListPage(r)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	group := mux.Vars(r)["group"]
 	rules := cluster.GetRuleManager().GetRulesByGroup(group)
-	h.rd.JSON(w, http.StatusOK, rules)
+	start, end := page.apply(w, len(rules))
+	h.rd.JSON(w, http.StatusOK, rules[start:end])
 }
 
 // @Tags rule
@@ -168,6 +188,71 @@ func (h *ruleHandler) GetAllByKey(w http.ResponseWriter, r *http.Request) {
 	h.rd.JSON(w, http.StatusOK, rules)
 }
 
+const (
+	defaultRuleWatchTimeout = 30 * time.Second
+	maxRuleWatchTimeout     = 5 * time.Minute
+)
+
+// RuleChanges is the result of watching the placement rules.
+type RuleChanges struct {
+	// Revision is the revision to resume watching from.
+	Revision int64                `json:"revision"`
+	Changes  []*server.RuleChange `json:"changes"`
+}
+
+// @Tags rule
+// @Summary Watch the changes of placement rules and rule groups after the revision. It returns once any change happens or the timeout is reached. If the revision is not specified, the current revision is returned immediately, the caller can list the rules and then watch from that revision without missing any change.
+// @Param revision query integer false "The revision to watch from"
+// @Param timeout query string false "The max duration to wait for changes, 30s by default"
+// @Produce json
+// @Success 200 {object} RuleChanges
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 410 {string} string "The revision has been compacted, the caller should list the rules again."
+// @Failure 412 {string} string "Placement rules feature is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /config/rules/watch [get]
+func (h *ruleHandler) Watch(w http.ResponseWriter, r *http.Request) {
+	cluster := getCluster(r)
+	if !cluster.GetOpts().IsPlacementRulesEnabled() {
+		h.rd.JSON(w, http.StatusPreconditionFailed, errPlacementDisabled.Error())
+		return
+	}
+	var revision int64
+	if revisionStr := r.URL.Query().Get("revision"); revisionStr != "" {
+		var err error
+		if revision, err = strconv.ParseInt(revisionStr, 10, 64); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	timeout := defaultRuleWatchTimeout
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		var err error
+		if timeout, err = time.ParseDuration(timeoutStr); err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if timeout > maxRuleWatchTimeout {
+			timeout = maxRuleWatchTimeout
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	revision, changes, err := h.svr.WatchPlacementRules(ctx, revision)
+	if err != nil {
+		if errs.ErrRuleCompacted.Equal(err) {
+			h.rd.JSON(w, http.StatusGone, err.Error())
+			return
+		}
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if changes == nil {
+		changes = []*server.RuleChange{}
+	}
+	h.rd.JSON(w, http.StatusOK, &RuleChanges{Revision: revision, Changes: changes})
+}
+
 // @Tags rule
 // @Summary Get rule of cluster by group and id.
 // @Param group path string true "The name of group"
@@ -229,6 +229,46 @@ func (s *testRuleSuite) TestGetAll(c *C) {
 	c.Assert(len(resp2), GreaterEqual, 1)
 }
 
+func (s *testRuleSuite) TestGetAllPaginated(c *C) {
+	for i := 1; i <= 4; i++ {
+		rule := placement.Rule{GroupID: "page", ID: fmt.Sprintf("%d", i), StartKeyHex: "1111", EndKeyHex: "3333", Role: "voter", Count: 1}
+		data, err := json.Marshal(rule)
+		c.Assert(err, IsNil)
+		err = postJSON(testDialClient, s.urlPrefix+"/rule", data)
+		c.Assert(err, IsNil)
+	}
+
+	readPage := func(url string) ([]*placement.Rule, string) {
+		resp, err := testDialClient.Get(url)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		var rules []*placement.Rule
+		c.Assert(json.NewDecoder(resp.Body).Decode(&rules), IsNil)
+		return rules, resp.Header.Get(totalCountHeader)
+	}
+	rules, total := readPage(s.urlPrefix + "/rules/group/page?offset=1&limit=2")
+	c.Assert(total, Equals, "4")
+	c.Assert(rules, HasLen, 2)
+	c.Assert(rules[0].ID, Equals, "2")
+	c.Assert(rules[1].ID, Equals, "3")
+	rules, total = readPage(s.urlPrefix + "/rules/group/page?offset=3")
+	c.Assert(total, Equals, "4")
+	c.Assert(rules, HasLen, 1)
+	c.Assert(rules[0].ID, Equals, "4")
+	rules, _ = readPage(s.urlPrefix + "/rules/group/page?offset=10")
+	c.Assert(rules, HasLen, 0)
+	// The whole list is returned without the page parameters.
+	rules, total = readPage(s.urlPrefix + "/rules")
+	c.Assert(total, Equals, "5")
+	c.Assert(rules, HasLen, 5)
+
+	resp, err := testDialClient.Get(s.urlPrefix + "/rules?limit=0")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+}
+
 func (s *testRuleSuite) TestSetAll(c *C) {
 	rule1 := placement.Rule{GroupID: "a", ID: "12", StartKeyHex: "1111", EndKeyHex: "3333", Role: "voter", Count: 1}
 	rule2 := placement.Rule{GroupID: "b", ID: "12", StartKeyHex: "1111", EndKeyHex: "3333", Role: "voter", Count: 1}
@@ -667,6 +707,55 @@ func (s *testRuleSuite) TestBatch(c *C) {
 	}
 }
 
+func (s *testRuleSuite) TestWatch(c *C) {
+	var res RuleChanges
+	err := readJSON(testDialClient, s.urlPrefix+"/rules/watch", &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Revision, Greater, int64(0))
+	c.Assert(res.Changes, HasLen, 0)
+	revision := res.Revision
+
+	rule := placement.Rule{GroupID: "a", ID: "watch", StartKeyHex: "1111", EndKeyHex: "3333", Role: "voter", Count: 1}
+	data, err := json.Marshal(rule)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/rule", data)
+	c.Assert(err, IsNil)
+
+	err = readJSON(testDialClient, fmt.Sprintf("%s/rules/watch?revision=%d", s.urlPrefix, revision), &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Revision, Greater, revision)
+	c.Assert(res.Changes, HasLen, 1)
+	c.Assert(res.Changes[0].Type, Equals, "put")
+	c.Assert(res.Changes[0].Kind, Equals, "rule")
+	c.Assert(res.Changes[0].Key, Equals, rule.StoreKey())
+	var changed placement.Rule
+	c.Assert(json.Unmarshal(res.Changes[0].Value, &changed), IsNil)
+	compareRule(c, &changed, &rule)
+	revision = res.Revision
+
+	// No change happens before the timeout.
+	err = readJSON(testDialClient, fmt.Sprintf("%s/rules/watch?revision=%d&timeout=100ms", s.urlPrefix, revision), &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Revision, Equals, revision)
+	c.Assert(res.Changes, HasLen, 0)
+
+	_, err = doDelete(testDialClient, s.urlPrefix+"/rule/a/watch")
+	c.Assert(err, IsNil)
+	err = readJSON(testDialClient, fmt.Sprintf("%s/rules/watch?revision=%d", s.urlPrefix, revision), &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Changes, HasLen, 1)
+	c.Assert(res.Changes[0].Type, Equals, "delete")
+	c.Assert(res.Changes[0].Key, Equals, rule.StoreKey())
+
+	// Resume from an old revision.
+	err = readJSON(testDialClient, fmt.Sprintf("%s/rules/watch?revision=%d", s.urlPrefix, revision-1), &res)
+	c.Assert(err, IsNil)
+	c.Assert(res.Changes[0].Revision, Equals, revision)
+
+	err = readJSON(testDialClient, s.urlPrefix+"/rules/watch?revision=abc", &res)
+	c.Assert(err, NotNil)
+}
+
 func (s *testRuleSuite) TestBundle(c *C) {
 	// GetAll
 	b1 := placement.GroupBundle{
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -25,6 +26,7 @@ import (
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/schedulers"
 	"github.com/unrolled/render"
 	"go.uber.org/zap"
@@ -32,6 +34,21 @@ import (
 
 const schedulerConfigPrefix = "pd/api/v1/scheduler-config"
 
+// The status of schedulers.
+const (
+	schedulerStatusRunning  = "running"
+	schedulerStatusPaused   = "paused"
+	schedulerStatusDisabled = "disabled"
+)
+
+// SchedulerStatus is the status of a scheduler, with the record of who paused or
+// disabled the scheduler and why.
+type SchedulerStatus struct {
+	Name          string                          `json:"name"`
+	Status        string                          `json:"status"`
+	DisableRecord *cluster.SchedulerDisableRecord `json:"disable_record,omitempty"`
+}
+
 type schedulerHandler struct {
 	*server.Handler
 	svr *server.Server
@@ -47,17 +64,28 @@ func newSchedulerHandler(svr *server.Server, r *render.Render) *schedulerHandler
 }
 
 // @Tags scheduler
-// @Summary List all schedulers by status.
+// @Summary List all schedulers by status. The status `all` lists the status of all schedulers with the disable records.
+// @Param status query string false "Filter by status" Enums(paused, disabled, all)
+// @Param offset query integer false "The offset of the page, the total count is returned in the X-Total-Count header."
+// @Param limit query integer false "The size of the page."
 // @Produce json
 // @Success 200 {array} string
+// @Failure 400 {string} string "The input is invalid."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /schedulers [get]
 func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
+	page, err := parseListPage(r)
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	schedulers, err := h.GetSchedulers()
 	if err != nil {
 		h.r.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Sort the schedulers so that the pages are stable.
+	sort.Strings(schedulers)
 
 	status := r.URL.Query().Get("status")
 	switch status {
@@ -74,7 +102,8 @@ func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 				pausedSchedulers = append(pausedSchedulers, scheduler)
 			}
 		}
-		h.r.JSON(w, http.StatusOK, pausedSchedulers)
+		start, end := page.apply(w, len(pausedSchedulers))
+		h.r.JSON(w, http.StatusOK, pausedSchedulers[start:end])
 		return
 	case "disabled":
 		var disabledSchedulers []string
@@ -89,9 +118,52 @@ func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 				disabledSchedulers = append(disabledSchedulers, scheduler)
 			}
 		}
-		h.r.JSON(w, http.StatusOK, disabledSchedulers)
+		start, end := page.apply(w, len(disabledSchedulers))
+		h.r.JSON(w, http.StatusOK, disabledSchedulers[start:end])
+	case "all":
+		statuses := make([]SchedulerStatus, 0, len(schedulers))
+		for _, scheduler := range schedulers {
+			paused, err := h.IsSchedulerPaused(scheduler)
+			if err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			disabled, err := h.IsSchedulerDisabled(scheduler)
+			if err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			record, err := h.GetSchedulerDisableRecord(scheduler)
+			if err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			status := SchedulerStatus{Name: scheduler, Status: schedulerStatusRunning, DisableRecord: record}
+			if disabled {
+				status.Status = schedulerStatusDisabled
+			} else if paused {
+				status.Status = schedulerStatusPaused
+			}
+			statuses = append(statuses, status)
+		}
+		disabled, err := h.GetDisabledSchedulers()
+		if err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, scheduler := range disabled {
+			record, err := h.GetSchedulerDisableRecord(scheduler)
+			if err != nil {
+				h.r.JSON(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			statuses = append(statuses, SchedulerStatus{Name: scheduler, Status: schedulerStatusDisabled, DisableRecord: record})
+		}
+		start, end := page.apply(w, len(statuses))
+		h.r.JSON(w, http.StatusOK, statuses[start:end])
 	default:
-		h.r.JSON(w, http.StatusOK, schedulers)
+		start, end := page.apply(w, len(schedulers))
+		h.r.JSON(w, http.StatusOK, schedulers[start:end])
 	}
 }
 
@@ -192,6 +264,20 @@ func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 			h.r.JSON(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+	case schedulers.GrantHotLeaderName:
+		labels, ok := input["store_labels"].(string)
+		if !ok {
+			h.r.JSON(w, http.StatusBadRequest, "missing store labels")
+			return
+		}
+		count := uint64(0)
+		if c, ok := input["hot_region_count"].(float64); ok {
+			count = uint64(c)
+		}
+		if err := h.AddGrantHotLeaderScheduler(labels, count); err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
 	default:
 		h.r.JSON(w, http.StatusBadRequest, "unknown scheduler")
 		return
@@ -290,7 +376,7 @@ func (h *schedulerHandler) redirectSchedulerDelete(w http.ResponseWriter, name,
 
 // FIXME: details of input json body params
 // @Tags scheduler
-// @Summary Pause or resume a scheduler.
+// @Summary Pause or resume a scheduler. The optional `actor` and `reason` in the body are recorded for the pause.
 // @Accept json
 // @Param name path string true "The name of the scheduler."
 // @Param body body object true "json params"
@@ -300,24 +386,74 @@ func (h *schedulerHandler) redirectSchedulerDelete(w http.ResponseWriter, name,
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /schedulers/{name} [post]
 func (h *schedulerHandler) PauseOrResume(w http.ResponseWriter, r *http.Request) {
-	var input map[string]int
+	var input map[string]interface{}
 	if err := apiutil.ReadJSONRespondError(h.r, w, r.Body, &input); err != nil {
 		return
 	}
 
 	name := mux.Vars(r)["name"]
-	t, ok := input["delay"]
+	t, ok := input["delay"].(float64)
 	if !ok {
 		h.r.JSON(w, http.StatusBadRequest, "missing pause time")
 		return
 	}
-	if err := h.PauseOrResumeScheduler(name, int64(t)); err != nil {
+	actor := cluster.SchedulerActorManual
+	if a, ok := input["actor"].(string); ok && len(a) > 0 {
+		actor = a
+	}
+	reason, _ := input["reason"].(string)
+	if err := h.PauseOrResumeScheduler(name, int64(t), actor, reason); err != nil {
 		h.r.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	h.r.JSON(w, http.StatusOK, "Pause or resume the scheduler successfully.")
 }
 
+// @Tags scheduler
+// @Summary Get the runtime statistics of a scheduler, which are kept across the leader changes.
+// @Param name path string true "The name of the scheduler."
+// @Produce json
+// @Success 200 {object} cluster.SchedulerStats
+// @Failure 404 {string} string "The scheduler is not found."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/{name}/stats [get]
+func (h *schedulerHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.GetSchedulerStats(mux.Vars(r)["name"])
+	if err != nil {
+		h.handleErr(w, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, stats)
+}
+
+// @Tags scheduler
+// @Summary Evaluate a scheduler with a candidate config over the current statistics. The candidate config is merged onto the config of the running scheduler, and the operators the scheduler would create are returned without being added.
+// @Param name path string true "The name of the scheduler."
+// @Param body body object true "The candidate config of the scheduler."
+// @Accept json
+// @Produce json
+// @Success 200 {object} cluster.SchedulerEvaluation
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The scheduler is not found."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /schedulers/{name}/evaluate [post]
+func (h *schedulerHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	var input map[string]interface{}
+	if err := apiutil.ReadJSONRespondError(h.r, w, r.Body, &input); err != nil {
+		return
+	}
+	evaluation, err := h.EvaluateScheduler(mux.Vars(r)["name"], input)
+	if err != nil {
+		if errors.ErrorEqual(err, errs.ErrSchedulerConfig.FastGenByArgs()) {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.handleErr(w, err)
+		return
+	}
+	h.r.JSON(w, http.StatusOK, evaluation)
+}
+
 type schedulerConfigHandler struct {
 	svr *server.Server
 	rd  *render.Render
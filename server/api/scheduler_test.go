@@ -16,12 +16,14 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	_ "github.com/tikv/pd/server/schedulers"
 )
@@ -397,6 +399,116 @@ func (s *testScheduleSuite) TestDisable(c *C) {
 	s.deleteScheduler(name, c)
 }
 
+func (s *testScheduleSuite) TestStatusAll(c *C) {
+	name := "balance-leader-scheduler"
+	input := make(map[string]interface{})
+	input["name"] = name
+	body, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+
+	statusURL := fmt.Sprintf("%s?status=all", s.urlPrefix)
+	// The other tests in the suite may leave schedulers or records, so only
+	// the status of the tested scheduler is checked.
+	readStatus := func() *SchedulerStatus {
+		var statuses []SchedulerStatus
+		c.Assert(readJSON(testDialClient, statusURL, &statuses), IsNil)
+		for i := range statuses {
+			if statuses[i].Name == name {
+				return &statuses[i]
+			}
+		}
+		return nil
+	}
+	status := readStatus()
+	c.Assert(status, NotNil)
+	c.Assert(status.Status, Equals, schedulerStatusRunning)
+	c.Assert(status.DisableRecord, IsNil)
+
+	// The actor and reason of the pause are recorded.
+	input = map[string]interface{}{"delay": 30, "actor": "maintenance", "reason": "upgrade tikv"}
+	body, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/"+name, body), IsNil)
+	status = readStatus()
+	c.Assert(status, NotNil)
+	c.Assert(status.Status, Equals, schedulerStatusPaused)
+	c.Assert(status.DisableRecord, NotNil)
+	c.Assert(status.DisableRecord.Action, Equals, cluster.SchedulerActionPause)
+	c.Assert(status.DisableRecord.Actor, Equals, "maintenance")
+	c.Assert(status.DisableRecord.Reason, Equals, "upgrade tikv")
+
+	// The record is removed after resuming.
+	input = map[string]interface{}{"delay": 0}
+	body, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, s.urlPrefix+"/"+name, body), IsNil)
+	status = readStatus()
+	c.Assert(status, NotNil)
+	c.Assert(status.Status, Equals, schedulerStatusRunning)
+	c.Assert(status.DisableRecord, IsNil)
+
+	// The default scheduler is disabled after deleting.
+	s.deleteScheduler(name, c)
+	status = readStatus()
+	c.Assert(status, NotNil)
+	c.Assert(status.Status, Equals, schedulerStatusDisabled)
+	c.Assert(status.DisableRecord, NotNil)
+	c.Assert(status.DisableRecord.Action, Equals, cluster.SchedulerActionDisable)
+	c.Assert(status.DisableRecord.Actor, Equals, cluster.SchedulerActorManual)
+
+	// The record is removed after adding the scheduler back.
+	input = map[string]interface{}{"name": name}
+	body, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+	status = readStatus()
+	c.Assert(status, NotNil)
+	c.Assert(status.Status, Equals, schedulerStatusRunning)
+	c.Assert(status.DisableRecord, IsNil)
+	s.deleteScheduler(name, c)
+}
+
+func (s *testScheduleSuite) TestStats(c *C) {
+	name := "balance-region-scheduler"
+	input := map[string]interface{}{"name": name}
+	body, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+
+	var stats cluster.SchedulerStats
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/"+name+"/stats", &stats), IsNil)
+	c.Assert(stats.Name, Equals, name)
+
+	s.deleteScheduler(name, c)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/"+name+"/stats", &stats), NotNil)
+}
+
+func (s *testScheduleSuite) TestEvaluate(c *C) {
+	name := "balance-leader-scheduler"
+	input := map[string]interface{}{"name": name}
+	body, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	s.addScheduler(name, name, body, nil, c)
+	defer s.deleteScheduler(name, c)
+
+	evaluateURL := s.urlPrefix + "/" + name + "/evaluate"
+	var evaluation cluster.SchedulerEvaluation
+	err = postJSON(testDialClient, evaluateURL, []byte(`{"ranges": [{"start-key": "", "end-key": ""}]}`), func(res []byte, _ int) {
+		c.Assert(json.Unmarshal(res, &evaluation), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(evaluation.Name, Equals, name)
+	c.Assert(evaluation.Operators, HasLen, 0)
+
+	err = postJSON(testDialClient, evaluateURL, []byte(`{"ranges": "invalid"}`))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "wrong scheduler config"), IsTrue)
+	err = postJSON(testDialClient, s.urlPrefix+"/not-found-scheduler/evaluate", []byte(`{}`))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "scheduler not found"), IsTrue)
+}
+
 func (s *testScheduleSuite) addScheduler(name, createdName string, body []byte, extraTest func(string, *C), c *C) {
 	if createdName == "" {
 		createdName = name
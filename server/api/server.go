@@ -35,6 +35,7 @@ func NewHandler(ctx context.Context, svr *server.Server) (http.Handler, server.S
 	r := createRouter(apiPrefix, svr)
 	router.PathPrefix(apiPrefix).Handler(negroni.New(
 		serverapi.NewRuntimeServiceValidator(svr, group),
+		serverapi.NewCertCNValidator(svr),
 		serverapi.NewRedirector(svr),
 		negroni.Wrap(r)),
 	)
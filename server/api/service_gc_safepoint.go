@@ -14,7 +14,9 @@
 package api
 
 import (
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tikv/pd/server"
@@ -22,6 +24,10 @@ import (
 	"github.com/unrolled/render"
 )
 
+// gcWorkerServiceID is the service ID of the TiDB GC worker, whose service GC
+// safepoint can't be deleted.
+const gcWorkerServiceID = "gc_worker"
+
 type serviceGCSafepointHandler struct {
 	svr *server.Server
 	rd  *render.Render
@@ -35,8 +41,27 @@ func newServiceGCSafepointHandler(svr *server.Server, rd *render.Render) *servic
 }
 
 type listServiceGCSafepoint struct {
-	ServiceGCSafepoints []*core.ServiceSafePoint `json:"service_gc_safe_points"`
-	GCSafePoint         uint64                   `json:"gc_safe_point"`
+	ServiceGCSafepoints []*serviceGCSafepoint `json:"service_gc_safe_points"`
+	GCSafePoint         uint64                `json:"gc_safe_point"`
+}
+
+// serviceGCSafepoint is a service GC safepoint with its remaining TTL.
+type serviceGCSafepoint struct {
+	*core.ServiceSafePoint
+	// TTL is the remaining seconds before the safepoint expires, -1 means it
+	// never expires and 0 means it has expired but not been cleaned up yet.
+	TTL int64 `json:"ttl"`
+}
+
+func newServiceGCSafepoint(ssp *core.ServiceSafePoint, now time.Time) *serviceGCSafepoint {
+	ttl := int64(-1)
+	if ssp.ExpiredAt != math.MaxInt64 {
+		ttl = ssp.ExpiredAt - now.Unix()
+		if ttl < 0 {
+			ttl = 0
+		}
+	}
+	return &serviceGCSafepoint{ServiceSafePoint: ssp, TTL: ttl}
 }
 
 // @Tags servicegcsafepoint
@@ -57,28 +82,57 @@ func (h *serviceGCSafepointHandler) List(w http.ResponseWriter, r *http.Request)
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	now := time.Now()
 	list := listServiceGCSafepoint{
 		GCSafePoint:         gcSafepoint,
-		ServiceGCSafepoints: ssps,
+		ServiceGCSafepoints: make([]*serviceGCSafepoint, 0, len(ssps)),
+	}
+	for _, ssp := range ssps {
+		list.ServiceGCSafepoints = append(list.ServiceGCSafepoints, newServiceGCSafepoint(ssp, now))
 	}
 	h.rd.JSON(w, http.StatusOK, list)
 }
 
 // @Tags servicegcsafepoint
-// @Summary Delete a service GC safepoint.
+// @Summary Delete a service GC safepoint. A stuck service safepoint blocks GC, but removing the safepoint of a running service may break it.
 // @Param service_id path string true "Service ID"
+// @Param confirm query bool true "Confirm the deletion"
 // @Produce json
 // @Success 200 {string} string "Delete service GC safepoint successfully."
+// @Failure 400 {string} string "The deletion is not confirmed or the service GC safepoint can't be deleted."
+// @Failure 404 {string} string "The service GC safepoint is not found."
 // @Failure 500 {string} string "PD server failed to proceed the request."
 // @Router /gc/safepoint/{service_id} [delete]
-// @Tags rule
 func (h *serviceGCSafepointHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	storage := h.svr.GetStorage()
 	serviceID := mux.Vars(r)["service_id"]
-	err := storage.RemoveServiceGCSafePoint(serviceID)
+	if serviceID == gcWorkerServiceID {
+		h.rd.JSON(w, http.StatusBadRequest, "cannot delete the service GC safepoint of gc_worker")
+		return
+	}
+	if r.URL.Query().Get("confirm") != "true" {
+		h.rd.JSON(w, http.StatusBadRequest, "the deletion may break the service, please confirm it with confirm=true")
+		return
+	}
+	ssps, err := storage.GetAllServiceGCSafePoints()
 	if err != nil {
 		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	found := false
+	for _, ssp := range ssps {
+		if ssp.ServiceID == serviceID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		h.rd.JSON(w, http.StatusNotFound, "service GC safepoint not found")
+		return
+	}
+	if err := storage.RemoveServiceGCSafePoint(serviceID); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	h.rd.JSON(w, http.StatusOK, "Delete service GC safepoint successfully.")
 }
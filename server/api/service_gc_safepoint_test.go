@@ -15,6 +15,7 @@ package api
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -52,27 +53,29 @@ func (s *testServiceGCSafepointSuite) TestRegionStats(c *C) {
 	sspURL := s.urlPrefix + "/gc/safepoint"
 
 	storage := s.svr.GetStorage()
-	list := &listServiceGCSafepoint{
-		ServiceGCSafepoints: []*core.ServiceSafePoint{
-			{
-				ServiceID: "a",
-				ExpiredAt: time.Now().Unix() + 10,
-				SafePoint: 1,
-			},
-			{
-				ServiceID: "b",
-				ExpiredAt: time.Now().Unix() + 10,
-				SafePoint: 2,
-			},
-			{
-				ServiceID: "c",
-				ExpiredAt: time.Now().Unix() + 10,
-				SafePoint: 3,
-			},
+	ssps := []*core.ServiceSafePoint{
+		{
+			ServiceID: "a",
+			ExpiredAt: time.Now().Unix() + 10,
+			SafePoint: 1,
+		},
+		{
+			ServiceID: "b",
+			ExpiredAt: time.Now().Unix() + 10,
+			SafePoint: 2,
+		},
+		{
+			ServiceID: "c",
+			ExpiredAt: time.Now().Unix() + 10,
+			SafePoint: 3,
+		},
+		{
+			ServiceID: "gc_worker",
+			ExpiredAt: math.MaxInt64,
+			SafePoint: 1,
 		},
-		GCSafePoint: 1,
 	}
-	for _, ssp := range list.ServiceGCSafepoints {
+	for _, ssp := range ssps {
 		err := storage.SaveServiceGCSafePoint(ssp)
 		c.Assert(err, IsNil)
 	}
@@ -83,13 +86,32 @@ func (s *testServiceGCSafepointSuite) TestRegionStats(c *C) {
 	listResp := &listServiceGCSafepoint{}
 	err = apiutil.ReadJSON(res.Body, listResp)
 	c.Assert(err, IsNil)
-	c.Assert(listResp, DeepEquals, list)
+	c.Assert(listResp.GCSafePoint, Equals, uint64(1))
+	c.Assert(listResp.ServiceGCSafepoints, HasLen, len(ssps))
+	for i, ssp := range listResp.ServiceGCSafepoints {
+		c.Assert(ssp.ServiceSafePoint, DeepEquals, ssps[i])
+		if ssp.ServiceID == "gc_worker" {
+			c.Assert(ssp.TTL, Equals, int64(-1))
+		} else {
+			c.Assert(ssp.TTL > 0 && ssp.TTL <= 10, IsTrue)
+		}
+	}
 
+	// The deletion must be confirmed.
 	res, err = doDelete(testDialClient, sspURL+"/a")
 	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
+	res, err = doDelete(testDialClient, sspURL+"/a?confirm=true")
+	c.Assert(err, IsNil)
 	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	res, err = doDelete(testDialClient, sspURL+"/a?confirm=true")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusNotFound)
+	res, err = doDelete(testDialClient, sspURL+"/gc_worker?confirm=true")
+	c.Assert(err, IsNil)
+	c.Assert(res.StatusCode, Equals, http.StatusBadRequest)
 
 	left, err := storage.GetAllServiceGCSafePoints()
 	c.Assert(err, IsNil)
-	c.Assert(left, DeepEquals, list.ServiceGCSafepoints[1:])
+	c.Assert(left, DeepEquals, ssps[1:])
 }
@@ -15,8 +15,13 @@ package api
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/statistics"
 	"github.com/unrolled/render"
 )
 
@@ -45,3 +50,137 @@ func (h *statsHandler) Region(w http.ResponseWriter, r *http.Request) {
 	stats := rc.GetRegionStats([]byte(startKey), []byte(endKey))
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+// ReplicationLag is the replication lag of the lagging peers and the stores.
+type ReplicationLag struct {
+	Threshold uint64                            `json:"threshold"`
+	Peers     []*statistics.PeerReplicationLag  `json:"peers"`
+	Stores    []*statistics.StoreReplicationLag `json:"stores"`
+}
+
+// @Tags stats
+// @Summary Get the replication lag of the peers and stores, according to the apply states reported along with region heartbeats.
+// @Param threshold query integer false "The number of log entries a peer falls behind to be considered as lagging"
+// @Produce json
+// @Success 200 {object} ReplicationLag
+// @Failure 400 {string} string "The input is invalid."
+// @Router /stats/replication-lag [get]
+func (h *statsHandler) ReplicationLag(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	threshold := uint64(statistics.DefaultReplicationLagThreshold)
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		var err error
+		threshold, err = strconv.ParseUint(thresholdStr, 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	lag := &ReplicationLag{
+		Threshold: threshold,
+		Peers:     rc.GetLaggingPeers(threshold),
+		Stores:    make([]*statistics.StoreReplicationLag, 0),
+	}
+	if lag.Peers == nil {
+		lag.Peers = make([]*statistics.PeerReplicationLag, 0)
+	}
+	for _, stat := range rc.GetStoreReplicationLags(threshold) {
+		lag.Stores = append(lag.Stores, stat)
+	}
+	sort.Slice(lag.Stores, func(i, j int) bool { return lag.Stores[i].StoreID < lag.Stores[j].StoreID })
+	h.rd.JSON(w, http.StatusOK, lag)
+}
+
+// clusterStatsOperatorWindow is the window to count the finished operator steps.
+const clusterStatsOperatorWindow = time.Minute
+
+// ClusterStats is the summary of the flow, hot regions, operators and stores
+// of the cluster, which is aggregated from the in-memory statistics.
+type ClusterStats struct {
+	// The flow rates are the sum of the stores, so the write flow includes
+	// the replication of all the peers.
+	ReadBytesRate  float64               `json:"read_bytes_rate"`
+	ReadKeysRate   float64               `json:"read_keys_rate"`
+	ReadQueryRate  float64               `json:"read_query_rate"`
+	WriteBytesRate float64               `json:"write_bytes_rate"`
+	WriteKeysRate  float64               `json:"write_keys_rate"`
+	WriteQueryRate float64               `json:"write_query_rate"`
+	RegionCount    int                   `json:"region_count"`
+	HotRegions     ClusterHotRegionStats `json:"hot_regions"`
+	Operators      ClusterOperatorStats  `json:"operators"`
+	StoreStates    map[string]int        `json:"store_states"`
+}
+
+// ClusterHotRegionStats is the count of the hot peers by the flow kind and the
+// role of the peers.
+type ClusterHotRegionStats struct {
+	ReadLeader  int `json:"read_leader"`
+	ReadPeer    int `json:"read_peer"`
+	WriteLeader int `json:"write_leader"`
+	WritePeer   int `json:"write_peer"`
+}
+
+// ClusterOperatorStats is the summary of the operators.
+type ClusterOperatorStats struct {
+	Running int `json:"running"`
+	Waiting int `json:"waiting"`
+	// FinishedSteps is the count of the steps of the operators which are
+	// finished in the last minute, by the kind of the steps.
+	FinishedSteps map[string]int `json:"finished_steps_last_minute"`
+}
+
+// @Tags stats
+// @Summary Get the summary of the flow, hot regions, operators and stores of the cluster.
+// @Produce json
+// @Success 200 {object} ClusterStats
+// @Router /stats/cluster [get]
+func (h *statsHandler) Cluster(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	stats := &ClusterStats{
+		RegionCount: rc.GetRegionCount(),
+		Operators: ClusterOperatorStats{
+			FinishedSteps: map[string]int{
+				core.LeaderKind.String(): 0,
+				core.RegionKind.String(): 0,
+			},
+		},
+		StoreStates: make(map[string]int),
+	}
+	for _, loads := range rc.GetStoresLoads() {
+		stats.ReadBytesRate += loads[statistics.StoreReadBytes]
+		stats.ReadKeysRate += loads[statistics.StoreReadKeys]
+		stats.ReadQueryRate += loads[statistics.StoreReadQuery]
+		stats.WriteBytesRate += loads[statistics.StoreWriteBytes]
+		stats.WriteKeysRate += loads[statistics.StoreWriteKeys]
+		stats.WriteQueryRate += loads[statistics.StoreWriteQuery]
+	}
+
+	stats.HotRegions.ReadLeader, stats.HotRegions.ReadPeer = countHotPeers(rc.RegionReadStats())
+	stats.HotRegions.WriteLeader, stats.HotRegions.WritePeer = countHotPeers(rc.RegionWriteStats())
+
+	oc := rc.GetOperatorController()
+	stats.Operators.Running = len(oc.GetOperators())
+	stats.Operators.Waiting = len(oc.GetWaitingOperators())
+	for _, history := range oc.GetHistory(time.Now().Add(-clusterStatsOperatorWindow)) {
+		stats.Operators.FinishedSteps[history.Kind.String()]++
+	}
+
+	opt := h.svr.GetPersistOptions()
+	for _, store := range rc.GetStores() {
+		stats.StoreStates[storeStateName(opt, store)]++
+	}
+	h.rd.JSON(w, http.StatusOK, stats)
+}
+
+// countHotPeers counts the hot leaders and the hot peers.
+func countHotPeers(stats map[uint64][]*statistics.HotPeerStat) (leaders, peers int) {
+	for _, peerStats := range stats {
+		for _, stat := range peerStats {
+			peers++
+			if stat.IsLeader() {
+				leaders++
+			}
+		}
+	}
+	return
+}
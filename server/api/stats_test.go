@@ -14,11 +14,13 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/core"
@@ -175,3 +177,87 @@ func (s *testStatsSuite) TestRegionStats(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stats, DeepEquals, stats23)
 }
+
+func (s *testStatsSuite) TestReplicationLag(c *C) {
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id:       1,
+			StartKey: []byte(""),
+			EndKey:   []byte("a"),
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3},
+			},
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		},
+		&metapb.Peer{Id: 101, StoreId: 1},
+	)
+	mustRegionHeartbeat(c, s.svr, region)
+	reports := []*statistics.ApplyStateReport{
+		{
+			RegionID: 1,
+			Peers: []statistics.PeerApplyState{
+				{PeerID: 101, AppliedIndex: 2000},
+				{PeerID: 102, AppliedIndex: 1990},
+				{PeerID: 103, AppliedIndex: 500},
+			},
+		},
+		// The region doesn't exist.
+		{RegionID: 100, Peers: []statistics.PeerApplyState{{PeerID: 1001, AppliedIndex: 10}}},
+	}
+	data, err := json.Marshal(reports)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, s.urlPrefix+"/regions/apply-states", data, func(body []byte, code int) {
+		res := &reportResult{}
+		c.Assert(json.Unmarshal(body, res), IsNil)
+		c.Assert(res.Count, Equals, 1)
+		c.Assert(res.Failures, HasLen, 1)
+		c.Assert(res.Failures[100], Matches, ".*not found.*")
+	})
+	c.Assert(err, IsNil)
+
+	lag := &ReplicationLag{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/replication-lag", lag), IsNil)
+	c.Assert(lag.Threshold, Equals, uint64(statistics.DefaultReplicationLagThreshold))
+	c.Assert(lag.Peers, HasLen, 1)
+	c.Assert(lag.Peers[0].PeerID, Equals, uint64(103))
+	c.Assert(lag.Peers[0].Lag, Equals, uint64(1500))
+	c.Assert(lag.Stores, HasLen, 3)
+	c.Assert(lag.Stores[2].LaggingPeerCount, Equals, 1)
+
+	lag = &ReplicationLag{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/replication-lag?threshold=10", lag), IsNil)
+	c.Assert(lag.Peers, HasLen, 2)
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/replication-lag?threshold=x", lag), NotNil)
+}
+
+func (s *testStatsSuite) TestClusterStats(c *C) {
+	mustPutStore(c, s.svr, 4, metapb.StoreState_Offline, nil)
+	mustPutStore(c, s.svr, 5, metapb.StoreState_Tombstone, nil)
+	rc := s.svr.GetRaftCluster()
+	for i := 0; i < 2; i++ {
+		err := rc.HandleStoreHeartbeat(&pdpb.StoreStats{
+			StoreId:      1,
+			Capacity:     100 * 1024 * 1024 * 1024,
+			Available:    50 * 1024 * 1024 * 1024,
+			BytesRead:    10 * 1024 * 1024,
+			KeysRead:     10 * 1024,
+			BytesWritten: 20 * 1024 * 1024,
+			KeysWritten:  20 * 1024,
+			Interval:     &pdpb.TimeInterval{StartTimestamp: uint64(i * 10), EndTimestamp: uint64(i*10 + 10)},
+		})
+		c.Assert(err, IsNil)
+	}
+
+	stats := &ClusterStats{}
+	c.Assert(readJSON(testDialClient, s.urlPrefix+"/stats/cluster", stats), IsNil)
+	c.Assert(stats.RegionCount, Equals, rc.GetRegionCount())
+	c.Assert(stats.ReadBytesRate, Greater, 0.0)
+	c.Assert(stats.WriteKeysRate, Greater, 0.0)
+	c.Assert(stats.StoreStates["Up"], Equals, 1)
+	c.Assert(stats.StoreStates["Offline"], Equals, 1)
+	c.Assert(stats.StoreStates["Tombstone"], Equals, 1)
+	c.Assert(stats.Operators.Running, Equals, 0)
+	c.Assert(stats.Operators.FinishedSteps, DeepEquals, map[string]int{"leader": 0, "region": 0})
+}
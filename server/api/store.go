@@ -15,8 +15,10 @@ package api
 
 import (
 	"fmt"
+	"hash/fnv"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
@@ -46,6 +49,8 @@ type StoreStatus struct {
 	Capacity           typeutil.ByteSize  `json:"capacity"`
 	Available          typeutil.ByteSize  `json:"available"`
 	UsedSize           typeutil.ByteSize  `json:"used_size"`
+	ReservedSpace      typeutil.ByteSize  `json:"reserved_space,omitempty"`
+	EffectiveAvailable typeutil.ByteSize  `json:"effective_available,omitempty"`
 	LeaderCount        int                `json:"leader_count"`
 	LeaderWeight       float64            `json:"leader_weight"`
 	LeaderScore        float64            `json:"leader_score"`
@@ -56,6 +61,7 @@ type StoreStatus struct {
 	RegionSize         int64              `json:"region_size"`
 	SendingSnapCount   uint32             `json:"sending_snap_count,omitempty"`
 	ReceivingSnapCount uint32             `json:"receiving_snap_count,omitempty"`
+	ApplyingSnapCount  uint32             `json:"applying_snap_count,omitempty"`
 	IsBusy             bool               `json:"is_busy,omitempty"`
 	StartTS            *time.Time         `json:"start_ts,omitempty"`
 	LastHeartbeatTS    *time.Time         `json:"last_heartbeat_ts,omitempty"`
@@ -73,30 +79,35 @@ const (
 	downStateName    = "Down"
 )
 
-func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo {
+func newStoreInfo(opt *config.PersistOptions, store *core.StoreInfo) *StoreInfo {
 	s := &StoreInfo{
 		Store: &MetaStore{
 			Store:     store.GetMeta(),
-			StateName: store.GetState().String(),
+			StateName: storeStateName(opt, store),
 		},
 		Status: &StoreStatus{
 			Capacity:           typeutil.ByteSize(store.GetCapacity()),
 			Available:          typeutil.ByteSize(store.GetAvailable()),
 			UsedSize:           typeutil.ByteSize(store.GetUsedSize()),
+			ReservedSpace:      typeutil.ByteSize(store.GetReservedSpace()),
 			LeaderCount:        store.GetLeaderCount(),
 			LeaderWeight:       store.GetLeaderWeight(),
-			LeaderScore:        store.LeaderScore(core.StringToSchedulePolicy(opt.LeaderSchedulePolicy), 0),
+			LeaderScore:        store.LeaderScore(opt.GetLeaderSchedulePolicy(), 0),
 			LeaderSize:         store.GetLeaderSize(),
 			RegionCount:        store.GetRegionCount(),
 			RegionWeight:       store.GetRegionWeight(),
-			RegionScore:        store.RegionScore(opt.RegionScoreFormulaVersion, opt.HighSpaceRatio, opt.LowSpaceRatio, 0),
+			RegionScore:        store.RegionScore(opt.GetRegionScoreFormulaVersion(), opt.GetHighSpaceRatio(), opt.GetLowSpaceRatio(), 0),
 			RegionSize:         store.GetRegionSize(),
 			SendingSnapCount:   store.GetSendingSnapCount(),
 			ReceivingSnapCount: store.GetReceivingSnapCount(),
+			ApplyingSnapCount:  store.GetApplyingSnapCount(),
 			IsBusy:             store.IsBusy(),
 		},
 	}
 
+	if store.GetReservedSpace() > 0 {
+		s.Status.EffectiveAvailable = typeutil.ByteSize(store.GetEffectiveAvailable())
+	}
 	if store.GetStoreStats() != nil {
 		startTS := store.GetStartTime()
 		s.Status.StartTS = &startTS
@@ -109,14 +120,21 @@ func newStoreInfo(opt *config.ScheduleConfig, store *core.StoreInfo) *StoreInfo
 		s.Status.Uptime = &duration
 	}
 
+	return s
+}
+
+// storeStateName returns the state name of the store, the up store is
+// regarded as down or disconnected if it has not sent heartbeats for a while.
+// The max store down time honors the temporary override if there is one.
+func storeStateName(opt *config.PersistOptions, store *core.StoreInfo) string {
 	if store.GetState() == metapb.StoreState_Up {
-		if store.DownTime() > opt.MaxStoreDownTime.Duration {
-			s.Store.StateName = downStateName
+		if store.DownTime() > opt.GetMaxStoreDownTime() {
+			return downStateName
 		} else if store.IsDisconnected() {
-			s.Store.StateName = disconnectedName
+			return disconnectedName
 		}
 	}
-	return s
+	return store.GetState().String()
 }
 
 // StoresInfo records stores' info.
@@ -161,10 +179,226 @@ func (h *storeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+	storeInfo := newStoreInfo(h.GetPersistOptions(), store)
 	h.rd.JSON(w, http.StatusOK, storeInfo)
 }
 
+// StoreSnapshotStats contains the snapshot statistics of a store.
+type StoreSnapshotStats struct {
+	StoreID            uint64                `json:"store_id"`
+	SendingSnapCount   uint32                `json:"sending_snap_count"`
+	ReceivingSnapCount uint32                `json:"receiving_snap_count"`
+	ApplyingSnapCount  uint32                `json:"applying_snap_count"`
+	ApplyingDuration   typeutil.Duration     `json:"applying_duration"`
+	History            []core.SnapshotRecord `json:"history"`
+}
+
+// @Tags store
+// @Summary Get the snapshot statistics of a store, including the recent history reported by store heartbeats.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} StoreSnapshotStats
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/snapshot [get]
+func (h *storeHandler) GetSnapshotStats(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	store := rc.GetStore(storeID)
+	if store == nil {
+		h.rd.JSON(w, http.StatusNotFound, server.ErrStoreNotFound(storeID).Error())
+		return
+	}
+
+	h.rd.JSON(w, http.StatusOK, &StoreSnapshotStats{
+		StoreID:            storeID,
+		SendingSnapCount:   store.GetSendingSnapCount(),
+		ReceivingSnapCount: store.GetReceivingSnapCount(),
+		ApplyingSnapCount:  store.GetApplyingSnapCount(),
+		ApplyingDuration:   typeutil.NewDuration(store.GetApplyingSnapDuration()),
+		History:            store.GetSnapshotHistory(),
+	})
+}
+
+// @Tags store
+// @Summary Get the state change history of a store, e.g. when it is set offline and buried. The history is kept even after the store is removed.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {array} core.StoreStateChange
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/history [get]
+func (h *storeHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	changes, err := rc.GetStoreStateChanges(storeID)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if changes == nil {
+		changes = []*core.StoreStateChange{}
+	}
+	h.rd.JSON(w, http.StatusOK, changes)
+}
+
+// StorePurgeStatus shows whether the data of a tombstone store is safe to be
+// destroyed.
+type StorePurgeStatus struct {
+	StoreID uint64 `json:"store_id"`
+	// NotifiedAt is when the node of the store is first notified of the
+	// tombstone by the current leader.
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+	// SafeToPurge is true if the node of the store confirms that it is
+	// notified of the tombstone and has stopped, then it never serves again.
+	SafeToPurge bool       `json:"safe_to_purge"`
+	AckedAt     *time.Time `json:"acked_at,omitempty"`
+}
+
+func newStorePurgeStatus(rc *cluster.RaftCluster, storeID uint64, ack *core.StoreTombstoneAck) *StorePurgeStatus {
+	status := &StorePurgeStatus{StoreID: storeID}
+	if notifiedAt := rc.GetStoreTombstoneNotice(storeID); !notifiedAt.IsZero() {
+		status.NotifiedAt = &notifiedAt
+	}
+	if ack != nil {
+		status.SafeToPurge = true
+		status.AckedAt = &ack.Time
+	}
+	return status
+}
+
+func (h *storeHandler) respondTombstoneAckError(w http.ResponseWriter, err error) {
+	switch {
+	case errs.ErrTombstonePurgeAckDisabled.Equal(err):
+		h.rd.JSON(w, http.StatusPreconditionFailed, err.Error())
+	case errs.ErrStoreNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+	case errs.ErrStoreNotTombstone.Equal(err):
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// @Tags store
+// @Summary Get whether the data of a tombstone store is safe to be destroyed. It is safe after the node of the store confirms that it has stopped.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} StorePurgeStatus
+// @Failure 400 {string} string "The input is invalid or the store is not tombstone."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 412 {string} string "The acknowledgement of the tombstone stores is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/purge [get]
+func (h *storeHandler) GetPurgeStatus(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	ack, err := rc.GetStoreTombstoneAck(storeID)
+	if err != nil {
+		h.respondTombstoneAckError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, newStorePurgeStatus(rc, storeID, ack))
+}
+
+// @Tags store
+// @Summary Confirm that the node of a tombstone store is notified of the tombstone and has stopped, then the data of the store is safe to be destroyed.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {object} StorePurgeStatus
+// @Failure 400 {string} string "The input is invalid or the store is not tombstone."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 412 {string} string "The acknowledgement of the tombstone stores is disabled."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/tombstone-ack [post]
+func (h *storeHandler) AckTombstone(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	ack, err := rc.AckStoreTombstone(storeID)
+	if err != nil {
+		h.respondTombstoneAckError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, newStorePurgeStatus(rc, storeID, ack))
+}
+
+// @Tags store
+// @Summary Annotate the store to restart. Its leaders are moved out before it restarts, and moved back after it restarts.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {string} string "The store is annotated to restart."
+// @Failure 400 {string} string "The input is invalid or the store has been removed."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /store/{id}/restart [post]
+func (h *storeHandler) PrepareRestart(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	if err := rc.PrepareStoreRestart(storeID); err != nil {
+		switch {
+		case errs.ErrStoreNotFound.Equal(err):
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+		case errs.ErrStoreTombstone.Equal(err):
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		default:
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, "The store is annotated to restart.")
+}
+
+// @Tags store
+// @Summary Remove the restart annotation of the store.
+// @Param id path integer true "Store Id"
+// @Produce json
+// @Success 200 {string} string "The restart annotation of the store is removed."
+// @Failure 400 {string} string "The input is invalid."
+// @Router /store/{id}/restart [delete]
+func (h *storeHandler) CancelRestart(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	vars := mux.Vars(r)
+	storeID, errParse := apiutil.ParseUint64VarsField(vars, "id")
+	if errParse != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(errParse))
+		return
+	}
+
+	rc.CancelStoreRestart(storeID)
+	h.rd.JSON(w, http.StatusOK, "The restart annotation of the store is removed.")
+}
+
 // @Tags store
 // @Summary Take down a store from the cluster.
 // @Param id path integer true "Store Id"
@@ -297,7 +531,7 @@ func (h *storeHandler) SetLabels(w http.ResponseWriter, r *http.Request) {
 
 // FIXME: details of input json body params
 // @Tags store
-// @Summary Set the store's leader/region weight.
+// @Summary Set the store's leader/region weight. The store with leader weight 0 doesn't accept new leaders from balance-leader.
 // @Param id path integer true "Store Id"
 // @Param body body object true "json params"
 // @Produce json
@@ -449,6 +683,117 @@ func (h *storesHandler) RemoveTombStone(w http.ResponseWriter, r *http.Request)
 	h.rd.JSON(w, http.StatusOK, "Remove tombstone successfully.")
 }
 
+// StoresRemoveInput selects the stores to take down in batch.
+type StoresRemoveInput struct {
+	// Address matches a store address, or all stores on a host if the port is omitted.
+	Address string            `json:"address,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Force   bool              `json:"force,omitempty"`
+	// Token confirms the removal. It is returned by a request without token.
+	Token string `json:"token,omitempty"`
+}
+
+// StoreRemoveResult is the result of removing one store.
+type StoreRemoveResult struct {
+	StoreID uint64 `json:"store_id"`
+	Address string `json:"address"`
+	Error   string `json:"error,omitempty"`
+}
+
+// StoresRemoveInfo records the stores selected for removal and the results.
+type StoresRemoveInfo struct {
+	Token   string               `json:"token"`
+	Applied bool                 `json:"applied"`
+	Stores  []*StoreRemoveResult `json:"stores"`
+}
+
+// @Tags store
+// @Summary Take down the stores matching the address or labels in batch.
+// @Accept json
+// @Param body body StoresRemoveInput true "Address, labels and confirmation token"
+// @Produce json
+// @Success 200 {object} StoresRemoveInfo
+// @Failure 400 {string} string "The input is invalid."
+// @Failure 409 {string} string "The selected stores have changed since the token is issued."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /stores/remove [post]
+func (h *storesHandler) RemoveStores(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	var input StoresRemoveInput
+	if err := apiutil.ReadJSONRespondError(h.rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.Address == "" && len(input.Labels) == 0 {
+		h.rd.JSON(w, http.StatusBadRequest, "address or labels should be specified")
+		return
+	}
+
+	labels := make([]*metapb.StoreLabel, 0, len(input.Labels))
+	for k, v := range input.Labels {
+		labels = append(labels, &metapb.StoreLabel{Key: k, Value: v})
+	}
+	if err := config.ValidateLabels(labels); err != nil {
+		apiutil.ErrorResp(h.rd, w, errcode.NewInvalidInputErr(err))
+		return
+	}
+
+	stores := rc.SelectStores(input.Address, labels)
+	sort.Slice(stores, func(i, j int) bool { return stores[i].GetID() < stores[j].GetID() })
+	storeIDs := make([]uint64, 0, len(stores))
+	info := &StoresRemoveInfo{Stores: make([]*StoreRemoveResult, 0, len(stores))}
+	for _, store := range stores {
+		storeIDs = append(storeIDs, store.GetID())
+		info.Stores = append(info.Stores, &StoreRemoveResult{
+			StoreID: store.GetID(),
+			Address: store.GetAddress(),
+		})
+	}
+	info.Token = storesRemoveToken(storeIDs, input.Force)
+
+	// Without a token, only returns the selected stores and the token to confirm.
+	if input.Token == "" {
+		h.rd.JSON(w, http.StatusOK, info)
+		return
+	}
+	if input.Token != info.Token {
+		h.rd.JSON(w, http.StatusConflict, "the selected stores have changed, please confirm again")
+		return
+	}
+
+	results := rc.RemoveStores(storeIDs, input.Force)
+	for _, result := range info.Stores {
+		if err := results[result.StoreID]; err != nil {
+			result.Error = err.Error()
+		}
+	}
+	info.Applied = true
+	h.rd.JSON(w, http.StatusOK, info)
+}
+
+func (h *storesHandler) respondStoreRemovalError(w http.ResponseWriter, err error) {
+	switch {
+	case errs.ErrStoreNotFound.Equal(err):
+		h.rd.JSON(w, http.StatusNotFound, err.Error())
+	case errs.ErrStoreTombstone.Equal(err):
+		h.rd.JSON(w, http.StatusGone, err.Error())
+	case errs.ErrStoreRemovalQueued.Equal(err):
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+	default:
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// storesRemoveToken generates a token which identifies the stores to remove,
+// so that the removal is confirmed against the same set of stores.
+func storesRemoveToken(storeIDs []uint64, force bool) string {
+	hash := fnv.New64a()
+	for _, id := range storeIDs {
+		hash.Write([]byte(strconv.FormatUint(id, 10) + ","))
+	}
+	hash.Write([]byte(strconv.FormatBool(force)))
+	return strconv.FormatUint(hash.Sum64(), 16)
+}
+
 // FIXME: details of input json body params
 // @Tags store
 // @Summary Set limit of all stores in the cluster.
@@ -607,6 +952,73 @@ func (h *storesHandler) GetStoreLimitScene(w http.ResponseWriter, r *http.Reques
 	h.rd.JSON(w, http.StatusOK, scene)
 }
 
+// @Tags store
+// @Summary Get the status of the rolling restart of the stores, during which the leader schedule limit is raised.
+// @Produce json
+// @Success 200 {object} cluster.RollingRestartStatus
+// @Router /stores/rolling-restart [get]
+func (h *storesHandler) GetRollingRestart(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetRollingRestartStatus())
+}
+
+// @Tags store
+// @Summary Enqueue the stores to the removal queue, which removes them in order with bounded parallel and free space check.
+// @Param ids query string true "Comma separated store IDs"
+// @Param parallel query integer false "The max count of the stores removed at the same time, the current one is kept if it is not specified"
+// @Param force query string false "The stores are physically destroyed"
+// @Produce json
+// @Success 200 {object} cluster.StoreRemovalQueueStatus
+// @Failure 400 {string} string "The input is invalid, or the store is already in the removal queue."
+// @Failure 404 {string} string "The store does not exist."
+// @Failure 410 {string} string "The store has been removed."
+// @Failure 500 {string} string "PD server failed to proceed the request."
+// @Router /stores [delete]
+func (h *storesHandler) EnqueueRemovals(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	query := r.URL.Query()
+	rawIDs := query.Get("ids")
+	if rawIDs == "" {
+		h.rd.JSON(w, http.StatusBadRequest, "ids should be specified")
+		return
+	}
+	var storeIDs []uint64
+	for _, rawID := range strings.Split(rawIDs, ",") {
+		storeID, err := strconv.ParseUint(strings.TrimSpace(rawID), 10, 64)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, fmt.Sprintf("invalid store id %q", rawID))
+			return
+		}
+		storeIDs = append(storeIDs, storeID)
+	}
+	var parallel int
+	if rawParallel := query.Get("parallel"); rawParallel != "" {
+		var err error
+		parallel, err = strconv.Atoi(rawParallel)
+		if err != nil || parallel <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "parallel should be a positive integer")
+			return
+		}
+	}
+	_, force := query["force"]
+
+	if err := rc.EnqueueStoreRemovals(storeIDs, force, parallel); err != nil {
+		h.respondStoreRemovalError(w, err)
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, rc.GetStoreRemovalQueueStatus())
+}
+
+// @Tags store
+// @Summary Get the status of the store removal queue, the finished removals are kept for a day by the current leader.
+// @Produce json
+// @Success 200 {object} cluster.StoreRemovalQueueStatus
+// @Router /stores/removal-queue [get]
+func (h *storesHandler) GetRemovalQueue(w http.ResponseWriter, r *http.Request) {
+	rc := getCluster(r)
+	h.rd.JSON(w, http.StatusOK, rc.GetStoreRemovalQueueStatus())
+}
+
 // @Tags store
 // @Summary Get stores in the cluster.
 // @Param state query array true "Specify accepted store states."
@@ -636,7 +1048,7 @@ func (h *storesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		storeInfo := newStoreInfo(h.GetScheduleConfig(), store)
+		storeInfo := newStoreInfo(h.GetPersistOptions(), store)
 		StoresInfo.Stores = append(StoresInfo.Stores, storeInfo)
 	}
 	StoresInfo.Count = len(StoresInfo.Stores)
@@ -28,9 +28,13 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/mock/mockhbstream"
+	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/hbstream"
 )
 
 var _ = Suite(&testStoreSuite{})
@@ -290,6 +294,184 @@ func (s *testStoreSuite) TestStoreDelete(c *C) {
 	s.SetUpSuite(c)
 }
 
+func (s *testStoreSuite) TestStorePurgeStatus(c *C) {
+	purgeURL := fmt.Sprintf("%s/store/7/purge", s.urlPrefix)
+	ackURL := fmt.Sprintf("%s/store/7/tombstone-ack", s.urlPrefix)
+	code := requestStatusBody(c, testDialClient, http.MethodGet, purgeURL)
+	c.Assert(code, Equals, http.StatusPreconditionFailed)
+	code = requestStatusBody(c, testDialClient, http.MethodPost, ackURL)
+	c.Assert(code, Equals, http.StatusPreconditionFailed)
+
+	cfg := s.svr.GetPersistOptions().GetPDServerConfig().Clone()
+	cfg.EnableTombstonePurgeAck = true
+	s.svr.GetPersistOptions().SetPDServerConfig(cfg)
+	defer func() {
+		cfg.EnableTombstonePurgeAck = false
+		s.svr.GetPersistOptions().SetPDServerConfig(cfg)
+	}()
+
+	// The node of store 7 is notified by the response of its heartbeat, but
+	// the data is not safe to be destroyed until it confirms.
+	resp, err := s.svr.StoreHeartbeat(context.Background(), &pdpb.StoreHeartbeatRequest{
+		Header: &pdpb.RequestHeader{ClusterId: s.svr.ClusterID()},
+		Stats:  &pdpb.StoreStats{StoreId: 7},
+	})
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetHeader().GetError().GetType(), Equals, pdpb.ErrorType_STORE_TOMBSTONE)
+	status := new(StorePurgeStatus)
+	err = readJSON(testDialClient, purgeURL, status)
+	c.Assert(err, IsNil)
+	c.Assert(status.StoreID, Equals, uint64(7))
+	c.Assert(status.NotifiedAt, NotNil)
+	c.Assert(status.SafeToPurge, IsFalse)
+
+	err = postJSON(testDialClient, ackURL, nil, func(res []byte, code int) {
+		c.Assert(code, Equals, http.StatusOK)
+		c.Assert(json.Unmarshal(res, status), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(status.SafeToPurge, IsTrue)
+	c.Assert(status.AckedAt, NotNil)
+	ackedAt := *status.AckedAt
+	status = new(StorePurgeStatus)
+	err = readJSON(testDialClient, purgeURL, status)
+	c.Assert(err, IsNil)
+	c.Assert(status.SafeToPurge, IsTrue)
+	c.Assert(status.AckedAt.Equal(ackedAt), IsTrue)
+
+	code = requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/store/1/purge", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusBadRequest)
+	code = requestStatusBody(c, testDialClient, http.MethodPost, fmt.Sprintf("%s/store/1/tombstone-ack", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusBadRequest)
+	code = requestStatusBody(c, testDialClient, http.MethodGet, fmt.Sprintf("%s/store/100/purge", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusNotFound)
+	code = requestStatusBody(c, testDialClient, http.MethodPost, fmt.Sprintf("%s/store/100/tombstone-ack", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusNotFound)
+}
+
+func (s *testStoreSuite) TestRollingRestart(c *C) {
+	statusURL := fmt.Sprintf("%s/stores/rolling-restart", s.urlPrefix)
+	status := new(cluster.RollingRestartStatus)
+	err := readJSON(testDialClient, statusURL, status)
+	c.Assert(err, IsNil)
+	c.Assert(status.InProgress, IsFalse)
+	c.Assert(status.LeaderScheduleLimit, Equals, s.svr.GetScheduleConfig().LeaderScheduleLimit)
+	c.Assert(status.Stores, HasLen, 0)
+
+	restartURL := fmt.Sprintf("%s/store/4/restart", s.urlPrefix)
+	code := requestStatusBody(c, testDialClient, http.MethodPost, restartURL)
+	c.Assert(code, Equals, http.StatusOK)
+	status = new(cluster.RollingRestartStatus)
+	err = readJSON(testDialClient, statusURL, status)
+	c.Assert(err, IsNil)
+	c.Assert(status.Stores, HasLen, 1)
+	c.Assert(status.Stores[4].State, Equals, cluster.StoreRestartEvacuating)
+
+	code = requestStatusBody(c, testDialClient, http.MethodDelete, restartURL)
+	c.Assert(code, Equals, http.StatusOK)
+	status = new(cluster.RollingRestartStatus)
+	err = readJSON(testDialClient, statusURL, status)
+	c.Assert(err, IsNil)
+	c.Assert(status.Stores, HasLen, 0)
+
+	code = requestStatusBody(c, testDialClient, http.MethodPost, fmt.Sprintf("%s/store/7/restart", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusBadRequest)
+	code = requestStatusBody(c, testDialClient, http.MethodPost, fmt.Sprintf("%s/store/100/restart", s.urlPrefix))
+	c.Assert(code, Equals, http.StatusNotFound)
+}
+
+func (s *testStoreSuite) TestStoresRemove(c *C) {
+	url := fmt.Sprintf("%s/stores/remove", s.urlPrefix)
+	err := postJSON(testDialClient, url, []byte(`{}`))
+	c.Assert(err, NotNil)
+
+	// Without token, nothing is removed.
+	info := &StoresRemoveInfo{}
+	input := map[string]interface{}{"address": "tikv4"}
+	body, err := json.Marshal(input)
+	c.Assert(err, IsNil)
+	err = postJSON(testDialClient, url, body, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, info), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(info.Applied, IsFalse)
+	c.Assert(info.Stores, HasLen, 1)
+	c.Assert(info.Stores[0].StoreID, Equals, uint64(4))
+	c.Assert(info.Token, Not(Equals), "")
+	c.Assert(s.svr.GetRaftCluster().GetStore(4).IsUp(), IsTrue)
+
+	// A mismatched token is rejected.
+	input["token"] = "foo"
+	body, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	c.Assert(postJSON(testDialClient, url, body), NotNil)
+	c.Assert(s.svr.GetRaftCluster().GetStore(4).IsUp(), IsTrue)
+
+	input["token"] = info.Token
+	body, err = json.Marshal(input)
+	c.Assert(err, IsNil)
+	info = &StoresRemoveInfo{}
+	err = postJSON(testDialClient, url, body, func(res []byte, code int) {
+		c.Assert(json.Unmarshal(res, info), IsNil)
+	})
+	c.Assert(err, IsNil)
+	c.Assert(info.Applied, IsTrue)
+	c.Assert(info.Stores, HasLen, 1)
+	c.Assert(info.Stores[0].Error, Equals, "")
+	c.Assert(s.svr.GetRaftCluster().GetStore(4).IsOffline(), IsTrue)
+	// reset store 4
+	s.cleanup()
+	s.SetUpSuite(c)
+}
+
+func (s *testStoreSuite) TestStoresRemovalQueue(c *C) {
+	url := fmt.Sprintf("%s/stores?ids=%%s", s.urlPrefix)
+	for ids, code := range map[string]int{
+		"":              http.StatusBadRequest,
+		"1,x":           http.StatusBadRequest,
+		"1&parallel=x":  http.StatusBadRequest,
+		"1&parallel=-1": http.StatusBadRequest,
+		"1,100":         http.StatusNotFound,
+		"1,7":           http.StatusGone,
+	} {
+		resp, err := doDelete(testDialClient, fmt.Sprintf(url, ids))
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, code, Commentf("ids: %s", ids))
+	}
+
+	resp, err := doDelete(testDialClient, fmt.Sprintf(url, "1,4,6")+"&parallel=2")
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	status := new(cluster.StoreRemovalQueueStatus)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/removal-queue", s.urlPrefix), status), IsNil)
+	c.Assert(status.Parallel, Equals, 2)
+	c.Assert(status.Removals, HasLen, 3)
+	// At most 2 stores are removed at the same time.
+	for i, removal := range status.Removals {
+		c.Assert(removal.StoreID, Equals, []uint64{1, 4, 6}[i])
+		c.Assert(removal.State, Equals, []string{cluster.StoreRemovalRemoving, cluster.StoreRemovalRemoving, cluster.StoreRemovalPending}[i])
+	}
+	c.Assert(s.svr.GetRaftCluster().GetStore(1).IsOffline(), IsTrue)
+	c.Assert(s.svr.GetRaftCluster().GetStore(4).IsOffline(), IsTrue)
+	// The stores in the queue can't be enqueued again.
+	resp, err = doDelete(testDialClient, fmt.Sprintf(url, "1"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+
+	// The parallel is kept if it is not specified.
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	resp, err = doDelete(testDialClient, fmt.Sprintf(url, "2"))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(readJSON(testDialClient, fmt.Sprintf("%s/stores/removal-queue", s.urlPrefix), status), IsNil)
+	c.Assert(status.Parallel, Equals, 2)
+	c.Assert(status.Removals, HasLen, 4)
+	c.Assert(status.Removals[3].StoreID, Equals, uint64(2))
+	// reset store 1, 2, 4 and 6
+	s.cleanup()
+	s.SetUpSuite(c)
+}
+
 func (s *testStoreSuite) TestStoreSetState(c *C) {
 	url := fmt.Sprintf("%s/store/1", s.urlPrefix)
 	info := StoreInfo{}
@@ -380,15 +562,24 @@ func (s *testStoreSuite) TestDownState(c *C) {
 		core.SetStoreStats(&pdpb.StoreStats{}),
 		core.SetLastHeartbeatTS(time.Now()),
 	)
-	storeInfo := newStoreInfo(s.svr.GetScheduleConfig(), store)
+	storeInfo := newStoreInfo(s.svr.GetPersistOptions(), store)
 	c.Assert(storeInfo.Store.StateName, Equals, metapb.StoreState_Up.String())
 
 	newStore := store.Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Minute * 2)))
-	storeInfo = newStoreInfo(s.svr.GetScheduleConfig(), newStore)
+	storeInfo = newStoreInfo(s.svr.GetPersistOptions(), newStore)
 	c.Assert(storeInfo.Store.StateName, Equals, disconnectedName)
 
 	newStore = store.Clone(core.SetLastHeartbeatTS(time.Now().Add(-time.Hour * 2)))
-	storeInfo = newStoreInfo(s.svr.GetScheduleConfig(), newStore)
+	storeInfo = newStoreInfo(s.svr.GetPersistOptions(), newStore)
+	c.Assert(storeInfo.Store.StateName, Equals, downStateName)
+
+	// The temporary override of max-store-down-time is honored.
+	addr := fmt.Sprintf("%s/config/schedule/override?key=max-store-down-time&value=3h&ttl=1s", s.urlPrefix)
+	c.Assert(postJSON(testDialClient, addr, nil), IsNil)
+	storeInfo = newStoreInfo(s.svr.GetPersistOptions(), newStore)
+	c.Assert(storeInfo.Store.StateName, Equals, disconnectedName)
+	time.Sleep(2 * time.Second)
+	storeInfo = newStoreInfo(s.svr.GetPersistOptions(), newStore)
 	c.Assert(storeInfo.Store.StateName, Equals, downStateName)
 }
 
@@ -491,3 +682,17 @@ func (s *testStoreSuite) TestStoreLimitTTL(c *C) {
 	c.Assert(s.svr.GetPersistOptions().GetStoreLimit(uint64(2)).AddPeer, Not(Equals), float64(997))
 	c.Assert(s.svr.GetPersistOptions().GetStoreLimit(uint64(2)).RemovePeer, Not(Equals), float64(996))
 }
+
+func (s *testStoreSuite) TestHeartbeatStreams(c *C) {
+	hbStreams := s.svr.GetHBStreams()
+	hbStreams.BindStream(1, mockhbstream.NewHeartbeatStream())
+	addr := fmt.Sprintf("%s/debug/heartbeat-streams", s.urlPrefix)
+	var status []*hbstream.StreamStatus
+	testutil.WaitUntil(c, func(c *C) bool {
+		c.Assert(readJSON(testDialClient, addr, &status), IsNil)
+		return len(status) == 1 && status[0].Bound
+	})
+	c.Assert(status[0].StoreID, Equals, uint64(1))
+	c.Assert(status[0].BindTime.IsZero(), IsFalse)
+	c.Assert(status[0].SendErrors, Equals, uint64(0))
+}
@@ -127,7 +127,7 @@ func (h *trendHandler) getTrendStores() ([]trendStore, error) {
 	}
 	trendStores := make([]trendStore, 0, len(stores))
 	for _, store := range stores {
-		info := newStoreInfo(h.svr.GetScheduleConfig(), store)
+		info := newStoreInfo(h.svr.GetPersistOptions(), store)
 		s := trendStore{
 			ID:              info.Store.GetId(),
 			Address:         info.Store.GetAddress(),
@@ -48,9 +48,9 @@ func (s *testTrendSuite) TestTrend(c *C) {
 
 	// Create 3 operators that transfers leader, moves follower, moves leader.
 	c.Assert(svr.GetHandler().AddTransferLeaderOperator(4, 2), IsNil)
-	c.Assert(svr.GetHandler().AddTransferPeerOperator(5, 2, 3), IsNil)
+	c.Assert(svr.GetHandler().AddTransferPeerOperator(5, 2, 3, false), IsNil)
 	time.Sleep(1 * time.Second)
-	c.Assert(svr.GetHandler().AddTransferPeerOperator(6, 1, 3), IsNil)
+	c.Assert(svr.GetHandler().AddTransferPeerOperator(6, 1, 3, false), IsNil)
 
 	// Complete the operators.
 	mustRegionHeartbeat(c, svr, region4.Clone(core.WithLeader(region4.GetStorePeer(2))))
@@ -21,6 +21,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/pingcap/errors"
 )
@@ -30,6 +31,48 @@ var (
 	errOptionNotExist = func(name string) error { return errors.Errorf("the option %s does not exist", name) }
 )
 
+// totalCountHeader is the response header which carries the total count of the
+// items of a list API, so that the client can page through the list.
+const totalCountHeader = "X-Total-Count"
+
+// listPage is a page of a list API requested by the offset and limit query
+// parameters.
+type listPage struct {
+	offset int
+	limit  int
+}
+
+// parseListPage parses the offset and limit query parameters of a list API. It
+// returns nil if neither of them is specified, which means the whole list.
+func parseListPage(r *http.Request) (*listPage, error) {
+	query := r.URL.Query()
+	if query.Get("offset") == "" && query.Get("limit") == "" {
+		return nil, nil
+	}
+	offset, limit, err := parseV2Page(r)
+	if err != nil {
+		return nil, err
+	}
+	return &listPage{offset: offset, limit: limit}, nil
+}
+
+// apply sets the total count of the list in the response header, and returns
+// the range [start, end) of the page in the list.
+func (p *listPage) apply(w http.ResponseWriter, total int) (start, end int) {
+	w.Header().Set(totalCountHeader, strconv.Itoa(total))
+	if p == nil {
+		return 0, total
+	}
+	start, end = p.offset, p.offset+p.limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
 func collectEscapeStringOption(option string, input map[string]interface{}, collectors ...func(v string)) error {
 	if v, ok := input[option].(string); ok {
 		value, err := url.QueryUnescape(v)
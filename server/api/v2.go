@@ -0,0 +1,373 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/cluster"
+	"github.com/tikv/pd/server/core"
+	"github.com/unrolled/render"
+)
+
+// API v2 is built on the handlers of v1 and standardizes:
+//   - the envelopes: {"data": ..., "pagination": ...} for the successful
+//     responses and {"error": {"code": ..., "message": ...}} for the errors.
+//   - the pagination: the lists are sorted in a stable order and paginated
+//     by the `offset` and `limit` query parameters.
+//   - the field filtering: the `fields` query parameter selects the fields
+//     of the returned objects, e.g. `fields=id,address`.
+//   - the timestamps: all of them are in RFC3339 format.
+const apiV2Prefix = "/api/v2"
+
+const (
+	defaultV2PageLimit = 100
+	maxV2PageLimit     = 1000
+)
+
+// The error codes of API v2.
+const (
+	v2ErrInvalidArgument = "invalid_argument"
+	v2ErrNotFound        = "not_found"
+	v2ErrInternal        = "internal"
+)
+
+// v2Response is the envelope of the successful responses of API v2.
+type v2Response struct {
+	Data       interface{}   `json:"data"`
+	Pagination *v2Pagination `json:"pagination,omitempty"`
+}
+
+// v2Pagination describes the page of a list.
+type v2Pagination struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Total  int `json:"total"`
+}
+
+// v2ErrorResponse is the envelope of the error responses of API v2.
+type v2ErrorResponse struct {
+	Error v2Error `json:"error"`
+}
+
+type v2Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// v2Store is the store in API v2, the status fields are flattened.
+type v2Store struct {
+	ID            uint64               `json:"id"`
+	Address       string               `json:"address"`
+	StatusAddress string               `json:"status_address,omitempty"`
+	PeerAddress   string               `json:"peer_address,omitempty"`
+	Version       string               `json:"version,omitempty"`
+	GitHash       string               `json:"git_hash,omitempty"`
+	DeployPath    string               `json:"deploy_path,omitempty"`
+	Labels        []*metapb.StoreLabel `json:"labels,omitempty"`
+	State         string               `json:"state"`
+	*StoreStatus
+}
+
+func newV2Store(info *StoreInfo) *v2Store {
+	meta := info.Store
+	return &v2Store{
+		ID:            meta.GetId(),
+		Address:       meta.GetAddress(),
+		StatusAddress: meta.GetStatusAddress(),
+		PeerAddress:   meta.GetPeerAddress(),
+		Version:       meta.GetVersion(),
+		GitHash:       meta.GetGitHash(),
+		DeployPath:    meta.GetDeployPath(),
+		Labels:        meta.GetLabels(),
+		State:         meta.StateName,
+		StoreStatus:   info.Status,
+	}
+}
+
+// v2Region is the region in API v2, which replaces the unix timestamps of v1.
+type v2Region struct {
+	*RegionInfo
+	FirstSeen     *time.Time `json:"first_seen,omitempty"`
+	LastHeartbeat *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+func newV2Region(r *core.RegionInfo) *v2Region {
+	region := &v2Region{RegionInfo: NewRegionInfo(r)}
+	if ts := r.GetFirstSeen(); ts > 0 {
+		t := time.Unix(ts, 0)
+		region.FirstSeen = &t
+	}
+	if ts := r.GetLastHeartbeat(); ts > 0 {
+		t := time.Unix(ts, 0)
+		region.LastHeartbeat = &t
+	}
+	return region
+}
+
+type v2Handler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newV2Handler(svr *server.Server, rd *render.Render) *v2Handler {
+	return &v2Handler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+func registerV2Routes(rootRouter *mux.Router, svr *server.Server, rd *render.Render) {
+	h := newV2Handler(svr, rd)
+	router := rootRouter.PathPrefix(apiV2Prefix).Subrouter()
+	router.HandleFunc("/stores", h.GetStores).Methods("GET")
+	router.HandleFunc("/stores/{id}", h.GetStore).Methods("GET")
+	router.HandleFunc("/regions", h.GetRegions).Methods("GET")
+	router.HandleFunc("/regions/{id}", h.GetRegion).Methods("GET")
+}
+
+func (h *v2Handler) error(w http.ResponseWriter, status int, code, message string) {
+	h.rd.JSON(w, status, &v2ErrorResponse{Error: v2Error{Code: code, Message: message}})
+}
+
+func (h *v2Handler) getCluster(w http.ResponseWriter) *cluster.RaftCluster {
+	rc := h.svr.GetRaftCluster()
+	if rc == nil {
+		h.error(w, http.StatusInternalServerError, v2ErrInternal, errs.ErrNotBootstrapped.FastGenByArgs().Error())
+	}
+	return rc
+}
+
+// parseV2Page parses the offset and limit query parameters.
+func parseV2Page(r *http.Request) (offset, limit int, err error) {
+	query := r.URL.Query()
+	limit = defaultV2PageLimit
+	if s := query.Get("offset"); s != "" {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %s", s)
+		}
+	}
+	if s := query.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit <= 0 || limit > maxV2PageLimit {
+			return 0, 0, fmt.Errorf("invalid limit %s, it should be in (0, %d]", s, maxV2PageLimit)
+		}
+	}
+	return offset, limit, nil
+}
+
+// parseV2Fields parses the fields query parameter, nil means all the fields.
+func parseV2Fields(r *http.Request) []string {
+	s := r.URL.Query().Get("fields")
+	if s == "" {
+		return nil
+	}
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields keeps the given JSON fields of the object.
+func selectFields(obj interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return obj, nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if v, ok := all[field]; ok {
+			selected[field] = v
+		}
+	}
+	return selected, nil
+}
+
+// writeList writes a page of the list whose total length is given.
+func (h *v2Handler) writeList(w http.ResponseWriter, items []interface{}, fields []string, offset, limit, total int) {
+	data := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		selected, err := selectFields(item, fields)
+		if err != nil {
+			h.error(w, http.StatusInternalServerError, v2ErrInternal, err.Error())
+			return
+		}
+		data = append(data, selected)
+	}
+	h.rd.JSON(w, http.StatusOK, &v2Response{
+		Data:       data,
+		Pagination: &v2Pagination{Offset: offset, Limit: limit, Total: total},
+	})
+}
+
+func (h *v2Handler) writeObject(w http.ResponseWriter, obj interface{}, fields []string) {
+	selected, err := selectFields(obj, fields)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, v2ErrInternal, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, &v2Response{Data: selected})
+}
+
+// GetStores lists the stores sorted by ID. The tombstone stores are excluded
+// unless the state query parameter is given, which is a list of state names
+// separated by commas, e.g. Up,Offline,Disconnected.
+func (h *v2Handler) GetStores(w http.ResponseWriter, r *http.Request) {
+	rc := h.getCluster(w)
+	if rc == nil {
+		return
+	}
+	offset, limit, err := parseV2Page(r)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, err.Error())
+		return
+	}
+	var states map[string]struct{}
+	if s := r.URL.Query().Get("state"); s != "" {
+		states = make(map[string]struct{})
+		for _, state := range strings.Split(s, ",") {
+			states[strings.ToLower(strings.TrimSpace(state))] = struct{}{}
+		}
+	}
+
+	stores := rc.GetStores()
+	sort.Slice(stores, func(i, j int) bool { return stores[i].GetID() < stores[j].GetID() })
+	opt := h.svr.GetPersistOptions()
+	var matched []interface{}
+	for _, store := range stores {
+		s := newV2Store(newStoreInfo(opt, store))
+		if states == nil {
+			if store.IsTombstone() {
+				continue
+			}
+		} else if _, ok := states[strings.ToLower(s.State)]; !ok {
+			continue
+		}
+		matched = append(matched, s)
+	}
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	h.writeList(w, matched[offset:end], parseV2Fields(r), offset, limit, total)
+}
+
+// GetStore gets a store by ID.
+func (h *v2Handler) GetStore(w http.ResponseWriter, r *http.Request) {
+	rc := h.getCluster(w)
+	if rc == nil {
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, err.Error())
+		return
+	}
+	store := rc.GetStore(id)
+	if store == nil {
+		h.error(w, http.StatusNotFound, v2ErrNotFound, errs.ErrStoreNotFound.FastGenByArgs(id).Error())
+		return
+	}
+	h.writeObject(w, newV2Store(newStoreInfo(h.svr.GetPersistOptions(), store)), parseV2Fields(r))
+}
+
+// GetRegions lists the regions sorted by start key in the key range given by
+// the start_key and end_key query parameters in hex format.
+func (h *v2Handler) GetRegions(w http.ResponseWriter, r *http.Request) {
+	rc := h.getCluster(w)
+	if rc == nil {
+		return
+	}
+	offset, limit, err := parseV2Page(r)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	startKey, err := hex.DecodeString(query.Get("start_key"))
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, "start_key is not in hex format")
+		return
+	}
+	endKey, err := hex.DecodeString(query.Get("end_key"))
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, "end_key is not in hex format")
+		return
+	}
+
+	var regions []*core.RegionInfo
+	var total int
+	if len(startKey) == 0 && len(endKey) == 0 {
+		regions = rc.ScanRegions(nil, nil, offset+limit)
+		total = rc.GetRegionCount()
+	} else {
+		regions = rc.ScanRegions(startKey, endKey, 0)
+		total = len(regions)
+	}
+	if offset > len(regions) {
+		offset = len(regions)
+	}
+	regions = regions[offset:]
+	if len(regions) > limit {
+		regions = regions[:limit]
+	}
+	items := make([]interface{}, 0, len(regions))
+	for _, region := range regions {
+		items = append(items, newV2Region(region))
+	}
+	h.writeList(w, items, parseV2Fields(r), offset, limit, total)
+}
+
+// GetRegion gets a region by ID.
+func (h *v2Handler) GetRegion(w http.ResponseWriter, r *http.Request) {
+	rc := h.getCluster(w)
+	if rc == nil {
+		return
+	}
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, v2ErrInvalidArgument, err.Error())
+		return
+	}
+	region := rc.GetRegion(id)
+	if region == nil {
+		h.error(w, http.StatusNotFound, v2ErrNotFound, server.ErrRegionNotFound(id).Error())
+		return
+	}
+	h.writeObject(w, newV2Region(region), parseV2Fields(r))
+}
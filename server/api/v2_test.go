@@ -0,0 +1,145 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testV2Suite{})
+
+type testV2Suite struct {
+	svr       *server.Server
+	cleanup   cleanUpFunc
+	urlPrefix string
+}
+
+type v2TestList struct {
+	Data       []map[string]interface{} `json:"data"`
+	Pagination *v2Pagination            `json:"pagination"`
+}
+
+type v2TestObject struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+func (s *testV2Suite) SetUpSuite(c *C) {
+	s.svr, s.cleanup = mustNewServer(c)
+	mustWaitLeader(c, []*server.Server{s.svr})
+
+	addr := s.svr.GetAddr()
+	s.urlPrefix = fmt.Sprintf("%s%s%s", addr, apiPrefix, apiV2Prefix)
+
+	mustBootstrapCluster(c, s.svr)
+	mustPutStore(c, s.svr, 2, metapb.StoreState_Up, nil)
+	mustPutStore(c, s.svr, 3, metapb.StoreState_Offline, nil)
+	mustPutStore(c, s.svr, 4, metapb.StoreState_Tombstone, nil)
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(2, 1, []byte("a"), []byte("b"), core.SetLastHeartbeat(time.Now().Unix())))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(3, 1, []byte("b"), []byte("c")))
+	mustRegionHeartbeat(c, s.svr, newTestRegionInfo(4, 2, []byte("c"), []byte("d")))
+}
+
+func (s *testV2Suite) TearDownSuite(c *C) {
+	s.cleanup()
+}
+
+func readV2(c *C, url string, data interface{}) int {
+	resp, err := testDialClient.Get(url)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(json.NewDecoder(resp.Body).Decode(data), IsNil)
+	return resp.StatusCode
+}
+
+func v2IDs(list *v2TestList) []uint64 {
+	ids := make([]uint64, 0, len(list.Data))
+	for _, item := range list.Data {
+		ids = append(ids, uint64(item["id"].(float64)))
+	}
+	return ids
+}
+
+func (s *testV2Suite) TestStores(c *C) {
+	list := &v2TestList{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores", list), Equals, http.StatusOK)
+	c.Assert(v2IDs(list), DeepEquals, []uint64{1, 2, 3})
+	c.Assert(*list.Pagination, Equals, v2Pagination{Offset: 0, Limit: defaultV2PageLimit, Total: 3})
+	// The timestamps are in RFC3339 format.
+	_, err := time.Parse(time.RFC3339, list.Data[1]["last_heartbeat_ts"].(string))
+	c.Assert(err, IsNil)
+	c.Assert(list.Data[1]["state"], Equals, "Up")
+	c.Assert(list.Data[1]["leader_count"], NotNil)
+
+	list = &v2TestList{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores?offset=1&limit=1", list), Equals, http.StatusOK)
+	c.Assert(v2IDs(list), DeepEquals, []uint64{2})
+	c.Assert(*list.Pagination, Equals, v2Pagination{Offset: 1, Limit: 1, Total: 3})
+
+	list = &v2TestList{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores?offset=5", list), Equals, http.StatusOK)
+	c.Assert(list.Data, HasLen, 0)
+
+	list = &v2TestList{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores?state=tombstone,offline&fields=id,state", list), Equals, http.StatusOK)
+	c.Assert(v2IDs(list), DeepEquals, []uint64{3, 4})
+	c.Assert(list.Data[0], DeepEquals, map[string]interface{}{"id": float64(3), "state": "Offline"})
+
+	obj := &v2TestObject{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores/2?fields=id,address", obj), Equals, http.StatusOK)
+	c.Assert(obj.Data, DeepEquals, map[string]interface{}{"id": float64(2), "address": "tikv2"})
+
+	e := &v2ErrorResponse{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores/100", e), Equals, http.StatusNotFound)
+	c.Assert(e.Error.Code, Equals, v2ErrNotFound)
+	e = &v2ErrorResponse{}
+	c.Assert(readV2(c, s.urlPrefix+"/stores?limit=0", e), Equals, http.StatusBadRequest)
+	c.Assert(e.Error.Code, Equals, v2ErrInvalidArgument)
+}
+
+func (s *testV2Suite) TestRegions(c *C) {
+	list := &v2TestList{}
+	url := fmt.Sprintf("%s/regions?start_key=%s&end_key=%s&limit=2", s.urlPrefix, hex.EncodeToString([]byte("a")), hex.EncodeToString([]byte("d")))
+	c.Assert(readV2(c, url, list), Equals, http.StatusOK)
+	c.Assert(v2IDs(list), DeepEquals, []uint64{2, 3})
+	c.Assert(*list.Pagination, Equals, v2Pagination{Offset: 0, Limit: 2, Total: 3})
+	c.Assert(list.Data[0]["start_key"], Equals, "61")
+
+	list = &v2TestList{}
+	c.Assert(readV2(c, url+"&offset=2", list), Equals, http.StatusOK)
+	c.Assert(v2IDs(list), DeepEquals, []uint64{4})
+
+	obj := &v2TestObject{}
+	c.Assert(readV2(c, s.urlPrefix+"/regions/2?fields=id,first_seen,last_heartbeat", obj), Equals, http.StatusOK)
+	c.Assert(obj.Data, HasLen, 3)
+	for _, field := range []string{"first_seen", "last_heartbeat"} {
+		_, err := time.Parse(time.RFC3339, obj.Data[field].(string))
+		c.Assert(err, IsNil)
+	}
+
+	e := &v2ErrorResponse{}
+	c.Assert(readV2(c, s.urlPrefix+"/regions/100", e), Equals, http.StatusNotFound)
+	c.Assert(e.Error.Code, Equals, v2ErrNotFound)
+	e = &v2ErrorResponse{}
+	c.Assert(readV2(c, s.urlPrefix+"/regions?start_key=zz", e), Equals, http.StatusBadRequest)
+	c.Assert(e.Error.Code, Equals, v2ErrInvalidArgument)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 
+	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/versioninfo"
 	"github.com/unrolled/render"
 )
@@ -31,3 +32,42 @@ func (h *versionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	h.rd.JSON(w, http.StatusOK, version)
 }
+
+// ServiceVersions is the versions of the PD server and the cluster, with the
+// features and the API compatibility level the server supports.
+type ServiceVersions struct {
+	Version        string `json:"version"`
+	GitHash        string `json:"git_hash"`
+	ClusterVersion string `json:"cluster_version"`
+	// Features are all the feature gates the server supports, and whether each
+	// of them is enabled by the cluster version.
+	Features              map[string]bool `json:"features"`
+	APICompatibilityLevel int             `json:"api_compatibility_level"`
+}
+
+type serviceVersionsHandler struct {
+	svr *server.Server
+	rd  *render.Render
+}
+
+func newServiceVersionsHandler(svr *server.Server, rd *render.Render) *serviceVersionsHandler {
+	return &serviceVersionsHandler{
+		svr: svr,
+		rd:  rd,
+	}
+}
+
+// @Summary Get the versions of PD server and the cluster, with the supported features and the API compatibility level.
+// @Produce json
+// @Success 200 {object} ServiceVersions
+// @Router /version/services [get]
+func (h *serviceVersionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	clusterVersion := h.svr.GetClusterVersion()
+	h.rd.JSON(w, http.StatusOK, &ServiceVersions{
+		Version:               versioninfo.PDReleaseVersion,
+		GitHash:               versioninfo.PDGitHash,
+		ClusterVersion:        clusterVersion.String(),
+		Features:              versioninfo.FeatureGates(clusterVersion),
+		APICompatibilityLevel: versioninfo.APICompatibilityLevel,
+	})
+}
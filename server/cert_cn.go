@@ -0,0 +1,93 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tikv/pd/pkg/grpcutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsCNAllowed checks whether the client certificate CN is allowed to call the
+// mutating admin or client requests. The allowlists are read from the persisted
+// options, so that they can be changed without restart. The CN of PD itself is
+// always allowed, since the other PD members send the internal requests with it.
+func (s *Server) IsCNAllowed(cn string, admin bool) bool {
+	cfg := s.persistOptions.GetPDServerConfig()
+	allowed := cfg.ClientAllowedCN
+	if admin {
+		allowed = cfg.AdminAllowedCN
+	}
+	if len(allowed) == 0 {
+		return true
+	}
+	if len(cn) == 0 {
+		return false
+	}
+	if cn == s.certCN {
+		return true
+	}
+	for _, allowedCN := range allowed {
+		if cn == allowedCN {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRequestCNAllowed checks whether the request sent with the certificate CN
+// peerCN is allowed to call the mutating admin or client requests on the
+// leader, whose persisted options are up to date. The request sent with the
+// certificate of PD is forwarded by another PD member, then the CN of PD isn't
+// trusted, and callerCNs, which are the CN of the original caller carried by
+// the request, are checked instead. So the forwarded request without the CN of
+// the caller is rejected.
+func (s *Server) IsRequestCNAllowed(peerCN string, callerCNs []string, admin bool) bool {
+	if len(peerCN) == 0 || peerCN != s.certCN {
+		return s.IsCNAllowed(peerCN, admin)
+	}
+	if len(callerCNs) != 1 {
+		// Nothing is checked if the allowlist is empty.
+		return s.IsCNAllowed("", admin)
+	}
+	return s.IsCNAllowed(callerCNs[0], admin)
+}
+
+// checkAllowedCN checks the client certificate CN of the gRPC request, which
+// may be forwarded by another PD member. It is called after the request is
+// validated, so that it is checked by the leader.
+func (s *Server) checkAllowedCN(ctx context.Context, admin bool) error {
+	cn := grpcutil.GetPeerCommonName(ctx)
+	callerCNs := grpcutil.GetForwardedCNs(ctx)
+	if s.IsRequestCNAllowed(cn, callerCNs, admin) {
+		return nil
+	}
+	if len(cn) > 0 && cn == s.certCN {
+		return status.Errorf(codes.PermissionDenied, "certificate CN %q of the caller of the forwarded request is not allowed", strings.Join(callerCNs, ","))
+	}
+	return status.Errorf(codes.PermissionDenied, "certificate CN %q is not allowed", cn)
+}
+
+// checkInternalCN checks the client certificate CN of the internal gRPC
+// request sent by the other PD members, which is never forwarded.
+func (s *Server) checkInternalCN(ctx context.Context, admin bool) error {
+	cn := grpcutil.GetPeerCommonName(ctx)
+	if s.IsCNAllowed(cn, admin) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "certificate CN %q is not allowed", cn)
+}
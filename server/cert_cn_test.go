@@ -0,0 +1,76 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/server/config"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var _ = Suite(&testCertCNSuite{})
+
+type testCertCNSuite struct{}
+
+func newTestCertCNServer(allowedCN ...string) *Server {
+	cfg := config.NewConfig()
+	cfg.PDServerCfg.ClientAllowedCN = allowedCN
+	return &Server{persistOptions: config.NewPersistOptions(cfg), certCN: "pd"}
+}
+
+// newCNContext returns the context of the gRPC request sent with the
+// certificate CN and the forwarded metadata.
+func newCNContext(cn string, md metadata.MD) context.Context {
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func (s *testCertCNSuite) TestForwardedCN(c *C) {
+	svr := newTestCertCNServer("tikv")
+	// The request sent directly by the caller.
+	c.Assert(svr.checkAllowedCN(newCNContext("tikv", nil), false), IsNil)
+	c.Assert(svr.checkAllowedCN(newCNContext("tidb", nil), false), NotNil)
+	// The caller can't pretend to be forwarded.
+	c.Assert(svr.checkAllowedCN(newCNContext("tidb", metadata.Pairs(grpcutil.ForwardedCNMetadataKey, "tikv")), false), NotNil)
+
+	// The request forwarded by a follower is checked against the CN of the
+	// caller, and the CN of PD isn't trusted.
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", metadata.Pairs(grpcutil.ForwardedCNMetadataKey, "tikv")), false), IsNil)
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", metadata.Pairs(grpcutil.ForwardedCNMetadataKey, "tidb")), false), NotNil)
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", metadata.Pairs(grpcutil.ForwardedCNMetadataKey, "")), false), NotNil)
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", nil), false), NotNil)
+	// The internal request is sent by the other PD members with the CN of PD.
+	c.Assert(svr.checkInternalCN(newCNContext("pd", nil), false), IsNil)
+
+	// The follower records the CN of the caller when it forwards the request.
+	ctx := newCNContext("tidb", metadata.Pairs(grpcutil.ForwardMetadataKey, "leader", grpcutil.ForwardedCNMetadataKey, "tikv"))
+	md, ok := metadata.FromOutgoingContext(grpcutil.ResetForwardContext(ctx))
+	c.Assert(ok, IsTrue)
+	c.Assert(md.Get(grpcutil.ForwardedCNMetadataKey), DeepEquals, []string{"tidb"})
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", md), false), NotNil)
+
+	// Nothing is checked without the allowlist.
+	svr = newTestCertCNServer()
+	c.Assert(svr.checkAllowedCN(newCNContext("pd", nil), false), IsNil)
+	c.Assert(svr.checkAllowedCN(newCNContext("tidb", nil), false), IsNil)
+}
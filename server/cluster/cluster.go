@@ -14,8 +14,10 @@
 package cluster
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
@@ -103,20 +105,32 @@ type RaftCluster struct {
 	changedRegions chan *core.RegionInfo
 
 	labelLevelStats *statistics.LabelStatistics
+	isolationStats  *statistics.IsolationStatistics
 	regionStats     *statistics.RegionStatistics
 	hotStat         *statistics.HotStat
+	replicationLag  *statistics.ReplicationLagStats
 
 	coordinator      *coordinator
-	suspectRegions   *cache.TTLUint64 // suspectRegions are regions that may need fix
-	suspectKeyRanges *cache.TTLString // suspect key-range regions that may need fix
+	suspectRegions   *cache.TTLUint64    // suspectRegions are regions that may need fix
+	suspectKeyRanges *cache.TTLString    // suspect key-range regions that may need fix
+	pinnedRegions    *core.PinnedRegions // pinnedRegions are regions exempted from balance
+
+	heartbeatQuarantine *heartbeatQuarantine
+	// tombstoneNotices is the time when the nodes of the tombstone stores are
+	// first notified of the tombstone by this leader.
+	tombstoneMu      sync.Mutex
+	tombstoneNotices map[uint64]time.Time
 
 	wg           sync.WaitGroup
 	quit         chan struct{}
 	regionSyncer *syncer.RegionSyncer
 
-	ruleManager *placement.RuleManager
-	etcdClient  *clientv3.Client
-	httpClient  *http.Client
+	ruleManager     *placement.RuleManager
+	replicasRollout *replicasRolloutController
+	rollingRestart  *rollingRestartController
+	storeRemovals   *storeRemovalQueue
+	etcdClient      *clientv3.Client
+	httpClient      *http.Client
 
 	replicationMode *replication.ModeManager
 	traceRegionFlow bool
@@ -205,11 +219,19 @@ func (c *RaftCluster) InitCluster(id id.Allocator, opt *config.PersistOptions, s
 	c.storage = storage
 	c.id = id
 	c.labelLevelStats = statistics.NewLabelStatistics()
+	c.isolationStats = statistics.NewIsolationStatistics()
 	c.hotStat = statistics.NewHotStat(c.ctx, c.quit)
+	c.replicationLag = statistics.NewReplicationLagStats()
 	c.prepareChecker = newPrepareChecker()
 	c.changedRegions = make(chan *core.RegionInfo, defaultChangedRegionsLimit)
 	c.suspectRegions = cache.NewIDTTL(c.ctx, time.Minute, 3*time.Minute)
 	c.suspectKeyRanges = cache.NewStringTTL(c.ctx, time.Minute, 3*time.Minute)
+	c.pinnedRegions = core.NewPinnedRegions()
+	c.heartbeatQuarantine = newHeartbeatQuarantine()
+	c.tombstoneNotices = make(map[uint64]time.Time)
+	c.replicasRollout = newReplicasRolloutController(c)
+	c.rollingRestart = newRollingRestartController(c)
+	c.storeRemovals = newStoreRemovalQueue(c)
 	c.traceRegionFlow = opt.GetPDServerConfig().TraceRegionFlow
 }
 
@@ -239,6 +261,12 @@ func (c *RaftCluster) Start(s Server) error {
 			return err
 		}
 	}
+	if err = c.replicasRollout.load(); err != nil {
+		return err
+	}
+	if err = c.storeRemovals.load(); err != nil {
+		return err
+	}
 
 	c.componentManager = component.NewManager(c.storage)
 	_, err = c.storage.LoadComponent(&c.componentManager)
@@ -322,6 +350,9 @@ func (c *RaftCluster) runBackgroundJobs(interval time.Duration) {
 			c.checkStores()
 			c.collectMetrics()
 			c.coordinator.opController.PruneHistory()
+			c.replicasRollout.patrol()
+			c.rollingRestart.patrol(time.Now())
+			c.storeRemovals.patrol(time.Now())
 		}
 	}
 }
@@ -395,6 +426,38 @@ func (c *RaftCluster) GetRegionSplitter() *schedule.RegionSplitter {
 	return c.coordinator.regionSplitter
 }
 
+// ScatterSplitRegions scatters the regions created by a split request within
+// the group, and returns the percentage of the regions whose scatter operators
+// are added.
+func (c *RaftCluster) ScatterSplitRegions(regionsID []uint64, group string, retryLimit int, source string) int {
+	if len(regionsID) == 0 {
+		return 100
+	}
+	ops, failures, err := c.GetRegionScatter().ScatterRegionsByID(regionsID, group, retryLimit)
+	if err != nil {
+		log.Warn("failed to scatter the split regions", zap.Uint64s("region-ids", regionsID), errs.ZapError(err))
+		return 0
+	}
+	for _, op := range ops {
+		op.SetSource(source)
+		op.SetReason("scatter the split regions")
+		if ok := c.GetOperatorController().AddOperator(op); !ok {
+			failures[op.RegionID()] = errors.Errorf("region %v failed to add operator", op.RegionID())
+		}
+	}
+	if len(failures) > 0 {
+		log.Info("some split regions are not scattered", zap.String("group", group), zap.Int("failures", len(failures)), zap.Int("regions", len(regionsID)))
+	}
+	return 100 - 100*len(failures)/len(regionsID)
+}
+
+// GetRangeMerger returns the range merger.
+func (c *RaftCluster) GetRangeMerger() *schedule.RangeMerger {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.rangeMerger
+}
+
 // GetHeartbeatStreams returns the heartbeat streams.
 func (c *RaftCluster) GetHeartbeatStreams() *hbstream.HeartbeatStreams {
 	c.RLock()
@@ -465,6 +528,16 @@ func (c *RaftCluster) RemoveSuspectRegion(id uint64) {
 	c.suspectRegions.Remove(id)
 }
 
+// GetPinnedRegions returns the regions pinned to be exempted from balance.
+func (c *RaftCluster) GetPinnedRegions() *core.PinnedRegions {
+	return c.pinnedRegions
+}
+
+// IsRegionPinned checks if the region is pinned to be exempted from balance.
+func (c *RaftCluster) IsRegionPinned(region *core.RegionInfo) bool {
+	return c.pinnedRegions.IsPinned(region)
+}
+
 // AddSuspectKeyRange adds the key range with the its ruleID as the key
 // The instance of each keyRange is like following format:
 // [2][]byte: start key/end key
@@ -508,12 +581,18 @@ func (c *RaftCluster) HandleStoreHeartbeat(stats *pdpb.StoreStats) error {
 	if store == nil {
 		return errors.Errorf("store %v not found", storeID)
 	}
-	newStore := store.Clone(core.SetStoreStats(stats), core.SetLastHeartbeatTS(time.Now()))
+	newStore := store.Clone(
+		core.SetStoreStats(stats),
+		core.SetLastHeartbeatTS(time.Now()),
+		core.SetReservedSpace(c.opt.GetStoreReservedSpace(storeID)),
+	)
 	if newStore.IsLowSpace(c.opt.GetLowSpaceRatio()) {
 		log.Warn("store does not have enough disk space",
 			zap.Uint64("store-id", newStore.GetID()),
 			zap.Uint64("capacity", newStore.GetCapacity()),
-			zap.Uint64("available", newStore.GetAvailable()))
+			zap.Uint64("available", newStore.GetAvailable()),
+			zap.Uint64("reserved-space", newStore.GetReservedSpace()),
+			zap.Uint64("effective-available", newStore.GetEffectiveAvailable()))
 	}
 	if newStore.NeedPersist() && c.storage != nil {
 		if err := c.storage.SaveStore(newStore.GetMeta()); err != nil {
@@ -582,6 +661,11 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	if err != nil {
 		return err
 	}
+	// The suspicious heartbeat doesn't update the routing until it is confirmed.
+	if cfg := c.opt.GetPDServerConfig(); cfg.EnableHeartbeatQuarantine &&
+		c.heartbeatQuarantine.quarantine(origin, region, cfg.HeartbeatQuarantineEpochGap) {
+		return nil
+	}
 	hotStat.CheckWriteAsync(statistics.NewCheckExpiredItemTask(region))
 	hotStat.CheckReadAsync(statistics.NewCheckExpiredItemTask(region))
 	reportInterval := region.GetInterval()
@@ -594,13 +678,16 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	// Save to storage if meta is updated.
 	// Save to cache if meta or leader is updated, or contains any down/pending peer.
 	// Mark isNew if the region in cache does not have leader.
-	var saveKV, saveCache, isNew, needSync bool
+	var saveKV, saveCache, isNew, needSync, flowRounded bool
 	if origin == nil {
 		log.Debug("insert new region",
 			zap.Uint64("region-id", region.GetID()),
 			logutil.ZapRedactStringer("meta-region", core.RegionToHexMeta(region.GetMeta())))
 		saveKV, saveCache, isNew = true, true, true
 	} else {
+		// The region in cache is not replaced if nothing changes, so the heartbeat
+		// timestamp is refreshed separately.
+		origin.UpdateLastHeartbeat(region.GetLastHeartbeat())
 		r := region.GetRegionEpoch()
 		o := origin.GetRegionEpoch()
 		if r.GetVersion() > o.GetVersion() {
@@ -654,6 +741,11 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 		if region.GetRoundBytesWritten() != origin.GetRoundBytesWritten() ||
 			region.GetRoundBytesRead() != origin.GetRoundBytesRead() {
 			saveCache, needSync = true, true
+		} else if region.GetBytesWritten() != origin.GetBytesWritten() ||
+			region.GetBytesRead() != origin.GetBytesRead() {
+			// The small fluctuation of the flow is rounded off by flow-round-by-digit,
+			// the hot statistics are updated by the raw flow above anyway.
+			flowRounded = true
 		}
 
 		if region.GetReplicationStatus().GetState() != replication_modepb.RegionReplicationState_UNKNOWN &&
@@ -664,6 +756,9 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 	}
 
 	if !saveKV && !saveCache && !isNew {
+		if flowRounded {
+			regionEventCounter.WithLabelValues("suppress_flow_update").Inc()
+		}
 		return nil
 	}
 
@@ -688,6 +783,9 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 				c.regionStats.ClearDefunctRegion(item.GetID())
 			}
 			c.labelLevelStats.ClearDefunctRegion(item.GetID())
+			c.isolationStats.ClearDefunctRegion(item.GetID())
+			c.replicationLag.ClearDefunctRegion(item.GetID())
+			c.hotStat.Buckets.Remove(item.GetID())
 		}
 
 		// Update related stores.
@@ -732,7 +830,9 @@ func (c *RaftCluster) processRegionHeartbeat(region *core.RegionInfo) error {
 			}
 		}
 		if saveKV {
-			if err := storage.SaveRegion(region.GetMeta()); err != nil {
+			// The refused writes for the insufficient disk space are counted by
+			// the disk guard, logging them for each region heartbeat is too noisy.
+			if err := storage.SaveRegion(region.GetMeta()); err != nil && !errs.ErrDiskSpaceInsufficient.Equal(err) {
 				log.Error("failed to save region to storage",
 					zap.Uint64("region-id", region.GetID()),
 					logutil.ZapRedactStringer("region-meta", core.RegionToHexMeta(region.GetMeta())),
@@ -890,6 +990,8 @@ func (c *RaftCluster) DropCacheRegion(id uint64) {
 	defer c.RUnlock()
 	if region := c.GetRegion(id); region != nil {
 		c.core.RemoveRegion(region)
+		c.hotStat.Buckets.Remove(id)
+		c.replicationLag.ClearDefunctRegion(id)
 	}
 }
 
@@ -928,6 +1030,12 @@ func (c *RaftCluster) GetAdjacentRegions(region *core.RegionInfo) (*core.RegionI
 	return c.core.GetAdjacentRegions(region)
 }
 
+// CheckRegionRanges returns the key ranges which are covered by no region or
+// more than one region, which indicates the routing is corrupted.
+func (c *RaftCluster) CheckRegionRanges() []*core.RegionRangeAnomaly {
+	return c.core.CheckRegionRanges()
+}
+
 // UpdateStoreLabels updates a store's location labels
 // If 'force' is true, then update the store's labels forcibly.
 func (c *RaftCluster) UpdateStoreLabels(storeID uint64, labels []*metapb.StoreLabel, force bool) error {
@@ -987,6 +1095,10 @@ func (c *RaftCluster) putStoreImpl(store *metapb.Store, force bool) error {
 			// If 'force' isn't set, the given labels will merge into those labels which already existed in the store.
 			labels = s.MergeLabels(labels)
 		}
+		// The store restarts if its start timestamp changes.
+		if s.GetMeta().GetStartTimestamp() > 0 && store.GetStartTimestamp() > s.GetMeta().GetStartTimestamp() {
+			c.rollingRestart.observeRestart(s.GetID(), time.Now())
+		}
 		// Update an existed store.
 		s = s.Clone(
 			core.SetStoreAddress(store.Address, store.StatusAddress, store.PeerAddress),
@@ -1072,6 +1184,7 @@ func (c *RaftCluster) RemoveStore(storeID uint64, physicallyDestroyed bool) erro
 		zap.Bool("physically-destroyed", newStore.IsPhysicallyDestroyed()))
 	err := c.putStoreLocked(newStore)
 	if err == nil {
+		c.saveStoreStateChange(store, newStore, core.StoreTriggerAPI)
 		// TODO: if the persist operation encounters error, the "Unlimited" will be rollback.
 		// And considering the store state has changed, RemoveStore is actually successful.
 		_ = c.SetStoreLimit(storeID, storelimit.RemovePeer, storelimit.Unlimited)
@@ -1079,6 +1192,66 @@ func (c *RaftCluster) RemoveStore(storeID uint64, physicallyDestroyed bool) erro
 	return err
 }
 
+// RemoveStores marks a batch of stores as offline in cluster. It returns the
+// result of each store keyed by the store ID, a nil value means the store is
+// removed successfully.
+func (c *RaftCluster) RemoveStores(storeIDs []uint64, physicallyDestroyed bool) map[uint64]error {
+	results := make(map[uint64]error, len(storeIDs))
+	for _, storeID := range storeIDs {
+		results[storeID] = c.RemoveStore(storeID, physicallyDestroyed)
+	}
+	return results
+}
+
+// EnqueueStoreRemovals enqueues the stores to be removed in order, at most
+// parallel stores are removed at the same time. A non-positive parallel keeps
+// the current one.
+func (c *RaftCluster) EnqueueStoreRemovals(storeIDs []uint64, physicallyDestroyed bool, parallel int) error {
+	return c.storeRemovals.enqueue(storeIDs, physicallyDestroyed, parallel)
+}
+
+// GetStoreRemovalQueueStatus returns the status of the store removal queue.
+func (c *RaftCluster) GetStoreRemovalQueueStatus() *StoreRemovalQueueStatus {
+	return c.storeRemovals.getStatus()
+}
+
+// SelectStores returns the stores that are not tombstone and match both the
+// address and the labels. An address without port matches all the stores on
+// that host. An empty address or empty labels means no restriction.
+func (c *RaftCluster) SelectStores(address string, labels []*metapb.StoreLabel) []*core.StoreInfo {
+	var stores []*core.StoreInfo
+	for _, s := range c.GetStores() {
+		if s.IsTombstone() {
+			continue
+		}
+		if address != "" && !matchStoreAddress(s.GetAddress(), address) {
+			continue
+		}
+		matched := true
+		for _, label := range labels {
+			if s.GetLabelValue(label.GetKey()) != label.GetValue() {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			stores = append(stores, s)
+		}
+	}
+	return stores
+}
+
+func matchStoreAddress(storeAddress, address string) bool {
+	if storeAddress == address {
+		return true
+	}
+	host, _, err := net.SplitHostPort(storeAddress)
+	if err != nil {
+		return false
+	}
+	return host == address
+}
+
 // buryStore marks a store as tombstone in cluster.
 // The store should be empty before calling this func
 // State transition: Offline -> Tombstone.
@@ -1109,6 +1282,7 @@ func (c *RaftCluster) buryStore(storeID uint64) error {
 	err := c.putStoreLocked(newStore)
 	c.onStoreVersionChangeLocked()
 	if err == nil {
+		c.saveStoreStateChange(store, newStore, core.StoreTriggerPD)
 		// clean up the residual information.
 		c.RemoveStoreLimit(storeID)
 		c.hotStat.RemoveRollingStoreStats(storeID)
@@ -1158,7 +1332,123 @@ func (c *RaftCluster) UpStore(storeID uint64) error {
 	log.Warn("store has been up",
 		zap.Uint64("store-id", storeID),
 		zap.String("store-address", newStore.GetAddress()))
-	return c.putStoreLocked(newStore)
+	if err := c.putStoreLocked(newStore); err != nil {
+		return err
+	}
+	c.saveStoreStateChange(store, newStore, core.StoreTriggerAPI)
+	return nil
+}
+
+// saveStoreStateChange persists the state change of the store. The history is
+// only for auditing, so the failure is logged instead of being returned.
+func (c *RaftCluster) saveStoreStateChange(origin, store *core.StoreInfo, trigger string) {
+	if c.storage == nil {
+		return
+	}
+	change := core.NewStoreStateChange(origin, store, trigger)
+	if err := c.storage.SaveStoreStateChange(change); err != nil {
+		log.Error("failed to save the store state change",
+			zap.Uint64("store-id", store.GetID()),
+			zap.String("from", change.From),
+			zap.String("to", change.To),
+			errs.ZapError(err))
+	}
+}
+
+// GetStoreStateChanges returns the state change history of the store, in
+// ascending order of time.
+func (c *RaftCluster) GetStoreStateChanges(storeID uint64) ([]*core.StoreStateChange, error) {
+	return c.storage.LoadStoreStateChanges(storeID)
+}
+
+// PrepareStoreRestart annotates the store to restart, then its leaders are
+// moved out before it restarts and back after it restarts.
+func (c *RaftCluster) PrepareStoreRestart(storeID uint64) error {
+	return c.rollingRestart.prepareRestart(storeID, time.Now())
+}
+
+// CancelStoreRestart removes the restart annotation of the store.
+func (c *RaftCluster) CancelStoreRestart(storeID uint64) {
+	c.rollingRestart.cancelRestart(storeID, time.Now())
+}
+
+// GetRollingRestartStatus returns the status of the rolling restart of the
+// stores.
+func (c *RaftCluster) GetRollingRestartStatus() *RollingRestartStatus {
+	return c.rollingRestart.getStatus()
+}
+
+// NotifyStoreTombstone records that the node of the tombstone store is notified
+// of the tombstone by the response of its request. The node is supposed to stop
+// itself then, which is confirmed by AckStoreTombstone.
+func (c *RaftCluster) NotifyStoreTombstone(storeID uint64) {
+	if !c.opt.GetPDServerConfig().EnableTombstonePurgeAck {
+		return
+	}
+	c.tombstoneMu.Lock()
+	defer c.tombstoneMu.Unlock()
+	if _, ok := c.tombstoneNotices[storeID]; !ok {
+		c.tombstoneNotices[storeID] = time.Now()
+	}
+}
+
+// GetStoreTombstoneNotice returns the time when the node of the tombstone store
+// is first notified of the tombstone by this leader, or zero if it is not.
+func (c *RaftCluster) GetStoreTombstoneNotice(storeID uint64) time.Time {
+	c.tombstoneMu.Lock()
+	defer c.tombstoneMu.Unlock()
+	return c.tombstoneNotices[storeID]
+}
+
+// AckStoreTombstone records that the node of the tombstone store confirms that
+// it is notified of the tombstone and has stopped, so the data of the store is
+// safe to be destroyed after then. The first acknowledgement is kept.
+func (c *RaftCluster) AckStoreTombstone(storeID uint64) (*core.StoreTombstoneAck, error) {
+	if !c.opt.GetPDServerConfig().EnableTombstonePurgeAck {
+		return nil, errs.ErrTombstonePurgeAckDisabled.FastGenByArgs()
+	}
+	store := c.GetStore(storeID)
+	if store == nil {
+		return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	if !store.IsTombstone() {
+		return nil, errs.ErrStoreNotTombstone.FastGenByArgs(storeID)
+	}
+	// The store may be acknowledged before the leader changes.
+	ack, err := c.storage.LoadStoreTombstoneAck(storeID)
+	if err != nil || ack != nil {
+		return ack, err
+	}
+	ack = &core.StoreTombstoneAck{
+		StoreID: storeID,
+		Address: store.GetAddress(),
+		Time:    time.Now(),
+	}
+	if err := c.storage.SaveStoreTombstoneAck(ack); err != nil {
+		return nil, err
+	}
+	log.Info("the node of the tombstone store is stopped, the data is safe to be destroyed",
+		zap.Uint64("store-id", storeID),
+		zap.String("store-address", store.GetAddress()))
+	return ack, nil
+}
+
+// GetStoreTombstoneAck returns the acknowledgement of the tombstone store, or
+// nil if its node does not confirm yet. The acknowledgement is kept even after
+// the store is removed.
+func (c *RaftCluster) GetStoreTombstoneAck(storeID uint64) (*core.StoreTombstoneAck, error) {
+	if !c.opt.GetPDServerConfig().EnableTombstonePurgeAck {
+		return nil, errs.ErrTombstonePurgeAckDisabled.FastGenByArgs()
+	}
+	store := c.GetStore(storeID)
+	if store != nil && !store.IsTombstone() {
+		return nil, errs.ErrStoreNotTombstone.FastGenByArgs(storeID)
+	}
+	ack, err := c.storage.LoadStoreTombstoneAck(storeID)
+	if err == nil && ack == nil && store == nil {
+		return nil, errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	return ack, err
 }
 
 // SetStoreWeight sets up a store's leader/region balance weight.
@@ -1305,6 +1595,8 @@ func (c *RaftCluster) collectClusterMetrics() {
 	}
 	c.regionStats.Collect()
 	c.labelLevelStats.Collect()
+	c.isolationStats.Collect()
+	c.replicationLag.Collect()
 	hotStat := c.hotStat
 	c.RUnlock()
 	// collect hot cache metrics
@@ -1320,6 +1612,8 @@ func (c *RaftCluster) resetClusterMetrics() {
 	}
 	c.regionStats.Reset()
 	c.labelLevelStats.Reset()
+	c.isolationStats.Reset()
+	c.replicationLag.Reset()
 	hotStat := c.hotStat
 	c.RUnlock()
 	// reset hot cache metrics
@@ -1355,6 +1649,25 @@ func (c *RaftCluster) GetRegionStatsByType(typ statistics.RegionStatisticType) [
 	return c.regionStats.GetRegionStatsByType(typ)
 }
 
+// GetLostQuorumRegions gets the regions which have fewer live voters than the
+// quorum.
+func (c *RaftCluster) GetLostQuorumRegions() []*statistics.LostQuorumRegion {
+	c.RLock()
+	defer c.RUnlock()
+	if c.regionStats == nil {
+		return nil
+	}
+	return c.regionStats.GetLostQuorumRegions()
+}
+
+// GetIsolationViolationReport gets the report of the regions which violate the
+// isolation level of the placement rules.
+func (c *RaftCluster) GetIsolationViolationReport() *statistics.IsolationViolationReport {
+	c.RLock()
+	defer c.RUnlock()
+	return c.isolationStats.GetReport()
+}
+
 // GetOfflineRegionStatsByType gets the status of the offline region by types.
 func (c *RaftCluster) GetOfflineRegionStatsByType(typ statistics.RegionStatisticType) []*core.RegionInfo {
 	c.RLock()
@@ -1370,6 +1683,11 @@ func (c *RaftCluster) updateRegionsLabelLevelStats(regions []*core.RegionInfo) {
 	defer c.Unlock()
 	for _, region := range regions {
 		c.labelLevelStats.Observe(region, c.getRegionStoresLocked(region), c.opt.GetLocationLabels())
+		var fit *placement.RegionFit
+		if c.opt.IsPlacementRulesEnabled() && c.ruleManager.IsInitialized() {
+			fit = c.ruleManager.FitRegion(c.core, region)
+		}
+		c.isolationStats.Observe(region, fit, c.core)
 	}
 }
 
@@ -1388,6 +1706,35 @@ func (c *RaftCluster) AllocID() (uint64, error) {
 	return c.id.Alloc()
 }
 
+// GetMaxObservedID returns the largest store, region and peer ID in the cluster,
+// all of which are allocated by the ID allocator.
+func (c *RaftCluster) GetMaxObservedID() uint64 {
+	var maxID uint64
+	for _, store := range c.GetStores() {
+		if store.GetID() > maxID {
+			maxID = store.GetID()
+		}
+	}
+	for _, region := range c.GetRegions() {
+		if region.GetID() > maxID {
+			maxID = region.GetID()
+		}
+		for _, peer := range region.GetPeers() {
+			if peer.GetId() > maxID {
+				maxID = peer.GetId()
+			}
+		}
+	}
+	return maxID
+}
+
+// EnsureIDAboveObserved makes sure the ID allocator doesn't allocate the IDs which
+// are already used by the cluster, e.g. after the allocator window rolls back.
+// The cluster is scanned in the background and the allocation waits for it.
+func (c *RaftCluster) EnsureIDAboveObserved() {
+	c.id.EnsureAboveAsync(c.GetMaxObservedID)
+}
+
 // OnStoreVersionChange changes the version of the cluster when needed.
 func (c *RaftCluster) OnStoreVersionChange() {
 	c.RLock()
@@ -1435,15 +1782,7 @@ func (c *RaftCluster) changedRegionNotifier() <-chan *core.RegionInfo {
 
 // IsFeatureSupported checks if the feature is supported by current cluster.
 func (c *RaftCluster) IsFeatureSupported(f versioninfo.Feature) bool {
-	clusterVersion := *c.opt.GetClusterVersion()
-	minSupportVersion := *versioninfo.MinSupportedVersion(f)
-	// For features before version 5.0 (such as BatchSplit), strict version checks are performed according to the
-	// original logic. But according to Semantic Versioning, specify a version MAJOR.MINOR.PATCH, PATCH is used when you
-	// make backwards compatible bug fixes. In version 5.0 and later, we need to strictly comply.
-	if versioninfo.IsCompatible(minSupportVersion, *versioninfo.MinSupportedVersion(versioninfo.Version4_0)) {
-		return !clusterVersion.LessThan(minSupportVersion)
-	}
-	return versioninfo.IsCompatible(minSupportVersion, clusterVersion)
+	return versioninfo.IsFeatureSupported(*c.opt.GetClusterVersion(), f)
 }
 
 // GetConfig gets config from cluster.
@@ -1508,6 +1847,48 @@ func (c *RaftCluster) RegionWriteStats() map[uint64][]*statistics.HotPeerStat {
 	return c.hotStat.RegionStats(statistics.WriteFlow, c.GetOpts().GetHotRegionCacheHitsThreshold())
 }
 
+// HandleBucketsReport updates the bucket statistics of a region. The buckets are
+// sub ranges of the region, which expose the skew of the flow within a large region.
+// The bucket keys must start with the start key of the region, end with its end
+// key and be strictly increasing, which is checked when updating the statistics.
+func (c *RaftCluster) HandleBucketsReport(report *statistics.BucketsReport) error {
+	region := c.GetRegion(report.RegionID)
+	if region == nil {
+		return errors.Errorf("region %d not found", report.RegionID)
+	}
+	if len(report.Keys) > 0 && (!bytes.Equal(report.Keys[0], region.GetStartKey()) ||
+		!bytes.Equal(report.Keys[len(report.Keys)-1], region.GetEndKey())) {
+		return errors.Errorf("the buckets of region %d don't match its range", report.RegionID)
+	}
+	return c.hotStat.Buckets.Update(report)
+}
+
+// HandleApplyStateReport updates the replication lag of a region according to
+// the apply states of its peers reported by the leader.
+func (c *RaftCluster) HandleApplyStateReport(report *statistics.ApplyStateReport) error {
+	region := c.GetRegion(report.RegionID)
+	if region == nil {
+		return errors.Errorf("region %d not found", report.RegionID)
+	}
+	return c.replicationLag.Observe(region, report)
+}
+
+// GetLaggingPeers returns the peers whose replication lag is not less than
+// the threshold.
+func (c *RaftCluster) GetLaggingPeers(threshold uint64) []*statistics.PeerReplicationLag {
+	return c.replicationLag.GetLaggingPeers(threshold)
+}
+
+// GetStoreReplicationLags returns the replication lag of the peers on each store.
+func (c *RaftCluster) GetStoreReplicationLags(threshold uint64) map[uint64]*statistics.StoreReplicationLag {
+	return c.replicationLag.GetStoreLags(threshold)
+}
+
+// RegionBucketsStat returns the bucket statistics of the region.
+func (c *RaftCluster) RegionBucketsStat(region *core.RegionInfo) *statistics.BucketsStat {
+	return c.hotStat.Buckets.Get(region)
+}
+
 // TODO: remove me.
 // only used in test.
 //nolint:unused
@@ -1616,6 +1997,21 @@ func getHotRegionsByStoreIDs(hotPeerInfos *statistics.StoreHotPeersInfos, storeI
 	}
 }
 
+// GetSchedulerStats returns the runtime statistics of the scheduler.
+func (c *RaftCluster) GetSchedulerStats(name string) (*SchedulerStats, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getSchedulerStats(name)
+}
+
+// EvaluateScheduler evaluates the scheduler with the candidate config over
+// the current statistics without adding the operators.
+func (c *RaftCluster) EvaluateScheduler(name string, config map[string]interface{}) (*SchedulerEvaluation, error) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.evaluateScheduler(name, config)
+}
+
 // GetSchedulers gets all schedulers.
 func (c *RaftCluster) GetSchedulers() []string {
 	c.RLock()
@@ -1644,11 +2040,27 @@ func (c *RaftCluster) RemoveScheduler(name string) error {
 	return c.coordinator.removeScheduler(name)
 }
 
-// PauseOrResumeScheduler pauses or resumes a scheduler.
-func (c *RaftCluster) PauseOrResumeScheduler(name string, t int64) error {
+// PauseOrResumeScheduler pauses or resumes a scheduler, the actor and reason
+// of the pause are recorded.
+func (c *RaftCluster) PauseOrResumeScheduler(name string, t int64, actor, reason string) error {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.pauseOrResumeScheduler(name, t, actor, reason)
+}
+
+// GetSchedulerDisableRecord returns the record of why the scheduler is paused or
+// disabled, it returns nil if the scheduler is running.
+func (c *RaftCluster) GetSchedulerDisableRecord(name string) *SchedulerDisableRecord {
+	c.RLock()
+	defer c.RUnlock()
+	return c.coordinator.getSchedulerDisableRecord(name)
+}
+
+// GetDisabledSchedulers returns the names of the disabled schedulers.
+func (c *RaftCluster) GetDisabledSchedulers() []string {
 	c.RLock()
 	defer c.RUnlock()
-	return c.coordinator.pauseOrResumeScheduler(name, t)
+	return c.coordinator.getDisabledSchedulers()
 }
 
 // IsSchedulerPaused checks if a scheduler is paused.
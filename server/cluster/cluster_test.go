@@ -15,12 +15,15 @@ package cluster
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	. "github.com/pingcap/check"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
@@ -28,6 +31,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockid"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/id"
@@ -57,6 +61,30 @@ func (s *testClusterInfoSuite) SetUpTest(c *C) {
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 }
 
+func (s *testClusterInfoSuite) TestStoreReservedSpace(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.StoreReservedSpace = 10 << 30
+	cfg.StoreReservedSpaceOverrides = map[uint64]typeutil.ByteSize{2: 30 << 30}
+	opt.SetScheduleConfig(cfg)
+
+	for _, store := range newTestStores(2, "2.0.0") {
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+		c.Assert(cluster.HandleStoreHeartbeat(&pdpb.StoreStats{
+			StoreId:   store.GetID(),
+			Capacity:  100 << 30,
+			Available: 50 << 30,
+			UsedSize:  40 << 30,
+		}), IsNil)
+	}
+	c.Assert(cluster.GetStore(1).GetReservedSpace(), Equals, uint64(10<<30))
+	c.Assert(cluster.GetStore(1).GetEffectiveAvailable(), Equals, uint64(50<<30))
+	c.Assert(cluster.GetStore(2).GetReservedSpace(), Equals, uint64(30<<30))
+	c.Assert(cluster.GetStore(2).GetEffectiveAvailable(), Equals, uint64(30<<30))
+}
+
 func (s *testClusterInfoSuite) TestStoreHeartbeat(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -255,6 +283,129 @@ func (s *testClusterInfoSuite) TestSetOfflineStore(c *C) {
 	}
 }
 
+func (s *testClusterInfoSuite) TestStoreRemovalQueue(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	// The headroom of each store is 100MiB since the low space ratio is 0.8.
+	regionSizes := []int64{0, 100, 250, 0, 0, 0}
+	for _, store := range newTestStores(5, "2.0.0") {
+		store = store.Clone(
+			core.SetStoreStats(&pdpb.StoreStats{Capacity: 1000 << 20, Available: 300 << 20}),
+			core.SetRegionSize(regionSizes[store.GetID()]),
+		)
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	getStates := func() map[uint64]string {
+		states := make(map[uint64]string)
+		for _, removal := range cluster.GetStoreRemovalQueueStatus().Removals {
+			states[removal.StoreID] = removal.State
+		}
+		return states
+	}
+
+	c.Assert(errors.ErrorEqual(cluster.EnqueueStoreRemovals([]uint64{1, 10}, false, 2), errs.ErrStoreNotFound.FastGenByArgs(10)), IsTrue)
+	c.Assert(cluster.GetStoreRemovalQueueStatus().Removals, HasLen, 0)
+	c.Assert(cluster.EnqueueStoreRemovals([]uint64{1, 2}, false, 2), IsNil)
+	c.Assert(cluster.EnqueueStoreRemovals([]uint64{2}, false, 0), NotNil)
+	// The store 2 is blocked since the 3 up stores can't hold the data of
+	// both the store 1 and the store 2.
+	c.Assert(getStates(), DeepEquals, map[uint64]string{1: StoreRemovalRemoving, 2: StoreRemovalPending})
+	c.Assert(cluster.GetStore(1).IsOffline(), IsTrue)
+	c.Assert(cluster.GetStore(2).IsUp(), IsTrue)
+	status := cluster.GetStoreRemovalQueueStatus()
+	c.Assert(status.Parallel, Equals, 2)
+	c.Assert(status.Removals[1].Blocked, Not(Equals), "")
+	// The blocked reason is recomputed by the patrol rather than persisted.
+	value, err := cluster.storage.Load("store_removal_queue")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(value, "blocked"), IsFalse)
+
+	// The queue goes on after the leader changes.
+	cluster.storeRemovals = newStoreRemovalQueue(cluster)
+	c.Assert(cluster.GetStoreRemovalQueueStatus().Removals, HasLen, 0)
+	c.Assert(cluster.storeRemovals.load(), IsNil)
+	c.Assert(cluster.GetStoreRemovalQueueStatus().Parallel, Equals, 2)
+	c.Assert(getStates(), DeepEquals, map[uint64]string{1: StoreRemovalRemoving, 2: StoreRemovalPending})
+
+	// The store 1 is buried since it has no region.
+	cluster.checkStores()
+	cluster.storeRemovals.patrol(time.Now())
+	c.Assert(getStates(), DeepEquals, map[uint64]string{1: StoreRemovalRemoved, 2: StoreRemovalRemoving})
+	c.Assert(cluster.GetStore(2).IsOffline(), IsTrue)
+
+	// The store is set up again.
+	c.Assert(cluster.UpStore(2), IsNil)
+	cluster.storeRemovals.patrol(time.Now())
+	c.Assert(getStates(), DeepEquals, map[uint64]string{1: StoreRemovalRemoved, 2: StoreRemovalFailed})
+
+	// The finished removals are pruned after a while.
+	cluster.storeRemovals.patrol(time.Now().Add(storeRemovalHistoryTTL))
+	c.Assert(cluster.GetStoreRemovalQueueStatus().Removals, HasLen, 0)
+
+	// The finished removals are not persisted.
+	c.Assert(cluster.EnqueueStoreRemovals([]uint64{3}, false, 0), IsNil)
+	c.Assert(getStates(), DeepEquals, map[uint64]string{3: StoreRemovalRemoving})
+	cluster.checkStores()
+	cluster.storeRemovals.patrol(time.Now())
+	c.Assert(getStates(), DeepEquals, map[uint64]string{3: StoreRemovalRemoved})
+	cluster.storeRemovals = newStoreRemovalQueue(cluster)
+	c.Assert(cluster.storeRemovals.load(), IsNil)
+	c.Assert(cluster.GetStoreRemovalQueueStatus().Removals, HasLen, 0)
+}
+
+func (s *testClusterInfoSuite) TestSelectAndRemoveStores(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	// Put 4 stores, store 1 and 2 are on the same host.
+	for _, store := range newTestStores(4, "2.0.0") {
+		meta := store.GetMeta()
+		if meta.GetId() <= 2 {
+			meta.Labels = []*metapb.StoreLabel{{Key: "host", Value: "h1"}}
+		} else {
+			meta.Address = fmt.Sprintf("127.0.0.2:%d", meta.GetId())
+		}
+		c.Assert(cluster.PutStore(meta), IsNil)
+	}
+
+	c.Assert(cluster.SelectStores("127.0.0.1", nil), HasLen, 2)
+	c.Assert(cluster.SelectStores("127.0.0.2:3", nil), HasLen, 1)
+	c.Assert(cluster.SelectStores("", []*metapb.StoreLabel{{Key: "host", Value: "h1"}}), HasLen, 2)
+	c.Assert(cluster.SelectStores("127.0.0.2", []*metapb.StoreLabel{{Key: "host", Value: "h1"}}), HasLen, 0)
+
+	c.Assert(cluster.RemoveStore(2, true), IsNil)
+	results := cluster.RemoveStores([]uint64{1, 2, 5}, false)
+	c.Assert(results, HasLen, 3)
+	c.Assert(results[1], IsNil)
+	c.Assert(results[2], NotNil)
+	c.Assert(results[5], NotNil)
+	c.Assert(cluster.GetStore(1).IsOffline(), IsTrue)
+}
+
+func (s *testClusterInfoSuite) TestEnsureIDAboveObserved(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	for _, store := range newTestStores(4, "2.0.0") {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+	for _, region := range newTestRegions(4, 3) {
+		c.Assert(cluster.putRegion(region), IsNil)
+	}
+	// The largest peer ID is 11.
+	c.Assert(cluster.GetMaxObservedID(), Equals, uint64(11))
+
+	cluster.EnsureIDAboveObserved()
+	id, err := cluster.AllocID()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, uint64(12))
+	cluster.EnsureIDAboveObserved()
+	id, err = cluster.AllocID()
+	c.Assert(err, IsNil)
+	c.Assert(id, Equals, uint64(13))
+}
+
 func (s *testClusterInfoSuite) TestReuseAddress(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -330,6 +481,246 @@ func (s *testClusterInfoSuite) TestUpStore(c *C) {
 
 }
 
+func (s *testClusterInfoSuite) TestReplicasRollout(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+	cluster.ruleManager = placement.NewRuleManager(storage, cluster)
+	c.Assert(cluster.ruleManager.Initialize(opt.GetMaxReplicas(), opt.GetLocationLabels()), IsNil)
+	for _, store := range newTestStores(4, "5.0.0") {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+	keys := [][]byte{{}, []byte("b"), []byte("c"), {}}
+	for i := uint64(1); i <= 3; i++ {
+		peers := []*metapb.Peer{{Id: i * 10, StoreId: 1}, {Id: i*10 + 1, StoreId: 2}, {Id: i*10 + 2, StoreId: 3}}
+		region := core.NewRegionInfo(&metapb.Region{
+			Id:          i,
+			StartKey:    keys[i-1],
+			EndKey:      keys[i],
+			Peers:       peers,
+			RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		}, peers[0])
+		c.Assert(cluster.putRegion(region), IsNil)
+	}
+	addPeer := func(regionID uint64) {
+		region := cluster.GetRegion(regionID)
+		c.Assert(cluster.putRegion(region.Clone(core.WithAddPeer(&metapb.Peer{Id: regionID*10 + 3, StoreId: 4}))), IsNil)
+	}
+
+	c.Assert(cluster.StartReplicasRollout(3, 1), NotNil)
+	c.Assert(cluster.PauseReplicasRollout(), NotNil)
+	c.Assert(cluster.StartReplicasRollout(4, 1), IsNil)
+	c.Assert(cluster.IsReplicasRolloutInProgress(), IsTrue)
+	c.Assert(errs.ErrReplicasRolloutInProgress.Equal(cluster.StartReplicasRollout(4, 1)), IsTrue)
+	rollout := cluster.GetReplicasRollout()
+	c.Assert(rollout.From, Equals, 3)
+	c.Assert(rollout.Batches, Equals, 1)
+	c.Assert(rollout.BatchEndKey, Equals, hex.EncodeToString([]byte("b")))
+	c.Assert(cluster.ruleManager.GetRule("pd", replicasRolloutRuleID).Count, Equals, 4)
+
+	// The next batch is not started until the regions in the batch are rolled out.
+	cluster.replicasRollout.patrol()
+	c.Assert(cluster.GetReplicasRollout().Batches, Equals, 1)
+	addPeer(1)
+	cluster.replicasRollout.patrol()
+	rollout = cluster.GetReplicasRollout()
+	c.Assert(rollout.Batches, Equals, 2)
+	c.Assert(rollout.RolledOutRegions, Equals, 1)
+	c.Assert(rollout.BatchEndKey, Equals, hex.EncodeToString([]byte("c")))
+
+	// The paused rollout does not start the next batch.
+	c.Assert(cluster.PauseReplicasRollout(), IsNil)
+	addPeer(2)
+	cluster.replicasRollout.patrol()
+	c.Assert(cluster.GetReplicasRollout().Batches, Equals, 2)
+	c.Assert(cluster.ResumeReplicasRollout(), IsNil)
+	cluster.replicasRollout.patrol()
+	rollout = cluster.GetReplicasRollout()
+	c.Assert(rollout.Batches, Equals, 3)
+	c.Assert(rollout.LastBatch, IsTrue)
+
+	// The new count is applied after all the regions are rolled out.
+	addPeer(3)
+	cluster.replicasRollout.patrol()
+	rollout = cluster.GetReplicasRollout()
+	c.Assert(rollout.State, Equals, ReplicasRolloutFinished)
+	c.Assert(rollout.RolledOutRegions, Equals, 3)
+	c.Assert(opt.GetMaxReplicas(), Equals, 4)
+	c.Assert(cluster.ruleManager.GetRule("pd", "default").Count, Equals, 4)
+	c.Assert(cluster.ruleManager.GetRule("pd", replicasRolloutRuleID), IsNil)
+
+	// Abort the rollout.
+	c.Assert(cluster.StartReplicasRollout(5, 10), IsNil)
+	c.Assert(cluster.ruleManager.GetRule("pd", replicasRolloutRuleID), NotNil)
+	c.Assert(cluster.AbortReplicasRollout(), IsNil)
+	c.Assert(cluster.GetReplicasRollout().State, Equals, ReplicasRolloutAborted)
+	c.Assert(cluster.IsReplicasRolloutInProgress(), IsFalse)
+	c.Assert(cluster.ruleManager.GetRule("pd", replicasRolloutRuleID), IsNil)
+	c.Assert(opt.GetMaxReplicas(), Equals, 4)
+
+	// The progress is persisted.
+	controller := newReplicasRolloutController(cluster)
+	c.Assert(controller.load(), IsNil)
+	c.Assert(controller.get().State, Equals, ReplicasRolloutAborted)
+	c.Assert(controller.get().To, Equals, 5)
+}
+
+func (s *testClusterInfoSuite) TestStoreStateHistory(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+
+	for _, store := range newTestStores(2, "2.0.0") {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+	c.Assert(cluster.RemoveStore(1, false), IsNil)
+	c.Assert(cluster.UpStore(1), IsNil)
+	c.Assert(cluster.RemoveStore(1, true), IsNil)
+	cluster.checkStores()
+
+	changes, err := cluster.GetStoreStateChanges(1)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 4)
+	expected := []struct {
+		from, to, trigger string
+	}{
+		{"Up", "Offline", core.StoreTriggerAPI},
+		{"Offline", "Up", core.StoreTriggerAPI},
+		{"Up", "Offline", core.StoreTriggerAPI},
+		{"Offline", "Tombstone", core.StoreTriggerPD},
+	}
+	for i, e := range expected {
+		c.Assert(changes[i].StoreID, Equals, uint64(1))
+		c.Assert(changes[i].From, Equals, e.from)
+		c.Assert(changes[i].To, Equals, e.to)
+		c.Assert(changes[i].Trigger, Equals, e.trigger)
+	}
+	c.Assert(changes[3].PhysicallyDestroyed, IsTrue)
+
+	// The store without any state change has no history.
+	changes, err = cluster.GetStoreStateChanges(2)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 0)
+}
+
+func (s *testClusterInfoSuite) TestStoreTombstoneAck(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	storage := core.NewStorage(kv.NewMemoryKV())
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, storage, core.NewBasicCluster())
+
+	for _, store := range newTestStores(2, "2.0.0") {
+		c.Assert(cluster.PutStore(store.GetMeta()), IsNil)
+	}
+	c.Assert(cluster.RemoveStore(1, false), IsNil)
+	cluster.checkStores()
+	c.Assert(cluster.GetStore(1).IsTombstone(), IsTrue)
+
+	// Nothing is tracked until it is enabled.
+	cluster.NotifyStoreTombstone(1)
+	c.Assert(cluster.GetStoreTombstoneNotice(1).IsZero(), IsTrue)
+	_, err = cluster.AckStoreTombstone(1)
+	c.Assert(errs.ErrTombstonePurgeAckDisabled.Equal(err), IsTrue)
+	_, err = cluster.GetStoreTombstoneAck(1)
+	c.Assert(errs.ErrTombstonePurgeAckDisabled.Equal(err), IsTrue)
+
+	cfg := opt.GetPDServerConfig().Clone()
+	cfg.EnableTombstonePurgeAck = true
+	opt.SetPDServerConfig(cfg)
+
+	// The store which is not tombstone can't be acknowledged.
+	_, err = cluster.AckStoreTombstone(2)
+	c.Assert(errs.ErrStoreNotTombstone.Equal(err), IsTrue)
+	_, err = cluster.GetStoreTombstoneAck(2)
+	c.Assert(errs.ErrStoreNotTombstone.Equal(err), IsTrue)
+	_, err = cluster.AckStoreTombstone(3)
+	c.Assert(errs.ErrStoreNotFound.Equal(err), IsTrue)
+	_, err = cluster.GetStoreTombstoneAck(3)
+	c.Assert(errs.ErrStoreNotFound.Equal(err), IsTrue)
+
+	// The notification alone doesn't make the data safe to be destroyed, as
+	// the node may not receive it.
+	cluster.NotifyStoreTombstone(1)
+	notifiedAt := cluster.GetStoreTombstoneNotice(1)
+	c.Assert(notifiedAt.IsZero(), IsFalse)
+	cluster.NotifyStoreTombstone(1)
+	c.Assert(cluster.GetStoreTombstoneNotice(1).Equal(notifiedAt), IsTrue)
+	ack, err := cluster.GetStoreTombstoneAck(1)
+	c.Assert(err, IsNil)
+	c.Assert(ack, IsNil)
+
+	ack, err = cluster.AckStoreTombstone(1)
+	c.Assert(err, IsNil)
+	c.Assert(ack.StoreID, Equals, uint64(1))
+	c.Assert(ack.Address, Equals, cluster.GetStore(1).GetAddress())
+	ack2, err := cluster.GetStoreTombstoneAck(1)
+	c.Assert(err, IsNil)
+	c.Assert(ack2.Time.Equal(ack.Time), IsTrue)
+
+	// The acknowledgement is not overwritten by the later ones.
+	ack2, err = cluster.AckStoreTombstone(1)
+	c.Assert(err, IsNil)
+	c.Assert(ack2.Time.Equal(ack.Time), IsTrue)
+
+	// The acknowledgement is kept after the store is removed.
+	c.Assert(cluster.RemoveTombStoneRecords(), IsNil)
+	c.Assert(cluster.GetStore(1), IsNil)
+	ack2, err = cluster.GetStoreTombstoneAck(1)
+	c.Assert(err, IsNil)
+	c.Assert(ack2.Time.Equal(ack.Time), IsTrue)
+}
+
+func (s *testClusterInfoSuite) TestRollingRestart(c *C) {
+	cfg, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cfg.RollingRestartLeaderScheduleLimit = 32
+	opt.SetScheduleConfig(cfg)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+
+	stores := newTestStores(3, "2.0.0")
+	restart := func(i int) {
+		meta := proto.Clone(stores[i].GetMeta()).(*metapb.Store)
+		meta.StartTimestamp++
+		c.Assert(cluster.PutStore(meta), IsNil)
+		stores[i] = core.NewStoreInfo(meta)
+	}
+	for i := range stores {
+		restart(i)
+	}
+	// Registering the stores is not restarting.
+	c.Assert(cluster.GetRollingRestartStatus().InProgress, IsFalse)
+	c.Assert(opt.GetLeaderScheduleLimit(), Equals, cfg.LeaderScheduleLimit)
+
+	// A single store restarting is not a rolling restart.
+	restart(0)
+	c.Assert(cluster.GetRollingRestartStatus().InProgress, IsFalse)
+	restart(1)
+	status := cluster.GetRollingRestartStatus()
+	c.Assert(status.InProgress, IsTrue)
+	c.Assert(status.Restarts, HasLen, 2)
+	c.Assert(status.LeaderScheduleLimit, Equals, uint64(32))
+	c.Assert(opt.GetLeaderScheduleLimit(), Equals, uint64(32))
+
+	// The rolling restart goes on while the last restarted store is in the window.
+	now := time.Now()
+	cluster.rollingRestart.restarts[1] = now.Add(-cfg.RollingRestartWindow.Duration)
+	cluster.rollingRestart.patrol(now)
+	c.Assert(cluster.GetRollingRestartStatus().InProgress, IsTrue)
+	cluster.rollingRestart.patrol(now.Add(cfg.RollingRestartWindow.Duration))
+	c.Assert(cluster.GetRollingRestartStatus().InProgress, IsFalse)
+	c.Assert(opt.GetLeaderScheduleLimit(), Equals, cfg.LeaderScheduleLimit)
+
+	// The limit is not raised if it is disabled.
+	cfg = opt.GetScheduleConfig().Clone()
+	cfg.RollingRestartLeaderScheduleLimit = 0
+	opt.SetScheduleConfig(cfg)
+	restart(1)
+	restart(2)
+	c.Assert(cluster.GetRollingRestartStatus().InProgress, IsFalse)
+	c.Assert(opt.GetLeaderScheduleLimit(), Equals, cfg.LeaderScheduleLimit)
+}
+
 func (s *testClusterInfoSuite) TestDeleteStoreUpdatesClusterVersion(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -410,6 +801,41 @@ func (s *testClusterInfoSuite) TestRegionHeartbeatHotStat(c *C) {
 	c.Assert(stats[4], HasLen, 1)
 }
 
+func (s *testClusterInfoSuite) TestBucketsReport(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	peer := &metapb.Peer{Id: 1, StoreId: 1}
+	region := core.NewRegionInfo(&metapb.Region{
+		Id:          1,
+		Peers:       []*metapb.Peer{peer},
+		StartKey:    []byte("a"),
+		EndKey:      []byte("e"),
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, peer)
+	c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+
+	newReport := func(keys ...string) *statistics.BucketsReport {
+		report := &statistics.BucketsReport{RegionID: 1, Version: 1, Interval: 10 * time.Second}
+		for _, key := range keys {
+			report.Keys = append(report.Keys, []byte(key))
+		}
+		report.Flows[statistics.RegionWriteBytes] = make([]uint64, len(keys)-1)
+		return report
+	}
+	for _, keys := range [][]string{
+		{"", "b", "e"},
+		{"a", "b", "f"},
+		{"a", "f", "e"},
+		{"a", "a", "e"},
+		{"a", "c", "b", "e"},
+	} {
+		c.Assert(cluster.HandleBucketsReport(newReport(keys...)), NotNil, Commentf("keys: %v", keys))
+	}
+	c.Assert(cluster.HandleBucketsReport(newReport("a", "b", "c", "e")), IsNil)
+	c.Assert(cluster.hotStat.Buckets.Get(region).BucketCount(), Equals, 3)
+}
+
 func (s *testClusterInfoSuite) TestRegionHeartbeat(c *C) {
 	_, opt, err := newTestScheduleConfig()
 	c.Assert(err, IsNil)
@@ -641,6 +1067,66 @@ func (s *testClusterInfoSuite) TestRegionFlowChanged(c *C) {
 	processRegions(regions)
 	newRegion := cluster.GetRegion(region.GetID())
 	c.Assert(newRegion.GetBytesRead(), Equals, uint64(1000))
+
+	// The small fluctuation is rounded off, which doesn't update the cache.
+	regions[0] = region.Clone(core.SetReadBytes(1200), core.WithFlowRoundByDigit(3))
+	processRegions(regions)
+	c.Assert(cluster.GetRegion(region.GetID()).GetBytesRead(), Equals, uint64(1000))
+	regions[0] = region.Clone(core.SetReadBytes(1600), core.WithFlowRoundByDigit(3))
+	processRegions(regions)
+	c.Assert(cluster.GetRegion(region.GetID()).GetBytesRead(), Equals, uint64(1600))
+}
+
+func (s *testClusterInfoSuite) TestHeartbeatQuarantine(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	region := core.NewRegionInfo(&metapb.Region{
+		Id:          1,
+		Peers:       []*metapb.Peer{{Id: 1, StoreId: 1}, {Id: 2, StoreId: 2}, {Id: 3, StoreId: 3}},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 3, Version: 5},
+	}, &metapb.Peer{Id: 1, StoreId: 1})
+	c.Assert(cluster.processRegionHeartbeat(region), IsNil)
+
+	// The epoch jumps far ahead with a radically different peer set.
+	suspicious := core.NewRegionInfo(&metapb.Region{
+		Id:          1,
+		Peers:       []*metapb.Peer{{Id: 1, StoreId: 1}, {Id: 12, StoreId: 4}, {Id: 13, StoreId: 5}},
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 3, Version: 5000},
+	}, &metapb.Peer{Id: 1, StoreId: 1})
+	// It is not quarantined until the quarantine is enabled.
+	c.Assert(suspiciousHeartbeatReason(region, suspicious, opt.GetPDServerConfig().HeartbeatQuarantineEpochGap), Not(Equals), "")
+	cfg := opt.GetPDServerConfig().Clone()
+	cfg.EnableHeartbeatQuarantine = true
+	opt.SetPDServerConfig(cfg)
+
+	c.Assert(cluster.processRegionHeartbeat(suspicious), IsNil)
+	c.Assert(cluster.processRegionHeartbeat(suspicious), IsNil)
+	c.Assert(cluster.GetRegion(1).GetRegionEpoch().GetVersion(), Equals, uint64(5))
+	quarantined := cluster.GetQuarantinedRegions()
+	c.Assert(quarantined, HasLen, 1)
+	c.Assert(quarantined[0].Region.GetRegionEpoch().GetVersion(), Equals, uint64(5000))
+	c.Assert(quarantined[0].Origin.GetRegionEpoch().GetVersion(), Equals, uint64(5))
+	c.Assert(quarantined[0].ReportCount, Equals, 2)
+	c.Assert(quarantined[0].Reason, Equals, "version jumps from 5 to 5000 and 1 of 3 peers are kept")
+
+	// The epoch jump with most of the peers kept is not suspicious.
+	normal := suspicious.Clone(core.SetPeers([]*metapb.Peer{{Id: 1, StoreId: 1}, {Id: 2, StoreId: 2}, {Id: 13, StoreId: 5}}))
+	c.Assert(suspiciousHeartbeatReason(region, normal, cfg.HeartbeatQuarantineEpochGap), Equals, "")
+
+	// The discarded heartbeat is quarantined again.
+	c.Assert(cluster.DiscardQuarantinedRegion(1), IsNil)
+	c.Assert(cluster.DiscardQuarantinedRegion(1), NotNil)
+	c.Assert(cluster.GetQuarantinedRegions(), HasLen, 0)
+	c.Assert(cluster.processRegionHeartbeat(suspicious), IsNil)
+	c.Assert(cluster.GetQuarantinedRegions(), HasLen, 1)
+
+	// The confirmed heartbeat updates the routing.
+	c.Assert(cluster.ConfirmQuarantinedRegion(1), IsNil)
+	c.Assert(cluster.GetQuarantinedRegions(), HasLen, 0)
+	c.Assert(cluster.GetRegion(1).GetRegionEpoch().GetVersion(), Equals, uint64(5000))
+	c.Assert(cluster.GetRegion(1).GetStorePeer(4), NotNil)
+	c.Assert(cluster.ConfirmQuarantinedRegion(1), NotNil)
 }
 
 func (s *testClusterInfoSuite) TestConcurrentRegionHeartbeat(c *C) {
@@ -15,6 +15,7 @@ package cluster
 
 import (
 	"bytes"
+	"sort"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/errors"
@@ -42,6 +43,65 @@ func (c *RaftCluster) HandleRegionHeartbeat(region *core.RegionInfo) error {
 	return nil
 }
 
+// HandleBatchPutRegions registers the metadata of a batch of regions directly,
+// which is used by the restore tools to warm up the region routing without
+// waiting for the heartbeats from TiKV. The whole batch is rejected if any region
+// is invalid, overlaps with another one in the batch or is staler than the
+// region in cache. Since the heartbeats can race with the batch, the regions
+// which become stale while being put are returned with the error.
+func (c *RaftCluster) HandleBatchPutRegions(regions []*core.RegionInfo) (map[uint64]error, error) {
+	if err := c.checkBatchRegions(regions); err != nil {
+		return nil, err
+	}
+	failures := make(map[uint64]error)
+	for _, region := range regions {
+		if err := c.processRegionHeartbeat(region); err != nil {
+			failures[region.GetID()] = err
+		}
+	}
+	return failures, nil
+}
+
+func (c *RaftCluster) checkBatchRegions(regions []*core.RegionInfo) error {
+	ids := make(map[uint64]struct{}, len(regions))
+	for _, region := range regions {
+		startKey, endKey := region.GetStartKey(), region.GetEndKey()
+		if region.GetID() == 0 || region.GetRegionEpoch() == nil || len(region.GetPeers()) == 0 ||
+			(len(endKey) > 0 && bytes.Compare(startKey, endKey) >= 0) {
+			return errors.Errorf("invalid region %v", core.RegionToHexMeta(region.GetMeta()))
+		}
+		if _, ok := ids[region.GetID()]; ok {
+			return errors.Errorf("duplicated region %d", region.GetID())
+		}
+		ids[region.GetID()] = struct{}{}
+		for _, peer := range region.GetPeers() {
+			store := c.GetStore(peer.GetStoreId())
+			if store == nil || store.IsTombstone() {
+				return errors.Errorf("invalid store %d of region %d", peer.GetStoreId(), region.GetID())
+			}
+		}
+		if leader := region.GetLeader(); leader != nil && region.GetStorePeer(leader.GetStoreId()) == nil {
+			return errors.Errorf("leader of region %d is not a peer", region.GetID())
+		}
+		if _, err := c.core.PreCheckPutRegion(region); err != nil {
+			return err
+		}
+	}
+
+	sorted := make([]*core.RegionInfo, len(regions))
+	copy(sorted, regions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].GetStartKey(), sorted[j].GetStartKey()) < 0
+	})
+	for i := 1; i < len(sorted); i++ {
+		prevEnd := sorted[i-1].GetEndKey()
+		if len(prevEnd) == 0 || bytes.Compare(prevEnd, sorted[i].GetStartKey()) > 0 {
+			return errors.Errorf("region %d overlaps with region %d", sorted[i-1].GetID(), sorted[i].GetID())
+		}
+	}
+	return nil
+}
+
 // HandleAskSplit handles the split request.
 func (c *RaftCluster) HandleAskSplit(request *pdpb.AskSplitRequest) (*pdpb.AskSplitResponse, error) {
 	reqRegion := request.GetRegion()
@@ -209,7 +269,7 @@ func (c *RaftCluster) HandleBatchReportSplit(request *pdpb.ReportBatchSplitReque
 	err := c.checkSplitRegions(regions)
 	if err != nil {
 		log.Warn("report batch split region is invalid",
-			zap.Stringer("region-meta", hrm),
+			logutil.ZapRedactStringer("region-meta", hrm),
 			errs.ZapError(err))
 		return nil, err
 	}
@@ -218,7 +278,7 @@ func (c *RaftCluster) HandleBatchReportSplit(request *pdpb.ReportBatchSplitReque
 	hrm = core.RegionsToHexMeta(regions[:last])
 	log.Info("region batch split, generate new regions",
 		zap.Uint64("region-id", originRegion.GetId()),
-		zap.Stringer("origin", hrm),
+		logutil.ZapRedactStringer("origin", hrm),
 		zap.Int("total", last))
 	return &pdpb.ReportBatchSplitResponse{}, nil
 }
@@ -65,3 +65,59 @@ func (s *testClusterWorkerSuite) TestReportBatchSplit(c *C) {
 	_, err = cluster.HandleBatchReportSplit(&pdpb.ReportBatchSplitRequest{Regions: regions})
 	c.Assert(err, IsNil)
 }
+
+func (s *testClusterWorkerSuite) TestBatchPutRegions(c *C) {
+	_, opt, err := newTestScheduleConfig()
+	c.Assert(err, IsNil)
+	cluster := newTestRaftCluster(s.ctx, mockid.NewIDAllocator(), opt, core.NewStorage(kv.NewMemoryKV()), core.NewBasicCluster())
+	for _, store := range newTestStores(3, "2.0.0") {
+		c.Assert(cluster.putStoreLocked(store), IsNil)
+	}
+	newRegion := func(id uint64, startKey, endKey string, version uint64) *core.RegionInfo {
+		peers := []*metapb.Peer{{Id: id*10 + 1, StoreId: 1}, {Id: id*10 + 2, StoreId: 2}, {Id: id*10 + 3, StoreId: 3}}
+		return core.NewRegionInfo(&metapb.Region{
+			Id:          id,
+			StartKey:    []byte(startKey),
+			EndKey:      []byte(endKey),
+			RegionEpoch: &metapb.RegionEpoch{Version: version, ConfVer: 1},
+			Peers:       peers,
+		}, peers[0])
+	}
+
+	regions := []*core.RegionInfo{newRegion(2, "b", "c", 2), newRegion(1, "a", "b", 2), newRegion(3, "c", "", 2)}
+	failures, err := cluster.HandleBatchPutRegions(regions)
+	c.Assert(err, IsNil)
+	c.Assert(failures, HasLen, 0)
+	for _, region := range regions {
+		c.Assert(cluster.GetRegion(region.GetID()).GetMeta(), DeepEquals, region.GetMeta())
+		c.Assert(cluster.GetRegion(region.GetID()).GetLeader().GetStoreId(), Equals, uint64(1))
+	}
+	c.Assert(cluster.GetRegionByKey([]byte("b1")).GetID(), Equals, uint64(2))
+
+	// The whole batch is rejected if any region is invalid.
+	testCases := [][]*core.RegionInfo{
+		// overlaps in the batch
+		{newRegion(4, "d", "f", 3), newRegion(5, "e", "g", 3)},
+		// duplicated
+		{newRegion(4, "d", "e", 3), newRegion(4, "e", "f", 3)},
+		// stale
+		{newRegion(4, "d", "e", 3), newRegion(3, "c", "", 1)},
+		// invalid key range
+		{newRegion(4, "e", "d", 3)},
+		// store not found
+		{core.NewRegionInfo(&metapb.Region{Id: 4, RegionEpoch: &metapb.RegionEpoch{}, Peers: []*metapb.Peer{{Id: 41, StoreId: 4}}}, nil)},
+	}
+	for _, regions := range testCases {
+		_, err = cluster.HandleBatchPutRegions(regions)
+		c.Assert(err, NotNil)
+		c.Assert(cluster.GetRegion(4), IsNil)
+	}
+
+	// A newer region replaces the overlapped ones.
+	failures, err = cluster.HandleBatchPutRegions([]*core.RegionInfo{newRegion(4, "a", "c", 3)})
+	c.Assert(err, IsNil)
+	c.Assert(failures, HasLen, 0)
+	c.Assert(cluster.GetRegion(1), IsNil)
+	c.Assert(cluster.GetRegion(2), IsNil)
+	c.Assert(cluster.GetRegionByKey([]byte("b1")).GetID(), Equals, uint64(4))
+}
@@ -18,10 +18,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
@@ -63,27 +65,36 @@ type coordinator struct {
 	checkers        *schedule.CheckerController
 	regionScatterer *schedule.RegionScatterer
 	regionSplitter  *schedule.RegionSplitter
+	rangeMerger     *schedule.RangeMerger
 	schedulers      map[string]*scheduleController
 	opController    *schedule.OperatorController
 	hbStreams       *hbstream.HeartbeatStreams
 	pluginInterface *schedule.PluginInterface
+	disableRecords  *schedulerDisableRegistry
+	schedulerStats  *schedulerStatsRegistry
 }
 
 // newCoordinator creates a new coordinator.
 func newCoordinator(ctx context.Context, cluster *RaftCluster, hbStreams *hbstream.HeartbeatStreams) *coordinator {
 	ctx, cancel := context.WithCancel(ctx)
 	opController := schedule.NewOperatorController(ctx, cluster, hbStreams)
+	schedulerStats := newSchedulerStatsRegistry(cluster.storage)
+	opController.AddEndListener(schedulerStats.observeEnd)
+	checkers := schedule.NewCheckerController(ctx, cluster, cluster.ruleManager, opController)
 	return &coordinator{
 		ctx:             ctx,
 		cancel:          cancel,
 		cluster:         cluster,
-		checkers:        schedule.NewCheckerController(ctx, cluster, cluster.ruleManager, opController),
+		checkers:        checkers,
 		regionScatterer: schedule.NewRegionScatterer(ctx, cluster),
 		regionSplitter:  schedule.NewRegionSplitter(cluster, schedule.NewSplitRegionsHandler(cluster, opController)),
+		rangeMerger:     schedule.NewRangeMerger(cluster, opController, checkers.GetMergeChecker()),
 		schedulers:      make(map[string]*scheduleController),
 		opController:    opController,
 		hbStreams:       hbStreams,
 		pluginInterface: schedule.NewPluginInterface(),
+		disableRecords:  newSchedulerDisableRegistry(cluster.storage),
+		schedulerStats:  schedulerStats,
 	}
 }
 
@@ -108,12 +119,15 @@ func (c *coordinator) patrolRegions() {
 			return
 		}
 
+		// The checks of one round are limited by the time budget, so that an expensive
+		// checker over a large number of regions can't stall the coordinator.
+		deadline := time.Now().Add(c.cluster.GetOpts().GetPatrolRegionBudget())
 		// Check suspect regions first.
-		c.checkSuspectRegions()
+		c.checkSuspectRegions(deadline)
 		// Check suspect key ranges
 		c.checkSuspectKeyRanges()
 		// Check regions in the waiting list
-		c.checkWaitingRegions()
+		c.checkWaitingRegions(deadline)
 
 		regions := c.cluster.ScanRegions(key, nil, patrolScanRegionLimit)
 		if len(regions) == 0 {
@@ -122,29 +136,11 @@ func (c *coordinator) patrolRegions() {
 			continue
 		}
 
-		for _, region := range regions {
-			// Skips the region if there is already a pending operator.
-			if c.opController.GetOperator(region.GetID()) != nil {
-				continue
-			}
-
-			ops := c.checkers.CheckRegion(region)
-
-			key = region.GetEndKey()
-			if len(ops) == 0 {
-				continue
-			}
-
-			if !c.opController.ExceedStoreLimit(ops...) {
-				c.opController.AddWaitingOperator(ops...)
-				c.checkers.RemoveWaitingRegion(region.GetID())
-				c.cluster.RemoveSuspectRegion(region.GetID())
-			} else {
-				c.checkers.AddWaitingRegion(region)
-			}
-		}
+		checked := c.checkRegions(regions, deadline)
+		key = checked[len(checked)-1].GetEndKey()
+		patrolCheckedRegionsHistogram.Observe(float64(len(checked)))
 		// Updates the label level isolation statistics.
-		c.cluster.updateRegionsLabelLevelStats(regions)
+		c.cluster.updateRegionsLabelLevelStats(checked)
 		if len(key) == 0 {
 			patrolCheckRegionsGauge.Set(time.Since(start).Seconds())
 			start = time.Now()
@@ -155,8 +151,41 @@ func (c *coordinator) patrolRegions() {
 	}
 }
 
-func (c *coordinator) checkSuspectRegions() {
+// checkRegions checks the regions until the deadline, and returns the checked ones.
+// At least one region is checked to make sure the patrol makes progress.
+func (c *coordinator) checkRegions(regions []*core.RegionInfo, deadline time.Time) []*core.RegionInfo {
+	for i, region := range regions {
+		if i > 0 && time.Now().After(deadline) {
+			patrolBudgetExceededCounter.WithLabelValues("patrol").Inc()
+			return regions[:i]
+		}
+		// Skips the region if there is already a pending operator.
+		if c.opController.GetOperator(region.GetID()) != nil {
+			continue
+		}
+
+		ops := c.checkers.CheckRegion(region)
+		if len(ops) == 0 {
+			continue
+		}
+
+		if !c.opController.ExceedStoreLimit(ops...) {
+			c.opController.AddWaitingOperator(ops...)
+			c.checkers.RemoveWaitingRegion(region.GetID())
+			c.cluster.RemoveSuspectRegion(region.GetID())
+		} else {
+			c.checkers.AddWaitingRegion(region)
+		}
+	}
+	return regions
+}
+
+func (c *coordinator) checkSuspectRegions(deadline time.Time) {
 	for _, id := range c.cluster.GetSuspectRegions() {
+		if time.Now().After(deadline) {
+			patrolBudgetExceededCounter.WithLabelValues("suspect").Inc()
+			return
+		}
 		region := c.cluster.GetRegion(id)
 		if region == nil {
 			// the region could be recent split, continue to wait.
@@ -205,10 +234,14 @@ func (c *coordinator) checkSuspectKeyRanges() {
 	c.cluster.AddSuspectRegions(regionIDList...)
 }
 
-func (c *coordinator) checkWaitingRegions() {
+func (c *coordinator) checkWaitingRegions(deadline time.Time) {
 	items := c.checkers.GetWaitingRegions()
 	regionWaitingListGauge.Set(float64(len(items)))
 	for _, item := range items {
+		if time.Now().After(deadline) {
+			patrolBudgetExceededCounter.WithLabelValues("waiting").Inc()
+			return
+		}
 		id := item.Key
 		region := c.cluster.GetRegion(id)
 		if region == nil {
@@ -288,6 +321,12 @@ func (c *coordinator) run() {
 	if err != nil {
 		log.Fatal("cannot load schedulers' config", errs.ZapError(err))
 	}
+	if err := c.disableRecords.load(); err != nil {
+		log.Error("cannot load schedulers' disable records", errs.ZapError(err))
+	}
+	if err := c.schedulerStats.load(); err != nil {
+		log.Error("cannot load schedulers' stats", errs.ZapError(err))
+	}
 
 	scheduleCfg := c.cluster.opt.GetScheduleConfig().Clone()
 	// The new way to create scheduler with the independent configuration.
@@ -454,6 +493,15 @@ func (c *coordinator) getHotReadRegions() *statistics.StoreHotPeersInfos {
 	return nil
 }
 
+func (c *coordinator) getSchedulerStats(name string) (*SchedulerStats, error) {
+	c.RLock()
+	defer c.RUnlock()
+	if _, ok := c.schedulers[name]; !ok {
+		return nil, errs.ErrSchedulerNotFound.FastGenByArgs()
+	}
+	return c.schedulerStats.get(name), nil
+}
+
 func (c *coordinator) getSchedulers() []string {
 	c.RLock()
 	defer c.RUnlock()
@@ -593,10 +641,16 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 		return err
 	}
 
+	c.schedulerStats.register(s.GetName())
 	c.wg.Add(1)
 	go c.runScheduler(s)
 	c.schedulers[s.GetName()] = s
 	c.cluster.opt.AddSchedulerCfg(s.GetType(), args)
+	if record := c.disableRecords.get(s.GetName()); record != nil && record.Action == SchedulerActionDisable {
+		if err := c.disableRecords.remove(s.GetName()); err != nil {
+			log.Error("can not remove the scheduler disable record", zap.String("scheduler-name", s.GetName()), errs.ZapError(err))
+		}
+	}
 	return nil
 }
 
@@ -630,7 +684,25 @@ func (c *coordinator) removeScheduler(name string) error {
 	s.Stop()
 	schedulerStatusGauge.WithLabelValues(name, "allow").Set(0)
 	delete(c.schedulers, name)
+	if err := c.schedulerStats.remove(name); err != nil {
+		log.Error("can not remove the scheduler stats", zap.String("scheduler-name", name), errs.ZapError(err))
+	}
 
+	// The default schedulers are disabled rather than removed, and only users can
+	// disable them.
+	var err error
+	if config.IsDefaultScheduler(s.GetType()) {
+		err = c.disableRecords.put(name, &SchedulerDisableRecord{
+			Action:    SchedulerActionDisable,
+			Actor:     SchedulerActorManual,
+			Timestamp: time.Now(),
+		})
+	} else {
+		err = c.disableRecords.remove(name)
+	}
+	if err != nil {
+		log.Error("can not update the scheduler disable record", zap.String("scheduler-name", name), errs.ZapError(err))
+	}
 	return nil
 }
 
@@ -657,7 +729,9 @@ func (c *coordinator) removeOptScheduler(o *config.PersistOptions, name string)
 	return nil
 }
 
-func (c *coordinator) pauseOrResumeScheduler(name string, t int64) error {
+// pauseOrResumeScheduler pauses the scheduler for t seconds, or resumes it if t
+// is 0. The actor and reason of the pause are recorded.
+func (c *coordinator) pauseOrResumeScheduler(name string, t int64, actor, reason string) error {
 	c.Lock()
 	defer c.Unlock()
 	if c.cluster == nil {
@@ -678,14 +752,64 @@ func (c *coordinator) pauseOrResumeScheduler(name string, t int64) error {
 	var err error
 	for _, sc := range s {
 		var delayUntil int64
+		now := time.Now()
 		if t > 0 {
-			delayUntil = time.Now().Unix() + t
+			delayUntil = now.Unix() + t
 		}
 		atomic.StoreInt64(&sc.delayUntil, delayUntil)
+		if t > 0 {
+			err = c.disableRecords.put(sc.GetName(), &SchedulerDisableRecord{
+				Action:     SchedulerActionPause,
+				Actor:      actor,
+				Reason:     reason,
+				Timestamp:  now,
+				PauseUntil: time.Unix(delayUntil, 0),
+			})
+		} else {
+			err = c.disableRecords.remove(sc.GetName())
+		}
+		if err != nil {
+			log.Error("can not update the scheduler disable record", zap.String("scheduler-name", sc.GetName()), errs.ZapError(err))
+		}
 	}
 	return err
 }
 
+// getSchedulerDisableRecord returns the record of why the scheduler is paused or
+// disabled, it returns nil if the scheduler is running.
+func (c *coordinator) getSchedulerDisableRecord(name string) *SchedulerDisableRecord {
+	c.RLock()
+	defer c.RUnlock()
+	record := c.disableRecords.get(name)
+	if record == nil {
+		return nil
+	}
+	if s, ok := c.schedulers[name]; ok {
+		if record.Action == SchedulerActionDisable || !s.IsPaused() {
+			return nil
+		}
+		return record
+	}
+	if record.Action == SchedulerActionPause {
+		return nil
+	}
+	return record
+}
+
+// getDisabledSchedulers returns the names of the disabled schedulers which have disable records.
+func (c *coordinator) getDisabledSchedulers() []string {
+	c.RLock()
+	defer c.RUnlock()
+	var names []string
+	for name, record := range c.disableRecords.getAll() {
+		if _, ok := c.schedulers[name]; !ok && record.Action == SchedulerActionDisable {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *coordinator) isSchedulerPaused(name string) (bool, error) {
 	c.RLock()
 	defer c.RUnlock()
@@ -747,12 +871,20 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 			if !s.AllowSchedule() {
 				continue
 			}
-			if op := s.Schedule(); len(op) > 0 {
+			start := time.Now()
+			op := s.Schedule()
+			c.schedulerStats.observeRun(s.GetName(), start, len(op))
+			if len(op) > 0 {
 				added := c.opController.AddWaitingOperator(op...)
 				log.Debug("add operator", zap.Int("added", added), zap.Int("total", len(op)), zap.String("scheduler", s.GetName()))
 			}
 
 		case <-s.Ctx().Done():
+			// Keeps the stats when the coordinator is stopped, e.g. the leader
+			// changes, while the stats of the removed scheduler are removed.
+			if c.ctx.Err() != nil {
+				c.schedulerStats.persist(s.GetName())
+			}
 			log.Info("scheduler has been stopped",
 				zap.String("scheduler-name", s.GetName()),
 				errs.ZapError(s.Ctx().Err()))
@@ -794,12 +926,28 @@ func (s *scheduleController) Stop() {
 }
 
 func (s *scheduleController) Schedule() []*operator.Operator {
+	span := opentracing.StartSpan("scheduleController.Schedule")
+	span.SetTag("scheduler", s.GetName())
+	defer span.Finish()
+	start := time.Now()
+	defer func() {
+		scheduleDuration.WithLabelValues(s.GetName()).Observe(time.Since(start).Seconds())
+	}()
 	for i := 0; i < maxScheduleRetries; i++ {
 		// If we have schedule, reset interval to the minimal interval.
 		if op := s.Scheduler.Schedule(s.cluster); op != nil {
 			s.nextInterval = s.Scheduler.GetMinInterval()
+			for _, o := range op {
+				o.SetSource(s.GetName())
+			}
 			return op
 		}
+		// Gives up the rest retries if the scheduler runs out of the time budget,
+		// it will be scheduled again after the interval.
+		if time.Since(start) > s.cluster.GetOpts().GetScheduleTimeBudget() {
+			scheduleBudgetExceededCounter.WithLabelValues(s.GetName()).Inc()
+			break
+		}
 	}
 	s.nextInterval = s.Scheduler.GetNextInterval(s.nextInterval)
 	return nil
@@ -20,11 +20,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/eraftpb"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/mock/mockhbstream"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/pkg/typeutil"
@@ -355,6 +358,82 @@ func (s *testCoordinatorSuite) TestCheckRegion(c *C) {
 	s.checkRegion(c, tc, co, 1, 0)
 }
 
+func (s *testCoordinatorSuite) TestRollingRestartMovesLeaders(c *C) {
+	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
+		cfg.RollingRestartLeaderScheduleLimit = 8
+	}, nil, nil, c)
+	defer cleanup()
+	tc.RaftCluster.coordinator = co
+
+	putStore := func(meta *metapb.Store) {
+		c.Assert(tc.PutStore(meta), IsNil)
+		store := tc.GetStore(meta.GetId()).Clone(core.SetLastHeartbeatTS(time.Now()))
+		tc.Lock()
+		defer tc.Unlock()
+		c.Assert(tc.putStoreLocked(store), IsNil)
+	}
+	for _, store := range newTestStores(3, "2.0.0") {
+		meta := store.GetMeta()
+		meta.StartTimestamp = 1
+		putStore(meta)
+	}
+	for i := uint64(1); i <= 4; i++ {
+		c.Assert(tc.addLeaderRegion(i, 1, 2, 3), IsNil)
+	}
+	// finishOperators applies the leader operators of the rolling restart,
+	// until there is no operator added by the patrol.
+	finishOperators := func(desc string, done func() bool) {
+		for i := 0; i < 100 && !done(); i++ {
+			tc.rollingRestart.patrol(time.Now())
+			for id := uint64(1); id <= 4; id++ {
+				op := co.opController.GetOperator(id)
+				if op == nil {
+					continue
+				}
+				c.Assert(op.Desc(), Equals, desc)
+				c.Assert(op.GetPriorityLevel(), Equals, core.HighPriority)
+				region := tc.GetRegion(id)
+				leader := region.GetStorePeer(op.Step(0).(operator.TransferLeader).ToStore)
+				c.Assert(tc.putRegion(region.Clone(core.WithLeader(leader))), IsNil)
+				co.opController.RemoveOperator(op)
+			}
+		}
+		c.Assert(done(), IsTrue)
+	}
+
+	// The leaders are moved out of the annotated store, and the other
+	// schedulers don't move leaders to it.
+	c.Assert(tc.PrepareStoreRestart(1), IsNil)
+	status := tc.GetRollingRestartStatus()
+	c.Assert(status.InProgress, IsTrue)
+	c.Assert(status.Stores[1], DeepEquals, &StoreRestart{State: StoreRestartEvacuating, LeaderCount: 4})
+	c.Assert(tc.GetOpts().GetLeaderScheduleLimit(), Equals, uint64(8))
+	c.Assert(tc.GetStore(1).AllowLeaderTransfer(), IsFalse)
+	finishOperators(rollingRestartEvacuateLeaderDesc, func() bool {
+		return tc.core.GetStoreLeaderCount(1) == 0
+	})
+	c.Assert(tc.GetRollingRestartStatus().Stores[1].State, Equals, StoreRestartEvacuating)
+
+	// The leaders are moved back after the store restarts.
+	meta := proto.Clone(tc.GetStore(1).GetMeta()).(*metapb.Store)
+	meta.StartTimestamp++
+	putStore(meta)
+	c.Assert(tc.GetRollingRestartStatus().Stores[1].State, Equals, StoreRestartReturning)
+	finishOperators(rollingRestartReturnLeaderDesc, func() bool {
+		return len(tc.GetRollingRestartStatus().Stores) == 0
+	})
+	c.Assert(tc.core.GetStoreLeaderCount(1), Equals, 4)
+	c.Assert(tc.GetStore(1).AllowLeaderTransfer(), IsTrue)
+
+	// The annotation can be canceled before the store restarts.
+	c.Assert(tc.PrepareStoreRestart(2), IsNil)
+	c.Assert(tc.GetStore(2).AllowLeaderTransfer(), IsFalse)
+	tc.CancelStoreRestart(2)
+	c.Assert(tc.GetRollingRestartStatus().Stores, HasLen, 0)
+	c.Assert(tc.GetStore(2).AllowLeaderTransfer(), IsTrue)
+	c.Assert(tc.PrepareStoreRestart(10), NotNil)
+}
+
 func (s *testCoordinatorSuite) TestCheckerIsBusy(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		cfg.ReplicaScheduleLimit = 0 // ensure replica checker is busy
@@ -496,6 +575,32 @@ func (s *testCoordinatorSuite) TestCheckCache(c *C) {
 	c.Assert(failpoint.Disable("github.com/tikv/pd/server/cluster/break-patrol"), IsNil)
 }
 
+func (s *testCoordinatorSuite) TestCheckRegionsBudget(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+
+	c.Assert(tc.addRegionStore(1, 0), IsNil)
+	c.Assert(tc.addRegionStore(2, 0), IsNil)
+	c.Assert(tc.addRegionStore(3, 0), IsNil)
+	// Make sure the store limit allows to add all the peers to store 3.
+	tc.SetStoreLimit(3, storelimit.AddPeer, 200)
+	// Add regions with two replicas.
+	for id := uint64(1); id <= 3; id++ {
+		c.Assert(tc.addLeaderRegion(id, 1, 2), IsNil)
+	}
+	regions := tc.ScanRegions(nil, nil, 10)
+	c.Assert(regions, HasLen, 3)
+
+	// At least one region is checked even if the budget runs out.
+	checked := co.checkRegions(regions, time.Now().Add(-time.Second))
+	c.Assert(checked, HasLen, 1)
+	c.Assert(co.opController.OperatorCount(operator.OpReplica), Equals, uint64(1))
+
+	checked = co.checkRegions(regions, time.Now().Add(time.Minute))
+	c.Assert(checked, HasLen, 3)
+	c.Assert(co.opController.OperatorCount(operator.OpReplica), Equals, uint64(3))
+}
+
 func (s *testCoordinatorSuite) TestPeerState(c *C) {
 	tc, co, cleanup := prepare(nil, nil, func(co *coordinator) { co.run() }, c)
 	defer cleanup()
@@ -771,6 +876,119 @@ func (s *testCoordinatorSuite) TestPersistScheduler(c *C) {
 	c.Assert(co.schedulers, HasLen, 3)
 }
 
+func (s *testCoordinatorSuite) TestSchedulerStats(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+	oc := co.opController
+	c.Assert(tc.addLeaderRegion(1, 1), IsNil)
+
+	name := schedulers.BalanceLeaderName
+	stats := co.schedulerStats
+	stats.register(name)
+	stats.observeRun(name, time.Now().Add(-time.Second), 2)
+	stats.observeRun(name, time.Now().Add(-time.Second), 0)
+
+	// The operators are counted by their source, the operators without steps
+	// are finished when removed.
+	op := newTestOperator(1, tc.GetRegion(1).GetRegionEpoch(), operator.OpLeader)
+	op.SetSource(name)
+	c.Assert(oc.AddOperator(op), IsTrue)
+	c.Assert(oc.RemoveOperator(op), IsTrue)
+	op = newTestOperator(1, tc.GetRegion(1).GetRegionEpoch(), operator.OpLeader)
+	c.Assert(oc.AddOperator(op), IsTrue)
+	c.Assert(oc.RemoveOperator(op), IsTrue)
+
+	stat := stats.get(name)
+	c.Assert(stat, NotNil)
+	c.Assert(stat.Runs, Equals, uint64(2))
+	c.Assert(stat.OperatorsCreated, Equals, uint64(2))
+	c.Assert(stat.OperatorsFinished, Equals, uint64(1))
+	c.Assert(stat.OperatorsCanceled, Equals, uint64(0))
+	c.Assert(stat.AvgRunDuration.Duration >= time.Second, IsTrue)
+
+	// The stats are kept after reloading.
+	stats.persist(name)
+	newStats := newSchedulerStatsRegistry(tc.storage)
+	c.Assert(newStats.load(), IsNil)
+	newStats.register(name)
+	loaded := newStats.get(name)
+	c.Assert(loaded.LastRunTime.Equal(stat.LastRunTime), IsTrue)
+	loaded.LastRunTime = stat.LastRunTime
+	c.Assert(loaded, DeepEquals, stat)
+
+	c.Assert(stats.remove(name), IsNil)
+	c.Assert(stats.get(name), IsNil)
+	names, _, err := tc.storage.LoadAllSchedulerStats()
+	c.Assert(err, IsNil)
+	c.Assert(names, HasLen, 0)
+}
+
+func (s *testCoordinatorSuite) TestEvaluateScheduler(c *C) {
+	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
+		// The running balance-leader-scheduler doesn't create operators.
+		cfg.LeaderScheduleLimit = 0
+	}, nil, func(co *coordinator) { co.run() }, c)
+	defer cleanup()
+
+	c.Assert(tc.addLeaderStore(1, 50), IsNil)
+	c.Assert(tc.addLeaderStore(2, 0), IsNil)
+	c.Assert(tc.addLeaderStore(3, 0), IsNil)
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+
+	_, err := co.evaluateScheduler("not-found-scheduler", nil)
+	c.Assert(errors.ErrorEqual(err, errs.ErrSchedulerNotFound.FastGenByArgs()), IsTrue)
+
+	evaluation, err := co.evaluateScheduler(schedulers.BalanceLeaderName, nil)
+	c.Assert(err, IsNil)
+	c.Assert(evaluation.ScheduleAllowed, IsFalse)
+	c.Assert(evaluation.Operators, HasLen, 1)
+	op := evaluation.Operators[0]
+	c.Assert(op.RegionID, Equals, uint64(1))
+	c.Assert(op.Kind, Matches, ".*leader.*")
+	c.Assert(op.Scores["sourceScore"], Not(Equals), "")
+	c.Assert(op.Scores["targetScore"], Not(Equals), "")
+	c.Assert(co.opController.GetOperators(), HasLen, 0)
+
+	// The candidate config is merged onto the config of the running scheduler.
+	_, err = co.evaluateScheduler(schedulers.BalanceLeaderName, map[string]interface{}{"ranges": "invalid"})
+	c.Assert(errors.ErrorEqual(err, errs.ErrSchedulerConfig.FastGenByArgs()), IsTrue)
+	ranges := []core.KeyRange{core.NewKeyRange("z", "")}
+	evaluation, err = co.evaluateScheduler(schedulers.BalanceLeaderName, map[string]interface{}{"ranges": ranges})
+	c.Assert(err, IsNil)
+	c.Assert(string(evaluation.Config), Matches, `.*"name":"balance-leader-scheduler".*`)
+	c.Assert(evaluation.Operators, HasLen, 0)
+
+	// Neither the running scheduler nor its persisted config is changed.
+	data, err := co.schedulers[schedulers.BalanceLeaderName].EncodeConfig()
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Not(Equals), string(evaluation.Config))
+	persisted, err := tc.storage.LoadScheduleConfig(schedulers.BalanceLeaderName)
+	c.Assert(err, IsNil)
+	c.Assert(persisted, Not(Equals), string(evaluation.Config))
+}
+
+func (s *testCoordinatorSuite) TestScatterSplitRegions(c *C) {
+	tc, co, cleanup := prepare(nil, nil, nil, c)
+	defer cleanup()
+	tc.RaftCluster.coordinator = co
+
+	for id := uint64(1); id <= 4; id++ {
+		c.Assert(tc.addRegionStore(id, 0), IsNil)
+	}
+	c.Assert(tc.addLeaderRegion(1, 1, 2, 3), IsNil)
+	c.Assert(tc.addLeaderRegion(2, 1, 2, 3), IsNil)
+
+	c.Assert(tc.ScatterSplitRegions(nil, "", 1, "test"), Equals, 100)
+	// The region 3 doesn't exist.
+	c.Assert(tc.ScatterSplitRegions([]uint64{1, 2, 3}, "split", 1, "test"), Equals, 67)
+	ops := co.opController.GetOperators()
+	c.Assert(ops, Not(HasLen), 0)
+	for _, op := range ops {
+		c.Assert(op.Source(), Equals, "test")
+		c.Assert(op.Desc(), Equals, "scatter-region")
+	}
+}
+
 func (s *testCoordinatorSuite) TestRemoveScheduler(c *C) {
 	tc, co, cleanup := prepare(func(cfg *config.ScheduleConfig) {
 		cfg.ReplicaScheduleLimit = 0
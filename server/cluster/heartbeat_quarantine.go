@@ -0,0 +1,184 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// QuarantinedRegion is a suspicious region heartbeat which is quarantined.
+type QuarantinedRegion struct {
+	// Region is the region reported by the latest quarantined heartbeat.
+	Region *core.RegionInfo
+	// Origin is the region in PD's record when the heartbeat is quarantined.
+	Origin          *core.RegionInfo
+	Reason          string
+	FirstReportTime time.Time
+	LastReportTime  time.Time
+	ReportCount     int
+}
+
+// heartbeatQuarantine holds the quarantined region heartbeats until they are
+// confirmed or discarded manually.
+type heartbeatQuarantine struct {
+	sync.RWMutex
+	regions map[uint64]*QuarantinedRegion
+	// confirmed is the epochs of the confirmed heartbeats, the heartbeat with
+	// the confirmed epoch is not quarantined again.
+	confirmed map[uint64]*metapb.RegionEpoch
+}
+
+func newHeartbeatQuarantine() *heartbeatQuarantine {
+	return &heartbeatQuarantine{
+		regions:   make(map[uint64]*QuarantinedRegion),
+		confirmed: make(map[uint64]*metapb.RegionEpoch),
+	}
+}
+
+// suspiciousHeartbeatReason returns the reason why the region heartbeat is
+// suspicious, which is that its epoch is ahead of the origin by more than the
+// gap and less than a majority of the origin peers are kept. It returns an
+// empty string if the heartbeat is not suspicious.
+func suspiciousHeartbeatReason(origin, region *core.RegionInfo, epochGap uint64) string {
+	o, r := origin.GetRegionEpoch(), region.GetRegionEpoch()
+	var epochJump string
+	switch {
+	case r.GetVersion() > o.GetVersion()+epochGap:
+		epochJump = fmt.Sprintf("version jumps from %d to %d", o.GetVersion(), r.GetVersion())
+	case r.GetConfVer() > o.GetConfVer()+epochGap:
+		epochJump = fmt.Sprintf("conf version jumps from %d to %d", o.GetConfVer(), r.GetConfVer())
+	default:
+		return ""
+	}
+	var kept int
+	for _, peer := range origin.GetPeers() {
+		if region.GetPeer(peer.GetId()) != nil {
+			kept++
+		}
+	}
+	if kept > len(origin.GetPeers())/2 {
+		return ""
+	}
+	return fmt.Sprintf("%s and %d of %d peers are kept", epochJump, kept, len(origin.GetPeers()))
+}
+
+// quarantine quarantines the region heartbeat if it is suspicious, it returns
+// true if the heartbeat is quarantined.
+func (q *heartbeatQuarantine) quarantine(origin, region *core.RegionInfo, epochGap uint64) bool {
+	if origin == nil {
+		return false
+	}
+	reason := suspiciousHeartbeatReason(origin, region, epochGap)
+	if reason == "" {
+		return false
+	}
+	q.Lock()
+	defer q.Unlock()
+	if epoch, ok := q.confirmed[region.GetID()]; ok {
+		if epoch.GetVersion() == region.GetRegionEpoch().GetVersion() && epoch.GetConfVer() == region.GetRegionEpoch().GetConfVer() {
+			return false
+		}
+	}
+	now := time.Now()
+	item, ok := q.regions[region.GetID()]
+	if !ok {
+		item = &QuarantinedRegion{FirstReportTime: now}
+		q.regions[region.GetID()] = item
+		log.Warn("region heartbeat is quarantined",
+			zap.Uint64("region-id", region.GetID()),
+			zap.String("reason", reason),
+			logutil.ZapRedactStringer("origin", core.RegionToHexMeta(origin.GetMeta())),
+			logutil.ZapRedactStringer("reported", core.RegionToHexMeta(region.GetMeta())))
+	}
+	item.Region, item.Origin, item.Reason = region, origin, reason
+	item.LastReportTime = now
+	item.ReportCount++
+	regionEventCounter.WithLabelValues("quarantine").Inc()
+	quarantinedRegionsGauge.Set(float64(len(q.regions)))
+	return true
+}
+
+// getAll returns the quarantined regions sorted by the region ID.
+func (q *heartbeatQuarantine) getAll() []QuarantinedRegion {
+	q.RLock()
+	defer q.RUnlock()
+	regions := make([]QuarantinedRegion, 0, len(q.regions))
+	for _, item := range q.regions {
+		regions = append(regions, *item)
+	}
+	sort.Slice(regions, func(i, j int) bool {
+		return regions[i].Region.GetID() < regions[j].Region.GetID()
+	})
+	return regions
+}
+
+// remove removes the quarantined region, and marks its epoch as confirmed if
+// confirm is true.
+func (q *heartbeatQuarantine) remove(regionID uint64, confirm bool) *core.RegionInfo {
+	q.Lock()
+	defer q.Unlock()
+	item, ok := q.regions[regionID]
+	if !ok {
+		return nil
+	}
+	delete(q.regions, regionID)
+	if confirm {
+		q.confirmed[regionID] = item.Region.GetRegionEpoch()
+	}
+	quarantinedRegionsGauge.Set(float64(len(q.regions)))
+	return item.Region
+}
+
+// clearConfirmed clears the confirmed epoch after the heartbeat is applied.
+func (q *heartbeatQuarantine) clearConfirmed(regionID uint64) {
+	q.Lock()
+	defer q.Unlock()
+	delete(q.confirmed, regionID)
+}
+
+// GetQuarantinedRegions returns the quarantined region heartbeats.
+func (c *RaftCluster) GetQuarantinedRegions() []QuarantinedRegion {
+	return c.heartbeatQuarantine.getAll()
+}
+
+// ConfirmQuarantinedRegion applies the quarantined region heartbeat.
+func (c *RaftCluster) ConfirmQuarantinedRegion(regionID uint64) error {
+	region := c.heartbeatQuarantine.remove(regionID, true)
+	if region == nil {
+		return errs.ErrRegionNotQuarantined.FastGenByArgs(regionID)
+	}
+	defer c.heartbeatQuarantine.clearConfirmed(regionID)
+	log.Info("quarantined region heartbeat is confirmed", zap.Uint64("region-id", regionID))
+	return c.processRegionHeartbeat(region)
+}
+
+// DiscardQuarantinedRegion discards the quarantined region heartbeat. The
+// heartbeat is quarantined again if it is reported again.
+func (c *RaftCluster) DiscardQuarantinedRegion(regionID uint64) error {
+	if c.heartbeatQuarantine.remove(regionID, false) == nil {
+		return errs.ErrRegionNotQuarantined.FastGenByArgs(regionID)
+	}
+	log.Info("quarantined region heartbeat is discarded", zap.Uint64("region-id", regionID))
+	return nil
+}
@@ -56,6 +56,56 @@ var (
 			Help:      "Time spent of patrol checks region.",
 		})
 
+	patrolCheckedRegionsHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "patrol_checked_regions",
+			Help:      "Bucketed histogram of the number of regions checked in one patrol round.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		})
+
+	patrolBudgetExceededCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "patrol_budget_exceeded",
+			Help:      "Counter of the patrol rounds which run out of the time budget.",
+		}, []string{"stage"})
+
+	scheduleDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "scheduler",
+			Name:      "schedule_duration_seconds",
+			Help:      "Bucketed histogram of the time spent in one schedule of the scheduler.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"type"})
+
+	scheduleBudgetExceededCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "scheduler",
+			Name:      "budget_exceeded",
+			Help:      "Counter of the schedules which run out of the time budget.",
+		}, []string{"type"})
+
+	schedulerOperatorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "scheduler",
+			Name:      "operators",
+			Help:      "Counter of the operators created by the scheduler.",
+		}, []string{"type", "event"})
+
+	schedulerLastRunGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "scheduler",
+			Name:      "last_run_timestamp_seconds",
+			Help:      "The timestamp of the last run of the scheduler.",
+		}, []string{"type"})
+
 	clusterStateCPUGauge = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -78,6 +128,14 @@ var (
 			Name:      "region_waiting_list",
 			Help:      "Number of region in waiting list",
 		})
+
+	quarantinedRegionsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "quarantined_regions",
+			Help:      "Number of the regions whose heartbeats are quarantined.",
+		})
 )
 
 func init() {
@@ -86,7 +144,14 @@ func init() {
 	prometheus.MustRegister(schedulerStatusGauge)
 	prometheus.MustRegister(hotSpotStatusGauge)
 	prometheus.MustRegister(patrolCheckRegionsGauge)
+	prometheus.MustRegister(patrolCheckedRegionsHistogram)
+	prometheus.MustRegister(patrolBudgetExceededCounter)
+	prometheus.MustRegister(scheduleDuration)
+	prometheus.MustRegister(scheduleBudgetExceededCounter)
+	prometheus.MustRegister(schedulerOperatorCounter)
+	prometheus.MustRegister(schedulerLastRunGauge)
 	prometheus.MustRegister(clusterStateCPUGauge)
 	prometheus.MustRegister(clusterStateCurrent)
 	prometheus.MustRegister(regionWaitingListGauge)
+	prometheus.MustRegister(quarantinedRegionsGauge)
 }
@@ -0,0 +1,326 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
+)
+
+// The states of the rollout of max-replicas.
+const (
+	ReplicasRolloutRunning  = "running"
+	ReplicasRolloutPaused   = "paused"
+	ReplicasRolloutFinished = "finished"
+	ReplicasRolloutAborted  = "aborted"
+)
+
+// replicasRolloutRuleID is the ID of the placement rule in the "pd" group,
+// which overrides the default rule in the rolled out key range.
+const replicasRolloutRuleID = "replicas-rollout"
+
+// DefaultReplicasRolloutBatchSize is the default count of the regions rolled
+// out in a batch.
+const DefaultReplicasRolloutBatchSize = 1000
+
+// ReplicasRollout is the progress of a staged rollout of max-replicas. The key
+// space is rolled out batch by batch from the beginning, and the next batch is
+// started after all the regions in the current batch satisfy the new count, so
+// that changing max-replicas does not add peers to all the regions at once.
+type ReplicasRollout struct {
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	BatchSize int    `json:"batch-size"`
+	State     string `json:"state"`
+	// BatchStartKey and BatchEndKey are the key range of the current batch in
+	// hex format. The keys before BatchEndKey are rolled out.
+	BatchStartKey string `json:"batch-start-key"`
+	BatchEndKey   string `json:"batch-end-key"`
+	// LastBatch reports whether the current batch reaches the end of the key
+	// space.
+	LastBatch bool `json:"last-batch"`
+	Batches   int  `json:"batches"`
+	// RolledOutRegions is the count of the regions in the finished batches,
+	// and BatchRegions is the count of the regions in the current batch.
+	RolledOutRegions int       `json:"rolled-out-regions"`
+	BatchRegions     int       `json:"batch-regions"`
+	StartTime        time.Time `json:"start-time"`
+	UpdateTime       time.Time `json:"update-time"`
+}
+
+// IsInProgress checks if the rollout is running or paused.
+func (r *ReplicasRollout) IsInProgress() bool {
+	return r.State == ReplicasRolloutRunning || r.State == ReplicasRolloutPaused
+}
+
+type replicasRolloutController struct {
+	sync.Mutex
+	cluster *RaftCluster
+	rollout *ReplicasRollout
+}
+
+func newReplicasRolloutController(cluster *RaftCluster) *replicasRolloutController {
+	return &replicasRolloutController{cluster: cluster}
+}
+
+func (rc *replicasRolloutController) load() error {
+	rollout := &ReplicasRollout{}
+	ok, err := rc.cluster.storage.LoadReplicasRollout(rollout)
+	if err != nil || !ok {
+		return err
+	}
+	rc.Lock()
+	defer rc.Unlock()
+	rc.rollout = rollout
+	return nil
+}
+
+func (rc *replicasRolloutController) get() *ReplicasRollout {
+	rc.Lock()
+	defer rc.Unlock()
+	if rc.rollout == nil {
+		return nil
+	}
+	rollout := *rc.rollout
+	return &rollout
+}
+
+func (rc *replicasRolloutController) isInProgress() bool {
+	rc.Lock()
+	defer rc.Unlock()
+	return rc.rollout != nil && rc.rollout.IsInProgress()
+}
+
+func (rc *replicasRolloutController) start(to, batchSize int) error {
+	rc.Lock()
+	defer rc.Unlock()
+	if rc.rollout != nil && rc.rollout.IsInProgress() {
+		return errs.ErrReplicasRolloutInProgress.FastGenByArgs()
+	}
+	if !rc.cluster.opt.IsPlacementRulesEnabled() {
+		return errors.New("the rollout of max-replicas requires placement rules to be enabled")
+	}
+	from := rc.cluster.opt.GetMaxReplicas()
+	defaultRule := rc.cluster.GetRuleManager().GetRule("pd", "default")
+	if defaultRule == nil || len(defaultRule.StartKey) != 0 || len(defaultRule.EndKey) != 0 || defaultRule.Count != from {
+		return errors.New("the default rule is not consistent with the replication config, please update rule instead")
+	}
+	if to <= 0 || to == from {
+		return errors.Errorf("invalid max-replicas %d", to)
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultReplicasRolloutBatchSize
+	}
+	now := time.Now()
+	rollout := &ReplicasRollout{
+		From:       from,
+		To:         to,
+		BatchSize:  batchSize,
+		State:      ReplicasRolloutRunning,
+		StartTime:  now,
+		UpdateTime: now,
+	}
+	if err := rc.nextBatch(rollout); err != nil {
+		return err
+	}
+	rc.rollout = rollout
+	log.Info("replicas rollout is started", zap.Int("from", from), zap.Int("to", to), zap.Int("batch-size", batchSize))
+	return nil
+}
+
+// setState pauses, resumes or aborts the rollout.
+func (rc *replicasRolloutController) setState(state string) error {
+	rc.Lock()
+	defer rc.Unlock()
+	if rc.rollout == nil || !rc.rollout.IsInProgress() {
+		return errs.ErrReplicasRolloutNotFound.FastGenByArgs()
+	}
+	rollout := *rc.rollout
+	rollout.State = state
+	rollout.UpdateTime = time.Now()
+	if state == ReplicasRolloutAborted {
+		// The regions in the rolled out key range go back to the old count.
+		if err := rc.cluster.GetRuleManager().DeleteRule("pd", replicasRolloutRuleID); err != nil {
+			return err
+		}
+	}
+	if err := rc.cluster.storage.SaveReplicasRollout(&rollout); err != nil {
+		return err
+	}
+	rc.rollout = &rollout
+	log.Info("replicas rollout state is changed", zap.String("state", state))
+	return nil
+}
+
+// patrol starts the next batch or finishes the rollout if all the regions in
+// the current batch satisfy the new count.
+func (rc *replicasRolloutController) patrol() {
+	rc.Lock()
+	defer rc.Unlock()
+	if rc.rollout == nil || rc.rollout.State != ReplicasRolloutRunning || !rc.isBatchDone(rc.rollout) {
+		return
+	}
+	rollout := *rc.rollout
+	var err error
+	if rollout.LastBatch {
+		err = rc.finish(&rollout)
+	} else {
+		err = rc.nextBatch(&rollout)
+	}
+	if err != nil {
+		log.Error("failed to advance the replicas rollout", errs.ZapError(err))
+		return
+	}
+	rc.rollout = &rollout
+}
+
+func (rc *replicasRolloutController) isBatchDone(rollout *ReplicasRollout) bool {
+	startKey, _ := hex.DecodeString(rollout.BatchStartKey)
+	endKey, _ := hex.DecodeString(rollout.BatchEndKey)
+	ruleManager := rc.cluster.GetRuleManager()
+	for {
+		regions := rc.cluster.ScanRegions(startKey, endKey, rollout.BatchSize)
+		for _, region := range regions {
+			if !ruleManager.FitRegion(rc.cluster, region).IsSatisfied() {
+				return false
+			}
+		}
+		if len(regions) < rollout.BatchSize {
+			return true
+		}
+		startKey = regions[len(regions)-1].GetEndKey()
+		if len(startKey) == 0 {
+			return true
+		}
+	}
+}
+
+// nextBatch extends the rolled out key range by the next batch of regions.
+func (rc *replicasRolloutController) nextBatch(rollout *ReplicasRollout) error {
+	startKey, _ := hex.DecodeString(rollout.BatchEndKey)
+	regions := rc.cluster.ScanRegions(startKey, nil, rollout.BatchSize)
+	var endKey []byte
+	if len(regions) > 0 {
+		endKey = regions[len(regions)-1].GetEndKey()
+	}
+	rule := rc.newRolloutRule(rollout, endKey)
+	if err := rc.cluster.GetRuleManager().SetRule(rule); err != nil {
+		return err
+	}
+	rollout.RolledOutRegions += rollout.BatchRegions
+	rollout.BatchRegions = len(regions)
+	rollout.BatchStartKey = rollout.BatchEndKey
+	rollout.BatchEndKey = hex.EncodeToString(endKey)
+	rollout.LastBatch = len(endKey) == 0
+	rollout.Batches++
+	rollout.UpdateTime = time.Now()
+	log.Info("replicas rollout starts a new batch",
+		zap.Int("batch", rollout.Batches),
+		logutil.ZapRedactString("start-key", rollout.BatchStartKey),
+		logutil.ZapRedactString("end-key", rollout.BatchEndKey))
+	return rc.cluster.storage.SaveReplicasRollout(rollout)
+}
+
+func (rc *replicasRolloutController) newRolloutRule(rollout *ReplicasRollout, endKey []byte) *placement.Rule {
+	defaultRule := rc.cluster.GetRuleManager().GetRule("pd", "default")
+	rule := &placement.Rule{
+		GroupID:   "pd",
+		ID:        replicasRolloutRuleID,
+		Index:     1,
+		Override:  true,
+		EndKeyHex: hex.EncodeToString(endKey),
+		Role:      placement.Voter,
+		Count:     rollout.To,
+	}
+	if defaultRule != nil {
+		rule.Index = defaultRule.Index + 1
+		rule.Role = defaultRule.Role
+		rule.LabelConstraints = defaultRule.LabelConstraints
+		rule.LocationLabels = defaultRule.LocationLabels
+		rule.IsolationLevel = defaultRule.IsolationLevel
+	}
+	return rule
+}
+
+// finish applies the new count to the default rule and the replication config.
+func (rc *replicasRolloutController) finish(rollout *ReplicasRollout) error {
+	ruleManager := rc.cluster.GetRuleManager()
+	defaultRule := ruleManager.GetRule("pd", "default")
+	if defaultRule == nil {
+		return errors.New("the default rule is not found")
+	}
+	rule := *defaultRule
+	rule.Count = rollout.To
+	if err := ruleManager.Batch([]placement.RuleOp{
+		{Rule: &rule, Action: placement.RuleOpAdd},
+		{Rule: &placement.Rule{GroupID: "pd", ID: replicasRolloutRuleID}, Action: placement.RuleOpDel},
+	}); err != nil {
+		return err
+	}
+	cfg := rc.cluster.opt.GetReplicationConfig().Clone()
+	cfg.MaxReplicas = uint64(rollout.To)
+	rc.cluster.opt.SetReplicationConfig(cfg)
+	if err := rc.cluster.opt.Persist(rc.cluster.storage); err != nil {
+		return err
+	}
+	rollout.RolledOutRegions += rollout.BatchRegions
+	rollout.BatchRegions = 0
+	rollout.State = ReplicasRolloutFinished
+	rollout.UpdateTime = time.Now()
+	log.Info("replicas rollout is finished", zap.Int("max-replicas", rollout.To))
+	return rc.cluster.storage.SaveReplicasRollout(rollout)
+}
+
+// StartReplicasRollout starts a staged rollout of max-replicas, which changes
+// the count of the replicas batch by batch, each batch has batchSize regions.
+func (c *RaftCluster) StartReplicasRollout(maxReplicas, batchSize int) error {
+	return c.replicasRollout.start(maxReplicas, batchSize)
+}
+
+// PauseReplicasRollout stops the rollout of max-replicas from starting the next
+// batch, the regions in the current batch are still rolled out.
+func (c *RaftCluster) PauseReplicasRollout() error {
+	return c.replicasRollout.setState(ReplicasRolloutPaused)
+}
+
+// ResumeReplicasRollout resumes the paused rollout of max-replicas.
+func (c *RaftCluster) ResumeReplicasRollout() error {
+	return c.replicasRollout.setState(ReplicasRolloutRunning)
+}
+
+// AbortReplicasRollout aborts the rollout of max-replicas, the regions which
+// are rolled out go back to the old count.
+func (c *RaftCluster) AbortReplicasRollout() error {
+	return c.replicasRollout.setState(ReplicasRolloutAborted)
+}
+
+// GetReplicasRollout returns the progress of the latest rollout of
+// max-replicas, nil means there is no rollout.
+func (c *RaftCluster) GetReplicasRollout() *ReplicasRollout {
+	return c.replicasRollout.get()
+}
+
+// IsReplicasRolloutInProgress checks if a rollout of max-replicas is running
+// or paused.
+func (c *RaftCluster) IsReplicasRolloutInProgress() bool {
+	return c.replicasRollout.isInProgress()
+}
@@ -0,0 +1,347 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// The states of a store annotated to restart.
+const (
+	// StoreRestartEvacuating means the leaders are moved out of the store
+	// before it restarts.
+	StoreRestartEvacuating = "evacuating"
+	// StoreRestartReturning means the leaders are moved back to the store
+	// after it restarts.
+	StoreRestartReturning = "returning"
+)
+
+const (
+	rollingRestartEvacuateLeaderDesc = "rolling-restart-evacuate-leader"
+	rollingRestartReturnLeaderDesc   = "rolling-restart-return-leader"
+)
+
+// RollingRestartStatus is the status of the rolling restart of the stores.
+type RollingRestartStatus struct {
+	InProgress bool       `json:"in_progress"`
+	Since      *time.Time `json:"since,omitempty"`
+	// Restarts are the latest restart time of the stores which restart within
+	// the window.
+	Restarts            map[uint64]time.Time `json:"restarts"`
+	LeaderScheduleLimit uint64               `json:"leader_schedule_limit"`
+	// Stores are the stores annotated to restart.
+	Stores map[uint64]*StoreRestart `json:"stores"`
+}
+
+// StoreRestart is the status of a store annotated to restart.
+type StoreRestart struct {
+	State string `json:"state"`
+	// LeaderCount is the count of the leaders on the store when it is
+	// annotated, which are moved back after it restarts.
+	LeaderCount int `json:"leader_count"`
+}
+
+type storeRestart struct {
+	state       string
+	leaderCount int
+	// paused is true if the leader transfer of the store is paused by the
+	// controller, which resumes it after the store restarts.
+	paused bool
+}
+
+// rollingRestartController detects the rolling restart of the stores, and
+// raises the leader schedule limit during it. Before a store is restarted, its
+// leaders are usually evicted, and they are moved back after it is restarted.
+// So raising the limit shortens the time window of restarting each store. The
+// limit is restored once no store restarts within the window.
+//
+// A store can also be annotated to restart before it is restarted. The leaders
+// of the annotated store are moved out with high priority, and after it
+// restarts, the same count of leaders are moved back with high priority, so
+// the restart doesn't wait for the balance leader scheduler.
+type rollingRestartController struct {
+	sync.Mutex
+	cluster    *RaftCluster
+	restarts   map[uint64]time.Time
+	stores     map[uint64]*storeRestart
+	inProgress bool
+	since      time.Time
+}
+
+func newRollingRestartController(cluster *RaftCluster) *rollingRestartController {
+	// The limit may be raised before the leader changes.
+	cluster.opt.SetLeaderScheduleLimitBoost(0)
+	return &rollingRestartController{
+		cluster:  cluster,
+		restarts: make(map[uint64]time.Time),
+		stores:   make(map[uint64]*storeRestart),
+	}
+}
+
+// prepareRestart annotates the store to restart, then its leaders are moved
+// out until it restarts.
+func (r *rollingRestartController) prepareRestart(storeID uint64, now time.Time) error {
+	store := r.cluster.GetStore(storeID)
+	if store == nil {
+		return errs.ErrStoreNotFound.FastGenByArgs(storeID)
+	}
+	if store.IsTombstone() {
+		return errs.ErrStoreTombstone.FastGenByArgs(storeID)
+	}
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.stores[storeID]; ok {
+		return nil
+	}
+	s := &storeRestart{
+		state:       StoreRestartEvacuating,
+		leaderCount: r.cluster.core.GetStoreLeaderCount(storeID),
+	}
+	// The other schedulers don't move the leaders to the store before it
+	// restarts. The leader transfer may have been paused by others, such as
+	// the evict leader scheduler, then it is left to them to resume it.
+	s.paused = r.cluster.core.PauseLeaderTransfer(storeID) == nil
+	r.stores[storeID] = s
+	log.Info("store is annotated to restart, evacuate its leaders",
+		zap.Uint64("store-id", storeID),
+		zap.Int("leader-count", s.leaderCount))
+	r.updateLocked(now)
+	return nil
+}
+
+// cancelRestart removes the restart annotation of the store.
+func (r *rollingRestartController) cancelRestart(storeID uint64, now time.Time) {
+	r.Lock()
+	defer r.Unlock()
+	if s, ok := r.stores[storeID]; ok {
+		r.finishLocked(storeID, s, "the restart of the store is canceled")
+		r.updateLocked(now)
+	}
+}
+
+func (r *rollingRestartController) finishLocked(storeID uint64, s *storeRestart, reason string) {
+	if s.paused {
+		r.cluster.core.ResumeLeaderTransfer(storeID)
+	}
+	delete(r.stores, storeID)
+	log.Info(reason, zap.Uint64("store-id", storeID), zap.String("state", s.state))
+}
+
+// observeRestart records the restart of the store, which is detected by the
+// change of its start timestamp.
+func (r *rollingRestartController) observeRestart(storeID uint64, now time.Time) {
+	r.Lock()
+	defer r.Unlock()
+	r.restarts[storeID] = now
+	if s, ok := r.stores[storeID]; ok && s.state == StoreRestartEvacuating {
+		s.state = StoreRestartReturning
+		log.Info("annotated store restarts, return its leaders",
+			zap.Uint64("store-id", storeID),
+			zap.Int("leader-count", s.leaderCount))
+	}
+	r.updateLocked(now)
+}
+
+// patrol moves the leaders of the annotated stores, and ends the rolling
+// restart if no store restarts within the window.
+func (r *rollingRestartController) patrol(now time.Time) {
+	r.Lock()
+	stores := make(map[uint64]storeRestart, len(r.stores))
+	for storeID, s := range r.stores {
+		if s.state == StoreRestartReturning {
+			// The leaders are returned within the window after the store
+			// restarts, and the rest are left to the balance leader scheduler.
+			if _, ok := r.restarts[storeID]; !ok {
+				r.finishLocked(storeID, s, "the window of returning the leaders of the restarted store is over")
+				continue
+			}
+			if s.paused {
+				r.cluster.core.ResumeLeaderTransfer(storeID)
+				s.paused = false
+			}
+		}
+		stores[storeID] = *s
+	}
+	r.updateLocked(now)
+	r.Unlock()
+
+	if len(stores) == 0 {
+		return
+	}
+	// The operators are added without holding the lock, which is acquired
+	// when the stores are put with the cluster lock held.
+	oc := r.cluster.GetOperatorController()
+	for storeID, s := range stores {
+		switch s.state {
+		case StoreRestartEvacuating:
+			r.evacuateLeaders(oc, storeID)
+		case StoreRestartReturning:
+			if r.returnLeaders(oc, storeID, s.leaderCount) {
+				r.Lock()
+				if s, ok := r.stores[storeID]; ok && s.state == StoreRestartReturning {
+					r.finishLocked(storeID, s, "the leaders of the restarted store are returned")
+					r.updateLocked(now)
+				}
+				r.Unlock()
+			}
+		}
+	}
+}
+
+// evacuateLeaders moves the leaders out of the store within the leader
+// schedule limit.
+func (r *rollingRestartController) evacuateLeaders(oc *schedule.OperatorController, storeID uint64) {
+	for i := leaderScheduleBudget(r.cluster, oc); i > 0; i-- {
+		region := r.cluster.RandLeaderRegion(storeID, []core.KeyRange{core.NewKeyRange("", "")}, opt.HealthRegion(r.cluster))
+		if region == nil {
+			return
+		}
+		if oc.GetOperator(region.GetID()) != nil {
+			continue
+		}
+		target := filter.NewCandidates(r.cluster.GetFollowerStores(region)).
+			FilterTarget(r.cluster.GetOpts(), &filter.StoreStateFilter{ActionScope: rollingRestartEvacuateLeaderDesc, TransferLeader: true}).
+			RandomPick()
+		if target == nil {
+			continue
+		}
+		addRollingRestartOperator(oc, rollingRestartEvacuateLeaderDesc, r.cluster, region, storeID, target.GetID(),
+			fmt.Sprintf("evacuate the leaders from store %d before it restarts", storeID))
+	}
+}
+
+// returnLeaders moves the leaders back to the restarted store within the
+// leader schedule limit, and returns true if there is nothing more to return.
+func (r *rollingRestartController) returnLeaders(oc *schedule.OperatorController, storeID uint64, leaderCount int) bool {
+	store := r.cluster.GetStore(storeID)
+	if store == nil || store.IsTombstone() {
+		return true
+	}
+	count := leaderCount - r.cluster.core.GetStoreLeaderCount(storeID)
+	if count <= 0 {
+		return true
+	}
+	// Waits for the store to be able to accept the leaders.
+	if !filter.Target(r.cluster.GetOpts(), store, []filter.Filter{&filter.StoreStateFilter{ActionScope: rollingRestartReturnLeaderDesc, TransferLeader: true}}) {
+		return false
+	}
+	if budget := leaderScheduleBudget(r.cluster, oc); budget < count {
+		count = budget
+	}
+	for i := 0; i < count; i++ {
+		region := r.cluster.RandFollowerRegion(storeID, []core.KeyRange{core.NewKeyRange("", "")}, opt.HealthRegion(r.cluster))
+		if region == nil {
+			return true
+		}
+		if oc.GetOperator(region.GetID()) != nil {
+			continue
+		}
+		addRollingRestartOperator(oc, rollingRestartReturnLeaderDesc, r.cluster, region, region.GetLeader().GetStoreId(), storeID,
+			fmt.Sprintf("return the leaders to store %d after it restarts", storeID))
+	}
+	return false
+}
+
+// leaderScheduleBudget returns how many leader operators can be added within
+// the leader schedule limit.
+func leaderScheduleBudget(cluster *RaftCluster, oc *schedule.OperatorController) int {
+	limit, count := cluster.GetOpts().GetLeaderScheduleLimit(), oc.OperatorCount(operator.OpLeader)
+	if count >= limit {
+		return 0
+	}
+	return int(limit - count)
+}
+
+func addRollingRestartOperator(oc *schedule.OperatorController, desc string, cluster *RaftCluster, region *core.RegionInfo, sourceStoreID, targetStoreID uint64, reason string) {
+	op, err := operator.CreateTransferLeaderOperator(desc, cluster, region, sourceStoreID, targetStoreID, operator.OpLeader)
+	if err != nil {
+		log.Debug("fail to create rolling restart operator", errs.ZapError(err))
+		return
+	}
+	op.SetReason(reason)
+	op.SetPriorityLevel(core.HighPriority)
+	oc.AddWaitingOperator(op)
+}
+
+func (r *rollingRestartController) updateLocked(now time.Time) {
+	window := r.cluster.opt.GetRollingRestartWindow()
+	for storeID, t := range r.restarts {
+		if now.Sub(t) >= window {
+			delete(r.restarts, storeID)
+		}
+	}
+	limit := r.cluster.opt.GetRollingRestartLeaderScheduleLimit()
+	switch {
+	// A single store restarting is not a rolling restart unless it is
+	// annotated, but the rolling restart goes on while the leaders are moved
+	// back to the last restarted store.
+	case !r.inProgress && limit > 0 && (len(r.restarts) > 1 || len(r.stores) > 0):
+		r.inProgress, r.since = true, now
+		log.Info("rolling restart of the stores is detected, raise the leader schedule limit",
+			zap.Uint64s("restarted-stores", r.restartedStoresLocked()),
+			zap.Uint64("leader-schedule-limit", limit))
+	case r.inProgress && (limit == 0 || len(r.restarts) == 0 && len(r.stores) == 0):
+		r.inProgress = false
+		log.Info("rolling restart of the stores is finished, restore the leader schedule limit",
+			zap.Duration("duration", now.Sub(r.since)))
+	}
+	if r.inProgress {
+		r.cluster.opt.SetLeaderScheduleLimitBoost(limit)
+	} else {
+		r.cluster.opt.SetLeaderScheduleLimitBoost(0)
+	}
+}
+
+func (r *rollingRestartController) restartedStoresLocked() []uint64 {
+	stores := make([]uint64, 0, len(r.restarts))
+	for storeID := range r.restarts {
+		stores = append(stores, storeID)
+	}
+	sort.Slice(stores, func(i, j int) bool { return stores[i] < stores[j] })
+	return stores
+}
+
+func (r *rollingRestartController) getStatus() *RollingRestartStatus {
+	r.Lock()
+	defer r.Unlock()
+	status := &RollingRestartStatus{
+		InProgress:          r.inProgress,
+		Restarts:            make(map[uint64]time.Time, len(r.restarts)),
+		LeaderScheduleLimit: r.cluster.opt.GetLeaderScheduleLimit(),
+		Stores:              make(map[uint64]*StoreRestart, len(r.stores)),
+	}
+	if r.inProgress {
+		since := r.since
+		status.Since = &since
+	}
+	for storeID, t := range r.restarts {
+		status.Restarts[storeID] = t
+	}
+	for storeID, s := range r.stores {
+		status.Stores[storeID] = &StoreRestart{State: s.state, LeaderCount: s.leaderCount}
+	}
+	return status
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// The actions recorded in SchedulerDisableRecord.
+const (
+	SchedulerActionPause   = "pause"
+	SchedulerActionDisable = "disable"
+)
+
+// SchedulerActorManual is the actor of the pauses and disables requested by users.
+const SchedulerActorManual = "manual"
+
+// SchedulerDisableRecord records who paused or disabled a scheduler and why, so
+// that the manual and automatic pauses can be told apart.
+type SchedulerDisableRecord struct {
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// PauseUntil is the time when a paused scheduler resumes.
+	PauseUntil time.Time `json:"pause_until,omitempty"`
+}
+
+// schedulerDisableRegistry keeps the disable records of schedulers and persists
+// them to storage, so the records survive the PD leader changes.
+type schedulerDisableRegistry struct {
+	sync.RWMutex
+	storage *core.Storage
+	records map[string]*SchedulerDisableRecord
+}
+
+func newSchedulerDisableRegistry(storage *core.Storage) *schedulerDisableRegistry {
+	return &schedulerDisableRegistry{
+		storage: storage,
+		records: make(map[string]*SchedulerDisableRecord),
+	}
+}
+
+// load loads the records from storage.
+func (r *schedulerDisableRegistry) load() error {
+	names, values, err := r.storage.LoadAllSchedulerDisableRecords()
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	for i, name := range names {
+		record := &SchedulerDisableRecord{}
+		if err := json.Unmarshal([]byte(values[i]), record); err != nil {
+			log.Warn("invalid scheduler disable record", zap.String("scheduler-name", name), errs.ZapError(errs.ErrJSONUnmarshal, err))
+			continue
+		}
+		r.records[name] = record
+	}
+	return nil
+}
+
+func (r *schedulerDisableRegistry) put(name string, record *SchedulerDisableRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	r.Lock()
+	defer r.Unlock()
+	if err := r.storage.SaveSchedulerDisableRecord(name, data); err != nil {
+		return err
+	}
+	r.records[name] = record
+	return nil
+}
+
+func (r *schedulerDisableRegistry) remove(name string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.records[name]; !ok {
+		return nil
+	}
+	if err := r.storage.RemoveSchedulerDisableRecord(name); err != nil {
+		return err
+	}
+	delete(r.records, name)
+	return nil
+}
+
+func (r *schedulerDisableRegistry) get(name string) *SchedulerDisableRecord {
+	r.RLock()
+	defer r.RUnlock()
+	return r.records[name]
+}
+
+func (r *schedulerDisableRegistry) getAll() map[string]*SchedulerDisableRecord {
+	r.RLock()
+	defer r.RUnlock()
+	records := make(map[string]*SchedulerDisableRecord, len(r.records))
+	for name, record := range r.records {
+		records[name] = record
+	}
+	return records
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/operator"
+)
+
+// SchedulerEvaluation is the result of evaluating a scheduler with a candidate
+// config, the operators are not added.
+type SchedulerEvaluation struct {
+	Name string `json:"name"`
+	// Config is the candidate config merged onto the config of the running
+	// scheduler.
+	Config json.RawMessage `json:"config"`
+	// ScheduleAllowed is false if the scheduler is limited by the schedule
+	// limits or the running operators now, the operators are evaluated anyway.
+	ScheduleAllowed bool                 `json:"schedule_allowed"`
+	Operators       []*EvaluatedOperator `json:"operators"`
+}
+
+// EvaluatedOperator is a hypothetical operator created by the evaluation.
+type EvaluatedOperator struct {
+	RegionID uint64   `json:"region_id"`
+	Desc     string   `json:"desc"`
+	Brief    string   `json:"brief"`
+	Kind     string   `json:"kind"`
+	Steps    []string `json:"steps"`
+	// Scores are the scores by which the scheduler picks the operator, e.g.
+	// the scores of the source and the target stores of the balance schedulers.
+	Scores map[string]string `json:"scores,omitempty"`
+}
+
+func newEvaluatedOperator(op *operator.Operator) *EvaluatedOperator {
+	steps := make([]string, 0, op.Len())
+	for i := 0; i < op.Len(); i++ {
+		steps = append(steps, op.Step(i).String())
+	}
+	return &EvaluatedOperator{
+		RegionID: op.RegionID(),
+		Desc:     op.Desc(),
+		Brief:    op.Brief(),
+		Kind:     op.Kind().String(),
+		Steps:    steps,
+		Scores:   op.AdditionalInfos,
+	}
+}
+
+// evaluateScheduler runs a scheduler of the same type as the running one once
+// over the current statistics, with the candidate config merged onto the config
+// of the running one. The evaluated scheduler is created with a memory storage
+// and discarded after the evaluation, so the running one is not affected.
+func (c *coordinator) evaluateScheduler(name string, candidate map[string]interface{}) (*SchedulerEvaluation, error) {
+	c.RLock()
+	s, ok := c.schedulers[name]
+	c.RUnlock()
+	if !ok {
+		return nil, errs.ErrSchedulerNotFound.FastGenByArgs()
+	}
+	data, err := s.EncodeConfig()
+	if err != nil {
+		return nil, err
+	}
+	config := make(map[string]interface{})
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).FastGenWithCause()
+	}
+	for k, v := range candidate {
+		config[k] = v
+	}
+	if data, err = json.Marshal(config); err != nil {
+		return nil, errs.ErrJSONMarshal.Wrap(err).FastGenWithCause()
+	}
+
+	storage := core.NewStorage(kv.NewMemoryKV())
+	evaluated, err := schedule.CreateScheduler(s.GetType(), c.opController, storage, schedule.ConfigJSONDecoder(data))
+	if err != nil {
+		return nil, errs.ErrSchedulerConfig.FastGenByArgs(err.Error())
+	}
+	// The scheduler is not prepared, because preparing may change the cluster,
+	// e.g. pausing the leader transfer of the stores.
+	evaluation := &SchedulerEvaluation{
+		Name:            name,
+		Config:          data,
+		ScheduleAllowed: evaluated.IsScheduleAllowed(c.cluster),
+		Operators:       []*EvaluatedOperator{},
+	}
+	for _, op := range evaluated.Schedule(c.cluster) {
+		evaluation.Operators = append(evaluation.Operators, newEvaluatedOperator(op))
+	}
+	return evaluation, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"go.uber.org/zap"
+)
+
+// schedulerStatsPersistInterval is the min interval to persist the statistics
+// of a scheduler, the statistics changed after the last persistence are lost
+// if the leader changes.
+const schedulerStatsPersistInterval = time.Minute
+
+// SchedulerStats is the runtime statistics of a scheduler. The statistics are
+// persisted periodically, so they are kept across the PD leader changes.
+type SchedulerStats struct {
+	Name string `json:"name"`
+	// OperatorsCreated is the number of the operators created by the scheduler,
+	// which may not be added if they are rejected by the operator controller.
+	OperatorsCreated  uint64 `json:"operators_created"`
+	OperatorsFinished uint64 `json:"operators_finished"`
+	// OperatorsCanceled includes the operators which are canceled, replaced or
+	// expired before starting.
+	OperatorsCanceled uint64            `json:"operators_canceled"`
+	OperatorsTimeout  uint64            `json:"operators_timeout"`
+	Runs              uint64            `json:"runs"`
+	LastRunTime       time.Time         `json:"last_run_time"`
+	TotalRunDuration  typeutil.Duration `json:"total_run_duration"`
+	// AvgRunDuration is calculated by TotalRunDuration and Runs.
+	AvgRunDuration typeutil.Duration `json:"avg_run_duration"`
+}
+
+type schedulerStatsEntry struct {
+	stats       SchedulerStats
+	persistedAt time.Time
+}
+
+// schedulerStatsRegistry keeps the statistics of the schedulers and persists
+// them to storage.
+type schedulerStatsRegistry struct {
+	sync.Mutex
+	storage *core.Storage
+	stats   map[string]*schedulerStatsEntry
+}
+
+func newSchedulerStatsRegistry(storage *core.Storage) *schedulerStatsRegistry {
+	return &schedulerStatsRegistry{
+		storage: storage,
+		stats:   make(map[string]*schedulerStatsEntry),
+	}
+}
+
+// load loads the statistics from storage.
+func (r *schedulerStatsRegistry) load() error {
+	names, values, err := r.storage.LoadAllSchedulerStats()
+	if err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	for i, name := range names {
+		entry := &schedulerStatsEntry{persistedAt: time.Now()}
+		if err := json.Unmarshal([]byte(values[i]), &entry.stats); err != nil {
+			log.Warn("invalid scheduler stats", zap.String("scheduler-name", name), errs.ZapError(errs.ErrJSONUnmarshal, err))
+			continue
+		}
+		r.stats[name] = entry
+	}
+	return nil
+}
+
+// register starts to record the statistics of the scheduler, the loaded
+// statistics are kept.
+func (r *schedulerStatsRegistry) register(name string) {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.stats[name]; !ok {
+		r.stats[name] = &schedulerStatsEntry{stats: SchedulerStats{Name: name}}
+	}
+}
+
+// remove removes the statistics of the removed scheduler.
+func (r *schedulerStatsRegistry) remove(name string) error {
+	r.Lock()
+	defer r.Unlock()
+	if _, ok := r.stats[name]; !ok {
+		return nil
+	}
+	if err := r.storage.RemoveSchedulerStats(name); err != nil {
+		return err
+	}
+	delete(r.stats, name)
+	schedulerOperatorCounter.DeleteLabelValues(name, "create")
+	schedulerOperatorCounter.DeleteLabelValues(name, "finish")
+	schedulerOperatorCounter.DeleteLabelValues(name, "cancel")
+	schedulerOperatorCounter.DeleteLabelValues(name, "timeout")
+	schedulerLastRunGauge.DeleteLabelValues(name)
+	return nil
+}
+
+// observeRun records a run of the scheduler, and persists the statistics if
+// they are not persisted for a while.
+func (r *schedulerStatsRegistry) observeRun(name string, start time.Time, ops int) {
+	r.Lock()
+	defer r.Unlock()
+	entry, ok := r.stats[name]
+	if !ok {
+		return
+	}
+	entry.stats.Runs++
+	entry.stats.LastRunTime = start
+	entry.stats.TotalRunDuration.Duration += time.Since(start)
+	entry.stats.OperatorsCreated += uint64(ops)
+	schedulerOperatorCounter.WithLabelValues(name, "create").Add(float64(ops))
+	schedulerLastRunGauge.WithLabelValues(name).Set(float64(start.Unix()))
+	if time.Since(entry.persistedAt) >= schedulerStatsPersistInterval {
+		r.persistLocked(entry)
+	}
+}
+
+// observeEnd records the operator created by a scheduler when it ends.
+func (r *schedulerStatsRegistry) observeEnd(op *operator.Operator) {
+	r.Lock()
+	defer r.Unlock()
+	entry, ok := r.stats[op.Source()]
+	if !ok {
+		return
+	}
+	switch op.Status() {
+	case operator.SUCCESS:
+		entry.stats.OperatorsFinished++
+		schedulerOperatorCounter.WithLabelValues(op.Source(), "finish").Inc()
+	case operator.CANCELED, operator.REPLACED, operator.EXPIRED:
+		entry.stats.OperatorsCanceled++
+		schedulerOperatorCounter.WithLabelValues(op.Source(), "cancel").Inc()
+	case operator.TIMEOUT:
+		entry.stats.OperatorsTimeout++
+		schedulerOperatorCounter.WithLabelValues(op.Source(), "timeout").Inc()
+	}
+}
+
+// persist persists the statistics of the scheduler.
+func (r *schedulerStatsRegistry) persist(name string) {
+	r.Lock()
+	defer r.Unlock()
+	if entry, ok := r.stats[name]; ok {
+		r.persistLocked(entry)
+	}
+}
+
+func (r *schedulerStatsRegistry) persistLocked(entry *schedulerStatsEntry) {
+	data, err := json.Marshal(&entry.stats)
+	if err != nil {
+		log.Error("failed to marshal scheduler stats", zap.String("scheduler-name", entry.stats.Name), errs.ZapError(errs.ErrJSONMarshal, err))
+		return
+	}
+	if err := r.storage.SaveSchedulerStats(entry.stats.Name, data); err != nil {
+		log.Warn("failed to persist scheduler stats", zap.String("scheduler-name", entry.stats.Name), errs.ZapError(err))
+		return
+	}
+	entry.persistedAt = time.Now()
+}
+
+// get returns the statistics of the scheduler.
+func (r *schedulerStatsRegistry) get(name string) *SchedulerStats {
+	r.Lock()
+	defer r.Unlock()
+	entry, ok := r.stats[name]
+	if !ok {
+		return nil
+	}
+	stats := entry.stats
+	if stats.Runs > 0 {
+		stats.AvgRunDuration.Duration = stats.TotalRunDuration.Duration / time.Duration(stats.Runs)
+	}
+	return &stats
+}
@@ -0,0 +1,350 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+// The states of a store in the removal queue.
+const (
+	StoreRemovalPending  = "pending"
+	StoreRemovalRemoving = "removing"
+	StoreRemovalRemoved  = "removed"
+	StoreRemovalFailed   = "failed"
+)
+
+// storeRemovalHistoryTTL is how long the finished removals are kept in the
+// status of the queue.
+const storeRemovalHistoryTTL = 24 * time.Hour
+
+// StoreRemoval is the removal of a store in the queue.
+type StoreRemoval struct {
+	StoreID uint64 `json:"store_id"`
+	Force   bool   `json:"force"`
+	State   string `json:"state"`
+	// Blocked is the reason why the pending removal is not started.
+	Blocked     string     `json:"blocked,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	EnqueueTime time.Time  `json:"enqueue_time"`
+	StartTime   *time.Time `json:"start_time,omitempty"`
+	FinishTime  *time.Time `json:"finish_time,omitempty"`
+}
+
+// StoreRemovalQueueStatus is the status of the store removal queue.
+type StoreRemovalQueueStatus struct {
+	// Parallel is the max count of the stores removed at the same time.
+	Parallel int             `json:"parallel"`
+	Removals []*StoreRemoval `json:"removals"`
+}
+
+// storeRemovalQueueRecord is the persisted part of the queue, which is the
+// parallel and the unfinished removals in order. The states of the removals
+// are recomputed from the stores when the queue is loaded, and the blocked
+// reasons by the patrol, so the queue is only saved when its members change.
+type storeRemovalQueueRecord struct {
+	Parallel int                   `json:"parallel"`
+	Removals []*storeRemovalRecord `json:"removals"`
+}
+
+type storeRemovalRecord struct {
+	StoreID     uint64    `json:"store_id"`
+	Force       bool      `json:"force"`
+	EnqueueTime time.Time `json:"enqueue_time"`
+}
+
+// storeRemovalQueue removes the enqueued stores in order. At most parallel
+// stores are removed at the same time, and a store is only removed if the
+// other up stores have enough free space to hold its data besides the data of
+// the stores being removed, so that removing many stores doesn't run the
+// cluster out of space. The unfinished removals are persisted, so the queue
+// goes on after the leader changes, while the finished ones are only kept in
+// memory.
+type storeRemovalQueue struct {
+	sync.Mutex
+	cluster  *RaftCluster
+	parallel int
+	removals []*StoreRemoval
+	// persisted is the encoded record of the queue which is saved lately.
+	persisted []byte
+}
+
+func newStoreRemovalQueue(cluster *RaftCluster) *storeRemovalQueue {
+	return &storeRemovalQueue{
+		cluster:  cluster,
+		parallel: 1,
+	}
+}
+
+// load loads the unfinished removals of the queue. A removal is removing if
+// its store is not up any more, otherwise it's pending.
+func (q *storeRemovalQueue) load() error {
+	record := &storeRemovalQueueRecord{}
+	ok, err := q.cluster.storage.LoadStoreRemovalQueue(record)
+	if err != nil || !ok {
+		return err
+	}
+	q.Lock()
+	defer q.Unlock()
+	if record.Parallel > 0 {
+		q.parallel = record.Parallel
+	}
+	q.removals = make([]*StoreRemoval, 0, len(record.Removals))
+	for _, r := range record.Removals {
+		state := StoreRemovalPending
+		if store := q.cluster.GetStore(r.StoreID); store == nil || !store.IsUp() {
+			state = StoreRemovalRemoving
+		}
+		q.removals = append(q.removals, &StoreRemoval{
+			StoreID:     r.StoreID,
+			Force:       r.Force,
+			State:       state,
+			EnqueueTime: r.EnqueueTime,
+		})
+	}
+	q.persisted, err = json.Marshal(q.recordLocked())
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return nil
+}
+
+// enqueue adds the stores to the queue, and updates the parallel if it is
+// positive. No store is enqueued if any of them is invalid.
+func (q *storeRemovalQueue) enqueue(storeIDs []uint64, force bool, parallel int) error {
+	q.Lock()
+	defer q.Unlock()
+	queued := make(map[uint64]struct{}, len(storeIDs))
+	for _, removal := range q.removals {
+		if removal.State == StoreRemovalPending || removal.State == StoreRemovalRemoving {
+			queued[removal.StoreID] = struct{}{}
+		}
+	}
+	for _, storeID := range storeIDs {
+		store := q.cluster.GetStore(storeID)
+		if store == nil {
+			return errs.ErrStoreNotFound.FastGenByArgs(storeID)
+		}
+		if store.IsTombstone() {
+			return errs.ErrStoreTombstone.FastGenByArgs(storeID)
+		}
+		if _, ok := queued[storeID]; ok {
+			return errs.ErrStoreRemovalQueued.FastGenByArgs(storeID)
+		}
+		queued[storeID] = struct{}{}
+	}
+	oldParallel, oldLen := q.parallel, len(q.removals)
+	if parallel > 0 {
+		q.parallel = parallel
+	}
+	now := time.Now()
+	for _, storeID := range storeIDs {
+		q.removals = append(q.removals, &StoreRemoval{
+			StoreID:     storeID,
+			Force:       force,
+			State:       StoreRemovalPending,
+			EnqueueTime: now,
+		})
+	}
+	if err := q.persistLocked(); err != nil {
+		q.parallel, q.removals = oldParallel, q.removals[:oldLen]
+		return err
+	}
+	log.Info("stores are enqueued to be removed", zap.Uint64s("store-ids", storeIDs), zap.Bool("force", force), zap.Int("parallel", q.parallel))
+	q.patrolLocked(now)
+	return nil
+}
+
+// patrol updates the states of the removing stores, and starts to remove the
+// pending stores if possible.
+func (q *storeRemovalQueue) patrol(now time.Time) {
+	q.Lock()
+	defer q.Unlock()
+	q.patrolLocked(now)
+}
+
+func (q *storeRemovalQueue) patrolLocked(now time.Time) {
+	q.updateLocked(now)
+	if err := q.persistLocked(); err != nil {
+		log.Warn("failed to persist the store removal queue", errs.ZapError(err))
+	}
+}
+
+func (q *storeRemovalQueue) updateLocked(now time.Time) {
+	removals := q.removals[:0]
+	removing := 0
+	for _, removal := range q.removals {
+		if removal.State == StoreRemovalRemoving {
+			q.checkRemovingLocked(removal, now)
+		}
+		switch removal.State {
+		case StoreRemovalRemoving:
+			removing++
+		case StoreRemovalRemoved, StoreRemovalFailed:
+			if now.Sub(*removal.FinishTime) >= storeRemovalHistoryTTL {
+				continue
+			}
+		}
+		removals = append(removals, removal)
+	}
+	q.removals = removals
+
+	for _, removal := range q.removals {
+		if removing >= q.parallel {
+			return
+		}
+		if removal.State != StoreRemovalPending {
+			continue
+		}
+		// The stores are removed in order, so the later ones wait if the
+		// first pending one is blocked.
+		if removal.Blocked = q.checkHeadroomLocked(removal.StoreID); removal.Blocked != "" {
+			return
+		}
+		startTime := now
+		removal.StartTime = &startTime
+		if err := q.cluster.RemoveStore(removal.StoreID, removal.Force); err != nil {
+			log.Warn("failed to remove the store in the queue", zap.Uint64("store-id", removal.StoreID), errs.ZapError(err))
+			q.finishLocked(removal, StoreRemovalFailed, err.Error(), now)
+			continue
+		}
+		removal.State = StoreRemovalRemoving
+		removing++
+	}
+}
+
+func (q *storeRemovalQueue) checkRemovingLocked(removal *StoreRemoval, now time.Time) {
+	store := q.cluster.GetStore(removal.StoreID)
+	switch {
+	case store == nil || store.IsTombstone():
+		q.finishLocked(removal, StoreRemovalRemoved, "", now)
+	case store.IsUp():
+		q.finishLocked(removal, StoreRemovalFailed, "the store is set up again", now)
+	}
+}
+
+func (q *storeRemovalQueue) finishLocked(removal *StoreRemoval, state, err string, now time.Time) {
+	finishTime := now
+	removal.State, removal.Error, removal.FinishTime = state, err, &finishTime
+	log.Info("the removal of the store in the queue is finished", zap.Uint64("store-id", removal.StoreID), zap.String("state", state), zap.String("error", err))
+}
+
+// checkHeadroomLocked returns the reason if the up stores except the enqueued
+// ones don't have enough free space for the data of the store and the stores
+// being removed. The free space under the low space ratio is not counted.
+func (q *storeRemovalQueue) checkHeadroomLocked(storeID uint64) string {
+	excluded := make(map[uint64]struct{}, len(q.removals))
+	for _, removal := range q.removals {
+		if removal.State == StoreRemovalPending || removal.State == StoreRemovalRemoving {
+			excluded[removal.StoreID] = struct{}{}
+		}
+	}
+	lowSpaceRatio := q.cluster.GetOpts().GetLowSpaceRatio()
+	var required, headroom uint64
+	for _, store := range q.cluster.GetStores() {
+		_, isExcluded := excluded[store.GetID()]
+		switch {
+		case store.GetID() == storeID:
+			required += regionSizeInBytes(store)
+		case store.IsOffline():
+			// The data of the stores being removed is moving to the up stores.
+			required += regionSizeInBytes(store)
+		case store.IsUp() && !isExcluded:
+			headroom += storeHeadroom(store, lowSpaceRatio)
+		}
+	}
+	if headroom < required {
+		return fmt.Sprintf("the free space of the up stores %s is less than the data to move %s",
+			units.BytesSize(float64(headroom)), units.BytesSize(float64(required)))
+	}
+	return ""
+}
+
+func regionSizeInBytes(store *core.StoreInfo) uint64 {
+	return uint64(store.GetRegionSize()) * units.MiB
+}
+
+// storeHeadroom returns the free space of the store before it is low space.
+func storeHeadroom(store *core.StoreInfo, lowSpaceRatio float64) uint64 {
+	reserved := uint64(float64(store.GetEffectiveCapacity()) * (1 - lowSpaceRatio))
+	available := store.GetEffectiveAvailable()
+	if available <= reserved {
+		return 0
+	}
+	return available - reserved
+}
+
+// persistLocked saves the record of the queue to the storage if it is changed
+// since it is saved lately.
+func (q *storeRemovalQueue) persistLocked() error {
+	record := q.recordLocked()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	if bytes.Equal(data, q.persisted) {
+		return nil
+	}
+	if err := q.cluster.storage.SaveStoreRemovalQueue(record); err != nil {
+		return err
+	}
+	q.persisted = data
+	return nil
+}
+
+func (q *storeRemovalQueue) recordLocked() *storeRemovalQueueRecord {
+	record := &storeRemovalQueueRecord{
+		Parallel: q.parallel,
+		Removals: make([]*storeRemovalRecord, 0, len(q.removals)),
+	}
+	for _, removal := range q.removals {
+		if removal.State == StoreRemovalPending || removal.State == StoreRemovalRemoving {
+			record.Removals = append(record.Removals, &storeRemovalRecord{
+				StoreID:     removal.StoreID,
+				Force:       removal.Force,
+				EnqueueTime: removal.EnqueueTime,
+			})
+		}
+	}
+	return record
+}
+
+// getStatus returns the status of the queue.
+func (q *storeRemovalQueue) getStatus() *StoreRemovalQueueStatus {
+	q.Lock()
+	defer q.Unlock()
+	return q.statusLocked()
+}
+
+func (q *storeRemovalQueue) statusLocked() *StoreRemovalQueueStatus {
+	status := &StoreRemovalQueueStatus{
+		Parallel: q.parallel,
+		Removals: make([]*StoreRemoval, 0, len(q.removals)),
+	}
+	for _, removal := range q.removals {
+		r := *removal
+		status.Removals = append(status.Removals, &r)
+	}
+	return status
+}
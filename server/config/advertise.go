@@ -0,0 +1,91 @@
+// Copyright 2016 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// getInterfaceHost returns the host of the network interface to advertise,
+// the IPv4 address is preferred.
+func getInterfaceHost(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to get advertise-interface %q", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", errors.Annotatef(err, "failed to get the addresses of advertise-interface %q", name)
+	}
+	var host string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+		if host == "" {
+			host = ipNet.IP.String()
+		}
+	}
+	if host == "" {
+		return "", errors.Errorf("advertise-interface %q has no address to advertise", name)
+	}
+	return host, nil
+}
+
+// replaceUrlsHost replaces the hosts of the comma separated urls, and the ports
+// are kept.
+func replaceUrlsHost(urls, host string) (string, error) {
+	items := strings.Split(urls, ",")
+	for i, item := range items {
+		u, err := url.Parse(item)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if port := u.Port(); port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		} else {
+			u.Host = host
+		}
+		items[i] = u.String()
+	}
+	return strings.Join(items, ","), nil
+}
+
+// validateAdvertiseUrls checks the advertise urls are the addresses the other
+// members and the clients can connect to, e.g. 0.0.0.0 is only available to
+// listen on.
+func validateAdvertiseUrls(name, urls string) error {
+	for _, item := range strings.Split(urls, ",") {
+		u, err := url.Parse(item)
+		if err != nil {
+			return errors.Errorf("failed to parse %s %q, err: %v", name, item, err)
+		}
+		host := u.Hostname()
+		if host == "" {
+			return errors.Errorf("%s %q has no host", name, item)
+		}
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			return errors.Errorf("%s %q is an unspecified address, set it to the address the others can connect to, or use advertise-interface", name, item)
+		}
+	}
+	return nil
+}
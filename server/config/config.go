@@ -23,6 +23,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +33,7 @@ import (
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/metricutil"
+	"github.com/tikv/pd/pkg/traceutil"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core/storelimit"
 	"github.com/tikv/pd/server/versioninfo"
@@ -59,11 +61,26 @@ type Config struct {
 	PeerUrls            string `toml:"peer-urls" json:"peer-urls"`
 	AdvertiseClientUrls string `toml:"advertise-client-urls" json:"advertise-client-urls"`
 	AdvertisePeerUrls   string `toml:"advertise-peer-urls" json:"advertise-peer-urls"`
+	// AdvertiseInterface is the name of the network interface whose address is
+	// advertised with the ports of the listening urls, if the advertise urls
+	// are not set.
+	AdvertiseInterface string `toml:"advertise-interface" json:"advertise-interface"`
 
 	Name              string `toml:"name" json:"name"`
 	DataDir           string `toml:"data-dir" json:"data-dir"`
 	ForceNewCluster   bool   `json:"force-new-cluster"`
 	EnableGRPCGateway bool   `json:"enable-grpc-gateway"`
+	// EnableGRPCReflection enables the gRPC server reflection, so that the
+	// tools like grpcurl can list and call the gRPC services without the proto
+	// files.
+	EnableGRPCReflection bool `toml:"enable-grpc-reflection" json:"enable-grpc-reflection"`
+
+	// ForceNew checks the data in the data directory against the cluster to
+	// join, and archives the data which is stale or conflicts with the cluster.
+	ForceNew bool `json:"force-new"`
+	// InitWait makes the server report ready only after it has joined the cluster,
+	// loaded the cluster info and either become the leader or found a leader.
+	InitWait bool `json:"init-wait"`
 
 	InitialCluster      string `toml:"initial-cluster" json:"initial-cluster"`
 	InitialClusterState string `toml:"initial-cluster-state" json:"initial-cluster-state"`
@@ -100,8 +117,17 @@ type Config struct {
 	// to indicate which DC this PD belongs to.
 	EnableLocalTSO bool `toml:"enable-local-tso" json:"enable-local-tso"`
 
+	// TSOQuotas is the max count of the timestamps can be allocated per second
+	// for each dc-location, the key of the Global TSO is "global". The
+	// dc-locations without a quota are unlimited. The quota of a dc-location
+	// is shared fairly by the clients requesting it, and only limits the TSO
+	// requests of the clients.
+	TSOQuotas map[string]uint64 `toml:"tso-quotas" json:"tso-quotas,omitempty"`
+
 	Metric metricutil.MetricConfig `toml:"metric" json:"metric"`
 
+	Trace traceutil.TraceConfig `toml:"trace" json:"trace"`
+
 	Schedule ScheduleConfig `toml:"schedule" json:"schedule"`
 
 	Replication ReplicationConfig `toml:"replication" json:"replication"`
@@ -129,6 +155,11 @@ type Config struct {
 	// The default retention is 1 hour.
 	// Before etcd v3.3.x, the type of retention is int. We add 'v2' suffix to make it backward compatible.
 	AutoCompactionRetention string `toml:"auto-compaction-retention" json:"auto-compaction-retention-v2"`
+	// MaxTxnOps is the max number of operations in an etcd transaction. The default is 128.
+	MaxTxnOps uint64 `toml:"max-txn-ops" json:"max-txn-ops"`
+	// SnapshotCount is the number of the committed etcd transactions to trigger a
+	// snapshot to disk. The default is 100000.
+	SnapshotCount uint64 `toml:"snapshot-count" json:"snapshot-count"`
 
 	// TickInterval is the interval for etcd Raft tick.
 	TickInterval typeutil.Duration `toml:"tick-interval"`
@@ -163,6 +194,8 @@ type Config struct {
 	Dashboard DashboardConfig `toml:"dashboard" json:"dashboard"`
 
 	ReplicationMode ReplicationModeConfig `toml:"replication-mode" json:"replication-mode"`
+
+	DiskGuard DiskGuardConfig `toml:"disk-guard" json:"disk-guard"`
 }
 
 // NewConfig creates a new config.
@@ -183,6 +216,7 @@ func NewConfig() *Config {
 	fs.StringVar(&cfg.AdvertiseClientUrls, "advertise-client-urls", "", "advertise url for client traffic (default '${client-urls}')")
 	fs.StringVar(&cfg.PeerUrls, "peer-urls", defaultPeerUrls, "url for peer traffic")
 	fs.StringVar(&cfg.AdvertisePeerUrls, "advertise-peer-urls", "", "advertise url for peer traffic (default '${peer-urls}')")
+	fs.StringVar(&cfg.AdvertiseInterface, "advertise-interface", "", "network interface whose address is advertised if the advertise urls are not set")
 	fs.StringVar(&cfg.InitialCluster, "initial-cluster", "", "initial cluster configuration for bootstrapping, e,g. pd=http://127.0.0.1:2380")
 	fs.StringVar(&cfg.Join, "join", "", "join to an existing cluster (usage: cluster's '${advertise-client-urls}'")
 
@@ -194,17 +228,50 @@ func NewConfig() *Config {
 	fs.StringVar(&cfg.Security.CAPath, "cacert", "", "path of file that contains list of trusted TLS CAs")
 	fs.StringVar(&cfg.Security.CertPath, "cert", "", "path of file that contains X509 certificate in PEM format")
 	fs.StringVar(&cfg.Security.KeyPath, "key", "", "path of file that contains X509 key in PEM format")
+	fs.Var(stringSliceFlag{&cfg.PDServerCfg.AdminAllowedCN}, "admin-allowed-cn", "comma separated CNs of client certificates allowed to call mutating admin requests")
+	fs.Var(stringSliceFlag{&cfg.PDServerCfg.ClientAllowedCN}, "client-allowed-cn", "comma separated CNs of client certificates allowed to call mutating client requests")
 	fs.BoolVar(&cfg.ForceNewCluster, "force-new-cluster", false, "force to create a new one-member cluster")
+	fs.BoolVar(&cfg.ForceNew, "force-new", false, "archive the data which is stale or conflicts with the cluster to join")
+	fs.BoolVar(&cfg.InitWait, "init-wait", false, "report ready only after joining the cluster and confirming a leader exists")
 
 	return cfg
 }
 
+// stringSliceFlag parses a comma separated flag into a StringSlice.
+type stringSliceFlag struct {
+	s *typeutil.StringSlice
+}
+
+// String implements flag.Value.
+func (f stringSliceFlag) String() string {
+	if f.s == nil {
+		return ""
+	}
+	return strings.Join(*f.s, ",")
+}
+
+// Set implements flag.Value.
+func (f stringSliceFlag) Set(value string) error {
+	if len(value) == 0 {
+		*f.s = []string{}
+		return nil
+	}
+	*f.s = strings.Split(value, ",")
+	return nil
+}
+
 const (
 	defaultLeaderLease             = int64(3)
 	defaultNextRetryDelay          = time.Second
 	defaultCompactionMode          = "periodic"
 	defaultAutoCompactionRetention = "1h"
 	defaultQuotaBackendBytes       = typeutil.ByteSize(8 * 1024 * 1024 * 1024) // 8GB
+	defaultMaxTxnOps               = uint64(128)
+	defaultSnapshotCount           = uint64(100000)
+
+	defaultDiskGuardWarningSpace  = typeutil.ByteSize(5 * 1024 * 1024 * 1024) // 5GB
+	defaultDiskGuardCriticalSpace = typeutil.ByteSize(1024 * 1024 * 1024)     // 1GB
+	defaultDiskGuardCheckInterval = 10 * time.Second
 
 	defaultName                = "pd"
 	defaultClientUrls          = "http://127.0.0.1:2379"
@@ -222,6 +289,8 @@ const (
 
 	defaultMetricsPushInterval = 15 * time.Second
 
+	defaultTraceSamplingRate = 0.01
+
 	defaultHeartbeatStreamRebindInterval = time.Minute
 
 	defaultLeaderPriorityCheckInterval = time.Minute
@@ -232,6 +301,17 @@ const (
 	defaultMaxResetTSGap    = 24 * time.Hour
 	defaultKeyType          = "table"
 
+	defaultSlowGRPCRequestThreshold = time.Second
+	// defaultLeaderFitnessTransferThreshold is the fitness score difference
+	// which triggers the leader transfer, e.g. the leader whose peer latency
+	// is 20ms is not fit compared to the member whose latency is 1ms.
+	defaultLeaderFitnessTransferThreshold = 50
+	defaultLeaderFitnessTransferDuration  = 5 * time.Minute
+	defaultLeaderFitnessTransferCooldown  = 30 * time.Minute
+	// defaultHeartbeatQuarantineEpochGap is far larger than the epoch changes
+	// between two heartbeats of a healthy region.
+	defaultHeartbeatQuarantineEpochGap = 1000
+
 	defaultStrictlyMatchLabel   = false
 	defaultEnablePlacementRules = true
 	defaultEnableGRPCGateway    = true
@@ -248,6 +328,11 @@ const (
 	DefaultTSOUpdatePhysicalInterval = 50 * time.Millisecond
 	maxTSOUpdatePhysicalInterval     = 10 * time.Second
 	minTSOUpdatePhysicalInterval     = 50 * time.Millisecond
+
+	// Too many operations in a transaction block the etcd raft for a long time.
+	maxEtcdMaxTxnOps = 10240
+	// Too frequent snapshots slow down the etcd.
+	minEtcdSnapshotCount = 1000
 )
 
 // Special keys for Labels
@@ -363,7 +448,10 @@ func adjustPath(p *string) {
 	}
 }
 
-// Parse parses flag definitions from the argument list.
+// Parse parses flag definitions from the argument list. The config items are
+// also loaded from the config file and the environment variables, and the
+// precedence is: flags > environment variables > config file > defaults. See
+// EnvName for the names of the environment variables.
 func (c *Config) Parse(arguments []string) error {
 	// Parse first to get config file.
 	err := c.flagSet.Parse(arguments)
@@ -404,6 +492,16 @@ func (c *Config) Parse(arguments []string) error {
 		}
 	}
 
+	// Load the environment variables, which take precedence over the config
+	// file.
+	envMeta, err := c.configFromEnv(os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	if envMeta != nil {
+		meta = envMeta
+	}
+
 	// Parse again to replace with command line options.
 	err = c.flagSet.Parse(arguments)
 	if err != nil {
@@ -442,6 +540,33 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validateEtcdConfig checks the options of the embedded etcd, it should be called
+// after the options are adjusted.
+func (c *Config) validateEtcdConfig() error {
+	switch c.AutoCompactionMode {
+	case embed.CompactorModePeriodic:
+		// The retention is a duration, or an integer in hours.
+		if _, err := time.ParseDuration(c.AutoCompactionRetention); err != nil {
+			if h, err := strconv.Atoi(c.AutoCompactionRetention); err != nil || h < 0 {
+				return errors.Errorf("invalid auto-compaction-retention %q for periodic mode", c.AutoCompactionRetention)
+			}
+		}
+	case embed.CompactorModeRevision:
+		if r, err := strconv.ParseInt(c.AutoCompactionRetention, 10, 64); err != nil || r < 0 {
+			return errors.Errorf("invalid auto-compaction-retention %q for revision mode", c.AutoCompactionRetention)
+		}
+	default:
+		return errors.Errorf("invalid auto-compaction-mode %q, it should be %q or %q", c.AutoCompactionMode, embed.CompactorModePeriodic, embed.CompactorModeRevision)
+	}
+	if c.MaxTxnOps > maxEtcdMaxTxnOps {
+		return errors.Errorf("max-txn-ops should not be larger than %d", maxEtcdMaxTxnOps)
+	}
+	if c.SnapshotCount < minEtcdSnapshotCount {
+		return errors.Errorf("snapshot-count should not be less than %d", minEtcdSnapshotCount)
+	}
+	return nil
+}
+
 // Utility to test if a configuration is defined.
 type configMetaData struct {
 	meta *toml.MetaData
@@ -507,10 +632,33 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 	}
 
 	adjustString(&c.ClientUrls, defaultClientUrls)
-	adjustString(&c.AdvertiseClientUrls, c.ClientUrls)
 	adjustString(&c.PeerUrls, defaultPeerUrls)
+	if len(c.AdvertiseInterface) > 0 && (len(c.AdvertiseClientUrls) == 0 || len(c.AdvertisePeerUrls) == 0) {
+		host, err := getInterfaceHost(c.AdvertiseInterface)
+		if err != nil {
+			return err
+		}
+		if len(c.AdvertiseClientUrls) == 0 {
+			if c.AdvertiseClientUrls, err = replaceUrlsHost(c.ClientUrls, host); err != nil {
+				return err
+			}
+		}
+		if len(c.AdvertisePeerUrls) == 0 {
+			if c.AdvertisePeerUrls, err = replaceUrlsHost(c.PeerUrls, host); err != nil {
+				return err
+			}
+		}
+	}
+	adjustString(&c.AdvertiseClientUrls, c.ClientUrls)
 	adjustString(&c.AdvertisePeerUrls, c.PeerUrls)
+	if err := validateAdvertiseUrls("advertise-client-urls", c.AdvertiseClientUrls); err != nil {
+		return err
+	}
+	if err := validateAdvertiseUrls("advertise-peer-urls", c.AdvertisePeerUrls); err != nil {
+		return err
+	}
 	adjustDuration(&c.Metric.PushInterval, defaultMetricsPushInterval)
+	adjustDuration(&c.Metric.StatsD.Interval, defaultMetricsPushInterval)
 
 	if len(c.InitialCluster) == 0 {
 		// The advertise peer urls may be http://127.0.0.1:2380,http://127.0.0.1:2381
@@ -558,6 +706,11 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 	if !configMetaData.IsDefined("quota-backend-bytes") {
 		c.QuotaBackendBytes = defaultQuotaBackendBytes
 	}
+	adjustUint64(&c.MaxTxnOps, defaultMaxTxnOps)
+	adjustUint64(&c.SnapshotCount, defaultSnapshotCount)
+	if err := c.validateEtcdConfig(); err != nil {
+		return err
+	}
 	adjustDuration(&c.TickInterval, defaultTickInterval)
 	adjustDuration(&c.ElectionInterval, defaultElectionInterval)
 
@@ -575,6 +728,9 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 	}
 
 	c.adjustLog(configMetaData.Child("log"))
+	if err := c.adjustTrace(configMetaData.Child("trace")); err != nil {
+		return err
+	}
 	adjustDuration(&c.HeartbeatStreamBindInterval, defaultHeartbeatStreamRebindInterval)
 
 	adjustDuration(&c.LeaderPriorityCheckInterval, defaultLeaderPriorityCheckInterval)
@@ -590,6 +746,13 @@ func (c *Config) Adjust(meta *toml.MetaData, reloading bool) error {
 
 	c.ReplicationMode.adjust(configMetaData.Child("replication-mode"))
 
+	if err := c.DiskGuard.adjust(configMetaData.Child("disk-guard")); err != nil {
+		return err
+	}
+
+	if err := c.Security.adjust(); err != nil {
+		return err
+	}
 	c.Security.Encryption.Adjust()
 
 	return nil
@@ -601,6 +764,16 @@ func (c *Config) adjustLog(meta *configMetaData) {
 	}
 }
 
+func (c *Config) adjustTrace(meta *configMetaData) error {
+	if !meta.IsDefined("sampling-rate") {
+		c.Trace.SamplingRate = defaultTraceSamplingRate
+	}
+	if c.Trace.SamplingRate < 0 || c.Trace.SamplingRate > 1 {
+		return errors.Errorf("trace sampling-rate should be in [0, 1], but got %v", c.Trace.SamplingRate)
+	}
+	return nil
+}
+
 // Clone returns a cloned configuration.
 func (c *Config) Clone() *Config {
 	cfg := *c
@@ -627,6 +800,9 @@ type ScheduleConfig struct {
 	// it will never be used as a source or target store.
 	MaxSnapshotCount    uint64 `toml:"max-snapshot-count" json:"max-snapshot-count"`
 	MaxPendingPeerCount uint64 `toml:"max-pending-peer-count" json:"max-pending-peer-count"`
+	// If a store keeps applying snapshots for longer than this value, it is
+	// considered slow and will not be used as a target store. 0 means disabled.
+	MaxSnapshotApplyTime typeutil.Duration `toml:"max-snapshot-apply-time" json:"max-snapshot-apply-time"`
 	// If both the size of region is smaller than MaxMergeRegionSize
 	// and the number of rows in region is smaller than MaxMergeRegionKeys,
 	// it will try to merge with adjacent regions.
@@ -634,13 +810,32 @@ type ScheduleConfig struct {
 	MaxMergeRegionKeys uint64 `toml:"max-merge-region-keys" json:"max-merge-region-keys"`
 	// SplitMergeInterval is the minimum interval time to permit merge after split.
 	SplitMergeInterval typeutil.Duration `toml:"split-merge-interval" json:"split-merge-interval"`
+	// RegionMoveCooldown is the minimum interval time to permit the schedulers
+	// to move a region again after it is moved. The operators to repair the
+	// replicas are not limited. 0 means disabled.
+	RegionMoveCooldown typeutil.Duration `toml:"region-move-cooldown" json:"region-move-cooldown"`
 	// EnableOneWayMerge is the option to enable one way merge. This means a Region can only be merged into the next region of it.
 	EnableOneWayMerge bool `toml:"enable-one-way-merge" json:"enable-one-way-merge,string"`
 	// EnableCrossTableMerge is the option to enable cross table merge. This means two Regions can be merged with different table IDs.
 	// This option only works when key type is "table".
 	EnableCrossTableMerge bool `toml:"enable-cross-table-merge" json:"enable-cross-table-merge,string"`
+	// EnableScatterAfterSplit is the option to scatter the regions created by
+	// the split requests automatically, it can be overridden by each request
+	// of the HTTP API.
+	EnableScatterAfterSplit bool `toml:"enable-scatter-after-split" json:"enable-scatter-after-split,string"`
+	// EnableHotBucketSplit is the experimental option to split the hot regions
+	// at the boundaries of their hot buckets, which are reported by the
+	// experimental POST /regions/buckets API until the region heartbeats carry
+	// the buckets.
+	EnableHotBucketSplit bool `toml:"enable-hot-bucket-split" json:"enable-hot-bucket-split,string"`
 	// PatrolRegionInterval is the interval for scanning region during patrol.
 	PatrolRegionInterval typeutil.Duration `toml:"patrol-region-interval" json:"patrol-region-interval"`
+	// PatrolRegionBudget is the max time spent on checking regions in one patrol round.
+	// The unchecked regions are left to the next rounds.
+	PatrolRegionBudget typeutil.Duration `toml:"patrol-region-budget" json:"patrol-region-budget"`
+	// ScheduleTimeBudget is the max time spent in the retries of one schedule.
+	// The scheduler gives up the rest retries once it runs out of the budget.
+	ScheduleTimeBudget typeutil.Duration `toml:"schedule-time-budget" json:"schedule-time-budget"`
 	// MaxStoreDownTime is the max duration after which
 	// a store will be considered to be down if it hasn't reported heartbeats.
 	MaxStoreDownTime typeutil.Duration `toml:"max-store-down-time" json:"max-store-down-time"`
@@ -648,6 +843,16 @@ type ScheduleConfig struct {
 	LeaderScheduleLimit uint64 `toml:"leader-schedule-limit" json:"leader-schedule-limit"`
 	// LeaderSchedulePolicy is the option to balance leader, there are some policies supported: ["count", "size"], default: "count"
 	LeaderSchedulePolicy string `toml:"leader-schedule-policy" json:"leader-schedule-policy"`
+	// RollingRestartLeaderScheduleLimit is the leader schedule limit during the
+	// rolling restart of the stores, which speeds up moving the leaders out of
+	// the stores to be restarted and back to the restarted stores. The rolling
+	// restart is detected when more than one store restarts within
+	// RollingRestartWindow or a store is annotated to restart. 0 means the
+	// limit is not raised.
+	RollingRestartLeaderScheduleLimit uint64 `toml:"rolling-restart-leader-schedule-limit" json:"rolling-restart-leader-schedule-limit"`
+	// RollingRestartWindow is the window to detect the rolling restart, and the
+	// rolling restart is considered as finished if no store restarts within it.
+	RollingRestartWindow typeutil.Duration `toml:"rolling-restart-window" json:"rolling-restart-window"`
 	// RegionScheduleLimit is the max coexist region schedules.
 	RegionScheduleLimit uint64 `toml:"region-schedule-limit" json:"region-schedule-limit"`
 	// ReplicaScheduleLimit is the max coexist replica schedules.
@@ -665,7 +870,16 @@ type ScheduleConfig struct {
 	StoreBalanceRate float64 `toml:"store-balance-rate" json:"store-balance-rate,omitempty"`
 	// StoreLimit is the limit of scheduling for stores.
 	StoreLimit map[uint64]StoreLimitConfig `toml:"store-limit" json:"store-limit"`
-	// TolerantSizeRatio is the ratio of buffer size for balance scheduler.
+	// StoreReservedSpace is the space of each store reserved for the processes
+	// other than TiKV, which is excluded from the capacity and the available
+	// size of the store when making the placement decisions. The space used by
+	// the other processes does not affect the scheduling unless it exceeds the
+	// reserved space. 0 means disabled.
+	StoreReservedSpace typeutil.ByteSize `toml:"store-reserved-space" json:"store-reserved-space"`
+	// StoreReservedSpaceOverrides overrides StoreReservedSpace for the stores.
+	StoreReservedSpaceOverrides map[uint64]typeutil.ByteSize `toml:"store-reserved-space-overrides" json:"store-reserved-space-overrides"`
+	// TolerantSizeRatio is the ratio of buffer size for balance scheduler. 0 means
+	// the ratio is adaptive to the cluster size, the region size and the scheduling speed.
 	TolerantSizeRatio float64 `toml:"tolerant-size-ratio" json:"tolerant-size-ratio"`
 	//
 	//      high space stage         transition stage           low space stage
@@ -735,6 +949,10 @@ type ScheduleConfig struct {
 	// is overwritten, the value is fixed until it is deleted.
 	// Default: manual
 	StoreLimitMode string `toml:"store-limit-mode" json:"store-limit-mode"`
+
+	// Profile is the name of the schedule profile applied last time. The
+	// items may be changed individually after the profile is applied.
+	Profile string `toml:"profile" json:"profile,omitempty"`
 }
 
 // Clone returns a cloned scheduling configuration.
@@ -747,8 +965,16 @@ func (c *ScheduleConfig) Clone() *ScheduleConfig {
 			storeLimit[k] = v
 		}
 	}
+	var reservedSpaceOverrides map[uint64]typeutil.ByteSize
+	if c.StoreReservedSpaceOverrides != nil {
+		reservedSpaceOverrides = make(map[uint64]typeutil.ByteSize, len(c.StoreReservedSpaceOverrides))
+		for k, v := range c.StoreReservedSpaceOverrides {
+			reservedSpaceOverrides[k] = v
+		}
+	}
 	cfg := *c
 	cfg.StoreLimit = storeLimit
+	cfg.StoreReservedSpaceOverrides = reservedSpaceOverrides
 	cfg.Schedulers = schedulers
 	cfg.SchedulersPayload = nil
 	return &cfg
@@ -762,7 +988,10 @@ const (
 	defaultMaxMergeRegionKeys        = 200000
 	defaultSplitMergeInterval        = 1 * time.Hour
 	defaultPatrolRegionInterval      = 100 * time.Millisecond
+	defaultPatrolRegionBudget        = 50 * time.Millisecond
+	defaultScheduleTimeBudget        = 200 * time.Millisecond
 	defaultMaxStoreDownTime          = 30 * time.Minute
+	defaultRollingRestartWindow      = 10 * time.Minute
 	defaultLeaderScheduleLimit       = 4
 	defaultRegionScheduleLimit       = 2048
 	defaultReplicaScheduleLimit      = 64
@@ -797,7 +1026,10 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 	}
 	adjustDuration(&c.SplitMergeInterval, defaultSplitMergeInterval)
 	adjustDuration(&c.PatrolRegionInterval, defaultPatrolRegionInterval)
+	adjustDuration(&c.PatrolRegionBudget, defaultPatrolRegionBudget)
+	adjustDuration(&c.ScheduleTimeBudget, defaultScheduleTimeBudget)
 	adjustDuration(&c.MaxStoreDownTime, defaultMaxStoreDownTime)
+	adjustDuration(&c.RollingRestartWindow, defaultRollingRestartWindow)
 	if !meta.IsDefined("leader-schedule-limit") {
 		adjustUint64(&c.LeaderScheduleLimit, defaultLeaderScheduleLimit)
 	}
@@ -861,6 +1093,10 @@ func (c *ScheduleConfig) adjust(meta *configMetaData, reloading bool) error {
 		c.StoreLimit = make(map[uint64]StoreLimitConfig)
 	}
 
+	if c.StoreReservedSpaceOverrides == nil {
+		c.StoreReservedSpaceOverrides = make(map[uint64]typeutil.ByteSize)
+	}
+
 	return c.Validate()
 }
 
@@ -922,6 +1158,12 @@ func (c *ScheduleConfig) Validate() error {
 	if c.LowSpaceRatio <= c.HighSpaceRatio {
 		return errors.New("low-space-ratio should be larger than high-space-ratio")
 	}
+	if c.PatrolRegionBudget.Duration <= 0 {
+		return errors.New("patrol-region-budget should be positive")
+	}
+	if c.ScheduleTimeBudget.Duration <= 0 {
+		return errors.New("schedule-time-budget should be positive")
+	}
 	for _, scheduleConfig := range c.Schedulers {
 		if !IsSchedulerRegistered(scheduleConfig.Type) {
 			return errors.Errorf("create func of %v is not registered, maybe misspelled", scheduleConfig.Type)
@@ -1017,6 +1259,13 @@ type ReplicationConfig struct {
 	// Even if a zone is down, PD will not try to make up replicas in other zone
 	// because other zones already have replicas on it.
 	IsolationLevel string `toml:"isolation-level" json:"isolation-level"`
+
+	// SnapshotLocalityLabel is the label key of the failure domain, e.g. "zone".
+	// If it's not empty, the stores in the failure domains which already have a
+	// healthy replica are preferred to place the new peers, so that the snapshots
+	// are less likely to be transferred across the failure domains.
+	// It works with both the replica checker and the placement rules.
+	SnapshotLocalityLabel string `toml:"snapshot-locality-label" json:"snapshot-locality-label"`
 }
 
 // Clone makes a deep copy of the config.
@@ -1043,6 +1292,9 @@ func (c *ReplicationConfig) Validate() error {
 	if c.IsolationLevel != "" && !foundIsolationLevel {
 		return errors.New("isolation-level must be one of location-labels or empty")
 	}
+	if c.SnapshotLocalityLabel != "" {
+		return ValidateLabels([]*metapb.StoreLabel{{Key: c.SnapshotLocalityLabel}})
+	}
 	return nil
 }
 
@@ -1079,8 +1331,71 @@ type PDServerConfig struct {
 	// TraceRegionFlow the option to update flow information of regions.
 	// WARN: TraceRegionFlow is deprecated.
 	TraceRegionFlow bool `toml:"trace-region-flow" json:"trace-region-flow,string,omitempty"`
-	// FlowRoundByDigit used to discretization processing flow information.
+	// FlowRoundByDigit used to discretization processing flow information. The
+	// region info is not updated if only the rounded off part of the flow changes.
 	FlowRoundByDigit int `toml:"flow-round-by-digit" json:"flow-round-by-digit"`
+	// AdminAllowedCN is the CNs of the client certificates which are allowed to call
+	// the mutating admin requests, e.g. the HTTP API and bootstrap. Empty means no limit.
+	AdminAllowedCN typeutil.StringSlice `toml:"admin-allowed-cn" json:"admin-allowed-cn"`
+	// ClientAllowedCN is the CNs of the client certificates which are allowed to call
+	// the mutating client requests, e.g. heartbeats and split. Empty means no limit.
+	ClientAllowedCN typeutil.StringSlice `toml:"client-allowed-cn" json:"client-allowed-cn"`
+	// MaxScanRegionsLimit is the max number of regions returned by a ScanRegions
+	// request, the response is truncated if there are more. 0 means no limit,
+	// which is the default, as the clients not aware of the truncation would
+	// take a truncated response as the complete one.
+	MaxScanRegionsLimit int `toml:"max-scan-regions-limit" json:"max-scan-regions-limit"`
+	// MaxResponseSize is the max approximate size of a ScanRegions or GetAllStores
+	// response, the response is truncated if it is larger, and the PD clients
+	// continue from where it is truncated. 0 means no limit.
+	MaxResponseSize typeutil.ByteSize `toml:"max-response-size" json:"max-response-size"`
+	// SlowGRPCRequestThreshold is the duration beyond which a gRPC request is
+	// logged as a slow request. 0 means disabled.
+	SlowGRPCRequestThreshold typeutil.Duration `toml:"slow-grpc-request-threshold" json:"slow-grpc-request-threshold"`
+	// GRPCRequestDeadlines is the max processing duration of the gRPC requests
+	// of each method, which overrides the default ones. 0 means no deadline.
+	GRPCRequestDeadlines map[string]typeutil.Duration `toml:"grpc-request-deadlines" json:"grpc-request-deadlines,omitempty"`
+	// EnableLeaderFitnessTransfer enables to transfer the leadership to a member
+	// whose leader fitness is better than the leader's by
+	// LeaderFitnessTransferThreshold for LeaderFitnessTransferDuration, and whose
+	// leader priority is not lower than the leader's. No transfer happens within
+	// LeaderFitnessTransferCooldown after the leader changes. If it is enabled,
+	// each member probes the other members and writes a key to etcd every 10s
+	// to check its fitness.
+	EnableLeaderFitnessTransfer    bool              `toml:"enable-leader-fitness-transfer" json:"enable-leader-fitness-transfer,string"`
+	LeaderFitnessTransferThreshold float64           `toml:"leader-fitness-transfer-threshold" json:"leader-fitness-transfer-threshold"`
+	LeaderFitnessTransferDuration  typeutil.Duration `toml:"leader-fitness-transfer-duration" json:"leader-fitness-transfer-duration"`
+	LeaderFitnessTransferCooldown  typeutil.Duration `toml:"leader-fitness-transfer-cooldown" json:"leader-fitness-transfer-cooldown"`
+	// EnableHeartbeatQuarantine enables to quarantine the region heartbeat
+	// whose epoch is ahead of PD's record by more than HeartbeatQuarantineEpochGap
+	// along with a radically different peer set, which may be reported by a
+	// misconfigured store or a replay. The quarantined heartbeat does not
+	// update the routing until it is confirmed manually.
+	EnableHeartbeatQuarantine   bool   `toml:"enable-heartbeat-quarantine" json:"enable-heartbeat-quarantine,string"`
+	HeartbeatQuarantineEpochGap uint64 `toml:"heartbeat-quarantine-epoch-gap" json:"heartbeat-quarantine-epoch-gap"`
+	// EnableTombstonePurgeAck enables to track whether the data of a tombstone
+	// store is safe to be destroyed, which is after its node confirms that it
+	// has been notified of the tombstone and stopped.
+	EnableTombstonePurgeAck bool `toml:"enable-tombstone-purge-ack" json:"enable-tombstone-purge-ack,string"`
+}
+
+// defaultGRPCRequestDeadlines is the max processing duration of the gRPC
+// requests which may take a long time. The other requests have no deadline
+// unless it is configured.
+var defaultGRPCRequestDeadlines = map[string]time.Duration{
+	"GetAllStores":  10 * time.Second,
+	"ScanRegions":   10 * time.Second,
+	"ScatterRegion": 30 * time.Second,
+	"SplitRegions":  time.Minute,
+}
+
+// GetGRPCRequestDeadline returns the max processing duration of the gRPC
+// requests of the method.
+func (c *PDServerConfig) GetGRPCRequestDeadline(method string) time.Duration {
+	if d, ok := c.GRPCRequestDeadlines[method]; ok {
+		return d.Duration
+	}
+	return defaultGRPCRequestDeadlines[method]
 }
 
 func (c *PDServerConfig) adjust(meta *configMetaData) error {
@@ -1103,6 +1418,15 @@ func (c *PDServerConfig) adjust(meta *configMetaData) error {
 	if !meta.IsDefined("flow-round-by-digit") {
 		adjustInt(&c.FlowRoundByDigit, defaultFlowRoundByDigit)
 	}
+	if !meta.IsDefined("slow-grpc-request-threshold") {
+		adjustDuration(&c.SlowGRPCRequestThreshold, defaultSlowGRPCRequestThreshold)
+	}
+	if !meta.IsDefined("leader-fitness-transfer-threshold") {
+		adjustFloat64(&c.LeaderFitnessTransferThreshold, defaultLeaderFitnessTransferThreshold)
+	}
+	adjustDuration(&c.LeaderFitnessTransferDuration, defaultLeaderFitnessTransferDuration)
+	adjustDuration(&c.LeaderFitnessTransferCooldown, defaultLeaderFitnessTransferCooldown)
+	adjustUint64(&c.HeartbeatQuarantineEpochGap, defaultHeartbeatQuarantineEpochGap)
 	c.migrateConfigurationFromFile(meta)
 	return c.Validate()
 }
@@ -1135,8 +1459,18 @@ func (c *PDServerConfig) MigrateDeprecatedFlags() {
 // Clone returns a cloned PD server config.
 func (c *PDServerConfig) Clone() *PDServerConfig {
 	runtimeServices := append(c.RuntimeServices[:0:0], c.RuntimeServices...)
+	adminAllowedCN := append(c.AdminAllowedCN[:0:0], c.AdminAllowedCN...)
+	clientAllowedCN := append(c.ClientAllowedCN[:0:0], c.ClientAllowedCN...)
 	cfg := *c
 	cfg.RuntimeServices = runtimeServices
+	cfg.AdminAllowedCN = adminAllowedCN
+	cfg.ClientAllowedCN = clientAllowedCN
+	if c.GRPCRequestDeadlines != nil {
+		cfg.GRPCRequestDeadlines = make(map[string]typeutil.Duration, len(c.GRPCRequestDeadlines))
+		for method, d := range c.GRPCRequestDeadlines {
+			cfg.GRPCRequestDeadlines[method] = d
+		}
+	}
 	return &cfg
 }
 
@@ -1153,6 +1487,20 @@ func (c *PDServerConfig) Validate() error {
 	if c.FlowRoundByDigit < 0 {
 		return errs.ErrConfigItem.GenWithStack("flow round by digit cannot be negative number")
 	}
+	if c.MaxScanRegionsLimit < 0 {
+		return errs.ErrConfigItem.GenWithStack("max scan regions limit cannot be negative number")
+	}
+	if c.SlowGRPCRequestThreshold.Duration < 0 {
+		return errs.ErrConfigItem.GenWithStack("slow grpc request threshold cannot be negative")
+	}
+	for method, d := range c.GRPCRequestDeadlines {
+		if d.Duration < 0 {
+			return errs.ErrConfigItem.GenWithStack("the grpc request deadline of %s cannot be negative", method)
+		}
+	}
+	if c.LeaderFitnessTransferThreshold < 0 || c.LeaderFitnessTransferThreshold > 100 {
+		return errs.ErrConfigItem.GenWithStack("leader fitness transfer threshold should be between 0 and 100")
+	}
 
 	return nil
 }
@@ -1202,7 +1550,11 @@ func (c *Config) SetupLogger() error {
 	}
 	c.logger = lg
 	c.logProps = p
-	logutil.SetRedactLog(c.Security.RedactInfoLog)
+	if c.Security.RedactInfoLogMode == "" {
+		logutil.SetRedactLog(c.Security.RedactInfoLog)
+	} else {
+		logutil.SetRedactMode(logutil.RedactMode(c.Security.RedactInfoLogMode))
+	}
 	return nil
 }
 
@@ -1265,6 +1617,8 @@ func (c *Config) GenEmbedEtcdConfig() (*embed.Config, error) {
 	cfg.AutoCompactionMode = c.AutoCompactionMode
 	cfg.AutoCompactionRetention = c.AutoCompactionRetention
 	cfg.QuotaBackendBytes = int64(c.QuotaBackendBytes)
+	cfg.MaxTxnOps = uint(c.MaxTxnOps)
+	cfg.SnapshotCount = c.SnapshotCount
 
 	allowedCN, serr := c.Security.GetOneAllowedCN()
 	if serr != nil {
@@ -1400,10 +1754,60 @@ func (c *DRAutoSyncReplicationConfig) adjust(meta *configMetaData) {
 	}
 }
 
+// DiskGuardConfig is the configuration of the guard of the free space of the
+// data dir, which is shared by the etcd data, the etcd WAL and the region
+// storage.
+type DiskGuardConfig struct {
+	// WarningSpace is the threshold of the free space to raise the warning
+	// alarm, the non-critical writes are refused below it, e.g. persisting
+	// the statistics. 0 disables the threshold.
+	WarningSpace typeutil.ByteSize `toml:"warning-space" json:"warning-space"`
+	// CriticalSpace is the threshold of the free space to raise the critical
+	// alarm, the region storage writes are refused below it as well, so that
+	// the space is kept for etcd. 0 disables the threshold.
+	CriticalSpace typeutil.ByteSize `toml:"critical-space" json:"critical-space"`
+	// CheckInterval is the interval to check the free space.
+	CheckInterval typeutil.Duration `toml:"check-interval" json:"check-interval"`
+}
+
+func (c *DiskGuardConfig) adjust(meta *configMetaData) error {
+	if !meta.IsDefined("warning-space") {
+		c.WarningSpace = defaultDiskGuardWarningSpace
+	}
+	if !meta.IsDefined("critical-space") {
+		c.CriticalSpace = defaultDiskGuardCriticalSpace
+	}
+	adjustDuration(&c.CheckInterval, defaultDiskGuardCheckInterval)
+	if c.WarningSpace > 0 && c.CriticalSpace > c.WarningSpace {
+		return errors.Errorf("disk-guard.critical-space %d should not be larger than disk-guard.warning-space %d", c.CriticalSpace, c.WarningSpace)
+	}
+	return nil
+}
+
 // SecurityConfig indicates the security configuration for pd server
 type SecurityConfig struct {
 	grpcutil.TLSConfig
 	// RedactInfoLog indicates that whether enabling redact log
-	RedactInfoLog bool              `toml:"redact-info-log" json:"redact-info-log"`
-	Encryption    encryption.Config `toml:"encryption" json:"encryption"`
+	RedactInfoLog bool `toml:"redact-info-log" json:"redact-info-log"`
+	// RedactInfoLogMode is the mode to redact the user data in the log, it
+	// can be "off", "on", "mark" or "hash". It overrides RedactInfoLog if set.
+	RedactInfoLogMode string            `toml:"redact-info-log-mode" json:"redact-info-log-mode"`
+	Encryption        encryption.Config `toml:"encryption" json:"encryption"`
+}
+
+func (c *SecurityConfig) adjust() error {
+	if c.RedactInfoLogMode == "" {
+		if c.RedactInfoLog {
+			c.RedactInfoLogMode = string(logutil.RedactModeOn)
+		} else {
+			c.RedactInfoLogMode = string(logutil.RedactModeOff)
+		}
+		return nil
+	}
+	mode, err := logutil.ParseRedactMode(c.RedactInfoLogMode)
+	if err != nil {
+		return err
+	}
+	c.RedactInfoLogMode = string(mode)
+	return nil
 }
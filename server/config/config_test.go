@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net"
 	"os"
 	"path"
 	"strings"
@@ -25,6 +26,8 @@ import (
 
 	"github.com/BurntSushi/toml"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/kv"
 )
@@ -48,6 +51,36 @@ func (s *testConfigSuite) SetUpSuite(c *C) {
 func (s *testConfigSuite) TestSecurity(c *C) {
 	cfg := NewConfig()
 	c.Assert(cfg.Security.RedactInfoLog, Equals, false)
+
+	// The mode is adjusted from redact-info-log if it is not set.
+	cfgData := `
+[security]
+redact-info-log = true
+`
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Security.RedactInfoLogMode, Equals, "on")
+
+	cfg = NewConfig()
+	cfgData = `
+[security]
+redact-info-log = true
+redact-info-log-mode = "Mark"
+`
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Security.RedactInfoLogMode, Equals, "mark")
+
+	cfg = NewConfig()
+	cfgData = `
+[security]
+redact-info-log-mode = "foo"
+`
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), NotNil)
 }
 
 func (s *testConfigSuite) TestTLS(c *C) {
@@ -155,6 +188,14 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.Validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.Validate(), NotNil)
+	cfg.Schedule.TolerantSizeRatio = 0
+	c.Assert(cfg.Schedule.ScheduleTimeBudget.Duration, Equals, defaultScheduleTimeBudget)
+	c.Assert(cfg.Schedule.Validate(), IsNil)
+	cfg.Schedule.ScheduleTimeBudget = typeutil.NewDuration(-time.Second)
+	c.Assert(cfg.Schedule.Validate(), NotNil)
+	cfg.Schedule.ScheduleTimeBudget = typeutil.NewDuration(time.Second)
+	cfg.Schedule.PatrolRegionBudget = typeutil.NewDuration(0)
+	c.Assert(cfg.Schedule.Validate(), NotNil)
 	// check quota
 	c.Assert(cfg.QuotaBackendBytes, Equals, defaultQuotaBackendBytes)
 }
@@ -386,6 +427,24 @@ dashboard-address = "foo"
 	}
 }
 
+func (s *testConfigSuite) TestStoreReservedSpace(c *C) {
+	cfgData := `
+[schedule]
+store-reserved-space = "10GiB"
+`
+	cfg := NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Schedule.StoreReservedSpace, Equals, typeutil.ByteSize(10<<30))
+
+	c.Assert(json.Unmarshal([]byte(`{"store-reserved-space-overrides": {"2": "20GiB", "3": "0B"}}`), &cfg.Schedule), IsNil)
+	opt := NewPersistOptions(cfg)
+	c.Assert(opt.GetStoreReservedSpace(1), Equals, uint64(10<<30))
+	c.Assert(opt.GetStoreReservedSpace(2), Equals, uint64(20<<30))
+	c.Assert(opt.GetStoreReservedSpace(3), Equals, uint64(0))
+}
+
 func (s *testConfigSuite) TestDashboardConfig(c *C) {
 	cfgData := `
 [dashboard]
@@ -424,6 +483,74 @@ tidb-cert-path = "/path/client.pem"
 	defaultEnableTelemetry = originalDefaultEnableTelemetry
 }
 
+func (s *testConfigSuite) TestTraceConfig(c *C) {
+	cfg := NewConfig()
+	meta, err := toml.Decode("", &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Trace.Enable, IsFalse)
+	c.Assert(cfg.Trace.SamplingRate, Equals, defaultTraceSamplingRate)
+
+	cfgData := `
+[trace]
+enable = true
+exporter-endpoint = "127.0.0.1:6831"
+sampling-rate = 0.5
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.Trace.Enable, IsTrue)
+	c.Assert(cfg.Trace.ExporterEndpoint, Equals, "127.0.0.1:6831")
+	c.Assert(cfg.Trace.SamplingRate, Equals, 0.5)
+
+	cfg = NewConfig()
+	meta, err = toml.Decode("[trace]\nsampling-rate = 1.5", &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), NotNil)
+}
+
+func (s *testConfigSuite) TestEtcdConfig(c *C) {
+	cfg := NewConfig()
+	meta, err := toml.Decode("", &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.MaxTxnOps, Equals, defaultMaxTxnOps)
+	c.Assert(cfg.SnapshotCount, Equals, defaultSnapshotCount)
+
+	cfgData := `
+quota-backend-bytes = "4GiB"
+auto-compaction-mode = "revision"
+auto-compaction-retention = "5000"
+max-txn-ops = 512
+snapshot-count = 50000
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.QuotaBackendBytes, Equals, typeutil.ByteSize(4*1024*1024*1024))
+	c.Assert(cfg.AutoCompactionMode, Equals, "revision")
+	c.Assert(cfg.AutoCompactionRetention, Equals, "5000")
+	c.Assert(cfg.MaxTxnOps, Equals, uint64(512))
+	c.Assert(cfg.SnapshotCount, Equals, uint64(50000))
+
+	invalidCfgs := []string{
+		`auto-compaction-mode = "unknown"`,
+		"auto-compaction-mode = \"revision\"\nauto-compaction-retention = \"1h\"",
+		`auto-compaction-retention = "1x"`,
+		`max-txn-ops = 100000`,
+		`snapshot-count = 10`,
+	}
+	for _, data := range invalidCfgs {
+		cfg = NewConfig()
+		meta, err = toml.Decode(data, &cfg)
+		c.Assert(err, IsNil)
+		c.Assert(cfg.Adjust(&meta, false), NotNil)
+	}
+}
+
 func (s *testConfigSuite) TestReplicationMode(c *C) {
 	cfgData := `
 [replication-mode]
@@ -459,6 +586,39 @@ wait-store-timeout = "120s"
 	c.Assert(cfg.ReplicationMode.ReplicationMode, Equals, "majority")
 }
 
+func (s *testConfigSuite) TestDiskGuard(c *C) {
+	cfg := NewConfig()
+	meta, err := toml.Decode("", &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.DiskGuard.WarningSpace, Equals, defaultDiskGuardWarningSpace)
+	c.Assert(cfg.DiskGuard.CriticalSpace, Equals, defaultDiskGuardCriticalSpace)
+	c.Assert(cfg.DiskGuard.CheckInterval.Duration, Equals, defaultDiskGuardCheckInterval)
+
+	// 0 disables the threshold.
+	cfgData := `
+[disk-guard]
+warning-space = "0"
+critical-space = "512MiB"
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.DiskGuard.WarningSpace, Equals, typeutil.ByteSize(0))
+	c.Assert(cfg.DiskGuard.CriticalSpace, Equals, typeutil.ByteSize(512*1024*1024))
+
+	cfgData = `
+[disk-guard]
+warning-space = "1GiB"
+critical-space = "2GiB"
+`
+	cfg = NewConfig()
+	meta, err = toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), NotNil)
+}
+
 func (s *testConfigSuite) TestConfigClone(c *C) {
 	cfg := &Config{}
 	cfg.Adjust(nil, false)
@@ -482,3 +642,117 @@ func (s *testConfigSuite) TestConfigClone(c *C) {
 	replicationMode.adjust(emptyConfigMetaData)
 	c.Assert(replicationMode.Clone(), DeepEquals, replicationMode)
 }
+
+func (s *testConfigSuite) TestAllowedCNFlags(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Parse([]string{"--admin-allowed-cn", "pd-ctl,admin", "--client-allowed-cn", "tikv"}), IsNil)
+	c.Assert(cfg.PDServerCfg.AdminAllowedCN, DeepEquals, typeutil.StringSlice{"pd-ctl", "admin"})
+	c.Assert(cfg.PDServerCfg.ClientAllowedCN, DeepEquals, typeutil.StringSlice{"tikv"})
+
+	cfgData := `
+[pd-server]
+admin-allowed-cn = ["pd-ctl"]
+`
+	cfg = NewConfig()
+	meta, err := toml.Decode(cfgData, &cfg)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Adjust(&meta, false), IsNil)
+	c.Assert(cfg.PDServerCfg.AdminAllowedCN, DeepEquals, typeutil.StringSlice{"pd-ctl"})
+	c.Assert(cfg.PDServerCfg.ClientAllowedCN, HasLen, 0)
+}
+
+func (s *testConfigSuite) TestAdvertiseUrls(c *C) {
+	cfg := NewConfig()
+	c.Assert(cfg.Parse([]string{"--client-urls", "http://0.0.0.0:2379"}), NotNil)
+	cfg = NewConfig()
+	c.Assert(cfg.Parse([]string{"--client-urls", "http://0.0.0.0:2379", "--advertise-client-urls", "http://127.0.0.1:2379"}), IsNil)
+	cfg = NewConfig()
+	c.Assert(cfg.Parse([]string{"--advertise-peer-urls", "http://[::]:2380"}), NotNil)
+
+	// The advertise urls are derived from the interface with the ports of the
+	// listening urls.
+	lo, err := loopbackInterface()
+	c.Assert(err, IsNil)
+	cfg = NewConfig()
+	c.Assert(cfg.Parse([]string{"--client-urls", "http://0.0.0.0:12379", "--peer-urls", "http://0.0.0.0:12380", "--advertise-interface", lo}), IsNil)
+	c.Assert(cfg.AdvertiseClientUrls, Equals, "http://127.0.0.1:12379")
+	c.Assert(cfg.AdvertisePeerUrls, Equals, "http://127.0.0.1:12380")
+	// The explicit advertise urls are kept.
+	cfg = NewConfig()
+	c.Assert(cfg.Parse([]string{"--client-urls", "http://0.0.0.0:12379", "--advertise-client-urls", "http://pd:2379", "--advertise-interface", lo}), IsNil)
+	c.Assert(cfg.AdvertiseClientUrls, Equals, "http://pd:2379")
+	c.Assert(cfg.AdvertisePeerUrls, Equals, "http://127.0.0.1:2380")
+	cfg = NewConfig()
+	c.Assert(cfg.Parse([]string{"--advertise-interface", "pd-not-exist"}), NotNil)
+}
+
+func loopbackInterface() (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			return iface.Name, nil
+		}
+	}
+	return "", errors.New("no loopback interface")
+}
+
+func (s *testConfigSuite) TestEnv(c *C) {
+	c.Assert(EnvName("schedule", "max-snapshot-count"), Equals, "PD_SCHEDULE_MAX_SNAPSHOT_COUNT")
+	bindings := envBindings()
+	for _, name := range []string{"PD_NAME", "PD_LOG_LEVEL", "PD_LOG_FILE_FILENAME", "PD_LABELS", "PD_PD_SERVER_ADMIN_ALLOWED_CN", "PD_SCHEDULE_PATROL_REGION_INTERVAL"} {
+		_, ok := bindings[name]
+		c.Assert(ok, IsTrue, Commentf(name))
+	}
+
+	cfgData := `
+name = "pd-file"
+data-dir = "/data/pd"
+enable-prevote = false
+[schedule]
+max-snapshot-count = 10
+leader-schedule-limit = 16
+`
+	cfgFile := path.Join(c.MkDir(), "pd.toml")
+	c.Assert(os.WriteFile(cfgFile, []byte(cfgData), 0644), IsNil)
+	envs := map[string]string{
+		"PD_NAME":                            "pd-env",
+		"PD_LOG_LEVEL":                       "warn",
+		"PD_LABELS":                          "zone=z1,host=h1",
+		"PD_PD_SERVER_ADMIN_ALLOWED_CN":      "pd-ctl,admin",
+		"PD_SCHEDULE_MAX_SNAPSHOT_COUNT":     "20",
+		"PD_SCHEDULE_PATROL_REGION_INTERVAL": "20ms",
+		"PD_REPLICATION_MAX_REPLICAS":        "5",
+	}
+	for k, v := range envs {
+		c.Assert(os.Setenv(k, v), IsNil)
+	}
+	defer func() {
+		for k := range envs {
+			os.Unsetenv(k)
+		}
+	}()
+
+	cfg := NewConfig()
+	c.Assert(cfg.Parse([]string{"--config", cfgFile, "-L", "error"}), IsNil)
+	// The flags take precedence over the environment variables.
+	c.Assert(cfg.Log.Level, Equals, "error")
+	// The environment variables take precedence over the config file.
+	c.Assert(cfg.Name, Equals, "pd-env")
+	c.Assert(cfg.Schedule.MaxSnapshotCount, Equals, uint64(20))
+	c.Assert(cfg.Labels, DeepEquals, map[string]string{"zone": "z1", "host": "h1"})
+	c.Assert(cfg.PDServerCfg.AdminAllowedCN, DeepEquals, typeutil.StringSlice{"pd-ctl", "admin"})
+	c.Assert(cfg.Schedule.PatrolRegionInterval.Duration, Equals, 20*time.Millisecond)
+	c.Assert(cfg.Replication.MaxReplicas, Equals, uint64(5))
+	// The items only in the config file are kept and treated as defined.
+	c.Assert(cfg.DataDir, Equals, "/data/pd")
+	c.Assert(cfg.Schedule.LeaderScheduleLimit, Equals, uint64(16))
+	c.Assert(cfg.PreVote, IsFalse)
+	// The others use the defaults.
+	c.Assert(cfg.Schedule.RegionScheduleLimit, Equals, uint64(defaultRegionScheduleLimit))
+
+	c.Assert(os.Setenv("PD_SCHEDULE_MAX_SNAPSHOT_COUNT", "abc"), IsNil)
+	c.Assert(NewConfig().Parse([]string{"--config", cfgFile}), NotNil)
+}
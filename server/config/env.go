@@ -0,0 +1,186 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
+)
+
+// EnvPrefix is the prefix of the environment variables to configure PD.
+const EnvPrefix = "PD_"
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// envBinding binds an environment variable to a config item.
+type envBinding struct {
+	path []string
+	typ  reflect.Type
+}
+
+// EnvName returns the name of the environment variable bound to the config
+// item with the toml path, e.g. PD_SCHEDULE_MAX_SNAPSHOT_COUNT is bound to
+// `max-snapshot-count` in the `[schedule]` section.
+func EnvName(path ...string) string {
+	name := strings.Join(path, "_")
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// envBindings returns the environment variables bound to the items of Config,
+// keyed by the names of the environment variables.
+func envBindings() map[string]envBinding {
+	bindings := make(map[string]envBinding)
+	collectEnvBindings(reflect.TypeOf(Config{}), nil, bindings)
+	return bindings
+}
+
+func collectEnvBindings(typ reflect.Type, path []string, bindings map[string]envBinding) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		// The items without a toml name can't be configured by the file either,
+		// and the deprecated items are shadowed by their successors.
+		if name == "" || name == "-" || field.PkgPath != "" || strings.HasSuffix(field.Name, "Deprecated") {
+			continue
+		}
+		fieldPath := append(append([]string(nil), path...), name)
+		switch {
+		case isEnvValueType(field.Type):
+			bindings[EnvName(fieldPath...)] = envBinding{path: fieldPath, typ: field.Type}
+		case field.Type.Kind() == reflect.Struct:
+			collectEnvBindings(field.Type, fieldPath, bindings)
+		}
+	}
+}
+
+func isEnvValueType(typ reflect.Type) bool {
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	case reflect.Slice:
+		return typ.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return typ.Key().Kind() == reflect.String && typ.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// parseEnvValue converts the value of the environment variable to the toml
+// value of the config item. The slices are comma separated, and the maps are
+// comma separated `key=value` pairs.
+func parseEnvValue(value string, typ reflect.Type) (interface{}, error) {
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return value, nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// toml doesn't support the unsigned integers larger than math.MaxInt64.
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Slice:
+		items := []string{}
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		return items, nil
+	case reflect.Map:
+		items := make(map[string]interface{})
+		if value == "" {
+			return items, nil
+		}
+		for _, item := range strings.Split(value, ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("%q is not in the format of key=value", item)
+			}
+			items[kv[0]] = kv[1]
+		}
+		return items, nil
+	}
+	return nil, errors.Errorf("unsupported type %v", typ)
+}
+
+// configFromEnv overwrites the config with the environment variables, which
+// take precedence over the config file. It returns the metadata of both the
+// config file and the environment variables, or nil if no environment variable
+// is set.
+func (c *Config) configFromEnv(lookupEnv func(string) (string, bool)) (*toml.MetaData, error) {
+	items := make(map[string]interface{})
+	if c.configFile != "" {
+		if _, err := toml.DecodeFile(c.configFile, &items); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	var found bool
+	for name, binding := range envBindings() {
+		value, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		v, err := parseEnvValue(value, binding.typ)
+		if err != nil {
+			return nil, errors.Annotatef(err, "invalid environment variable %s", name)
+		}
+		if err := setItem(items, binding.path, v); err != nil {
+			return nil, errors.Annotatef(err, "invalid environment variable %s", name)
+		}
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	// Encode the items of the config file and the environment variables into
+	// a single document, so that the metadata covers both of them.
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(items); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	meta, err := toml.Decode(buf.String(), c)
+	return &meta, errors.WithStack(err)
+}
+
+func setItem(items map[string]interface{}, path []string, value interface{}) error {
+	for _, key := range path[:len(path)-1] {
+		child, ok := items[key]
+		if !ok {
+			child = make(map[string]interface{})
+			items[key] = child
+		}
+		childItems, ok := child.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("%s is not a table in the config file", key)
+		}
+		items = childItems
+	}
+	items[path[len(path)-1]] = value
+	return nil
+}
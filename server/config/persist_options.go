@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -48,6 +49,9 @@ type PersistOptions struct {
 	replicationMode atomic.Value
 	labelProperty   atomic.Value
 	clusterVersion  unsafe.Pointer
+	// leaderScheduleLimitBoost raises the leader schedule limit temporarily,
+	// e.g. during the rolling restart of the stores. 0 means not raised.
+	leaderScheduleLimitBoost uint64
 }
 
 // NewPersistOptions creates a new PersistOptions instance.
@@ -138,6 +142,12 @@ func (o *PersistOptions) GetIsolationLevel() string {
 	return o.GetReplicationConfig().IsolationLevel
 }
 
+// GetSnapshotLocalityLabel returns the label key of the failure domain to
+// prefer placing the new peers near the healthy replicas.
+func (o *PersistOptions) GetSnapshotLocalityLabel() string {
+	return o.GetReplicationConfig().SnapshotLocalityLabel
+}
+
 // IsPlacementRulesEnabled returns if the placement rules is enabled.
 func (o *PersistOptions) IsPlacementRulesEnabled() bool {
 	return o.GetReplicationConfig().EnablePlacementRules
@@ -179,6 +189,7 @@ const (
 	hotRegionScheduleLimitKey      = "schedule.hot-region-schedule-limit"
 	schedulerMaxWaitingOperatorKey = "schedule.scheduler-max-waiting-operator"
 	enableLocationReplacement      = "schedule.enable-location-replacement"
+	maxStoreDownTimeKey            = "schedule.max-store-down-time"
 )
 
 var supportedTTLConfigs = []string{
@@ -193,6 +204,7 @@ var supportedTTLConfigs = []string{
 	hotRegionScheduleLimitKey,
 	schedulerMaxWaitingOperatorKey,
 	enableLocationReplacement,
+	maxStoreDownTimeKey,
 	"default-add-peer",
 	"default-remove-peer",
 }
@@ -207,11 +219,46 @@ func IsSupportedTTLConfig(key string) bool {
 	return strings.HasPrefix(key, "add-peer-") || strings.HasPrefix(key, "remove-peer-")
 }
 
+// ValidateTTLConfig checks whether the value is valid for the config item with
+// ttl.
+func ValidateTTLConfig(key, value string) error {
+	if !IsSupportedTTLConfig(key) {
+		return errors.Errorf("unsupported ttl config %s", key)
+	}
+	var err error
+	switch {
+	case key == maxStoreDownTimeKey:
+		_, err = time.ParseDuration(value)
+	case key == enableLocationReplacement:
+		_, err = strconv.ParseBool(value)
+	case strings.HasSuffix(key, "-peer") || strings.HasPrefix(key, "add-peer-") || strings.HasPrefix(key, "remove-peer-"):
+		_, err = strconv.ParseFloat(value, 64)
+	default:
+		_, err = strconv.ParseUint(value, 10, 64)
+	}
+	if err != nil {
+		return errors.Errorf("invalid value %q of ttl config %s", value, key)
+	}
+	return nil
+}
+
 // GetMaxSnapshotCount returns the number of the max snapshot which is allowed to send.
 func (o *PersistOptions) GetMaxSnapshotCount() uint64 {
 	return o.getTTLUintOr(maxSnapshotCountKey, o.GetScheduleConfig().MaxSnapshotCount)
 }
 
+// GetMaxSnapshotApplyTime returns the max duration a store keeps applying
+// snapshots before it is considered slow.
+func (o *PersistOptions) GetMaxSnapshotApplyTime() time.Duration {
+	return o.GetScheduleConfig().MaxSnapshotApplyTime.Duration
+}
+
+// GetRegionMoveCooldown returns the minimum interval between two moves of a
+// region by the schedulers.
+func (o *PersistOptions) GetRegionMoveCooldown() time.Duration {
+	return o.GetScheduleConfig().RegionMoveCooldown.Duration
+}
+
 // GetMaxPendingPeerCount returns the number of the max pending peers.
 func (o *PersistOptions) GetMaxPendingPeerCount() uint64 {
 	return o.getTTLUintOr(maxPendingPeerCountKey, o.GetScheduleConfig().MaxPendingPeerCount)
@@ -290,6 +337,18 @@ func (o *PersistOptions) IsOneWayMergeEnabled() bool {
 	return o.GetScheduleConfig().EnableOneWayMerge
 }
 
+// IsScatterAfterSplitEnabled returns if the regions created by the split
+// requests are scattered automatically.
+func (o *PersistOptions) IsScatterAfterSplitEnabled() bool {
+	return o.GetScheduleConfig().EnableScatterAfterSplit
+}
+
+// IsHotBucketSplitEnabled returns if the hot regions are split by the hot
+// buckets, which is experimental.
+func (o *PersistOptions) IsHotBucketSplitEnabled() bool {
+	return o.GetScheduleConfig().EnableHotBucketSplit
+}
+
 // IsCrossTableMergeEnabled returns if across table merge is enabled.
 func (o *PersistOptions) IsCrossTableMergeEnabled() bool {
 	return o.GetScheduleConfig().EnableCrossTableMerge
@@ -300,14 +359,47 @@ func (o *PersistOptions) GetPatrolRegionInterval() time.Duration {
 	return o.GetScheduleConfig().PatrolRegionInterval.Duration
 }
 
+// GetPatrolRegionBudget returns the max time spent on checking regions in one patrol round.
+func (o *PersistOptions) GetPatrolRegionBudget() time.Duration {
+	return o.GetScheduleConfig().PatrolRegionBudget.Duration
+}
+
+// GetScheduleTimeBudget returns the max time spent in the retries of one schedule.
+func (o *PersistOptions) GetScheduleTimeBudget() time.Duration {
+	return o.GetScheduleConfig().ScheduleTimeBudget.Duration
+}
+
 // GetMaxStoreDownTime returns the max down time of a store.
 func (o *PersistOptions) GetMaxStoreDownTime() time.Duration {
-	return o.GetScheduleConfig().MaxStoreDownTime.Duration
+	return o.getTTLDurationOr(maxStoreDownTimeKey, o.GetScheduleConfig().MaxStoreDownTime.Duration)
 }
 
 // GetLeaderScheduleLimit returns the limit for leader schedule.
 func (o *PersistOptions) GetLeaderScheduleLimit() uint64 {
-	return o.getTTLUintOr(leaderScheduleLimitKey, o.GetScheduleConfig().LeaderScheduleLimit)
+	limit := o.getTTLUintOr(leaderScheduleLimitKey, o.GetScheduleConfig().LeaderScheduleLimit)
+	if boost := atomic.LoadUint64(&o.leaderScheduleLimitBoost); boost > limit {
+		return boost
+	}
+	return limit
+}
+
+// SetLeaderScheduleLimitBoost raises the leader schedule limit to the boost
+// temporarily, the limit is not lowered if it is larger than the boost. 0
+// means the limit is not raised.
+func (o *PersistOptions) SetLeaderScheduleLimitBoost(boost uint64) {
+	atomic.StoreUint64(&o.leaderScheduleLimitBoost, boost)
+}
+
+// GetRollingRestartLeaderScheduleLimit returns the leader schedule limit
+// during the rolling restart of the stores.
+func (o *PersistOptions) GetRollingRestartLeaderScheduleLimit() uint64 {
+	return o.GetScheduleConfig().RollingRestartLeaderScheduleLimit
+}
+
+// GetRollingRestartWindow returns the window to detect the rolling restart of
+// the stores.
+func (o *PersistOptions) GetRollingRestartWindow() time.Duration {
+	return o.GetScheduleConfig().RollingRestartWindow.Duration
 }
 
 // GetRegionScheduleLimit returns the limit for region schedule.
@@ -410,6 +502,16 @@ func (o *PersistOptions) GetLowSpaceRatio() float64 {
 	return o.GetScheduleConfig().LowSpaceRatio
 }
 
+// GetStoreReservedSpace returns the space of the store reserved for the
+// processes other than TiKV.
+func (o *PersistOptions) GetStoreReservedSpace(storeID uint64) uint64 {
+	cfg := o.GetScheduleConfig()
+	if reservedSpace, ok := cfg.StoreReservedSpaceOverrides[storeID]; ok {
+		return uint64(reservedSpace)
+	}
+	return uint64(cfg.StoreReservedSpace)
+}
+
 // GetHighSpaceRatio returns the high space ratio.
 func (o *PersistOptions) GetHighSpaceRatio() float64 {
 	return o.GetScheduleConfig().HighSpaceRatio
@@ -676,6 +778,39 @@ func (o *PersistOptions) getTTLFloatOr(key string, defaultValue float64) float64
 	return defaultValue
 }
 
+func (o *PersistOptions) getTTLDurationOr(key string, defaultValue time.Duration) time.Duration {
+	if stringForm, ok := o.getTTLData(key); ok {
+		if v, err := time.ParseDuration(stringForm); err == nil {
+			return v
+		}
+		log.Warn("failed to parse " + key + " from PersistOptions's ttl storage")
+	}
+	return defaultValue
+}
+
+// TTLConfigItem is a config item with ttl, which overrides the persisted
+// config until it expires.
+type TTLConfigItem struct {
+	Key      string    `json:"key"`
+	Value    string    `json:"value"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// GetTTLConfigs returns the config items with ttl which are not expired.
+func (o *PersistOptions) GetTTLConfigs() []*TTLConfigItem {
+	if o.ttl == nil {
+		return nil
+	}
+	var items []*TTLConfigItem
+	for _, key := range o.ttl.GetAllID() {
+		if value, expireAt, ok := o.ttl.GetWithExpiration(key); ok {
+			items = append(items, &TTLConfigItem{Key: key, Value: value.(string), ExpireAt: expireAt})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items
+}
+
 func (o *PersistOptions) getTTLData(key string) (string, bool) {
 	if o.ttl == nil {
 		return "", false
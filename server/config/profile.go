@@ -0,0 +1,186 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// The names of the builtin schedule profiles.
+const (
+	DefaultProfile          = "default"
+	ImportHeavyProfile      = "import-heavy"
+	MaintenanceProfile      = "maintenance"
+	LatencySensitiveProfile = "latency-sensitive"
+)
+
+// profileNameFormat is the format of the profile names, which are also used as
+// the keys in etcd and the paths of the API.
+const profileNameFormat = "^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$"
+
+// ScheduleProfile is a named bundle of schedule config items, which are
+// applied at once to switch the scheduling behavior, e.g. during a data
+// import or a maintenance.
+type ScheduleProfile struct {
+	Name string `json:"name"`
+	// Items are the schedule config items keyed by their json names, such as
+	// "region-schedule-limit".
+	Items   map[string]interface{} `json:"items"`
+	Builtin bool                   `json:"builtin,omitempty"`
+}
+
+// newBuiltinProfile creates a builtin profile by overriding the items of the
+// default profile, so that all the builtin profiles cover the same items and
+// switching among them leaves nothing behind.
+func newBuiltinProfile(name string, overrides map[string]interface{}) *ScheduleProfile {
+	items := map[string]interface{}{
+		"leader-schedule-limit":          defaultLeaderScheduleLimit,
+		"region-schedule-limit":          defaultRegionScheduleLimit,
+		"replica-schedule-limit":         defaultReplicaScheduleLimit,
+		"merge-schedule-limit":           defaultMergeScheduleLimit,
+		"hot-region-schedule-limit":      defaultHotRegionScheduleLimit,
+		"max-snapshot-count":             defaultMaxSnapshotCount,
+		"max-pending-peer-count":         defaultMaxPendingPeerCount,
+		"scheduler-max-waiting-operator": defaultSchedulerMaxWaitingOperator,
+		"enable-location-replacement":    "true",
+	}
+	for k, v := range overrides {
+		items[k] = v
+	}
+	return &ScheduleProfile{Name: name, Items: items, Builtin: true}
+}
+
+var builtinProfiles = []*ScheduleProfile{
+	newBuiltinProfile(DefaultProfile, nil),
+	// Importing creates lots of empty regions and moves data fast, so the
+	// merge and hot region scheduling are stopped while the snapshots are
+	// allowed to be more.
+	newBuiltinProfile(ImportHeavyProfile, map[string]interface{}{
+		"merge-schedule-limit":      0,
+		"hot-region-schedule-limit": 0,
+		"max-snapshot-count":        16,
+		"max-pending-peer-count":    64,
+	}),
+	// Only the replicas are repaired during the maintenance, the data are not
+	// moved for balance.
+	newBuiltinProfile(MaintenanceProfile, map[string]interface{}{
+		"region-schedule-limit":       0,
+		"merge-schedule-limit":        0,
+		"hot-region-schedule-limit":   0,
+		"enable-location-replacement": "false",
+	}),
+	// The scheduling is throttled to reduce its impact on the foreground
+	// requests, except the hot region scheduling which relieves hotspots.
+	newBuiltinProfile(LatencySensitiveProfile, map[string]interface{}{
+		"leader-schedule-limit":     2,
+		"region-schedule-limit":     256,
+		"replica-schedule-limit":    16,
+		"merge-schedule-limit":      2,
+		"hot-region-schedule-limit": 8,
+		"max-snapshot-count":        2,
+		"max-pending-peer-count":    8,
+	}),
+}
+
+// GetBuiltinProfile returns the builtin profile with the name, nil if there is
+// no such profile.
+func GetBuiltinProfile(name string) *ScheduleProfile {
+	for _, p := range builtinProfiles {
+		if p.Name == name {
+			return p.Clone()
+		}
+	}
+	return nil
+}
+
+// GetBuiltinProfiles returns all the builtin profiles.
+func GetBuiltinProfiles() []*ScheduleProfile {
+	profiles := make([]*ScheduleProfile, 0, len(builtinProfiles))
+	for _, p := range builtinProfiles {
+		profiles = append(profiles, p.Clone())
+	}
+	return profiles
+}
+
+// Clone returns a copy of the profile.
+func (p *ScheduleProfile) Clone() *ScheduleProfile {
+	items := make(map[string]interface{}, len(p.Items))
+	for k, v := range p.Items {
+		items[k] = v
+	}
+	return &ScheduleProfile{Name: p.Name, Items: items, Builtin: p.Builtin}
+}
+
+// ItemKeys returns the keys of the items in order.
+func (p *ScheduleProfile) ItemKeys() []string {
+	keys := make([]string, 0, len(p.Items))
+	for k := range p.Items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks the name and the items of the profile.
+func (p *ScheduleProfile) Validate() error {
+	if err := validateFormat(p.Name, profileNameFormat); err != nil {
+		return err
+	}
+	if len(p.Items) == 0 {
+		return errors.Errorf("profile %s has no config item", p.Name)
+	}
+	return p.decode(&ScheduleConfig{})
+}
+
+// ApplyTo applies the items of the profile to the schedule config.
+func (p *ScheduleProfile) ApplyTo(cfg *ScheduleConfig) error {
+	if err := p.decode(cfg); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+func (p *ScheduleProfile) decode(cfg *ScheduleConfig) error {
+	for _, k := range p.ItemKeys() {
+		if !isProfileItem(k) {
+			return errors.Errorf("config item %s cannot be set by profile", k)
+		}
+	}
+	data, err := json.Marshal(p.Items)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(json.Unmarshal(data, cfg))
+}
+
+// isProfileItem checks if the key is a schedule config item which can be set
+// by profiles. The schedulers are managed by their own API.
+func isProfileItem(key string) bool {
+	switch key {
+	case "schedulers-v2", "schedulers-payload", "store-limit", "profile":
+		return false
+	}
+	t := reflect.TypeOf(ScheduleConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == key {
+			return true
+		}
+	}
+	return false
+}
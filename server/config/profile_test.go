@@ -0,0 +1,77 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	. "github.com/pingcap/check"
+)
+
+var _ = Suite(&testProfileSuite{})
+
+type testProfileSuite struct{}
+
+func (s *testProfileSuite) TestBuiltinProfiles(c *C) {
+	profiles := GetBuiltinProfiles()
+	c.Assert(profiles, HasLen, 4)
+	defaultCfg := NewTestOptions().GetScheduleConfig()
+	for _, p := range profiles {
+		c.Assert(p.Builtin, IsTrue)
+		c.Assert(p.Validate(), IsNil)
+		// All the builtin profiles cover the same items.
+		c.Assert(p.ItemKeys(), DeepEquals, profiles[0].ItemKeys())
+		cfg := defaultCfg.Clone()
+		c.Assert(p.ApplyTo(cfg), IsNil)
+	}
+
+	// The default profile keeps the default config.
+	cfg := defaultCfg.Clone()
+	c.Assert(GetBuiltinProfile(MaintenanceProfile).ApplyTo(cfg), IsNil)
+	c.Assert(cfg.RegionScheduleLimit, Equals, uint64(0))
+	c.Assert(cfg.EnableLocationReplacement, IsFalse)
+	c.Assert(GetBuiltinProfile(DefaultProfile).ApplyTo(cfg), IsNil)
+	c.Assert(cfg, DeepEquals, defaultCfg.Clone())
+
+	// The builtin profiles cannot be modified by the callers.
+	GetBuiltinProfile(DefaultProfile).Items["region-schedule-limit"] = 0
+	c.Assert(GetBuiltinProfile(DefaultProfile).Items["region-schedule-limit"], Equals, defaultRegionScheduleLimit)
+	c.Assert(GetBuiltinProfile("unknown"), IsNil)
+}
+
+func (s *testProfileSuite) TestValidateProfile(c *C) {
+	profile := &ScheduleProfile{
+		Name:  "night",
+		Items: map[string]interface{}{"region-schedule-limit": 4096, "enable-one-way-merge": "true"},
+	}
+	c.Assert(profile.Validate(), IsNil)
+	cfg := NewTestOptions().GetScheduleConfig().Clone()
+	c.Assert(profile.ApplyTo(cfg), IsNil)
+	c.Assert(cfg.RegionScheduleLimit, Equals, uint64(4096))
+	c.Assert(cfg.EnableOneWayMerge, IsTrue)
+
+	for _, p := range []*ScheduleProfile{
+		{Name: "", Items: profile.Items},
+		{Name: "a/b", Items: profile.Items},
+		{Name: "empty"},
+		{Name: "unknown", Items: map[string]interface{}{"unknown": 1}},
+		{Name: "store-limit", Items: map[string]interface{}{"store-limit": nil}},
+		{Name: "wrong-type", Items: map[string]interface{}{"region-schedule-limit": "many"}},
+	} {
+		c.Assert(p.Validate(), NotNil)
+	}
+
+	// The config is validated after the profile is applied.
+	profile.Items = map[string]interface{}{"low-space-ratio": 0.5}
+	c.Assert(profile.Validate(), IsNil)
+	c.Assert(profile.ApplyTo(NewTestOptions().GetScheduleConfig().Clone()), NotNil)
+}
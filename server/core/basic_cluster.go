@@ -129,6 +129,14 @@ func (bc *BasicCluster) GetAdjacentRegions(region *RegionInfo) (*RegionInfo, *Re
 	return bc.Regions.GetAdjacentRegions(region)
 }
 
+// CheckRegionRanges returns the key ranges which are not covered by exactly
+// one region.
+func (bc *BasicCluster) CheckRegionRanges() []*RegionRangeAnomaly {
+	bc.RLock()
+	defer bc.RUnlock()
+	return bc.Regions.CheckRanges()
+}
+
 // PauseLeaderTransfer prevents the store from been selected as source or
 // target store of TransferLeader.
 func (bc *BasicCluster) PauseLeaderTransfer(storeID uint64) error {
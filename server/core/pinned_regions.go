@@ -0,0 +1,149 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// PinnedRegion is a region, or the regions in a key range, which is pinned to
+// be exempted from balance. Either RegionID or the key range is set.
+type PinnedRegion struct {
+	RegionID uint64
+	StartKey []byte
+	EndKey   []byte
+	ExpireAt time.Time
+}
+
+func (p *PinnedRegion) isSame(regionID uint64, startKey, endKey []byte) bool {
+	if regionID != 0 || p.RegionID != 0 {
+		return p.RegionID == regionID
+	}
+	return bytes.Equal(p.StartKey, startKey) && bytes.Equal(p.EndKey, endKey)
+}
+
+func (p *PinnedRegion) contains(region *RegionInfo) bool {
+	if p.RegionID != 0 {
+		return p.RegionID == region.GetID()
+	}
+	// The region is pinned if it overlaps with the key range.
+	return (len(p.EndKey) == 0 || bytes.Compare(region.GetStartKey(), p.EndKey) < 0) &&
+		(len(region.GetEndKey()) == 0 || bytes.Compare(p.StartKey, region.GetEndKey()) < 0)
+}
+
+// PinnedRegions records the pinned regions. The pins are removed after they
+// expire.
+type PinnedRegions struct {
+	sync.RWMutex
+	pins []*PinnedRegion
+}
+
+// NewPinnedRegions creates a PinnedRegions.
+func NewPinnedRegions() *PinnedRegions {
+	return &PinnedRegions{}
+}
+
+// PinRegion pins the region for the given ttl. The ttl is refreshed if the
+// region is already pinned.
+func (p *PinnedRegions) PinRegion(regionID uint64, ttl time.Duration) {
+	p.pin(regionID, nil, nil, ttl)
+}
+
+// PinKeyRange pins the regions which overlap with the key range for the given
+// ttl. An empty end key means the end of the whole key space.
+func (p *PinnedRegions) PinKeyRange(startKey, endKey []byte, ttl time.Duration) {
+	p.pin(0, startKey, endKey, ttl)
+}
+
+func (p *PinnedRegions) pin(regionID uint64, startKey, endKey []byte, ttl time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+	now := time.Now()
+	p.removeExpired(now)
+	expireAt := now.Add(ttl)
+	for _, pin := range p.pins {
+		if pin.isSame(regionID, startKey, endKey) {
+			pin.ExpireAt = expireAt
+			return
+		}
+	}
+	p.pins = append(p.pins, &PinnedRegion{
+		RegionID: regionID,
+		StartKey: startKey,
+		EndKey:   endKey,
+		ExpireAt: expireAt,
+	})
+}
+
+// UnpinRegion removes the pin of the region. It returns false if the region
+// is not pinned.
+func (p *PinnedRegions) UnpinRegion(regionID uint64) bool {
+	return p.unpin(regionID, nil, nil)
+}
+
+// UnpinKeyRange removes the pin of the key range. It returns false if the key
+// range is not pinned.
+func (p *PinnedRegions) UnpinKeyRange(startKey, endKey []byte) bool {
+	return p.unpin(0, startKey, endKey)
+}
+
+func (p *PinnedRegions) unpin(regionID uint64, startKey, endKey []byte) bool {
+	p.Lock()
+	defer p.Unlock()
+	for i, pin := range p.pins {
+		if pin.isSame(regionID, startKey, endKey) {
+			p.pins = append(p.pins[:i], p.pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// IsPinned checks if the region is pinned.
+func (p *PinnedRegions) IsPinned(region *RegionInfo) bool {
+	p.RLock()
+	defer p.RUnlock()
+	now := time.Now()
+	for _, pin := range p.pins {
+		if pin.ExpireAt.After(now) && pin.contains(region) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAll returns all the pins which are not expired.
+func (p *PinnedRegions) GetAll() []*PinnedRegion {
+	p.Lock()
+	defer p.Unlock()
+	p.removeExpired(time.Now())
+	res := make([]*PinnedRegion, 0, len(p.pins))
+	for _, pin := range p.pins {
+		copied := *pin
+		res = append(res, &copied)
+	}
+	return res
+}
+
+func (p *PinnedRegions) removeExpired(now time.Time) {
+	pins := p.pins[:0]
+	for _, pin := range p.pins {
+		if pin.ExpireAt.After(now) {
+			pins = append(pins, pin)
+		}
+	}
+	p.pins = pins
+}
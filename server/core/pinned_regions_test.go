@@ -0,0 +1,59 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+var _ = Suite(&testPinnedRegionsSuite{})
+
+type testPinnedRegionsSuite struct{}
+
+func (s *testPinnedRegionsSuite) TestPinnedRegions(c *C) {
+	newRegion := func(id uint64, start, end string) *RegionInfo {
+		return NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte(start), EndKey: []byte(end)}, nil)
+	}
+	r1 := newRegion(1, "", "b")
+	r2 := newRegion(2, "b", "d")
+	r3 := newRegion(3, "d", "")
+
+	pinned := NewPinnedRegions()
+	pinned.PinRegion(1, time.Minute)
+	c.Assert(pinned.IsPinned(r1), IsTrue)
+	c.Assert(pinned.IsPinned(r2), IsFalse)
+
+	pinned.PinKeyRange([]byte("c"), []byte("d"), time.Minute)
+	c.Assert(pinned.IsPinned(r2), IsTrue)
+	c.Assert(pinned.IsPinned(r3), IsFalse)
+	pinned.PinKeyRange([]byte("e"), nil, time.Minute)
+	c.Assert(pinned.IsPinned(r3), IsTrue)
+	c.Assert(pinned.GetAll(), HasLen, 3)
+
+	// Pin again to refresh the ttl.
+	pinned.PinRegion(1, 0)
+	c.Assert(pinned.IsPinned(r1), IsFalse)
+	c.Assert(pinned.GetAll(), HasLen, 2)
+
+	c.Assert(pinned.UnpinKeyRange([]byte("c"), []byte("d")), IsTrue)
+	c.Assert(pinned.UnpinKeyRange([]byte("c"), []byte("d")), IsFalse)
+	c.Assert(pinned.IsPinned(r2), IsFalse)
+	c.Assert(pinned.UnpinRegion(1), IsFalse)
+	pins := pinned.GetAll()
+	c.Assert(pins, HasLen, 1)
+	c.Assert(pins[0].StartKey, DeepEquals, []byte("e"))
+}
@@ -20,6 +20,8 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/gogo/protobuf/proto"
@@ -27,6 +29,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/kvproto/pkg/replication_modepb"
+	"github.com/tikv/pd/pkg/logutil"
 )
 
 // errRegionIsStale is error info for region is stale.
@@ -54,6 +57,12 @@ type RegionInfo struct {
 	replicationStatus *replication_modepb.RegionReplicationStatus
 	QueryStats        *pdpb.QueryStats
 	flowRoundDivisor  uint64
+	// firstSeen is the unix timestamp in seconds when PD first knows the region.
+	firstSeen int64
+	// lastHeartbeat is the unix timestamp in seconds when PD receives the last
+	// heartbeat of the region. Different from the other fields, it is updated
+	// atomically by the heartbeats which don't change the region.
+	lastHeartbeat int64
 }
 
 // NewRegionInfo creates RegionInfo with region's meta and leader peer.
@@ -121,6 +130,7 @@ func RegionFromHeartbeat(heartbeat *pdpb.RegionHeartbeatRequest, opts ...RegionC
 		interval:          heartbeat.GetInterval(),
 		replicationStatus: heartbeat.GetReplicationStatus(),
 		QueryStats:        heartbeat.GetQueryStats(),
+		lastHeartbeat:     time.Now().Unix(),
 	}
 
 	for _, opt := range opts {
@@ -168,6 +178,8 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 		approximateKeys:   r.approximateKeys,
 		interval:          proto.Clone(r.interval).(*pdpb.TimeInterval),
 		replicationStatus: r.replicationStatus,
+		firstSeen:         r.firstSeen,
+		lastHeartbeat:     r.GetLastHeartbeat(),
 	}
 
 	for _, opt := range opts {
@@ -384,6 +396,37 @@ func (r *RegionInfo) GetInterval() *pdpb.TimeInterval {
 	return r.interval
 }
 
+// GetFirstSeen returns the unix timestamp in seconds when PD first knows the region.
+func (r *RegionInfo) GetFirstSeen() int64 {
+	return r.firstSeen
+}
+
+// GetLastHeartbeat returns the unix timestamp in seconds when PD receives the
+// last heartbeat of the region, 0 means no heartbeat is received since PD
+// loads the region.
+func (r *RegionInfo) GetLastHeartbeat() int64 {
+	return atomic.LoadInt64(&r.lastHeartbeat)
+}
+
+// UpdateLastHeartbeat updates the last heartbeat timestamp of the region. It
+// is used when a heartbeat doesn't change the region thus the region in cache
+// is not replaced.
+func (r *RegionInfo) UpdateLastHeartbeat(ts int64) {
+	atomic.StoreInt64(&r.lastHeartbeat, ts)
+}
+
+// IsHeartbeatStale returns true if the region has not been heartbeated within
+// the threshold. The region which has not been heartbeated since it is loaded
+// from the storage or synced from the leader is not regarded as stale, since
+// the regions are not heartbeated yet right after a leader change.
+func (r *RegionInfo) IsHeartbeatStale(now time.Time, threshold time.Duration) bool {
+	lastHeartbeat := r.GetLastHeartbeat()
+	if lastHeartbeat == 0 {
+		return false
+	}
+	return now.Unix()-lastHeartbeat > int64(threshold.Seconds())
+}
+
 // GetDownPeers returns the down peers of the region.
 func (r *RegionInfo) GetDownPeers() []*pdpb.PeerStats {
 	return r.downPeers
@@ -529,6 +572,9 @@ func (r *RegionsInfo) SetRegion(region *RegionInfo) (overlaps []*RegionInfo) {
 	if item = r.regions.Get(region.GetID()); item != nil {
 		// If this ID already exists, use the existing regionItem and pick out the origin.
 		origin = item.region
+		if region.firstSeen == 0 {
+			region.firstSeen = origin.firstSeen
+		}
 		rangeChanged = !bytes.Equal(origin.GetStartKey(), region.GetStartKey()) ||
 			!bytes.Equal(origin.GetEndKey(), region.GetEndKey())
 		if rangeChanged {
@@ -553,6 +599,9 @@ func (r *RegionsInfo) SetRegion(region *RegionInfo) (overlaps []*RegionInfo) {
 		peersChanged = true
 		item = r.regions.AddNew(region)
 	}
+	if region.firstSeen == 0 {
+		region.firstSeen = time.Now().Unix()
+	}
 
 	if !rangeChanged {
 		// If the range is not changed, only the statistical on the regionTree needs to be updated.
@@ -984,6 +1033,84 @@ func (r *RegionsInfo) GetAdjacentRegions(region *RegionInfo) (*RegionInfo, *Regi
 	return prev, next
 }
 
+// The kinds of the region range anomalies.
+const (
+	// RegionRangeGap means the key range is covered by no region.
+	RegionRangeGap = "gap"
+	// RegionRangeOverlap means the key range is covered by more than one region.
+	RegionRangeOverlap = "overlap"
+)
+
+// RegionRangeAnomaly is a key range which is covered by no region or more than
+// one region in the region tree, which should not happen.
+type RegionRangeAnomaly struct {
+	Kind     string
+	StartKey []byte
+	EndKey   []byte
+	// Regions are the regions around the gap or the overlapped regions.
+	Regions []*RegionInfo
+}
+
+// CheckRanges scans the region tree and returns the key ranges which are not
+// covered by exactly one region.
+func (r *RegionsInfo) CheckRanges() []*RegionRangeAnomaly {
+	var (
+		anomalies []*RegionRangeAnomaly
+		// farthest is the region with the largest end key among the scanned
+		// regions, the key space before its end key has been checked.
+		farthest *RegionInfo
+	)
+	r.tree.scanRange(nil, func(region *RegionInfo) bool {
+		if farthest == nil {
+			if len(region.GetStartKey()) > 0 {
+				anomalies = append(anomalies, &RegionRangeAnomaly{
+					Kind:    RegionRangeGap,
+					EndKey:  region.GetStartKey(),
+					Regions: []*RegionInfo{region},
+				})
+			}
+			farthest = region
+			return true
+		}
+		end := farthest.GetEndKey()
+		switch {
+		case len(end) > 0 && bytes.Compare(end, region.GetStartKey()) < 0:
+			anomalies = append(anomalies, &RegionRangeAnomaly{
+				Kind:     RegionRangeGap,
+				StartKey: end,
+				EndKey:   region.GetStartKey(),
+				Regions:  []*RegionInfo{farthest, region},
+			})
+		case len(end) == 0 || bytes.Compare(end, region.GetStartKey()) > 0:
+			overlapEnd := region.GetEndKey()
+			if len(end) > 0 && (len(overlapEnd) == 0 || bytes.Compare(end, overlapEnd) < 0) {
+				overlapEnd = end
+			}
+			anomalies = append(anomalies, &RegionRangeAnomaly{
+				Kind:     RegionRangeOverlap,
+				StartKey: region.GetStartKey(),
+				EndKey:   overlapEnd,
+				Regions:  []*RegionInfo{farthest, region},
+			})
+		}
+		if len(end) > 0 && (len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), end) > 0) {
+			farthest = region
+		}
+		return true
+	})
+	switch {
+	case farthest == nil:
+		anomalies = append(anomalies, &RegionRangeAnomaly{Kind: RegionRangeGap})
+	case len(farthest.GetEndKey()) > 0:
+		anomalies = append(anomalies, &RegionRangeAnomaly{
+			Kind:     RegionRangeGap,
+			StartKey: farthest.GetEndKey(),
+			Regions:  []*RegionInfo{farthest},
+		})
+	}
+	return anomalies
+}
+
 // GetAverageRegionSize returns the average region approximate size.
 func (r *RegionsInfo) GetAverageRegionSize() int64 {
 	if r.tree.length() == 0 {
@@ -1096,6 +1223,12 @@ func HexRegionKeyStr(key []byte) string {
 	return String(HexRegionKey(key))
 }
 
+// RedactedHexRegionKeyStr converts region key to hex format, which is redacted
+// by the redact mode. Used for the keys returned by the API.
+func RedactedHexRegionKeyStr(key []byte) string {
+	return logutil.RedactString(HexRegionKeyStr(key))
+}
+
 // RegionToHexMeta converts a region meta's keys to hex format. Used for formating
 // region in logs.
 func RegionToHexMeta(meta *metapb.Region) HexRegionMeta {
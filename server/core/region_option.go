@@ -285,6 +285,13 @@ func WithReplacePeerStore(oldStoreID, newStoreID uint64) RegionCreateOption {
 	}
 }
 
+// SetLastHeartbeat sets the last heartbeat timestamp of the region.
+func SetLastHeartbeat(ts int64) RegionCreateOption {
+	return func(region *RegionInfo) {
+		region.lastHeartbeat = ts
+	}
+}
+
 // WithInterval sets the interval
 func WithInterval(interval *pdpb.TimeInterval) RegionCreateOption {
 	return func(region *RegionInfo) {
@@ -20,10 +20,12 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/mock/mockid"
 	"github.com/tikv/pd/server/id"
 )
@@ -179,6 +181,83 @@ func (s *testRegionInfoSuite) TestRegionRoundingFlow(c *C) {
 	}
 }
 
+func (s *testRegionInfoSuite) TestRegionTimestamps(c *C) {
+	regions := NewRegionsInfo()
+	region := NewRegionInfo(&metapb.Region{Id: 100}, nil)
+	c.Assert(region.GetFirstSeen(), Equals, int64(0))
+	c.Assert(region.GetLastHeartbeat(), Equals, int64(0))
+	regions.SetRegion(region)
+	firstSeen := region.GetFirstSeen()
+	c.Assert(firstSeen, Not(Equals), int64(0))
+
+	// The first seen time is inherited from the origin.
+	heartbeat := RegionFromHeartbeat(&pdpb.RegionHeartbeatRequest{Region: &metapb.Region{Id: 100}})
+	c.Assert(heartbeat.GetLastHeartbeat(), Not(Equals), int64(0))
+	regions.SetRegion(heartbeat.Clone(SetLastHeartbeat(1)))
+	c.Assert(regions.GetRegion(100).GetFirstSeen(), Equals, firstSeen)
+	c.Assert(regions.GetRegion(100).GetLastHeartbeat(), Equals, int64(1))
+
+	now := time.Unix(1000, 0)
+	// The region not heartbeated since it is loaded is not stale.
+	loaded := NewRegionInfo(&metapb.Region{Id: 101}, nil)
+	c.Assert(loaded.IsHeartbeatStale(now, time.Second), IsFalse)
+	regions.GetRegion(100).UpdateLastHeartbeat(now.Unix() - 30)
+	c.Assert(regions.GetRegion(100).IsHeartbeatStale(now, time.Minute), IsFalse)
+	c.Assert(regions.GetRegion(100).IsHeartbeatStale(now, 10*time.Second), IsTrue)
+}
+
+func (s *testRegionInfoSuite) TestCheckRanges(c *C) {
+	regions := NewRegionsInfo()
+	anomalies := regions.CheckRanges()
+	c.Assert(anomalies, HasLen, 1)
+	c.Assert(anomalies[0].Kind, Equals, RegionRangeGap)
+	c.Assert(anomalies[0].Regions, HasLen, 0)
+
+	newRegion := func(id uint64, start, end string) *RegionInfo {
+		return NewRegionInfo(&metapb.Region{Id: id, StartKey: []byte(start), EndKey: []byte(end)}, nil)
+	}
+	regions.SetRegion(newRegion(1, "", "b"))
+	regions.SetRegion(newRegion(2, "b", "d"))
+	regions.SetRegion(newRegion(3, "d", ""))
+	c.Assert(regions.CheckRanges(), HasLen, 0)
+
+	// |  1  |  2  | gap |  4  | gap
+	regions.RemoveRegion(regions.GetRegion(3))
+	regions.SetRegion(newRegion(4, "e", "f"))
+	anomalies = regions.CheckRanges()
+	c.Assert(anomalies, HasLen, 2)
+	c.Assert(anomalies[0].Kind, Equals, RegionRangeGap)
+	c.Assert(anomalies[0].StartKey, DeepEquals, []byte("d"))
+	c.Assert(anomalies[0].EndKey, DeepEquals, []byte("e"))
+	c.Assert(anomalies[0].Regions[0].GetID(), Equals, uint64(2))
+	c.Assert(anomalies[0].Regions[1].GetID(), Equals, uint64(4))
+	c.Assert(anomalies[1].Kind, Equals, RegionRangeGap)
+	c.Assert(anomalies[1].StartKey, DeepEquals, []byte("f"))
+	c.Assert(anomalies[1].EndKey, HasLen, 0)
+
+	// The region tree never keeps the overlapped regions, so they are put into
+	// the tree directly.
+	regions.tree.tree.ReplaceOrInsert(&regionItem{region: newRegion(5, "a", "c")})
+	regions.tree.tree.ReplaceOrInsert(&regionItem{region: newRegion(6, "d", "")})
+	anomalies = regions.CheckRanges()
+	c.Assert(anomalies, HasLen, 3)
+	c.Assert(anomalies[0].Kind, Equals, RegionRangeOverlap)
+	c.Assert(anomalies[0].StartKey, DeepEquals, []byte("a"))
+	c.Assert(anomalies[0].EndKey, DeepEquals, []byte("b"))
+	c.Assert(anomalies[0].Regions[0].GetID(), Equals, uint64(1))
+	c.Assert(anomalies[0].Regions[1].GetID(), Equals, uint64(5))
+	c.Assert(anomalies[1].Kind, Equals, RegionRangeOverlap)
+	c.Assert(anomalies[1].StartKey, DeepEquals, []byte("b"))
+	c.Assert(anomalies[1].EndKey, DeepEquals, []byte("c"))
+	c.Assert(anomalies[1].Regions[0].GetID(), Equals, uint64(5))
+	c.Assert(anomalies[1].Regions[1].GetID(), Equals, uint64(2))
+	c.Assert(anomalies[2].Kind, Equals, RegionRangeOverlap)
+	c.Assert(anomalies[2].StartKey, DeepEquals, []byte("e"))
+	c.Assert(anomalies[2].EndKey, DeepEquals, []byte("f"))
+	c.Assert(anomalies[2].Regions[0].GetID(), Equals, uint64(6))
+	c.Assert(anomalies[2].Regions[1].GetID(), Equals, uint64(4))
+}
+
 var _ = Suite(&testRegionMapSuite{})
 
 type testRegionMapSuite struct{}
@@ -270,6 +349,16 @@ func (*testRegionKey) TestRegionKey(c *C) {
 	}
 }
 
+func (*testRegionKey) TestRedactedRegionKey(c *C) {
+	defer logutil.SetRedactMode(logutil.RedactModeOff)
+	key := []byte("t\x80")
+	c.Assert(RedactedHexRegionKeyStr(key), Equals, "7480")
+	logutil.SetRedactMode(logutil.RedactModeOn)
+	c.Assert(RedactedHexRegionKeyStr(key), Equals, "?")
+	logutil.SetRedactMode(logutil.RedactModeMark)
+	c.Assert(RedactedHexRegionKeyStr(key), Equals, "‹7480›")
+}
+
 func (*testRegionKey) TestSetRegion(c *C) {
 	regions := NewRegionsInfo()
 	for i := 0; i < 100; i++ {
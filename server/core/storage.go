@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/diskguard"
 	"github.com/tikv/pd/pkg/encryption"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/encryptionkm"
@@ -45,7 +46,15 @@ const (
 	replicationPath            = "replication_mode"
 	componentPath              = "component"
 	customScheduleConfigPath   = "scheduler_config"
+	schedulerDisableRecordPath = "scheduler_disable_record"
+	schedulerStatsPath         = "scheduler_stats"
 	encryptionKeysPath         = "encryption_keys"
+	storeHistoryPath           = "store_history"
+	storeTombstoneAckPath      = "store_tombstone_ack"
+	scheduleProfilePath        = "schedule_profile"
+	replicasRolloutPath        = "replicas_rollout"
+	storeRemovalQueuePath      = "store_removal_queue"
+	keyspacePath               = "keyspaces"
 	gcWorkerServiceSafePointID = "gc_worker"
 )
 
@@ -59,6 +68,7 @@ type Storage struct {
 	kv.Base
 	regionStorage        *RegionStorage
 	encryptionKeyManager *encryptionkm.KeyManager
+	diskGuard            *diskguard.Guard
 	useRegionStorage     int32
 	regionLoaded         int32
 	mu                   sync.Mutex
@@ -68,6 +78,7 @@ type Storage struct {
 type StorageOpt struct {
 	regionStorage        *RegionStorage
 	encryptionKeyManager *encryptionkm.KeyManager
+	diskGuard            *diskguard.Guard
 }
 
 // StorageOption configures StorageOpt
@@ -87,6 +98,14 @@ func WithEncryptionKeyManager(encryptionKeyManager *encryptionkm.KeyManager) Sto
 	}
 }
 
+// WithDiskGuard sets the disk guard to the Storage, which refuses the region
+// writes and the non-critical writes when the free space of the disk is low.
+func WithDiskGuard(diskGuard *diskguard.Guard) StorageOption {
+	return func(opt *StorageOpt) {
+		opt.diskGuard = diskGuard
+	}
+}
+
 // NewStorage creates Storage instance with Base.
 func NewStorage(base kv.Base, opts ...StorageOption) *Storage {
 	options := &StorageOpt{}
@@ -97,6 +116,7 @@ func NewStorage(base kv.Base, opts ...StorageOption) *Storage {
 		Base:                 base,
 		regionStorage:        options.regionStorage,
 		encryptionKeyManager: options.encryptionKeyManager,
+		diskGuard:            options.diskGuard,
 	}
 }
 
@@ -159,6 +179,33 @@ func (s *Storage) LoadScheduleConfig(scheduleName string) (string, error) {
 	return s.Load(configPath)
 }
 
+// SaveSchedulerDisableRecord saves the record of why the scheduler is paused or disabled.
+func (s *Storage) SaveSchedulerDisableRecord(scheduleName string, data []byte) error {
+	recordPath := path.Join(schedulerDisableRecordPath, scheduleName)
+	return s.Save(recordPath, string(data))
+}
+
+// RemoveSchedulerDisableRecord removes the disable record of scheduler.
+func (s *Storage) RemoveSchedulerDisableRecord(scheduleName string) error {
+	recordPath := path.Join(schedulerDisableRecordPath, scheduleName)
+	return s.Remove(recordPath)
+}
+
+// SaveSchedulerStats saves the runtime statistics of scheduler.
+func (s *Storage) SaveSchedulerStats(scheduleName string, data []byte) error {
+	if err := s.diskGuard.CheckWrite(diskguard.NonCriticalWrite); err != nil {
+		return err
+	}
+	statsPath := path.Join(schedulerStatsPath, scheduleName)
+	return s.Save(statsPath, string(data))
+}
+
+// RemoveSchedulerStats removes the runtime statistics of scheduler.
+func (s *Storage) RemoveSchedulerStats(scheduleName string) error {
+	statsPath := path.Join(schedulerStatsPath, scheduleName)
+	return s.Remove(statsPath)
+}
+
 // LoadMeta loads cluster meta from storage.
 func (s *Storage) LoadMeta(meta *metapb.Cluster) (bool, error) {
 	return loadProto(s.Base, clusterPath, meta)
@@ -218,6 +265,9 @@ func (s *Storage) LoadRegionsOnce(f func(region *RegionInfo) []*RegionInfo) erro
 
 // SaveRegion saves one region to storage.
 func (s *Storage) SaveRegion(region *metapb.Region) error {
+	if err := s.diskGuard.CheckWrite(diskguard.RegionWrite); err != nil {
+		return err
+	}
 	if atomic.LoadInt32(&s.useRegionStorage) > 0 {
 		return s.regionStorage.SaveRegion(region)
 	}
@@ -272,6 +322,18 @@ func (s *Storage) LoadRules(f func(k, v string)) error {
 	return s.LoadRangeByPrefix(rulesPath+"/", f)
 }
 
+// RulesPrefix returns the key prefix of the placement rules, relative to the
+// root path of the storage.
+func RulesPrefix() string {
+	return rulesPath + "/"
+}
+
+// RuleGroupsPrefix returns the key prefix of the rule groups, relative to the
+// root path of the storage.
+func RuleGroupsPrefix() string {
+	return ruleGroupPath + "/"
+}
+
 // SaveRuleGroup stores a rule group config to storage.
 func (s *Storage) SaveRuleGroup(groupID string, group interface{}) error {
 	return s.SaveJSON(ruleGroupPath, groupID, group)
@@ -287,6 +349,50 @@ func (s *Storage) LoadRuleGroups(f func(k, v string)) error {
 	return s.LoadRangeByPrefix(ruleGroupPath+"/", f)
 }
 
+// SaveScheduleProfile stores a schedule profile to storage.
+func (s *Storage) SaveScheduleProfile(name string, profile interface{}) error {
+	return s.SaveJSON(scheduleProfilePath, name, profile)
+}
+
+// DeleteScheduleProfile removes a schedule profile from storage.
+func (s *Storage) DeleteScheduleProfile(name string) error {
+	return s.Remove(path.Join(scheduleProfilePath, name))
+}
+
+// LoadScheduleProfiles loads all schedule profiles from storage.
+func (s *Storage) LoadScheduleProfiles(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(scheduleProfilePath+"/", f)
+}
+
+func keyspaceKey(id uint32) string {
+	return fmt.Sprintf("%08d", id)
+}
+
+// SaveKeyspace stores a keyspace meta to storage.
+func (s *Storage) SaveKeyspace(id uint32, meta interface{}) error {
+	return s.SaveJSON(keyspacePath, keyspaceKey(id), meta)
+}
+
+// LoadKeyspace loads a keyspace meta from storage.
+func (s *Storage) LoadKeyspace(id uint32, meta interface{}) (bool, error) {
+	v, err := s.Load(path.Join(keyspacePath, keyspaceKey(id)))
+	if err != nil {
+		return false, err
+	}
+	if v == "" {
+		return false, nil
+	}
+	if err = json.Unmarshal([]byte(v), meta); err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return true, nil
+}
+
+// LoadKeyspaces loads all keyspace metas from storage in the order of the id.
+func (s *Storage) LoadKeyspaces(f func(k, v string)) error {
+	return s.LoadRangeByPrefix(keyspacePath+"/", f)
+}
+
 // SaveJSON saves json format data to storage.
 func (s *Storage) SaveJSON(prefix, key string, data interface{}) error {
 	value, err := json.Marshal(data)
@@ -315,6 +421,54 @@ func (s *Storage) LoadRangeByPrefix(prefix string, f func(k, v string)) error {
 	}
 }
 
+// SaveReplicasRollout stores the progress of the rollout of max-replicas.
+func (s *Storage) SaveReplicasRollout(rollout interface{}) error {
+	value, err := json.Marshal(rollout)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return s.Save(replicasRolloutPath, string(value))
+}
+
+// LoadReplicasRollout loads the progress of the rollout of max-replicas.
+func (s *Storage) LoadReplicasRollout(rollout interface{}) (bool, error) {
+	v, err := s.Load(replicasRolloutPath)
+	if err != nil {
+		return false, err
+	}
+	if v == "" {
+		return false, nil
+	}
+	if err = json.Unmarshal([]byte(v), rollout); err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return true, nil
+}
+
+// SaveStoreRemovalQueue stores the queue of the stores to be removed.
+func (s *Storage) SaveStoreRemovalQueue(queue interface{}) error {
+	value, err := json.Marshal(queue)
+	if err != nil {
+		return errs.ErrJSONMarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return s.Save(storeRemovalQueuePath, string(value))
+}
+
+// LoadStoreRemovalQueue loads the queue of the stores to be removed.
+func (s *Storage) LoadStoreRemovalQueue(queue interface{}) (bool, error) {
+	v, err := s.Load(storeRemovalQueuePath)
+	if err != nil {
+		return false, err
+	}
+	if v == "" {
+		return false, nil
+	}
+	if err = json.Unmarshal([]byte(v), queue); err != nil {
+		return false, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return true, nil
+}
+
 // SaveReplicationStatus stores replication status by mode.
 func (s *Storage) SaveReplicationStatus(mode string, status interface{}) error {
 	value, err := json.Marshal(status)
@@ -365,6 +519,77 @@ func (s *Storage) LoadComponent(component interface{}) (bool, error) {
 	return true, nil
 }
 
+func storeHistoryPrefix(storeID uint64) string {
+	return path.Join(storeHistoryPath, fmt.Sprintf("%020d", storeID)) + "/"
+}
+
+// SaveStoreStateChange appends a state change to the history of the store.
+// Only the latest maxStoreHistoryCount changes of each store are retained.
+func (s *Storage) SaveStoreStateChange(change *StoreStateChange) error {
+	if err := s.diskGuard.CheckWrite(diskguard.NonCriticalWrite); err != nil {
+		return err
+	}
+	prefix := storeHistoryPrefix(change.StoreID)
+	if err := s.SaveJSON(prefix, fmt.Sprintf("%020d", change.Time.UnixNano()), change); err != nil {
+		return err
+	}
+	var keys []string
+	if err := s.LoadRangeByPrefix(prefix, func(k, v string) { keys = append(keys, k) }); err != nil {
+		return err
+	}
+	// The keys are in ascending order of time, so the oldest ones go first.
+	for i := 0; i < len(keys)-maxStoreHistoryCount; i++ {
+		if err := s.Remove(prefix + keys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadStoreStateChanges loads the state change history of the store, in
+// ascending order of time.
+func (s *Storage) LoadStoreStateChanges(storeID uint64) ([]*StoreStateChange, error) {
+	var changes []*StoreStateChange
+	var err error
+	loadErr := s.LoadRangeByPrefix(storeHistoryPrefix(storeID), func(k, v string) {
+		change := &StoreStateChange{}
+		if e := json.Unmarshal([]byte(v), change); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByArgs()
+			return
+		}
+		changes = append(changes, change)
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SaveStoreTombstoneAck saves the acknowledgement of the tombstone store.
+func (s *Storage) SaveStoreTombstoneAck(ack *StoreTombstoneAck) error {
+	if err := s.diskGuard.CheckWrite(diskguard.NonCriticalWrite); err != nil {
+		return err
+	}
+	return s.SaveJSON(storeTombstoneAckPath, fmt.Sprintf("%020d", ack.StoreID), ack)
+}
+
+// LoadStoreTombstoneAck loads the acknowledgement of the tombstone store, it
+// returns nil if the node of the store is not notified yet.
+func (s *Storage) LoadStoreTombstoneAck(storeID uint64) (*StoreTombstoneAck, error) {
+	value, err := s.Load(path.Join(storeTombstoneAckPath, fmt.Sprintf("%020d", storeID)))
+	if err != nil || value == "" {
+		return nil, err
+	}
+	ack := &StoreTombstoneAck{}
+	if err := json.Unmarshal([]byte(value), ack); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByArgs()
+	}
+	return ack, nil
+}
+
 // LoadStores loads all stores from storage to StoresInfo.
 func (s *Storage) LoadStores(f func(store *StoreInfo)) error {
 	nextID := uint64(0)
@@ -606,6 +831,26 @@ func (s *Storage) LoadAllScheduleConfig() ([]string, []string, error) {
 	return keys, values, err
 }
 
+// LoadAllSchedulerDisableRecords loads the disable records of all schedulers.
+func (s *Storage) LoadAllSchedulerDisableRecords() ([]string, []string, error) {
+	prefix := schedulerDisableRecordPath + "/"
+	keys, values, err := s.LoadRange(prefix, clientv3.GetPrefixRangeEnd(prefix), 1000)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+	return keys, values, err
+}
+
+// LoadAllSchedulerStats loads the runtime statistics of all schedulers.
+func (s *Storage) LoadAllSchedulerStats() ([]string, []string, error) {
+	prefix := schedulerStatsPath + "/"
+	keys, values, err := s.LoadRange(prefix, clientv3.GetPrefixRangeEnd(prefix), 1000)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix)
+	}
+	return keys, values, err
+}
+
 func loadProto(s kv.Base, key string, msg proto.Message) (bool, error) {
 	value, err := s.Load(key)
 	if err != nil {
@@ -123,6 +123,36 @@ func (s *testKVSuite) TestStoreWeight(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestStoreStateChanges(c *C) {
+	storage := NewStorage(kv.NewMemoryKV())
+	start := time.Now()
+	for i := 0; i < maxStoreHistoryCount+10; i++ {
+		change := &StoreStateChange{
+			StoreID: 1,
+			From:    metapb.StoreState_Up.String(),
+			To:      metapb.StoreState_Offline.String(),
+			Trigger: StoreTriggerAPI,
+			Time:    start.Add(time.Duration(i) * time.Second),
+		}
+		c.Assert(storage.SaveStoreStateChange(change), IsNil)
+	}
+	c.Assert(storage.SaveStoreStateChange(&StoreStateChange{StoreID: 2, Time: start}), IsNil)
+
+	// Only the latest changes are retained.
+	changes, err := storage.LoadStoreStateChanges(1)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, maxStoreHistoryCount)
+	c.Assert(changes[0].Time.Equal(start.Add(10*time.Second)), IsTrue)
+	c.Assert(changes[maxStoreHistoryCount-1].Time.Equal(start.Add((maxStoreHistoryCount+9)*time.Second)), IsTrue)
+
+	changes, err = storage.LoadStoreStateChanges(2)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 1)
+	changes, err = storage.LoadStoreStateChanges(3)
+	c.Assert(err, IsNil)
+	c.Assert(changes, HasLen, 0)
+}
+
 func mustSaveRegions(c *C, s *Storage, n int) []*metapb.Region {
 	regions := make([]*metapb.Region, 0, n)
 	for i := 0; i < n; i++ {
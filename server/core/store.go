@@ -49,6 +49,9 @@ type StoreInfo struct {
 	leaderWeight        float64
 	regionWeight        float64
 	available           map[storelimit.Type]func() bool
+	// reservedSpace is the space of the store reserved for the other
+	// processes, which is excluded from the effective capacity.
+	reservedSpace uint64
 }
 
 // NewStoreInfo creates StoreInfo with meta data.
@@ -81,6 +84,7 @@ func (s *StoreInfo) Clone(opts ...StoreCreateOption) *StoreInfo {
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
 		available:           s.available,
+		reservedSpace:       s.reservedSpace,
 	}
 
 	for _, opt := range opts {
@@ -104,6 +108,7 @@ func (s *StoreInfo) ShallowClone(opts ...StoreCreateOption) *StoreInfo {
 		leaderWeight:        s.leaderWeight,
 		regionWeight:        s.regionWeight,
 		available:           s.available,
+		reservedSpace:       s.reservedSpace,
 	}
 
 	for _, opt := range opts {
@@ -259,9 +264,9 @@ func (s *StoreInfo) RegionScore(version string, highSpaceRatio, lowSpaceRatio fl
 func (s *StoreInfo) regionScoreV1(highSpaceRatio, lowSpaceRatio float64, delta int64) float64 {
 	var score float64
 	var amplification float64
-	available := float64(s.GetAvailable()) / mb
+	available := float64(s.GetEffectiveAvailable()) / mb
 	used := float64(s.GetUsedSize()) / mb
-	capacity := float64(s.GetCapacity()) / mb
+	capacity := float64(s.GetEffectiveCapacity()) / mb
 
 	if s.GetRegionSize() == 0 || used == 0 {
 		amplification = 1
@@ -302,8 +307,8 @@ func (s *StoreInfo) regionScoreV1(highSpaceRatio, lowSpaceRatio float64, delta i
 }
 
 func (s *StoreInfo) regionScoreV2(delta int64, lowSpaceRatio float64) float64 {
-	A := float64(s.GetAvgAvailable())
-	C := float64(s.GetCapacity()) / gb
+	A := float64(s.GetEffectiveAvgAvailable())
+	C := float64(s.GetEffectiveCapacity()) / gb
 	R := float64(s.GetRegionSize() + delta)
 	if R < 0 {
 		R = float64(s.GetRegionSize())
@@ -341,12 +346,57 @@ func (s *StoreInfo) StorageSize() uint64 {
 	return s.GetUsedSize()
 }
 
-// AvailableRatio is store's freeSpace/capacity.
+// GetReservedSpace returns the space of the store reserved for the other
+// processes.
+func (s *StoreInfo) GetReservedSpace() uint64 {
+	return s.reservedSpace
+}
+
+// GetEffectiveCapacity returns the capacity of the store excluding the
+// reserved space.
+func (s *StoreInfo) GetEffectiveCapacity() uint64 {
+	capacity := s.GetCapacity()
+	if s.reservedSpace >= capacity {
+		return 0
+	}
+	return capacity - s.reservedSpace
+}
+
+// GetEffectiveAvailable returns the available size of the store excluding the
+// reserved space. The space used by the other processes is covered by the
+// reserved space, so it does not reduce the effective available size unless
+// it exceeds the reserved space.
+func (s *StoreInfo) GetEffectiveAvailable() uint64 {
+	return s.effectiveAvailable(s.GetAvailable())
+}
+
+// GetEffectiveAvgAvailable returns the smoothed available size of the store
+// excluding the reserved space.
+func (s *StoreInfo) GetEffectiveAvgAvailable() uint64 {
+	return s.effectiveAvailable(s.GetAvgAvailable())
+}
+
+func (s *StoreInfo) effectiveAvailable(available uint64) uint64 {
+	if s.reservedSpace == 0 {
+		return available
+	}
+	capacity, used := s.GetEffectiveCapacity(), s.GetUsedSize()
+	if used >= capacity {
+		return 0
+	}
+	if capacity-used < available {
+		return capacity - used
+	}
+	return available
+}
+
+// AvailableRatio is store's freeSpace/capacity, excluding the reserved space.
 func (s *StoreInfo) AvailableRatio() float64 {
-	if s.GetCapacity() == 0 {
+	capacity := s.GetEffectiveCapacity()
+	if capacity == 0 {
 		return 0
 	}
-	return float64(s.GetAvailable()) / float64(s.GetCapacity())
+	return float64(s.GetEffectiveAvailable()) / float64(capacity)
 }
 
 // IsLowSpace checks if the store is lack of space. Not check if region count less
@@ -356,7 +406,7 @@ func (s *StoreInfo) IsLowSpace(lowSpaceRatio float64) bool {
 		return false
 	}
 	// issue #3444
-	if s.regionCount < initialMaxRegionCounts && s.GetAvailable() > initialMinSpace {
+	if s.regionCount < initialMaxRegionCounts && s.GetEffectiveAvailable() > initialMinSpace {
 		return false
 	}
 	return s.AvailableRatio() < 1-lowSpaceRatio
@@ -450,6 +500,22 @@ func (s *StoreInfo) GetLabelValue(key string) string {
 	return ""
 }
 
+const (
+	// EngineKey is the label key used to indicate engine.
+	EngineKey = "engine"
+	// DefaultEngineGroup is the engine group of the stores without the engine label.
+	DefaultEngineGroup = "tikv"
+)
+
+// GetEngineGroup returns the engine group of the store, which is derived from
+// the engine label. Regions are never moved across engine groups.
+func (s *StoreInfo) GetEngineGroup() string {
+	if engine := s.GetLabelValue(EngineKey); engine != "" {
+		return strings.ToLower(engine)
+	}
+	return DefaultEngineGroup
+}
+
 // CompareLocation compares 2 stores' labels and returns at which level their
 // locations are different. It returns -1 if they are at the same location.
 func (s *StoreInfo) CompareLocation(other *StoreInfo, labels []string) int {
@@ -0,0 +1,63 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "time"
+
+// maxStoreHistoryCount is the max number of state changes retained for each
+// store.
+const maxStoreHistoryCount = 100
+
+// The triggers of the store state changes.
+const (
+	// StoreTriggerAPI means the change is requested by users through the API.
+	StoreTriggerAPI = "api"
+	// StoreTriggerPD means the change is made by PD itself, e.g. an offline
+	// store is buried after all its regions are moved out.
+	StoreTriggerPD = "pd"
+)
+
+// StoreStateChange records a state transition of a store, e.g. Up -> Offline.
+type StoreStateChange struct {
+	StoreID             uint64    `json:"store_id"`
+	Address             string    `json:"address"`
+	From                string    `json:"from"`
+	To                  string    `json:"to"`
+	PhysicallyDestroyed bool      `json:"physically_destroyed,omitempty"`
+	Trigger             string    `json:"trigger"`
+	Time                time.Time `json:"time"`
+}
+
+// NewStoreStateChange creates a StoreStateChange from the origin store and the
+// store after the change.
+func NewStoreStateChange(origin, store *StoreInfo, trigger string) *StoreStateChange {
+	return &StoreStateChange{
+		StoreID:             store.GetID(),
+		Address:             store.GetAddress(),
+		From:                origin.GetState().String(),
+		To:                  store.GetState().String(),
+		PhysicallyDestroyed: store.IsPhysicallyDestroyed(),
+		Trigger:             trigger,
+		Time:                time.Now(),
+	}
+}
+
+// StoreTombstoneAck records that the node of a tombstone store confirms that it
+// is notified of the tombstone and has stopped, so its data can be destroyed
+// safely after then.
+type StoreTombstoneAck struct {
+	StoreID uint64    `json:"store_id"`
+	Address string    `json:"address"`
+	Time    time.Time `json:"time"`
+}
@@ -166,6 +166,13 @@ func SetRegionWeight(regionWeight float64) StoreCreateOption {
 	}
 }
 
+// SetReservedSpace sets the space of the store reserved for the other processes.
+func SetReservedSpace(reservedSpace uint64) StoreCreateOption {
+	return func(store *StoreInfo) {
+		store.reservedSpace = reservedSpace
+	}
+}
+
 // SetLastHeartbeatTS sets the time of last heartbeat for the store.
 func SetLastHeartbeatTS(lastHeartbeatTS time.Time) StoreCreateOption {
 	return func(store *StoreInfo) {
@@ -16,6 +16,7 @@ package core
 import (
 	"math"
 	"sync"
+	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/movingaverage"
@@ -33,8 +34,26 @@ type storeStats struct {
 	// `HMA` is used to make it smooth.
 	maxAvailableDeviation    *movingaverage.MaxFilter
 	avgMaxAvailableDeviation *movingaverage.HMA
+
+	// applyingSnapSince is the time since which the store keeps applying
+	// snapshots, zero if it is not applying snapshots.
+	applyingSnapSince time.Time
+	// snapshotHistory records the recent snapshot statistics.
+	snapshotHistory []SnapshotRecord
+}
+
+// SnapshotRecord is the snapshot statistics reported in a store heartbeat.
+type SnapshotRecord struct {
+	Time           time.Time `json:"time"`
+	SendingCount   uint32    `json:"sending_count"`
+	ReceivingCount uint32    `json:"receiving_count"`
+	ApplyingCount  uint32    `json:"applying_count"`
 }
 
+// maxSnapshotHistory is the max number of the snapshot records, which covers
+// 10 minutes under 10s heartbeat rate.
+const maxSnapshotHistory = 60
+
 func newStoreStats() *storeStats {
 	return &storeStats{
 		rawStats:                 &pdpb.StoreStats{},
@@ -48,6 +67,7 @@ func (ss *storeStats) updateRawStats(rawStats *pdpb.StoreStats) {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 	ss.rawStats = rawStats
+	ss.updateSnapshotStats(rawStats)
 
 	if ss.avgAvailable == nil {
 		return
@@ -59,6 +79,24 @@ func (ss *storeStats) updateRawStats(rawStats *pdpb.StoreStats) {
 	ss.avgMaxAvailableDeviation.Add(ss.maxAvailableDeviation.Get())
 }
 
+func (ss *storeStats) updateSnapshotStats(rawStats *pdpb.StoreStats) {
+	now := time.Now()
+	if rawStats.GetApplyingSnapCount() == 0 {
+		ss.applyingSnapSince = time.Time{}
+	} else if ss.applyingSnapSince.IsZero() {
+		ss.applyingSnapSince = now
+	}
+	if len(ss.snapshotHistory) >= maxSnapshotHistory {
+		ss.snapshotHistory = ss.snapshotHistory[1:]
+	}
+	ss.snapshotHistory = append(ss.snapshotHistory, SnapshotRecord{
+		Time:           now,
+		SendingCount:   rawStats.GetSendingSnapCount(),
+		ReceivingCount: rawStats.GetReceivingSnapCount(),
+		ApplyingCount:  rawStats.GetApplyingSnapCount(),
+	})
+}
+
 // GetStoreStats returns the statistics information of the store.
 func (ss *storeStats) GetStoreStats() *pdpb.StoreStats {
 	ss.mu.RLock()
@@ -122,6 +160,31 @@ func (ss *storeStats) IsBusy() bool {
 	return ss.rawStats.GetIsBusy()
 }
 
+// GetApplyingSnapCount returns the current applying snapshot count of the store.
+func (ss *storeStats) GetApplyingSnapCount() uint32 {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return ss.rawStats.GetApplyingSnapCount()
+}
+
+// GetApplyingSnapDuration returns how long the store keeps applying snapshots.
+func (ss *storeStats) GetApplyingSnapDuration() time.Duration {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if ss.applyingSnapSince.IsZero() {
+		return 0
+	}
+	return time.Since(ss.applyingSnapSince)
+}
+
+// GetSnapshotHistory returns the recent snapshot statistics of the store, the
+// oldest one comes first.
+func (ss *storeStats) GetSnapshotHistory() []SnapshotRecord {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return append([]SnapshotRecord(nil), ss.snapshotHistory...)
+}
+
 // GetSendingSnapCount returns the current sending snapshot count of the store.
 func (ss *storeStats) GetSendingSnapCount() uint32 {
 	ss.mu.RLock()
@@ -14,6 +14,8 @@
 package core
 
 import (
+	"time"
+
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -50,3 +52,35 @@ func (s *testStoreStatsSuite) TestStoreStats(c *C) {
 	c.Assert(store.GetAvailableDeviation(), Greater, uint64(0))
 	c.Assert(store.GetAvailableDeviation(), Less, 10*G)
 }
+
+func (s *testStoreStatsSuite) TestSnapshotStats(c *C) {
+	meta := &metapb.Store{Id: 1, State: metapb.StoreState_Up}
+	store := NewStoreInfo(meta, SetStoreStats(&pdpb.StoreStats{SendingSnapCount: 1}))
+	c.Assert(store.GetApplyingSnapCount(), Equals, uint32(0))
+	c.Assert(store.GetApplyingSnapDuration(), Equals, time.Duration(0))
+
+	store = store.Clone(SetStoreStats(&pdpb.StoreStats{ReceivingSnapCount: 2, ApplyingSnapCount: 1}))
+	time.Sleep(10 * time.Millisecond)
+	c.Assert(store.GetApplyingSnapCount(), Equals, uint32(1))
+	c.Assert(store.GetApplyingSnapDuration(), GreaterEqual, 10*time.Millisecond)
+	// The duration keeps growing while the store is still applying snapshots.
+	store = store.Clone(SetStoreStats(&pdpb.StoreStats{ApplyingSnapCount: 2}))
+	c.Assert(store.GetApplyingSnapDuration(), GreaterEqual, 10*time.Millisecond)
+
+	history := store.GetSnapshotHistory()
+	c.Assert(history, HasLen, 3)
+	c.Assert(history[0].SendingCount, Equals, uint32(1))
+	c.Assert(history[1].ReceivingCount, Equals, uint32(2))
+	c.Assert(history[2].ApplyingCount, Equals, uint32(2))
+
+	store = store.Clone(SetStoreStats(&pdpb.StoreStats{}))
+	c.Assert(store.GetApplyingSnapDuration(), Equals, time.Duration(0))
+
+	for i := 0; i < maxSnapshotHistory; i++ {
+		store = store.Clone(SetStoreStats(&pdpb.StoreStats{SendingSnapCount: uint32(i)}))
+	}
+	history = store.GetSnapshotHistory()
+	c.Assert(history, HasLen, maxSnapshotHistory)
+	c.Assert(history[0].SendingCount, Equals, uint32(0))
+	c.Assert(history[maxSnapshotHistory-1].SendingCount, Equals, uint32(maxSnapshotHistory-1))
+}
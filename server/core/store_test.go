@@ -126,3 +126,38 @@ func (s *testStoreSuite) TestLowSpaceRatio(c *C) {
 	store.rawStats.Available = store.rawStats.Capacity >> 2
 	c.Assert(store.IsLowSpace(0.8), Equals, false)
 }
+
+func (s *testStoreSuite) TestReservedSpace(c *C) {
+	stats := &pdpb.StoreStats{
+		Capacity:  100 * gb,
+		Available: 50 * gb,
+		UsedSize:  30 * gb,
+	}
+	store := NewStoreInfo(&metapb.Store{Id: 1}, SetStoreStats(stats), SetRegionCount(100))
+	c.Assert(store.GetEffectiveCapacity(), Equals, uint64(100*gb))
+	c.Assert(store.GetEffectiveAvailable(), Equals, uint64(50*gb))
+	c.Assert(store.IsLowSpace(0.8), IsFalse)
+
+	// The other processes use 20GB, which is covered by the reserved space.
+	store = store.Clone(SetReservedSpace(30 * gb))
+	c.Assert(store.GetEffectiveCapacity(), Equals, uint64(70*gb))
+	c.Assert(store.GetEffectiveAvailable(), Equals, uint64(40*gb))
+	c.Assert(store.AvailableRatio(), Equals, 40.0/70.0)
+
+	// The other processes use 40GB, which exceeds the reserved space.
+	stats.Available = 30 * gb
+	store = store.Clone(SetStoreStats(stats))
+	c.Assert(store.GetEffectiveAvailable(), Equals, uint64(30*gb))
+
+	// The reserved space leaves little space for TiKV.
+	store = store.Clone(SetReservedSpace(60 * gb))
+	c.Assert(store.GetEffectiveCapacity(), Equals, uint64(40*gb))
+	c.Assert(store.GetEffectiveAvailable(), Equals, uint64(10*gb))
+	c.Assert(store.IsLowSpace(0.8), IsFalse)
+	store = store.Clone(SetReservedSpace(70 * gb))
+	c.Assert(store.GetEffectiveAvailable(), Equals, uint64(0))
+	c.Assert(store.IsLowSpace(0.8), IsTrue)
+	store = store.Clone(SetReservedSpace(200 * gb))
+	c.Assert(store.GetEffectiveCapacity(), Equals, uint64(0))
+	c.Assert(store.IsLowSpace(0.8), IsTrue)
+}
@@ -15,7 +15,9 @@ package election
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -28,6 +30,17 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxElectionHistory is the max count of the election records kept by a
+// leadership.
+const maxElectionHistory = 16
+
+// The events of the election records.
+const (
+	campaignSucceededEvent = "campaign-succeeded"
+	campaignFailedEvent    = "campaign-failed"
+	resetEvent             = "reset"
+)
+
 // GetLeader gets the corresponding leader from etcd by given leaderPath (as the key).
 func GetLeader(c *clientv3.Client, leaderPath string) (*pdpb.Member, int64, error) {
 	leader := &pdpb.Member{}
@@ -52,6 +65,27 @@ type Leadership struct {
 	// leaderKey and leaderValue are key-value pair in etcd
 	leaderKey   string
 	leaderValue string
+	// fencingToken is the create revision of the leader key written by the
+	// last successful campaign, which is unique among the terms even if the
+	// same member is elected again. It is kept after the leadership is reset,
+	// so the writes made after then are still compared with it and rejected.
+	// 0 means the leadership has never been held.
+	fencingToken int64
+
+	mu struct {
+		sync.RWMutex
+		lastCampaignTime time.Time
+		// history is the recent election records, the oldest one is the first.
+		history []ElectionRecord
+	}
+}
+
+// ElectionRecord is a record of the campaign or the reset of the leadership.
+type ElectionRecord struct {
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	LeaseID int64     `json:"lease_id,omitempty"`
+	Error   string    `json:"error,omitempty"`
 }
 
 // NewLeadership creates a new Leadership.
@@ -89,7 +123,17 @@ func (ls *Leadership) GetLeaderKey() string {
 }
 
 // Campaign is used to campaign the leader with given lease and returns a leadership
-func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string, cmps ...clientv3.Cmp) error {
+func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string, cmps ...clientv3.Cmp) (err error) {
+	ls.mu.Lock()
+	ls.mu.lastCampaignTime = time.Now()
+	ls.mu.Unlock()
+	defer func() {
+		if err != nil {
+			ls.record(campaignFailedEvent, err)
+		} else {
+			ls.record(campaignSucceededEvent, nil)
+		}
+	}()
 	ls.leaderValue = leaderData
 	// Create a new lease to campaign
 	ls.setLease(&lease{
@@ -97,7 +141,7 @@ func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string, cmps ...cl
 		client:  ls.client,
 		lease:   clientv3.NewLease(ls.client),
 	})
-	if err := ls.getLease().Grant(leaseTimeout); err != nil {
+	if err = ls.getLease().Grant(leaseTimeout); err != nil {
 		return err
 	}
 	finalCmps := make([]clientv3.Cmp, 0, len(cmps)+1)
@@ -117,10 +161,39 @@ func (ls *Leadership) Campaign(leaseTimeout int64, leaderData string, cmps ...cl
 		ls.getLease().Close()
 		return errs.ErrEtcdTxnConflict.FastGenByArgs()
 	}
-	log.Info("write leaderData to leaderPath ok", zap.String("leaderPath", ls.leaderKey), zap.String("purpose", ls.purpose))
+	// The leader key is created by this transaction, so its create revision is
+	// the revision of the transaction.
+	atomic.StoreInt64(&ls.fencingToken, resp.Header.GetRevision())
+	log.Info("write leaderData to leaderPath ok",
+		zap.String("leaderPath", ls.leaderKey),
+		zap.String("purpose", ls.purpose),
+		zap.Int64("fencing-token", resp.Header.GetRevision()))
 	return nil
 }
 
+// GetFencingToken returns the fencing token of the last campaign of the
+// leadership, 0 means the leadership has never been held.
+func (ls *Leadership) GetFencingToken() int64 {
+	if ls == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&ls.fencingToken)
+}
+
+// FenceCmps returns the comparisons which guard the writes made as leader.
+// The writes are rejected if the leader key is not created by the last
+// campaign of this leadership, e.g. the leader is paused and a new leader is
+// elected, or the leadership is reset. If the leadership has never been held,
+// the comparison always fails.
+func (ls *Leadership) FenceCmps() []clientv3.Cmp {
+	token := ls.GetFencingToken()
+	if token == 0 {
+		// The create revision of a key is never negative.
+		return []clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision(ls.leaderKey), "<", 0)}
+	}
+	return []clientv3.Cmp{clientv3.Compare(clientv3.CreateRevision(ls.leaderKey), "=", token)}
+}
+
 // Keep will keep the leadership available by update the lease's expired time continuously
 func (ls *Leadership) Keep(ctx context.Context) {
 	ls.getLease().KeepAlive(ctx)
@@ -135,7 +208,8 @@ func (ls *Leadership) Check() bool {
 // the transaction can be executed only if the server is leader.
 func (ls *Leadership) LeaderTxn(cs ...clientv3.Cmp) clientv3.Txn {
 	txn := kv.NewSlowLogTxn(ls.client)
-	return txn.If(append(cs, ls.leaderCmp())...)
+	cs = append(cs, ls.leaderCmp())
+	return txn.If(append(cs, ls.FenceCmps()...)...)
 }
 
 func (ls *Leadership) leaderCmp() clientv3.Cmp {
@@ -213,4 +287,93 @@ func (ls *Leadership) Reset() {
 		return
 	}
 	ls.getLease().Close()
+	ls.record(resetEvent, nil)
+}
+
+// record appends an election record to the history.
+func (ls *Leadership) record(event string, err error) {
+	r := ElectionRecord{
+		Time:  time.Now(),
+		Event: event,
+	}
+	if l := ls.getLease(); l != nil {
+		r.LeaseID = int64(l.ID)
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.mu.history = append(ls.mu.history, r)
+	if len(ls.mu.history) > maxElectionHistory {
+		ls.mu.history = ls.mu.history[len(ls.mu.history)-maxElectionHistory:]
+	}
+}
+
+// LeadershipInfo is the information of the leadership for debugging, which
+// includes the leader key in etcd and the local state of the leadership.
+type LeadershipInfo struct {
+	Purpose   string `json:"purpose"`
+	LeaderKey string `json:"leader_key"`
+	// LeaderValue is the raw value of the leader key, it is empty if there is
+	// no leader.
+	LeaderValue          string `json:"-"`
+	LeaderCreateRevision int64  `json:"leader_create_revision,omitempty"`
+	LeaderModRevision    int64  `json:"leader_mod_revision,omitempty"`
+	// LeaseID, LeaseTTL and LeaseRemainingTTL are of the lease attached to the
+	// leader key, the TTLs are in seconds.
+	LeaseID           int64 `json:"lease_id,omitempty"`
+	LeaseTTL          int64 `json:"lease_ttl,omitempty"`
+	LeaseRemainingTTL int64 `json:"lease_remaining_ttl,omitempty"`
+	// Held is whether the leadership is held by this member, and the
+	// LocalLeaseExpireTime is when this member regards its lease as expired.
+	Held                 bool             `json:"held"`
+	LocalLeaseExpireTime *time.Time       `json:"local_lease_expire_time,omitempty"`
+	LastCampaignTime     *time.Time       `json:"last_campaign_time,omitempty"`
+	History              []ElectionRecord `json:"history"`
+}
+
+// GetLeadershipInfo returns the information of the leadership, the leader key
+// and its lease are read from etcd.
+func (ls *Leadership) GetLeadershipInfo() (*LeadershipInfo, error) {
+	info := &LeadershipInfo{
+		Purpose:   ls.purpose,
+		LeaderKey: ls.leaderKey,
+		Held:      ls.Check(),
+	}
+	resp, err := etcdutil.EtcdKVGet(ls.client, ls.leaderKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) > 0 {
+		kv := resp.Kvs[0]
+		info.LeaderValue = string(kv.Value)
+		info.LeaderCreateRevision = kv.CreateRevision
+		info.LeaderModRevision = kv.ModRevision
+		info.LeaseID = kv.Lease
+	}
+	if info.LeaseID != 0 {
+		ctx, cancel := context.WithTimeout(ls.client.Ctx(), requestTimeout)
+		ttl, err := ls.client.TimeToLive(ctx, clientv3.LeaseID(info.LeaseID))
+		cancel()
+		if err != nil {
+			return nil, errs.ErrEtcdLeaseTimeToLive.Wrap(err).GenWithStackByCause()
+		}
+		info.LeaseTTL = ttl.GrantedTTL
+		info.LeaseRemainingTTL = ttl.TTL
+	}
+	if l := ls.getLease(); l != nil {
+		if expireTime, ok := l.expireTime.Load().(time.Time); ok && !expireTime.IsZero() {
+			info.LocalLeaseExpireTime = &expireTime
+		}
+	}
+
+	ls.mu.RLock()
+	defer ls.mu.RUnlock()
+	if !ls.mu.lastCampaignTime.IsZero() {
+		lastCampaignTime := ls.mu.lastCampaignTime
+		info.LastCampaignTime = &lastCampaignTime
+	}
+	info.History = append([]ElectionRecord{}, ls.mu.history...)
+	return info, nil
 }
@@ -19,7 +19,9 @@ import (
 	"time"
 
 	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/server/kv"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
 )
@@ -103,4 +105,88 @@ func (s *testLeadershipSuite) TestLeadership(c *C) {
 
 	c.Assert(leadership1.Check(), IsFalse)
 	c.Assert(leadership2.Check(), IsTrue)
+
+	// Check the leadership information.
+	info, err := leadership2.GetLeadershipInfo()
+	c.Assert(err, IsNil)
+	c.Assert(info.LeaderKey, Equals, "/test_leader")
+	c.Assert(info.LeaderValue, Equals, "test_leader_2")
+	c.Assert(info.Held, IsTrue)
+	c.Assert(info.LeaseID, Not(Equals), int64(0))
+	c.Assert(info.LeaseTTL, GreaterEqual, int64(defaultTestLeaderLease))
+	c.Assert(info.LastCampaignTime, NotNil)
+	events := make([]string, 0, len(info.History))
+	for _, r := range info.History {
+		events = append(events, r.Event)
+	}
+	c.Assert(events, DeepEquals, []string{campaignFailedEvent, campaignSucceededEvent})
+	c.Assert(info.History[1].LeaseID, Equals, info.LeaseID)
+
+	info, err = leadership1.GetLeadershipInfo()
+	c.Assert(err, IsNil)
+	c.Assert(info.Held, IsFalse)
+	c.Assert(info.History[len(info.History)-1].Event, Equals, resetEvent)
+}
+
+func (s *testLeadershipSuite) TestFencingToken(c *C) {
+	cfg := etcdutil.NewTestSingleConfig()
+	etcd, err := embed.StartEtcd(cfg)
+	defer func() {
+		etcd.Close()
+		etcdutil.CleanConfig(cfg)
+	}()
+	c.Assert(err, IsNil)
+
+	ep := cfg.LCUrls[0].String()
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints: []string{ep},
+	})
+	c.Assert(err, IsNil)
+
+	<-etcd.Server.ReadyNotify()
+
+	leadership1 := NewLeadership(client, "/test_leader", "test_leader_1")
+	leadership2 := NewLeadership(client, "/test_leader", "test_leader_2")
+	kv1 := kv.NewFencedEtcdKVBase(client, "/test", leadership1.FenceCmps)
+	kv2 := kv.NewFencedEtcdKVBase(client, "/test", leadership2.FenceCmps)
+
+	// The writes are rejected before the leadership is ever held.
+	c.Assert(leadership1.GetFencingToken(), Equals, int64(0))
+	c.Assert(errs.ErrEtcdFenced.Equal(kv1.Save("key", "0")), IsTrue)
+	c.Assert(kv.NewEtcdKVBase(client, "/test").Save("key", "0"), IsNil)
+
+	c.Assert(leadership1.Campaign(defaultTestLeaderLease, "test_leader_1"), IsNil)
+	token1 := leadership1.GetFencingToken()
+	c.Assert(token1, Greater, int64(0))
+	c.Assert(kv1.Save("key", "1"), IsNil)
+
+	// leadership2 becomes the leader while leadership1 still believes it is
+	// the leader, e.g. leadership1 is paused longer than the lease.
+	_, err = client.Delete(context.Background(), "/test_leader")
+	c.Assert(err, IsNil)
+	c.Assert(leadership2.Campaign(defaultTestLeaderLease, "test_leader_2"), IsNil)
+	c.Assert(leadership2.GetFencingToken(), Greater, token1)
+	c.Assert(kv2.Save("key", "2"), IsNil)
+
+	// The writes of the stale leader are rejected.
+	err = kv1.Save("key", "3")
+	c.Assert(errs.ErrEtcdFenced.Equal(err), IsTrue)
+	c.Assert(errs.ErrEtcdFenced.Equal(kv1.Remove("key")), IsTrue)
+	v, err := kv2.Load("key")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "2")
+
+	// The writes are rejected once the leadership is lost, e.g. the in-flight
+	// writes of a leader which is resigned.
+	_, err = client.Delete(context.Background(), "/test_leader")
+	c.Assert(err, IsNil)
+	c.Assert(leadership1.Campaign(defaultTestLeaderLease, "test_leader_1"), IsNil)
+	token3 := leadership1.GetFencingToken()
+	c.Assert(kv1.Save("key", "4"), IsNil)
+	c.Assert(leadership1.DeleteLeaderKey(), IsNil)
+	c.Assert(leadership1.GetFencingToken(), Equals, token3)
+	c.Assert(errs.ErrEtcdFenced.Equal(kv1.Save("key", "5")), IsTrue)
+	v, err = kv1.Load("key")
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "4")
 }
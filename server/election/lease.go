@@ -26,9 +26,13 @@ import (
 )
 
 const (
-	revokeLeaseTimeout = time.Second
-	requestTimeout     = etcdutil.DefaultRequestTimeout
-	slowRequestTime    = etcdutil.DefaultSlowRequestTime
+	// leaseClockDriftRatio is the ratio of the lease TTL reserved for the clock
+	// drift between PD and etcd. The lease is regarded as expired earlier than
+	// etcd does, so the old leader steps down before a new one is elected.
+	leaseClockDriftRatio = 0.1
+	revokeLeaseTimeout   = time.Second
+	requestTimeout       = etcdutil.DefaultRequestTimeout
+	slowRequestTime      = etcdutil.DefaultSlowRequestTime
 )
 
 // lease is used as the low-level mechanism for campaigning and renewing elected leadership.
@@ -60,10 +64,17 @@ func (l *lease) Grant(leaseTimeout int64) error {
 	log.Info("lease granted", zap.Int64("lease-id", int64(leaseResp.ID)), zap.Int64("lease-timeout", leaseTimeout), zap.String("purpose", l.Purpose))
 	l.ID = leaseResp.ID
 	l.leaseTimeout = time.Duration(leaseTimeout) * time.Second
-	l.expireTime.Store(start.Add(time.Duration(leaseResp.TTL) * time.Second))
+	l.expireTime.Store(leaseExpireTime(start, leaseResp.TTL))
 	return nil
 }
 
+// leaseExpireTime returns the expire time of the lease whose TTL is renewed
+// by the request sent at the start time.
+func leaseExpireTime(start time.Time, ttl int64) time.Time {
+	d := time.Duration(ttl) * time.Second
+	return start.Add(d - time.Duration(float64(d)*leaseClockDriftRatio))
+}
+
 // Close releases the lease.
 func (l *lease) Close() error {
 	// Reset expire time.
@@ -129,7 +140,7 @@ func (l *lease) keepAliveWorker(ctx context.Context, interval time.Duration) <-c
 					return
 				}
 				if res.TTL > 0 {
-					expire := start.Add(time.Duration(res.TTL) * time.Second)
+					expire := leaseExpireTime(start, res.TTL)
 					select {
 					case ch <- expire:
 					case <-ctx1.Done():
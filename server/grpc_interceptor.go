@@ -0,0 +1,409 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"google.golang.org/grpc"
+)
+
+const pdServiceName = "pdpb.PD"
+
+// GRPCInterceptors are the extra interceptors of the PD gRPC service, which
+// are used to inject faults such as latency or authentication failures in
+// tests. The interceptors run in order after the ones of the embedded etcd.
+type GRPCInterceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// SetGRPCInterceptors sets the extra interceptors of the PD gRPC service, it
+// must be called before the server runs.
+func (s *Server) SetGRPCInterceptors(interceptors *GRPCInterceptors) {
+	s.grpcInterceptors = interceptors
+}
+
+// registerPDService registers the PD gRPC service with the interceptors, the
+// extra ones run before the one tracking the requests. The gRPC server is
+// created by the embedded etcd, which does not accept server options, so the
+// interceptors are run by pdService.
+func (s *Server) registerPDService(gs *grpc.Server) {
+	svc := &pdService{Server: s}
+	if s.grpcInterceptors != nil {
+		svc.unaryInterceptors = append(svc.unaryInterceptors, s.grpcInterceptors.Unary...)
+		svc.streamInterceptors = append(svc.streamInterceptors, s.grpcInterceptors.Stream...)
+	}
+	svc.unaryInterceptors = append(svc.unaryInterceptors, s.trackRequest)
+	pdpb.RegisterPDServer(gs, svc)
+}
+
+// pdService is the PD gRPC service which runs the interceptors before calling
+// the handlers of the server.
+type pdService struct {
+	*Server
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
+}
+
+// unary runs the unary interceptors and then the handler of the method.
+func (s *pdService) unary(ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{
+		Server:     s.Server,
+		FullMethod: "/" + pdServiceName + "/" + method,
+	}
+	for i := len(s.unaryInterceptors) - 1; i >= 0; i-- {
+		handler = chainUnaryHandler(s.unaryInterceptors[i], info, handler)
+	}
+	return handler(ctx, req)
+}
+
+func chainUnaryHandler(interceptor grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptor(ctx, req, info, next)
+	}
+}
+
+// stream runs the stream interceptors and then the handler of the method, all
+// the streams of the PD service are bidirectional.
+func (s *pdService) stream(method string, stream grpc.ServerStream, handler grpc.StreamHandler) error {
+	info := &grpc.StreamServerInfo{
+		FullMethod:     "/" + pdServiceName + "/" + method,
+		IsClientStream: true,
+		IsServerStream: true,
+	}
+	for i := len(s.streamInterceptors) - 1; i >= 0; i-- {
+		handler = chainStreamHandler(s.streamInterceptors[i], info, handler)
+	}
+	return handler(s.Server, stream)
+}
+
+func chainStreamHandler(interceptor grpc.StreamServerInterceptor, info *grpc.StreamServerInfo, next grpc.StreamHandler) grpc.StreamHandler {
+	return func(srv interface{}, stream grpc.ServerStream) error {
+		return interceptor(srv, stream, info, next)
+	}
+}
+
+// GetMembers implements gRPC PDServer.
+func (s *pdService) GetMembers(ctx context.Context, request *pdpb.GetMembersRequest) (*pdpb.GetMembersResponse, error) {
+	resp, err := s.unary(ctx, "GetMembers", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetMembers(ctx, req.(*pdpb.GetMembersRequest))
+	})
+	r, _ := resp.(*pdpb.GetMembersResponse)
+	return r, err
+}
+
+// Bootstrap implements gRPC PDServer.
+func (s *pdService) Bootstrap(ctx context.Context, request *pdpb.BootstrapRequest) (*pdpb.BootstrapResponse, error) {
+	resp, err := s.unary(ctx, "Bootstrap", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.Bootstrap(ctx, req.(*pdpb.BootstrapRequest))
+	})
+	r, _ := resp.(*pdpb.BootstrapResponse)
+	return r, err
+}
+
+// IsBootstrapped implements gRPC PDServer.
+func (s *pdService) IsBootstrapped(ctx context.Context, request *pdpb.IsBootstrappedRequest) (*pdpb.IsBootstrappedResponse, error) {
+	resp, err := s.unary(ctx, "IsBootstrapped", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.IsBootstrapped(ctx, req.(*pdpb.IsBootstrappedRequest))
+	})
+	r, _ := resp.(*pdpb.IsBootstrappedResponse)
+	return r, err
+}
+
+// AllocID implements gRPC PDServer.
+func (s *pdService) AllocID(ctx context.Context, request *pdpb.AllocIDRequest) (*pdpb.AllocIDResponse, error) {
+	resp, err := s.unary(ctx, "AllocID", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.AllocID(ctx, req.(*pdpb.AllocIDRequest))
+	})
+	r, _ := resp.(*pdpb.AllocIDResponse)
+	return r, err
+}
+
+// GetStore implements gRPC PDServer.
+func (s *pdService) GetStore(ctx context.Context, request *pdpb.GetStoreRequest) (*pdpb.GetStoreResponse, error) {
+	resp, err := s.unary(ctx, "GetStore", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetStore(ctx, req.(*pdpb.GetStoreRequest))
+	})
+	r, _ := resp.(*pdpb.GetStoreResponse)
+	return r, err
+}
+
+// PutStore implements gRPC PDServer.
+func (s *pdService) PutStore(ctx context.Context, request *pdpb.PutStoreRequest) (*pdpb.PutStoreResponse, error) {
+	resp, err := s.unary(ctx, "PutStore", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.PutStore(ctx, req.(*pdpb.PutStoreRequest))
+	})
+	r, _ := resp.(*pdpb.PutStoreResponse)
+	return r, err
+}
+
+// GetAllStores implements gRPC PDServer.
+func (s *pdService) GetAllStores(ctx context.Context, request *pdpb.GetAllStoresRequest) (*pdpb.GetAllStoresResponse, error) {
+	resp, err := s.unary(ctx, "GetAllStores", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetAllStores(ctx, req.(*pdpb.GetAllStoresRequest))
+	})
+	r, _ := resp.(*pdpb.GetAllStoresResponse)
+	return r, err
+}
+
+// StoreHeartbeat implements gRPC PDServer.
+func (s *pdService) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbeatRequest) (*pdpb.StoreHeartbeatResponse, error) {
+	resp, err := s.unary(ctx, "StoreHeartbeat", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.StoreHeartbeat(ctx, req.(*pdpb.StoreHeartbeatRequest))
+	})
+	r, _ := resp.(*pdpb.StoreHeartbeatResponse)
+	return r, err
+}
+
+// GetRegion implements gRPC PDServer.
+func (s *pdService) GetRegion(ctx context.Context, request *pdpb.GetRegionRequest) (*pdpb.GetRegionResponse, error) {
+	resp, err := s.unary(ctx, "GetRegion", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetRegion(ctx, req.(*pdpb.GetRegionRequest))
+	})
+	r, _ := resp.(*pdpb.GetRegionResponse)
+	return r, err
+}
+
+// GetPrevRegion implements gRPC PDServer.
+func (s *pdService) GetPrevRegion(ctx context.Context, request *pdpb.GetRegionRequest) (*pdpb.GetRegionResponse, error) {
+	resp, err := s.unary(ctx, "GetPrevRegion", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetPrevRegion(ctx, req.(*pdpb.GetRegionRequest))
+	})
+	r, _ := resp.(*pdpb.GetRegionResponse)
+	return r, err
+}
+
+// GetRegionByID implements gRPC PDServer.
+func (s *pdService) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDRequest) (*pdpb.GetRegionResponse, error) {
+	resp, err := s.unary(ctx, "GetRegionByID", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetRegionByID(ctx, req.(*pdpb.GetRegionByIDRequest))
+	})
+	r, _ := resp.(*pdpb.GetRegionResponse)
+	return r, err
+}
+
+// ScanRegions implements gRPC PDServer.
+func (s *pdService) ScanRegions(ctx context.Context, request *pdpb.ScanRegionsRequest) (*pdpb.ScanRegionsResponse, error) {
+	resp, err := s.unary(ctx, "ScanRegions", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.ScanRegions(ctx, req.(*pdpb.ScanRegionsRequest))
+	})
+	r, _ := resp.(*pdpb.ScanRegionsResponse)
+	return r, err
+}
+
+// AskSplit implements gRPC PDServer.
+func (s *pdService) AskSplit(ctx context.Context, request *pdpb.AskSplitRequest) (*pdpb.AskSplitResponse, error) {
+	resp, err := s.unary(ctx, "AskSplit", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.AskSplit(ctx, req.(*pdpb.AskSplitRequest))
+	})
+	r, _ := resp.(*pdpb.AskSplitResponse)
+	return r, err
+}
+
+// ReportSplit implements gRPC PDServer.
+func (s *pdService) ReportSplit(ctx context.Context, request *pdpb.ReportSplitRequest) (*pdpb.ReportSplitResponse, error) {
+	resp, err := s.unary(ctx, "ReportSplit", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.ReportSplit(ctx, req.(*pdpb.ReportSplitRequest))
+	})
+	r, _ := resp.(*pdpb.ReportSplitResponse)
+	return r, err
+}
+
+// AskBatchSplit implements gRPC PDServer.
+func (s *pdService) AskBatchSplit(ctx context.Context, request *pdpb.AskBatchSplitRequest) (*pdpb.AskBatchSplitResponse, error) {
+	resp, err := s.unary(ctx, "AskBatchSplit", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.AskBatchSplit(ctx, req.(*pdpb.AskBatchSplitRequest))
+	})
+	r, _ := resp.(*pdpb.AskBatchSplitResponse)
+	return r, err
+}
+
+// ReportBatchSplit implements gRPC PDServer.
+func (s *pdService) ReportBatchSplit(ctx context.Context, request *pdpb.ReportBatchSplitRequest) (*pdpb.ReportBatchSplitResponse, error) {
+	resp, err := s.unary(ctx, "ReportBatchSplit", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.ReportBatchSplit(ctx, req.(*pdpb.ReportBatchSplitRequest))
+	})
+	r, _ := resp.(*pdpb.ReportBatchSplitResponse)
+	return r, err
+}
+
+// GetClusterConfig implements gRPC PDServer.
+func (s *pdService) GetClusterConfig(ctx context.Context, request *pdpb.GetClusterConfigRequest) (*pdpb.GetClusterConfigResponse, error) {
+	resp, err := s.unary(ctx, "GetClusterConfig", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetClusterConfig(ctx, req.(*pdpb.GetClusterConfigRequest))
+	})
+	r, _ := resp.(*pdpb.GetClusterConfigResponse)
+	return r, err
+}
+
+// PutClusterConfig implements gRPC PDServer.
+func (s *pdService) PutClusterConfig(ctx context.Context, request *pdpb.PutClusterConfigRequest) (*pdpb.PutClusterConfigResponse, error) {
+	resp, err := s.unary(ctx, "PutClusterConfig", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.PutClusterConfig(ctx, req.(*pdpb.PutClusterConfigRequest))
+	})
+	r, _ := resp.(*pdpb.PutClusterConfigResponse)
+	return r, err
+}
+
+// ScatterRegion implements gRPC PDServer.
+func (s *pdService) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionRequest) (*pdpb.ScatterRegionResponse, error) {
+	resp, err := s.unary(ctx, "ScatterRegion", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.ScatterRegion(ctx, req.(*pdpb.ScatterRegionRequest))
+	})
+	r, _ := resp.(*pdpb.ScatterRegionResponse)
+	return r, err
+}
+
+// GetGCSafePoint implements gRPC PDServer.
+func (s *pdService) GetGCSafePoint(ctx context.Context, request *pdpb.GetGCSafePointRequest) (*pdpb.GetGCSafePointResponse, error) {
+	resp, err := s.unary(ctx, "GetGCSafePoint", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetGCSafePoint(ctx, req.(*pdpb.GetGCSafePointRequest))
+	})
+	r, _ := resp.(*pdpb.GetGCSafePointResponse)
+	return r, err
+}
+
+// UpdateGCSafePoint implements gRPC PDServer.
+func (s *pdService) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSafePointRequest) (*pdpb.UpdateGCSafePointResponse, error) {
+	resp, err := s.unary(ctx, "UpdateGCSafePoint", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.UpdateGCSafePoint(ctx, req.(*pdpb.UpdateGCSafePointRequest))
+	})
+	r, _ := resp.(*pdpb.UpdateGCSafePointResponse)
+	return r, err
+}
+
+// UpdateServiceGCSafePoint implements gRPC PDServer.
+func (s *pdService) UpdateServiceGCSafePoint(ctx context.Context, request *pdpb.UpdateServiceGCSafePointRequest) (*pdpb.UpdateServiceGCSafePointResponse, error) {
+	resp, err := s.unary(ctx, "UpdateServiceGCSafePoint", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.UpdateServiceGCSafePoint(ctx, req.(*pdpb.UpdateServiceGCSafePointRequest))
+	})
+	r, _ := resp.(*pdpb.UpdateServiceGCSafePointResponse)
+	return r, err
+}
+
+// GetOperator implements gRPC PDServer.
+func (s *pdService) GetOperator(ctx context.Context, request *pdpb.GetOperatorRequest) (*pdpb.GetOperatorResponse, error) {
+	resp, err := s.unary(ctx, "GetOperator", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetOperator(ctx, req.(*pdpb.GetOperatorRequest))
+	})
+	r, _ := resp.(*pdpb.GetOperatorResponse)
+	return r, err
+}
+
+// SyncMaxTS implements gRPC PDServer.
+func (s *pdService) SyncMaxTS(ctx context.Context, request *pdpb.SyncMaxTSRequest) (*pdpb.SyncMaxTSResponse, error) {
+	resp, err := s.unary(ctx, "SyncMaxTS", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.SyncMaxTS(ctx, req.(*pdpb.SyncMaxTSRequest))
+	})
+	r, _ := resp.(*pdpb.SyncMaxTSResponse)
+	return r, err
+}
+
+// SplitRegions implements gRPC PDServer.
+func (s *pdService) SplitRegions(ctx context.Context, request *pdpb.SplitRegionsRequest) (*pdpb.SplitRegionsResponse, error) {
+	resp, err := s.unary(ctx, "SplitRegions", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.SplitRegions(ctx, req.(*pdpb.SplitRegionsRequest))
+	})
+	r, _ := resp.(*pdpb.SplitRegionsResponse)
+	return r, err
+}
+
+// GetDCLocationInfo implements gRPC PDServer.
+func (s *pdService) GetDCLocationInfo(ctx context.Context, request *pdpb.GetDCLocationInfoRequest) (*pdpb.GetDCLocationInfoResponse, error) {
+	resp, err := s.unary(ctx, "GetDCLocationInfo", request, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Server.GetDCLocationInfo(ctx, req.(*pdpb.GetDCLocationInfoRequest))
+	})
+	r, _ := resp.(*pdpb.GetDCLocationInfoResponse)
+	return r, err
+}
+
+// Tso implements gRPC PDServer.
+func (s *pdService) Tso(stream pdpb.PD_TsoServer) error {
+	return s.stream("Tso", stream, func(_ interface{}, ss grpc.ServerStream) error {
+		if stream, ok := ss.(pdpb.PD_TsoServer); ok {
+			return s.Server.Tso(stream)
+		}
+		return s.Server.Tso(&pdTsoServer{ss})
+	})
+}
+
+// RegionHeartbeat implements gRPC PDServer.
+func (s *pdService) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
+	return s.stream("RegionHeartbeat", stream, func(_ interface{}, ss grpc.ServerStream) error {
+		if stream, ok := ss.(pdpb.PD_RegionHeartbeatServer); ok {
+			return s.Server.RegionHeartbeat(stream)
+		}
+		return s.Server.RegionHeartbeat(&pdRegionHeartbeatServer{ss})
+	})
+}
+
+// SyncRegions implements gRPC PDServer.
+func (s *pdService) SyncRegions(stream pdpb.PD_SyncRegionsServer) error {
+	return s.stream("SyncRegions", stream, func(_ interface{}, ss grpc.ServerStream) error {
+		if stream, ok := ss.(pdpb.PD_SyncRegionsServer); ok {
+			return s.Server.SyncRegions(stream)
+		}
+		return s.Server.SyncRegions(&pdSyncRegionsServer{ss})
+	})
+}
+
+type pdTsoServer struct {
+	grpc.ServerStream
+}
+
+func (s *pdTsoServer) Send(m *pdpb.TsoResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *pdTsoServer) Recv() (*pdpb.TsoRequest, error) {
+	m := new(pdpb.TsoRequest)
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type pdRegionHeartbeatServer struct {
+	grpc.ServerStream
+}
+
+func (s *pdRegionHeartbeatServer) Send(m *pdpb.RegionHeartbeatResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *pdRegionHeartbeatServer) Recv() (*pdpb.RegionHeartbeatRequest, error) {
+	m := new(pdpb.RegionHeartbeatRequest)
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type pdSyncRegionsServer struct {
+	grpc.ServerStream
+}
+
+func (s *pdSyncRegionsServer) Send(m *pdpb.SyncRegionResponse) error {
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *pdSyncRegionsServer) Recv() (*pdpb.SyncRegionRequest, error) {
+	m := new(pdpb.SyncRegionRequest)
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
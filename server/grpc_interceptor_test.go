@@ -0,0 +1,48 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"google.golang.org/grpc"
+)
+
+var _ = Suite(&testGRPCInterceptorSuite{})
+
+type testGRPCInterceptorSuite struct{}
+
+func (s *testGRPCInterceptorSuite) TestPDServiceInterceptors(c *C) {
+	var methods []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			methods = append(methods, name+info.FullMethod)
+			return handler(ctx, req)
+		}
+	}
+	reject := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return nil, errors.New("rejected")
+	}
+	svc := &pdService{
+		Server:            &Server{},
+		unaryInterceptors: []grpc.UnaryServerInterceptor{record("a"), record("b"), reject},
+	}
+	resp, err := svc.GetRegionByID(context.Background(), &pdpb.GetRegionByIDRequest{RegionId: 1})
+	c.Assert(err, ErrorMatches, "rejected")
+	c.Assert(resp, IsNil)
+	c.Assert(methods, DeepEquals, []string{"a/pdpb.PD/GetRegionByID", "b/pdpb.PD/GetRegionByID"})
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/logutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestFields returns the fields of the requests attached to the slow logs,
+// e.g. the region ID, by the methods of the PD service.
+var requestFields = map[string]func(req interface{}) []zap.Field{
+	"GetStore": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("store-id", req.(*pdpb.GetStoreRequest).GetStoreId())}
+	},
+	"PutStore": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("store-id", req.(*pdpb.PutStoreRequest).GetStore().GetId())}
+	},
+	"StoreHeartbeat": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("store-id", req.(*pdpb.StoreHeartbeatRequest).GetStats().GetStoreId())}
+	},
+	"GetRegion": func(req interface{}) []zap.Field {
+		return []zap.Field{logutil.ZapRedactByteString("region-key", req.(*pdpb.GetRegionRequest).GetRegionKey())}
+	},
+	"GetPrevRegion": func(req interface{}) []zap.Field {
+		return []zap.Field{logutil.ZapRedactByteString("region-key", req.(*pdpb.GetRegionRequest).GetRegionKey())}
+	},
+	"GetRegionByID": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("region-id", req.(*pdpb.GetRegionByIDRequest).GetRegionId())}
+	},
+	"ScanRegions": func(req interface{}) []zap.Field {
+		request := req.(*pdpb.ScanRegionsRequest)
+		return []zap.Field{
+			logutil.ZapRedactByteString("start-key", request.GetStartKey()),
+			logutil.ZapRedactByteString("end-key", request.GetEndKey()),
+		}
+	},
+	"AskBatchSplit": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("region-id", req.(*pdpb.AskBatchSplitRequest).GetRegion().GetId())}
+	},
+	"ScatterRegion": func(req interface{}) []zap.Field {
+		request := req.(*pdpb.ScatterRegionRequest)
+		return []zap.Field{zap.Uint64("region-id", request.GetRegionId()), zap.Uint64s("region-ids", request.GetRegionsId())}
+	},
+	"UpdateServiceGCSafePoint": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.ByteString("service-id", req.(*pdpb.UpdateServiceGCSafePointRequest).GetServiceId())}
+	},
+	"GetOperator": func(req interface{}) []zap.Field {
+		return []zap.Field{zap.Uint64("region-id", req.(*pdpb.GetOperatorRequest).GetRegionId())}
+	},
+}
+
+// trackRequest is the interceptor of the unary methods of the PD service which
+// enforces the deadlines of the requests and logs the slow ones.
+func (s *Server) trackRequest(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, tracker, err := s.beginRequest(ctx, strings.TrimPrefix(info.FullMethod, "/"+pdServiceName+"/"), req)
+	if err != nil {
+		return nil, err
+	}
+	defer tracker.finish()
+	return handler(ctx, req)
+}
+
+// requestTracker tracks a unary gRPC request to log it if it is slow.
+type requestTracker struct {
+	method    string
+	caller    string
+	start     time.Time
+	threshold time.Duration
+	request   interface{}
+	cancel    context.CancelFunc
+}
+
+// beginRequest starts to process a unary gRPC request. The request is rejected
+// if the deadline of the client is already exceeded, since nobody waits for the
+// response. Otherwise the returned context is limited by the max processing
+// duration of the method, and the tracker must be finished after the request
+// is processed.
+func (s *Server) beginRequest(ctx context.Context, method string, request interface{}) (context.Context, *requestTracker, error) {
+	if err := contextError(ctx); err != nil {
+		grpcRequestCounter.WithLabelValues(method, "rejected").Inc()
+		return ctx, nil, err
+	}
+	cfg := s.persistOptions.GetPDServerConfig()
+	t := &requestTracker{
+		method:    method,
+		caller:    getCaller(ctx),
+		start:     time.Now(),
+		threshold: cfg.SlowGRPCRequestThreshold.Duration,
+		request:   request,
+		cancel:    func() {},
+	}
+	if deadline := cfg.GetGRPCRequestDeadline(method); deadline > 0 {
+		ctx, t.cancel = context.WithTimeout(ctx, deadline)
+	}
+	return ctx, t, nil
+}
+
+// finish releases the context of the request and logs it if it is slow.
+func (t *requestTracker) finish() {
+	t.cancel()
+	duration := time.Since(t.start)
+	if t.threshold <= 0 || duration < t.threshold {
+		return
+	}
+	grpcRequestCounter.WithLabelValues(t.method, "slow").Inc()
+	fields := []zap.Field{
+		zap.String("method", t.method),
+		zap.String("caller", t.caller),
+		zap.Duration("duration", duration),
+	}
+	if requestFields, ok := requestFields[t.method]; ok && t.request != nil {
+		fields = append(fields, requestFields(t.request)...)
+	}
+	log.Warn("slow grpc request", fields...)
+}
+
+// contextError converts the error of the context to a gRPC error.
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+	default:
+		return status.Error(codes.Canceled, context.Canceled.Error())
+	}
+}
+
+func getCaller(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Suite(&testGRPCRequestSuite{})
+
+type testGRPCRequestSuite struct{}
+
+func (s *testGRPCRequestSuite) TestBeginRequest(c *C) {
+	cfg := NewTestSingleConfig(c)
+	svr := &Server{persistOptions: config.NewPersistOptions(cfg)}
+
+	// The request is rejected if the deadline of the client is exceeded.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	_, _, err := svr.beginRequest(ctx, "GetStore", nil)
+	c.Assert(status.Code(err), Equals, codes.DeadlineExceeded)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, _, err = svr.beginRequest(ctx, "GetStore", nil)
+	c.Assert(status.Code(err), Equals, codes.Canceled)
+
+	// No deadline by default.
+	ctx, tracker, err := svr.beginRequest(context.Background(), "GetStore", nil)
+	c.Assert(err, IsNil)
+	_, ok := ctx.Deadline()
+	c.Assert(ok, IsFalse)
+	tracker.finish()
+
+	// The default deadline of ScanRegions.
+	ctx, tracker, err = svr.beginRequest(context.Background(), "ScanRegions", nil)
+	c.Assert(err, IsNil)
+	deadline, ok := ctx.Deadline()
+	c.Assert(ok, IsTrue)
+	c.Assert(time.Until(deadline) <= 10*time.Second, IsTrue)
+	tracker.finish()
+	c.Assert(contextError(ctx), NotNil)
+
+	// The configured deadlines override the default ones.
+	pdServerCfg := svr.persistOptions.GetPDServerConfig().Clone()
+	pdServerCfg.GRPCRequestDeadlines = map[string]typeutil.Duration{
+		"ScanRegions": typeutil.NewDuration(0),
+		"GetStore":    typeutil.NewDuration(time.Second),
+	}
+	svr.persistOptions.SetPDServerConfig(pdServerCfg)
+	ctx, tracker, err = svr.beginRequest(context.Background(), "ScanRegions", nil)
+	c.Assert(err, IsNil)
+	_, ok = ctx.Deadline()
+	c.Assert(ok, IsFalse)
+	tracker.finish()
+	ctx, tracker, err = svr.beginRequest(context.Background(), "GetStore", nil)
+	c.Assert(err, IsNil)
+	deadline, ok = ctx.Deadline()
+	c.Assert(ok, IsTrue)
+	c.Assert(time.Until(deadline) <= time.Second, IsTrue)
+	tracker.finish()
+}
+
+func (s *testGRPCRequestSuite) TestTrackRequest(c *C) {
+	cfg := NewTestSingleConfig(c)
+	svr := &Server{persistOptions: config.NewPersistOptions(cfg)}
+	info := &grpc.UnaryServerInfo{FullMethod: "/pdpb.PD/ScanRegions"}
+
+	// The deadline of the method is applied to the handler.
+	var called bool
+	_, err := svr.trackRequest(context.Background(), &pdpb.ScanRegionsRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		_, ok := ctx.Deadline()
+		c.Assert(ok, IsTrue)
+		return nil, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(called, IsTrue)
+
+	// The handler is not called if the request is rejected.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svr.trackRequest(ctx, &pdpb.ScanRegionsRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		c.Fatal("the rejected request is handled")
+		return nil, nil
+	})
+	c.Assert(status.Code(err), Equals, codes.Canceled)
+}
+
+func (s *testGRPCRequestSuite) TestRequestFields(c *C) {
+	serverType := reflect.TypeOf((*pdpb.PDServer)(nil)).Elem()
+	for name, fields := range requestFields {
+		method, ok := serverType.MethodByName(name)
+		c.Assert(ok, IsTrue, Commentf("method %s", name))
+		c.Assert(method.Type.NumIn(), Equals, 2, Commentf("method %s", name))
+		req := reflect.New(method.Type.In(1).Elem()).Interface()
+		c.Assert(fields(req), Not(HasLen), 0, Commentf("method %s", name))
+	}
+}
@@ -18,10 +18,12 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 	"strconv"
 	"sync/atomic"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -30,9 +32,11 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/logutil"
+	"github.com/tikv/pd/pkg/traceutil"
 	"github.com/tikv/pd/pkg/tsoutil"
 	"github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/server/versioninfo"
 	"go.uber.org/zap"
@@ -42,7 +46,16 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-const slowThreshold = 5 * time.Millisecond
+const (
+	slowThreshold = 5 * time.Millisecond
+	// scanRegionsCheckInterval is the count of regions between the checks of
+	// the request deadline when building the ScanRegions response.
+	scanRegionsCheckInterval = 1024
+	// maxFollowerSyncLag is the max time since the last message received by the
+	// region syncer of the follower to serve the region lookups. The leader
+	// sends a keepalive message every 10 seconds if no region is changed.
+	maxFollowerSyncLag = 30 * time.Second
+)
 
 // gRPC errors
 var (
@@ -85,6 +98,19 @@ func (s *Server) GetMembers(context.Context, *pdpb.GetMembersRequest) (*pdpb.Get
 		}
 	}
 
+	// Fill the dc-locations of the members, so that the clients can route the
+	// requests to the members in their dc-locations. The dc-locations are
+	// cached by the allocator manager, which refreshes them periodically.
+	for dcLocation, info := range tsoAllocatorManager.GetClusterDCLocations() {
+		for _, id := range info.ServerIDs {
+			for _, m := range members {
+				if m.MemberId == id {
+					m.DcLocation = dcLocation
+				}
+			}
+		}
+	}
+
 	return &pdpb.GetMembersResponse{
 		Header:              s.header(),
 		Members:             members,
@@ -100,6 +126,7 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 		forwardStream     pdpb.PD_TsoClient
 		cancel            context.CancelFunc
 		lastForwardedHost string
+		lastSendTime      time.Time
 	)
 	defer func() {
 		// cancel the forward stream
@@ -107,6 +134,9 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 			cancel()
 		}
 	}()
+	caller := getCaller(stream.Context())
+	connStats := s.tsoStats.openStream(caller)
+	defer s.tsoStats.closeStream(caller)
 	for {
 		request, err := stream.Recv()
 		if err == io.EOF {
@@ -115,6 +145,7 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 		if err != nil {
 			return errors.WithStack(err)
 		}
+		start := time.Now()
 
 		forwardedHost := getForwardedHost(stream.Context())
 		if !s.isLocalRequest(forwardedHost) {
@@ -151,7 +182,6 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 			continue
 		}
 
-		start := time.Now()
 		// TSO uses leader lease to determine validity. No need to check leader here.
 		if s.IsClosed() {
 			return status.Errorf(codes.Unknown, "server not started")
@@ -160,7 +190,25 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 			return status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.clusterID, request.GetHeader().GetClusterId())
 		}
 		count := request.GetCount()
+		if err := s.tsoAllocatorManager.TakeTSOQuota(request.GetDcLocation(), caller, count); err != nil {
+			// Only reject the request rather than closing the stream, the client
+			// can retry it later on the same stream.
+			response := &pdpb.TsoResponse{
+				Header: s.errorHeader(&pdpb.Error{
+					Type:    pdpb.ErrorType_UNKNOWN,
+					Message: err.Error(),
+				}),
+			}
+			if err := stream.Send(response); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		span, _ := traceutil.StartSpanFromContext(stream.Context(), "GrpcServer.Tso")
+		span.SetTag("dc-location", request.GetDcLocation())
+		span.SetTag("count", count)
 		ts, err := s.tsoAllocatorManager.HandleTSORequest(request.GetDcLocation(), count)
+		span.Finish()
 		if err != nil {
 			return status.Errorf(codes.Unknown, err.Error())
 		}
@@ -170,6 +218,11 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 			log.Warn("get timestamp too slow", zap.Duration("cost", elapsed))
 		}
 		tsoHandleDuration.Observe(time.Since(start).Seconds())
+		var wait time.Duration
+		if !lastSendTime.IsZero() {
+			wait = start.Sub(lastSendTime)
+		}
+		s.tsoStats.observe(connStats, count, wait, elapsed, start)
 		response := &pdpb.TsoResponse{
 			Header:    s.header(),
 			Timestamp: &ts,
@@ -178,6 +231,7 @@ func (s *Server) Tso(stream pdpb.PD_TsoServer) error {
 		if err := stream.Send(response); err != nil {
 			return errors.WithStack(err)
 		}
+		lastSendTime = time.Now()
 	}
 }
 
@@ -196,6 +250,9 @@ func (s *Server) Bootstrap(ctx context.Context, request *pdpb.BootstrapRequest)
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, true); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc != nil {
@@ -255,6 +312,9 @@ func (s *Server) AllocID(ctx context.Context, request *pdpb.AllocIDRequest) (*pd
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	// We can use an allocator for all types ID allocation.
 	id, err := s.idAllocator.Alloc()
@@ -307,6 +367,8 @@ func checkStore(rc *cluster.RaftCluster, storeID uint64) *pdpb.Error {
 	store := rc.GetStore(storeID)
 	if store != nil {
 		if store.GetState() == metapb.StoreState_Tombstone {
+			// The node stops itself when it receives the error.
+			rc.NotifyStoreTombstone(storeID)
 			return &pdpb.Error{
 				Type:    pdpb.ErrorType_STORE_TOMBSTONE,
 				Message: "store is tombstone",
@@ -331,7 +393,21 @@ func (s *Server) PutStore(ctx context.Context, request *pdpb.PutStoreRequest) (*
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
+	resp, err := s.handleIdempotently(ctx, "PutStore", request, func() (interface{}, error) {
+		return s.putStore(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pdpb.PutStoreResponse), nil
+}
+
+// putStore puts the store on the leader.
+func (s *Server) putStore(request *pdpb.PutStoreRequest) (*pdpb.PutStoreResponse, error) {
 	rc := s.GetRaftCluster()
 	if rc == nil {
 		return &pdpb.PutStoreResponse{Header: s.notBootstrappedHeader()}, nil
@@ -371,7 +447,12 @@ func (s *Server) GetAllStores(ctx context.Context, request *pdpb.GetAllStoresReq
 			return nil, err
 		}
 		ctx = grpcutil.ResetForwardContext(ctx)
-		return pdpb.NewPDClient(client).GetAllStores(ctx, request)
+		var header metadata.MD
+		resp, err := pdpb.NewPDClient(client).GetAllStores(ctx, request, grpc.Header(&header))
+		if err == nil && grpcutil.IsTruncated(header) {
+			err = grpcutil.SetTruncatedHeader(ctx, grpcutil.GetContinuation(header))
+		}
+		return resp, err
 	}
 
 	failpoint.Inject("customTimeout", func() {
@@ -398,6 +479,35 @@ func (s *Server) GetAllStores(ctx context.Context, request *pdpb.GetAllStoresReq
 		stores = rc.GetMetaStores()
 	}
 
+	// The truncated response continues from the ID of the first store which is
+	// not returned, so the stores are returned in the order of ID.
+	maxSize := uint64(s.persistOptions.GetPDServerConfig().MaxResponseSize)
+	continuation := grpcutil.GetRequestContinuation(ctx)
+	if maxSize > 0 || continuation != "" {
+		sort.Slice(stores, func(i, j int) bool { return stores[i].GetId() < stores[j].GetId() })
+	}
+	if continuation != "" {
+		startID, err := strconv.ParseUint(continuation, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid continuation %q of get all stores", continuation)
+		}
+		stores = stores[sort.Search(len(stores), func(i int) bool { return stores[i].GetId() >= startID }):]
+	}
+	if maxSize > 0 {
+		var size uint64
+		for i, store := range stores {
+			size += uint64(store.Size())
+			if size > maxSize && i > 0 {
+				log.Warn("get all stores response is truncated", zap.Int("store-count", len(stores)), zap.Int("returned-count", i))
+				if err := grpcutil.SetTruncatedHeader(ctx, strconv.FormatUint(store.GetId(), 10)); err != nil {
+					return nil, err
+				}
+				stores = stores[:i]
+				break
+			}
+		}
+	}
+
 	return &pdpb.GetAllStoresResponse{
 		Header: s.header(),
 		Stores: stores,
@@ -419,6 +529,9 @@ func (s *Server) StoreHeartbeat(ctx context.Context, request *pdpb.StoreHeartbea
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	if request.GetStats() == nil {
 		return nil, errors.Errorf("invalid store heartbeat command, but %v", request)
@@ -508,6 +621,7 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 		cancel            context.CancelFunc
 		lastForwardedHost string
 		lastBind          time.Time
+		boundStoreID      uint64
 		errCh             chan error
 	)
 	defer func() {
@@ -515,6 +629,10 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 		if cancel != nil {
 			cancel()
 		}
+		// unbind the closed stream, so that the messages are not sent to it
+		if boundStoreID != 0 {
+			s.hbStreams.UnbindStream(boundStoreID, server)
+		}
 	}()
 
 	for {
@@ -537,7 +655,7 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 					return err
 				}
 				log.Info("create region heartbeat forward stream", zap.String("forwarded-host", forwardedHost))
-				forwardStream, cancel, err = s.createHeartbeatForwardStream(client)
+				forwardStream, cancel, err = s.createHeartbeatForwardStream(client, grpcutil.GetPeerCommonName(stream.Context()))
 				if err != nil {
 					return err
 				}
@@ -569,6 +687,9 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 		if err = s.validateRequest(request.GetHeader()); err != nil {
 			return err
 		}
+		if err = s.checkAllowedCN(stream.Context(), false); err != nil {
+			return err
+		}
 
 		storeID := request.GetLeader().GetStoreId()
 		storeLabel := strconv.FormatUint(storeID, 10)
@@ -581,12 +702,15 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 		regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "recv").Inc()
 		regionHeartbeatLatency.WithLabelValues(storeAddress, storeLabel).Observe(float64(time.Now().Unix()) - float64(request.GetInterval().GetEndTimestamp()))
 
-		if time.Since(lastBind) > s.cfg.HeartbeatStreamBindInterval.Duration {
+		// The stream is bound again at once if it is unbound because of the
+		// send failures, instead of waiting for the bind interval.
+		if time.Since(lastBind) > s.cfg.HeartbeatStreamBindInterval.Duration || !s.hbStreams.IsBound(storeID) {
 			regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "bind").Inc()
 			s.hbStreams.BindStream(storeID, server)
 			// refresh FlowRoundByDigit
 			FlowRoundByDigit = s.persistOptions.GetPDServerConfig().FlowRoundByDigit
 			lastBind = time.Now()
+			boundStoreID = storeID
 		}
 
 		region := core.RegionFromHeartbeat(request, core.WithFlowRoundByDigit(FlowRoundByDigit))
@@ -615,7 +739,11 @@ func (s *Server) RegionHeartbeat(stream pdpb.PD_RegionHeartbeatServer) error {
 		}
 		start := time.Now()
 
+		span, _ := traceutil.StartSpanFromContext(stream.Context(), "GrpcServer.RegionHeartbeat")
+		span.SetTag("region-id", region.GetID())
+		span.SetTag("store-id", storeID)
 		err = rc.HandleRegionHeartbeat(region)
+		span.Finish()
 		if err != nil {
 			regionHeartbeatCounter.WithLabelValues(storeAddress, storeLabel, "report", "err").Inc()
 			msg := err.Error()
@@ -638,7 +766,15 @@ func (s *Server) GetRegion(ctx context.Context, request *pdpb.GetRegionRequest)
 		ctx = grpcutil.ResetForwardContext(ctx)
 		return pdpb.NewPDClient(client).GetRegion(ctx, request)
 	}
+	if s.isFollowerHandleRequest(ctx) {
+		if err := s.validateFollowerRequest(request.GetHeader()); err != nil {
+			return nil, err
+		}
+		return regionResponse(s.followerHeader(), s.basicCluster.SearchRegion(request.GetRegionKey())), nil
+	}
 
+	span, _ := traceutil.StartSpanFromContext(ctx, "GrpcServer.GetRegion")
+	defer span.Finish()
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
@@ -671,6 +807,12 @@ func (s *Server) GetPrevRegion(ctx context.Context, request *pdpb.GetRegionReque
 		ctx = grpcutil.ResetForwardContext(ctx)
 		return pdpb.NewPDClient(client).GetPrevRegion(ctx, request)
 	}
+	if s.isFollowerHandleRequest(ctx) {
+		if err := s.validateFollowerRequest(request.GetHeader()); err != nil {
+			return nil, err
+		}
+		return regionResponse(s.followerHeader(), s.basicCluster.SearchPrevRegion(request.GetRegionKey())), nil
+	}
 
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
@@ -705,6 +847,12 @@ func (s *Server) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDR
 		ctx = grpcutil.ResetForwardContext(ctx)
 		return pdpb.NewPDClient(client).GetRegionByID(ctx, request)
 	}
+	if s.isFollowerHandleRequest(ctx) {
+		if err := s.validateFollowerRequest(request.GetHeader()); err != nil {
+			return nil, err
+		}
+		return regionResponse(s.followerHeader(), s.basicCluster.GetRegion(request.GetRegionId())), nil
+	}
 
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
@@ -716,15 +864,21 @@ func (s *Server) GetRegionByID(ctx context.Context, request *pdpb.GetRegionByIDR
 	}
 	region := rc.GetRegion(request.GetRegionId())
 	if region == nil {
+		s.regionResponseCache.remove(request.GetRegionId())
 		return &pdpb.GetRegionResponse{Header: s.header()}, nil
 	}
-	return &pdpb.GetRegionResponse{
+	if resp, ok := s.regionResponseCache.get(region); ok {
+		return resp, nil
+	}
+	resp := &pdpb.GetRegionResponse{
 		Header:       s.header(),
 		Region:       region.GetMeta(),
 		Leader:       region.GetLeader(),
 		DownPeers:    region.GetDownPeers(),
 		PendingPeers: region.GetPendingPeers(),
-	}, nil
+	}
+	s.regionResponseCache.put(region, resp)
+	return resp, nil
 }
 
 // ScanRegions implements gRPC PDServer.
@@ -736,7 +890,12 @@ func (s *Server) ScanRegions(ctx context.Context, request *pdpb.ScanRegionsReque
 			return nil, err
 		}
 		ctx = grpcutil.ResetForwardContext(ctx)
-		return pdpb.NewPDClient(client).ScanRegions(ctx, request)
+		var header metadata.MD
+		resp, err := pdpb.NewPDClient(client).ScanRegions(ctx, request, grpc.Header(&header))
+		if err == nil && grpcutil.IsTruncated(header) {
+			err = grpcutil.SetTruncatedHeader(ctx, grpcutil.GetContinuation(header))
+		}
+		return resp, err
 	}
 
 	if err := s.validateRequest(request.GetHeader()); err != nil {
@@ -747,22 +906,59 @@ func (s *Server) ScanRegions(ctx context.Context, request *pdpb.ScanRegionsReque
 	if rc == nil {
 		return &pdpb.ScanRegionsResponse{Header: s.notBootstrappedHeader()}, nil
 	}
-	regions := rc.ScanRegions(request.GetStartKey(), request.GetEndKey(), int(request.GetLimit()))
+	cfg := s.persistOptions.GetPDServerConfig()
+	limit, maxLimit := int(request.GetLimit()), cfg.MaxScanRegionsLimit
+	if maxLimit > 0 && (limit <= 0 || limit > maxLimit) {
+		// Scan one more region to know if the response is truncated.
+		limit = maxLimit + 1
+	}
+	regions := rc.ScanRegions(request.GetStartKey(), request.GetEndKey(), limit)
+	var truncated bool
+	if maxLimit > 0 && len(regions) > maxLimit {
+		regions, truncated = regions[:maxLimit], true
+	}
 	resp := &pdpb.ScanRegionsResponse{Header: s.header()}
-	for _, r := range regions {
+	var size int
+	for i, r := range regions {
+		// Give up if the deadline of the request is exceeded, since building a
+		// large response costs a lot.
+		if i%scanRegionsCheckInterval == 0 {
+			if err := contextError(ctx); err != nil {
+				return nil, err
+			}
+		}
 		leader := r.GetLeader()
 		if leader == nil {
 			leader = &metapb.Peer{}
 		}
-		// Set RegionMetas and Leaders to make it compatible with old client.
-		resp.RegionMetas = append(resp.RegionMetas, r.GetMeta())
-		resp.Leaders = append(resp.Leaders, leader)
-		resp.Regions = append(resp.Regions, &pdpb.Region{
+		region := &pdpb.Region{
 			Region:       r.GetMeta(),
 			Leader:       leader,
 			DownPeers:    r.GetDownPeers(),
 			PendingPeers: r.GetPendingPeers(),
-		})
+		}
+		// The meta and leader are set twice for the compatibility.
+		size += region.Size() + r.GetMeta().Size() + leader.Size()
+		// At least one region is returned so the client can move forward.
+		if cfg.MaxResponseSize > 0 && uint64(size) > uint64(cfg.MaxResponseSize) && len(resp.Regions) > 0 {
+			truncated = true
+			break
+		}
+		// Set RegionMetas and Leaders to make it compatible with old client.
+		resp.RegionMetas = append(resp.RegionMetas, r.GetMeta())
+		resp.Leaders = append(resp.Leaders, leader)
+		resp.Regions = append(resp.Regions, region)
+	}
+	if truncated {
+		log.Warn("scan regions response is truncated",
+			logutil.ZapRedactByteString("start-key", request.GetStartKey()),
+			logutil.ZapRedactByteString("end-key", request.GetEndKey()),
+			zap.Int32("limit", request.GetLimit()),
+			zap.Int("region-count", len(resp.Regions)))
+		// The client continues from the end key of the last region.
+		if err := grpcutil.SetTruncatedHeader(ctx, ""); err != nil {
+			return nil, err
+		}
 	}
 	return resp, nil
 }
@@ -782,6 +978,9 @@ func (s *Server) AskSplit(ctx context.Context, request *pdpb.AskSplitRequest) (*
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -820,6 +1019,9 @@ func (s *Server) AskBatchSplit(ctx context.Context, request *pdpb.AskBatchSplitR
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -862,6 +1064,9 @@ func (s *Server) ReportSplit(ctx context.Context, request *pdpb.ReportSplitReque
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -892,6 +1097,9 @@ func (s *Server) ReportBatchSplit(ctx context.Context, request *pdpb.ReportBatch
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -949,7 +1157,21 @@ func (s *Server) PutClusterConfig(ctx context.Context, request *pdpb.PutClusterC
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, true); err != nil {
+		return nil, err
+	}
 
+	resp, err := s.handleIdempotently(ctx, "PutClusterConfig", request, func() (interface{}, error) {
+		return s.putClusterConfig(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pdpb.PutClusterConfigResponse), nil
+}
+
+// putClusterConfig puts the cluster config on the leader.
+func (s *Server) putClusterConfig(request *pdpb.PutClusterConfigRequest) (*pdpb.PutClusterConfigResponse, error) {
 	rc := s.GetRaftCluster()
 	if rc == nil {
 		return &pdpb.PutClusterConfigResponse{Header: s.notBootstrappedHeader()}, nil
@@ -981,7 +1203,21 @@ func (s *Server) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionR
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, true); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.handleIdempotently(ctx, "ScatterRegion", request, func() (interface{}, error) {
+		return s.scatterRegion(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pdpb.ScatterRegionResponse), nil
+}
 
+// scatterRegion creates the operators to scatter the regions on the leader.
+func (s *Server) scatterRegion(ctx context.Context, request *pdpb.ScatterRegionRequest) (*pdpb.ScatterRegionResponse, error) {
 	rc := s.GetRaftCluster()
 	if rc == nil {
 		return &pdpb.ScatterRegionResponse{Header: s.notBootstrappedHeader()}, nil
@@ -992,7 +1228,13 @@ func (s *Server) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionR
 		if err != nil {
 			return nil, err
 		}
+		// Do not add the operators if the deadline of the request is exceeded.
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
 		for _, op := range ops {
+			op.SetSource(operator.SourceGRPC)
+			op.SetReason("scatter the regions")
 			if ok := rc.GetOperatorController().AddOperator(op); !ok {
 				failures[op.RegionID()] = fmt.Errorf("region %v failed to add operator", op.RegionID())
 			}
@@ -1029,6 +1271,8 @@ func (s *Server) ScatterRegion(ctx context.Context, request *pdpb.ScatterRegionR
 		return nil, err
 	}
 	if op != nil {
+		op.SetSource(operator.SourceGRPC)
+		op.SetReason("scatter the region")
 		rc.GetOperatorController().AddOperator(op)
 	}
 
@@ -1093,6 +1337,9 @@ func (s *Server) UpdateGCSafePoint(ctx context.Context, request *pdpb.UpdateGCSa
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -1144,6 +1391,9 @@ func (s *Server) UpdateServiceGCSafePoint(ctx context.Context, request *pdpb.Upd
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, false); err != nil {
+		return nil, err
+	}
 
 	rc := s.GetRaftCluster()
 	if rc == nil {
@@ -1251,6 +1501,82 @@ func (s *Server) validateRequest(header *pdpb.RequestHeader) error {
 	return nil
 }
 
+// isFollowerHandleRequest checks if the request is handled by the follower,
+// which is allowed by the client to avoid the cross-DC latency to the leader.
+func (s *Server) isFollowerHandleRequest(ctx context.Context) bool {
+	return grpcutil.IsFollowerHandleEnabled(ctx) && !s.member.IsLeader()
+}
+
+// validateFollowerRequest checks if the follower is able to handle the request.
+// The follower serves the regions synced from the leader, which may be stale.
+func (s *Server) validateFollowerRequest(header *pdpb.RequestHeader) error {
+	if s.IsClosed() || !s.persistOptions.IsUseRegionStorage() {
+		return errors.WithStack(ErrNotLeader)
+	}
+	if header.GetClusterId() != s.clusterID {
+		return status.Errorf(codes.FailedPrecondition, "mismatch cluster id, need %d but got %d", s.clusterID, header.GetClusterId())
+	}
+	return nil
+}
+
+// followerHeader returns the header of the response served by the follower.
+// The header carries an error if the region syncer isn't streaming the regions
+// from the leader or hasn't received any message for a while, so that the
+// client can fall back to the leader instead of getting the stale regions.
+func (s *Server) followerHeader() *pdpb.ResponseHeader {
+	syncer := s.cluster.GetRegionSyncer()
+	if !syncer.IsRunning() {
+		return s.errorHeader(&pdpb.Error{
+			Type:    pdpb.ErrorType_UNKNOWN,
+			Message: "region syncer is not running",
+		})
+	}
+	if lag := time.Since(syncer.GetLastSyncTime()); lag > maxFollowerSyncLag {
+		return s.errorHeader(&pdpb.Error{
+			Type:    pdpb.ErrorType_UNKNOWN,
+			Message: fmt.Sprintf("region syncer has not synced with leader for %s", lag),
+		})
+	}
+	return s.header()
+}
+
+// handleIdempotently handles the mutating request once for the retries with
+// the same idempotency key in the gRPC metadata.
+func (s *Server) handleIdempotently(ctx context.Context, method string, request proto.Message, handle func() (interface{}, error)) (interface{}, error) {
+	key := grpcutil.GetIdempotencyKey(ctx)
+	if key == "" {
+		return handle()
+	}
+	content, err := proto.Marshal(request)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	resp, err := s.idempotentRequests.Do(ctx, key, append([]byte(method), content...), handle)
+	switch {
+	case errs.ErrIdempotencyKeyReused.Equal(err):
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	case err == context.Canceled:
+		return nil, status.Errorf(codes.Canceled, err.Error())
+	case err == context.DeadlineExceeded:
+		return nil, status.Errorf(codes.DeadlineExceeded, err.Error())
+	}
+	return resp, err
+}
+
+// regionResponse builds the response of the region lookup.
+func regionResponse(header *pdpb.ResponseHeader, region *core.RegionInfo) *pdpb.GetRegionResponse {
+	if region == nil || header.GetError() != nil {
+		return &pdpb.GetRegionResponse{Header: header}
+	}
+	return &pdpb.GetRegionResponse{
+		Header:       header,
+		Region:       region.GetMeta(),
+		Leader:       region.GetLeader(),
+		DownPeers:    region.GetDownPeers(),
+		PendingPeers: region.GetPendingPeers(),
+	}
+}
+
 func (s *Server) header() *pdpb.ResponseHeader {
 	return &pdpb.ResponseHeader{ClusterId: s.clusterID}
 }
@@ -1283,6 +1609,11 @@ var mockLocalAllocatorLeaderChangeFlag = false
 // SyncMaxTS will check whether MaxTS is the biggest one among all Local TSOs this PD is holding when skipCheck is set,
 // and write it into all Local TSO Allocators then if it's indeed the biggest one.
 func (s *Server) SyncMaxTS(ctx context.Context, request *pdpb.SyncMaxTSRequest) (*pdpb.SyncMaxTSResponse, error) {
+	// It is sent by the Global TSO Allocator of the other PD members, whose CN
+	// is always allowed, and it writes the Local TSOs, so it is an admin request.
+	if err := s.checkInternalCN(ctx, true); err != nil {
+		return nil, err
+	}
 	if err := s.validateInternalRequest(request.GetHeader(), true); err != nil {
 		return nil, err
 	}
@@ -1378,7 +1709,25 @@ func (s *Server) SplitRegions(ctx context.Context, request *pdpb.SplitRegionsReq
 	if err := s.validateRequest(request.GetHeader()); err != nil {
 		return nil, err
 	}
+	if err := s.checkAllowedCN(ctx, true); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.handleIdempotently(ctx, "SplitRegions", request, func() (interface{}, error) {
+		return s.splitRegions(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pdpb.SplitRegionsResponse), nil
+}
+
+// splitRegions splits the regions by the keys on the leader.
+func (s *Server) splitRegions(ctx context.Context, request *pdpb.SplitRegionsRequest) (*pdpb.SplitRegionsResponse, error) {
 	finishedPercentage, newRegionIDs := s.cluster.GetRegionSplitter().SplitRegions(ctx, request.GetSplitKeys(), int(request.GetRetryLimit()))
+	if s.cluster.GetOpts().IsScatterAfterSplitEnabled() {
+		s.cluster.ScatterSplitRegions(newRegionIDs, "", int(request.GetRetryLimit()), operator.SourceGRPC)
+	}
 	return &pdpb.SplitRegionsResponse{
 		Header:             s.header(),
 		RegionsId:          newRegionIDs,
@@ -1485,9 +1834,12 @@ func (s *Server) createTsoForwardStream(client *grpc.ClientConn) (pdpb.PD_TsoCli
 	return forwardStream, cancel, err
 }
 
-func (s *Server) createHeartbeatForwardStream(client *grpc.ClientConn) (pdpb.PD_RegionHeartbeatClient, context.CancelFunc, error) {
+// createHeartbeatForwardStream creates the stream to forward the region
+// heartbeats, which carries the certificate CN of the caller to be checked by
+// the leader.
+func (s *Server) createHeartbeatForwardStream(client *grpc.ClientConn, callerCN string) (pdpb.PD_RegionHeartbeatClient, context.CancelFunc, error) {
 	done := make(chan struct{})
-	ctx, cancel := context.WithCancel(s.ctx)
+	ctx, cancel := context.WithCancel(grpcutil.BuildForwardedCNContext(s.ctx, callerCN))
 	go checkStream(ctx, cancel, done)
 	forwardStream, err := pdpb.NewPDClient(client).RegionHeartbeat(ctx)
 	done <- struct{}{}
@@ -51,10 +51,15 @@ var (
 	ErrServerNotStarted = errors.New("The server has not been started")
 	// ErrOperatorNotFound is error info for operator not found.
 	ErrOperatorNotFound = errors.New("operator not found")
-	// ErrAddOperator is error info for already have an operator when adding operator.
-	ErrAddOperator = errors.New("failed to add operator, maybe already have one")
+	// ErrAddOperator is error info for the operator rejected when adding operator,
+	// the returned errors are annotated with the reject reason.
+	ErrAddOperator = errors.New("failed to add operator")
 	// ErrRegionNotAdjacent is error info for region not adjacent.
 	ErrRegionNotAdjacent = errors.New("two regions are not adjacent")
+	// ErrRegionTooLarge is error info for region too large to merge.
+	ErrRegionTooLarge = func(regionID uint64) error {
+		return errors.Errorf("region %v exceeds the max merge region size or keys", regionID)
+	}
 	// ErrRegionNotFound is error info for region not found.
 	ErrRegionNotFound = func(regionID uint64) error {
 		return errors.Errorf("region %v not found", regionID)
@@ -121,6 +126,24 @@ func (h *Handler) IsSchedulerDisabled(name string) (bool, error) {
 	return rc.IsSchedulerDisabled(name)
 }
 
+// GetSchedulerDisableRecord returns the record of why the scheduler is paused or disabled.
+func (h *Handler) GetSchedulerDisableRecord(name string) (*cluster.SchedulerDisableRecord, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.GetSchedulerDisableRecord(name), nil
+}
+
+// GetDisabledSchedulers returns the names of the disabled schedulers.
+func (h *Handler) GetDisabledSchedulers() ([]string, error) {
+	rc, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return rc.GetDisabledSchedulers(), nil
+}
+
 // IsSchedulerExisted returns whether scheduler is existed.
 func (h *Handler) IsSchedulerExisted(name string) (bool, error) {
 	rc, err := h.GetRaftCluster()
@@ -135,6 +158,11 @@ func (h *Handler) GetScheduleConfig() *config.ScheduleConfig {
 	return h.s.GetScheduleConfig()
 }
 
+// GetPersistOptions returns the persist options, including the temporary overrides.
+func (h *Handler) GetPersistOptions() *config.PersistOptions {
+	return h.s.GetPersistOptions()
+}
+
 // GetSchedulers returns all names of schedulers.
 func (h *Handler) GetSchedulers() ([]string, error) {
 	c, err := h.GetRaftCluster()
@@ -144,6 +172,25 @@ func (h *Handler) GetSchedulers() ([]string, error) {
 	return c.GetSchedulers(), nil
 }
 
+// GetSchedulerStats returns the runtime statistics of the scheduler.
+func (h *Handler) GetSchedulerStats(name string) (*cluster.SchedulerStats, error) {
+	c, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetSchedulerStats(name)
+}
+
+// EvaluateScheduler evaluates the scheduler with the candidate config, and
+// returns the operators it would create without adding them.
+func (h *Handler) EvaluateScheduler(name string, config map[string]interface{}) (*cluster.SchedulerEvaluation, error) {
+	c, err := h.GetRaftCluster()
+	if err != nil {
+		return nil, err
+	}
+	return c.EvaluateScheduler(name, config)
+}
+
 // GetStores returns all stores in the cluster.
 func (h *Handler) GetStores() ([]*core.StoreInfo, error) {
 	rc := h.s.GetRaftCluster()
@@ -225,12 +272,13 @@ func (h *Handler) RemoveScheduler(name string) error {
 // PauseOrResumeScheduler pauses a scheduler for delay seconds or resume a paused scheduler.
 // t == 0 : resume scheduler.
 // t > 0 : scheduler delays t seconds.
-func (h *Handler) PauseOrResumeScheduler(name string, t int64) error {
+// The actor and reason are recorded for the pause.
+func (h *Handler) PauseOrResumeScheduler(name string, t int64, actor, reason string) error {
 	c, err := h.GetRaftCluster()
 	if err != nil {
 		return err
 	}
-	if err = c.PauseOrResumeScheduler(name, t); err != nil {
+	if err = c.PauseOrResumeScheduler(name, t, actor, reason); err != nil {
 		if t == 0 {
 			log.Error("can not resume scheduler", zap.String("scheduler-name", name), errs.ZapError(err))
 		} else {
@@ -290,6 +338,15 @@ func (h *Handler) AddShuffleHotRegionScheduler(limit uint64) error {
 	return h.AddScheduler(schedulers.ShuffleHotRegionType, strconv.FormatUint(limit, 10))
 }
 
+// AddGrantHotLeaderScheduler adds a grant-hot-leader-scheduler. The labels are
+// in the form of `k1=v1,k2=v2`, and the count of hot regions is default if 0.
+func (h *Handler) AddGrantHotLeaderScheduler(labels string, hotRegionCount uint64) error {
+	if hotRegionCount == 0 {
+		return h.AddScheduler(schedulers.GrantHotLeaderType, labels)
+	}
+	return h.AddScheduler(schedulers.GrantHotLeaderType, labels, strconv.FormatUint(hotRegionCount, 10))
+}
+
 // AddRandomMergeScheduler adds a random-merge-scheduler.
 func (h *Handler) AddRandomMergeScheduler() error {
 	return h.AddScheduler(schedulers.RandomMergeType)
@@ -454,6 +511,12 @@ func (h *Handler) SetStoreLimit(storeID uint64, ratePerMin float64, limitType st
 	return c.SetStoreLimit(storeID, limitType, ratePerMin)
 }
 
+// addOperatorError returns ErrAddOperator annotated with the reason why the
+// operator is rejected.
+func addOperatorError(reason schedule.RejectReason) error {
+	return errors.Annotatef(errors.WithStack(ErrAddOperator), "rejected for %s", reason)
+}
+
 // AddTransferLeaderOperator adds an operator to transfer leader to the store.
 func (h *Handler) AddTransferLeaderOperator(regionID uint64, storeID uint64) error {
 	c, err := h.GetRaftCluster()
@@ -476,14 +539,19 @@ func (h *Handler) AddTransferLeaderOperator(regionID uint64, storeID uint64) err
 		log.Debug("fail to create transfer leader operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("transfer the leader to store %d", storeID))
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
 
 // AddTransferRegionOperator adds an operator to transfer region to the stores.
-func (h *Handler) AddTransferRegionOperator(regionID uint64, storeIDs map[uint64]placement.PeerRoleType) error {
+func (h *Handler) AddTransferRegionOperator(regionID uint64, storeIDs map[uint64]placement.PeerRoleType, force bool) error {
 	c, err := h.GetRaftCluster()
 	if err != nil {
 		return err
@@ -520,14 +588,20 @@ func (h *Handler) AddTransferRegionOperator(regionID uint64, storeIDs map[uint64
 		log.Debug("fail to create move region operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason("move the peers to the specified stores")
+	op.SetForce(force)
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
 
 // AddTransferPeerOperator adds an operator to transfer peer.
-func (h *Handler) AddTransferPeerOperator(regionID uint64, fromStoreID, toStoreID uint64) error {
+func (h *Handler) AddTransferPeerOperator(regionID uint64, fromStoreID, toStoreID uint64, force bool) error {
 	c, err := h.GetRaftCluster()
 	if err != nil {
 		return err
@@ -553,8 +627,14 @@ func (h *Handler) AddTransferPeerOperator(regionID uint64, fromStoreID, toStoreI
 		log.Debug("fail to create move peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("move the peer from store %d to store %d", fromStoreID, toStoreID))
+	op.SetForce(force)
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
@@ -583,7 +663,7 @@ func (h *Handler) checkAdminAddPeerOperator(regionID uint64, toStoreID uint64) (
 }
 
 // AddAddPeerOperator adds an operator to add peer.
-func (h *Handler) AddAddPeerOperator(regionID uint64, toStoreID uint64) error {
+func (h *Handler) AddAddPeerOperator(regionID uint64, toStoreID uint64, force bool) error {
 	c, region, err := h.checkAdminAddPeerOperator(regionID, toStoreID)
 	if err != nil {
 		return err
@@ -595,14 +675,20 @@ func (h *Handler) AddAddPeerOperator(regionID uint64, toStoreID uint64) error {
 		log.Debug("fail to create add peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("add a peer on store %d", toStoreID))
+	op.SetForce(force)
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
 
 // AddAddLearnerOperator adds an operator to add learner.
-func (h *Handler) AddAddLearnerOperator(regionID uint64, toStoreID uint64) error {
+func (h *Handler) AddAddLearnerOperator(regionID uint64, toStoreID uint64, force bool) error {
 	c, region, err := h.checkAdminAddPeerOperator(regionID, toStoreID)
 	if err != nil {
 		return err
@@ -618,14 +704,20 @@ func (h *Handler) AddAddLearnerOperator(regionID uint64, toStoreID uint64) error
 		log.Debug("fail to create add learner operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("add a learner on store %d", toStoreID))
+	op.SetForce(force)
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
 
 // AddRemovePeerOperator adds an operator to remove peer.
-func (h *Handler) AddRemovePeerOperator(regionID uint64, fromStoreID uint64) error {
+func (h *Handler) AddRemovePeerOperator(regionID uint64, fromStoreID uint64, force bool) error {
 	c, err := h.GetRaftCluster()
 	if err != nil {
 		return err
@@ -645,14 +737,23 @@ func (h *Handler) AddRemovePeerOperator(regionID uint64, fromStoreID uint64) err
 		log.Debug("fail to create move peer operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("remove the peer on store %d", fromStoreID))
+	op.SetForce(force)
+	if err := operator.Validate(c, region, op); err != nil {
+		return err
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
 
-// AddMergeRegionOperator adds an operator to merge region.
-func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64) error {
+// AddMergeRegionOperator adds an operator to merge region. The peers of the
+// source region are moved to the stores of the target region first if they
+// don't match. If force is true, the source region is merged even if it exceeds
+// the max merge region size or keys, and the moves bypass the store limit.
+func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64, force bool) error {
 	c, err := h.GetRaftCluster()
 	if err != nil {
 		return err
@@ -682,13 +783,28 @@ func (h *Handler) AddMergeRegionOperator(regionID uint64, targetID uint64) error
 		return ErrRegionNotAdjacent
 	}
 
+	// The max merge region size or keys of 0 only disables the merge checker,
+	// the regions can still be merged manually.
+	if !force {
+		maxSize, maxKeys := c.GetOpts().GetMaxMergeRegionSize(), c.GetOpts().GetMaxMergeRegionKeys()
+		if (maxSize > 0 && region.GetApproximateSize() > int64(maxSize)) ||
+			(maxKeys > 0 && region.GetApproximateKeys() > int64(maxKeys)) {
+			return ErrRegionTooLarge(regionID)
+		}
+	}
+
 	ops, err := operator.CreateMergeRegionOperator("admin-merge-region", c, region, target, operator.OpAdmin)
 	if err != nil {
 		log.Debug("fail to create merge region operator", errs.ZapError(err))
 		return err
 	}
-	if ok := c.GetOperatorController().AddOperator(ops...); !ok {
-		return errors.WithStack(ErrAddOperator)
+	for _, op := range ops {
+		op.SetSource(operator.SourceHTTP)
+		op.SetReason(fmt.Sprintf("merge region %d into region %d", regionID, targetID))
+		op.SetForce(force)
+	}
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(ops...); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
@@ -726,8 +842,10 @@ func (h *Handler) AddSplitRegionOperator(regionID uint64, policyStr string, keys
 		return err
 	}
 
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason(fmt.Sprintf("split the region by policy %s", policyStr))
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
@@ -756,8 +874,10 @@ func (h *Handler) AddScatterRegionOperator(regionID uint64, group string) error
 	if op == nil {
 		return nil
 	}
-	if ok := c.GetOperatorController().AddOperator(op); !ok {
-		return errors.WithStack(ErrAddOperator)
+	op.SetSource(operator.SourceHTTP)
+	op.SetReason("scatter the region")
+	if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+		return addOperatorError(reason)
 	}
 	return nil
 }
@@ -792,8 +912,10 @@ func (h *Handler) AddScatterRegionsOperators(regionIDs []uint64, startRawKey, en
 	}
 	// If there existed any operator failed to be added into Operator Controller, add its regions into unProcessedRegions
 	for _, op := range ops {
-		if ok := c.GetOperatorController().AddOperator(op); !ok {
-			failures[op.RegionID()] = fmt.Errorf("region %v failed to add operator", op.RegionID())
+		op.SetSource(operator.SourceHTTP)
+		op.SetReason("scatter the regions")
+		if ok, reason := c.GetOperatorController().AddOperatorWithReason(op); !ok {
+			failures[op.RegionID()] = fmt.Errorf("region %v failed to add operator, rejected for %s", op.RegionID(), reason)
 		}
 	}
 	percentage := 100
@@ -812,6 +934,26 @@ func (h *Handler) GetRegionsByType(typ statistics.RegionStatisticType) ([]*core.
 	return c.GetRegionStatsByType(typ), nil
 }
 
+// GetLostQuorumRegions gets the regions which have fewer live voters than the
+// quorum.
+func (h *Handler) GetLostQuorumRegions() ([]*statistics.LostQuorumRegion, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.GetLostQuorumRegions(), nil
+}
+
+// GetIsolationViolationReport gets the report of the regions which violate the
+// isolation level of the placement rules.
+func (h *Handler) GetIsolationViolationReport() (*statistics.IsolationViolationReport, error) {
+	c := h.s.GetRaftCluster()
+	if c == nil {
+		return nil, errs.ErrNotBootstrapped.FastGenByArgs()
+	}
+	return c.GetIsolationViolationReport(), nil
+}
+
 // GetSchedulerConfigHandler gets the handler of schedulers.
 func (h *Handler) GetSchedulerConfigHandler() http.Handler {
 	c, err := h.GetRaftCluster()
@@ -34,6 +34,13 @@ type Allocator interface {
 	// which also resets the end of the allocator. (base, end) is the range that can
 	// be allocated in memory.
 	Rebase() error
+	// EnsureAbove makes sure the IDs allocated afterwards are larger than the given
+	// ID, which is the largest ID observed in the persisted metadata. It bumps the
+	// base if the allocator rolls back, and returns whether a rollback is detected.
+	EnsureAbove(id uint64) (bool, error)
+	// EnsureAboveAsync is like EnsureAbove, but gets the largest observed ID by
+	// calling observe in the background. Alloc waits until it returns.
+	EnsureAboveAsync(observe func() uint64)
 }
 
 const allocStep = uint64(1000)
@@ -43,6 +50,10 @@ type allocatorImpl struct {
 	mu   sync.Mutex
 	base uint64
 	end  uint64
+	// observed is closed once the largest observed ID is got by EnsureAboveAsync,
+	// minBase holds the ID until the base is bumped above it by Alloc.
+	observed chan struct{}
+	minBase  uint64
 
 	client   *clientv3.Client
 	rootPath string
@@ -51,14 +62,28 @@ type allocatorImpl struct {
 
 // NewAllocator creates a new ID Allocator.
 func NewAllocator(client *clientv3.Client, rootPath string, member string) Allocator {
-	return &allocatorImpl{client: client, rootPath: rootPath, member: member}
+	observed := make(chan struct{})
+	close(observed)
+	return &allocatorImpl{observed: observed, client: client, rootPath: rootPath, member: member}
 }
 
 // Alloc returns a new id.
 func (alloc *allocatorImpl) Alloc() (uint64, error) {
+	alloc.mu.Lock()
+	observed := alloc.observed
+	alloc.mu.Unlock()
+	<-observed
+
 	alloc.mu.Lock()
 	defer alloc.mu.Unlock()
 
+	if alloc.minBase > 0 {
+		if _, err := alloc.ensureAboveLocked(alloc.minBase); err != nil {
+			return 0, err
+		}
+		alloc.minBase = 0
+	}
+
 	if alloc.base == alloc.end {
 		if err := alloc.rebaseLocked(); err != nil {
 			return 0, err
@@ -80,7 +105,51 @@ func (alloc *allocatorImpl) Rebase() error {
 	return alloc.rebaseLocked()
 }
 
+// EnsureAbove makes sure the IDs allocated afterwards are larger than the given ID.
+func (alloc *allocatorImpl) EnsureAbove(id uint64) (bool, error) {
+	alloc.mu.Lock()
+	defer alloc.mu.Unlock()
+
+	return alloc.ensureAboveLocked(id)
+}
+
+// EnsureAboveAsync gets the largest observed ID in the background, so the caller
+// isn't blocked by a slow observe, e.g. scanning all the regions.
+func (alloc *allocatorImpl) EnsureAboveAsync(observe func() uint64) {
+	observed := make(chan struct{})
+	alloc.mu.Lock()
+	alloc.observed = observed
+	alloc.mu.Unlock()
+
+	go func() {
+		defer close(observed)
+		id := observe()
+		alloc.mu.Lock()
+		defer alloc.mu.Unlock()
+		if id > alloc.minBase {
+			alloc.minBase = id
+		}
+	}()
+}
+
+func (alloc *allocatorImpl) ensureAboveLocked(id uint64) (bool, error) {
+	if alloc.base >= id {
+		return false, nil
+	}
+	log.Error("id allocator rolls back, bump the base to the largest observed id",
+		zap.Uint64("base", alloc.base),
+		zap.Uint64("observed-id", id))
+	idRollbackCounter.Inc()
+	return true, alloc.rebaseAboveLocked(id)
+}
+
 func (alloc *allocatorImpl) rebaseLocked() error {
+	return alloc.rebaseAboveLocked(0)
+}
+
+// rebaseAboveLocked allocates a new window from the persistent window boundary,
+// the base of the new window is at least minBase.
+func (alloc *allocatorImpl) rebaseAboveLocked(minBase uint64) error {
 	key := alloc.getAllocIDPath()
 	value, err := etcdutil.GetValue(alloc.client, key)
 	if err != nil {
@@ -105,6 +174,9 @@ func (alloc *allocatorImpl) rebaseLocked() error {
 		cmp = clientv3.Compare(clientv3.Value(key), "=", string(value))
 	}
 
+	if end < minBase {
+		end = minBase
+	}
 	end += allocStep
 	value = typeutil.Uint64ToBytes(end)
 	txn := kv.NewSlowLogTxn(alloc.client)
@@ -23,8 +23,17 @@ var (
 			Name:      "id",
 			Help:      "Record of id allocator.",
 		}, []string{"type"})
+
+	idRollbackCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "cluster",
+			Name:      "id_rollback",
+			Help:      "Counter of the detected id allocator rollbacks.",
+		})
 )
 
 func init() {
 	prometheus.MustRegister(idGauge)
+	prometheus.MustRegister(idRollbackCounter)
 }
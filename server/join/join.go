@@ -14,9 +14,12 @@
 package join
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,7 +28,9 @@ import (
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/versioninfo"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
 	"go.uber.org/zap"
@@ -36,6 +41,17 @@ const (
 	privateFileMode = 0600
 	// privateDirMode grants owner to make/remove files inside the directory.
 	privateDirMode = 0700
+
+	// joinFile records the initial cluster after the PD joins a cluster.
+	joinFile = "join"
+	// etcdClusterIDFile records the ID of the etcd cluster the PD joins.
+	etcdClusterIDFile = "etcd_cluster_id"
+	// pdClusterIDPath and pdRootPath are the same as the ones in the server package.
+	pdClusterIDPath = "/pd/cluster_id"
+	pdRootPath      = "/pd"
+	// checkDataTimeout is the timeout to check the data against the cluster to
+	// join, the check is skipped if the cluster is not reachable in time.
+	checkDataTimeout = 3 * time.Second
 )
 
 // listMemberRetryTimes is the retry times of list member.
@@ -78,6 +94,11 @@ var listMemberRetryTimes = 20
 //      What join does: return "" (as etcd will read data directory and find
 //                      that the PD itself has been removed, so an empty string
 //                      is fine.)
+//
+// If ForceNew is set, the data is checked against the cluster to join first.
+// The data which is stale or belongs to a different cluster is archived, and
+// the PD joins the cluster as a new one. Before a new PD joins the cluster, it
+// also checks that its version is compatible with the cluster version.
 func PrepareJoinCluster(cfg *config.Config) error {
 	// - A PD tries to join itself.
 	if cfg.Join == "" {
@@ -88,48 +109,37 @@ func PrepareJoinCluster(cfg *config.Config) error {
 		return errors.New("join self is forbidden")
 	}
 
-	filePath := path.Join(cfg.DataDir, "join")
-	// Read the persist join config
-	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
-		s, err := os.ReadFile(filePath)
-		if err != nil {
-			log.Fatal("read the join config meet error", errs.ZapError(errs.ErrIORead, err))
+	filePath := path.Join(cfg.DataDir, joinFile)
+	_, err := os.Stat(filePath)
+	joined := !os.IsNotExist(err)
+	if joined || isDataExist(path.Join(cfg.DataDir, "member")) {
+		if !cfg.ForceNew {
+			return prepareWithData(cfg, joined)
+		}
+		err := checkLocalData(cfg)
+		if err == nil {
+			return prepareWithData(cfg, joined)
+		}
+		log.Warn("the data conflicts with the cluster to join, archive it", errs.ZapError(err))
+		if err := archiveData(cfg.DataDir); err != nil {
+			return err
 		}
-		cfg.InitialCluster = strings.TrimSpace(string(s))
-		cfg.InitialClusterState = embed.ClusterStateFlagExisting
-		return nil
-	}
-
-	initialCluster := ""
-	// Cases with data directory.
-	if isDataExist(path.Join(cfg.DataDir, "member")) {
-		cfg.InitialCluster = initialCluster
-		cfg.InitialClusterState = embed.ClusterStateFlagExisting
-		return nil
 	}
 
 	// Below are cases without data directory.
-	tlsConfig, err := cfg.Security.ToTLSConfig()
+	client, err := newJoinClient(cfg, etcdutil.DefaultDialTimeout)
 	if err != nil {
 		return err
 	}
-	lgc := zap.NewProductionConfig()
-	lgc.Encoding = log.ZapEncodingName
-	client, err := clientv3.New(clientv3.Config{
-		Endpoints:   strings.Split(cfg.Join, ","),
-		DialTimeout: etcdutil.DefaultDialTimeout,
-		TLS:         tlsConfig,
-		LogConfig:   &lgc,
-	})
-	if err != nil {
-		return errors.WithStack(err)
-	}
 	defer client.Close()
 
 	listResp, err := etcdutil.ListEtcdMembers(client)
 	if err != nil {
 		return err
 	}
+	if err := checkClusterVersion(client); err != nil {
+		return err
+	}
 
 	existed := false
 	for _, m := range listResp.Members {
@@ -143,7 +153,7 @@ func PrepareJoinCluster(cfg *config.Config) error {
 
 	// - A failed PD re-joins the previous cluster.
 	if existed {
-		return errors.New("missing data or join a duplicated pd")
+		return errors.Errorf("missing data or join a duplicated pd, the member %s already exists in the cluster", cfg.Name)
 	}
 
 	var addResp *clientv3.MemberAddResponse
@@ -198,18 +208,167 @@ func PrepareJoinCluster(cfg *config.Config) error {
 		return errors.Errorf("join failed, adds the new member %s may failed", cfg.Name)
 	}
 
-	initialCluster = strings.Join(pds, ",")
-	cfg.InitialCluster = initialCluster
+	cfg.InitialCluster = strings.Join(pds, ",")
 	cfg.InitialClusterState = embed.ClusterStateFlagExisting
 	err = os.MkdirAll(cfg.DataDir, privateDirMode)
 	if err != nil && !os.IsExist(err) {
 		return errors.WithStack(err)
 	}
 
+	clusterID := strconv.FormatUint(listResp.Header.GetClusterId(), 10)
+	if err := os.WriteFile(path.Join(cfg.DataDir, etcdClusterIDFile), []byte(clusterID), privateFileMode); err != nil {
+		return errors.WithStack(err)
+	}
 	err = os.WriteFile(filePath, []byte(cfg.InitialCluster), privateFileMode)
 	return errors.WithStack(err)
 }
 
+// prepareWithData prepares the initial configuration when there is a data
+// directory.
+func prepareWithData(cfg *config.Config, joined bool) error {
+	cfg.InitialClusterState = embed.ClusterStateFlagExisting
+	if !joined {
+		// etcd will get the correct configurations from the data directory.
+		cfg.InitialCluster = ""
+		return nil
+	}
+	// Read the persist join config
+	s, err := os.ReadFile(path.Join(cfg.DataDir, joinFile))
+	if err != nil {
+		log.Fatal("read the join config meet error", errs.ZapError(errs.ErrIORead, err))
+	}
+	cfg.InitialCluster = strings.TrimSpace(string(s))
+	return nil
+}
+
+func newJoinClient(cfg *config.Config, dialTimeout time.Duration) (*clientv3.Client, error) {
+	tlsConfig, err := cfg.Security.ToTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	lgc := zap.NewProductionConfig()
+	lgc.Encoding = log.ZapEncodingName
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(cfg.Join, ","),
+		DialTimeout: dialTimeout,
+		TLS:         tlsConfig,
+		LogConfig:   &lgc,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return client, nil
+}
+
+// checkLocalData checks whether the data in the data directory belongs to the
+// cluster to join. It is best-effort, and skipped if the cluster is not
+// reachable within checkDataTimeout, e.g. all the members are restarting.
+func checkLocalData(cfg *config.Config) error {
+	client, err := newJoinClient(cfg, checkDataTimeout)
+	if err != nil {
+		log.Warn("failed to connect the cluster to join, skip checking the data", errs.ZapError(err))
+		return nil
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(client.Ctx(), checkDataTimeout)
+	defer cancel()
+	listResp, err := client.MemberList(ctx)
+	if err != nil {
+		log.Warn("failed to list the members of the cluster to join, skip checking the data", errs.ZapError(err))
+		return nil
+	}
+
+	// The data directory of the old version PD does not record the cluster ID.
+	if s, err := os.ReadFile(path.Join(cfg.DataDir, etcdClusterIDFile)); err == nil {
+		clusterID := listResp.Header.GetClusterId()
+		if strings.TrimSpace(string(s)) != strconv.FormatUint(clusterID, 10) {
+			return errors.Errorf("the data directory %s belongs to the etcd cluster %s, but the cluster to join is %d",
+				cfg.DataDir, strings.TrimSpace(string(s)), clusterID)
+		}
+	}
+
+	peerURLs := strings.Split(cfg.AdvertisePeerUrls, ",")
+	for _, m := range listResp.Members {
+		// The member which has been added but not started yet has no name.
+		if m.Name == cfg.Name || isSameURLs(m.PeerURLs, peerURLs) {
+			return nil
+		}
+	}
+	return errors.Errorf("the member %s has been removed from the cluster to join, the data in %s is stale", cfg.Name, cfg.DataDir)
+}
+
+// isSameURLs checks whether the two lists have the same URLs regardless of the
+// order.
+func isSameURLs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	urls := make(map[string]struct{}, len(a))
+	for _, u := range a {
+		urls[strings.TrimSpace(u)] = struct{}{}
+	}
+	for _, u := range b {
+		if _, ok := urls[strings.TrimSpace(u)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// archiveData moves the data of the previous member into an archive
+// directory in the data directory.
+func archiveData(dataDir string) error {
+	archiveDir := path.Join(dataDir, fmt.Sprintf("archive-%d", time.Now().Unix()))
+	if err := os.MkdirAll(archiveDir, privateDirMode); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, name := range []string{"member", joinFile, etcdClusterIDFile} {
+		err := os.Rename(path.Join(dataDir, name), path.Join(archiveDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+	}
+	log.Info("the previous data is archived", zap.String("archive-dir", archiveDir))
+	return nil
+}
+
+// checkClusterVersion checks whether the version of the PD is compatible with
+// the version of the cluster to join.
+func checkClusterVersion(client *clientv3.Client) error {
+	version, err := versioninfo.ParseVersion(versioninfo.PDReleaseVersion)
+	if err != nil {
+		// The version is not set for the binaries which are not built with
+		// the release process.
+		return nil
+	}
+	value, err := etcdutil.GetValue(client, pdClusterIDPath)
+	if err != nil || value == nil {
+		return err
+	}
+	clusterID, err := typeutil.BytesToUint64(value)
+	if err != nil {
+		return err
+	}
+	value, err = etcdutil.GetValue(client, path.Join(pdRootPath, strconv.FormatUint(clusterID, 10), "config"))
+	if err != nil || value == nil {
+		return err
+	}
+	var cfg struct {
+		ClusterVersion string `json:"cluster-version"`
+	}
+	if err := json.Unmarshal(value, &cfg); err != nil {
+		return errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	clusterVersion, err := versioninfo.ParseVersion(cfg.ClusterVersion)
+	if err != nil {
+		return err
+	}
+	if !versioninfo.IsCompatible(*clusterVersion, *version) {
+		return errors.Errorf("the version %s is not compatible with the cluster version %s", version, clusterVersion)
+	}
+	return nil
+}
+
 func isDataExist(d string) bool {
 	dir, err := os.Open(d)
 	if err != nil {
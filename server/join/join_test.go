@@ -14,7 +14,11 @@
 package join
 
 import (
+	"os"
+	"path"
+	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/tikv/pd/pkg/testutil"
@@ -36,3 +40,42 @@ func (s *testJoinServerSuite) TestPDJoinsItself(c *C) {
 	cfg.Join = cfg.AdvertiseClientUrls
 	c.Assert(PrepareJoinCluster(cfg), NotNil)
 }
+
+func (s *testJoinServerSuite) TestArchiveData(c *C) {
+	dataDir := c.MkDir()
+	c.Assert(os.MkdirAll(path.Join(dataDir, "member", "wal"), privateDirMode), IsNil)
+	c.Assert(os.WriteFile(path.Join(dataDir, joinFile), []byte("pd=http://127.0.0.1:2380"), privateFileMode), IsNil)
+	c.Assert(isDataExist(path.Join(dataDir, "member")), IsTrue)
+
+	c.Assert(archiveData(dataDir), IsNil)
+	c.Assert(isDataExist(path.Join(dataDir, "member")), IsFalse)
+	_, err := os.Stat(path.Join(dataDir, joinFile))
+	c.Assert(os.IsNotExist(err), IsTrue)
+	archives, err := filepath.Glob(path.Join(dataDir, "archive-*", joinFile))
+	c.Assert(err, IsNil)
+	c.Assert(archives, HasLen, 1)
+}
+
+// A PD with data restarts while the cluster to join is unreachable.
+func (s *testJoinServerSuite) TestRestartWithData(c *C) {
+	cfg := server.NewTestSingleConfig(c)
+	defer testutil.CleanServer(cfg.DataDir)
+	cfg.Join = "http://127.0.0.1:1"
+	c.Assert(os.MkdirAll(path.Join(cfg.DataDir, "member", "wal"), privateDirMode), IsNil)
+
+	start := time.Now()
+	c.Assert(PrepareJoinCluster(cfg), IsNil)
+	c.Assert(time.Since(start) < time.Second, IsTrue)
+	c.Assert(cfg.InitialCluster, Equals, "")
+
+	// The data is kept if the cluster to join is unreachable.
+	cfg.ForceNew = true
+	c.Assert(PrepareJoinCluster(cfg), IsNil)
+	c.Assert(isDataExist(path.Join(cfg.DataDir, "member")), IsTrue)
+}
+
+func (s *testJoinServerSuite) TestIsSameURLs(c *C) {
+	c.Assert(isSameURLs([]string{"http://a:2380", "http://b:2380"}, []string{"http://b:2380", " http://a:2380"}), IsTrue)
+	c.Assert(isSameURLs([]string{"http://a:2380"}, []string{"http://a:2380", "http://b:2380"}), IsFalse)
+	c.Assert(isSameURLs([]string{"http://a:2380", "http://b:2380"}, []string{"http://a:2380", "http://c:2380"}), IsFalse)
+}
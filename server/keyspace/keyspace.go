@@ -0,0 +1,192 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyspace
+
+import (
+	"encoding/json"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultKeyspaceID is reserved for the data which does not belong to any
+	// keyspace, it is never allocated.
+	DefaultKeyspaceID = uint32(0)
+	// MaxKeyspaceID is the max keyspace id, the id is encoded into 3 bytes in
+	// the key prefix of the keyspace.
+	MaxKeyspaceID = uint32(1<<24 - 1)
+)
+
+// The states of the keyspace.
+const (
+	StateEnabled  = "enabled"
+	StateDisabled = "disabled"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_\-]{1,64}$`)
+
+// Meta is the meta of a keyspace.
+type Meta struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+	// State is "enabled" or "disabled". The upper layers should reject the
+	// requests of the disabled keyspace.
+	State          string            `json:"state"`
+	CreatedAt      int64             `json:"created_at"`
+	StateChangedAt int64             `json:"state_changed_at"`
+	Config         map[string]string `json:"config,omitempty"`
+}
+
+// Manager manages the keyspaces. The keyspace metas are loaded from storage
+// by each request, so that the manager keeps no state across leader changes.
+type Manager struct {
+	sync.Mutex
+	storage *core.Storage
+}
+
+// NewManager creates a keyspace manager.
+func NewManager(storage *core.Storage) *Manager {
+	return &Manager{storage: storage}
+}
+
+// CreateKeyspace creates a keyspace with the name and the config, the id is
+// allocated by the manager.
+func (m *Manager) CreateKeyspace(name string, config map[string]string) (*Meta, error) {
+	if !validName.MatchString(name) {
+		return nil, errs.ErrKeyspaceInvalidName.FastGenByArgs(name)
+	}
+	m.Lock()
+	defer m.Unlock()
+	keyspaces, err := m.loadKeyspaces()
+	if err != nil {
+		return nil, err
+	}
+	// The ids are allocated in ascending order and never reused.
+	id := DefaultKeyspaceID
+	for _, keyspace := range keyspaces {
+		if keyspace.Name == name {
+			return nil, errs.ErrKeyspaceExists.FastGenByArgs(name)
+		}
+		if keyspace.ID > id {
+			id = keyspace.ID
+		}
+	}
+	if id >= MaxKeyspaceID {
+		return nil, errs.ErrKeyspaceIDExhausted.FastGenByArgs()
+	}
+	now := time.Now().Unix()
+	meta := &Meta{
+		ID:             id + 1,
+		Name:           name,
+		State:          StateEnabled,
+		CreatedAt:      now,
+		StateChangedAt: now,
+		Config:         config,
+	}
+	if err := m.storage.SaveKeyspace(meta.ID, meta); err != nil {
+		return nil, err
+	}
+	log.Info("keyspace is created", zap.Uint32("id", meta.ID), zap.String("name", name))
+	return meta, nil
+}
+
+// GetKeyspaces returns all keyspaces in the order of the id.
+func (m *Manager) GetKeyspaces() ([]*Meta, error) {
+	return m.loadKeyspaces()
+}
+
+// GetKeyspace returns the keyspace with the name.
+func (m *Manager) GetKeyspace(name string) (*Meta, error) {
+	keyspaces, err := m.loadKeyspaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, keyspace := range keyspaces {
+		if keyspace.Name == name {
+			return keyspace, nil
+		}
+	}
+	return nil, errs.ErrKeyspaceNotFound.FastGenByArgs(name)
+}
+
+// GetKeyspaceByID returns the keyspace with the id.
+func (m *Manager) GetKeyspaceByID(id uint32) (*Meta, error) {
+	meta := &Meta{}
+	ok, err := m.storage.LoadKeyspace(id, meta)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.ErrKeyspaceNotFound.FastGenByArgs(id)
+	}
+	return meta, nil
+}
+
+// EnableKeyspace enables the keyspace with the name.
+func (m *Manager) EnableKeyspace(name string) (*Meta, error) {
+	return m.updateKeyspaceState(name, StateEnabled)
+}
+
+// DisableKeyspace disables the keyspace with the name, the keyspace and its id
+// are kept.
+func (m *Manager) DisableKeyspace(name string) (*Meta, error) {
+	return m.updateKeyspaceState(name, StateDisabled)
+}
+
+func (m *Manager) updateKeyspaceState(name, state string) (*Meta, error) {
+	m.Lock()
+	defer m.Unlock()
+	meta, err := m.GetKeyspace(name)
+	if err != nil {
+		return nil, err
+	}
+	if meta.State == state {
+		return meta, nil
+	}
+	meta.State = state
+	meta.StateChangedAt = time.Now().Unix()
+	if err := m.storage.SaveKeyspace(meta.ID, meta); err != nil {
+		return nil, err
+	}
+	log.Info("keyspace state is updated", zap.Uint32("id", meta.ID), zap.String("name", name), zap.String("state", state))
+	return meta, nil
+}
+
+func (m *Manager) loadKeyspaces() ([]*Meta, error) {
+	var (
+		keyspaces []*Meta
+		err       error
+	)
+	loadErr := m.storage.LoadKeyspaces(func(k, v string) {
+		meta := &Meta{}
+		if e := json.Unmarshal([]byte(v), meta); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByArgs()
+			return
+		}
+		keyspaces = append(keyspaces, meta)
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return keyspaces, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyspace
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+)
+
+func TestKeyspace(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testKeyspaceSuite{})
+
+type testKeyspaceSuite struct{}
+
+func (s *testKeyspaceSuite) TestManager(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	m := NewManager(storage)
+	keyspaces, err := m.GetKeyspaces()
+	c.Assert(err, IsNil)
+	c.Assert(keyspaces, HasLen, 0)
+
+	ks1, err := m.CreateKeyspace("ks1", map[string]string{"gc_life_time": "10m"})
+	c.Assert(err, IsNil)
+	c.Assert(ks1.ID, Equals, uint32(1))
+	c.Assert(ks1.State, Equals, StateEnabled)
+	ks2, err := m.CreateKeyspace("ks2", nil)
+	c.Assert(err, IsNil)
+	c.Assert(ks2.ID, Equals, uint32(2))
+
+	_, err = m.CreateKeyspace("ks1", nil)
+	c.Assert(errs.ErrKeyspaceExists.Equal(err), IsTrue)
+	for _, name := range []string{"", "ks/1", "ks 1"} {
+		_, err = m.CreateKeyspace(name, nil)
+		c.Assert(errs.ErrKeyspaceInvalidName.Equal(err), IsTrue)
+	}
+
+	keyspaces, err = m.GetKeyspaces()
+	c.Assert(err, IsNil)
+	c.Assert(keyspaces, DeepEquals, []*Meta{ks1, ks2})
+	ks, err := m.GetKeyspace("ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, ks1)
+	ks, err = m.GetKeyspaceByID(2)
+	c.Assert(err, IsNil)
+	c.Assert(ks, DeepEquals, ks2)
+	_, err = m.GetKeyspace("ks3")
+	c.Assert(errs.ErrKeyspaceNotFound.Equal(err), IsTrue)
+	_, err = m.GetKeyspaceByID(3)
+	c.Assert(errs.ErrKeyspaceNotFound.Equal(err), IsTrue)
+
+	ks, err = m.DisableKeyspace("ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.State, Equals, StateDisabled)
+	// The state is persisted, and the disabled keyspace keeps its id.
+	ks, err = NewManager(storage).GetKeyspace("ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.State, Equals, StateDisabled)
+	c.Assert(ks.ID, Equals, uint32(1))
+	ks3, err := m.CreateKeyspace("ks3", nil)
+	c.Assert(err, IsNil)
+	c.Assert(ks3.ID, Equals, uint32(3))
+	ks, err = m.EnableKeyspace("ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.State, Equals, StateEnabled)
+	_, err = m.DisableKeyspace("ks4")
+	c.Assert(errs.ErrKeyspaceNotFound.Equal(err), IsTrue)
+}
+
+func (s *testKeyspaceSuite) TestIDExhausted(c *C) {
+	storage := core.NewStorage(kv.NewMemoryKV())
+	c.Assert(storage.SaveKeyspace(MaxKeyspaceID, &Meta{ID: MaxKeyspaceID, Name: "last"}), IsNil)
+	_, err := NewManager(storage).CreateKeyspace("ks", nil)
+	c.Assert(errs.ErrKeyspaceIDExhausted.Equal(err), IsTrue)
+}
@@ -32,9 +32,15 @@ const (
 	slowRequestTime = 1 * time.Second
 )
 
+// Fence returns the comparisons which guard the writes, e.g. the fencing token
+// of the leader. The writes are rejected if any comparison fails, so that a
+// stale leader cannot overwrite the data.
+type Fence func() []clientv3.Cmp
+
 type etcdKVBase struct {
 	client   *clientv3.Client
 	rootPath string
+	fence    Fence
 }
 
 // NewEtcdKVBase creates a new etcd kv.
@@ -45,6 +51,33 @@ func NewEtcdKVBase(client *clientv3.Client, rootPath string) *etcdKVBase {
 	}
 }
 
+// NewFencedEtcdKVBase creates a new etcd kv whose writes are guarded by the fence.
+func NewFencedEtcdKVBase(client *clientv3.Client, rootPath string, fence Fence) *etcdKVBase {
+	return &etcdKVBase{
+		client:   client,
+		rootPath: rootPath,
+		fence:    fence,
+	}
+}
+
+// newTxn creates a transaction guarded by the fence.
+func (kv *etcdKVBase) newTxn() clientv3.Txn {
+	txn := NewSlowLogTxn(kv.client)
+	if kv.fence != nil {
+		if cmps := kv.fence(); len(cmps) > 0 {
+			txn = txn.If(cmps...)
+		}
+	}
+	return txn
+}
+
+func (kv *etcdKVBase) conflictError() error {
+	if kv.fence != nil {
+		return errs.ErrEtcdFenced.FastGenByArgs()
+	}
+	return errs.ErrEtcdTxnConflict.FastGenByArgs()
+}
+
 func (kv *etcdKVBase) Load(key string) (string, error) {
 	key = path.Join(kv.rootPath, key)
 
@@ -86,7 +119,7 @@ func (kv *etcdKVBase) LoadRange(key, endKey string, limit int) ([]string, []stri
 func (kv *etcdKVBase) Save(key, value string) error {
 	key = path.Join(kv.rootPath, key)
 
-	txn := NewSlowLogTxn(kv.client)
+	txn := kv.newTxn()
 	resp, err := txn.Then(clientv3.OpPut(key, value)).Commit()
 	if err != nil {
 		e := errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
@@ -94,7 +127,7 @@ func (kv *etcdKVBase) Save(key, value string) error {
 		return e
 	}
 	if !resp.Succeeded {
-		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+		return kv.conflictError()
 	}
 	return nil
 }
@@ -102,7 +135,7 @@ func (kv *etcdKVBase) Save(key, value string) error {
 func (kv *etcdKVBase) Remove(key string) error {
 	key = path.Join(kv.rootPath, key)
 
-	txn := NewSlowLogTxn(kv.client)
+	txn := kv.newTxn()
 	resp, err := txn.Then(clientv3.OpDelete(key)).Commit()
 	if err != nil {
 		err = errs.ErrEtcdKVDelete.Wrap(err).GenWithStackByCause()
@@ -110,7 +143,7 @@ func (kv *etcdKVBase) Remove(key string) error {
 		return err
 	}
 	if !resp.Succeeded {
-		return errs.ErrEtcdTxnConflict.FastGenByArgs()
+		return kv.conflictError()
 	}
 	return nil
 }
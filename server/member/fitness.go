@@ -0,0 +1,303 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package member
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/etcdutil"
+	"github.com/tikv/pd/pkg/typeutil"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/kv"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// LeaderFitnessCheckInterval is the interval to check the leader fitness.
+	LeaderFitnessCheckInterval = 10 * time.Second
+	// leaderFitnessBaseLatency is the peer latency with which the fitness
+	// score is 50.
+	leaderFitnessBaseLatency = 10 * time.Millisecond
+	// leaderFitnessSmoothing is the weight of the latest self-check in the
+	// fitness score, so that a single slow write does not change it too much.
+	leaderFitnessSmoothing = 0.3
+	// leaderFitnessCheckTimeout is the timeout to probe a peer, the probe which
+	// fails or times out is regarded as taking the timeout.
+	leaderFitnessCheckTimeout = 3 * time.Second
+	// leaderFitnessExpireTime is the time after which the fitness of a member
+	// is regarded as stale. The member which fails to report its fitness is
+	// the least fit.
+	leaderFitnessExpireTime = 3 * LeaderFitnessCheckInterval
+)
+
+// LeaderFitness is the fitness of a member to be the leader, which is measured
+// by the round trip time to the other members.
+type LeaderFitness struct {
+	MemberID    uint64            `json:"member-id"`
+	Name        string            `json:"name"`
+	PeerLatency typeutil.Duration `json:"peer-latency"`
+	// Score is from 0 to 100, the higher the fitter.
+	Score      float64   `json:"score"`
+	UpdateTime time.Time `json:"update-time"`
+}
+
+// leaderFitnessState is the state of the leader fitness check of the member.
+type leaderFitnessState struct {
+	sync.Mutex
+	score float64
+	// fitterSince is the time since when the member is fitter than the etcd
+	// leader continuously.
+	fitterSince time.Time
+	// etcdLeader is the etcd leader observed by the last check, and
+	// leaderSince is the time since when it is observed.
+	etcdLeader  uint64
+	leaderSince time.Time
+	// conns are the connections to probe the other members by the client URL.
+	conns map[string]*grpc.ClientConn
+}
+
+// leaderFitnessScore converts the peer latency to the fitness score.
+func leaderFitnessScore(latency time.Duration) float64 {
+	return 100 * float64(leaderFitnessBaseLatency) / float64(leaderFitnessBaseLatency+latency)
+}
+
+func (m *Member) getMemberLeaderFitnessPath(id uint64) string {
+	return path.Join(m.rootPath, fmt.Sprintf("member/%d/leader_fitness", id))
+}
+
+// checkPeerLatency returns the latency with which the member commits the
+// writes if it is the etcd leader, which is the round trip time to the fastest
+// peers forming a quorum with it. Unlike the latency of the writes, which all
+// go through the current etcd leader, it is measured in the same way by all
+// the members.
+func (m *Member) checkPeerLatency(ctx context.Context) (time.Duration, error) {
+	res, err := etcdutil.ListEtcdMembers(m.client)
+	if err != nil {
+		return 0, err
+	}
+	latencies := make([]time.Duration, 0, len(res.Members))
+	for _, member := range res.Members {
+		if member.GetID() == m.ID() || len(member.GetClientURLs()) == 0 {
+			continue
+		}
+		latencies = append(latencies, m.probePeer(ctx, member.GetClientURLs()[0]))
+	}
+	peers := len(res.Members) / 2
+	if peers == 0 {
+		return 0, nil
+	}
+	if len(latencies) < peers {
+		return leaderFitnessCheckTimeout, nil
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return latencies[peers-1], nil
+}
+
+// probePeer returns the round trip time of a health check to the member.
+func (m *Member) probePeer(ctx context.Context, clientURL string) time.Duration {
+	conn, err := m.getPeerConn(clientURL)
+	if err != nil {
+		log.Warn("failed to connect to the member", zap.String("url", clientURL), errs.ZapError(err))
+		return leaderFitnessCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, leaderFitnessCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		log.Warn("failed to probe the member", zap.String("url", clientURL), errs.ZapError(err))
+		return leaderFitnessCheckTimeout
+	}
+	return time.Since(start)
+}
+
+// getPeerConn returns the connection to the member, which is kept so that the
+// probes don't measure the time to connect.
+func (m *Member) getPeerConn(clientURL string) (*grpc.ClientConn, error) {
+	m.fitness.Lock()
+	defer m.fitness.Unlock()
+	if conn, ok := m.fitness.conns[clientURL]; ok {
+		return conn, nil
+	}
+	conn, err := m.client.Dial(clientURL)
+	if err != nil {
+		return nil, err
+	}
+	if m.fitness.conns == nil {
+		m.fitness.conns = make(map[string]*grpc.ClientConn)
+	}
+	m.fitness.conns[clientURL] = conn
+	return conn, nil
+}
+
+// closePeerConns closes the connections to probe the other members.
+func (m *Member) closePeerConns() {
+	m.fitness.Lock()
+	defer m.fitness.Unlock()
+	for _, conn := range m.fitness.conns {
+		conn.Close()
+	}
+	m.fitness.conns = nil
+}
+
+// UpdateLeaderFitness probes the other members, then updates and saves the
+// fitness of the member.
+func (m *Member) UpdateLeaderFitness(ctx context.Context) (*LeaderFitness, error) {
+	latency, err := m.checkPeerLatency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	score := leaderFitnessScore(latency)
+	m.fitness.Lock()
+	if m.fitness.score > 0 {
+		score = leaderFitnessSmoothing*score + (1-leaderFitnessSmoothing)*m.fitness.score
+	}
+	m.fitness.score = score
+	m.fitness.Unlock()
+
+	fitness := &LeaderFitness{
+		MemberID:    m.ID(),
+		Name:        m.Member().GetName(),
+		PeerLatency: typeutil.NewDuration(latency),
+		Score:       score,
+		UpdateTime:  time.Now(),
+	}
+	value, err := json.Marshal(fitness)
+	if err != nil {
+		return nil, errs.ErrJSONMarshal.Wrap(err).GenWithStackByCause()
+	}
+	res, err := kv.NewSlowLogTxn(m.client).Then(clientv3.OpPut(m.getMemberLeaderFitnessPath(m.ID()), string(value))).Commit()
+	if err != nil {
+		return nil, errs.ErrEtcdKVPut.Wrap(err).GenWithStackByCause()
+	}
+	if !res.Succeeded {
+		return nil, errors.New("failed to save leader fitness")
+	}
+	return fitness, nil
+}
+
+// GetMemberLeaderFitness loads the fitness of a member, nil means the member
+// has not reported its fitness.
+func (m *Member) GetMemberLeaderFitness(id uint64) (*LeaderFitness, error) {
+	res, err := etcdutil.EtcdKVGet(m.client, m.getMemberLeaderFitnessPath(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Kvs) == 0 {
+		return nil, nil
+	}
+	fitness := &LeaderFitness{}
+	if err := json.Unmarshal(res.Kvs[0].Value, fitness); err != nil {
+		return nil, errs.ErrJSONUnmarshal.Wrap(err).GenWithStackByCause()
+	}
+	return fitness, nil
+}
+
+// CheckLeaderFitness updates the fitness of the member, and moves the etcd
+// leader to the member if the member is fitter than the etcd leader by the
+// threshold for a sustained period. It does nothing if the leader fitness
+// transfer is disabled, to save the probes and the writes of the fitness.
+func (m *Member) CheckLeaderFitness(ctx context.Context, cfg *config.PDServerConfig) {
+	if !cfg.EnableLeaderFitnessTransfer {
+		m.fitness.Lock()
+		m.fitness.fitterSince = time.Time{}
+		m.fitness.Unlock()
+		return
+	}
+	fitness, err := m.UpdateLeaderFitness(ctx)
+	if err != nil {
+		log.Error("failed to update leader fitness", errs.ZapError(err))
+		return
+	}
+	fitter, leaderFitness, err := m.isFitterThanEtcdLeader(fitness, cfg)
+	if err != nil {
+		log.Error("failed to load etcd leader fitness", errs.ZapError(err))
+	}
+	now := time.Now()
+	m.fitness.Lock()
+	defer m.fitness.Unlock()
+	if etcdLeader := m.GetEtcdLeader(); etcdLeader != m.fitness.etcdLeader {
+		m.fitness.etcdLeader = etcdLeader
+		m.fitness.leaderSince = now
+	}
+	if !fitter {
+		m.fitness.fitterSince = time.Time{}
+		return
+	}
+	if m.fitness.fitterSince.IsZero() {
+		m.fitness.fitterSince = now
+	}
+	if now.Sub(m.fitness.fitterSince) < cfg.LeaderFitnessTransferDuration.Duration {
+		return
+	}
+	// Avoid transferring the leadership back and forth.
+	if now.Sub(m.fitness.leaderSince) < cfg.LeaderFitnessTransferCooldown.Duration {
+		return
+	}
+	etcdLeader := leaderFitness.MemberID
+	if err := m.MoveEtcdLeader(ctx, etcdLeader, m.ID()); err != nil {
+		log.Error("failed to transfer etcd leader", errs.ZapError(err))
+		return
+	}
+	m.fitness.fitterSince = time.Time{}
+	log.Info("transfer etcd leader to the fitter member",
+		zap.Uint64("from", etcdLeader),
+		zap.Uint64("to", m.ID()),
+		zap.Float64("from-score", leaderFitness.Score),
+		zap.Float64("to-score", fitness.Score))
+}
+
+// isFitterThanEtcdLeader checks if the member is fitter than the etcd leader
+// by the threshold, it returns the fitness of the etcd leader as well. The
+// member whose leader priority is lower than the etcd leader's is never
+// fitter, otherwise the etcd leader moves the leadership back by the priority.
+func (m *Member) isFitterThanEtcdLeader(fitness *LeaderFitness, cfg *config.PDServerConfig) (bool, *LeaderFitness, error) {
+	etcdLeader := m.GetEtcdLeader()
+	if etcdLeader == m.ID() || etcdLeader == 0 {
+		return false, nil, nil
+	}
+	leaderFitness, err := m.GetMemberLeaderFitness(etcdLeader)
+	if err != nil {
+		return false, nil, err
+	}
+	if leaderFitness == nil {
+		return false, nil, errors.Errorf("etcd leader %d has not reported its fitness", etcdLeader)
+	}
+	if time.Since(leaderFitness.UpdateTime) > leaderFitnessExpireTime {
+		leaderFitness.Score = 0
+	}
+	if fitness.Score-leaderFitness.Score < cfg.LeaderFitnessTransferThreshold {
+		return false, leaderFitness, nil
+	}
+	myPriority, err := m.GetMemberLeaderPriority(m.ID())
+	if err != nil {
+		return false, leaderFitness, err
+	}
+	leaderPriority, err := m.GetMemberLeaderPriority(etcdLeader)
+	if err != nil {
+		return false, leaderFitness, err
+	}
+	return myPriority >= leaderPriority, leaderFitness, nil
+}
@@ -58,6 +58,7 @@ type Member struct {
 	// etcd leader key when the PD node is successfully elected as the PD leader
 	// of the cluster. Every write will use it to check PD leadership.
 	memberValue string
+	fitness     leaderFitnessState
 }
 
 // NewMember create a new Member.
@@ -466,5 +467,6 @@ func (m *Member) SetMemberGitHash(id uint64, gitHash string) error {
 
 // Close gracefully shuts down all servers/listeners.
 func (m *Member) Close() {
+	m.closePeerConns()
 	m.Etcd().Close()
 }
@@ -66,6 +66,32 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 13),
 		})
 
+	tsoBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "tso_batch_size",
+			Help:      "Bucketed histogram of the number of the timestamps requested by a tso request.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 13),
+		})
+
+	tsoWaitDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "tso_wait_duration_seconds",
+			Help:      "Bucketed histogram of the time (s) between sending a tso response and receiving the next request on the same stream.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 15),
+		})
+
+	tsoStreamGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "tso_streams",
+			Help:      "The number of the tso streams.",
+		})
+
 	regionHeartbeatHandleDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "pd",
@@ -84,6 +110,22 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 29), // 0.1ms ~ 7hours
 		}, []string{"address", "store"})
 
+	regionResponseCacheCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "region_response_cache",
+			Help:      "Counter of the region response cache lookups of GetRegionByID.",
+		}, []string{"type"})
+
+	grpcRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "server",
+			Name:      "grpc_request",
+			Help:      "Counter of the rejected and slow gRPC requests.",
+		}, []string{"method", "type"})
+
 	serverInfo = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -100,7 +142,12 @@ func init() {
 	prometheus.MustRegister(metadataGauge)
 	prometheus.MustRegister(etcdStateGauge)
 	prometheus.MustRegister(tsoHandleDuration)
+	prometheus.MustRegister(tsoBatchSize)
+	prometheus.MustRegister(tsoWaitDuration)
+	prometheus.MustRegister(tsoStreamGauge)
 	prometheus.MustRegister(regionHeartbeatHandleDuration)
 	prometheus.MustRegister(storeHeartbeatHandleDuration)
+	prometheus.MustRegister(regionResponseCacheCounter)
+	prometheus.MustRegister(grpcRequestCounter)
 	prometheus.MustRegister(serverInfo)
 }
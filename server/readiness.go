@@ -0,0 +1,86 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
+)
+
+const readinessCheckInterval = 100 * time.Millisecond
+
+// IsReady returns whether the server is ready to serve the requests. If the server
+// is started with init-wait, it is ready only after it has joined the cluster,
+// loaded the cluster info and either become the leader or found a leader.
+func (s *Server) IsReady() bool {
+	return atomic.LoadInt64(&s.isReady) == 1 && !s.IsClosed()
+}
+
+// checkReady checks the conditions of init-wait. Since the leader only enables
+// itself after loading the cluster info, a known leader covers both cases that
+// the server becomes the leader or confirms a healthy leader exists.
+func (s *Server) checkReady() bool {
+	return !s.IsClosed() && s.member.GetLeader() != nil
+}
+
+func (s *Server) readinessLoop() {
+	defer logutil.LogPanic()
+	defer s.serverLoopWg.Done()
+
+	ticker := time.NewTicker(readinessCheckInterval)
+	defer ticker.Stop()
+	for {
+		if s.checkReady() {
+			s.markReady()
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-s.serverLoopCtx.Done():
+			log.Info("server is closed, exit readiness loop")
+			return
+		}
+	}
+}
+
+func (s *Server) markReady() {
+	atomic.StoreInt64(&s.isReady, 1)
+	log.Info("server is ready to serve")
+	if err := sdNotify("READY=1"); err != nil {
+		log.Warn("failed to notify systemd", errs.ZapError(err))
+	}
+}
+
+// sdNotify sends the state to systemd through $NOTIFY_SOCKET. It does nothing if
+// the process isn't started by systemd with Type=notify.
+func sdNotify(state string) error {
+	addr := &net.UnixAddr{Name: os.Getenv("NOTIFY_SOCKET"), Net: "unixgram"}
+	if addr.Name == "" {
+		return nil
+	}
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/server/core"
+)
+
+const (
+	defaultRegionResponseCacheSize = 10240
+	regionResponseCacheShards      = 16
+)
+
+// regionResponseCache caches the responses of GetRegionByID. A cached response
+// is reused as long as the epoch, leader, down peers and pending peers of the
+// region are unchanged, which saves the work to rebuild the same responses
+// during the routing refresh storms, e.g. after TiKV restarts. The cache is sharded by the region ID to reduce
+// the lock contention.
+type regionResponseCache struct {
+	shards []cache.Cache
+}
+
+type cachedRegionResponse struct {
+	region *core.RegionInfo
+	resp   *pdpb.GetRegionResponse
+}
+
+func newRegionResponseCache(size int) *regionResponseCache {
+	shardSize := size / regionResponseCacheShards
+	if shardSize < 1 {
+		shardSize = 1
+	}
+	shards := make([]cache.Cache, regionResponseCacheShards)
+	for i := range shards {
+		shards[i] = cache.NewCache(shardSize, cache.LRUCache)
+	}
+	return &regionResponseCache{shards: shards}
+}
+
+func (c *regionResponseCache) shard(regionID uint64) cache.Cache {
+	return c.shards[regionID%regionResponseCacheShards]
+}
+
+// get returns the cached response of the region if it is still up to date.
+func (c *regionResponseCache) get(region *core.RegionInfo) (*pdpb.GetRegionResponse, bool) {
+	v, ok := c.shard(region.GetID()).Get(region.GetID())
+	if !ok {
+		regionResponseCacheCounter.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	cached := v.(*cachedRegionResponse)
+	if cached.region != region && !isRegionResponseUnchanged(cached.region, region) {
+		regionResponseCacheCounter.WithLabelValues("stale").Inc()
+		return nil, false
+	}
+	regionResponseCacheCounter.WithLabelValues("hit").Inc()
+	return cached.resp, true
+}
+
+// put caches the response of the region. The cached response is shared by the
+// requests, so it must not be modified after put.
+func (c *regionResponseCache) put(region *core.RegionInfo, resp *pdpb.GetRegionResponse) {
+	c.shard(region.GetID()).Put(region.GetID(), &cachedRegionResponse{region: region, resp: resp})
+}
+
+func (c *regionResponseCache) remove(regionID uint64) {
+	c.shard(regionID).Remove(regionID)
+}
+
+// isRegionResponseUnchanged checks whether the fields returned by GetRegionByID
+// are the same between the two region infos.
+func isRegionResponseUnchanged(origin, region *core.RegionInfo) bool {
+	originEpoch, epoch := origin.GetRegionEpoch(), region.GetRegionEpoch()
+	if originEpoch.GetVersion() != epoch.GetVersion() || originEpoch.GetConfVer() != epoch.GetConfVer() {
+		return false
+	}
+	if origin.GetLeader().GetId() != region.GetLeader().GetId() {
+		return false
+	}
+	originDownPeers, downPeers := origin.GetDownPeers(), region.GetDownPeers()
+	if len(originDownPeers) != len(downPeers) {
+		return false
+	}
+	for i := range downPeers {
+		if originDownPeers[i].GetPeer().GetId() != downPeers[i].GetPeer().GetId() ||
+			originDownPeers[i].GetDownSeconds() != downPeers[i].GetDownSeconds() {
+			return false
+		}
+	}
+	return isSamePeers(origin.GetPendingPeers(), region.GetPendingPeers())
+}
+
+func isSamePeers(origin, peers []*metapb.Peer) bool {
+	if len(origin) != len(peers) {
+		return false
+	}
+	for i := range peers {
+		if origin[i].GetId() != peers[i].GetId() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testRegionResponseCacheSuite{})
+
+type testRegionResponseCacheSuite struct{}
+
+func (s *testRegionResponseCacheSuite) TestRegionResponseCache(c *C) {
+	peers := []*metapb.Peer{
+		{Id: 1, StoreId: 1},
+		{Id: 2, StoreId: 2},
+		{Id: 3, StoreId: 3},
+	}
+	region := core.NewRegionInfo(&metapb.Region{
+		Id:          1,
+		Peers:       peers,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+	}, peers[0])
+	resp := &pdpb.GetRegionResponse{Region: region.GetMeta(), Leader: region.GetLeader()}
+
+	cache := newRegionResponseCache(10)
+	_, ok := cache.get(region)
+	c.Assert(ok, IsFalse)
+	cache.put(region, resp)
+	cached, ok := cache.get(region)
+	c.Assert(ok, IsTrue)
+	c.Assert(cached, Equals, resp)
+
+	// Flow changes don't invalidate the cache.
+	cached, ok = cache.get(region.Clone(core.SetWrittenBytes(100)))
+	c.Assert(ok, IsTrue)
+	c.Assert(cached, Equals, resp)
+
+	// Epoch, leader, down peers and pending peers changes invalidate the cache.
+	_, ok = cache.get(region.Clone(core.WithIncVersion()))
+	c.Assert(ok, IsFalse)
+	_, ok = cache.get(region.Clone(core.WithLeader(peers[1])))
+	c.Assert(ok, IsFalse)
+	_, ok = cache.get(region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: peers[2], DownSeconds: 10}})))
+	c.Assert(ok, IsFalse)
+	_, ok = cache.get(region.Clone(core.WithPendingPeers(peers[2:])))
+	c.Assert(ok, IsFalse)
+
+	cache.remove(region.GetID())
+	_, ok = cache.get(region)
+	c.Assert(ok, IsFalse)
+}
+
+func newBenchmarkRegion() *core.RegionInfo {
+	peers := []*metapb.Peer{
+		{Id: 1, StoreId: 1},
+		{Id: 2, StoreId: 2},
+		{Id: 3, StoreId: 3},
+	}
+	return core.NewRegionInfo(&metapb.Region{
+		Id:          1,
+		StartKey:    []byte("7480000000000000ff0a5f728000000000ff0f42400000000000fa"),
+		EndKey:      []byte("7480000000000000ff0a5f728000000000ff1e84800000000000fa"),
+		Peers:       peers,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 5, Version: 100},
+	}, peers[0], core.WithPendingPeers(peers[2:]))
+}
+
+func BenchmarkGetRegionResponse(b *testing.B) {
+	region := newBenchmarkRegion()
+	header := &pdpb.ResponseHeader{ClusterId: 1}
+	newResponse := func() *pdpb.GetRegionResponse {
+		return &pdpb.GetRegionResponse{
+			Header:       header,
+			Region:       region.GetMeta(),
+			Leader:       region.GetLeader(),
+			DownPeers:    region.GetDownPeers(),
+			PendingPeers: region.GetPendingPeers(),
+		}
+	}
+	// The responses are marshalled by the codec of the gRPC server.
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := proto.Marshal(newResponse()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+	b.Run("cached", func(b *testing.B) {
+		cache := newRegionResponseCache(defaultRegionResponseCacheSize)
+		cache.put(region, newResponse())
+		// The flow of the region changes after the response is cached.
+		region := region.Clone(core.SetWrittenBytes(100))
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				resp, ok := cache.get(region)
+				if !ok {
+					b.Fatal("cache miss")
+				}
+				if _, err := proto.Marshal(resp); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
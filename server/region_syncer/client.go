@@ -15,6 +15,7 @@ package syncer
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -47,6 +48,23 @@ func (s *RegionSyncer) StopSyncWithLeader() {
 	s.wg.Wait()
 }
 
+// IsRunning returns whether the region syncer is streaming the regions from
+// the leader.
+func (s *RegionSyncer) IsRunning() bool {
+	return atomic.LoadInt32(&s.streamingRunning) == 1
+}
+
+// GetLastSyncTime returns the time of the last message received from the
+// leader, the leader sends a keepalive message if no region is changed.
+func (s *RegionSyncer) GetLastSyncTime() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastSyncTime))
+}
+
+func (s *RegionSyncer) markSynced() {
+	atomic.StoreInt64(&s.lastSyncTime, time.Now().UnixNano())
+	atomic.StoreInt32(&s.streamingRunning, 1)
+}
+
 func (s *RegionSyncer) reset() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -131,6 +149,7 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 	s.mu.RUnlock()
 	go func() {
 		defer s.wg.Done()
+		defer atomic.StoreInt32(&s.streamingRunning, 0)
 		// used to load region from kv storage to cache storage.
 		err := s.server.GetStorage().LoadRegionsOnce(s.server.GetBasicCluster().CheckAndPutRegion)
 		if err != nil {
@@ -173,9 +192,13 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 				continue
 			}
 			log.Info("server starts to synchronize with leader", zap.String("server", s.server.Name()), zap.String("leader", s.server.GetLeader().GetName()), zap.Uint64("request-index", s.history.GetNextIndex()))
+			// The leader sends the records missed by the follower first, and
+			// nothing if the follower is already in sync.
+			s.markSynced()
 			for {
 				resp, err := stream.Recv()
 				if err != nil {
+					atomic.StoreInt32(&s.streamingRunning, 0)
 					log.Error("region sync with leader meet error", errs.ZapError(errs.ErrGRPCRecv, err))
 					if err = stream.CloseSend(); err != nil {
 						log.Error("failed to terminate client stream", errs.ZapError(errs.ErrGRPCCloseSend, err))
@@ -221,6 +244,7 @@ func (s *RegionSyncer) StartSyncWithLeader(addr string) {
 						s.history.Record(region)
 					}
 				}
+				s.markSynced()
 			}
 		}
 	}()
@@ -79,6 +79,11 @@ type RegionSyncer struct {
 	history   *historyBuffer
 	limit     *ratelimit.Bucket
 	tlsConfig *grpcutil.TLSConfig
+	// streamingRunning is set if the follower is streaming the regions from
+	// the leader, and lastSyncTime is the unix nano time of the last message
+	// received from the leader. They are accessed atomically.
+	streamingRunning int32
+	lastSyncTime     int64
 }
 
 // NewRegionSyncer returns a region syncer.
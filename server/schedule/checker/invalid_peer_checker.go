@@ -0,0 +1,108 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"fmt"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// InvalidPeerChecker fixes the peers on the tombstone stores. Such peers may
+// exist after unsafe recovery, and they are ignored by the other checkers.
+// The peers on the stores unknown to the cluster are left untouched, since it
+// is the normal state while PD is being recovered, they are only reported by
+// the region statistics.
+type InvalidPeerChecker struct {
+	cluster opt.Cluster
+}
+
+// NewInvalidPeerChecker creates an invalid peer checker.
+func NewInvalidPeerChecker(cluster opt.Cluster) *InvalidPeerChecker {
+	return &InvalidPeerChecker{
+		cluster: cluster,
+	}
+}
+
+// GetType returns InvalidPeerChecker's type.
+func (c *InvalidPeerChecker) GetType() string {
+	return "invalid-peer-checker"
+}
+
+// Check verifies the stores of a region's peers, creating an operator to
+// replace or remove the peer if its store is tombstone.
+func (c *InvalidPeerChecker) Check(region *core.RegionInfo) *operator.Operator {
+	checkerCounter.WithLabelValues("invalid_peer_checker", "check").Inc()
+	for _, peer := range region.GetPeers() {
+		store := c.cluster.GetStore(peer.GetStoreId())
+		if store == nil {
+			checkerCounter.WithLabelValues("invalid_peer_checker", "unknown-store").Inc()
+			continue
+		}
+		if !store.IsTombstone() {
+			continue
+		}
+		checkerCounter.WithLabelValues("invalid_peer_checker", "tombstone-store").Inc()
+		if peer.GetId() == region.GetLeader().GetId() {
+			checkerCounter.WithLabelValues("invalid_peer_checker", "invalid-leader").Inc()
+			log.Warn("the leader is on a tombstone store", zap.Uint64("region-id", region.GetID()), zap.Uint64("store-id", peer.GetStoreId()))
+			return nil
+		}
+		op, err := c.fixPeer(region, peer)
+		if err != nil {
+			checkerCounter.WithLabelValues("invalid_peer_checker", "create-operator-fail").Inc()
+			log.Debug("fail to fix invalid peer", zap.Uint64("region-id", region.GetID()), errs.ZapError(err))
+			return nil
+		}
+		checkerCounter.WithLabelValues("invalid_peer_checker", "new-operator").Inc()
+		op.SetReason(fmt.Sprintf("the store %d of the peer is tombstone", peer.GetStoreId()))
+		op.SetPriorityLevel(core.HighPriority)
+		return op
+	}
+	return nil
+}
+
+// fixPeer replaces the peer with a new one if there is a suitable store,
+// otherwise removes it. The placement rules are respected by the rule checker
+// after the peer is removed.
+func (c *InvalidPeerChecker) fixPeer(region *core.RegionInfo, peer *metapb.Peer) (*operator.Operator, error) {
+	opts := c.cluster.GetOpts()
+	if !opts.IsPlacementRulesEnabled() && len(region.GetVoters()) <= opts.GetMaxReplicas() {
+		var stores []*core.StoreInfo
+		for _, p := range region.GetPeers() {
+			if store := c.cluster.GetStore(p.GetStoreId()); store != nil && !store.IsTombstone() {
+				stores = append(stores, store)
+			}
+		}
+		strategy := &ReplicaStrategy{
+			checkerName:    c.GetType(),
+			cluster:        c.cluster,
+			locationLabels: opts.GetLocationLabels(),
+			isolationLevel: opts.GetIsolationLevel(),
+			region:         region,
+		}
+		if target := strategy.SelectStoreToAdd(stores); target != 0 {
+			newPeer := &metapb.Peer{StoreId: target, Role: peer.GetRole()}
+			return operator.CreateMovePeerOperator("replace-invalid-peer", c.cluster, region, operator.OpReplica, peer.GetStoreId(), newPeer)
+		}
+		checkerCounter.WithLabelValues("invalid_peer_checker", "no-target-store").Inc()
+	}
+	return operator.CreateRemovePeerOperator("remove-invalid-peer", c.cluster, operator.OpReplica, region, peer.GetStoreId())
+}
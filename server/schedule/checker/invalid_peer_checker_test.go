@@ -0,0 +1,149 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/versioninfo"
+)
+
+var _ = Suite(&testInvalidPeerCheckerSuite{})
+
+type testInvalidPeerCheckerSuite struct {
+	cluster *mockcluster.Cluster
+	ic      *InvalidPeerChecker
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *testInvalidPeerCheckerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.cluster = mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	s.cluster.DisableFeature(versioninfo.JointConsensus)
+	s.cluster.SetEnablePlacementRules(false)
+	s.ic = NewInvalidPeerChecker(s.cluster)
+	for id := uint64(1); id <= 4; id++ {
+		s.cluster.PutStoreWithLabels(id)
+	}
+}
+
+func (s *testInvalidPeerCheckerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testInvalidPeerCheckerSuite) TestSkipUnknownStorePeer(c *C) {
+	// store 5 is unknown to the cluster, which is normal while PD is being
+	// recovered, so the peer is left untouched.
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 5},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	c.Assert(s.ic.Check(region), IsNil)
+	s.cluster.SetEnablePlacementRules(true)
+	c.Assert(s.ic.Check(region), IsNil)
+}
+
+func (s *testInvalidPeerCheckerSuite) TestReplaceTombstoneStorePeer(c *C) {
+	s.cluster.PutStore(s.cluster.GetStore(3).Clone(core.TombstoneStore()))
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	op := s.ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "replace-invalid-peer")
+	c.Assert(op.GetPriorityLevel(), Equals, core.HighPriority)
+	c.Assert(op.Reason(), Equals, "the store 3 of the peer is tombstone")
+	c.Assert(op.Step(0), FitsTypeOf, operator.AddLearner{})
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(4))
+	c.Assert(op.Step(op.Len()-1), FitsTypeOf, operator.RemovePeer{})
+	c.Assert(op.Step(op.Len()-1).(operator.RemovePeer).FromStore, Equals, uint64(3))
+
+	// the rule checker places the new peer if the placement rules are enabled.
+	s.cluster.SetEnablePlacementRules(true)
+	op = s.ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-invalid-peer")
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(3))
+}
+
+func (s *testInvalidPeerCheckerSuite) TestRemoveTombstoneStorePeer(c *C) {
+	s.cluster.PutStore(s.cluster.GetStore(4).Clone(core.TombstoneStore()))
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3},
+				{Id: 104, StoreId: 4},
+			},
+		}, &metapb.Peer{Id: 101, StoreId: 1})
+	// there are more voters than the max replicas, so remove the peer directly.
+	op := s.ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-invalid-peer")
+	c.Assert(op.Reason(), Equals, "the store 4 of the peer is tombstone")
+	c.Assert(op.Len(), Equals, 1)
+	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(4))
+
+	// replace the peer with a new one on store 3.
+	region = region.Clone(core.WithRemoveStorePeer(3))
+	op = s.ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "replace-invalid-peer")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(3))
+
+	// there is no store to place the new peer, so remove the peer directly.
+	s.cluster.SetStoreOffline(3)
+	op = s.ic.Check(region)
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-invalid-peer")
+	c.Assert(op.Step(0).(operator.RemovePeer).FromStore, Equals, uint64(4))
+}
+
+func (s *testInvalidPeerCheckerSuite) TestInvalidLeader(c *C) {
+	s.cluster.PutStore(s.cluster.GetStore(3).Clone(core.TombstoneStore()))
+	region := core.NewRegionInfo(
+		&metapb.Region{
+			Id: 1,
+			Peers: []*metapb.Peer{
+				{Id: 101, StoreId: 1},
+				{Id: 102, StoreId: 2},
+				{Id: 103, StoreId: 3},
+			},
+		}, &metapb.Peer{Id: 103, StoreId: 3})
+	c.Assert(s.ic.Check(region), IsNil)
+
+	region = region.Clone(core.WithLeader(region.GetStorePeer(1)))
+	c.Assert(s.ic.Check(region), NotNil)
+}
@@ -49,6 +49,7 @@ func (c *JointStateChecker) Check(region *core.RegionInfo) *operator.Operator {
 		if op.Len() > 1 {
 			checkerCounter.WithLabelValues("joint_state_checker", "transfer-leader").Inc()
 		}
+		op.SetReason("the region is in joint state")
 		op.SetPriorityLevel(core.HighPriority)
 	}
 	return op
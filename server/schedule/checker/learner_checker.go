@@ -41,6 +41,7 @@ func (l *LearnerChecker) Check(region *core.RegionInfo) *operator.Operator {
 			log.Debug("fail to create promote learner operator", errs.ZapError(err))
 			continue
 		}
+		op.SetReason("the learner should be promoted")
 		return op
 	}
 	return nil
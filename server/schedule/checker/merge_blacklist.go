@@ -0,0 +1,163 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MergeBlacklistItem is a key prefix whose regions must not be merged until it
+// expires.
+type MergeBlacklistItem struct {
+	// Prefix is the key prefix in hex format.
+	Prefix   string    `json:"prefix"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// prefixRange is the key range [startKey, endKey) covered by a key prefix, the
+// empty endKey means +inf.
+type prefixRange struct {
+	startKey, endKey []byte
+}
+
+// mergeBlacklist keeps the key prefixes whose regions must not be merged, e.g.
+// the index ranges under heavy DDL, to prevent the merge/split churn. The
+// prefixes are compiled into the sorted and disjoint key ranges, so a region
+// can be matched by a binary search.
+type mergeBlacklist struct {
+	sync.RWMutex
+	items map[string]*MergeBlacklistItem
+	// ranges is compiled from the items which are not expired.
+	ranges []prefixRange
+	// nextExpire is the earliest expire time of the items, the ranges need to
+	// be compiled again after it.
+	nextExpire time.Time
+}
+
+func newMergeBlacklist() *mergeBlacklist {
+	return &mergeBlacklist{items: make(map[string]*MergeBlacklistItem)}
+}
+
+func (l *mergeBlacklist) put(prefix []byte, ttl time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+	key := hex.EncodeToString(prefix)
+	l.items[key] = &MergeBlacklistItem{Prefix: key, ExpireAt: time.Now().Add(ttl)}
+	l.compile(time.Now())
+}
+
+func (l *mergeBlacklist) remove(prefix []byte) bool {
+	l.Lock()
+	defer l.Unlock()
+	key := hex.EncodeToString(prefix)
+	if _, ok := l.items[key]; !ok {
+		return false
+	}
+	delete(l.items, key)
+	l.compile(time.Now())
+	return true
+}
+
+func (l *mergeBlacklist) getAll() []*MergeBlacklistItem {
+	l.Lock()
+	defer l.Unlock()
+	l.compileIfExpired(time.Now())
+	items := make([]*MergeBlacklistItem, 0, len(l.items))
+	for _, item := range l.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Prefix < items[j].Prefix })
+	return items
+}
+
+// overlaps returns true if the key range [startKey, endKey) overlaps with any
+// blacklisted prefix.
+func (l *mergeBlacklist) overlaps(startKey, endKey []byte) bool {
+	l.RLock()
+	expired := !l.nextExpire.IsZero() && time.Now().After(l.nextExpire)
+	if !expired {
+		defer l.RUnlock()
+		return overlapsPrefixRanges(l.ranges, startKey, endKey)
+	}
+	l.RUnlock()
+
+	l.Lock()
+	defer l.Unlock()
+	l.compileIfExpired(time.Now())
+	return overlapsPrefixRanges(l.ranges, startKey, endKey)
+}
+
+func (l *mergeBlacklist) compileIfExpired(now time.Time) {
+	if !l.nextExpire.IsZero() && now.After(l.nextExpire) {
+		l.compile(now)
+	}
+}
+
+// compile removes the expired items and compiles the others into the ranges.
+func (l *mergeBlacklist) compile(now time.Time) {
+	l.nextExpire = time.Time{}
+	prefixes := make([][]byte, 0, len(l.items))
+	for key, item := range l.items {
+		if now.After(item.ExpireAt) {
+			delete(l.items, key)
+			continue
+		}
+		if l.nextExpire.IsZero() || item.ExpireAt.Before(l.nextExpire) {
+			l.nextExpire = item.ExpireAt
+		}
+		prefix, _ := hex.DecodeString(key)
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return bytes.Compare(prefixes[i], prefixes[j]) < 0 })
+
+	l.ranges = l.ranges[:0]
+	for _, prefix := range prefixes {
+		// The range of a prefix is either contained by the range of the previous
+		// prefix or disjoint with it, so only the latter is kept.
+		if n := len(l.ranges); n > 0 && bytes.HasPrefix(prefix, l.ranges[n-1].startKey) {
+			continue
+		}
+		l.ranges = append(l.ranges, prefixRange{startKey: prefix, endKey: prefixEndKey(prefix)})
+	}
+}
+
+// overlapsPrefixRanges returns true if the key range [startKey, endKey) overlaps
+// with any of the sorted and disjoint ranges.
+func overlapsPrefixRanges(ranges []prefixRange, startKey, endKey []byte) bool {
+	// Find the first range which ends after the startKey.
+	i := sort.Search(len(ranges), func(i int) bool {
+		return len(ranges[i].endKey) == 0 || bytes.Compare(ranges[i].endKey, startKey) > 0
+	})
+	if i == len(ranges) {
+		return false
+	}
+	return len(endKey) == 0 || bytes.Compare(ranges[i].startKey, endKey) < 0
+}
+
+// prefixEndKey returns the smallest key which is larger than all the keys with
+// the prefix, the empty key means +inf.
+func prefixEndKey(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{}
+}
@@ -16,6 +16,7 @@ package checker
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/pingcap/log"
@@ -37,6 +38,7 @@ type MergeChecker struct {
 	cluster    opt.Cluster
 	opts       *config.PersistOptions
 	splitCache *cache.TTLUint64
+	blacklist  *mergeBlacklist
 	startTime  time.Time // it's used to judge whether server recently start.
 }
 
@@ -48,6 +50,7 @@ func NewMergeChecker(ctx context.Context, cluster opt.Cluster) *MergeChecker {
 		cluster:    cluster,
 		opts:       opts,
 		splitCache: splitCache,
+		blacklist:  newMergeBlacklist(),
 		startTime:  time.Now(),
 	}
 }
@@ -65,6 +68,29 @@ func (m *MergeChecker) RecordRegionSplit(regionIDs []uint64) {
 	}
 }
 
+// AddMergeBlacklist prevents the regions with the key prefix from being merged
+// until the TTL expires.
+func (m *MergeChecker) AddMergeBlacklist(prefix []byte, ttl time.Duration) {
+	m.blacklist.put(prefix, ttl)
+}
+
+// RemoveMergeBlacklist removes the key prefix from the merge blacklist. It
+// returns false if the prefix is not found.
+func (m *MergeChecker) RemoveMergeBlacklist(prefix []byte) bool {
+	return m.blacklist.remove(prefix)
+}
+
+// GetMergeBlacklist returns the key prefixes in the merge blacklist.
+func (m *MergeChecker) GetMergeBlacklist() []*MergeBlacklistItem {
+	return m.blacklist.getAll()
+}
+
+// IsMergeBlacklisted returns true if the region overlaps with the key prefixes
+// in the merge blacklist.
+func (m *MergeChecker) IsMergeBlacklisted(region *core.RegionInfo) bool {
+	return m.blacklist.overlaps(region.GetStartKey(), region.GetEndKey())
+}
+
 // Check verifies a region's replicas, creating an Operator if need.
 func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 	checkerCounter.WithLabelValues("merge_checker", "check").Inc()
@@ -79,6 +105,11 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 		return nil
 	}
 
+	if m.blacklist.overlaps(region.GetStartKey(), region.GetEndKey()) {
+		checkerCounter.WithLabelValues("merge_checker", "blacklist").Inc()
+		return nil
+	}
+
 	// when pd just started, it will load region meta from etcd
 	// but the size for these loaded region info is 0
 	// pd don't know the real size of one region until the first heartbeat of the region
@@ -147,11 +178,15 @@ func (m *MergeChecker) Check(region *core.RegionInfo) []*operator.Operator {
 		region.GetApproximateKeys() > target.GetApproximateKeys() {
 		checkerCounter.WithLabelValues("merge_checker", "larger-source").Inc()
 	}
+	for _, op := range ops {
+		op.SetReason(fmt.Sprintf("region %d is small, size %d MB and %d keys", region.GetID(), region.GetApproximateSize(), region.GetApproximateKeys()))
+	}
 	return ops
 }
 
 func (m *MergeChecker) checkTarget(region, adjacent *core.RegionInfo) bool {
 	return adjacent != nil && !m.splitCache.Exists(adjacent.GetID()) && !m.cluster.IsRegionHot(adjacent) &&
+		!m.blacklist.overlaps(adjacent.GetStartKey(), adjacent.GetEndKey()) &&
 		AllowMerge(m.cluster, region, adjacent) && opt.IsRegionHealthy(m.cluster, adjacent) &&
 		opt.IsRegionReplicated(m.cluster, adjacent)
 }
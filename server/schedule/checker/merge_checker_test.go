@@ -435,6 +435,69 @@ var _ = Suite(&testSplitMergeSuite{})
 
 type testSplitMergeSuite struct{}
 
+func (s *testMergeCheckerSuite) TestMergeBlacklist(c *C) {
+	s.cluster.SetSplitMergeInterval(0)
+	ops := s.mc.Check(s.regions[2])
+	c.Assert(ops, NotNil)
+
+	// The region itself is blacklisted.
+	s.mc.AddMergeBlacklist([]byte("t"), time.Hour)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	c.Assert(s.mc.RemoveMergeBlacklist([]byte("t")), IsTrue)
+	c.Assert(s.mc.RemoveMergeBlacklist([]byte("t")), IsFalse)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+
+	// The target region overlaps with the blacklisted prefixes.
+	s.mc.AddMergeBlacklist([]byte("ab"), time.Hour)
+	s.mc.AddMergeBlacklist([]byte("abc"), time.Hour)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	items := s.mc.GetMergeBlacklist()
+	c.Assert(items, HasLen, 2)
+	c.Assert(items[0].Prefix, Equals, hex.EncodeToString([]byte("ab")))
+	c.Assert(items[1].Prefix, Equals, hex.EncodeToString([]byte("abc")))
+	s.mc.RemoveMergeBlacklist([]byte("ab"))
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	s.mc.RemoveMergeBlacklist([]byte("abc"))
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+
+	// The expired prefixes are removed.
+	s.mc.AddMergeBlacklist([]byte("t"), 100*time.Millisecond)
+	c.Assert(s.mc.Check(s.regions[2]), IsNil)
+	time.Sleep(200 * time.Millisecond)
+	c.Assert(s.mc.Check(s.regions[2]), NotNil)
+	c.Assert(s.mc.GetMergeBlacklist(), HasLen, 0)
+}
+
+func (s *testMergeCheckerSuite) TestMergeBlacklistRanges(c *C) {
+	c.Assert(prefixEndKey([]byte("ab")), DeepEquals, []byte("ac"))
+	c.Assert(prefixEndKey([]byte{'a', 0xff}), DeepEquals, []byte("b"))
+	c.Assert(prefixEndKey([]byte{0xff, 0xff}), DeepEquals, []byte{})
+
+	l := newMergeBlacklist()
+	l.put([]byte("b"), time.Hour)
+	l.put([]byte("bc"), time.Hour)
+	l.put([]byte("d"), time.Hour)
+	c.Assert(l.ranges, HasLen, 2)
+	testCases := []struct {
+		startKey, endKey string
+		overlaps         bool
+	}{
+		{"", "", true},
+		{"", "b", false},
+		{"", "b1", true},
+		{"a", "z", true},
+		{"b", "c", true},
+		{"bz", "d", true},
+		{"c", "d", false},
+		{"c", "da", true},
+		{"d", "", true},
+		{"e", "", false},
+	}
+	for _, t := range testCases {
+		c.Assert(l.overlaps([]byte(t.startKey), []byte(t.endKey)), Equals, t.overlaps, Commentf("%+v", t))
+	}
+}
+
 func (s *testMergeCheckerSuite) TestCache(c *C) {
 	cfg := config.NewTestOptions()
 	s.cluster = mockcluster.NewCluster(s.ctx, cfg)
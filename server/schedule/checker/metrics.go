@@ -23,8 +23,17 @@ var (
 			Name:      "event_count",
 			Help:      "Counter of checker events.",
 		}, []string{"type", "name"})
+
+	snapshotLocalityCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "snapshot_locality",
+			Help:      "Counter of the new peers placed in the failure domains with or without a healthy replica.",
+		}, []string{"type", "locality"})
 )
 
 func init() {
 	prometheus.MustRegister(checkerCounter)
+	prometheus.MustRegister(snapshotLocalityCounter)
 }
@@ -167,6 +167,7 @@ func (r *ReplicaChecker) checkMakeUpReplica(region *core.RegionInfo) *operator.O
 		log.Debug("create make-up-replica operator fail", errs.ZapError(err))
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("the region has %d replicas, less than %d", len(region.GetPeers()), r.opts.GetMaxReplicas()))
 	return op
 }
 
@@ -192,6 +193,7 @@ func (r *ReplicaChecker) checkRemoveExtraReplica(region *core.RegionInfo) *opera
 		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("the region has %d voters, more than %d", len(region.GetVoters()), r.opts.GetMaxReplicas()))
 	return op
 }
 
@@ -220,6 +222,7 @@ func (r *ReplicaChecker) checkLocationReplacement(region *core.RegionInfo) *oper
 		checkerCounter.WithLabelValues("replica_checker", "create-operator-fail").Inc()
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("store %d is better isolated than store %d", newStore, oldStore))
 	return op
 }
 
@@ -233,6 +236,7 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 			checkerCounter.WithLabelValues("replica_checker", reason).Inc()
 			return nil
 		}
+		op.SetReason(fmt.Sprintf("the peer on store %d is %s", storeID, status))
 		return op
 	}
 
@@ -253,6 +257,7 @@ func (r *ReplicaChecker) fixPeer(region *core.RegionInfo, storeID uint64, status
 		checkerCounter.WithLabelValues("replica_checker", reason).Inc()
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("the peer on store %d is %s", storeID, status))
 	return op
 }
 
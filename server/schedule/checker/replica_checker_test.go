@@ -555,6 +555,41 @@ func (s *testReplicaCheckerSuite) TestFixDownPeer(c *C) {
 	c.Assert(rc.Check(region), IsNil)
 }
 
+func (s *testReplicaCheckerSuite) TestSnapshotLocality(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	tc.SetLocationLabels([]string{"host"})
+	rc := NewReplicaChecker(tc, cache.NewDefaultCache(10))
+
+	tc.AddLabelsStore(1, 1, map[string]string{"zone": "z1", "host": "h1"})
+	tc.AddLabelsStore(2, 10, map[string]string{"zone": "z1", "host": "h2"})
+	tc.AddLabelsStore(3, 1, map[string]string{"zone": "z2", "host": "h3"})
+	tc.AddLabelsStore(4, 1, map[string]string{"zone": "z3", "host": "h4"})
+	tc.AddLabelsStore(5, 1, map[string]string{"zone": "z3", "host": "h5"})
+	tc.AddLabelsStore(6, 5, map[string]string{"zone": "z2", "host": "h6"})
+
+	tc.AddLeaderRegion(1, 1, 3, 4)
+	region := tc.GetRegion(1)
+	tc.SetStoreDown(4)
+	region = region.Clone(core.WithDownPeers([]*pdpb.PeerStats{
+		{Peer: region.GetStorePeer(4), DownSeconds: 6000},
+	}))
+	// The store with the least regions is selected by default.
+	testutil.CheckTransferPeer(c, rc.Check(region), operator.OpRegion, 4, 5)
+
+	// The stores in z1 and z2 have the healthy replicas.
+	tc.SetSnapshotLocalityLabel("zone")
+	testutil.CheckTransferPeer(c, rc.Check(region), operator.OpRegion, 4, 6)
+	tc.SetStoreDown(6)
+	testutil.CheckTransferPeer(c, rc.Check(region), operator.OpRegion, 4, 2)
+
+	// The pending peer is not healthy.
+	tc.SetStoreUp(6)
+	region = region.Clone(core.WithPendingPeers([]*metapb.Peer{region.GetStorePeer(3)}))
+	testutil.CheckTransferPeer(c, rc.Check(region), operator.OpRegion, 4, 2)
+}
+
 // See issue: https://github.com/tikv/pd/issues/3705
 func (s *testReplicaCheckerSuite) TestFixOfflinePeer(c *C) {
 	opt := config.NewTestOptions()
@@ -18,6 +18,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/filter"
 	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
 	"go.uber.org/zap"
 )
 
@@ -28,6 +29,7 @@ type ReplicaStrategy struct {
 	cluster        opt.Cluster
 	locationLabels []string
 	isolationLevel string
+	labelLimits    []placement.LabelLimit
 	region         *core.RegionInfo
 	extraFilters   []filter.Filter
 }
@@ -60,6 +62,9 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.StoreInfo, e
 	if len(s.locationLabels) > 0 && s.isolationLevel != "" {
 		filters = append(filters, filter.NewIsolationFilter(s.checkerName, s.isolationLevel, s.locationLabels, coLocationStores))
 	}
+	if len(s.labelLimits) > 0 {
+		filters = append(filters, filter.NewLabelLimitFilter(s.checkerName, coLocationStores, s.labelLimits))
+	}
 	if len(extraFilters) > 0 {
 		filters = append(filters, extraFilters...)
 	}
@@ -69,23 +74,60 @@ func (s *ReplicaStrategy) SelectStoreToAdd(coLocationStores []*core.StoreInfo, e
 
 	isolationComparer := filter.IsolationComparer(s.locationLabels, coLocationStores)
 	strictStateFilter := &filter.StoreStateFilter{ActionScope: s.checkerName, MoveRegion: true}
-	target := filter.NewCandidates(s.cluster.GetStores()).
+	candidates := filter.NewCandidates(s.cluster.GetStores()).
 		FilterTarget(s.cluster.GetOpts(), filters...).
-		Sort(isolationComparer).Reverse().Top(isolationComparer).        // greater isolation score is better
-		Sort(filter.RegionScoreComparer(s.cluster.GetOpts())).           // less region score is better
-		FilterTarget(s.cluster.GetOpts(), strictStateFilter).PickFirst() // the filter does not ignore temp states
+		Sort(isolationComparer).Reverse().Top(isolationComparer). // greater isolation score is better
+		Sort(filter.RegionScoreComparer(s.cluster.GetOpts())).    // less region score is better
+		FilterTarget(s.cluster.GetOpts(), strictStateFilter)      // the filter does not ignore temp states
+	// The stores near the healthy replicas are preferred, but the isolation
+	// is never sacrificed for the locality of the snapshot.
+	label := s.cluster.GetOpts().GetSnapshotLocalityLabel()
+	var localityFilter filter.Filter
+	if label != "" {
+		localityFilter = filter.NewSnapshotLocalityFilter(s.checkerName, label, s.healthyStores())
+		candidates.Prefer(s.cluster.GetOpts(), localityFilter)
+	}
+	target := candidates.PickFirst()
 	if target == nil {
 		return 0
 	}
+	if localityFilter != nil {
+		if localityFilter.Target(s.cluster.GetOpts(), target) {
+			snapshotLocalityCounter.WithLabelValues(s.checkerName, "local").Inc()
+		} else {
+			snapshotLocalityCounter.WithLabelValues(s.checkerName, "remote").Inc()
+		}
+	}
 	return target.GetID()
 }
 
+// healthyStores returns the stores of the region's peers which are neither down
+// nor pending, and whose stores are up and connected.
+func (s *ReplicaStrategy) healthyStores() []*core.StoreInfo {
+	var stores []*core.StoreInfo
+	for _, peer := range s.region.GetPeers() {
+		if s.region.GetDownPeer(peer.GetId()) != nil || s.region.GetPendingPeer(peer.GetId()) != nil {
+			continue
+		}
+		store := s.cluster.GetStore(peer.GetStoreId())
+		if store == nil || !store.IsUp() || store.IsDisconnected() {
+			continue
+		}
+		stores = append(stores, store)
+	}
+	return stores
+}
+
 // SelectStoreToFix returns a store to replace down/offline old peer. The location
 // placement after scheduling is allowed to be worse than original.
 func (s *ReplicaStrategy) SelectStoreToFix(coLocationStores []*core.StoreInfo, old uint64) uint64 {
 	// trick to avoid creating a slice with `old` removed.
 	s.swapStoreToFirst(coLocationStores, old)
-	return s.SelectStoreToAdd(coLocationStores[1:])
+	var filters []filter.Filter
+	if oldStore := s.cluster.GetStore(old); oldStore != nil {
+		filters = append(filters, filter.NewEngineGroupFilter(s.checkerName, oldStore.GetEngineGroup()))
+	}
+	return s.SelectStoreToAdd(coLocationStores[1:], filters...)
 }
 
 // SelectStoreToImprove returns a store to replace oldStore. The location
@@ -14,6 +14,7 @@
 package checker
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -97,7 +98,7 @@ func (c *RuleChecker) fixRange(region *core.RegionInfo) *operator.Operator {
 		log.Debug("create split region operator failed", errs.ZapError(err))
 		return nil
 	}
-
+	op.SetReason("the region spans multiple rules")
 	return op
 }
 
@@ -123,6 +124,18 @@ func (c *RuleChecker) fixRulePeer(region *core.RegionInfo, fit *placement.Region
 		if err != nil {
 			return nil, err
 		}
+		if op != nil {
+			op.SetReason(fmt.Sprintf("the peer on store %d does not match the role %s of rule %s/%s", peer.GetStoreId(), rf.Rule.Role, rf.Rule.GroupID, rf.Rule.ID))
+			return op, nil
+		}
+	}
+	// fix peers exceeding the label limits.
+	for _, peer := range rf.PeersExceedingLabelLimits {
+		checkerCounter.WithLabelValues("rule_checker", "fix-label-limit").Inc()
+		op, err := c.fixLabelLimitPeer(region, rf, peer)
+		if err != nil {
+			return nil, err
+		}
 		if op != nil {
 			return op, nil
 		}
@@ -144,6 +157,7 @@ func (c *RuleChecker) addRulePeer(region *core.RegionInfo, rf *placement.RuleFit
 	if err != nil {
 		return nil, err
 	}
+	op.SetReason(fmt.Sprintf("rule %s/%s needs %d peers but has %d", rf.Rule.GroupID, rf.Rule.ID, rf.Rule.Count, len(rf.Peers)))
 	op.SetPriorityLevel(core.HighPriority)
 	return op, nil
 }
@@ -193,6 +207,7 @@ func (c *RuleChecker) replaceUnexpectRulePeer(region *core.RegionInfo, rf *place
 	if newLeader != nil {
 		c.record.incOfflineLeaderCount(newLeader.GetStoreId())
 	}
+	op.SetReason(fmt.Sprintf("the peer on store %d is %s", peer.GetStoreId(), status))
 	op.SetPriorityLevel(core.HighPriority)
 	return op, nil
 }
@@ -223,6 +238,24 @@ func (c *RuleChecker) fixLooseMatchPeer(region *core.RegionInfo, fit *placement.
 	return nil, nil
 }
 
+// The peer's store has the same label value with too many other peers, need
+// to be moved to a store which does not exceed the label limits.
+func (c *RuleChecker) fixLabelLimitPeer(region *core.RegionInfo, rf *placement.RuleFit, peer *metapb.Peer) (*operator.Operator, error) {
+	ruleStores := c.getRuleFitStores(rf)
+	store := c.strategy(region, rf.Rule).SelectStoreToFix(ruleStores, peer.GetStoreId())
+	if store == 0 {
+		checkerCounter.WithLabelValues("rule_checker", "no-store-label-limit").Inc()
+		return nil, errors.New("no store to fix label limit")
+	}
+	newPeer := &metapb.Peer{StoreId: store, Role: rf.Rule.Role.MetaPeerRole()}
+	op, err := operator.CreateMovePeerOperator("fix-label-limit", c.cluster, region, operator.OpReplica, peer.GetStoreId(), newPeer)
+	if err != nil {
+		return nil, err
+	}
+	op.SetReason(fmt.Sprintf("the peer on store %d exceeds the label limits of rule %s/%s", peer.GetStoreId(), rf.Rule.GroupID, rf.Rule.ID))
+	return op, nil
+}
+
 func (c *RuleChecker) allowLeader(fit *placement.RegionFit, peer *metapb.Peer) bool {
 	if core.IsLearner(peer) {
 		return false
@@ -262,7 +295,12 @@ func (c *RuleChecker) fixBetterLocation(region *core.RegionInfo, rf *placement.R
 	}
 	checkerCounter.WithLabelValues("rule_checker", "move-to-better-location").Inc()
 	newPeer := &metapb.Peer{StoreId: newStore, Role: rf.Rule.Role.MetaPeerRole()}
-	return operator.CreateMovePeerOperator("move-to-better-location", c.cluster, region, operator.OpReplica, oldStore, newPeer)
+	op, err := operator.CreateMovePeerOperator("move-to-better-location", c.cluster, region, operator.OpReplica, oldStore, newPeer)
+	if err != nil {
+		return nil, err
+	}
+	op.SetReason(fmt.Sprintf("store %d is better isolated than store %d", newStore, oldStore))
+	return op, nil
 }
 
 func (c *RuleChecker) fixOrphanPeers(region *core.RegionInfo, fit *placement.RegionFit) (*operator.Operator, error) {
@@ -278,7 +316,12 @@ func (c *RuleChecker) fixOrphanPeers(region *core.RegionInfo, fit *placement.Reg
 	}
 	checkerCounter.WithLabelValues("rule_checker", "remove-orphan-peer").Inc()
 	peer := fit.OrphanPeers[0]
-	return operator.CreateRemovePeerOperator("remove-orphan-peer", c.cluster, 0, region, peer.StoreId)
+	op, err := operator.CreateRemovePeerOperator("remove-orphan-peer", c.cluster, 0, region, peer.StoreId)
+	if err != nil {
+		return nil, err
+	}
+	op.SetReason(fmt.Sprintf("the peer on store %d matches no rule", peer.GetStoreId()))
+	return op, nil
 }
 
 func (c *RuleChecker) isDownPeer(region *core.RegionInfo, peer *metapb.Peer) bool {
@@ -318,6 +361,7 @@ func (c *RuleChecker) strategy(region *core.RegionInfo, rule *placement.Rule) *R
 		cluster:        c.cluster,
 		isolationLevel: rule.IsolationLevel,
 		locationLabels: rule.LocationLabels,
+		labelLimits:    rule.LabelLimits,
 		region:         region,
 		extraFilters:   []filter.Filter{filter.NewLabelConstaintFilter(c.name, rule.LabelConstraints)},
 	}
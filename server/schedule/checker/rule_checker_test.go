@@ -348,6 +348,65 @@ func (s *testRuleCheckerSuite) TestNoBetterReplacement(c *C) {
 	c.Assert(op, IsNil)
 }
 
+func (s *testRuleCheckerSuite) TestFixLabelLimit(c *C) {
+	s.cluster.AddLabelsStore(1, 1, map[string]string{"host": "host1"})
+	s.cluster.AddLabelsStore(2, 1, map[string]string{"host": "host1"})
+	s.cluster.AddLabelsStore(3, 1, map[string]string{"host": "host2"})
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3)
+	s.ruleManager.SetRule(&placement.Rule{
+		GroupID:     "pd",
+		ID:          "test",
+		Index:       100,
+		Override:    true,
+		Role:        placement.Voter,
+		Count:       3,
+		LabelLimits: []placement.LabelLimit{{Key: "host", MaxCount: 1}},
+	})
+	// no store to move the peer to.
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, IsNil)
+
+	s.cluster.AddLabelsStore(4, 1, map[string]string{"host": "host1"})
+	s.cluster.AddLabelsStore(5, 1, map[string]string{"host": "host3"})
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "fix-label-limit")
+	c.Assert(op.Reason(), Equals, "the peer on store 2 exceeds the label limits of rule pd/test")
+	c.Assert(op.Step(0).(operator.AddLearner).ToStore, Equals, uint64(5))
+	c.Assert(op.Step(op.Len()-1).(operator.RemovePeer).FromStore, Equals, uint64(2))
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 3, 5)
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, IsNil)
+}
+
+func (s *testRuleCheckerSuite) TestFixCountRange(c *C) {
+	for id := uint64(1); id <= 6; id++ {
+		s.cluster.AddLeaderStore(id, 1)
+	}
+	s.ruleManager.SetRule(&placement.Rule{
+		GroupID:  "pd",
+		ID:       "test",
+		Index:    100,
+		Override: true,
+		Role:     placement.Voter,
+		Count:    3,
+		MaxCount: 5,
+	})
+	// [3, 5] peers are accepted.
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4, 5)
+	c.Assert(s.rc.Check(s.cluster.GetRegion(1)), IsNil)
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2, 3, 4, 5, 6)
+	op := s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "remove-orphan-peer")
+	s.cluster.AddLeaderRegionWithRange(1, "", "", 1, 2)
+	op = s.rc.Check(s.cluster.GetRegion(1))
+	c.Assert(op, NotNil)
+	c.Assert(op.Desc(), Equals, "add-rule-peer")
+}
+
 func (s *testRuleCheckerSuite) TestIssue2419(c *C) {
 	s.cluster.AddLeaderStore(1, 1)
 	s.cluster.AddLeaderStore(2, 1)
@@ -15,6 +15,7 @@ package schedule
 
 import (
 	"context"
+	"time"
 
 	"github.com/tikv/pd/pkg/cache"
 	"github.com/tikv/pd/server/config"
@@ -38,6 +39,7 @@ type CheckerController struct {
 	ruleChecker       *checker.RuleChecker
 	mergeChecker      *checker.MergeChecker
 	jointStateChecker *checker.JointStateChecker
+	invalidChecker    *checker.InvalidPeerChecker
 	regionWaitingList cache.Cache
 }
 
@@ -54,6 +56,7 @@ func NewCheckerController(ctx context.Context, cluster opt.Cluster, ruleManager
 		ruleChecker:       checker.NewRuleChecker(cluster, ruleManager, regionWaitingList),
 		mergeChecker:      checker.NewMergeChecker(ctx, cluster),
 		jointStateChecker: checker.NewJointStateChecker(cluster),
+		invalidChecker:    checker.NewInvalidPeerChecker(cluster),
 		regionWaitingList: regionWaitingList,
 	}
 }
@@ -64,25 +67,49 @@ func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Ope
 	// Don't check isRaftLearnerEnabled cause it maybe disable learner feature but there are still some learners to promote.
 	opController := c.opController
 
-	if op := c.jointStateChecker.Check(region); op != nil {
-		return []*operator.Operator{op}
+	start := time.Now()
+	op := c.jointStateChecker.Check(region)
+	observeCheckerDuration("joint-state-checker", start)
+	if op != nil {
+		return withSource("joint-state-checker", op)
+	}
+
+	start = time.Now()
+	op = c.invalidChecker.Check(region)
+	observeCheckerDuration(c.invalidChecker.GetType(), start)
+	if op != nil {
+		if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
+			return withSource(c.invalidChecker.GetType(), op)
+		}
+		operator.OperatorLimitCounter.WithLabelValues(c.invalidChecker.GetType(), operator.OpReplica.String()).Inc()
+		c.regionWaitingList.Put(region.GetID(), nil)
+		return nil
 	}
 
 	if c.opts.IsPlacementRulesEnabled() {
-		if op := c.ruleChecker.Check(region); op != nil {
+		start = time.Now()
+		op = c.ruleChecker.Check(region)
+		observeCheckerDuration(c.ruleChecker.GetType(), start)
+		if op != nil {
 			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
-				return []*operator.Operator{op}
+				return withSource(c.ruleChecker.GetType(), op)
 			}
 			operator.OperatorLimitCounter.WithLabelValues(c.ruleChecker.GetType(), operator.OpReplica.String()).Inc()
 			c.regionWaitingList.Put(region.GetID(), nil)
 		}
 	} else {
-		if op := c.learnerChecker.Check(region); op != nil {
-			return []*operator.Operator{op}
+		start = time.Now()
+		op = c.learnerChecker.Check(region)
+		observeCheckerDuration("learner-checker", start)
+		if op != nil {
+			return withSource("learner-checker", op)
 		}
-		if op := c.replicaChecker.Check(region); op != nil {
+		start = time.Now()
+		op = c.replicaChecker.Check(region)
+		observeCheckerDuration(c.replicaChecker.GetType(), start)
+		if op != nil {
 			if opController.OperatorCount(operator.OpReplica) < c.opts.GetReplicaScheduleLimit() {
-				return []*operator.Operator{op}
+				return withSource(c.replicaChecker.GetType(), op)
 			}
 			operator.OperatorLimitCounter.WithLabelValues(c.replicaChecker.GetType(), operator.OpReplica.String()).Inc()
 			c.regionWaitingList.Put(region.GetID(), nil)
@@ -94,15 +121,31 @@ func (c *CheckerController) CheckRegion(region *core.RegionInfo) []*operator.Ope
 		if !allowed {
 			operator.OperatorLimitCounter.WithLabelValues(c.mergeChecker.GetType(), operator.OpMerge.String()).Inc()
 		} else {
-			if ops := c.mergeChecker.Check(region); ops != nil {
+			start = time.Now()
+			ops := c.mergeChecker.Check(region)
+			observeCheckerDuration(c.mergeChecker.GetType(), start)
+			if ops != nil {
 				// It makes sure that two operators can be added successfully altogether.
-				return ops
+				return withSource(c.mergeChecker.GetType(), ops...)
 			}
 		}
 	}
 	return nil
 }
 
+// withSource marks the operators as created by the checker.
+func withSource(checker string, ops ...*operator.Operator) []*operator.Operator {
+	for _, op := range ops {
+		op.SetSource(checker)
+	}
+	return ops
+}
+
+// observeCheckerDuration records the time spent by the checker since start.
+func observeCheckerDuration(typ string, start time.Time) {
+	checkerDuration.WithLabelValues(typ).Observe(time.Since(start).Seconds())
+}
+
 // GetMergeChecker returns the merge checker.
 func (c *CheckerController) GetMergeChecker() *checker.MergeChecker {
 	return c.mergeChecker
@@ -44,6 +44,15 @@ func (c *StoreCandidates) FilterTarget(opt *config.PersistOptions, filters ...Fi
 	return c
 }
 
+// Prefer keeps stores that can pass all target filters if there is any,
+// otherwise the candidates are unchanged.
+func (c *StoreCandidates) Prefer(opt *config.PersistOptions, filters ...Filter) *StoreCandidates {
+	if stores := SelectTargetStores(c.Stores, filters, opt); len(stores) > 0 {
+		c.Stores = stores
+	}
+	return c
+}
+
 // Sort sorts store list by given comparer in ascending order.
 func (c *StoreCandidates) Sort(less StoreComparer) *StoreCandidates {
 	sort.Slice(c.Stores, func(i, j int) bool { return less(c.Stores[i], c.Stores[j]) < 0 })
@@ -187,14 +187,39 @@ func (f *storageThresholdFilter) Target(opt *config.PersistOptions, store *core.
 	return !store.IsLowSpace(opt.GetLowSpaceRatio())
 }
 
+type leaderWeightFilter struct{ scope string }
+
+// NewLeaderWeightFilter creates a Filter that filters the stores whose leader
+// weight is 0, which means no new leaders should be transferred to them.
+func NewLeaderWeightFilter(scope string) Filter {
+	return &leaderWeightFilter{scope: scope}
+}
+
+func (f *leaderWeightFilter) Scope() string {
+	return f.scope
+}
+
+func (f *leaderWeightFilter) Type() string {
+	return "leader-weight-filter"
+}
+
+func (f *leaderWeightFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+func (f *leaderWeightFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return store.GetLeaderWeight() > 0
+}
+
 // distinctScoreFilter ensures that distinct score will not decrease.
 type distinctScoreFilter struct {
-	scope     string
-	labels    []string
-	stores    []*core.StoreInfo
-	policy    string
-	safeScore float64
-	srcStore  uint64
+	scope       string
+	labels      []string
+	stores      []*core.StoreInfo
+	policy      string
+	safeScore   float64
+	srcStore    uint64
+	engineGroup string
 }
 
 const (
@@ -227,12 +252,13 @@ func newDistinctScoreFilter(scope string, labels []string, stores []*core.StoreI
 	}
 
 	return &distinctScoreFilter{
-		scope:     scope,
-		labels:    labels,
-		stores:    newStores,
-		safeScore: core.DistinctScore(labels, newStores, source),
-		policy:    policy,
-		srcStore:  source.GetID(),
+		scope:       scope,
+		labels:      labels,
+		stores:      newStores,
+		safeScore:   core.DistinctScore(labels, newStores, source),
+		policy:      policy,
+		srcStore:    source.GetID(),
+		engineGroup: source.GetEngineGroup(),
 	}
 }
 
@@ -249,6 +275,10 @@ func (f *distinctScoreFilter) Source(opt *config.PersistOptions, store *core.Sto
 }
 
 func (f *distinctScoreFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	// The peer is never moved across engine groups.
+	if store.GetEngineGroup() != f.engineGroup {
+		return false
+	}
 	score := core.DistinctScore(f.labels, f.stores, store)
 	switch f.policy {
 	case locationSafeguard:
@@ -341,6 +371,13 @@ func (f *StoreStateFilter) tooManySnapshots(opt *config.PersistOptions, store *c
 		uint64(store.GetReceivingSnapCount()) > opt.GetMaxSnapshotCount())
 }
 
+func (f *StoreStateFilter) slowApplyingSnapshots(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	f.Reason = "slow-applying-snapshot"
+	return !f.AllowTemporaryStates &&
+		opt.GetMaxSnapshotApplyTime() > 0 &&
+		store.GetApplyingSnapDuration() > opt.GetMaxSnapshotApplyTime()
+}
+
 func (f *StoreStateFilter) tooManyPendingPeers(opt *config.PersistOptions, store *core.StoreInfo) bool {
 	f.Reason = "too-many-pending-peer"
 	return !f.AllowTemporaryStates &&
@@ -358,13 +395,13 @@ func (f *StoreStateFilter) hasRejectLeaderProperty(opts *config.PersistOptions,
 // N: the condition is expected to be true for a long time.
 // X means when the condition is true, the store CANNOT be selected.
 //
-// Condition    Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending Reject
-// IsTemporary  N    N       N    N     Y       Y    Y       Y        Y    Y       N
+// Condition    Down Offline Tomb Pause Disconn Busy RmLimit AddLimit Snap Pending Reject SlowApply
+// IsTemporary  N    N       N    N     Y       Y    Y       Y        Y    Y       N      Y
 //
 // LeaderSource X            X    X     X
 // RegionSource                                 X    X                X
 // LeaderTarget X    X       X    X     X       X                                  X
-// RegionTarget X    X       X          X       X            X        X    X
+// RegionTarget X    X       X          X       X            X        X    X              X
 
 const (
 	leaderSource = iota
@@ -386,7 +423,7 @@ func (f *StoreStateFilter) anyConditionMatch(typ int, opt *config.PersistOptions
 			f.isDisconnected, f.isBusy, f.hasRejectLeaderProperty}
 	case regionTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy,
-			f.exceedAddLimit, f.tooManySnapshots, f.tooManyPendingPeers}
+			f.exceedAddLimit, f.tooManySnapshots, f.tooManyPendingPeers, f.slowApplyingSnapshots}
 	case scatterRegionTarget:
 		funcs = []conditionFunc{f.isTombstone, f.isOffline, f.isDown, f.isDisconnected, f.isBusy}
 	}
@@ -456,6 +493,40 @@ func (f labelConstraintFilter) Target(opt *config.PersistOptions, store *core.St
 	return placement.MatchLabelConstraints(store, f.constraints)
 }
 
+// labelLimitFilter is a filter that selects stores which do not exceed the
+// label limits if they are placed along with the other stores.
+type labelLimitFilter struct {
+	scope  string
+	stores []*core.StoreInfo
+	limits []placement.LabelLimit
+}
+
+// NewLabelLimitFilter creates a filter that selects stores which do not exceed
+// the label limits if they are placed along with the stores.
+func NewLabelLimitFilter(scope string, stores []*core.StoreInfo, limits []placement.LabelLimit) Filter {
+	return &labelLimitFilter{scope: scope, stores: stores, limits: limits}
+}
+
+// Scope returns the scheduler or the checker which the filter acts on.
+func (f *labelLimitFilter) Scope() string {
+	return f.scope
+}
+
+// Type returns the name of the filter.
+func (f *labelLimitFilter) Type() string {
+	return "label-limit-filter"
+}
+
+// Source filters stores when select them as schedule source.
+func (f *labelLimitFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+// Target filters stores when select them as schedule target.
+func (f *labelLimitFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return placement.MatchLabelLimits(f.stores, store, f.limits)
+}
+
 // RegionFitter is the interface that can fit a region against placement rules.
 type RegionFitter interface {
 	FitRegion(*core.RegionInfo) *placement.RegionFit
@@ -575,6 +646,32 @@ func NewPlacementLeaderSafeguard(scope string, cluster opt.Cluster, region *core
 	return nil
 }
 
+type engineGroupFilter struct {
+	scope string
+	group string
+}
+
+// NewEngineGroupFilter creates a filter that only keeps the stores in the engine group.
+func NewEngineGroupFilter(scope string, group string) Filter {
+	return &engineGroupFilter{scope: scope, group: group}
+}
+
+func (f *engineGroupFilter) Scope() string {
+	return f.scope
+}
+
+func (f *engineGroupFilter) Type() string {
+	return "engine-group-filter"
+}
+
+func (f *engineGroupFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return store.GetEngineGroup() == f.group
+}
+
+func (f *engineGroupFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return store.GetEngineGroup() == f.group
+}
+
 type engineFilter struct {
 	scope      string
 	constraint placement.LabelConstraint
@@ -682,7 +779,7 @@ const (
 	SpecialUseReserved = "reserved"
 
 	// EngineKey is the label key used to indicate engine.
-	EngineKey = "engine"
+	EngineKey = core.EngineKey
 	// EngineTiFlash is the tiflash value of the engine label.
 	EngineTiFlash = "tiflash"
 	// EngineTiKV indicates the tikv engine in metrics
@@ -758,6 +855,44 @@ func (f *isolationFilter) Target(opt *config.PersistOptions, store *core.StoreIn
 	return true
 }
 
+type snapshotLocalityFilter struct {
+	scope   string
+	label   string
+	domains map[string]struct{}
+}
+
+// NewSnapshotLocalityFilter creates a filter that only keeps the stores in the
+// failure domains of the healthy replicas, where the failure domain is decided
+// by the label. For example, with label = zone, if a region has healthy replicas
+// in z1 and z2, the stores in z1 and z2 are kept, so that the snapshot of the
+// new peer is less likely to be transferred across the zones.
+func NewSnapshotLocalityFilter(scope, label string, healthyStores []*core.StoreInfo) Filter {
+	domains := make(map[string]struct{}, len(healthyStores))
+	for _, store := range healthyStores {
+		if domain := store.GetLabelValue(label); domain != "" {
+			domains[domain] = struct{}{}
+		}
+	}
+	return &snapshotLocalityFilter{scope: scope, label: label, domains: domains}
+}
+
+func (f *snapshotLocalityFilter) Scope() string {
+	return f.scope
+}
+
+func (f *snapshotLocalityFilter) Type() string {
+	return "snapshot-locality-filter"
+}
+
+func (f *snapshotLocalityFilter) Source(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	return true
+}
+
+func (f *snapshotLocalityFilter) Target(opt *config.PersistOptions, store *core.StoreInfo) bool {
+	_, ok := f.domains[store.GetLabelValue(f.label)]
+	return ok
+}
+
 // createRegionForRuleFit is used to create a clone region with RegionCreateOptions which is only used for
 // FitRegion in filter
 func createRegionForRuleFit(startKey, endKey []byte,
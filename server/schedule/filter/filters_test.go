@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -192,6 +193,20 @@ func (s *testFiltersSuite) TestStoreStateFilter(c *C) {
 		{3, true, true},
 	}
 	check(store, testCases)
+
+	// Slow applying snapshots
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.MaxSnapshotApplyTime = typeutil.NewDuration(time.Millisecond)
+	opt.SetScheduleConfig(cfg)
+	store = store.Clone(core.SetStoreStats(&pdpb.StoreStats{ApplyingSnapCount: 1}))
+	time.Sleep(5 * time.Millisecond)
+	testCases = []testCase{
+		{0, true, true},
+		{1, true, false},
+		{2, true, false},
+		{3, true, true},
+	}
+	check(store, testCases)
 }
 
 func (s *testFiltersSuite) TestIsolationFilter(c *C) {
@@ -261,6 +276,31 @@ func (s *testFiltersSuite) TestIsolationFilter(c *C) {
 	}
 }
 
+func (s *testFiltersSuite) TestSnapshotLocalityFilter(c *C) {
+	opt := config.NewTestOptions()
+	testCluster := mockcluster.NewCluster(s.ctx, opt)
+	testCluster.AddLabelsStore(1, 1, map[string]string{"zone": "z1"})
+	testCluster.AddLabelsStore(2, 1, map[string]string{"zone": "z1"})
+	testCluster.AddLabelsStore(3, 1, map[string]string{"zone": "z2"})
+	testCluster.AddLabelsStore(4, 1, map[string]string{"zone": "z3"})
+	testCluster.AddLabelsStore(5, 1, map[string]string{})
+
+	filter := NewSnapshotLocalityFilter("", "zone", []*core.StoreInfo{testCluster.GetStore(1), testCluster.GetStore(3), testCluster.GetStore(5)})
+	targetRes := []bool{true, true, true, false, false}
+	for i, res := range targetRes {
+		store := testCluster.GetStore(uint64(i + 1))
+		c.Assert(filter.Source(testCluster.GetOpts(), store), IsTrue)
+		c.Assert(filter.Target(testCluster.GetOpts(), store), Equals, res)
+	}
+
+	// The candidates are unchanged if no store passes the filter.
+	stores := NewCandidates(testCluster.GetStores()).Prefer(testCluster.GetOpts(), filter).Stores
+	c.Assert(stores, HasLen, 3)
+	filter = NewSnapshotLocalityFilter("", "zone", nil)
+	stores = NewCandidates(testCluster.GetStores()).Prefer(testCluster.GetOpts(), filter).Stores
+	c.Assert(stores, HasLen, 5)
+}
+
 func (s *testFiltersSuite) TestPlacementGuard(c *C) {
 	opt := config.NewTestOptions()
 	opt.SetPlacementRuleEnabled(false)
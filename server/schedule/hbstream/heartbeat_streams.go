@@ -15,6 +15,7 @@ package hbstream
 
 import (
 	"context"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -38,6 +39,25 @@ const (
 type streamUpdate struct {
 	storeID uint64
 	stream  opt.HeartbeatStream
+	// unbind removes the stream if it is still bound to the store.
+	unbind bool
+}
+
+// StreamStatus is the health of the heartbeat stream of a store, which is used
+// to diagnose why the operators are not dispatched to the store.
+type StreamStatus struct {
+	StoreID uint64 `json:"store_id"`
+	// Bound is false if there is no stream to send the messages to the store,
+	// and the messages are dropped until the store reports again.
+	Bound        bool      `json:"bound"`
+	BindTime     time.Time `json:"bind_time"`
+	LastSendTime time.Time `json:"last_send_time"`
+	// Backlog is the number of the messages waiting to be sent to the store.
+	Backlog         int64     `json:"backlog"`
+	SendErrors      uint64    `json:"send_errors"`
+	DroppedMessages uint64    `json:"dropped_messages"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastErrorTime   time.Time `json:"last_error_time"`
 }
 
 // HeartbeatStreams is the bridge of communication with TIKV instance.
@@ -51,6 +71,9 @@ type HeartbeatStreams struct {
 	streamCh       chan streamUpdate
 	storeInformer  core.StoreSetInformer
 	needRun        bool // For test only.
+
+	statusMu sync.RWMutex
+	status   map[uint64]*StreamStatus
 }
 
 // NewHeartbeatStreams creates a new HeartbeatStreams which enable background running by default.
@@ -75,6 +98,7 @@ func newHbStreams(ctx context.Context, clusterID uint64, storeInformer core.Stor
 		streamCh:       make(chan streamUpdate, 1),
 		storeInformer:  storeInformer,
 		needRun:        needRun,
+		status:         make(map[uint64]*StreamStatus),
 	}
 	if needRun {
 		hs.wg.Add(1)
@@ -96,9 +120,22 @@ func (s *HeartbeatStreams) run() {
 	for {
 		select {
 		case update := <-s.streamCh:
+			if update.unbind {
+				if stream, ok := s.streams[update.storeID]; ok && stream == update.stream {
+					log.Info("heartbeat stream is closed", zap.Uint64("store-id", update.storeID))
+					delete(s.streams, update.storeID)
+					s.updateStatus(update.storeID, func(status *StreamStatus) { status.Bound = false })
+				}
+				continue
+			}
 			s.streams[update.storeID] = update.stream
+			s.updateStatus(update.storeID, func(status *StreamStatus) {
+				status.Bound = true
+				status.BindTime = time.Now()
+			})
 		case msg := <-s.msgCh:
 			storeID := msg.GetTargetPeer().GetStoreId()
+			s.updateStatus(storeID, func(status *StreamStatus) { status.Backlog-- })
 			storeLabel := strconv.FormatUint(storeID, 10)
 			store := s.storeInformer.GetStore(storeID)
 			if store == nil {
@@ -106,6 +143,7 @@ func (s *HeartbeatStreams) run() {
 					zap.Uint64("region-id", msg.RegionId),
 					zap.Uint64("store-id", storeID), errs.ZapError(errs.ErrGetSourceStore))
 				delete(s.streams, storeID)
+				s.removeStatus(storeID)
 				continue
 			}
 			storeAddress := store.GetAddress()
@@ -114,14 +152,17 @@ func (s *HeartbeatStreams) run() {
 					log.Error("send heartbeat message fail",
 						zap.Uint64("region-id", msg.RegionId), errs.ZapError(errs.ErrGRPCSend.Wrap(err).GenWithStackByArgs()))
 					delete(s.streams, storeID)
+					s.updateStatus(storeID, func(status *StreamStatus) { status.recordError(err) })
 					heartbeatStreamCounter.WithLabelValues(storeAddress, storeLabel, "push", "err").Inc()
 				} else {
+					s.updateStatus(storeID, func(status *StreamStatus) { status.LastSendTime = time.Now() })
 					heartbeatStreamCounter.WithLabelValues(storeAddress, storeLabel, "push", "ok").Inc()
 				}
 			} else {
 				log.Debug("heartbeat stream not found, skip send message",
 					zap.Uint64("region-id", msg.RegionId),
 					zap.Uint64("store-id", storeID))
+				s.updateStatus(storeID, func(status *StreamStatus) { status.DroppedMessages++ })
 				heartbeatStreamCounter.WithLabelValues(storeAddress, storeLabel, "push", "skip").Inc()
 			}
 		case <-keepAliveTicker.C:
@@ -130,6 +171,7 @@ func (s *HeartbeatStreams) run() {
 				if store == nil {
 					log.Error("failed to get store", zap.Uint64("store-id", storeID), errs.ZapError(errs.ErrGetSourceStore))
 					delete(s.streams, storeID)
+					s.removeStatus(storeID)
 					continue
 				}
 				storeAddress := store.GetAddress()
@@ -139,8 +181,10 @@ func (s *HeartbeatStreams) run() {
 						zap.Uint64("target-store-id", storeID),
 						errs.ZapError(err))
 					delete(s.streams, storeID)
+					s.updateStatus(storeID, func(status *StreamStatus) { status.recordError(err) })
 					heartbeatStreamCounter.WithLabelValues(storeAddress, storeLabel, "keepalive", "err").Inc()
 				} else {
+					s.updateStatus(storeID, func(status *StreamStatus) { status.LastSendTime = time.Now() })
 					heartbeatStreamCounter.WithLabelValues(storeAddress, storeLabel, "keepalive", "ok").Inc()
 				}
 			}
@@ -168,6 +212,71 @@ func (s *HeartbeatStreams) BindStream(storeID uint64, stream opt.HeartbeatStream
 	}
 }
 
+// UnbindStream unbinds the stream from the store if the stream is still bound
+// to it, so that the messages are not sent to the closed stream.
+func (s *HeartbeatStreams) UnbindStream(storeID uint64, stream opt.HeartbeatStream) {
+	update := streamUpdate{
+		storeID: storeID,
+		stream:  stream,
+		unbind:  true,
+	}
+	select {
+	case s.streamCh <- update:
+	case <-s.hbStreamCtx.Done():
+	}
+}
+
+// IsBound returns whether there is a stream bound to the store. The stream is
+// unbound if it fails to send messages, which should be bound again by the
+// next heartbeat of the store.
+func (s *HeartbeatStreams) IsBound(storeID uint64) bool {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	status, ok := s.status[storeID]
+	return ok && status.Bound
+}
+
+// GetStreamStatus returns the status of the heartbeat streams in the order of
+// the store id.
+func (s *HeartbeatStreams) GetStreamStatus() []*StreamStatus {
+	s.statusMu.RLock()
+	defer s.statusMu.RUnlock()
+	res := make([]*StreamStatus, 0, len(s.status))
+	for _, status := range s.status {
+		status := *status
+		res = append(res, &status)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].StoreID < res[j].StoreID })
+	return res
+}
+
+func (s *HeartbeatStreams) updateStatus(storeID uint64, f func(status *StreamStatus)) {
+	if storeID == 0 {
+		return
+	}
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	status, ok := s.status[storeID]
+	if !ok {
+		status = &StreamStatus{StoreID: storeID}
+		s.status[storeID] = status
+	}
+	f(status)
+}
+
+func (s *HeartbeatStreams) removeStatus(storeID uint64) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	delete(s.status, storeID)
+}
+
+func (status *StreamStatus) recordError(err error) {
+	status.Bound = false
+	status.SendErrors++
+	status.LastError = err.Error()
+	status.LastErrorTime = time.Now()
+}
+
 // SendMsg sends a message to related store.
 func (s *HeartbeatStreams) SendMsg(region *core.RegionInfo, msg *pdpb.RegionHeartbeatResponse) {
 	if region.GetLeader() == nil {
@@ -179,6 +288,7 @@ func (s *HeartbeatStreams) SendMsg(region *core.RegionInfo, msg *pdpb.RegionHear
 	msg.RegionEpoch = region.GetRegionEpoch()
 	msg.TargetPeer = region.GetLeader()
 
+	s.updateStatus(msg.TargetPeer.GetStoreId(), func(status *StreamStatus) { status.Backlog++ })
 	select {
 	case s.msgCh <- msg:
 	case <-s.hbStreamCtx.Done():
@@ -198,6 +308,7 @@ func (s *HeartbeatStreams) SendErr(errType pdpb.ErrorType, errMsg string, target
 		TargetPeer: targetPeer,
 	}
 
+	s.updateStatus(targetPeer.GetStoreId(), func(status *StreamStatus) { status.Backlog++ })
 	select {
 	case s.msgCh <- msg:
 	case <-s.hbStreamCtx.Done():
@@ -217,7 +328,8 @@ func (s *HeartbeatStreams) Drain(count int) error {
 		return errors.Normalize("hbstream running enabled")
 	}
 	for i := 0; i < count; i++ {
-		<-s.msgCh
+		msg := <-s.msgCh
+		s.updateStatus(msg.GetTargetPeer().GetStoreId(), func(status *StreamStatus) { status.Backlog-- })
 	}
 	return nil
 }
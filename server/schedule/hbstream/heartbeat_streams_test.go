@@ -16,6 +16,7 @@ package hbstream
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	. "github.com/pingcap/check"
@@ -87,3 +88,47 @@ func (s *testHeartbeatStreamSuite) TestActivity(c *C) {
 		return stream1.Recv() != nil && stream2.Recv() == nil
 	})
 }
+
+func (s *testHeartbeatStreamSuite) TestStreamStatus(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cluster := mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	cluster.AddRegionStore(1, 1)
+	cluster.AddLeaderRegion(1, 1)
+	region := cluster.GetRegion(1)
+
+	hbs := NewTestHeartbeatStreams(ctx, cluster.ID, cluster, true)
+	stream1, stream2 := mockhbstream.NewHeartbeatStream(), mockhbstream.NewHeartbeatStream()
+	c.Assert(hbs.IsBound(1), IsFalse)
+	hbs.BindStream(1, stream1)
+	testutil.WaitUntil(c, func(c *C) bool { return hbs.IsBound(1) })
+
+	// The message is not received, so the stream is unbound after the send
+	// fails.
+	hbs.SendMsg(region, &pdpb.RegionHeartbeatResponse{})
+	testutil.WaitUntil(c, func(c *C) bool { return !hbs.IsBound(1) })
+	status := hbs.GetStreamStatus()
+	c.Assert(status, HasLen, 1)
+	c.Assert(status[0].StoreID, Equals, uint64(1))
+	c.Assert(status[0].SendErrors, Equals, uint64(1))
+	c.Assert(status[0].LastError, Not(Equals), "")
+	c.Assert(status[0].Backlog, Equals, int64(0))
+	// The messages are dropped before the stream is bound again.
+	hbs.SendMsg(region, &pdpb.RegionHeartbeatResponse{})
+	testutil.WaitUntil(c, func(c *C) bool { return hbs.GetStreamStatus()[0].DroppedMessages == 1 })
+
+	hbs.BindStream(1, stream1)
+	testutil.WaitUntil(c, func(c *C) bool { return hbs.IsBound(1) })
+	hbs.SendMsg(region, &pdpb.RegionHeartbeatResponse{})
+	testutil.WaitUntil(c, func(c *C) bool { return stream1.Recv() != nil })
+	c.Assert(hbs.IsBound(1), IsTrue)
+	c.Assert(hbs.GetStreamStatus()[0].LastSendTime.IsZero(), IsFalse)
+
+	// Only the stream bound to the store can be unbound.
+	hbs.UnbindStream(1, stream2)
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(hbs.IsBound(1), IsTrue)
+	hbs.UnbindStream(1, stream1)
+	testutil.WaitUntil(c, func(c *C) bool { return !hbs.IsBound(1) })
+}
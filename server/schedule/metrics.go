@@ -41,6 +41,22 @@ var (
 			Help:      "Counter of schedule waiting operators.",
 		}, []string{"type", "event"})
 
+	operatorRejectCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "operators_rejected_count",
+			Help:      "Counter of rejected schedule operators.",
+		}, []string{"type", "reason"})
+
+	operatorTargetUnavailableCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "operators_target_unavailable_count",
+			Help:      "Counter of the operators canceled because the target store is unavailable.",
+		}, []string{"type", "reason"})
+
 	operatorWaitDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "pd",
@@ -50,6 +66,15 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
 		}, []string{"type"})
 
+	checkerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "pd",
+			Subsystem: "checker",
+			Name:      "check_duration_seconds",
+			Help:      "Bucketed histogram of the time spent in checking one region by the checker.",
+			Buckets:   prometheus.ExponentialBuckets(0.00001, 2, 16),
+		}, []string{"type"})
+
 	storeLimitCostCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "pd",
@@ -79,8 +104,11 @@ func init() {
 	prometheus.MustRegister(operatorCounter)
 	prometheus.MustRegister(operatorDuration)
 	prometheus.MustRegister(operatorWaitDuration)
+	prometheus.MustRegister(checkerDuration)
 	prometheus.MustRegister(storeLimitCostCounter)
 	prometheus.MustRegister(operatorWaitCounter)
+	prometheus.MustRegister(operatorRejectCounter)
+	prometheus.MustRegister(operatorTargetUnavailableCounter)
 	prometheus.MustRegister(scatterCounter)
 	prometheus.MustRegister(scatterDistributionCounter)
 }
@@ -351,6 +351,9 @@ func (b *Builder) prepareBuild() (string, error) {
 	if voterCount == 0 {
 		return "", errors.New("cannot create operator: target peers have no voter")
 	}
+	if err := b.checkEngineGroups(); err != nil {
+		return "", err
+	}
 
 	// Diff `originPeers` and `targetPeers` to initialize `toAdd`, `toRemove`, `toPromote`, `toDemote`.
 	// Note: Use `toDemote` only when `allowDemote` is true. Otherwise use `toAdd`, `toRemove` instead.
@@ -418,8 +421,8 @@ func (b *Builder) prepareBuild() (string, error) {
 
 	if b.targetLeaderStoreID != 0 {
 		targetLeader := b.targetPeers[b.targetLeaderStoreID]
-		if !b.allowLeader(targetLeader, b.forceTargetLeader) {
-			return "", errors.New("cannot create operator: target leader is not allowed")
+		if err := b.checkLeader(targetLeader, b.forceTargetLeader); err != nil {
+			return "", errors.Errorf("cannot create operator: target leader is not allowed, %v", err)
 		}
 	}
 
@@ -433,6 +436,39 @@ func (b *Builder) prepareBuild() (string, error) {
 	return b.brief(), nil
 }
 
+// checkEngineGroups ensures the operator never moves peers across engine groups,
+// that is, it is not allowed to remove peers from an engine group while adding
+// peers to another one.
+func (b *Builder) checkEngineGroups() error {
+	delta := make(map[string]int)
+	for storeID := range b.originPeers {
+		if _, ok := b.targetPeers[storeID]; !ok {
+			if store := b.cluster.GetStore(storeID); store != nil {
+				delta[store.GetEngineGroup()]--
+			}
+		}
+	}
+	for storeID := range b.targetPeers {
+		if _, ok := b.originPeers[storeID]; !ok {
+			if store := b.cluster.GetStore(storeID); store != nil {
+				delta[store.GetEngineGroup()]++
+			}
+		}
+	}
+	var from, to string
+	for group, d := range delta {
+		if d < 0 {
+			from = group
+		} else if d > 0 {
+			to = group
+		}
+	}
+	if from != "" && to != "" {
+		return errors.Errorf("cannot move peers from engine group %s to %s", from, to)
+	}
+	return nil
+}
+
 // generate brief description of the operator.
 func (b *Builder) brief() string {
 	switch {
@@ -702,43 +738,48 @@ func (b *Builder) execChangePeerV2(needEnter bool, needTransferLeader bool) {
 
 // check if the peer is allowed to become the leader.
 func (b *Builder) allowLeader(peer *metapb.Peer, ignoreClusterLimit bool) bool {
+	return b.checkLeader(peer, ignoreClusterLimit) == nil
+}
+
+// checkLeader returns the reason why the peer is not allowed to become leader.
+func (b *Builder) checkLeader(peer *metapb.Peer, ignoreClusterLimit bool) error {
 	// these peer roles are not allowed to become leader.
 	switch peer.GetRole() {
 	case metapb.PeerRole_Learner, metapb.PeerRole_DemotingVoter:
-		return false
+		return errors.Errorf("peer on store %d is %s", peer.GetStoreId(), peer.GetRole())
 	}
 
 	// store does not exist
 	if peer.GetStoreId() == b.currentLeaderStoreID {
-		return true
+		return nil
 	}
 	store := b.cluster.GetStore(peer.GetStoreId())
 	if store == nil {
-		return false
+		return errors.Errorf("store %d not found", peer.GetStoreId())
 	}
 
 	if ignoreClusterLimit {
-		return true
+		return nil
 	}
 
 	stateFilter := &filter.StoreStateFilter{ActionScope: "operator-builder", TransferLeader: true}
 	// store state filter
 	if !stateFilter.Target(b.cluster.GetOpts(), store) {
-		return false
+		return errors.Errorf("store %d is unavailable: %s", store.GetID(), stateFilter.Reason)
 	}
 
 	// placement rules
 	if len(b.rules) == 0 {
-		return true
+		return nil
 	}
 	for _, r := range b.rules {
 		if (r.Role == placement.Leader || r.Role == placement.Voter) &&
 			placement.MatchLabelConstraints(store, r.LabelConstraints) {
-			return true
+			return nil
 		}
 	}
 
-	return false
+	return errors.Errorf("store %d matches no leader or voter rule", store.GetID())
 }
 
 // stepPlan is exec step. It can be:
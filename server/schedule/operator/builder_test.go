@@ -75,6 +75,38 @@ func (s *testBuilderSuite) TestNewBuilder(c *C) {
 	c.Assert(builder.err, NotNil)
 }
 
+func (s *testBuilderSuite) TestEngineGroup(c *C) {
+	s.cluster.AddLabelsStore(11, 0, map[string]string{"engine": "tiflash"})
+	s.cluster.AddLabelsStore(12, 0, map[string]string{"engine": "tiflash"})
+	peers := []*metapb.Peer{
+		{Id: 11, StoreId: 1},
+		{Id: 12, StoreId: 2},
+		{Id: 13, StoreId: 3},
+		{Id: 14, StoreId: 11, Role: metapb.PeerRole_Learner},
+	}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	learner := func(storeID uint64) *metapb.Peer {
+		return &metapb.Peer{StoreId: storeID, Role: metapb.PeerRole_Learner}
+	}
+
+	// Moves across engine groups are rejected.
+	_, err := NewBuilder("test", s.cluster, region).AddPeer(learner(12)).RemovePeer(3).Build(0)
+	c.Assert(err, NotNil)
+	_, err = NewBuilder("test", s.cluster, region).AddPeer(learner(4)).RemovePeer(11).Build(0)
+	c.Assert(err, NotNil)
+
+	// Moves within engine groups are allowed.
+	_, err = NewBuilder("test", s.cluster, region).AddPeer(&metapb.Peer{StoreId: 4}).RemovePeer(3).Build(0)
+	c.Assert(err, IsNil)
+	_, err = NewBuilder("test", s.cluster, region).AddPeer(learner(12)).RemovePeer(11).Build(0)
+	c.Assert(err, IsNil)
+	// Adding or removing peers in an engine group alone is allowed.
+	_, err = NewBuilder("test", s.cluster, region).AddPeer(learner(12)).Build(0)
+	c.Assert(err, IsNil)
+	_, err = NewBuilder("test", s.cluster, region).RemovePeer(11).Build(0)
+	c.Assert(err, IsNil)
+}
+
 func (s *testBuilderSuite) newBuilder() *Builder {
 	peers := []*metapb.Peer{
 		{Id: 11, StoreId: 1},
@@ -23,10 +23,10 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/schedule/placement"
-	"go.uber.org/zap"
 )
 
 // CreateAddPeerOperator creates an operator that adds a new peer.
@@ -258,7 +258,7 @@ func CreateLeaveJointStateOperator(desc string, cluster opt.Cluster, origin *cor
 	if b.targetLeaderStoreID == 0 {
 		log.Error(
 			"unable to find target leader",
-			zap.Reflect("region", origin),
+			logutil.ZapRedactStringer("region", core.RegionToHexMeta(origin.GetMeta())),
 			errs.ZapError(errs.ErrCreateOperator.FastGenByArgs("no target leader")))
 		b.originLeaderStoreID = 0
 	} else if b.originLeaderStoreID != b.targetLeaderStoreID {
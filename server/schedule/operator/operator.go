@@ -38,10 +38,22 @@ const (
 	SlowOperatorWaitTime = 10 * time.Minute
 )
 
+// The sources of the operators which are not created by the schedulers or
+// the checkers.
+const (
+	// SourceHTTP means the operator is created by the HTTP API.
+	SourceHTTP = "http-api"
+	// SourceGRPC means the operator is created by the gRPC API.
+	SourceGRPC = "grpc-api"
+)
+
 // Operator contains execution steps generated by scheduler.
 type Operator struct {
 	desc             string
 	brief            string
+	source           string // the scheduler, checker or API which creates the operator
+	reason           string // why the operator is created
+	force            bool   // whether the operator bypasses the store limit
 	regionID         uint64
 	regionEpoch      *metapb.RegionEpoch
 	kind             OpKind
@@ -80,7 +92,17 @@ func (o *Operator) String() string {
 	for i := range o.steps {
 		stepStrs[i] = o.steps[i].String()
 	}
-	s := fmt.Sprintf("%s {%s} (kind:%s, region:%v(%v,%v), createAt:%s, startAt:%s, currentStep:%v, steps:[%s])", o.desc, o.brief, o.kind, o.regionID, o.regionEpoch.GetVersion(), o.regionEpoch.GetConfVer(), o.GetCreateTime(), o.GetStartTime(), atomic.LoadInt32(&o.currentStep), strings.Join(stepStrs, ", "))
+	s := fmt.Sprintf("%s {%s} (kind:%s, region:%v(%v,%v), createAt:%s, startAt:%s, currentStep:%v, steps:[%s]", o.desc, o.brief, o.kind, o.regionID, o.regionEpoch.GetVersion(), o.regionEpoch.GetConfVer(), o.GetCreateTime(), o.GetStartTime(), atomic.LoadInt32(&o.currentStep), strings.Join(stepStrs, ", "))
+	if o.source != "" {
+		s += ", source:" + o.source
+	}
+	if o.reason != "" {
+		s += ", reason:" + o.reason
+	}
+	if o.force {
+		s += ", force"
+	}
+	s += ")"
 	if o.CheckSuccess() {
 		s = s + " finished"
 	}
@@ -100,11 +122,48 @@ func (o *Operator) Desc() string {
 	return o.desc
 }
 
+// Brief returns the operator's brief of the changes, e.g. the source and the
+// target stores.
+func (o *Operator) Brief() string {
+	return o.brief
+}
+
 // SetDesc sets the description for the operator.
 func (o *Operator) SetDesc(desc string) {
 	o.desc = desc
 }
 
+// Source returns the scheduler, checker or API which creates the operator.
+func (o *Operator) Source() string {
+	return o.source
+}
+
+// SetSource sets the source of the operator.
+func (o *Operator) SetSource(source string) {
+	o.source = source
+}
+
+// Reason returns why the operator is created.
+func (o *Operator) Reason() string {
+	return o.reason
+}
+
+// SetReason sets the one-line reason of the operator.
+func (o *Operator) SetReason(reason string) {
+	o.reason = reason
+}
+
+// IsForce returns whether the operator bypasses the store limit.
+func (o *Operator) IsForce() bool {
+	return o.force
+}
+
+// SetForce sets whether the operator bypasses the store limit. It is only
+// used by the operators created manually for the urgent repairs.
+func (o *Operator) SetForce(force bool) {
+	o.force = force
+}
+
 // AttachKind attaches an operator kind for the operator.
 func (o *Operator) AttachKind(kind OpKind) {
 	o.kind |= kind
@@ -291,6 +350,34 @@ func (o *Operator) UnfinishedInfluence(opInfluence OpInfluence, region *core.Reg
 	}
 }
 
+// UnfinishedTargetStores returns the stores which the unfinished steps add peers
+// to, including the stores of the learners to be promoted.
+func (o *Operator) UnfinishedTargetStores(region *core.RegionInfo) []uint64 {
+	var stores []uint64
+	for step := atomic.LoadInt32(&o.currentStep); int(step) < len(o.steps); step++ {
+		if o.steps[int(step)].IsFinish(region) {
+			continue
+		}
+		switch s := o.steps[int(step)].(type) {
+		case AddPeer:
+			stores = append(stores, s.ToStore)
+		case AddLearner:
+			stores = append(stores, s.ToStore)
+		case AddLightPeer:
+			stores = append(stores, s.ToStore)
+		case AddLightLearner:
+			stores = append(stores, s.ToStore)
+		case PromoteLearner:
+			stores = append(stores, s.ToStore)
+		case ChangePeerV2Enter:
+			for _, pl := range s.PromoteLearners {
+				stores = append(stores, pl.ToStore)
+			}
+		}
+	}
+	return stores
+}
+
 // TotalInfluence calculates the store difference which whole operator steps make.
 func (o *Operator) TotalInfluence(opInfluence OpInfluence, region *core.RegionInfo) {
 	for step := 0; step < len(o.steps); step++ {
@@ -303,6 +390,8 @@ type OpHistory struct {
 	FinishTime time.Time
 	From, To   uint64
 	Kind       core.ResourceKind
+	Source     string
+	Reason     string
 }
 
 // History transfers the operator's steps to operator histories.
@@ -318,6 +407,8 @@ func (o *Operator) History() []OpHistory {
 				From:       s.FromStore,
 				To:         s.ToStore,
 				Kind:       core.LeaderKind,
+				Source:     o.source,
+				Reason:     o.reason,
 			})
 		case AddPeer:
 			addPeerStores = append(addPeerStores, s.ToStore)
@@ -338,6 +429,8 @@ func (o *Operator) History() []OpHistory {
 				From:       removePeerStores[i],
 				To:         addPeerStores[i],
 				Kind:       core.RegionKind,
+				Source:     o.source,
+				Reason:     o.reason,
 			})
 		}
 	}
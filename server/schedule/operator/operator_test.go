@@ -415,3 +415,20 @@ func (s *testOperatorSuite) TestSchedulerKind(c *C) {
 		c.Assert(v.op.SchedulerKind(), Equals, v.expect)
 	}
 }
+
+func (s *testOperatorSuite) TestSourceAndReason(c *C) {
+	op := s.newTestOperator(1, OpLeader, TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(op.String(), Not(Matches), ".*source:.*")
+	c.Assert(op.String(), Not(Matches), ".*reason:.*")
+
+	op.SetSource("balance-leader-scheduler")
+	op.SetReason("store 1 has too many leaders")
+	c.Assert(op.Source(), Equals, "balance-leader-scheduler")
+	c.Assert(op.Reason(), Equals, "store 1 has too many leaders")
+	c.Assert(op.String(), Matches, `.*steps:\[.*\], source:balance-leader-scheduler, reason:store 1 has too many leaders\)`)
+
+	histories := op.History()
+	c.Assert(histories, HasLen, 1)
+	c.Assert(histories[0].Source, Equals, "balance-leader-scheduler")
+	c.Assert(histories[0].Reason, Equals, "store 1 has too many leaders")
+}
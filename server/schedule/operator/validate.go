@@ -0,0 +1,177 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+// StepError describes why a step of the operator can not be executed.
+type StepError struct {
+	Index int
+	Step  OpStep
+	Err   error
+}
+
+func (e StepError) Error() string {
+	return fmt.Sprintf("step %d (%s): %v", e.Index, e.Step, e.Err)
+}
+
+// Validate checks whether the operator is able to be executed on the region.
+// The steps are replayed on a copy of the region one by one, and each of them
+// is checked against the store state, the placement rules and the region it
+// will be applied to. All the problems are collected into the returned error.
+func Validate(cluster opt.Cluster, region *core.RegionInfo, op *Operator) error {
+	if stepErrs := ValidateSteps(cluster, region, op); len(stepErrs) > 0 {
+		msgs := make([]string, 0, len(stepErrs))
+		for _, e := range stepErrs {
+			msgs = append(msgs, e.Error())
+		}
+		return errs.ErrInvalidOperator.FastGenByArgs(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// ValidateSteps is the same as Validate, but returns the problems of the steps
+// separately.
+func ValidateSteps(cluster opt.Cluster, region *core.RegionInfo, op *Operator) []StepError {
+	// placement rules
+	var rules []*placement.Rule
+	if cluster.GetOpts().IsPlacementRulesEnabled() {
+		for _, rf := range cluster.FitRegion(region).RuleFits {
+			rules = append(rules, rf.Rule)
+		}
+	}
+
+	var stepErrs []StepError
+	for i := 0; i < op.Len(); i++ {
+		step := op.Step(i)
+		if err := checkStepStores(cluster, rules, step); err != nil {
+			stepErrs = append(stepErrs, StepError{Index: i, Step: step, Err: err})
+		}
+		if err := step.CheckSafety(region); err != nil {
+			stepErrs = append(stepErrs, StepError{Index: i, Step: step, Err: err})
+		}
+		region = applyStep(region, step)
+	}
+	return stepErrs
+}
+
+// checkStepStores checks whether the stores that the step moves the peer or
+// the leader to are able to accept it.
+func checkStepStores(cluster opt.Cluster, rules []*placement.Rule, step OpStep) error {
+	switch s := step.(type) {
+	case TransferLeader:
+		return checkTargetStore(cluster, rules, s.ToStore, true)
+	case AddPeer:
+		return checkTargetStore(cluster, rules, s.ToStore, false)
+	case AddLearner:
+		return checkTargetStore(cluster, rules, s.ToStore, false)
+	case AddLightPeer:
+		return checkTargetStore(cluster, rules, s.ToStore, false)
+	case AddLightLearner:
+		return checkTargetStore(cluster, rules, s.ToStore, false)
+	case PromoteLearner:
+		return checkTargetStore(cluster, rules, s.ToStore, false)
+	case ChangePeerV2Enter:
+		for _, pl := range s.PromoteLearners {
+			if err := checkTargetStore(cluster, rules, pl.ToStore, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkTargetStore(cluster opt.Cluster, rules []*placement.Rule, storeID uint64, leader bool) error {
+	store := cluster.GetStore(storeID)
+	if store == nil {
+		return errors.Errorf("store %d not found", storeID)
+	}
+	// Only the states which are not expected to change soon are checked.
+	stateFilter := &filter.StoreStateFilter{
+		ActionScope:          "operator-validator",
+		TransferLeader:       leader,
+		MoveRegion:           !leader,
+		AllowTemporaryStates: true,
+	}
+	if !stateFilter.Target(cluster.GetOpts(), store) {
+		return errors.Errorf("store %d is unavailable: %s", storeID, stateFilter.Reason)
+	}
+	if !leader || len(rules) == 0 {
+		return nil
+	}
+	for _, r := range rules {
+		if (r.Role == placement.Leader || r.Role == placement.Voter) &&
+			placement.MatchLabelConstraints(store, r.LabelConstraints) {
+			return nil
+		}
+	}
+	return errors.Errorf("store %d matches no leader or voter rule", storeID)
+}
+
+// applyStep returns a copy of the region as if the step has been finished.
+func applyStep(region *core.RegionInfo, step OpStep) *core.RegionInfo {
+	switch s := step.(type) {
+	case TransferLeader:
+		if peer := region.GetStorePeer(s.ToStore); peer != nil {
+			return region.Clone(core.WithLeader(peer))
+		}
+	case AddPeer:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Voter)
+	case AddLightPeer:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Voter)
+	case AddLearner:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Learner)
+	case AddLightLearner:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Learner)
+	case PromoteLearner:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Voter)
+	case DemoteFollower:
+		return withPeer(region, s.ToStore, s.PeerID, metapb.PeerRole_Learner)
+	case RemovePeer:
+		return region.Clone(core.WithRemoveStorePeer(s.FromStore))
+	case ChangePeerV2Enter:
+		for _, pl := range s.PromoteLearners {
+			region = withPeer(region, pl.ToStore, pl.PeerID, metapb.PeerRole_IncomingVoter)
+		}
+		for _, dv := range s.DemoteVoters {
+			region = withPeer(region, dv.ToStore, dv.PeerID, metapb.PeerRole_DemotingVoter)
+		}
+	case ChangePeerV2Leave:
+		for _, pl := range s.PromoteLearners {
+			region = withPeer(region, pl.ToStore, pl.PeerID, metapb.PeerRole_Voter)
+		}
+		for _, dv := range s.DemoteVoters {
+			region = withPeer(region, dv.ToStore, dv.PeerID, metapb.PeerRole_Learner)
+		}
+	}
+	return region
+}
+
+func withPeer(region *core.RegionInfo, storeID, peerID uint64, role metapb.PeerRole) *core.RegionInfo {
+	return region.Clone(
+		core.WithRemoveStorePeer(storeID),
+		core.WithAddPeer(&metapb.Peer{Id: peerID, StoreId: storeID, Role: role}),
+	)
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operator
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testValidateSuite{})
+
+type testValidateSuite struct {
+	cluster *mockcluster.Cluster
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func (s *testValidateSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.cluster = mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	s.cluster.SetLabelPropertyConfig(config.LabelPropertyConfig{
+		opt.RejectLeader: {{Key: "noleader", Value: "true"}},
+	})
+	for id := uint64(1); id <= 4; id++ {
+		s.cluster.AddLabelsStore(id, 0, map[string]string{"zone": "z1"})
+	}
+	s.cluster.AddLabelsStore(5, 0, map[string]string{"zone": "z1", "noleader": "true"})
+	s.cluster.AddLabelsStore(6, 0, map[string]string{"zone": "z2"})
+}
+
+func (s *testValidateSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testValidateSuite) TestValidateSteps(c *C) {
+	region := s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	newOp := func(steps ...OpStep) *Operator {
+		return NewOperator("test", "test", 1, region.GetRegionEpoch(), OpAdmin, steps...)
+	}
+
+	// The operator created by the builder passes the validation.
+	op, err := CreateMovePeerOperator("test", s.cluster, region, OpAdmin, 3, &metapb.Peer{StoreId: 4})
+	c.Assert(err, IsNil)
+	c.Assert(ValidateSteps(s.cluster, region, op), HasLen, 0)
+	c.Assert(Validate(s.cluster, region, op), IsNil)
+
+	// The stores which are not able to accept the peers.
+	s.cluster.SetStoreOffline(4)
+	op = newOp(AddLearner{ToStore: 4, PeerID: 4}, PromoteLearner{ToStore: 4, PeerID: 4}, RemovePeer{FromStore: 3, PeerID: 3})
+	stepErrs := ValidateSteps(s.cluster, region, op)
+	c.Assert(stepErrs, HasLen, 2)
+	c.Assert(stepErrs[0].Index, Equals, 0)
+	c.Assert(stepErrs[0].Error(), Matches, "step 0 .*store 4 is unavailable: offline")
+	c.Assert(stepErrs[1].Index, Equals, 1)
+	err = Validate(s.cluster, region, op)
+	c.Assert(errs.ErrInvalidOperator.Equal(err), IsTrue)
+
+	op = newOp(AddLearner{ToStore: 7, PeerID: 7})
+	stepErrs = ValidateSteps(s.cluster, region, op)
+	c.Assert(stepErrs, HasLen, 1)
+	c.Assert(stepErrs[0].Error(), Matches, ".*store 7 not found")
+
+	// The store which rejects the leaders.
+	op = newOp(AddLearner{ToStore: 5, PeerID: 5}, PromoteLearner{ToStore: 5, PeerID: 5}, TransferLeader{FromStore: 1, ToStore: 5})
+	stepErrs = ValidateSteps(s.cluster, region, op)
+	c.Assert(stepErrs, HasLen, 1)
+	c.Assert(stepErrs[0].Index, Equals, 2)
+	c.Assert(stepErrs[0].Error(), Matches, ".*store 5 is unavailable: reject-leader")
+
+	// The steps are checked against the region after the previous steps.
+	op = newOp(RemovePeer{FromStore: 2, PeerID: 2}, TransferLeader{FromStore: 1, ToStore: 2})
+	stepErrs = ValidateSteps(s.cluster, region, op)
+	c.Assert(stepErrs, HasLen, 1)
+	c.Assert(stepErrs[0].Index, Equals, 1)
+	c.Assert(stepErrs[0].Error(), Matches, ".*peer does not existed")
+}
+
+func (s *testValidateSuite) TestValidateLeaderRule(c *C) {
+	s.cluster.SetEnablePlacementRules(true)
+	err := s.cluster.RuleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "default", Role: placement.Voter, Count: 3,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z1"}}},
+	})
+	c.Assert(err, IsNil)
+	err = s.cluster.RuleManager.SetRule(&placement.Rule{
+		GroupID: "pd", ID: "learner", Role: placement.Learner, Count: 1,
+		LabelConstraints: []placement.LabelConstraint{{Key: "zone", Op: placement.In, Values: []string{"z2"}}},
+	})
+	c.Assert(err, IsNil)
+	region := s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	newOp := func(steps ...OpStep) *Operator {
+		return NewOperator("test", "test", 1, region.GetRegionEpoch(), OpAdmin, steps...)
+	}
+
+	op := newOp(AddLearner{ToStore: 6, PeerID: 6}, TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(ValidateSteps(s.cluster, region, op), HasLen, 0)
+
+	// The leader can't be transferred to the store matching no leader or voter rule.
+	op = newOp(AddLearner{ToStore: 6, PeerID: 6}, PromoteLearner{ToStore: 6, PeerID: 6}, TransferLeader{FromStore: 1, ToStore: 6})
+	stepErrs := ValidateSteps(s.cluster, region, op)
+	c.Assert(stepErrs, HasLen, 1)
+	c.Assert(stepErrs[0].Index, Equals, 2)
+	c.Assert(stepErrs[0].Error(), Matches, ".*store 6 matches no leader or voter rule")
+}
+
+func (s *testValidateSuite) TestApplyStep(c *C) {
+	region := s.cluster.AddLeaderRegion(1, 1, 2, 3)
+	region = applyStep(region, AddLearner{ToStore: 4, PeerID: 4})
+	c.Assert(core.IsLearner(region.GetStorePeer(4)), IsTrue)
+	region = applyStep(region, ChangePeerV2Enter{
+		PromoteLearners: []PromoteLearner{{ToStore: 4, PeerID: 4}},
+		DemoteVoters:    []DemoteVoter{{ToStore: 3, PeerID: 3}},
+	})
+	c.Assert(region.GetStorePeer(4).GetRole(), Equals, metapb.PeerRole_IncomingVoter)
+	c.Assert(region.GetStorePeer(3).GetRole(), Equals, metapb.PeerRole_DemotingVoter)
+	region = applyStep(region, ChangePeerV2Leave{
+		PromoteLearners: []PromoteLearner{{ToStore: 4, PeerID: 4}},
+		DemoteVoters:    []DemoteVoter{{ToStore: 3, PeerID: 3}},
+	})
+	c.Assert(region.GetStorePeer(4).GetRole(), Equals, metapb.PeerRole_Voter)
+	c.Assert(core.IsLearner(region.GetStorePeer(3)), IsTrue)
+	region = applyStep(region, TransferLeader{FromStore: 1, ToStore: 4})
+	c.Assert(region.GetLeader().GetStoreId(), Equals, uint64(4))
+	region = applyStep(region, RemovePeer{FromStore: 3, PeerID: 3})
+	c.Assert(region.GetStorePeer(3), IsNil)
+	c.Assert(region.GetPeers(), HasLen, 3)
+}
@@ -44,6 +44,22 @@ const (
 	DispatchFromCreate        = "create"
 )
 
+// RejectReason is the reason why the operator controller refuses to add operators.
+type RejectReason string
+
+// The reasons of rejecting operators.
+const (
+	RejectNone             RejectReason = ""
+	RejectRegionNotFound   RejectReason = "region-not-found"
+	RejectEpochNotMatch    RejectReason = "epoch-not-match"
+	RejectAlreadyHave      RejectReason = "already-have"
+	RejectUnexpectedStatus RejectReason = "unexpected-status"
+	RejectExceedMaxWaiting RejectReason = "exceed-max-waiting"
+	RejectExpired          RejectReason = "expired"
+	RejectExceedStoreLimit RejectReason = "exceed-store-limit"
+	RejectRecentlyMoved    RejectReason = "recently-moved"
+)
+
 var (
 	historyKeepTime    = 5 * time.Minute
 	slowNotifyInterval = 5 * time.Second
@@ -64,6 +80,7 @@ type OperatorController struct {
 	operators       map[uint64]*operator.Operator
 	hbStreams       *hbstream.HeartbeatStreams
 	fastOperators   *cache.TTLUint64
+	recentlyMoved   *cache.TTLUint64
 	histories       *list.List
 	counts          map[operator.OpKind]uint64
 	opRecords       *OperatorRecords
@@ -71,6 +88,7 @@ type OperatorController struct {
 	wop             WaitingOperator
 	wopStatus       *WaitingOperatorStatus
 	opNotifierQueue operatorQueue
+	endListeners    []func(*operator.Operator)
 }
 
 // NewOperatorController creates a OperatorController.
@@ -82,6 +100,7 @@ func NewOperatorController(ctx context.Context, cluster opt.Cluster, hbStreams *
 		hbStreams:       hbStreams,
 		histories:       list.New(),
 		fastOperators:   cache.NewIDTTL(ctx, time.Minute, FastOperatorFinishTime),
+		recentlyMoved:   cache.NewIDTTL(ctx, time.Minute, time.Minute),
 		counts:          make(map[operator.OpKind]uint64),
 		opRecords:       NewOperatorRecords(ctx),
 		storesLimit:     make(map[uint64]map[storelimit.Type]*storelimit.StoreLimit),
@@ -123,6 +142,9 @@ func (oc *OperatorController) Dispatch(region *core.RegionInfo, source string) {
 			if source == DispatchFromHeartBeat && oc.checkStaleOperator(op, step, region) {
 				return
 			}
+			if oc.checkUnavailableTarget(op, region) {
+				return
+			}
 			oc.SendScheduleCommand(region, step, source)
 		case operator.SUCCESS:
 			oc.pushHistory(op)
@@ -194,6 +216,58 @@ func (oc *OperatorController) checkStaleOperator(op *operator.Operator, step ope
 	return false
 }
 
+// checkUnavailableTarget cancels the operator if a store which the operator adds
+// a peer to becomes unavailable, and lets the checkers check the region again,
+// so that the region is scheduled to another store instead of waiting for the
+// operator to time out.
+func (oc *OperatorController) checkUnavailableTarget(op *operator.Operator, region *core.RegionInfo) bool {
+	// The region in the joint state must leave it first.
+	if core.IsInJointState(region.GetPeers()...) {
+		return false
+	}
+	for _, storeID := range op.UnfinishedTargetStores(region) {
+		reason := oc.getStoreUnavailableReason(storeID)
+		if reason == "" {
+			continue
+		}
+		if oc.RemoveOperator(
+			op,
+			zap.String("reason", "target store is unavailable"),
+			zap.Uint64("target-store", storeID),
+			zap.String("store-state", reason),
+		) {
+			operatorCounter.WithLabelValues(op.Desc(), "target-unavailable").Inc()
+			operatorTargetUnavailableCounter.WithLabelValues(op.Desc(), reason).Inc()
+			operatorWaitCounter.WithLabelValues(op.Desc(), "promote-target-unavailable").Inc()
+			oc.cluster.AddSuspectRegions(region.GetID())
+			oc.PromoteWaitingOperator()
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// getStoreUnavailableReason returns why the store can not be the target of an
+// operator, or empty if the store is available. The store which is not found is
+// left to the timeout of the operator. The store is regarded as unavailable once
+// it is disconnected, since it becomes down only after max-store-down-time,
+// which is much longer than the timeout of the operator.
+func (oc *OperatorController) getStoreUnavailableReason(storeID uint64) string {
+	store := oc.cluster.GetStore(storeID)
+	switch {
+	case store == nil:
+		return ""
+	case store.IsTombstone():
+		return "tombstone"
+	case store.IsOffline():
+		return "offline"
+	case store.IsDisconnected():
+		return "disconnected"
+	}
+	return ""
+}
+
 func (oc *OperatorController) getNextPushOperatorTime(step operator.OpStep, now time.Time) time.Time {
 	nextTime := slowNotifyInterval
 	switch step.(type) {
@@ -285,14 +359,11 @@ func (oc *OperatorController) AddWaitingOperator(ops ...*operator.Operator) int
 			}
 			isMerge = true
 		}
-		if !oc.checkAddOperator(op) {
-			_ = op.Cancel()
-			oc.buryOperator(op)
+		if reason := oc.checkAddOperator(op); reason != RejectNone {
+			oc.rejectOperators(reason, op)
 			if isMerge {
 				// Merge operation have two operators, cancel them all
-				next := ops[i+1]
-				_ = next.Cancel()
-				oc.buryOperator(next)
+				oc.rejectOperators(reason, ops[i+1])
 			}
 			oc.Unlock()
 			return added
@@ -318,22 +389,40 @@ func (oc *OperatorController) AddWaitingOperator(ops ...*operator.Operator) int
 
 // AddOperator adds operators to the running operators.
 func (oc *OperatorController) AddOperator(ops ...*operator.Operator) bool {
+	ok, _ := oc.AddOperatorWithReason(ops...)
+	return ok
+}
+
+// AddOperatorWithReason adds operators to the running operators. It returns
+// the reason if the operators are rejected.
+func (oc *OperatorController) AddOperatorWithReason(ops ...*operator.Operator) (bool, RejectReason) {
 	oc.Lock()
 	defer oc.Unlock()
 
-	if oc.exceedStoreLimitLocked(ops...) || !oc.checkAddOperator(ops...) {
-		for _, op := range ops {
-			_ = op.Cancel()
-			oc.buryOperator(op)
-		}
-		return false
+	reason := RejectExceedStoreLimit
+	if !oc.exceedStoreLimitLocked(ops...) {
+		reason = oc.checkAddOperator(ops...)
+	}
+	if reason != RejectNone {
+		oc.rejectOperators(reason, ops...)
+		return false, reason
 	}
 	for _, op := range ops {
 		if !oc.addOperatorLocked(op) {
-			return false
+			operatorRejectCounter.WithLabelValues(op.Desc(), string(RejectUnexpectedStatus)).Inc()
+			return false, RejectUnexpectedStatus
 		}
 	}
-	return true
+	return true, RejectNone
+}
+
+// rejectOperators cancels the rejected operators and records the reason.
+func (oc *OperatorController) rejectOperators(reason RejectReason, ops ...*operator.Operator) {
+	for _, op := range ops {
+		operatorRejectCounter.WithLabelValues(op.Desc(), string(reason)).Inc()
+		_ = op.Cancel()
+		oc.buryOperator(op)
+	}
 }
 
 // PromoteWaitingOperator promotes operators from waiting operators.
@@ -349,12 +438,15 @@ func (oc *OperatorController) PromoteWaitingOperator() {
 		}
 		operatorWaitCounter.WithLabelValues(ops[0].Desc(), "get").Inc()
 
-		if oc.exceedStoreLimitLocked(ops...) || !oc.checkAddOperator(ops...) {
+		reason := RejectExceedStoreLimit
+		if !oc.exceedStoreLimitLocked(ops...) {
+			reason = oc.checkAddOperator(ops...)
+		}
+		if reason != RejectNone {
 			for _, op := range ops {
 				operatorWaitCounter.WithLabelValues(op.Desc(), "promote-canceled").Inc()
-				_ = op.Cancel()
-				oc.buryOperator(op)
 			}
+			oc.rejectOperators(reason, ops...)
 			oc.wopStatus.ops[ops[0].Desc()]--
 			continue
 		}
@@ -376,14 +468,15 @@ func (oc *OperatorController) PromoteWaitingOperator() {
 // - The region already has a higher priority or same priority operator.
 // - Exceed the max number of waiting operators
 // - At least one operator is expired.
-func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) bool {
+// It returns the reason if the operators can't be added.
+func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) RejectReason {
 	for _, op := range ops {
 		region := oc.cluster.GetRegion(op.RegionID())
 		if region == nil {
 			log.Debug("region not found, cancel add operator",
 				zap.Uint64("region-id", op.RegionID()))
 			operatorWaitCounter.WithLabelValues(op.Desc(), "not-found").Inc()
-			return false
+			return RejectRegionNotFound
 		}
 		if region.GetRegionEpoch().GetVersion() != op.RegionEpoch().GetVersion() ||
 			region.GetRegionEpoch().GetConfVer() != op.RegionEpoch().GetConfVer() {
@@ -392,14 +485,14 @@ func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) bool {
 				zap.Reflect("old", region.GetRegionEpoch()),
 				zap.Reflect("new", op.RegionEpoch()))
 			operatorWaitCounter.WithLabelValues(op.Desc(), "epoch-not-match").Inc()
-			return false
+			return RejectEpochNotMatch
 		}
 		if old := oc.operators[op.RegionID()]; old != nil && !isHigherPriorityOperator(op, old) {
 			log.Debug("already have operator, cancel add operator",
 				zap.Uint64("region-id", op.RegionID()),
 				zap.Reflect("old", old))
 			operatorWaitCounter.WithLabelValues(op.Desc(), "already-have").Inc()
-			return false
+			return RejectAlreadyHave
 		}
 		if op.Status() != operator.CREATED {
 			log.Error("trying to add operator with unexpected status",
@@ -410,12 +503,19 @@ func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) bool {
 				panic(op)
 			})
 			operatorWaitCounter.WithLabelValues(op.Desc(), "unexpected-status").Inc()
-			return false
+			return RejectUnexpectedStatus
 		}
 		if oc.wopStatus.ops[op.Desc()] >= oc.cluster.GetOpts().GetSchedulerMaxWaitingOperator() {
 			log.Debug("exceed max return false", zap.Uint64("waiting", oc.wopStatus.ops[op.Desc()]), zap.String("desc", op.Desc()), zap.Uint64("max", oc.cluster.GetOpts().GetSchedulerMaxWaitingOperator()))
 			operatorWaitCounter.WithLabelValues(op.Desc(), "exceed-max").Inc()
-			return false
+			return RejectExceedMaxWaiting
+		}
+		if oc.cluster.GetOpts().GetRegionMoveCooldown() > 0 && !isMoveCooldownExempted(op) && oc.IsRegionRecentlyMoved(op.RegionID()) {
+			log.Debug("region is moved recently, cancel add operator",
+				zap.Uint64("region-id", op.RegionID()),
+				zap.String("desc", op.Desc()))
+			operatorWaitCounter.WithLabelValues(op.Desc(), "recently-moved").Inc()
+			return RejectRecentlyMoved
 		}
 	}
 	expired := false
@@ -425,7 +525,10 @@ func (oc *OperatorController) checkAddOperator(ops ...*operator.Operator) bool {
 			operatorWaitCounter.WithLabelValues(op.Desc(), "expired").Inc()
 		}
 	}
-	return !expired
+	if expired {
+		return RejectExpired
+	}
+	return RejectNone
 }
 
 func isHigherPriorityOperator(new, old *operator.Operator) bool {
@@ -558,6 +661,7 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 		for _, counter := range op.FinishedCounters {
 			counter.Inc()
 		}
+		oc.recordMovedRegion(op)
 	case operator.REPLACED:
 		log.Info("replace old operator",
 			zap.Uint64("region-id", op.RegionID()),
@@ -590,6 +694,41 @@ func (oc *OperatorController) buryOperator(op *operator.Operator, extraFields ..
 	}
 
 	oc.opRecords.Put(op)
+	for _, listener := range oc.endListeners {
+		listener(op)
+	}
+}
+
+// AddEndListener adds a listener which is called when an operator ends. The
+// listener is called with the lock of the controller held, so it should not
+// call the controller and should return quickly.
+func (oc *OperatorController) AddEndListener(listener func(*operator.Operator)) {
+	oc.Lock()
+	defer oc.Unlock()
+	oc.endListeners = append(oc.endListeners, listener)
+}
+
+// isMoveCooldownExempted checks if the operator is allowed to move a region
+// which is moved recently, e.g. the operators created by the admin or to
+// repair the replicas.
+func isMoveCooldownExempted(op *operator.Operator) bool {
+	return op.Kind()&(operator.OpAdmin|operator.OpReplica|operator.OpMerge) != 0
+}
+
+// recordMovedRegion records the region moved by the finished operator, so that
+// the schedulers do not move it again until the cooldown is over.
+func (oc *OperatorController) recordMovedRegion(op *operator.Operator) {
+	cooldown := oc.cluster.GetOpts().GetRegionMoveCooldown()
+	if cooldown <= 0 || op.Kind()&(operator.OpLeader|operator.OpRegion) == 0 {
+		return
+	}
+	oc.recentlyMoved.PutWithTTL(op.RegionID(), time.Now(), cooldown)
+}
+
+// IsRegionRecentlyMoved checks if the region is moved by an operator within the
+// region move cooldown.
+func (oc *OperatorController) IsRegionRecentlyMoved(regionID uint64) bool {
+	return oc.recentlyMoved.Exists(regionID)
 }
 
 // GetOperatorStatus gets the operator and its status with the specify id.
@@ -911,6 +1050,7 @@ func (oc *OperatorController) ExceedStoreLimit(ops ...*operator.Operator) bool {
 }
 
 // exceedStoreLimitLocked returns true if the store exceeds the cost limit after adding the operator. Otherwise, returns false.
+// The forced operators bypass the store limit.
 func (oc *OperatorController) exceedStoreLimitLocked(ops ...*operator.Operator) bool {
 	opInfluence := NewTotalOpInfluence(ops, oc.cluster)
 	for storeID := range opInfluence.StoresInfluence {
@@ -920,13 +1060,28 @@ func (oc *OperatorController) exceedStoreLimitLocked(ops ...*operator.Operator)
 				continue
 			}
 			if oc.getOrCreateStoreLimit(storeID, v).Available() < stepCost {
-				return true
+				if !isForceOperators(ops) {
+					return true
+				}
+				log.Warn("the forced operator bypasses the store limit",
+					zap.Uint64("store-id", storeID),
+					zap.String("type", v.String()),
+					zap.Reflect("operators", ops))
 			}
 		}
 	}
 	return false
 }
 
+func isForceOperators(ops []*operator.Operator) bool {
+	for _, op := range ops {
+		if !op.IsForce() {
+			return false
+		}
+	}
+	return len(ops) > 0
+}
+
 // newStoreLimit is used to create the limit of a store.
 func (oc *OperatorController) newStoreLimit(storeID uint64, ratePerSec float64, limitType storelimit.Type) {
 	log.Info("create or update a store limit", zap.Uint64("store-id", storeID), zap.String("type", limitType.String()), zap.Float64("rate", ratePerSec))
@@ -17,6 +17,7 @@ import (
 	"container/heap"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/core/storelimit"
@@ -164,6 +166,81 @@ func (t *testOperatorControllerSuite) TestFastFailOperator(c *C) {
 	c.Assert(oc.GetOperator(region.GetID()), IsNil)
 }
 
+func (t *testOperatorControllerSuite) TestAddOperatorWithReason(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 1)
+	tc.AddLeaderRegion(1, 2, 1)
+
+	op := operator.NewOperator("test", "test", 2, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 1})
+	ok, reason := oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsFalse)
+	c.Assert(reason, Equals, RejectRegionNotFound)
+	c.Assert(op.Status(), Equals, operator.CANCELED)
+
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{Version: 10}, operator.OpRegion, operator.TransferLeader{ToStore: 1})
+	ok, reason = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsFalse)
+	c.Assert(reason, Equals, RejectEpochNotMatch)
+
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 1})
+	ok, reason = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsTrue)
+	c.Assert(reason, Equals, RejectNone)
+
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 1})
+	ok, reason = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsFalse)
+	c.Assert(reason, Equals, RejectAlreadyHave)
+}
+
+func (t *testOperatorControllerSuite) TestRegionMoveCooldown(c *C) {
+	opt := config.NewTestOptions()
+	cfg := opt.GetScheduleConfig().Clone()
+	cfg.RegionMoveCooldown = typeutil.NewDuration(time.Minute)
+	opt.SetScheduleConfig(cfg)
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	c.Assert(oc.IsRegionRecentlyMoved(1), IsFalse)
+
+	op := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpLeader, operator.TransferLeader{FromStore: 1, ToStore: 2})
+	ok, reason := oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsTrue)
+	c.Assert(reason, Equals, RejectNone)
+	ApplyOperator(tc, op)
+	oc.Dispatch(tc.GetRegion(1), "test")
+	c.Assert(op.Status(), Equals, operator.SUCCESS)
+	c.Assert(oc.IsRegionRecentlyMoved(1), IsTrue)
+
+	// The schedulers can not move the region again.
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpLeader, operator.TransferLeader{FromStore: 2, ToStore: 1})
+	ok, reason = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsFalse)
+	c.Assert(reason, Equals, RejectRecentlyMoved)
+
+	// The operators to repair the replicas are not limited.
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpReplica|operator.OpLeader, operator.TransferLeader{FromStore: 2, ToStore: 1})
+	ok, reason = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsTrue)
+	c.Assert(reason, Equals, RejectNone)
+	c.Assert(oc.RemoveOperator(op), IsTrue)
+
+	// Disable the cooldown.
+	cfg = opt.GetScheduleConfig().Clone()
+	cfg.RegionMoveCooldown = typeutil.NewDuration(0)
+	opt.SetScheduleConfig(cfg)
+	op = operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpLeader, operator.TransferLeader{FromStore: 2, ToStore: 1})
+	ok, _ = oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsTrue)
+}
+
 func (t *testOperatorControllerSuite) TestCheckAddUnexpectedStatus(c *C) {
 	c.Assert(failpoint.Disable("github.com/tikv/pd/server/schedule/unexpectedOperator"), IsNil)
 	opt := config.NewTestOptions()
@@ -182,36 +259,36 @@ func (t *testOperatorControllerSuite) TestCheckAddUnexpectedStatus(c *C) {
 	{
 		// finished op
 		op := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 2})
-		c.Assert(oc.checkAddOperator(op), IsTrue)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectNone)
 		op.Start()
-		c.Assert(oc.checkAddOperator(op), IsFalse) // started
+		c.Assert(oc.checkAddOperator(op), Equals, RejectUnexpectedStatus) // started
 		c.Assert(op.Check(region1), IsNil)
 		c.Assert(op.Status(), Equals, operator.SUCCESS)
-		c.Assert(oc.checkAddOperator(op), IsFalse) // success
+		c.Assert(oc.checkAddOperator(op), Equals, RejectUnexpectedStatus) // success
 	}
 	{
 		// finished op canceled
 		op := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 2})
-		c.Assert(oc.checkAddOperator(op), IsTrue)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectNone)
 		c.Assert(op.Cancel(), IsTrue)
-		c.Assert(oc.checkAddOperator(op), IsFalse)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectUnexpectedStatus)
 	}
 	{
 		// finished op replaced
 		op := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 2})
-		c.Assert(oc.checkAddOperator(op), IsTrue)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectNone)
 		c.Assert(op.Start(), IsTrue)
 		c.Assert(op.Replace(), IsTrue)
-		c.Assert(oc.checkAddOperator(op), IsFalse)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectUnexpectedStatus)
 	}
 	{
 		// finished op expired
 		op1 := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 2})
 		op2 := operator.NewOperator("test", "test", 2, &metapb.RegionEpoch{}, operator.OpRegion, operator.TransferLeader{ToStore: 1})
-		c.Assert(oc.checkAddOperator(op1, op2), IsTrue)
+		c.Assert(oc.checkAddOperator(op1, op2), Equals, RejectNone)
 		operator.SetOperatorStatusReachTime(op1, operator.CREATED, time.Now().Add(-operator.OperatorExpireTime))
 		operator.SetOperatorStatusReachTime(op2, operator.CREATED, time.Now().Add(-operator.OperatorExpireTime))
-		c.Assert(oc.checkAddOperator(op1, op2), IsFalse)
+		c.Assert(oc.checkAddOperator(op1, op2), Equals, RejectExpired)
 		c.Assert(op1.Status(), Equals, operator.EXPIRED)
 		c.Assert(op2.Status(), Equals, operator.EXPIRED)
 	}
@@ -220,11 +297,11 @@ func (t *testOperatorControllerSuite) TestCheckAddUnexpectedStatus(c *C) {
 	{
 		// unfinished op timeout
 		op := operator.NewOperator("test", "test", 1, &metapb.RegionEpoch{}, operator.OpRegion, steps...)
-		c.Assert(oc.checkAddOperator(op), IsTrue)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectNone)
 		op.Start()
 		operator.SetOperatorStatusReachTime(op, operator.STARTED, time.Now().Add(-operator.SlowOperatorWaitTime))
 		c.Assert(op.CheckTimeout(), IsTrue)
-		c.Assert(oc.checkAddOperator(op), IsFalse)
+		c.Assert(oc.checkAddOperator(op), Equals, RejectUnexpectedStatus)
 	}
 }
 
@@ -409,6 +486,37 @@ func (t *testOperatorControllerSuite) TestStoreLimit(c *C) {
 	c.Assert(oc.RemoveOperator(op), IsFalse)
 }
 
+func (t *testOperatorControllerSuite) TestForceOperator(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(t.ctx, tc, stream)
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	for i := uint64(1); i <= 10; i++ {
+		tc.AddLeaderRegion(i, 1)
+		tc.PutRegion(tc.GetRegion(i).Clone(core.SetApproximateSize(10)))
+	}
+
+	tc.SetStoreLimit(2, storelimit.AddPeer, 60)
+	for i := uint64(1); i <= 5; i++ {
+		op := operator.NewOperator("test", "test", i, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: 2, PeerID: i})
+		c.Assert(oc.AddOperator(op), IsTrue)
+	}
+	op := operator.NewOperator("test", "test", 6, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: 2, PeerID: 6})
+	ok, reason := oc.AddOperatorWithReason(op)
+	c.Assert(ok, IsFalse)
+	c.Assert(reason, Equals, RejectExceedStoreLimit)
+
+	// The forced operator bypasses the store limit, but still takes the cost.
+	op = operator.NewOperator("test", "test", 7, &metapb.RegionEpoch{}, operator.OpRegion|operator.OpAdmin, operator.AddPeer{ToStore: 2, PeerID: 7})
+	op.SetForce(true)
+	c.Assert(oc.AddOperator(op), IsTrue)
+	c.Assert(strings.HasSuffix(op.String(), ", force)"), IsTrue)
+	op = operator.NewOperator("test", "test", 8, &metapb.RegionEpoch{}, operator.OpRegion, operator.AddPeer{ToStore: 2, PeerID: 8})
+	c.Assert(oc.AddOperator(op), IsFalse)
+}
+
 // #1652
 func (t *testOperatorControllerSuite) TestDispatchOutdatedRegion(c *C) {
 	cluster := mockcluster.NewCluster(t.ctx, config.NewTestOptions())
@@ -571,6 +679,63 @@ func (t *testOperatorControllerSuite) TestDispatchUnfinishedStep(c *C) {
 	}
 }
 
+func (t *testOperatorControllerSuite) TestCancelUnavailableTarget(c *C) {
+	opt := config.NewTestOptions()
+	cluster := mockcluster.NewCluster(t.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(t.ctx, cluster.ID, cluster, false /* no need to run */)
+	controller := NewOperatorController(t.ctx, cluster, stream)
+	for i := uint64(1); i <= 3; i++ {
+		cluster.AddRegionStore(i, 1)
+	}
+	cluster.AddLeaderRegion(1, 1, 2)
+	region := cluster.GetRegion(1)
+	steps := []operator.OpStep{
+		operator.AddLearner{ToStore: 3, PeerID: 3},
+		operator.PromoteLearner{ToStore: 3, PeerID: 3},
+		operator.RemovePeer{FromStore: 2},
+	}
+	region2 := region.Clone(core.WithAddPeer(&metapb.Peer{Id: 3, StoreId: 3, Role: metapb.PeerRole_Learner}))
+
+	// The operator is kept if the target store is available.
+	op := operator.NewOperator("test", "test", 1, region.GetRegionEpoch(), operator.OpRegion, steps...)
+	c.Assert(op.UnfinishedTargetStores(region), DeepEquals, []uint64{3, 3})
+	c.Assert(op.UnfinishedTargetStores(region2), DeepEquals, []uint64{3})
+	c.Assert(controller.AddOperator(op), IsTrue)
+	controller.Dispatch(region, DispatchFromHeartBeat)
+	c.Assert(controller.GetOperator(1), Equals, op)
+
+	// The operator is canceled at once if the target store is disconnected, down
+	// or offline, even if the peer is added, and the region is checked again.
+	for _, setUnavailable := range []func(uint64){cluster.SetStoreDisconnect, cluster.SetStoreDown, cluster.SetStoreOffline} {
+		cluster.SetStoreUp(3)
+		cluster.ResetSuspectRegions()
+		controller = NewOperatorController(t.ctx, cluster, stream)
+		op = operator.NewOperator("test", "test", 1, region.GetRegionEpoch(), operator.OpRegion, steps...)
+		c.Assert(controller.AddOperator(op), IsTrue)
+		setUnavailable(3)
+		controller.Dispatch(region2, DispatchFromHeartBeat)
+		c.Assert(controller.GetOperator(1), IsNil)
+		c.Assert(op.Status(), Equals, operator.CANCELED)
+		c.Assert(cluster.CheckRegionUnderSuspect(1), IsTrue)
+	}
+
+	// The target store disconnects in the middle of the operator, long before
+	// it becomes down.
+	cluster.SetStoreUp(3)
+	cluster.ResetSuspectRegions()
+	controller = NewOperatorController(t.ctx, cluster, stream)
+	op = operator.NewOperator("test", "test", 1, region.GetRegionEpoch(), operator.OpRegion, steps...)
+	c.Assert(controller.AddOperator(op), IsTrue)
+	controller.Dispatch(region2, DispatchFromHeartBeat)
+	c.Assert(controller.GetOperator(1), Equals, op)
+	cluster.SetStoreDisconnect(3)
+	c.Assert(cluster.GetStore(3).DownTime() < opt.GetMaxStoreDownTime(), IsTrue)
+	controller.Dispatch(region2, DispatchFromHeartBeat)
+	c.Assert(controller.GetOperator(1), IsNil)
+	c.Assert(op.Status(), Equals, operator.CANCELED)
+	c.Assert(cluster.CheckRegionUnderSuspect(1), IsTrue)
+}
+
 func (t *testOperatorControllerSuite) TestStoreLimitWithMerge(c *C) {
 	cfg := config.NewTestOptions()
 	tc := mockcluster.NewCluster(t.ctx, cfg)
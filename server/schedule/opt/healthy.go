@@ -70,6 +70,12 @@ func IsRegionReplicated(cluster Cluster, region *core.RegionInfo) bool {
 	return len(region.GetLearners()) == 0 && len(region.GetPeers()) == cluster.GetOpts().GetMaxReplicas()
 }
 
+// NotPinnedRegion returns a function that checks if a region is not pinned to
+// be exempted from balance.
+func NotPinnedRegion(cluster Cluster) func(*core.RegionInfo) bool {
+	return func(region *core.RegionInfo) bool { return !cluster.IsRegionPinned(region) }
+}
+
 // ReplicatedRegion returns a function that checks if a region is fully replicated.
 func ReplicatedRegion(cluster Cluster) func(*core.RegionInfo) bool {
 	return func(region *core.RegionInfo) bool { return IsRegionReplicated(cluster, region) }
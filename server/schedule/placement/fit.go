@@ -85,6 +85,9 @@ type RuleFit struct {
 	// different Role from configuration (the Role can be migrated to target role
 	// by scheduling).
 	PeersWithDifferentRole []*metapb.Peer
+	// PeersExceedingLabelLimits is subset of `Peers`. It contains the Peers
+	// that should be moved to other stores to satisfy the label limits.
+	PeersExceedingLabelLimits []*metapb.Peer
 	// IsolationScore indicates at which level of labeling these Peers are
 	// isolated. A larger value is better.
 	IsolationScore float64
@@ -92,11 +95,28 @@ type RuleFit struct {
 
 // IsSatisfied returns if the rule is properly satisfied.
 func (f *RuleFit) IsSatisfied() bool {
-	return len(f.Peers) == f.Rule.Count && len(f.PeersWithDifferentRole) == 0
+	return len(f.Peers) >= f.Rule.Count && len(f.Peers) <= f.Rule.GetMaxCount() &&
+		len(f.PeersWithDifferentRole) == 0 && len(f.PeersExceedingLabelLimits) == 0
+}
+
+// matchedCount returns the count of the peers which are required by the rule.
+func (f *RuleFit) matchedCount() int {
+	if len(f.Peers) < f.Rule.Count {
+		return len(f.Peers)
+	}
+	return f.Rule.Count
 }
 
 func compareRuleFit(a, b *RuleFit) int {
 	switch {
+	case a.matchedCount() < b.matchedCount():
+		return -1
+	case a.matchedCount() > b.matchedCount():
+		return 1
+	case len(a.PeersExceedingLabelLimits) > len(b.PeersExceedingLabelLimits):
+		return -1
+	case len(a.PeersExceedingLabelLimits) < len(b.PeersExceedingLabelLimits):
+		return 1
 	case len(a.Peers) < len(b.Peers):
 		return -1
 	case len(a.Peers) > len(b.Peers):
@@ -183,11 +203,20 @@ func (w *fitWorker) fitRule(index int) bool {
 		}
 	}
 
-	count := w.rules[index].Count
+	// The rule accepts [Count, MaxCount] peers. Less peers may be better if
+	// the extra peers exceed the label limits.
+	count, minCount := w.rules[index].GetMaxCount(), w.rules[index].Count
 	if len(candidates) < count {
 		count = len(candidates)
 	}
-	return w.enumPeers(candidates, nil, index, count)
+	if len(candidates) < minCount {
+		minCount = len(candidates)
+	}
+	var better bool
+	for ; count >= minCount; count-- {
+		better = w.enumPeers(candidates, nil, index, count) || better
+	}
+	return better
 }
 
 // Recursively traverses all feasible peer combinations.
@@ -258,6 +287,15 @@ func newRuleFit(rule *Rule, peers []*fitPeer) *RuleFit {
 			rf.PeersWithDifferentRole = append(rf.PeersWithDifferentRole, p.Peer)
 		}
 	}
+	if len(rule.LabelLimits) > 0 {
+		stores := make([]*core.StoreInfo, 0, len(peers))
+		for _, p := range peers {
+			stores = append(stores, p.store)
+		}
+		for _, i := range exceedLabelLimits(stores, rule.LabelLimits) {
+			rf.PeersExceedingLabelLimits = append(rf.PeersExceedingLabelLimits, peers[i].Peer)
+		}
+	}
 	return rf
 }
 
@@ -155,6 +155,61 @@ func (s *testFitSuite) TestFitRegion(c *C) {
 	}
 }
 
+func (s *testFitSuite) TestFitRegionWithCountRange(c *C) {
+	stores := s.makeStores()
+
+	cases := []struct {
+		region      string
+		rule        string
+		maxCount    int
+		labelLimits []LabelLimit
+		fitPeers    string
+		orphanPeers string
+		exceeded    string
+		satisfied   bool
+	}{
+		// [Count, MaxCount] peers are accepted.
+		{"1111,1112,1113,1114", "3/voter//", 5, nil, "1111,1112,1113,1114", "", "", true},
+		{"1111,1112,1113,1114,1115,1121", "3/voter//", 5, nil, "1111,1112,1113,1114,1115", "1121", "", true},
+		{"1111,1112", "3/voter//", 5, nil, "1111,1112", "", "", false},
+		// at most 1 peer per host.
+		{"1111,1121,1131", "3/voter//", 0, []LabelLimit{{Key: "host", MaxCount: 1}}, "1111,1121,1131", "", "", true},
+		{"1111,1112,1121", "3/voter//", 0, []LabelLimit{{Key: "host", MaxCount: 1}}, "1111,1112,1121", "", "1112", false},
+		{"1111,1112,1121", "2/voter//", 3, []LabelLimit{{Key: "host", MaxCount: 1}}, "1111,1121", "1112", "", true},
+		{"1111,1112,1113,1121", "3/voter//", 0, []LabelLimit{{Key: "host", MaxCount: 2}}, "1111,1112,1121", "1113", "", true},
+		// stores without the label are not limited.
+		{"1111,1112", "2/voter//", 0, []LabelLimit{{Key: "disk", MaxCount: 1}}, "1111,1112", "", "", true},
+	}
+
+	for _, cc := range cases {
+		rule := s.makeRule(cc.rule)
+		rule.MaxCount, rule.LabelLimits = cc.maxCount, cc.labelLimits
+		rf := FitRegion(stores, s.makeRegion(cc.region), []*Rule{rule})
+		c.Assert(s.checkPeerMatch(rf.RuleFits[0].Peers, cc.fitPeers), IsTrue)
+		c.Assert(s.checkPeerMatch(rf.OrphanPeers, cc.orphanPeers), IsTrue)
+		c.Assert(s.checkPeerMatch(rf.RuleFits[0].PeersExceedingLabelLimits, cc.exceeded), IsTrue)
+		c.Assert(rf.RuleFits[0].IsSatisfied(), Equals, cc.satisfied)
+	}
+}
+
+func (s *testFitSuite) TestMatchLabelLimits(c *C) {
+	stores := s.makeStores()
+	getStores := func(ids ...uint64) []*core.StoreInfo {
+		var ss []*core.StoreInfo
+		for _, id := range ids {
+			ss = append(ss, stores.GetStore(id))
+		}
+		return ss
+	}
+	limits := []LabelLimit{{Key: "host", MaxCount: 1}, {Key: "zone", MaxCount: 2}}
+	c.Assert(MatchLabelLimits(getStores(1111, 2111), stores.GetStore(1121), limits), IsTrue)
+	c.Assert(MatchLabelLimits(getStores(1111, 2111), stores.GetStore(1112), limits), IsFalse)
+	c.Assert(MatchLabelLimits(getStores(1111, 1121), stores.GetStore(1131), limits), IsFalse)
+	// The store itself is not counted.
+	c.Assert(MatchLabelLimits(getStores(1111, 2121), stores.GetStore(1111), limits), IsTrue)
+	c.Assert(exceedLabelLimits(getStores(1111, 1121, 1112, 1131), limits), DeepEquals, []int{2, 3})
+}
+
 func (s *testFitSuite) TestIsolationScore(c *C) {
 	stores := s.makeStores()
 	testCases := []struct {
@@ -0,0 +1,83 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placement
+
+import (
+	"sort"
+
+	"github.com/tikv/pd/server/core"
+)
+
+// LabelLimit restricts the count of peers placed on the stores which have the
+// same value of a label. For example, `{"key": "host", "max_count": 1}` means
+// at most 1 peer per host. The stores without the label are not limited.
+type LabelLimit struct {
+	Key      string `json:"key"`
+	MaxCount int    `json:"max_count"`
+}
+
+// MatchLabelLimits checks if a store can be placed along with the stores
+// without exceeding the label limits.
+func MatchLabelLimits(stores []*core.StoreInfo, store *core.StoreInfo, limits []LabelLimit) bool {
+	if store == nil {
+		return false
+	}
+	for _, limit := range limits {
+		value := store.GetLabelValue(limit.Key)
+		if value == "" {
+			continue
+		}
+		count := 1
+		for _, s := range stores {
+			if s.GetID() != store.GetID() && s.GetLabelValue(limit.Key) == value {
+				count++
+			}
+		}
+		if count > limit.MaxCount {
+			return false
+		}
+	}
+	return true
+}
+
+// exceedLabelLimits returns the indexes of the stores which exceed the label
+// limits. For each label value, the stores after the first `MaxCount` ones are
+// regarded as exceeded.
+func exceedLabelLimits(stores []*core.StoreInfo, limits []LabelLimit) []int {
+	var exceeded []int
+	for _, limit := range limits {
+		counts := make(map[string]int)
+		for i, s := range stores {
+			value := s.GetLabelValue(limit.Key)
+			if value == "" {
+				continue
+			}
+			counts[value]++
+			if counts[value] > limit.MaxCount && !containsIndex(exceeded, i) {
+				exceeded = append(exceeded, i)
+			}
+		}
+	}
+	sort.Ints(exceeded)
+	return exceeded
+}
+
+func containsIndex(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
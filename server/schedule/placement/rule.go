@@ -64,6 +64,8 @@ type Rule struct {
 	LabelConstraints []LabelConstraint `json:"label_constraints,omitempty"` // used to select stores to place peers
 	LocationLabels   []string          `json:"location_labels,omitempty"`   // used to make peers isolated physically
 	IsolationLevel   string            `json:"isolation_level,omitempty"`   // used to isolate replicas explicitly and forcibly
+	MaxCount         int               `json:"max_count,omitempty"`         // max count of the peers, the rule accepts [Count, MaxCount] peers if it is set
+	LabelLimits      []LabelLimit      `json:"label_limits,omitempty"`      // used to limit the count of peers with the same label value
 
 	group *RuleGroup // only set at runtime, no need to {,un}marshal or persist.
 }
@@ -83,6 +85,14 @@ func (r *Rule) StoreKey() string {
 	return hex.EncodeToString([]byte(r.GroupID)) + "-" + hex.EncodeToString([]byte(r.ID))
 }
 
+// GetMaxCount returns the max count of the peers which can be matched by the rule.
+func (r *Rule) GetMaxCount() int {
+	if r.MaxCount > r.Count {
+		return r.MaxCount
+	}
+	return r.Count
+}
+
 func (r *Rule) groupIndex() int {
 	if r.group != nil {
 		return r.group.Index
@@ -201,6 +201,20 @@ func (m *RuleManager) adjustRule(r *Rule, groupID string) (err error) {
 			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid op %s", c.Op))
 		}
 	}
+	if r.MaxCount != 0 && r.MaxCount < r.Count {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("max count %d is less than count %d", r.MaxCount, r.Count))
+	}
+	if r.Role == Leader && r.MaxCount > 1 {
+		return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("define multiple leaders by max count %d", r.MaxCount))
+	}
+	for _, l := range r.LabelLimits {
+		if l.Key == "" {
+			return errs.ErrRuleContent.FastGenByArgs("label limit key should not be empty")
+		}
+		if l.MaxCount <= 0 {
+			return errs.ErrRuleContent.FastGenByArgs(fmt.Sprintf("invalid max count %d of label limit %s", l.MaxCount, l.Key))
+		}
+	}
 
 	if m.storeSetInformer != nil {
 		stores := m.storeSetInformer.GetStores()
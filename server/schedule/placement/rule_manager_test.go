@@ -62,10 +62,15 @@ func (s *testManagerSuite) TestAdjustRule(c *C) {
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 0},
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: -1},
 		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, LabelConstraints: []LabelConstraint{{Op: "foo"}}},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, MaxCount: 2},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "leader", Count: 1, MaxCount: 2},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, LabelLimits: []LabelLimit{{MaxCount: 1}}},
+		{GroupID: "group", ID: "id", StartKeyHex: "123abc", EndKeyHex: "123abf", Role: "voter", Count: 3, LabelLimits: []LabelLimit{{Key: "host"}}},
 	}
 	c.Assert(s.manager.adjustRule(&rules[0], "group"), IsNil)
 	c.Assert(rules[0].StartKey, DeepEquals, []byte{0x12, 0x3a, 0xbc})
 	c.Assert(rules[0].EndKey, DeepEquals, []byte{0x12, 0x3a, 0xbf})
+	c.Assert(s.manager.adjustRule(&Rule{GroupID: "group", ID: "id", Role: "voter", Count: 3, MaxCount: 5, LabelLimits: []LabelLimit{{Key: "host", MaxCount: 1}}}, "group"), IsNil)
 	c.Assert(s.manager.adjustRule(&rules[1], ""), NotNil)
 	for i := 2; i < len(rules); i++ {
 		c.Assert(s.manager.adjustRule(&rules[i], "group"), NotNil)
@@ -0,0 +1,193 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"go.uber.org/zap"
+)
+
+// RangeMergeDesc is the description of the operators created by RangeMerger.
+const RangeMergeDesc = "merge-range"
+
+const (
+	defaultRangeMergeMaxSize    = 20     // in MB
+	defaultRangeMergeMaxKeys    = 200000 // in keys
+	defaultRangeMergeMaxRunning = 64
+)
+
+// RangeMergeOptions is the options of merging the regions in a key range.
+type RangeMergeOptions struct {
+	// MaxRegionSize and MaxRegionKeys are the thresholds of the small regions,
+	// only the small regions are merged.
+	MaxRegionSize int64
+	MaxRegionKeys int64
+	// MaxRunning is the max number of the running merges created by the
+	// range merger, which limits the rate of merging instead of the merge
+	// schedule limit.
+	MaxRunning int
+}
+
+func (o *RangeMergeOptions) adjust() {
+	if o.MaxRegionSize <= 0 {
+		o.MaxRegionSize = defaultRangeMergeMaxSize
+	}
+	if o.MaxRegionKeys <= 0 {
+		o.MaxRegionKeys = defaultRangeMergeMaxKeys
+	}
+	if o.MaxRunning <= 0 {
+		o.MaxRunning = defaultRangeMergeMaxRunning
+	}
+}
+
+// RangeMergeProgress is the progress of merging the regions in a key range.
+type RangeMergeProgress struct {
+	// Regions is the number of the regions in the range.
+	Regions int `json:"regions"`
+	// SmallRegions is the number of the small regions in the range, the
+	// merging is done when there are no more than one small region.
+	SmallRegions int `json:"small-regions"`
+	// RunningMerges includes the new merges.
+	RunningMerges int `json:"running-merges"`
+	NewMerges     int `json:"new-merges"`
+}
+
+// RangeMerger merges the small regions in a key range aggressively, e.g. the
+// empty regions left by dropping tables. It bypasses the merge schedule limit
+// and the split merge interval which pace the merge checker, and it is limited
+// by the number of its running merges instead.
+type RangeMerger struct {
+	// mu serializes the merging, so that the running merges are not exceeded.
+	mu           sync.Mutex
+	cluster      opt.Cluster
+	opController *OperatorController
+	mergeChecker *checker.MergeChecker
+}
+
+// NewRangeMerger creates a RangeMerger.
+func NewRangeMerger(cluster opt.Cluster, opController *OperatorController, mergeChecker *checker.MergeChecker) *RangeMerger {
+	return &RangeMerger{
+		cluster:      cluster,
+		opController: opController,
+		mergeChecker: mergeChecker,
+	}
+}
+
+// MergeRange creates the merge operators of the adjacent small regions in
+// [startKey, endKey), and returns the progress. It should be called
+// repeatedly until the merging is done, since a region can be merged only
+// once in a call.
+func (m *RangeMerger) MergeRange(startKey, endKey []byte, opts RangeMergeOptions) *RangeMergeProgress {
+	opts.adjust()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	progress := &RangeMergeProgress{RunningMerges: m.runningMerges()}
+	regions := m.cluster.ScanRegions(startKey, endKey, -1)
+	// The last region may exceed the range.
+	if n := len(regions); n > 0 && !inRange(regions[n-1], endKey) {
+		regions = regions[:n-1]
+	}
+	progress.Regions = len(regions)
+	var source *core.RegionInfo
+	for _, region := range regions {
+		if !m.isSmall(region, opts) {
+			source = nil
+			continue
+		}
+		progress.SmallRegions++
+		if !m.isMergeable(region) {
+			source = nil
+			continue
+		}
+		if source == nil || progress.RunningMerges >= opts.MaxRunning {
+			source = region
+			continue
+		}
+		if m.merge(source, region) {
+			progress.NewMerges++
+			progress.RunningMerges++
+			source = nil
+		} else {
+			source = region
+		}
+	}
+	return progress
+}
+
+// inRange returns true if the region ends before the end key.
+func inRange(region *core.RegionInfo, endKey []byte) bool {
+	if len(endKey) == 0 {
+		return true
+	}
+	return len(region.GetEndKey()) > 0 && bytes.Compare(region.GetEndKey(), endKey) <= 0
+}
+
+func (m *RangeMerger) isSmall(region *core.RegionInfo, opts RangeMergeOptions) bool {
+	// The size is unknown before the first heartbeat.
+	return region.GetApproximateSize() > 0 &&
+		region.GetApproximateSize() <= opts.MaxRegionSize &&
+		region.GetApproximateKeys() <= opts.MaxRegionKeys
+}
+
+func (m *RangeMerger) isMergeable(region *core.RegionInfo) bool {
+	return m.opController.GetOperator(region.GetID()) == nil &&
+		!m.mergeChecker.IsMergeBlacklisted(region) &&
+		opt.IsRegionHealthy(m.cluster, region) &&
+		opt.IsRegionReplicated(m.cluster, region)
+}
+
+func (m *RangeMerger) merge(source, target *core.RegionInfo) bool {
+	if !checker.AllowMerge(m.cluster, source, target) {
+		return false
+	}
+	ops, err := operator.CreateMergeRegionOperator(RangeMergeDesc, m.cluster, source, target, operator.OpAdmin)
+	if err != nil {
+		log.Debug("fail to create merge region operator", errs.ZapError(err))
+		return false
+	}
+	for _, op := range ops {
+		op.SetSource(operator.SourceHTTP)
+		op.SetReason(fmt.Sprintf("merge the small regions in range, region %d into region %d", source.GetID(), target.GetID()))
+	}
+	if ok, reason := m.opController.AddOperatorWithReason(ops...); !ok {
+		log.Debug("merge region operator is rejected",
+			zap.Uint64("source", source.GetID()),
+			zap.Uint64("target", target.GetID()),
+			zap.String("reason", string(reason)))
+		return false
+	}
+	return true
+}
+
+// runningMerges returns the number of the running merges created by the range
+// merger, a merge consists of the operators of the source and the target.
+func (m *RangeMerger) runningMerges() int {
+	count := 0
+	for _, op := range m.opController.GetOperators() {
+		if op.Desc() == RangeMergeDesc {
+			count++
+		}
+	}
+	return count / 2
+}
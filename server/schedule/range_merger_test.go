@@ -0,0 +1,109 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/schedule/checker"
+	"github.com/tikv/pd/server/schedule/hbstream"
+)
+
+var _ = Suite(&testRangeMergerSuite{})
+
+type testRangeMergerSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testRangeMergerSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testRangeMergerSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+func (s *testRangeMergerSuite) newRangeMerger() (*RangeMerger, *checker.MergeChecker) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddLeaderStore(i, 10)
+	}
+	// The regions [a, b), [b, c), ..., [g, ""), and [c, d) is large.
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", ""}
+	for i := 0; i < len(keys)-1; i++ {
+		size := int64(1)
+		if keys[i] == "c" {
+			size = 100
+		}
+		id := uint64(i + 1)
+		peers := []uint64{id * 10, 1}
+		tc.PutRegion(newRegionInfo(id, keys[i], keys[i+1], size, 10, peers, peers, []uint64{id*10 + 1, 2}, []uint64{id*10 + 2, 3}))
+	}
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, tc.ID, tc, false /* no need to run */)
+	oc := NewOperatorController(s.ctx, tc, stream)
+	mc := checker.NewMergeChecker(s.ctx, tc)
+	return NewRangeMerger(tc, oc, mc), mc
+}
+
+func (s *testRangeMergerSuite) TestMergeRange(c *C) {
+	m, _ := s.newRangeMerger()
+	progress := m.MergeRange([]byte("a"), []byte("g"), RangeMergeOptions{})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 6, SmallRegions: 5, RunningMerges: 2, NewMerges: 2})
+	c.Assert(m.opController.GetOperator(1).Desc(), Equals, RangeMergeDesc)
+	c.Assert(m.opController.GetOperator(2), NotNil)
+	c.Assert(m.opController.GetOperator(3), IsNil)
+	c.Assert(m.opController.GetOperator(4), NotNil)
+	c.Assert(m.opController.GetOperator(5), NotNil)
+	c.Assert(m.opController.GetOperator(6), IsNil)
+
+	// The merging regions are not merged again.
+	progress = m.MergeRange([]byte("a"), []byte("g"), RangeMergeOptions{})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 6, SmallRegions: 5, RunningMerges: 2})
+
+	// The region exceeding the range is not merged.
+	progress = m.MergeRange([]byte("f"), nil, RangeMergeOptions{})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 2, SmallRegions: 2, RunningMerges: 3, NewMerges: 1})
+	c.Assert(m.opController.GetOperator(6), NotNil)
+	c.Assert(m.opController.GetOperator(7), NotNil)
+}
+
+func (s *testRangeMergerSuite) TestMergeRangeLimit(c *C) {
+	m, _ := s.newRangeMerger()
+	progress := m.MergeRange([]byte("a"), []byte("g"), RangeMergeOptions{MaxRunning: 1})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 6, SmallRegions: 5, RunningMerges: 1, NewMerges: 1})
+	c.Assert(m.opController.GetOperator(4), IsNil)
+
+	// The large region is merged with the larger threshold.
+	progress = m.MergeRange([]byte("c"), []byte("e"), RangeMergeOptions{MaxRegionSize: 100, MaxRunning: 2})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 2, SmallRegions: 2, RunningMerges: 2, NewMerges: 1})
+	c.Assert(m.opController.GetOperator(3), NotNil)
+}
+
+func (s *testRangeMergerSuite) TestMergeRangeBlacklist(c *C) {
+	m, mc := s.newRangeMerger()
+	mc.AddMergeBlacklist([]byte("a"), time.Hour)
+	progress := m.MergeRange([]byte("a"), []byte("c"), RangeMergeOptions{})
+	c.Assert(*progress, DeepEquals, RangeMergeProgress{Regions: 2, SmallRegions: 2})
+	for _, id := range []uint64{1, 2} {
+		c.Assert(m.opController.GetOperator(id), IsNil, Commentf(fmt.Sprint(id)))
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/cache"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core"
@@ -153,8 +155,85 @@ const maxSleepDuration = 1 * time.Minute
 const initialSleepDuration = 100 * time.Millisecond
 const maxRetryLimit = 30
 
+// groupFunc returns the group which the region is scattered in.
+type groupFunc func(region *core.RegionInfo) string
+
+func sameGroup(group string) groupFunc {
+	return func(*core.RegionInfo) string { return group }
+}
+
+// partitionGroup scatters the regions of each table partition in its own group,
+// so that the regions of a single partition are evenly distributed too. The
+// partition is recognized by the table ID of the region's start key, and the
+// regions which do not belong to any table are scattered in the given group.
+func partitionGroup(group string) groupFunc {
+	return func(region *core.RegionInfo) string {
+		return getPartitionGroup(group, codec.Key(region.GetStartKey()).TableID())
+	}
+}
+
+func getPartitionGroup(group string, tableID int64) string {
+	if tableID == 0 {
+		return group
+	}
+	if group == "" {
+		return fmt.Sprintf("partition-%d", tableID)
+	}
+	return fmt.Sprintf("%s-partition-%d", group, tableID)
+}
+
+// PartitionDistribution is the distribution of the peers and leaders of a
+// table partition after scattering.
+type PartitionDistribution struct {
+	TableID int64             `json:"table_id"`
+	Peers   map[uint64]uint64 `json:"peers"`
+	Leaders map[uint64]uint64 `json:"leaders"`
+}
+
+// GetPartitionDistributions returns the distributions of the partitions which
+// the regions belong to. Only the stores of the ordinary engine are counted.
+func (r *RegionScatterer) GetPartitionDistributions(regions []*core.RegionInfo, group string) []*PartitionDistribution {
+	var res []*PartitionDistribution
+	visited := make(map[int64]struct{})
+	for _, region := range regions {
+		tableID := codec.Key(region.GetStartKey()).TableID()
+		if _, ok := visited[tableID]; ok || tableID == 0 {
+			continue
+		}
+		visited[tableID] = struct{}{}
+		partition := getPartitionGroup(group, tableID)
+		peers, _ := r.ordinaryEngine.selectedPeer.GetGroupDistribution(partition)
+		leaders, _ := r.ordinaryEngine.selectedLeader.GetGroupDistribution(partition)
+		res = append(res, &PartitionDistribution{
+			TableID: tableID,
+			Peers:   copyDistribution(peers),
+			Leaders: copyDistribution(leaders),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].TableID < res[j].TableID })
+	return res
+}
+
+func copyDistribution(distribution map[uint64]uint64) map[uint64]uint64 {
+	res := make(map[uint64]uint64, len(distribution))
+	for storeID, count := range distribution {
+		res[storeID] = count
+	}
+	return res
+}
+
 // ScatterRegionsByRange directly scatter regions by ScatterRegions
 func (r *RegionScatterer) ScatterRegionsByRange(startKey, endKey []byte, group string, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
+	return r.scatterRegionsByRange(startKey, endKey, sameGroup(group), retryLimit)
+}
+
+// ScatterPartitionedRegionsByRange scatters the regions in the range, the regions
+// of each table partition are scattered independently.
+func (r *RegionScatterer) ScatterPartitionedRegionsByRange(startKey, endKey []byte, group string, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
+	return r.scatterRegionsByRange(startKey, endKey, partitionGroup(group), retryLimit)
+}
+
+func (r *RegionScatterer) scatterRegionsByRange(startKey, endKey []byte, groupOf groupFunc, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
 	regions := r.cluster.ScanRegions(startKey, endKey, -1)
 	if len(regions) < 1 {
 		scatterCounter.WithLabelValues("skip", "empty-region").Inc()
@@ -166,7 +245,7 @@ func (r *RegionScatterer) ScatterRegionsByRange(startKey, endKey []byte, group s
 		regionMap[region.GetID()] = region
 	}
 	// If there existed any region failed to relocated after retry, add it into unProcessedRegions
-	ops, err := r.ScatterRegions(regionMap, failures, group, retryLimit)
+	ops, err := r.scatterRegions(regionMap, failures, groupOf, retryLimit)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -175,6 +254,16 @@ func (r *RegionScatterer) ScatterRegionsByRange(startKey, endKey []byte, group s
 
 // ScatterRegionsByID directly scatter regions by ScatterRegions
 func (r *RegionScatterer) ScatterRegionsByID(regionsID []uint64, group string, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
+	return r.scatterRegionsByID(regionsID, sameGroup(group), retryLimit)
+}
+
+// ScatterPartitionedRegionsByID scatters the regions by ID, the regions of each
+// table partition are scattered independently.
+func (r *RegionScatterer) ScatterPartitionedRegionsByID(regionsID []uint64, group string, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
+	return r.scatterRegionsByID(regionsID, partitionGroup(group), retryLimit)
+}
+
+func (r *RegionScatterer) scatterRegionsByID(regionsID []uint64, groupOf groupFunc, retryLimit int) ([]*operator.Operator, map[uint64]error, error) {
 	if len(regionsID) < 1 {
 		scatterCounter.WithLabelValues("skip", "empty-region").Inc()
 		return nil, nil, errors.New("empty region")
@@ -196,7 +285,7 @@ func (r *RegionScatterer) ScatterRegionsByID(regionsID []uint64, group string, r
 		regionMap[region.GetID()] = region
 	}
 	// If there existed any region failed to relocated after retry, add it into unProcessedRegions
-	ops, err := r.ScatterRegions(regionMap, failures, group, retryLimit)
+	ops, err := r.scatterRegions(regionMap, failures, groupOf, retryLimit)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -210,6 +299,10 @@ func (r *RegionScatterer) ScatterRegionsByID(regionsID []uint64, group string, r
 // Failures indicates the regions which are failed to be relocated, the key of the failures indicates the regionID
 // and the value of the failures indicates the failure error.
 func (r *RegionScatterer) ScatterRegions(regions map[uint64]*core.RegionInfo, failures map[uint64]error, group string, retryLimit int) ([]*operator.Operator, error) {
+	return r.scatterRegions(regions, failures, sameGroup(group), retryLimit)
+}
+
+func (r *RegionScatterer) scatterRegions(regions map[uint64]*core.RegionInfo, failures map[uint64]error, groupOf groupFunc, retryLimit int) ([]*operator.Operator, error) {
 	if len(regions) < 1 {
 		scatterCounter.WithLabelValues("skip", "empty-region").Inc()
 		return nil, errors.New("empty region")
@@ -220,7 +313,7 @@ func (r *RegionScatterer) ScatterRegions(regions map[uint64]*core.RegionInfo, fa
 	ops := make([]*operator.Operator, 0, len(regions))
 	for currentRetry := 0; currentRetry <= retryLimit; currentRetry++ {
 		for _, region := range regions {
-			op, err := r.Scatter(region, group)
+			op, err := r.Scatter(region, groupOf(region))
 			failpoint.Inject("scatterFail", func() {
 				if region.GetID() == 1 {
 					err = errors.New("mock error")
@@ -292,8 +385,22 @@ func (r *RegionScatterer) scatterRegion(region *core.RegionInfo, group string) *
 	targetPeers := make(map[uint64]*metapb.Peer)
 	selectedStores := make(map[uint64]struct{})
 	scatterWithSameEngine := func(peers map[uint64]*metapb.Peer, context engineContext) {
+		unprocessed := make(map[uint64]struct{}, len(peers))
 		for _, peer := range peers {
-			candidates := r.selectCandidates(region, peer.GetStoreId(), selectedStores, context)
+			unprocessed[peer.GetStoreId()] = struct{}{}
+		}
+		for _, peer := range peers {
+			delete(unprocessed, peer.GetStoreId())
+			// The peer can not be moved to the stores of the other peers which
+			// are not scattered yet, otherwise they are overwritten.
+			excluded := make(map[uint64]struct{}, len(selectedStores)+len(unprocessed))
+			for storeID := range selectedStores {
+				excluded[storeID] = struct{}{}
+			}
+			for storeID := range unprocessed {
+				excluded[storeID] = struct{}{}
+			}
+			candidates := r.selectCandidates(region, peer.GetStoreId(), excluded, context)
 			newPeer := r.selectStore(group, peer, peer.GetStoreId(), candidates, context)
 			targetPeers[newPeer.GetStoreId()] = newPeer
 			selectedStores[newPeer.GetStoreId()] = struct{}{}
@@ -9,6 +9,8 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/pkg/codec"
 	"github.com/tikv/pd/pkg/mock/mockcluster"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
@@ -246,6 +248,37 @@ func (s *testScatterRegionSuite) TestStoreLimit(c *C) {
 	}
 }
 
+func (s *testScatterRegionSuite) TestScatterNotOverwritePeers(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+
+	// Add stores 1~3.
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+
+	for i := uint64(1); i <= 20; i++ {
+		region := tc.AddLeaderRegion(i, 1, 2, 3)
+		scatterer := NewRegionScatterer(ctx, tc)
+		// Store 1 has been selected the most times in the group, so the peer on
+		// it prefers the other stores of the region, which are not scattered yet.
+		scatterer.Put(map[uint64]*metapb.Peer{1: region.GetStorePeer(1)}, 1, "group")
+		op, err := scatterer.Scatter(region, "group")
+		c.Assert(err, IsNil)
+		if op != nil {
+			ApplyOperator(tc, op)
+		}
+		region = tc.GetRegion(i)
+		c.Assert(region.GetPeers(), HasLen, 3)
+		for storeID := uint64(1); storeID <= 3; storeID++ {
+			c.Assert(region.GetStorePeer(storeID), NotNil)
+		}
+	}
+}
+
 func (s *testScatterRegionSuite) TestScatterCheck(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -421,6 +454,103 @@ func (s *testScatterRegionSuite) TestScattersGroup(c *C) {
 	}
 }
 
+func (s *testScatterRegionSuite) TestScatterPartitionedRegions(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	// Add 5 stores.
+	for i := uint64(1); i <= 5; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	// Add 2 partitions with 50 regions each, all the regions are located on the same stores.
+	rowKey := func(tableID, rowID int64) string {
+		return string(codec.EncodeBytes(codec.GenerateRowKey(tableID, rowID)))
+	}
+	regionID := uint64(1)
+	for _, tableID := range []int64{100, 101} {
+		for i := int64(0); i < 50; i++ {
+			tc.AddLeaderRegionWithRange(regionID, rowKey(tableID, i), rowKey(tableID, i+1), 1, 2, 3)
+			regionID++
+		}
+	}
+
+	scatterer := NewRegionScatterer(ctx, tc)
+	ops, failures, err := scatterer.ScatterPartitionedRegionsByRange(nil, nil, "group", 3)
+	c.Assert(err, IsNil)
+	c.Assert(failures, HasLen, 0)
+	c.Assert(ops, Not(HasLen), 0)
+
+	distributions := scatterer.GetPartitionDistributions(tc.ScanRegions(nil, nil, -1), "group")
+	c.Assert(distributions, HasLen, 2)
+	for i, tableID := range []int64{100, 101} {
+		distribution := distributions[i]
+		c.Assert(distribution.TableID, Equals, tableID)
+		// Each partition is scattered to all the stores independently.
+		var totalPeers, totalLeaders uint64
+		max, min := uint64(0), uint64(math.MaxUint64)
+		for storeID := uint64(1); storeID <= 5; storeID++ {
+			count := distribution.Leaders[storeID]
+			if count > max {
+				max = count
+			}
+			if count < min {
+				min = count
+			}
+			totalLeaders += count
+			totalPeers += distribution.Peers[storeID]
+			c.Assert(distribution.Peers[storeID], Greater, uint64(0))
+		}
+		c.Assert(totalLeaders, Equals, uint64(50))
+		c.Assert(totalPeers, Equals, uint64(150))
+		c.Assert(max-min, LessEqual, uint64(3))
+	}
+	_, ok := scatterer.ordinaryEngine.selectedLeader.GetGroupDistribution("group")
+	c.Assert(ok, IsFalse)
+}
+
+func (s *testScatterRegionSuite) TestScatterPartitionedNotOverwritePeers(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+
+	// Add stores 1~3.
+	for i := uint64(1); i <= 3; i++ {
+		tc.AddRegionStore(i, 0)
+	}
+	rowKey := func(rowID int64) string {
+		return string(codec.EncodeBytes(codec.GenerateRowKey(100, rowID)))
+	}
+	regionIDs := make([]uint64, 0, 20)
+	for i := uint64(1); i <= 20; i++ {
+		tc.AddLeaderRegionWithRange(i, rowKey(int64(i)), rowKey(int64(i+1)), 1, 2, 3)
+		regionIDs = append(regionIDs, i)
+	}
+
+	scatterer := NewRegionScatterer(ctx, tc)
+	// Store 1 has been selected the most times in the group of the partition,
+	// so the peers on it prefer the other stores of the regions, which are not
+	// scattered yet.
+	for i := 0; i < 20; i++ {
+		scatterer.Put(map[uint64]*metapb.Peer{1: tc.GetRegion(1).GetStorePeer(1)}, 1, getPartitionGroup("group", 100))
+	}
+	ops, failures, err := scatterer.ScatterPartitionedRegionsByID(regionIDs, "group", 3)
+	c.Assert(err, IsNil)
+	c.Assert(failures, HasLen, 0)
+	for _, op := range ops {
+		ApplyOperator(tc, op)
+	}
+	for _, regionID := range regionIDs {
+		region := tc.GetRegion(regionID)
+		c.Assert(region.GetPeers(), HasLen, 3)
+		for storeID := uint64(1); storeID <= 3; storeID++ {
+			c.Assert(region.GetStorePeer(storeID), NotNil)
+		}
+	}
+}
+
 func (s *testScatterRegionSuite) TestSelectedStoreGC(c *C) {
 	// use a shorter gcTTL and gcInterval during the test
 	gcInterval = time.Second
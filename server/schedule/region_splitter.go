@@ -185,6 +185,7 @@ func (h *splitRegionsHandler) SplitRegionByKeys(region *core.RegionInfo, splitKe
 	if err != nil {
 		return err
 	}
+	op.SetReason("split the region by the specified keys")
 
 	if ok := h.oc.AddOperator(op); !ok {
 		log.Warn("add region split operator failed", zap.Uint64("region-id", region.GetID()))
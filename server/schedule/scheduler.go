@@ -16,6 +16,7 @@ package schedule
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -142,3 +143,13 @@ func FindSchedulerTypeByName(name string) string {
 	}
 	return typ
 }
+
+// RegisteredSchedulerTypes returns the types of all the registered schedulers.
+func RegisteredSchedulerTypes() []string {
+	types := make([]string, 0, len(schedulerMap))
+	for typ := range schedulerMap {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
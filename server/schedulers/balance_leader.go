@@ -14,17 +14,25 @@
 package schedulers
 
 import (
+	"fmt"
+	"math"
+	"net/http"
 	"sort"
 	"strconv"
+	"sync"
 
+	"github.com/gorilla/mux"
 	"github.com/pingcap/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tikv/pd/pkg/apiutil"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/filter"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
+	"github.com/unrolled/render"
 	"go.uber.org/zap"
 )
 
@@ -35,6 +43,19 @@ const (
 	BalanceLeaderType = "balance-leader"
 	// balanceLeaderRetryLimit is the limit to retry schedule for selected source store and target store.
 	balanceLeaderRetryLimit = 10
+
+	// balanceLeaderCountMode balances the leader scores decided by the leader
+	// schedule policy, it is the default mode.
+	balanceLeaderCountMode = "count"
+	// balanceLeaderCPUMode weighs the leader scores by the CPU usages reported
+	// by the stores, so the leaders are moved off the busy stores even if the
+	// leader counts are equal.
+	balanceLeaderCPUMode = "cpu"
+	// minLeaderLoadFactor and maxLeaderLoadFactor bound the load factors in
+	// the cpu mode, which prevents a store from being drained or flooded by
+	// the transient CPU spikes.
+	minLeaderLoadFactor = 0.5
+	maxLeaderLoadFactor = 2.0
 )
 
 func init() {
@@ -55,7 +76,7 @@ func init() {
 	})
 
 	schedule.RegisterScheduler(BalanceLeaderType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
-		conf := &balanceLeaderSchedulerConfig{}
+		conf := &balanceLeaderSchedulerConfig{storage: storage}
 		if err := decoder(conf); err != nil {
 			return nil, err
 		}
@@ -64,8 +85,75 @@ func init() {
 }
 
 type balanceLeaderSchedulerConfig struct {
-	Name   string          `json:"name"`
-	Ranges []core.KeyRange `json:"ranges"`
+	mu      sync.RWMutex
+	storage *core.Storage
+	Name    string          `json:"name"`
+	Ranges  []core.KeyRange `json:"ranges"`
+	// Mode decides how the leader scores of the stores are weighed, it is the
+	// count mode if empty.
+	Mode string `json:"mode,omitempty"`
+}
+
+func (conf *balanceLeaderSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *balanceLeaderSchedulerConfig) getMode() string {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	if conf.Mode == "" {
+		return balanceLeaderCountMode
+	}
+	return conf.Mode
+}
+
+func (conf *balanceLeaderSchedulerConfig) persist() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(conf.Name, data)
+}
+
+func (conf *balanceLeaderSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *balanceLeaderSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	rd.JSON(w, http.StatusOK, conf)
+}
+
+func (conf *balanceLeaderSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	var input struct {
+		Mode string `json:"mode"`
+	}
+	if err := apiutil.ReadJSONRespondError(rd, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.Mode != balanceLeaderCountMode && input.Mode != balanceLeaderCPUMode {
+		rd.JSON(w, http.StatusBadRequest, errs.ErrSchedulerConfig.FastGenByArgs("mode").Error())
+		return
+	}
+
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+	old := conf.Mode
+	conf.Mode = input.Mode
+	if err := conf.persist(); err != nil {
+		conf.Mode = old // revert
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.JSON(w, http.StatusOK, nil)
 }
 
 type balanceLeaderScheduler struct {
@@ -93,6 +181,7 @@ func newBalanceLeaderScheduler(opController *schedule.OperatorController, conf *
 	s.filters = []filter.Filter{
 		&filter.StoreStateFilter{ActionScope: s.GetName(), TransferLeader: true},
 		filter.NewSpecialUseFilter(s.GetName()),
+		filter.NewLeaderWeightFilter(s.GetName()),
 	}
 	return s
 }
@@ -123,7 +212,11 @@ func (l *balanceLeaderScheduler) GetType() string {
 }
 
 func (l *balanceLeaderScheduler) EncodeConfig() ([]byte, error) {
-	return schedule.EncodeConfig(l.conf)
+	return l.conf.EncodeConfig()
+}
+
+func (l *balanceLeaderScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.conf.ServeHTTP(w, r)
 }
 
 func (l *balanceLeaderScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
@@ -143,19 +236,20 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 	plan := newBalancePlan(kind, cluster, opInfluence)
 
 	stores := cluster.GetStores()
+	if l.conf.getMode() == balanceLeaderCPUMode {
+		plan.leaderLoadFactors = leaderLoadFactors(cluster, stores)
+	}
 	sources := filter.SelectSourceStores(stores, l.filters, cluster.GetOpts())
 	targets := filter.SelectTargetStores(stores, l.filters, cluster.GetOpts())
 	sort.Slice(sources, func(i, j int) bool {
 		iOp := plan.GetOpInfluence(sources[i].GetID())
 		jOp := plan.GetOpInfluence(sources[j].GetID())
-		return sources[i].LeaderScore(leaderSchedulePolicy, iOp) >
-			sources[j].LeaderScore(leaderSchedulePolicy, jOp)
+		return plan.leaderScore(sources[i], iOp) > plan.leaderScore(sources[j], jOp)
 	})
 	sort.Slice(targets, func(i, j int) bool {
 		iOp := plan.GetOpInfluence(targets[i].GetID())
 		jOp := plan.GetOpInfluence(targets[i].GetID())
-		return targets[i].LeaderScore(leaderSchedulePolicy, iOp) <
-			targets[j].LeaderScore(leaderSchedulePolicy, jOp)
+		return plan.leaderScore(targets[i], iOp) < plan.leaderScore(targets[j], jOp)
 	})
 
 	for i := 0; i < len(sources) || i < len(targets); i++ {
@@ -194,7 +288,7 @@ func (l *balanceLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 // It randomly selects a health region from the source store, then picks
 // the best follower peer and transfers the leader.
 func (l *balanceLeaderScheduler) transferLeaderOut(plan *balancePlan) []*operator.Operator {
-	plan.region = plan.cluster.RandLeaderRegion(plan.SourceStoreID(), l.conf.Ranges, opt.HealthRegion(plan.cluster))
+	plan.region = plan.cluster.RandLeaderRegion(plan.SourceStoreID(), l.conf.Ranges, opt.HealthRegion(plan.cluster), opt.NotPinnedRegion(plan.cluster), notRecentlyMovedRegion(l.opController))
 	if plan.region == nil {
 		log.Debug("store has no leader", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", plan.SourceStoreID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no-leader-region").Inc()
@@ -206,11 +300,10 @@ func (l *balanceLeaderScheduler) transferLeaderOut(plan *balancePlan) []*operato
 		finalFilters = append(l.filters, leaderFilter)
 	}
 	targets = filter.SelectTargetStores(targets, finalFilters, plan.cluster.GetOpts())
-	leaderSchedulePolicy := l.opController.GetLeaderSchedulePolicy()
 	sort.Slice(targets, func(i, j int) bool {
 		iOp := plan.GetOpInfluence(targets[i].GetID())
 		jOp := plan.GetOpInfluence(targets[j].GetID())
-		return targets[i].LeaderScore(leaderSchedulePolicy, iOp) < targets[j].LeaderScore(leaderSchedulePolicy, jOp)
+		return plan.leaderScore(targets[i], iOp) < plan.leaderScore(targets[j], jOp)
 	})
 	for _, plan.target = range targets {
 		if op := l.createOperator(plan); len(op) > 0 {
@@ -226,7 +319,7 @@ func (l *balanceLeaderScheduler) transferLeaderOut(plan *balancePlan) []*operato
 // It randomly selects a health region from the target store, then picks
 // the worst follower peer and transfers the leader.
 func (l *balanceLeaderScheduler) transferLeaderIn(plan *balancePlan) []*operator.Operator {
-	plan.region = plan.cluster.RandFollowerRegion(plan.TargetStoreID(), l.conf.Ranges, opt.HealthRegion(plan.cluster))
+	plan.region = plan.cluster.RandFollowerRegion(plan.TargetStoreID(), l.conf.Ranges, opt.HealthRegion(plan.cluster), opt.NotPinnedRegion(plan.cluster), notRecentlyMovedRegion(l.opController))
 	if plan.region == nil {
 		log.Debug("store has no follower", zap.String("scheduler", l.GetName()), zap.Uint64("store-id", plan.TargetStoreID()))
 		schedulerCounter.WithLabelValues(l.GetName(), "no-follower-region").Inc()
@@ -289,5 +382,33 @@ func (l *balanceLeaderScheduler) createOperator(plan *balancePlan) []*operator.O
 	)
 	op.AdditionalInfos["sourceScore"] = strconv.FormatFloat(plan.sourceScore, 'f', 2, 64)
 	op.AdditionalInfos["targetScore"] = strconv.FormatFloat(plan.targetScore, 'f', 2, 64)
+	op.SetReason(fmt.Sprintf("the leader score of store %d (%.2f) is higher than store %d (%.2f)", plan.SourceStoreID(), plan.sourceScore, plan.TargetStoreID(), plan.targetScore))
 	return []*operator.Operator{op}
 }
+
+// leaderLoadFactors returns the load factors of the stores in the cpu mode,
+// which are the ratios of the CPU usages of the stores to the average one. The
+// stores which do not report the CPU usage are not weighted.
+func leaderLoadFactors(cluster opt.Cluster, stores []*core.StoreInfo) map[uint64]float64 {
+	storesLoads := cluster.GetStoresLoads()
+	usages := make(map[uint64]float64)
+	var sum float64
+	for _, store := range stores {
+		if !store.IsUp() {
+			continue
+		}
+		if loads, ok := storesLoads[store.GetID()]; ok && loads[statistics.StoreCPUUsage] > 0 {
+			usages[store.GetID()] = loads[statistics.StoreCPUUsage]
+			sum += loads[statistics.StoreCPUUsage]
+		}
+	}
+	if len(usages) < 2 {
+		return nil
+	}
+	avg := sum / float64(len(usages))
+	factors := make(map[uint64]float64, len(usages))
+	for id, usage := range usages {
+		factors[id] = math.Min(math.Max(usage/avg, minLeaderLoadFactor), maxLeaderLoadFactor)
+	}
+	return factors
+}
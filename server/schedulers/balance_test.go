@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -206,6 +207,95 @@ func (s *testBalanceSuite) TestTolerantRatio(c *C) {
 	}
 }
 
+func (s *testBalanceSuite) TestAdaptiveTolerantRatio(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	kind := core.ScheduleKind{Resource: core.RegionKind, Policy: core.BySize}
+	tc.AddRegionStore(1, 0)
+	tc.AddRegionStore(2, 0)
+	tc.AddRegionStore(3, 0)
+	tc.AddLeaderRegion(1, 1)
+
+	info := GetTolerantRatioInfo(tc)
+	c.Assert(info.StoreCount, Equals, 3)
+	c.Assert(info.MaxRegionCount, Equals, 1)
+	c.Assert(info.StoreFactor, Equals, float64(smallClusterStoreCount)/3)
+	c.Assert(info.SizeFactor, Equals, 1.0)
+	c.Assert(info.SpeedFactor, Equals, 1.0)
+	c.Assert(info.AdaptiveRatio, Equals, minTolerantSizeRatio)
+
+	// The ratio grows with the region count.
+	for i := uint64(2); i <= 2000; i++ {
+		tc.AddLeaderRegion(i, 1)
+	}
+	info = GetTolerantRatioInfo(tc)
+	c.Assert(info.MaxRegionCount, Equals, 2000)
+	c.Assert(math.Abs(info.AdaptiveRatio-10) < 1e-6, IsTrue)
+	c.Assert(adjustTolerantRatio(tc, kind), Equals, info.AdaptiveRatio)
+
+	// The slow scheduling lowers the cap.
+	tc.AddRegionStore(4, 0)
+	tc.AddRegionStore(5, 0)
+	tc.SetRegionScheduleLimit(512)
+	info = GetTolerantRatioInfo(tc)
+	c.Assert(info.StoreFactor, Equals, 1.0)
+	c.Assert(info.SpeedFactor, Equals, 0.25)
+	c.Assert(info.AdaptiveRatio, Equals, maxTolerantSizeRatio*0.25)
+
+	// The config overrides the adaptive ratio.
+	tc.SetTolerantSizeRatio(2.5)
+	info = GetTolerantRatioInfo(tc)
+	c.Assert(info.AdaptiveRatio, Equals, maxTolerantSizeRatio*0.25)
+	c.Assert(info.Ratio, Equals, 2.5)
+	c.Assert(adjustTolerantRatio(tc, kind), Equals, 2.5)
+}
+
+func (s *testBalanceSuite) TestStoreScores(c *C) {
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	stream := hbstream.NewTestHeartbeatStreams(s.ctx, tc.ID, tc, false /* no need to run */)
+	oc := schedule.NewOperatorController(s.ctx, tc, stream)
+	tc.AddLeaderStore(1, 10)
+	tc.AddLeaderStore(2, 20)
+	tc.AddLeaderStore(3, 30)
+	tc.UpdateStoreLeaderWeight(3, 2)
+	tc.AddLeaderRegion(1, 1, 2, 3)
+
+	info, err := GetStoreScores(tc, oc, BalanceLeaderType)
+	c.Assert(err, IsNil)
+	c.Assert(info.Policy, Equals, core.ByCount.String())
+	c.Assert(info.Stores, HasLen, 3)
+	// Sorted by the scores in descending order.
+	c.Assert(info.Stores[0].StoreID, Equals, uint64(2))
+	c.Assert(info.Stores[0].Resource, Equals, int64(20))
+	c.Assert(info.Stores[0].Score, Equals, 20.0)
+	c.Assert(info.Stores[1].StoreID, Equals, uint64(3))
+	c.Assert(info.Stores[1].Weight, Equals, 2.0)
+	c.Assert(info.Stores[1].Score, Equals, 15.0)
+
+	// The pending operators influence the scores.
+	op, err := operator.CreateTransferLeaderOperator("test", tc, tc.GetRegion(1), 1, 2, operator.OpLeader)
+	c.Assert(err, IsNil)
+	c.Assert(oc.AddWaitingOperator(op), Equals, 1)
+	info, err = GetStoreScores(tc, oc, BalanceLeaderType)
+	c.Assert(err, IsNil)
+	c.Assert(info.Stores[0].StoreID, Equals, uint64(2))
+	c.Assert(info.Stores[0].OpInfluence, Equals, int64(1))
+	c.Assert(info.Stores[0].ScoreWithInfluence, Equals, 21.0)
+
+	info, err = GetStoreScores(tc, oc, BalanceRegionType)
+	c.Assert(err, IsNil)
+	c.Assert(info.Policy, Equals, core.BySize.String())
+	c.Assert(info.Stores, HasLen, 3)
+	for _, store := range info.Stores {
+		c.Assert(store.Capacity > 0, IsTrue)
+		c.Assert(store.Score, Equals, float64(store.Resource))
+	}
+
+	_, err = GetStoreScores(tc, oc, ShuffleLeaderType)
+	c.Assert(err, NotNil)
+}
+
 var _ = Suite(&testBalanceLeaderSchedulerSuite{})
 
 type testBalanceLeaderSchedulerSuite struct {
@@ -270,6 +360,26 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLimit(c *C) {
 	c.Check(s.schedule(), NotNil)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestPinnedRegion(c *C) {
+	// Stores:     1    2    3    4
+	// Leaders:    16   0    0    0
+	// Region1:    L    F    F    F
+	s.tc.AddLeaderStore(1, 16)
+	s.tc.AddLeaderStore(2, 0)
+	s.tc.AddLeaderStore(3, 0)
+	s.tc.AddLeaderStore(4, 0)
+	s.tc.AddLeaderRegionWithRange(1, "a", "b", 1, 2, 3, 4)
+	c.Check(s.schedule(), NotNil)
+
+	s.tc.PinRegion(1, time.Minute)
+	c.Check(s.schedule(), IsNil)
+	s.tc.PinRegion(1, 0)
+	c.Check(s.schedule(), NotNil)
+
+	s.tc.PinKeyRange([]byte("aa"), []byte("c"), time.Minute)
+	c.Check(s.schedule(), IsNil)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderSchedulePolicy(c *C) {
 	// Stores:          1       2       3       4
 	// Leader Count:    10      10      10      10
@@ -286,6 +396,49 @@ func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderSchedulePolicy(c *C)
 	c.Check(s.schedule(), NotNil)
 }
 
+func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderCPUMode(c *C) {
+	// Stores:          1       2       3       4
+	// Leader Count:    10      10      10      10
+	// CPU Usage:       400     100     100     100
+	// Region1:         L       F       F       F
+	s.tc.SetTolerantSizeRatio(1)
+	s.tc.AddLeaderStore(1, 10)
+	s.tc.AddLeaderStore(2, 10)
+	s.tc.AddLeaderStore(3, 10)
+	s.tc.AddLeaderStore(4, 10)
+	s.tc.UpdateStoreCPUUsage(1, 400)
+	s.tc.UpdateStoreCPUUsage(2, 100)
+	s.tc.UpdateStoreCPUUsage(3, 100)
+	s.tc.UpdateStoreCPUUsage(4, 100)
+	s.tc.AddLeaderRegion(1, 1, 2, 3, 4)
+	conf := s.lb.(*balanceLeaderScheduler).conf
+	c.Assert(conf.getMode(), Equals, balanceLeaderCountMode)
+	c.Check(s.schedule(), IsNil)
+
+	conf.Mode = balanceLeaderCPUMode
+	ops := s.schedule()
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeaderFrom(c, ops[0], operator.OpKind(0), 1)
+
+	// The load factors are bounded.
+	factors := leaderLoadFactors(s.tc, s.tc.GetStores())
+	c.Assert(factors[1], Equals, maxLeaderLoadFactor)
+	c.Assert(factors[2], Less, 1.0)
+
+	// The store which does not report the CPU usage is not weighted.
+	s.tc.UpdateStoreCPUUsage(4, 0)
+	factors = leaderLoadFactors(s.tc, s.tc.GetStores())
+	_, ok := factors[4]
+	c.Assert(ok, IsFalse)
+
+	// The mode is persisted.
+	data, err := s.lb.EncodeConfig()
+	c.Assert(err, IsNil)
+	newConf := &balanceLeaderSchedulerConfig{}
+	c.Assert(schedule.DecodeConfig(data, newConf), IsNil)
+	c.Assert(newConf.getMode(), Equals, balanceLeaderCPUMode)
+}
+
 func (s *testBalanceLeaderSchedulerSuite) TestBalanceLeaderTolerantRatio(c *C) {
 	s.tc.SetTolerantSizeRatio(2.5)
 	// test schedule leader by count, with tolerantSizeRatio=2.5
@@ -428,6 +581,13 @@ func (s *testBalanceLeaderSchedulerSuite) TestLeaderWeight(c *C) {
 	testutil.CheckTransferLeader(c, s.schedule()[0], operator.OpKind(0), 1, 4)
 	s.tc.UpdateLeaderCount(4, 30)
 	testutil.CheckTransferLeader(c, s.schedule()[0], operator.OpKind(0), 1, 3)
+
+	// The stores with leader weight 0 don't accept new leaders.
+	s.tc.UpdateStoreLeaderWeight(3, 0)
+	testutil.CheckTransferLeader(c, s.schedule()[0], operator.OpKind(0), 1, 2)
+	s.tc.UpdateStoreLeaderWeight(2, 0)
+	s.tc.UpdateStoreLeaderWeight(4, 0)
+	c.Assert(s.schedule(), HasLen, 0)
 }
 
 func (s *testBalanceLeaderSchedulerSuite) TestBalancePolicy(c *C) {
@@ -648,6 +808,28 @@ func (s *testBalanceRegionSchedulerSuite) TestBalance(c *C) {
 	c.Assert(sb.Schedule(tc), NotNil)
 }
 
+func (s *testBalanceRegionSchedulerSuite) TestEngineGroup(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	tc := mockcluster.NewCluster(s.ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	oc := schedule.NewOperatorController(s.ctx, nil, nil)
+
+	sb, err := schedule.CreateScheduler(BalanceRegionType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(BalanceRegionType, []string{"", ""}))
+	c.Assert(err, IsNil)
+
+	opt.SetMaxReplicas(1)
+	tc.AddRegionStore(1, 16)
+	tc.AddLabelsStore(2, 0, map[string]string{"engine": "tiflash"})
+	tc.AddLeaderRegion(1, 1)
+	// The TiFlash store is never selected as the target of a TiKV peer.
+	c.Assert(sb.Schedule(tc), IsNil)
+
+	// The peer is moved within the TiKV engine group.
+	tc.AddRegionStore(3, 0)
+	testutil.CheckTransferPeerWithLeaderTransfer(c, sb.Schedule(tc)[0], operator.OpKind(0), 1, 3)
+}
+
 func (s *testBalanceRegionSchedulerSuite) TestReplicas3(c *C) {
 	opt := config.NewTestOptions()
 	//TODO: enable placementrules
@@ -1048,6 +1230,8 @@ func (s *testRandomMergeSchedulerSuite) TestMerge(c *C) {
 	c.Assert(ops, HasLen, 2)
 	c.Assert(ops[0].Kind()&operator.OpMerge, Not(Equals), 0)
 	c.Assert(ops[1].Kind()&operator.OpMerge, Not(Equals), 0)
+	c.Assert(ops[0].Reason(), Matches, "randomly merge region [1-4] into region [1-4]")
+	c.Assert(ops[1].Reason(), Equals, ops[0].Reason())
 
 	oc.AddWaitingOperator(ops...)
 	c.Assert(mb.IsScheduleAllowed(tc), IsFalse)
@@ -1123,6 +1307,9 @@ func (s *testScatterRangeLeaderSuite) TestBalance(c *C) {
 
 	hb, err := schedule.CreateScheduler(ScatterRangeType, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(ScatterRangeType, []string{"s_00", "s_50", "t"}))
 	c.Assert(err, IsNil)
+	ops := hb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	c.Assert(ops[0].Reason(), Matches, "the (leader|region) score of store .* is higher than store .*")
 
 	scheduleAndApplyOperator(tc, hb, 100)
 	for i := 1; i <= 5; i++ {
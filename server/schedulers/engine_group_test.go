@@ -0,0 +1,156 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+
+	. "github.com/pingcap/check"
+	"github.com/tikv/pd/pkg/mock/mockcluster"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/kv"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testEngineGroupSuite{})
+
+type testEngineGroupSuite struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *testEngineGroupSuite) SetUpTest(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+}
+
+func (s *testEngineGroupSuite) TearDownTest(c *C) {
+	s.cancel()
+}
+
+// schedulerArgs is the args of the schedulers which can't be created with the
+// default ones.
+var schedulerArgs = map[string][]string{
+	EvictLeaderType:      {"1"},
+	GrantLeaderType:      {"2"},
+	GrantHotLeaderType:   {"zone=z1"},
+	ScatterRangeType:     {"", "", "all"},
+	ShuffleHotRegionType: {"1"},
+}
+
+// TestMixedEngines runs all the registered schedulers and the checkers over a
+// cluster with both TiKV and TiFlash stores, none of the operators should move
+// peers across the engine groups.
+func (s *testEngineGroupSuite) TestMixedEngines(c *C) {
+	s.checkMixedEngines(c, false)
+	s.checkMixedEngines(c, true)
+}
+
+func (s *testEngineGroupSuite) checkMixedEngines(c *C, enablePlacementRules bool) {
+	tc := mockcluster.NewCluster(s.ctx, config.NewTestOptions())
+	tc.SetEnablePlacementRules(enablePlacementRules)
+	if enablePlacementRules {
+		c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+			GroupID: "pd", ID: "default", Role: placement.Voter, Count: 3,
+			LabelConstraints: []placement.LabelConstraint{{Key: filter.EngineKey, Op: placement.NotIn, Values: []string{filter.EngineTiFlash}}},
+		}), IsNil)
+		c.Assert(tc.RuleManager.SetRule(&placement.Rule{
+			GroupID: "tiflash", ID: "learner", Role: placement.Learner, Count: 1,
+			LabelConstraints: []placement.LabelConstraint{{Key: filter.EngineKey, Op: placement.In, Values: []string{filter.EngineTiFlash}}},
+		}), IsNil)
+	}
+
+	// The TiKV stores 1-5 and the TiFlash stores 6-9 are both unbalanced.
+	for id, count := range map[uint64]int{1: 60, 2: 50, 3: 40, 4: 0, 5: 0} {
+		tc.AddLabelsStore(id, count, map[string]string{"zone": "z1"})
+	}
+	for id, count := range map[uint64]int{6: 60, 7: 10, 8: 0, 9: 0} {
+		tc.AddLabelsStore(id, count, map[string]string{"zone": "z2", filter.EngineKey: filter.EngineTiFlash})
+	}
+	for id := uint64(1); id <= 30; id++ {
+		switch id % 3 {
+		case 0:
+			tc.AddRegionWithLearner(id, 1, []uint64{2, 3}, []uint64{6})
+		case 1:
+			// The regions miss the TiFlash learner.
+			tc.AddLeaderRegion(id, 1, 2, 3)
+		case 2:
+			// The regions miss a voter and have an extra learner.
+			tc.AddRegionWithLearner(id, 2, []uint64{1}, []uint64{6, 7})
+		}
+	}
+	// The peers on the offline stores are moved to the stores of the same engine.
+	tc.SetStoreOffline(3)
+	tc.SetStoreOffline(7)
+
+	var ops []*operator.Operator
+	oc := schedule.NewOperatorController(s.ctx, nil, nil)
+	for _, typ := range schedule.RegisteredSchedulerTypes() {
+		args, ok := schedulerArgs[typ]
+		if !ok {
+			args = []string{"", ""}
+		}
+		scheduler, err := schedule.CreateScheduler(typ, oc, core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(typ, args))
+		c.Assert(err, IsNil, Commentf("scheduler %s", typ))
+		for i := 0; i < 10; i++ {
+			ops = append(ops, scheduler.Schedule(tc)...)
+		}
+	}
+	checkers := schedule.NewCheckerController(s.ctx, tc, tc.RuleManager, oc)
+	for _, region := range tc.GetRegions() {
+		ops = append(ops, checkers.CheckRegion(region)...)
+	}
+
+	var moved int
+	for _, op := range ops {
+		from, to := s.movedEngineGroups(tc, op)
+		for group := range from {
+			for target := range to {
+				c.Assert(target, Equals, group, Commentf("placement rules: %v, operator %s", enablePlacementRules, op))
+			}
+		}
+		if len(from) > 0 && len(to) > 0 {
+			moved++
+		}
+	}
+	// Make sure the peers are really moved within the engine groups.
+	c.Assert(moved, Greater, 0)
+}
+
+// movedEngineGroups returns the engine groups of the stores which the operator
+// removes peers from and adds peers to.
+func (s *testEngineGroupSuite) movedEngineGroups(tc *mockcluster.Cluster, op *operator.Operator) (from, to map[string]struct{}) {
+	from, to = make(map[string]struct{}), make(map[string]struct{})
+	group := func(storeID uint64) string {
+		return tc.GetStore(storeID).GetEngineGroup()
+	}
+	for i := 0; i < op.Len(); i++ {
+		switch step := op.Step(i).(type) {
+		case operator.AddPeer:
+			to[group(step.ToStore)] = struct{}{}
+		case operator.AddLearner:
+			to[group(step.ToStore)] = struct{}{}
+		case operator.AddLightPeer:
+			to[group(step.ToStore)] = struct{}{}
+		case operator.AddLightLearner:
+			to[group(step.ToStore)] = struct{}{}
+		case operator.RemovePeer:
+			from[group(step.FromStore)] = struct{}{}
+		}
+	}
+	return from, to
+}
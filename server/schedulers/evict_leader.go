@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -256,6 +257,7 @@ func (s *evictLeaderScheduler) scheduleOnce(cluster opt.Cluster) []*operator.Ope
 			log.Debug("fail to create evict leader operator", errs.ZapError(err))
 			continue
 		}
+		op.SetReason(fmt.Sprintf("evict the leaders from store %d", id))
 		op.SetPriorityLevel(core.HighPriority)
 		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 		ops = append(ops, op)
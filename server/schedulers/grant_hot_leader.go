@@ -0,0 +1,371 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/apiutil"
+	"github.com/tikv/pd/pkg/errs"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/filter"
+	"github.com/tikv/pd/server/schedule/operator"
+	"github.com/tikv/pd/server/schedule/opt"
+	"github.com/tikv/pd/server/statistics"
+	"github.com/unrolled/render"
+)
+
+const (
+	// GrantHotLeaderName is grant hot leader scheduler name.
+	GrantHotLeaderName = "grant-hot-leader-scheduler"
+	// GrantHotLeaderType is grant hot leader scheduler type.
+	GrantHotLeaderType = "grant-hot-leader"
+
+	defaultGrantHotRegionCount = 10
+)
+
+func init() {
+	schedule.RegisterSliceDecoderBuilder(GrantHotLeaderType, func(args []string) schedule.ConfigDecoder {
+		return func(v interface{}) error {
+			conf, ok := v.(*grantHotLeaderSchedulerConfig)
+			if !ok {
+				return errs.ErrScheduleConfigNotExist.FastGenByArgs()
+			}
+			return conf.buildWithArgs(args)
+		}
+	})
+
+	schedule.RegisterScheduler(GrantHotLeaderType, func(opController *schedule.OperatorController, storage *core.Storage, decoder schedule.ConfigDecoder) (schedule.Scheduler, error) {
+		conf := &grantHotLeaderSchedulerConfig{
+			storage:        storage,
+			StoreLabels:    make(map[string]string),
+			HotRegionCount: defaultGrantHotRegionCount,
+			RWType:         read.String(),
+		}
+		if err := decoder(conf); err != nil {
+			return nil, err
+		}
+		return newGrantHotLeaderScheduler(opController, conf), nil
+	})
+}
+
+type grantHotLeaderSchedulerConfig struct {
+	mu      sync.RWMutex
+	storage *core.Storage
+	// StoreLabels selects the designated stores, a store is designated if it
+	// has all the labels.
+	StoreLabels map[string]string `json:"store-labels"`
+	// HotRegionCount is the number of the hottest regions whose leaders are granted.
+	HotRegionCount int `json:"hot-region-count"`
+	// RWType decides the hottest regions are ranked by read or write flow.
+	RWType string `json:"rw-type"`
+	// MaxHotLeaderCount is the max number of the granted hot leaders on one
+	// designated store, 0 means no limit.
+	MaxHotLeaderCount int `json:"max-hot-leader-count"`
+	// MaxCPUUsage is the max CPU usage reported by the designated store, above which
+	// the store doesn't accept more hot leaders, 0 means no limit.
+	MaxCPUUsage float64 `json:"max-cpu-usage"`
+}
+
+// buildWithArgs builds the config from the args in the form of
+// `<label-key>=<label-value>[,<label-key>=<label-value>] [hot-region-count]`.
+func (conf *grantHotLeaderSchedulerConfig) buildWithArgs(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("store-labels")
+	}
+	labels := make(map[string]string)
+	for _, kv := range strings.Split(args[0], ",") {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 || len(pair[0]) == 0 {
+			return errs.ErrSchedulerConfig.FastGenByArgs("store-labels")
+		}
+		labels[pair[0]] = pair[1]
+	}
+	count := defaultGrantHotRegionCount
+	if len(args) == 2 {
+		c, err := strconv.Atoi(args[1])
+		if err != nil {
+			return errs.ErrStrconvParseInt.Wrap(err).FastGenWithCause()
+		}
+		if c <= 0 {
+			return errs.ErrSchedulerConfig.FastGenByArgs("hot-region-count")
+		}
+		count = c
+	}
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+	conf.StoreLabels = labels
+	conf.HotRegionCount = count
+	return nil
+}
+
+func (conf *grantHotLeaderSchedulerConfig) EncodeConfig() ([]byte, error) {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	return schedule.EncodeConfig(conf)
+}
+
+func (conf *grantHotLeaderSchedulerConfig) Clone() *grantHotLeaderSchedulerConfig {
+	conf.mu.RLock()
+	defer conf.mu.RUnlock()
+	labels := make(map[string]string, len(conf.StoreLabels))
+	for k, v := range conf.StoreLabels {
+		labels[k] = v
+	}
+	return &grantHotLeaderSchedulerConfig{
+		StoreLabels:       labels,
+		HotRegionCount:    conf.HotRegionCount,
+		RWType:            conf.RWType,
+		MaxHotLeaderCount: conf.MaxHotLeaderCount,
+		MaxCPUUsage:       conf.MaxCPUUsage,
+	}
+}
+
+// assign copies the configurable fields, the caller should hold the lock.
+func (conf *grantHotLeaderSchedulerConfig) assign(other *grantHotLeaderSchedulerConfig) {
+	conf.StoreLabels = other.StoreLabels
+	conf.HotRegionCount = other.HotRegionCount
+	conf.RWType = other.RWType
+	conf.MaxHotLeaderCount = other.MaxHotLeaderCount
+	conf.MaxCPUUsage = other.MaxCPUUsage
+}
+
+func (conf *grantHotLeaderSchedulerConfig) validate() error {
+	if len(conf.StoreLabels) == 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("store-labels")
+	}
+	if conf.HotRegionCount <= 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("hot-region-count")
+	}
+	if conf.RWType != read.String() && conf.RWType != write.String() {
+		return errs.ErrSchedulerConfig.FastGenByArgs("rw-type")
+	}
+	if conf.MaxHotLeaderCount < 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("max-hot-leader-count")
+	}
+	if conf.MaxCPUUsage < 0 {
+		return errs.ErrSchedulerConfig.FastGenByArgs("max-cpu-usage")
+	}
+	return nil
+}
+
+func (conf *grantHotLeaderSchedulerConfig) persist() error {
+	data, err := schedule.EncodeConfig(conf)
+	if err != nil {
+		return err
+	}
+	return conf.storage.SaveScheduleConfig(GrantHotLeaderName, data)
+}
+
+func (conf *grantHotLeaderSchedulerConfig) isDesignated(store *core.StoreInfo) bool {
+	for k, v := range conf.StoreLabels {
+		if store.GetLabelValue(k) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (conf *grantHotLeaderSchedulerConfig) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	router := mux.NewRouter()
+	router.HandleFunc("/list", conf.handleGetConfig).Methods("GET")
+	router.HandleFunc("/config", conf.handleSetConfig).Methods("POST")
+	router.ServeHTTP(w, r)
+}
+
+func (conf *grantHotLeaderSchedulerConfig) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	rd.JSON(w, http.StatusOK, conf.Clone())
+}
+
+func (conf *grantHotLeaderSchedulerConfig) handleSetConfig(w http.ResponseWriter, r *http.Request) {
+	rd := render.New(render.Options{IndentJSON: true})
+	newConf := conf.Clone()
+	if err := apiutil.ReadJSONRespondError(rd, w, r.Body, newConf); err != nil {
+		return
+	}
+	if err := newConf.validate(); err != nil {
+		rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	old := conf.Clone()
+	conf.mu.Lock()
+	defer conf.mu.Unlock()
+	conf.assign(newConf)
+	if err := conf.persist(); err != nil {
+		conf.assign(old) // revert
+		rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rd.JSON(w, http.StatusOK, nil)
+}
+
+// grantHotLeaderScheduler concentrates the leaders of the hottest regions onto the
+// designated stores, e.g. the stores with better hardware in a tiered deployment.
+type grantHotLeaderScheduler struct {
+	*BaseScheduler
+	conf    *grantHotLeaderSchedulerConfig
+	filters []filter.Filter
+}
+
+// newGrantHotLeaderScheduler creates an admin scheduler that transfers the leaders
+// of the hottest regions to the designated stores.
+func newGrantHotLeaderScheduler(opController *schedule.OperatorController, conf *grantHotLeaderSchedulerConfig) schedule.Scheduler {
+	return &grantHotLeaderScheduler{
+		BaseScheduler: NewBaseScheduler(opController),
+		conf:          conf,
+		filters: []filter.Filter{
+			&filter.StoreStateFilter{ActionScope: GrantHotLeaderName, TransferLeader: true},
+		},
+	}
+}
+
+func (s *grantHotLeaderScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.conf.ServeHTTP(w, r)
+}
+
+func (s *grantHotLeaderScheduler) GetName() string {
+	return GrantHotLeaderName
+}
+
+func (s *grantHotLeaderScheduler) GetType() string {
+	return GrantHotLeaderType
+}
+
+func (s *grantHotLeaderScheduler) EncodeConfig() ([]byte, error) {
+	return s.conf.EncodeConfig()
+}
+
+func (s *grantHotLeaderScheduler) IsScheduleAllowed(cluster opt.Cluster) bool {
+	allowed := s.OpController.OperatorCount(operator.OpLeader) < cluster.GetOpts().GetLeaderScheduleLimit()
+	if !allowed {
+		operator.OperatorLimitCounter.WithLabelValues(s.GetType(), operator.OpLeader.String()).Inc()
+	}
+	return allowed
+}
+
+func (s *grantHotLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
+	schedulerCounter.WithLabelValues(s.GetName(), "schedule").Inc()
+	conf := s.conf.Clone()
+
+	designated := make(map[uint64]*core.StoreInfo)
+	for _, store := range cluster.GetStores() {
+		if conf.isDesignated(store) && filter.Target(cluster.GetOpts(), store, s.filters) {
+			designated[store.GetID()] = store
+		}
+	}
+	if len(designated) == 0 {
+		schedulerCounter.WithLabelValues(s.GetName(), "no-designated-store").Inc()
+		return nil
+	}
+
+	regions := s.hottestRegions(cluster, conf)
+	// Counts the granted hot leaders on each designated store.
+	hotLeaders := make(map[uint64]int, len(designated))
+	for _, region := range regions {
+		if _, ok := designated[region.GetLeader().GetStoreId()]; ok {
+			hotLeaders[region.GetLeader().GetStoreId()]++
+		}
+	}
+	storesLoads := cluster.GetStoresLoads()
+	isOverloaded := func(storeID uint64) bool {
+		if conf.MaxHotLeaderCount > 0 && hotLeaders[storeID] >= conf.MaxHotLeaderCount {
+			return true
+		}
+		if conf.MaxCPUUsage > 0 {
+			if loads, ok := storesLoads[storeID]; ok && loads[statistics.StoreCPUUsage] >= conf.MaxCPUUsage {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, region := range regions {
+		if _, ok := designated[region.GetLeader().GetStoreId()]; ok {
+			continue
+		}
+		if !opt.IsRegionHealthy(cluster, region) {
+			continue
+		}
+		var target uint64
+		for _, peer := range region.GetFollowers() {
+			storeID := peer.GetStoreId()
+			if _, ok := designated[storeID]; !ok || isOverloaded(storeID) {
+				continue
+			}
+			if target == 0 || hotLeaders[storeID] < hotLeaders[target] {
+				target = storeID
+			}
+		}
+		if target == 0 {
+			schedulerCounter.WithLabelValues(s.GetName(), "no-target-store").Inc()
+			continue
+		}
+		op, err := operator.CreateTransferLeaderOperator(GrantHotLeaderType, cluster, region, region.GetLeader().GetStoreId(), target, operator.OpLeader|operator.OpHotRegion)
+		if err != nil {
+			log.Debug("fail to create grant hot leader operator", errs.ZapError(err))
+			continue
+		}
+		op.SetReason(fmt.Sprintf("grant the hot %s leader to store %d", conf.RWType, target))
+		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
+		return []*operator.Operator{op}
+	}
+	schedulerCounter.WithLabelValues(s.GetName(), "skip").Inc()
+	return nil
+}
+
+// hottestRegions returns the hottest regions ranked by the flow of the configured type.
+func (s *grantHotLeaderScheduler) hottestRegions(cluster opt.Cluster, conf *grantHotLeaderSchedulerConfig) []*core.RegionInfo {
+	stats, kind := cluster.RegionReadStats(), statistics.RegionReadBytes
+	if conf.RWType == write.String() {
+		stats, kind = cluster.RegionWriteStats(), statistics.RegionWriteBytes
+	}
+	loads := make(map[uint64]float64)
+	for _, peers := range stats {
+		for _, peer := range peers {
+			if load := peer.GetLoad(kind); load > loads[peer.RegionID] {
+				loads[peer.RegionID] = load
+			}
+		}
+	}
+	ids := make([]uint64, 0, len(loads))
+	for id := range loads {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if loads[ids[i]] != loads[ids[j]] {
+			return loads[ids[i]] > loads[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	regions := make([]*core.RegionInfo, 0, conf.HotRegionCount)
+	for _, id := range ids {
+		if len(regions) >= conf.HotRegionCount {
+			break
+		}
+		if region := cluster.GetRegion(id); region != nil {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"sync"
@@ -242,6 +243,7 @@ func (s *grantLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Operato
 			log.Debug("fail to create grant leader operator", errs.ZapError(err))
 			continue
 		}
+		op.SetReason(fmt.Sprintf("grant the leaders to store %d", id))
 		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 		op.SetPriorityLevel(core.HighPriority)
 		ops = append(ops, op)
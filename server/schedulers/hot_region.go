@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/pd/pkg/errs"
@@ -65,6 +66,10 @@ const (
 
 	minHotScheduleInterval = time.Second
 	maxHotScheduleInterval = 20 * time.Second
+
+	// hotBucketSkewThreshold is the ratio between the flow of the hottest bucket and the
+	// average flow of the buckets, above which a hot region is split rather than moved.
+	hotBucketSkewThreshold = 2.0
 )
 
 // schedulePeerPr the probability of schedule the hot peer.
@@ -510,6 +515,18 @@ func (bs *balanceSolver) solve() []*operator.Operator {
 			if bs.cur.region == nil {
 				continue
 			}
+			if bs.cluster.GetOpts().IsHotBucketSplitEnabled() {
+				if op := bs.splitByBuckets(); op != nil {
+					// The split doesn't move the flow between the stores, so the
+					// influence is empty. It only marks the region as pending to
+					// avoid splitting it again before the split finishes.
+					infl := Influence{Loads: make([]float64, len(srcPeerStat.Loads))}
+					if !bs.sche.addPendingInfluence(op, srcStoreID, srcStoreID, infl) {
+						return nil
+					}
+					return []*operator.Operator{op}
+				}
+			}
 			for dstStoreID := range bs.filterDstStores() {
 				bs.cur.dstStoreID = dstStoreID
 				bs.calcProgressiveRank()
@@ -655,6 +672,18 @@ func (bs *balanceSolver) isRegionAvailable(region *core.RegionInfo) bool {
 		return false
 	}
 
+	if bs.cluster.IsRegionPinned(region) {
+		log.Debug("region is pinned", zap.String("scheduler", bs.sche.GetName()), zap.Uint64("region-id", region.GetID()))
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "pinned-region").Inc()
+		return false
+	}
+
+	if bs.sche.OpController.IsRegionRecentlyMoved(region.GetID()) {
+		log.Debug("region is moved recently", zap.String("scheduler", bs.sche.GetName()), zap.Uint64("region-id", region.GetID()))
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "recently-moved-region").Inc()
+		return false
+	}
+
 	return true
 }
 
@@ -1028,6 +1057,7 @@ func (bs *balanceSolver) buildOperator() (op *operator.Operator, infl *Influence
 		return nil, nil
 	}
 
+	op.SetReason(fmt.Sprintf("the hot %s load of store %d is higher than store %d", bs.rwTy, bs.cur.srcStoreID, bs.cur.dstStoreID))
 	op.SetPriorityLevel(core.HighPriority)
 	op.Counters = append(op.Counters, counters...)
 	op.Counters = append(op.Counters,
@@ -1041,6 +1071,41 @@ func (bs *balanceSolver) buildOperator() (op *operator.Operator, infl *Influence
 	return op, infl
 }
 
+// splitByBuckets splits the hot region at the bucket boundary which halves its flow
+// if the flow concentrates on a part of the region, since moving the whole region
+// only moves the hotspot to another store.
+func (bs *balanceSolver) splitByBuckets() *operator.Operator {
+	stat := bs.cluster.RegionBucketsStat(bs.cur.region)
+	if stat == nil {
+		return nil
+	}
+	kind := statistics.RegionWriteBytes
+	if bs.rwTy == read {
+		kind = statistics.RegionReadBytes
+	}
+	skew := stat.Skew(kind)
+	if skew < hotBucketSkewThreshold {
+		return nil
+	}
+	splitKey := stat.SplitKey(kind)
+	if splitKey == nil {
+		return nil
+	}
+	desc := "split-hot-" + bs.rwTy.String() + "-region"
+	op, err := operator.CreateSplitRegionOperator(desc, bs.cur.region, operator.OpHotRegion, pdpb.CheckPolicy_USEKEY, [][]byte{splitKey})
+	if err != nil {
+		log.Debug("fail to create split operator", zap.Uint64("region-id", bs.cur.region.GetID()), errs.ZapError(err))
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "create-operator-fail").Inc()
+		return nil
+	}
+	op.SetReason(fmt.Sprintf("the hot %s flow concentrates on a part of the region (skew %.2f)", bs.rwTy, skew))
+	op.SetPriorityLevel(core.HighPriority)
+	op.Counters = append(op.Counters,
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "new-operator"),
+		schedulerCounter.WithLabelValues(bs.sche.GetName(), "split-by-buckets"))
+	return op
+}
+
 func (h *hotScheduler) GetHotStatus(typ string) *statistics.StoreHotPeersInfos {
 	h.RLock()
 	defer h.RUnlock()
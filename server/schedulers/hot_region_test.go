@@ -513,6 +513,7 @@ func (s *testHotWriteRegionSchedulerSuite) TestLeader(c *C) {
 		hb.(*hotScheduler).clearPendingInfluence()
 		op := hb.Schedule(tc)[0]
 		testutil.CheckTransferLeaderFrom(c, op, operator.OpHotRegion, 2)
+		c.Assert(op.Reason(), Matches, "the hot write load of store 2 is higher than store [13]")
 		c.Assert(hb.Schedule(tc), HasLen, 0)
 	}
 }
@@ -779,6 +780,77 @@ func (s *testHotReadRegionSchedulerSuite) TestByteRateOnly(c *C) {
 	hb.(*hotScheduler).clearPendingInfluence()
 }
 
+func (s *testHotReadRegionSchedulerSuite) TestSplitByBuckets(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.DisableFeature(versioninfo.JointConsensus)
+	hb, err := schedule.CreateScheduler(HotReadRegionType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), nil)
+	c.Assert(err, IsNil)
+	tc.SetHotRegionCacheHitsThreshold(0)
+
+	tc.AddRegionStore(1, 3)
+	tc.AddRegionStore(2, 2)
+	tc.AddRegionStore(3, 2)
+	tc.UpdateStorageReadBytes(1, 7.5*MB*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadBytes(2, 4.9*MB*statistics.StoreHeartBeatReportInterval)
+	tc.UpdateStorageReadBytes(3, 3.7*MB*statistics.StoreHeartBeatReportInterval)
+	addRegionInfo(tc, read, []testRegionInfo{
+		{1, []uint64{1, 2, 3}, 512 * KB, 0},
+		{2, []uint64{2, 1, 3}, 512 * KB, 0},
+		{3, []uint64{1, 2, 3}, 512 * KB, 0},
+	})
+
+	// Most of the flow of the hot regions concentrates on the middle bucket.
+	for _, id := range []uint64{1, 2, 3} {
+		region := tc.GetRegion(id)
+		report := &statistics.BucketsReport{
+			RegionID: id,
+			Version:  1,
+			Keys: [][]byte{
+				region.GetStartKey(),
+				append(append([]byte{}, region.GetStartKey()...), 'a'),
+				append(append([]byte{}, region.GetStartKey()...), 'b'),
+				region.GetEndKey(),
+			},
+			Interval: 10 * time.Second,
+		}
+		report.Flows[statistics.RegionReadBytes] = []uint64{10 * KB, 100 * KB, 30 * KB}
+		c.Assert(tc.HotStat.Buckets.Update(report), IsNil)
+	}
+
+	// The hot regions are not split by the buckets by default.
+	for _, op := range hb.Schedule(tc) {
+		c.Assert(op.Kind()&operator.OpSplit, Equals, operator.OpKind(0))
+	}
+	hb.(*hotScheduler).clearPendingInfluence()
+
+	tc.SetEnableHotBucketSplit(true)
+	ops := hb.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	op := ops[0]
+	c.Assert(op.Kind()&operator.OpSplit, Equals, operator.OpSplit)
+	split, ok := op.Step(0).(operator.SplitRegion)
+	c.Assert(ok, IsTrue)
+	startKey := tc.GetRegion(op.RegionID()).GetStartKey()
+	c.Assert(split.SplitKeys, DeepEquals, [][]byte{append(append([]byte{}, startKey...), 'b')})
+	c.Assert(op.Reason(), Equals, "the hot read flow concentrates on a part of the region (skew 2.14)")
+
+	// The region being split is pending, so it isn't split again.
+	splitRegions := map[uint64]struct{}{op.RegionID(): {}}
+	for i := 0; i < 10; i++ {
+		for _, op := range hb.Schedule(tc) {
+			if op.Kind()&operator.OpSplit == 0 {
+				continue
+			}
+			_, ok := splitRegions[op.RegionID()]
+			c.Assert(ok, IsFalse)
+			splitRegions[op.RegionID()] = struct{}{}
+		}
+	}
+}
+
 func (s *testHotReadRegionSchedulerSuite) TestWithKeyRate(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
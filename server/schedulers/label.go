@@ -14,6 +14,8 @@
 package schedulers
 
 import (
+	"fmt"
+
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
@@ -137,6 +139,7 @@ func (s *labelScheduler) Schedule(cluster opt.Cluster) []*operator.Operator {
 				log.Debug("fail to create transfer label reject leader operator", errs.ZapError(err))
 				return nil
 			}
+			op.SetReason(fmt.Sprintf("store %d has the reject-leader label", id))
 			op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 			return []*operator.Operator{op}
 		}
@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"fmt"
 	"math/rand"
 
 	"github.com/pingcap/log"
@@ -133,6 +134,9 @@ func (s *randomMergeScheduler) Schedule(cluster opt.Cluster) []*operator.Operato
 		log.Debug("fail to create merge region operator", errs.ZapError(err))
 		return nil
 	}
+	for _, op := range ops {
+		op.SetReason(fmt.Sprintf("randomly merge region %d into region %d", region.GetID(), target.GetID()))
+	}
 	ops[0].Counters = append(ops[0].Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 	return ops
 }
@@ -15,6 +15,7 @@ package schedulers
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	. "github.com/pingcap/check"
@@ -70,6 +71,7 @@ func (s *testShuffleLeaderSuite) TestShuffle(c *C) {
 		op := sl.Schedule(tc)
 		c.Assert(op, NotNil)
 		c.Assert(op[0].Kind(), Equals, operator.OpLeader|operator.OpAdmin)
+		c.Assert(op[0].Reason(), Equals, fmt.Sprintf("shuffle the leader to the random store %d", op[0].Step(0).(operator.TransferLeader).ToStore))
 	}
 }
 
@@ -101,6 +103,7 @@ func (s *testRejectLeaderSuite) TestRejectLeader(c *C) {
 	c.Assert(err, IsNil)
 	op := sl.Schedule(tc)
 	testutil.CheckTransferLeaderFrom(c, op[0], operator.OpLeader, 1)
+	c.Assert(op[0].Reason(), Equals, "store 1 has the reject-leader label")
 
 	// If store3 is disconnected, transfer leader to store 2.
 	tc.SetStoreDisconnect(3)
@@ -208,6 +211,7 @@ func (s *testShuffleHotRegionSchedulerSuite) checkBalance(c *C, tc *mockcluster.
 	c.Assert(op, NotNil)
 	c.Assert(op[0].Step(1).(operator.PromoteLearner).ToStore, Equals, op[0].Step(op[0].Len()-1).(operator.TransferLeader).ToStore)
 	c.Assert(op[0].Step(1).(operator.PromoteLearner).ToStore, Not(Equals), 6)
+	c.Assert(op[0].Reason(), Equals, fmt.Sprintf("shuffle the hot leader from store 1 to the random store %d", op[0].Step(1).(operator.PromoteLearner).ToStore))
 }
 
 var _ = Suite(&testHotRegionSchedulerSuite{})
@@ -264,6 +268,86 @@ func (s *testEvictLeaderSuite) TestEvictLeader(c *C) {
 	c.Assert(sl.IsScheduleAllowed(tc), IsTrue)
 	op := sl.Schedule(tc)
 	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
+	c.Assert(op[0].Reason(), Equals, "evict the leaders from store 1")
+}
+
+func (s *testEvictLeaderSuite) TestGrantLeader(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+
+	// Add stores 1, 2
+	tc.AddLeaderStore(1, 0)
+	tc.AddLeaderStore(2, 0)
+	// Add region 1 with leader in store 1
+	tc.AddLeaderRegion(1, 1, 2)
+
+	sl, err := schedule.CreateScheduler(GrantLeaderType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(GrantLeaderType, []string{"2"}))
+	c.Assert(err, IsNil)
+	c.Assert(sl.IsScheduleAllowed(tc), IsTrue)
+	op := sl.Schedule(tc)
+	testutil.CheckTransferLeader(c, op[0], operator.OpLeader, 1, 2)
+	c.Assert(op[0].Reason(), Equals, "grant the leaders to store 2")
+}
+
+var _ = Suite(&testGrantHotLeaderSuite{})
+
+type testGrantHotLeaderSuite struct{}
+
+func (s *testGrantHotLeaderSuite) TestGrantHotLeader(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt := config.NewTestOptions()
+	tc := mockcluster.NewCluster(ctx, opt)
+	tc.SetHotRegionCacheHitsThreshold(0)
+
+	// Stores 4 and 5 are the designated stores.
+	tc.AddLabelsStore(1, 0, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(2, 0, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(3, 0, map[string]string{"disk": "hdd"})
+	tc.AddLabelsStore(4, 0, map[string]string{"disk": "nvme"})
+	tc.AddLabelsStore(5, 0, map[string]string{"disk": "nvme"})
+
+	//| region_id | leader_store | follower_store | follower_store | read_bytes |
+	//|-----------|--------------|----------------|----------------|------------|
+	//|     1     |       1      |        2       |       4        |     3MB    |
+	//|     2     |       2      |        3       |       4        |     2MB    |
+	//|     3     |       3      |        1       |       2        |     1MB    |
+	tc.AddRegionWithReadInfo(1, 1, 3*MB*statistics.ReadReportInterval, 0, statistics.ReadReportInterval, []uint64{2, 4})
+	tc.AddRegionWithReadInfo(2, 2, 2*MB*statistics.ReadReportInterval, 0, statistics.ReadReportInterval, []uint64{3, 4})
+	tc.AddRegionWithReadInfo(3, 3, 1*MB*statistics.ReadReportInterval, 0, statistics.ReadReportInterval, []uint64{1, 2})
+
+	_, err := schedule.CreateScheduler(GrantHotLeaderType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(GrantHotLeaderType, []string{"disk"}))
+	c.Assert(err, NotNil)
+	_, err = schedule.CreateScheduler(GrantHotLeaderType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(GrantHotLeaderType, []string{"disk=nvme", "0"}))
+	c.Assert(err, NotNil)
+	sl, err := schedule.CreateScheduler(GrantHotLeaderType, schedule.NewOperatorController(ctx, nil, nil), core.NewStorage(kv.NewMemoryKV()), schedule.ConfigSliceDecoder(GrantHotLeaderType, []string{"disk=nvme", "2"}))
+	c.Assert(err, IsNil)
+	c.Assert(sl.IsScheduleAllowed(tc), IsTrue)
+
+	// The hottest region is granted first.
+	ops := sl.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader|operator.OpHotRegion, 1, 4)
+	c.Assert(ops[0].Reason(), Equals, "grant the hot read leader to store 4")
+
+	// Region 1 is granted, then region 2.
+	tc.AddRegionWithReadInfo(1, 4, 3*MB*statistics.ReadReportInterval, 0, statistics.ReadReportInterval, []uint64{1, 2})
+	ops = sl.Schedule(tc)
+	c.Assert(ops, HasLen, 1)
+	testutil.CheckTransferLeader(c, ops[0], operator.OpLeader|operator.OpHotRegion, 2, 4)
+
+	// Store 4 doesn't accept more hot leaders beyond the limit.
+	conf := sl.(*grantHotLeaderScheduler).conf
+	conf.MaxHotLeaderCount = 1
+	c.Assert(sl.Schedule(tc), HasLen, 0)
+
+	// Region 3 is not in the hottest 2 regions, and it has no peer on the designated stores.
+	conf.MaxHotLeaderCount = 0
+	conf.HotRegionCount = 3
+	tc.AddRegionWithReadInfo(2, 4, 2*MB*statistics.ReadReportInterval, 0, statistics.ReadReportInterval, []uint64{2, 3})
+	c.Assert(sl.Schedule(tc), HasLen, 0)
 }
 
 var _ = Suite(&testShuffleRegionSuite{})
@@ -296,6 +380,7 @@ func (s *testShuffleRegionSuite) TestShuffle(c *C) {
 		op := sl.Schedule(tc)
 		c.Assert(op, NotNil)
 		c.Assert(op[0].Kind(), Equals, operator.OpRegion|operator.OpAdmin)
+		c.Assert(op[0].Reason(), Matches, "shuffle the peer from store [1-4] to the random store [1-4]")
 	}
 }
 
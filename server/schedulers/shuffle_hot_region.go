@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"fmt"
 	"math/rand"
 	"strconv"
 	"time"
@@ -201,6 +202,7 @@ func (s *shuffleHotRegionScheduler) randomSchedule(cluster opt.Cluster, loadDeta
 			log.Debug("fail to create move leader operator", errs.ZapError(err))
 			return nil
 		}
+		op.SetReason(fmt.Sprintf("shuffle the hot leader from store %d to the random store %d", srcStoreID, destStoreID))
 		op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 		return []*operator.Operator{op}
 	}
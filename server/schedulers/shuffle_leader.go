@@ -14,6 +14,8 @@
 package schedulers
 
 import (
+	"fmt"
+
 	"github.com/pingcap/log"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/server/core"
@@ -124,6 +126,7 @@ func (s *shuffleLeaderScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 		log.Debug("fail to create shuffle leader operator", errs.ZapError(err))
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("shuffle the leader to the random store %d", targetStore.GetID()))
 	op.SetPriorityLevel(core.HighPriority)
 	op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 	return []*operator.Operator{op}
@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -121,6 +122,7 @@ func (s *shuffleRegionScheduler) Schedule(cluster opt.Cluster) []*operator.Opera
 		schedulerCounter.WithLabelValues(s.GetName(), "create-operator-fail").Inc()
 		return nil
 	}
+	op.SetReason(fmt.Sprintf("shuffle the peer from store %d to the random store %d", oldPeer.GetStoreId(), newPeer.GetStoreId()))
 	op.Counters = append(op.Counters, schedulerCounter.WithLabelValues(s.GetName(), "new-operator"))
 	op.SetPriorityLevel(core.HighPriority)
 	return []*operator.Operator{op}
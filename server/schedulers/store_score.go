@@ -0,0 +1,115 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/core/storelimit"
+	"github.com/tikv/pd/server/schedule"
+	"github.com/tikv/pd/server/schedule/opt"
+)
+
+// StoreScore contains the components of the score of a store, which is used
+// by a balance scheduler to pick the source and target stores.
+type StoreScore struct {
+	StoreID uint64 `json:"store-id"`
+	// Resource is the leader count or size, or the region size of the store.
+	Resource int64   `json:"resource"`
+	Weight   float64 `json:"weight"`
+	// Available and Capacity affect the region score when the space of the
+	// store is insufficient.
+	Available uint64 `json:"available,omitempty"`
+	Capacity  uint64 `json:"capacity,omitempty"`
+	// OpInfluence is the influence of the pending operators on the resource.
+	OpInfluence int64 `json:"op-influence"`
+	// SourceLimited and TargetLimited report whether the store limit stops the
+	// store from being the source or the target.
+	SourceLimited bool `json:"source-limited"`
+	TargetLimited bool `json:"target-limited"`
+	// Score is the score without the influence of the pending operators, and
+	// ScoreWithInfluence is the one used to sort the stores.
+	Score              float64 `json:"score"`
+	ScoreWithInfluence float64 `json:"score-with-influence"`
+}
+
+// StoreScoresInfo contains the scores of the stores used by a balance scheduler.
+type StoreScoresInfo struct {
+	Scheduler string `json:"scheduler"`
+	// Policy is the schedule policy, "count" or "size".
+	Policy            string        `json:"policy"`
+	TolerantSizeRatio float64       `json:"tolerant-size-ratio"`
+	Stores            []*StoreScore `json:"stores"`
+}
+
+// GetStoreScores computes the score components of the stores used by the
+// balance scheduler of the type. The stores are sorted by the scores with the
+// influence in descending order.
+func GetStoreScores(cluster opt.Cluster, oc *schedule.OperatorController, schedulerType string) (*StoreScoresInfo, error) {
+	var kind core.ScheduleKind
+	switch schedulerType {
+	case BalanceLeaderType:
+		kind = core.NewScheduleKind(core.LeaderKind, oc.GetLeaderSchedulePolicy())
+	case BalanceRegionType:
+		kind = core.NewScheduleKind(core.RegionKind, core.BySize)
+	default:
+		return nil, errors.Errorf("the store scores of scheduler %s are not supported", schedulerType)
+	}
+	opInfluence := oc.GetOpInfluence(cluster)
+	if kind.Resource == core.RegionKind {
+		oc.GetFastOpInfluence(cluster, opInfluence)
+	}
+	plan := newBalancePlan(kind, cluster, opInfluence)
+	opts := cluster.GetOpts()
+
+	info := &StoreScoresInfo{
+		Scheduler:         schedulerType,
+		Policy:            kind.Policy.String(),
+		TolerantSizeRatio: plan.tolerantSizeRatio,
+	}
+	for _, store := range cluster.GetStores() {
+		if store.IsTombstone() {
+			continue
+		}
+		score := &StoreScore{
+			StoreID:     store.GetID(),
+			Weight:      store.ResourceWeight(kind.Resource),
+			OpInfluence: plan.GetOpInfluence(store.GetID()),
+		}
+		switch kind.Resource {
+		case core.LeaderKind:
+			score.Resource = store.ResourceSize(core.LeaderKind)
+			if kind.Policy == core.ByCount {
+				score.Resource = int64(store.GetLeaderCount())
+			}
+			score.Score = store.LeaderScore(kind.Policy, 0)
+			score.ScoreWithInfluence = store.LeaderScore(kind.Policy, score.OpInfluence)
+		case core.RegionKind:
+			score.Resource = store.GetRegionSize()
+			score.Available = store.GetAvailable()
+			score.Capacity = store.GetCapacity()
+			score.SourceLimited = !store.IsAvailable(storelimit.RemovePeer)
+			score.TargetLimited = !store.IsAvailable(storelimit.AddPeer)
+			score.Score = store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), 0)
+			score.ScoreWithInfluence = store.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), score.OpInfluence)
+		}
+		info.Stores = append(info.Stores, score)
+	}
+	sort.Slice(info.Stores, func(i, j int) bool {
+		return info.Stores[i].ScoreWithInfluence > info.Stores[j].ScoreWithInfluence
+	})
+	return info, nil
+}
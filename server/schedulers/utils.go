@@ -23,6 +23,7 @@ import (
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/typeutil"
 	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule"
 	"github.com/tikv/pd/server/schedule/operator"
 	"github.com/tikv/pd/server/schedule/opt"
 	"github.com/tikv/pd/server/statistics"
@@ -34,7 +35,21 @@ const (
 	adjustRatio             float64 = 0.005
 	leaderTolerantSizeRatio float64 = 5.0
 	minTolerantSizeRatio    float64 = 1.0
-	influenceAmp            int64   = 100
+	// maxTolerantSizeRatio caps the adaptive tolerant size ratio, otherwise the
+	// big clusters converge slowly.
+	maxTolerantSizeRatio float64 = 20.0
+	influenceAmp         int64   = 100
+
+	// The cap of the adaptive tolerant size ratio is raised for the clusters with
+	// fewer stores than smallClusterStoreCount to avoid thrashing.
+	smallClusterStoreCount = 5
+	// The regions larger than standardRegionSize lower the adaptive tolerant size
+	// ratio, since the tolerant resource is proportional to the region size.
+	standardRegionSize int64 = 96
+	// The region schedule limit lower than standardRegionScheduleLimit lowers the
+	// cap of the adaptive tolerant size ratio, since the slow scheduling makes a
+	// large tolerance stall the convergence.
+	standardRegionScheduleLimit uint64 = 2048
 )
 
 type balancePlan struct {
@@ -49,6 +64,10 @@ type balancePlan struct {
 
 	sourceScore float64
 	targetScore float64
+
+	// leaderLoadFactors weigh the leader scores of the stores by their loads,
+	// the stores without a factor are not weighted.
+	leaderLoadFactors map[uint64]float64
 }
 
 func newBalancePlan(kind core.ScheduleKind, cluster opt.Cluster, opInfluence operator.OpInfluence) *balancePlan {
@@ -64,6 +83,15 @@ func (p *balancePlan) GetOpInfluence(storeID uint64) int64 {
 	return p.opInfluence.GetStoreInfluence(storeID).ResourceProperty(p.kind)
 }
 
+// leaderScore returns the leader score of the store weighted by its load factor.
+func (p *balancePlan) leaderScore(store *core.StoreInfo, delta int64) float64 {
+	score := store.LeaderScore(p.kind.Policy, delta)
+	if factor, ok := p.leaderLoadFactors[store.GetID()]; ok {
+		score *= factor
+	}
+	return score
+}
+
 func (p *balancePlan) SourceStoreID() uint64 {
 	return p.source.GetID()
 }
@@ -106,8 +134,8 @@ func (p *balancePlan) shouldBalance(scheduleName string) bool {
 	switch p.kind.Resource {
 	case core.LeaderKind:
 		sourceDelta, targetDelta := sourceInfluence-tolerantResource, targetInfluence+tolerantResource
-		p.sourceScore = p.source.LeaderScore(p.kind.Policy, sourceDelta)
-		p.targetScore = p.target.LeaderScore(p.kind.Policy, targetDelta)
+		p.sourceScore = p.leaderScore(p.source, sourceDelta)
+		p.targetScore = p.leaderScore(p.target, targetDelta)
 	case core.RegionKind:
 		sourceDelta, targetDelta := sourceInfluence*influenceAmp-tolerantResource, targetInfluence*influenceAmp+tolerantResource
 		p.sourceScore = p.source.RegionScore(opts.GetRegionScoreFormulaVersion(), opts.GetHighSpaceRatio(), opts.GetLowSpaceRatio(), sourceDelta)
@@ -155,20 +183,67 @@ func adjustTolerantRatio(cluster opt.Cluster, kind core.ScheduleKind) float64 {
 	}
 
 	if tolerantSizeRatio == 0 {
-		var maxRegionCount float64
-		stores := cluster.GetStores()
-		for _, store := range stores {
-			regionCount := float64(cluster.GetStoreRegionCount(store.GetID()))
-			if maxRegionCount < regionCount {
-				maxRegionCount = regionCount
-			}
+		tolerantSizeRatio = GetTolerantRatioInfo(cluster).AdaptiveRatio
+	}
+	return tolerantSizeRatio
+}
+
+// TolerantRatioInfo contains the inputs and the result of the adaptive tolerant
+// size ratio of the region balance.
+type TolerantRatioInfo struct {
+	// ConfiguredRatio is the `tolerant-size-ratio` config, it overrides the
+	// adaptive ratio if it is not 0.
+	ConfiguredRatio     float64 `json:"configured-ratio"`
+	StoreCount          int     `json:"store-count"`
+	MaxRegionCount      int     `json:"max-region-count"`
+	AverageRegionSize   int64   `json:"average-region-size"`
+	RegionScheduleLimit uint64  `json:"region-schedule-limit"`
+	// AdaptiveRatio = clamp(MaxRegionCount * 0.005 * SizeFactor, 1, 20 * StoreFactor * SpeedFactor).
+	SizeFactor    float64 `json:"size-factor"`
+	StoreFactor   float64 `json:"store-factor"`
+	SpeedFactor   float64 `json:"speed-factor"`
+	AdaptiveRatio float64 `json:"adaptive-ratio"`
+	// Ratio is the ratio in effect.
+	Ratio float64 `json:"ratio"`
+}
+
+// GetTolerantRatioInfo computes the adaptive tolerant size ratio of the region
+// balance from the cluster size, the region size and the scheduling speed.
+func GetTolerantRatioInfo(cluster opt.Cluster) *TolerantRatioInfo {
+	info := &TolerantRatioInfo{
+		ConfiguredRatio:     cluster.GetOpts().GetTolerantSizeRatio(),
+		AverageRegionSize:   cluster.GetAverageRegionSize(),
+		RegionScheduleLimit: cluster.GetOpts().GetRegionScheduleLimit(),
+		SizeFactor:          1,
+		StoreFactor:         1,
+		SpeedFactor:         1,
+	}
+	for _, store := range cluster.GetStores() {
+		if store.IsUp() {
+			info.StoreCount++
 		}
-		tolerantSizeRatio = maxRegionCount * adjustRatio
-		if tolerantSizeRatio < minTolerantSizeRatio {
-			tolerantSizeRatio = minTolerantSizeRatio
+		if regionCount := cluster.GetStoreRegionCount(store.GetID()); info.MaxRegionCount < regionCount {
+			info.MaxRegionCount = regionCount
 		}
 	}
-	return tolerantSizeRatio
+	if info.AverageRegionSize > standardRegionSize {
+		info.SizeFactor = float64(standardRegionSize) / float64(info.AverageRegionSize)
+	}
+	if info.StoreCount > 0 && info.StoreCount < smallClusterStoreCount {
+		info.StoreFactor = float64(smallClusterStoreCount) / float64(info.StoreCount)
+	}
+	if info.RegionScheduleLimit < standardRegionScheduleLimit {
+		info.SpeedFactor = float64(info.RegionScheduleLimit) / float64(standardRegionScheduleLimit)
+	}
+
+	ratio := float64(info.MaxRegionCount) * adjustRatio * info.SizeFactor
+	ratio = math.Min(ratio, maxTolerantSizeRatio*info.StoreFactor*info.SpeedFactor)
+	info.AdaptiveRatio = math.Max(ratio, minTolerantSizeRatio)
+	info.Ratio = info.AdaptiveRatio
+	if info.ConfiguredRatio != 0 {
+		info.Ratio = info.ConfiguredRatio
+	}
+	return info
 }
 
 func adjustBalanceLimit(cluster opt.Cluster, kind core.ResourceKind) uint64 {
@@ -183,6 +258,12 @@ func adjustBalanceLimit(cluster opt.Cluster, kind core.ResourceKind) uint64 {
 	return typeutil.MaxUint64(1, uint64(limit))
 }
 
+// notRecentlyMovedRegion returns a function that checks if a region is not
+// moved within the region move cooldown.
+func notRecentlyMovedRegion(oc *schedule.OperatorController) func(*core.RegionInfo) bool {
+	return func(region *core.RegionInfo) bool { return !oc.IsRegionRecentlyMoved(region.GetID()) }
+}
+
 func getKeyRanges(args []string) ([]core.KeyRange, error) {
 	var ranges []core.KeyRange
 	for len(args) > 1 {
@@ -16,6 +16,7 @@ package server
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -23,6 +24,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +32,7 @@ import (
 	"time"
 
 	"github.com/coreos/go-semver/semver"
+	"github.com/docker/go-units"
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
 	"github.com/pingcap/errors"
@@ -39,9 +42,11 @@ import (
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/log"
 	"github.com/pingcap/sysutil"
+	"github.com/tikv/pd/pkg/diskguard"
 	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/grpcutil"
+	"github.com/tikv/pd/pkg/idempotency"
 	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/pkg/systimemon"
 	"github.com/tikv/pd/pkg/typeutil"
@@ -50,6 +55,7 @@ import (
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/encryptionkm"
 	"github.com/tikv/pd/server/id"
+	"github.com/tikv/pd/server/keyspace"
 	"github.com/tikv/pd/server/kv"
 	"github.com/tikv/pd/server/member"
 	syncer "github.com/tikv/pd/server/region_syncer"
@@ -70,6 +76,8 @@ const (
 	etcdTimeout           = time.Second * 3
 	serverMetricsInterval = time.Minute
 	leaderTickInterval    = 50 * time.Millisecond
+	// alertDiskSpace is the type of the alert of the insufficient disk space.
+	alertDiskSpace = "disk-space"
 	// pdRootPath for all pd servers.
 	pdRootPath      = "/pd"
 	pdAPIPrefix     = "/pd/"
@@ -89,6 +97,8 @@ type Server struct {
 
 	// Server state.
 	isServing int64
+	// isReady is set once the server is ready to serve, see IsReady.
+	isReady int64
 
 	// Server start timestamp
 	startTimestamp int64
@@ -98,6 +108,10 @@ type Server struct {
 	etcdCfg        *embed.Config
 	persistOptions *config.PersistOptions
 	handler        *Handler
+	// certCN is the CN of the certificate of PD itself.
+	certCN string
+	// grpcInterceptors are the extra interceptors of the PD gRPC service.
+	grpcInterceptors *GRPCInterceptors
 
 	ctx              context.Context
 	serverLoopCtx    context.Context
@@ -122,6 +136,8 @@ type Server struct {
 	encryptionKeyManager *encryptionkm.KeyManager
 	// for storage operation.
 	storage *core.Storage
+	// diskGuard watches the free space of the data dir.
+	diskGuard *diskguard.Guard
 	// for basicCluster operation.
 	basicCluster *core.BasicCluster
 	// for tso.
@@ -130,6 +146,8 @@ type Server struct {
 	cluster *cluster.RaftCluster
 	// For async region heartbeat.
 	hbStreams *hbstream.HeartbeatStreams
+	// for keyspace management.
+	keyspaceManager *keyspace.Manager
 	// Zap logger
 	lg       *zap.Logger
 	logProps *log.ZapProperties
@@ -143,6 +161,13 @@ type Server struct {
 
 	// Store as map[string]*grpc.ClientConn
 	clientConns sync.Map
+
+	// regionResponseCache caches the responses of GetRegionByID.
+	regionResponseCache *regionResponseCache
+	// idempotentRequests remembers the results of the mutating requests with
+	// the idempotency keys.
+	idempotentRequests *idempotency.Cache
+	tsoStats           *tsoStatsRecorder
 }
 
 // HandlerBuilder builds a server HTTP handler.
@@ -159,6 +184,8 @@ type ServiceGroup struct {
 const (
 	// CorePath the core group, is at REST path `/pd/api/v1`.
 	CorePath = "/pd/api/v1"
+	// CoreV2Path the v2 API of the core group, is at REST path `/pd/api/v2`.
+	CoreV2Path = "/pd/api/v2"
 	// ExtensionsPath the named groups are REST at `/pd/apis/{GROUP_NAME}/{Version}`.
 	ExtensionsPath = "/pd/apis"
 )
@@ -202,6 +229,7 @@ func combineBuilderServerHTTPService(ctx context.Context, svr *Server, serviceBu
 			// and finally apiService is registered in userHandlers.
 			router.PathPrefix(pathPrefix).Handler(handler)
 			if info.IsCore {
+				router.PathPrefix(CoreV2Path).Handler(handler)
 				// Deprecated
 				router.Path("/pd/health").Handler(handler)
 				// Deprecated
@@ -223,16 +251,25 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 	rand.Seed(time.Now().UnixNano())
 
 	s := &Server{
-		cfg:               cfg,
-		persistOptions:    config.NewPersistOptions(cfg),
-		member:            &member.Member{},
-		ctx:               ctx,
-		startTimestamp:    time.Now().Unix(),
-		DiagnosticsServer: sysutil.NewDiagnosticsServer(cfg.Log.File.Filename),
+		cfg:                 cfg,
+		persistOptions:      config.NewPersistOptions(cfg),
+		member:              &member.Member{},
+		ctx:                 ctx,
+		startTimestamp:      time.Now().Unix(),
+		DiagnosticsServer:   sysutil.NewDiagnosticsServer(cfg.Log.File.Filename),
+		regionResponseCache: newRegionResponseCache(defaultRegionResponseCacheSize),
+		idempotentRequests:  idempotency.NewCache(idempotency.DefaultTTL, idempotency.DefaultMaxEntries),
+		tsoStats:            newTSOStatsRecorder(),
 	}
 
 	s.handler = newHandler(s)
 
+	certCN, err := cfg.Security.LoadCommonName()
+	if err != nil {
+		return nil, err
+	}
+	s.certCN = certCN
+
 	// Adjust etcd config.
 	etcdCfg, err := s.cfg.GenEmbedEtcdConfig()
 	if err != nil {
@@ -246,8 +283,11 @@ func CreateServer(ctx context.Context, cfg *config.Config, serviceBuilders ...Ha
 		etcdCfg.UserHandlers = userHandlers
 	}
 	etcdCfg.ServiceRegister = func(gs *grpc.Server) {
-		pdpb.RegisterPDServer(gs, s)
+		s.registerPDService(gs)
 		diagnosticspb.RegisterDiagnosticsServer(gs, s)
+		if cfg.EnableGRPCReflection {
+			grpcutil.RegisterReflection(gs)
+		}
 	}
 	s.etcdCfg = etcdCfg
 	if EnableZap {
@@ -292,6 +332,7 @@ func (s *Server) startEtcd(ctx context.Context) error {
 	case <-newCtx.Done():
 		return errs.ErrCancelStartEtcd.FastGenByArgs()
 	}
+	checkAdvertiseUrls(s.cfg)
 
 	endpoints := []string{s.etcdCfg.ACUrls[0].String()}
 	log.Info("create etcd v3 client", zap.Strings("endpoints", endpoints), zap.Reflect("cert", s.cfg.Security))
@@ -376,7 +417,14 @@ func (s *Server) startServer(ctx context.Context) error {
 		return err
 	}
 	s.encryptionKeyManager = encryptionKeyManager
-	kvBase := kv.NewEtcdKVBase(s.client, s.rootPath)
+	// The writes made by a stale leader are rejected by the fencing token, as
+	// well as the writes made by a member which is not the leader. The member
+	// metadata written without the leadership, e.g. the deploy path, is written
+	// by the etcd client directly, which is not fenced.
+	kvBase := kv.NewFencedEtcdKVBase(s.client, s.rootPath, func() []clientv3.Cmp {
+		return s.member.GetLeadership().FenceCmps()
+	})
+	s.diskGuard = diskguard.NewGuard(s.cfg.DataDir, uint64(s.cfg.DiskGuard.WarningSpace), uint64(s.cfg.DiskGuard.CriticalSpace))
 	path := filepath.Join(s.cfg.DataDir, "region-meta")
 	regionStorage, err := core.NewRegionStorage(ctx, path, encryptionKeyManager)
 	if err != nil {
@@ -387,10 +435,12 @@ func (s *Server) startServer(ctx context.Context) error {
 		kvBase,
 		core.WithRegionStorage(regionStorage),
 		core.WithEncryptionKeyManager(encryptionKeyManager),
+		core.WithDiskGuard(s.diskGuard),
 	)
 	s.basicCluster = core.NewBasicCluster()
 	s.cluster = cluster.NewRaftCluster(ctx, s.GetClusterRootPath(), s.clusterID, syncer.NewRegionSyncer(s), s.client, s.httpClient)
 	s.hbStreams = hbstream.NewHeartbeatStreams(ctx, s.clusterID, s.cluster)
+	s.keyspaceManager = keyspace.NewManager(s.storage)
 
 	// Run callbacks
 	for _, cb := range s.startCallbacks {
@@ -482,6 +532,9 @@ func (s *Server) Run() error {
 	}
 
 	s.startServerLoop(s.ctx)
+	if !s.cfg.InitWait {
+		s.markReady()
+	}
 
 	return nil
 }
@@ -498,12 +551,18 @@ func (s *Server) LoopContext() context.Context {
 
 func (s *Server) startServerLoop(ctx context.Context) {
 	s.serverLoopCtx, s.serverLoopCancel = context.WithCancel(ctx)
-	s.serverLoopWg.Add(5)
+	s.serverLoopWg.Add(7)
 	go s.leaderLoop()
 	go s.etcdLeaderLoop()
+	go s.leaderFitnessLoop()
 	go s.serverMetricsLoop()
 	go s.tsoAllocatorLoop()
 	go s.encryptionKeyManagerLoop()
+	go s.diskGuardLoop()
+	if s.cfg.InitWait {
+		s.serverLoopWg.Add(1)
+		go s.readinessLoop()
+	}
 }
 
 func (s *Server) stopServerLoop() {
@@ -550,6 +609,17 @@ func (s *Server) encryptionKeyManagerLoop() {
 	log.Info("server is closed, exist encryption key manager loop")
 }
 
+// diskGuardLoop is used to check the free space of the data dir.
+func (s *Server) diskGuardLoop() {
+	defer logutil.LogPanic()
+	defer s.serverLoopWg.Done()
+
+	ctx, cancel := context.WithCancel(s.serverLoopCtx)
+	defer cancel()
+	s.diskGuard.Run(ctx, s.cfg.DiskGuard.CheckInterval.Duration)
+	log.Info("server is closed, exit disk guard loop")
+}
+
 func (s *Server) collectEtcdStateMetrics() {
 	etcdStateGauge.WithLabelValues("term").Set(float64(s.member.Etcd().Server.Term()))
 	etcdStateGauge.WithLabelValues("appliedIndex").Set(float64(s.member.Etcd().Server.AppliedIndex()))
@@ -703,6 +773,11 @@ func (s *Server) GetStorage() *core.Storage {
 	return s.storage
 }
 
+// GetKeyspaceManager returns the keyspace manager of server.
+func (s *Server) GetKeyspaceManager() *keyspace.Manager {
+	return s.keyspaceManager
+}
+
 // SetStorage changes the storage only for test purpose.
 // When we use it, we should prevent calling GetStorage, otherwise, it may cause a data race problem.
 func (s *Server) SetStorage(storage *core.Storage) {
@@ -729,6 +804,37 @@ func (s *Server) GetAllocator() id.Allocator {
 	return s.idAllocator
 }
 
+// GetDiskGuard returns the guard of the free space of the data dir.
+func (s *Server) GetDiskGuard() *diskguard.Guard {
+	return s.diskGuard
+}
+
+// GetAlerts returns the recent alerts of the server, including the alerts of
+// updating the TSO and the alert of the insufficient disk space. The latest
+// one comes first.
+func (s *Server) GetAlerts() []tso.Alert {
+	alerts := s.tsoAllocatorManager.GetAlerts()
+	level := s.diskGuard.Level()
+	if level == diskguard.LevelNormal {
+		return alerts
+	}
+	severity := tso.AlertWarning
+	if level == diskguard.LevelCritical {
+		severity = tso.AlertCritical
+	}
+	status := s.diskGuard.GetStatus()
+	alerts = append(alerts, tso.Alert{
+		Type:      alertDiskSpace,
+		Severity:  severity,
+		Message:   fmt.Sprintf("the free space of %s is %s, which is below the %s threshold", status.Dir, units.BytesSize(float64(status.Available)), level),
+		Count:     1,
+		FirstSeen: status.Since,
+		LastSeen:  status.LastCheck,
+	})
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].LastSeen.After(alerts[j].LastSeen) })
+	return alerts
+}
+
 // GetTSOAllocatorManager returns the manager of TSO Allocator.
 func (s *Server) GetTSOAllocatorManager() *tso.AllocatorManager {
 	return s.tsoAllocatorManager
@@ -783,6 +889,24 @@ func (s *Server) GetConfig() *config.Config {
 	return cfg
 }
 
+// GetPersistedConfig returns the config items persisted in the storage. It
+// returns nil if the config has not been persisted yet.
+func (s *Server) GetPersistedConfig() (map[string]interface{}, error) {
+	cfg := make(map[string]interface{})
+	isExist, err := s.GetStorage().LoadConfig(&cfg)
+	if err != nil || !isExist {
+		return nil, err
+	}
+	// Only part of the config is persisted, the others are zero values.
+	persisted := make(map[string]interface{})
+	for _, key := range []string{"schedule", "replication", "pd-server", "replication-mode", "label-property", "cluster-version"} {
+		if v, ok := cfg[key]; ok {
+			persisted[key] = v
+		}
+	}
+	return persisted, nil
+}
+
 // GetScheduleConfig gets the balance config information.
 func (s *Server) GetScheduleConfig() *config.ScheduleConfig {
 	return s.persistOptions.GetScheduleConfig().Clone()
@@ -811,6 +935,90 @@ func (s *Server) SetScheduleConfig(cfg config.ScheduleConfig) error {
 	return nil
 }
 
+// GetScheduleProfiles returns the builtin and the custom schedule profiles,
+// ordered by name.
+func (s *Server) GetScheduleProfiles() ([]*config.ScheduleProfile, error) {
+	profiles := config.GetBuiltinProfiles()
+	var err error
+	loadErr := s.storage.LoadScheduleProfiles(func(k, v string) {
+		profile := &config.ScheduleProfile{}
+		if e := json.Unmarshal([]byte(v), profile); e != nil {
+			err = errs.ErrJSONUnmarshal.Wrap(e).GenWithStackByArgs()
+			return
+		}
+		profiles = append(profiles, profile)
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// GetScheduleProfile returns the schedule profile with the name.
+func (s *Server) GetScheduleProfile(name string) (*config.ScheduleProfile, error) {
+	profiles, err := s.GetScheduleProfiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, errs.ErrProfileNotFound.FastGenByArgs(name)
+}
+
+// SaveScheduleProfile creates or updates a custom schedule profile. The
+// builtin profiles cannot be changed.
+func (s *Server) SaveScheduleProfile(profile *config.ScheduleProfile) error {
+	if config.GetBuiltinProfile(profile.Name) != nil {
+		return errs.ErrProfileBuiltin.FastGenByArgs(profile.Name)
+	}
+	profile.Builtin = false
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	if err := s.storage.SaveScheduleProfile(profile.Name, profile); err != nil {
+		return err
+	}
+	log.Info("schedule profile is saved", zap.String("name", profile.Name), zap.Reflect("items", profile.Items))
+	return nil
+}
+
+// DeleteScheduleProfile deletes a custom schedule profile.
+func (s *Server) DeleteScheduleProfile(name string) error {
+	if config.GetBuiltinProfile(name) != nil {
+		return errs.ErrProfileBuiltin.FastGenByArgs(name)
+	}
+	if _, err := s.GetScheduleProfile(name); err != nil {
+		return err
+	}
+	if err := s.storage.DeleteScheduleProfile(name); err != nil {
+		return err
+	}
+	log.Info("schedule profile is deleted", zap.String("name", name))
+	return nil
+}
+
+// ApplyScheduleProfile applies all the items of the schedule profile to the
+// schedule config at once.
+func (s *Server) ApplyScheduleProfile(name string) error {
+	profile, err := s.GetScheduleProfile(name)
+	if err != nil {
+		return err
+	}
+	cfg := s.GetScheduleConfig()
+	if err := profile.ApplyTo(cfg); err != nil {
+		return err
+	}
+	cfg.Profile = name
+	return s.SetScheduleConfig(*cfg)
+}
+
 // GetReplicationConfig get the replication config.
 func (s *Server) GetReplicationConfig() *config.ReplicationConfig {
 	return s.persistOptions.GetReplicationConfig().Clone()
@@ -822,6 +1030,11 @@ func (s *Server) SetReplicationConfig(cfg config.ReplicationConfig) error {
 		return err
 	}
 	old := s.persistOptions.GetReplicationConfig()
+	if cfg.MaxReplicas != old.MaxReplicas {
+		if rc := s.GetRaftCluster(); rc != nil && rc.IsReplicasRolloutInProgress() {
+			return errs.ErrReplicasRolloutInProgress.FastGenByArgs()
+		}
+	}
 	if cfg.EnablePlacementRules != old.EnablePlacementRules {
 		raftCluster := s.GetRaftCluster()
 		if raftCluster == nil {
@@ -1019,6 +1232,17 @@ func (s *Server) GetClusterVersion() semver.Version {
 	return *s.persistOptions.GetClusterVersion()
 }
 
+// GetIdempotentRequests returns the results of the mutating requests with the
+// idempotency keys.
+func (s *Server) GetIdempotentRequests() *idempotency.Cache {
+	return s.idempotentRequests
+}
+
+// GetTSOStats returns the statistics of the TSO requests handled by the server.
+func (s *Server) GetTSOStats() *TSOStats {
+	return s.tsoStats.getStats()
+}
+
 // GetTLSConfig get the security config.
 func (s *Server) GetTLSConfig() *grpcutil.TLSConfig {
 	return &s.cfg.Security.TLSConfig
@@ -1034,6 +1258,86 @@ func (s *Server) GetClusterRootPath() string {
 	return path.Join(s.rootPath, "raft")
 }
 
+// RuleChange is a change of the placement rules or rule groups.
+type RuleChange struct {
+	// Revision is the etcd revision of the change.
+	Revision int64 `json:"revision"`
+	// Type is either "put" or "delete".
+	Type string `json:"type"`
+	// Kind is either "rule" or "rule-group".
+	Kind string `json:"kind"`
+	// Key is the storage key of the rule or rule group.
+	Key string `json:"key"`
+	// Value is the rule or rule group after a put change.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// WatchPlacementRules waits for the changes of the placement rules and rule
+// groups after the given revision, and returns the changes with the revision
+// to resume from. If the revision is not positive, it returns the current
+// revision immediately, so that the caller can list the rules and then watch
+// from the returned revision without missing any change. It returns no change
+// if the context is done before any change happens.
+func (s *Server) WatchPlacementRules(ctx context.Context, revision int64) (int64, []*RuleChange, error) {
+	rulesPrefix := path.Join(s.rootPath, core.RulesPrefix()) + "/"
+	ruleGroupsPrefix := path.Join(s.rootPath, core.RuleGroupsPrefix()) + "/"
+	if revision <= 0 {
+		resp, err := etcdutil.EtcdKVGet(s.client, rulesPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+		if err != nil {
+			return 0, nil, err
+		}
+		return resp.Header.Revision, nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watcher := clientv3.NewWatcher(s.client)
+	defer watcher.Close()
+	// Watch the common prefix of rules and rule groups with a single watcher
+	// to keep the changes in order.
+	watchChan := watcher.Watch(ctx, path.Join(s.rootPath, "rule"), clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+	for {
+		select {
+		case <-ctx.Done():
+			return revision, nil, nil
+		case resp, ok := <-watchChan:
+			if !ok {
+				return revision, nil, nil
+			}
+			if resp.CompactRevision != 0 {
+				return 0, nil, errs.ErrRuleCompacted.FastGenByArgs(revision, resp.CompactRevision)
+			}
+			if err := resp.Err(); err != nil {
+				return 0, nil, errs.ErrEtcdWatcherCancel.Wrap(err).GenWithStackByCause()
+			}
+			var changes []*RuleChange
+			for _, event := range resp.Events {
+				revision = event.Kv.ModRevision
+				key := string(event.Kv.Key)
+				change := &RuleChange{Revision: event.Kv.ModRevision}
+				switch {
+				case strings.HasPrefix(key, rulesPrefix):
+					change.Kind, change.Key = "rule", strings.TrimPrefix(key, rulesPrefix)
+				case strings.HasPrefix(key, ruleGroupsPrefix):
+					change.Kind, change.Key = "rule-group", strings.TrimPrefix(key, ruleGroupsPrefix)
+				default:
+					continue
+				}
+				if event.Type == clientv3.EventTypeDelete {
+					change.Type = "delete"
+				} else {
+					change.Type = "put"
+					change.Value = json.RawMessage(event.Kv.Value)
+				}
+				changes = append(changes, change)
+			}
+			if len(changes) > 0 {
+				return revision, changes, nil
+			}
+		}
+	}
+}
+
 // GetRaftCluster gets Raft cluster.
 // If cluster has not been bootstrapped, return nil.
 func (s *Server) GetRaftCluster() *cluster.RaftCluster {
@@ -1092,6 +1396,18 @@ func (s *Server) SetLogLevel(level string) error {
 	return nil
 }
 
+// SetRedactInfoLogMode sets the mode to redact the user data in the log.
+func (s *Server) SetRedactInfoLogMode(mode string) error {
+	m, err := logutil.ParseRedactMode(mode)
+	if err != nil {
+		return err
+	}
+	s.cfg.Security.RedactInfoLogMode = string(m)
+	logutil.SetRedactMode(m)
+	log.Warn("redact info log mode changed", zap.String("mode", string(m)))
+	return nil
+}
+
 func isLevelLegal(level string) bool {
 	switch strings.ToLower(level) {
 	case "fatal", "error", "warn", "warning", "debug", "info":
@@ -1259,6 +1575,9 @@ func (s *Server) campaignLeader() {
 		log.Error("failed to sync id from etcd", errs.ZapError(err))
 		return
 	}
+	if rc := s.GetRaftCluster(); rc != nil {
+		rc.EnsureIDAboveObserved()
+	}
 	// EnableLeader to accept the remaining service, such as GetStore, GetRegion.
 	s.member.EnableLeader()
 	// Check the cluster dc-location after the PD leader is elected.
@@ -1313,6 +1632,50 @@ func (s *Server) etcdLeaderLoop() {
 	}
 }
 
+func (s *Server) leaderFitnessLoop() {
+	defer logutil.LogPanic()
+	defer s.serverLoopWg.Done()
+
+	ctx, cancel := context.WithCancel(s.serverLoopCtx)
+	defer cancel()
+	ticker := time.NewTicker(member.LeaderFitnessCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cfg, err := s.loadPDServerConfig()
+			if err != nil {
+				log.Error("failed to load the pd server config", errs.ZapError(err))
+				continue
+			}
+			s.member.CheckLeaderFitness(ctx, cfg)
+		case <-ctx.Done():
+			log.Info("server is closed, exit leader fitness loop")
+			return
+		}
+	}
+}
+
+// loadPDServerConfig returns the latest PD server config. The persisted options
+// of a follower are only reloaded when it becomes the leader, so a follower
+// loads the config from etcd.
+func (s *Server) loadPDServerConfig() (*config.PDServerConfig, error) {
+	if s.member.IsLeader() {
+		return s.persistOptions.GetPDServerConfig(), nil
+	}
+	cfg := &config.Config{}
+	cfg.Adjust(nil, true)
+	isExist, err := s.storage.LoadConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !isExist {
+		return s.persistOptions.GetPDServerConfig(), nil
+	}
+	cfg.PDServerCfg.MigrateDeprecatedFlags()
+	return &cfg.PDServerCfg, nil
+}
+
 func (s *Server) reloadConfigFromKV() error {
 	err := s.persistOptions.Reload(s.storage)
 	if err != nil {
@@ -21,9 +21,11 @@ import (
 	"testing"
 
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/pkg/etcdutil"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/core"
 	"go.etcd.io/etcd/embed"
 	"go.etcd.io/etcd/pkg/types"
 	"go.uber.org/goleak"
@@ -95,6 +97,59 @@ func (s *testLeaderServerSuite) TearDownSuite(c *C) {
 	}
 }
 
+func (s *testLeaderServerSuite) TestLoadPDServerConfig(c *C) {
+	leader := mustWaitLeader(c, s.serverList())
+	cfg := leader.GetPersistOptions().GetPDServerConfig().Clone()
+	cfg.EnableLeaderFitnessTransfer = true
+	c.Assert(leader.SetPDServerConfig(*cfg), IsNil)
+	defer func() {
+		cfg.EnableLeaderFitnessTransfer = false
+		c.Assert(leader.SetPDServerConfig(*cfg), IsNil)
+	}()
+
+	// The followers load the latest config from etcd.
+	for _, svr := range s.svrs {
+		loaded, err := svr.loadPDServerConfig()
+		c.Assert(err, IsNil)
+		c.Assert(loaded.EnableLeaderFitnessTransfer, IsTrue)
+		if svr != leader {
+			c.Assert(svr.GetPersistOptions().GetPDServerConfig().EnableLeaderFitnessTransfer, IsFalse)
+		}
+	}
+}
+
+func (s *testLeaderServerSuite) TestFollowerHeader(c *C) {
+	leader := mustWaitLeader(c, s.serverList())
+	var follower *Server
+	for _, svr := range s.svrs {
+		if svr != leader {
+			follower = svr
+			break
+		}
+	}
+	// The follower serves the region lookups once it syncs with the leader.
+	testutil.WaitUntil(c, func(c *C) bool {
+		return follower.followerHeader().GetError() == nil
+	})
+
+	syncer := follower.cluster.GetRegionSyncer()
+	syncer.StopSyncWithLeader()
+	defer syncer.StartSyncWithLeader(leader.GetAddr())
+	header := follower.followerHeader()
+	c.Assert(header.GetError(), NotNil)
+	c.Assert(header.GetError().GetMessage(), Equals, "region syncer is not running")
+	resp := regionResponse(header, core.NewRegionInfo(&metapb.Region{Id: 1}, nil))
+	c.Assert(resp.GetRegion(), IsNil)
+}
+
+func (s *testLeaderServerSuite) serverList() []*Server {
+	svrs := make([]*Server, 0, len(s.svrs))
+	for _, svr := range s.svrs {
+		svrs = append(svrs, svr)
+	}
+	return svrs
+}
+
 var _ = Suite(&testServerSuite{})
 
 type testServerSuite struct{}
@@ -0,0 +1,188 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/server/core"
+)
+
+// BucketsReport is the flow of the buckets of a region reported by the leader.
+// A bucket is a sub range of the region, the i-th bucket is [Keys[i], Keys[i+1]).
+type BucketsReport struct {
+	RegionID uint64
+	// Version is the version of the bucket boundaries. A report with a lower
+	// version than the cached one is ignored.
+	Version uint64
+	Keys    [][]byte
+	// Flows is the flow of each bucket in the report interval, indexed by RegionStatKind.
+	Flows    [RegionStatCount][]uint64
+	Interval time.Duration
+}
+
+// BucketsStat is the hot statistics of the buckets of a region.
+type BucketsStat struct {
+	RegionID uint64
+	Version  uint64
+	Keys     [][]byte
+	// Loads is the flow rate of each bucket, indexed by RegionStatKind.
+	Loads      [RegionStatCount][]float64
+	UpdateTime time.Time
+}
+
+// BucketCount returns the count of the buckets.
+func (s *BucketsStat) BucketCount() int {
+	return len(s.Keys) - 1
+}
+
+// Skew returns the ratio between the load of the hottest bucket and the average
+// load of all buckets. A high skew means the flow concentrates on a small sub range,
+// thus moving the region helps little and splitting is preferred.
+func (s *BucketsStat) Skew(kind RegionStatKind) float64 {
+	loads := s.Loads[kind]
+	if len(loads) == 0 {
+		return 0
+	}
+	var sum, max float64
+	for _, load := range loads {
+		sum += load
+		if load > max {
+			max = load
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return max / (sum / float64(len(loads)))
+}
+
+// SplitKey returns the bucket boundary which splits the load of the kind most
+// evenly. It returns nil if there is no such boundary.
+func (s *BucketsStat) SplitKey(kind RegionStatKind) []byte {
+	loads := s.Loads[kind]
+	if len(loads) < 2 {
+		return nil
+	}
+	var sum float64
+	for _, load := range loads {
+		sum += load
+	}
+	if sum == 0 {
+		return nil
+	}
+	var (
+		acc     float64
+		bestKey []byte
+		bestGap = math.MaxFloat64
+	)
+	// The boundaries between buckets are Keys[1:len(Keys)-1]. Among the boundaries
+	// with the same gap, the one closest to the hot buckets is preferred.
+	for i := 0; i < len(loads)-1; i++ {
+		acc += loads[i]
+		gap := math.Abs(acc - (sum - acc))
+		if gap < bestGap || (gap == bestGap && acc <= sum/2) {
+			bestGap, bestKey = gap, s.Keys[i+1]
+		}
+	}
+	return bestKey
+}
+
+// HotBucketCache holds the hot statistics of the buckets of regions.
+type HotBucketCache struct {
+	sync.RWMutex
+	buckets map[uint64]*BucketsStat
+}
+
+// NewHotBucketCache creates a new bucket cache.
+func NewHotBucketCache() *HotBucketCache {
+	return &HotBucketCache{
+		buckets: make(map[uint64]*BucketsStat),
+	}
+}
+
+// Update updates the bucket statistics according to the report.
+func (c *HotBucketCache) Update(report *BucketsReport) error {
+	if len(report.Keys) < 2 {
+		return errors.Errorf("region %d reports %d bucket keys, at least 2 keys are required", report.RegionID, len(report.Keys))
+	}
+	if report.Interval <= 0 {
+		return errors.Errorf("region %d reports buckets with invalid interval %v", report.RegionID, report.Interval)
+	}
+	// The keys must be strictly increasing, otherwise a boundary may fall on
+	// the start key of the region or out of the region. The empty last key
+	// means the end of the key space.
+	for i := 1; i < len(report.Keys); i++ {
+		if i == len(report.Keys)-1 && len(report.Keys[i]) == 0 {
+			continue
+		}
+		if bytes.Compare(report.Keys[i-1], report.Keys[i]) >= 0 {
+			return errors.Errorf("region %d reports bucket keys which are not strictly increasing", report.RegionID)
+		}
+	}
+	stat := &BucketsStat{
+		RegionID:   report.RegionID,
+		Version:    report.Version,
+		Keys:       report.Keys,
+		UpdateTime: time.Now(),
+	}
+	for kind, flows := range report.Flows {
+		if len(flows) == 0 {
+			continue
+		}
+		if len(flows) != stat.BucketCount() {
+			return errors.Errorf("region %d reports %d %s flows for %d buckets", report.RegionID, len(flows), RegionStatKind(kind), stat.BucketCount())
+		}
+		loads := make([]float64, len(flows))
+		for i, flow := range flows {
+			loads[i] = float64(flow) / report.Interval.Seconds()
+		}
+		stat.Loads[kind] = loads
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if origin, ok := c.buckets[report.RegionID]; ok && origin.Version > report.Version {
+		return nil
+	}
+	c.buckets[report.RegionID] = stat
+	return nil
+}
+
+// Get returns the bucket statistics of the region if the buckets still fit the
+// range of the region.
+func (c *HotBucketCache) Get(region *core.RegionInfo) *BucketsStat {
+	c.RLock()
+	stat, ok := c.buckets[region.GetID()]
+	c.RUnlock()
+	if !ok {
+		return nil
+	}
+	// The region has been split or merged since the report.
+	if !bytes.Equal(stat.Keys[0], region.GetStartKey()) || !bytes.Equal(stat.Keys[len(stat.Keys)-1], region.GetEndKey()) {
+		return nil
+	}
+	return stat
+}
+
+// Remove removes the bucket statistics of the region.
+func (c *HotBucketCache) Remove(regionID uint64) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.buckets, regionID)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testHotBucketCache{})
+
+type testHotBucketCache struct{}
+
+func newTestBucketsReport(version uint64, writeFlows ...uint64) *BucketsReport {
+	report := &BucketsReport{
+		RegionID: 1,
+		Version:  version,
+		Keys:     [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d"), []byte("e")},
+		Interval: 10 * time.Second,
+	}
+	report.Flows[RegionWriteBytes] = writeFlows
+	return report
+}
+
+func (t *testHotBucketCache) TestUpdate(c *C) {
+	cache := NewHotBucketCache()
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, StartKey: []byte("a"), EndKey: []byte("e")}, nil)
+
+	c.Assert(cache.Update(newTestBucketsReport(2, 100, 100, 100, 100)), IsNil)
+	stat := cache.Get(region)
+	c.Assert(stat, NotNil)
+	c.Assert(stat.BucketCount(), Equals, 4)
+	c.Assert(stat.Loads[RegionWriteBytes], DeepEquals, []float64{10, 10, 10, 10})
+	c.Assert(stat.Loads[RegionReadBytes], IsNil)
+
+	// Stale and invalid reports.
+	c.Assert(cache.Update(newTestBucketsReport(1, 0, 0, 0, 100)), IsNil)
+	c.Assert(cache.Get(region).Version, Equals, uint64(2))
+	c.Assert(cache.Update(newTestBucketsReport(3, 100, 100)), NotNil)
+	report := newTestBucketsReport(3, 100, 100, 100, 100)
+	report.Keys = report.Keys[:1]
+	c.Assert(cache.Update(report), NotNil)
+	// The keys are not strictly increasing.
+	for _, keys := range [][]string{
+		{"a", "b", "b", "e"},
+		{"a", "c", "b", "e"},
+		{"a", "", "c", "e"},
+		{"a", "b", "c", "a"},
+	} {
+		report = newTestBucketsReport(3, 100, 100, 100)
+		report.Keys = report.Keys[:0]
+		for _, key := range keys {
+			report.Keys = append(report.Keys, []byte(key))
+		}
+		c.Assert(cache.Update(report), NotNil, Commentf("keys: %v", keys))
+	}
+	c.Assert(cache.Get(region).Version, Equals, uint64(2))
+	// The empty last key means the end of the key space.
+	report = newTestBucketsReport(3, 100, 100)
+	report.Keys = [][]byte{[]byte("a"), []byte("c"), {}}
+	c.Assert(cache.Update(report), IsNil)
+	c.Assert(cache.Get(region.Clone(core.WithEndKey(nil))).Version, Equals, uint64(3))
+
+	// The buckets don't fit the region after split.
+	c.Assert(cache.Get(region.Clone(core.WithEndKey([]byte("c")))), IsNil)
+
+	cache.Remove(1)
+	c.Assert(cache.Get(region), IsNil)
+}
+
+func (t *testHotBucketCache) TestSplitKey(c *C) {
+	testCases := []struct {
+		flows    []uint64
+		skew     float64
+		splitKey []byte
+	}{
+		{[]uint64{100, 100, 100, 100}, 1, []byte("c")},
+		{[]uint64{0, 0, 400, 0}, 4, []byte("c")},
+		{[]uint64{100, 600, 100, 0}, 3, []byte("b")},
+		{[]uint64{700, 100, 0, 0}, 3.5, []byte("b")},
+		{[]uint64{0, 0, 0, 0}, 0, nil},
+	}
+	for _, tc := range testCases {
+		cache := NewHotBucketCache()
+		c.Assert(cache.Update(newTestBucketsReport(1, tc.flows...)), IsNil)
+		stat := cache.buckets[1]
+		c.Assert(stat.Skew(RegionWriteBytes), Equals, tc.skew)
+		c.Assert(stat.SplitKey(RegionWriteBytes), DeepEquals, tc.splitKey)
+		c.Assert(stat.SplitKey(RegionReadBytes), IsNil)
+	}
+}
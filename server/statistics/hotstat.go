@@ -21,6 +21,7 @@ import (
 type HotStat struct {
 	*HotCache
 	*StoresStats
+	Buckets *HotBucketCache
 }
 
 // NewHotStat creates the container to hold cluster's hotspot statistics.
@@ -28,5 +29,6 @@ func NewHotStat(ctx context.Context, quit <-chan struct{}) *HotStat {
 	return &HotStat{
 		HotCache:    NewHotCache(ctx, quit),
 		StoresStats: NewStoresStats(),
+		Buckets:     NewHotBucketCache(),
 	}
 }
@@ -0,0 +1,225 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+const (
+	// isolationTrendInterval is the min interval between the points of the
+	// isolation violation trend.
+	isolationTrendInterval = time.Minute
+	// maxIsolationTrendPoints keeps the trend of one day.
+	maxIsolationTrendPoints = 24 * 60
+)
+
+// IsolationViolationRegion is a region whose peers of a rule violate the
+// isolation level of the rule, e.g. two peers are in the same zone with
+// isolation-level = zone.
+type IsolationViolationRegion struct {
+	ID       uint64 `json:"id"`
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+	// Rule is the key of the rule in the format of "<group_id>/<id>".
+	Rule           string `json:"rule"`
+	IsolationLevel string `json:"isolation_level"`
+	// Domains are the failure domains which have more than one peer of the
+	// rule, keyed by the label values joined by "/", e.g. "z1/r1" with
+	// location-labels = ["zone", "rack"] and isolation-level = rack, and
+	// valued by the stores of the peers in the failure domain.
+	Domains map[string][]uint64 `json:"domains"`
+	Since   time.Time           `json:"since"`
+}
+
+// IsolationViolationTrend is a point of the trend of the isolation violations.
+type IsolationViolationTrend struct {
+	Time       time.Time      `json:"time"`
+	Count      int            `json:"count"`
+	RuleCounts map[string]int `json:"rule_counts"`
+}
+
+// IsolationViolationReport reports the regions which violate the isolation
+// level of the placement rules.
+type IsolationViolationReport struct {
+	// Count is the number of the regions which violate the isolation level of
+	// any rule.
+	Count int `json:"count"`
+	// RuleCounts is the number of the violating regions of each rule.
+	RuleCounts map[string]int              `json:"rule_counts"`
+	Regions    []*IsolationViolationRegion `json:"regions"`
+	Trend      []*IsolationViolationTrend  `json:"trend"`
+}
+
+// IsolationStatistics records the regions which violate the isolation level of
+// the placement rules. It is updated by the patrol of the regions, so it may
+// be out of date for a patrol round.
+type IsolationStatistics struct {
+	regions map[uint64][]*IsolationViolationRegion
+	trend   []*IsolationViolationTrend
+}
+
+// NewIsolationStatistics creates a new IsolationStatistics.
+func NewIsolationStatistics() *IsolationStatistics {
+	return &IsolationStatistics{
+		regions: make(map[uint64][]*IsolationViolationRegion),
+	}
+}
+
+// Observe records the isolation violations of the region by its rule fit. A
+// nil fit clears the region, e.g. the placement rules are disabled.
+func (s *IsolationStatistics) Observe(region *core.RegionInfo, fit *placement.RegionFit, stores placement.StoreSet) {
+	if fit == nil {
+		s.ClearDefunctRegion(region.GetID())
+		return
+	}
+	last := make(map[string]*IsolationViolationRegion, len(s.regions[region.GetID()]))
+	for _, violation := range s.regions[region.GetID()] {
+		last[violation.Rule] = violation
+	}
+	var violations []*IsolationViolationRegion
+	for _, rf := range fit.RuleFits {
+		domains := getViolatedDomains(rf, stores)
+		if len(domains) == 0 {
+			continue
+		}
+		violation := &IsolationViolationRegion{
+			ID:             region.GetID(),
+			StartKey:       core.RedactedHexRegionKeyStr(region.GetStartKey()),
+			EndKey:         core.RedactedHexRegionKeyStr(region.GetEndKey()),
+			Rule:           rf.Rule.GroupID + "/" + rf.Rule.ID,
+			IsolationLevel: rf.Rule.IsolationLevel,
+			Domains:        domains,
+			Since:          time.Now(),
+		}
+		if old, ok := last[violation.Rule]; ok {
+			violation.Since = old.Since
+		}
+		violations = append(violations, violation)
+	}
+	if len(violations) == 0 {
+		s.ClearDefunctRegion(region.GetID())
+		return
+	}
+	s.regions[region.GetID()] = violations
+}
+
+// getViolatedDomains returns the failure domains which have more than one
+// peer of the rule at the isolation level of the rule.
+func getViolatedDomains(rf *placement.RuleFit, stores placement.StoreSet) map[string][]uint64 {
+	level := -1
+	for i, label := range rf.Rule.LocationLabels {
+		if label == rf.Rule.IsolationLevel {
+			level = i
+			break
+		}
+	}
+	if level < 0 || len(rf.Peers) <= 1 {
+		return nil
+	}
+	domainStores := make(map[string][]uint64)
+	for _, peer := range rf.Peers {
+		store := stores.GetStore(peer.GetStoreId())
+		if store == nil {
+			continue
+		}
+		values := make([]string, 0, level+1)
+		for _, label := range rf.Rule.LocationLabels[:level+1] {
+			values = append(values, store.GetLabelValue(label))
+		}
+		domain := strings.Join(values, "/")
+		domainStores[domain] = append(domainStores[domain], store.GetID())
+	}
+	var domains map[string][]uint64
+	for domain, storeIDs := range domainStores {
+		if len(storeIDs) > 1 {
+			if domains == nil {
+				domains = make(map[string][]uint64)
+			}
+			sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+			domains[domain] = storeIDs
+		}
+	}
+	return domains
+}
+
+// ClearDefunctRegion is used to handle the overlap region.
+func (s *IsolationStatistics) ClearDefunctRegion(regionID uint64) {
+	delete(s.regions, regionID)
+}
+
+func (s *IsolationStatistics) ruleCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, violations := range s.regions {
+		for _, violation := range violations {
+			counts[violation.Rule]++
+		}
+	}
+	return counts
+}
+
+// GetReport returns the report of the isolation violations, the regions are
+// sorted by the region ID and the rule.
+func (s *IsolationStatistics) GetReport() *IsolationViolationReport {
+	report := &IsolationViolationReport{
+		Count:      len(s.regions),
+		RuleCounts: s.ruleCounts(),
+		Regions:    make([]*IsolationViolationRegion, 0, len(s.regions)),
+		Trend:      append([]*IsolationViolationTrend(nil), s.trend...),
+	}
+	for _, violations := range s.regions {
+		report.Regions = append(report.Regions, violations...)
+	}
+	sort.Slice(report.Regions, func(i, j int) bool {
+		if report.Regions[i].ID != report.Regions[j].ID {
+			return report.Regions[i].ID < report.Regions[j].ID
+		}
+		return report.Regions[i].Rule < report.Regions[j].Rule
+	})
+	return report
+}
+
+// Collect collects the metrics of the isolation violations, and records the
+// trend.
+func (s *IsolationStatistics) Collect() {
+	ruleCounts := s.ruleCounts()
+	// Reset the gauge to remove the rules which have no violation.
+	isolationViolationGauge.Reset()
+	for rule, count := range ruleCounts {
+		isolationViolationGauge.WithLabelValues(rule).Set(float64(count))
+	}
+
+	now := time.Now()
+	if len(s.trend) > 0 && now.Sub(s.trend[len(s.trend)-1].Time) < isolationTrendInterval {
+		return
+	}
+	s.trend = append(s.trend, &IsolationViolationTrend{
+		Time:       now,
+		Count:      len(s.regions),
+		RuleCounts: ruleCounts,
+	})
+	if len(s.trend) > maxIsolationTrendPoints {
+		s.trend = s.trend[len(s.trend)-maxIsolationTrendPoints:]
+	}
+}
+
+// Reset resets the metrics of the isolation violations.
+func (s *IsolationStatistics) Reset() {
+	isolationViolationGauge.Reset()
+}
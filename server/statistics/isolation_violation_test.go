@@ -0,0 +1,80 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/server/schedule/placement"
+)
+
+var _ = Suite(&testIsolationStatisticsSuite{})
+
+type testIsolationStatisticsSuite struct{}
+
+func (t *testIsolationStatisticsSuite) TestIsolationViolation(c *C) {
+	stores := core.NewBasicCluster()
+	for id, zone := range map[uint64]string{1: "z1", 2: "z1", 3: "z2", 4: "z3"} {
+		stores.PutStore(core.NewStoreInfo(&metapb.Store{
+			Id:     id,
+			Labels: []*metapb.StoreLabel{{Key: "zone", Value: zone}},
+		}))
+	}
+	rules := []*placement.Rule{
+		{GroupID: "pd", ID: "default", Role: placement.Voter, Count: 3, LocationLabels: []string{"zone"}, IsolationLevel: "zone"},
+	}
+	region1 := newTestLagRegion(1, 1, 2, 3)
+	region2 := newTestLagRegion(2, 1, 3, 4)
+
+	stats := NewIsolationStatistics()
+	stats.Observe(region1, placement.FitRegion(stores, region1, rules), stores)
+	stats.Observe(region2, placement.FitRegion(stores, region2, rules), stores)
+	report := stats.GetReport()
+	c.Assert(report.Count, Equals, 1)
+	c.Assert(report.RuleCounts, DeepEquals, map[string]int{"pd/default": 1})
+	c.Assert(report.Regions, HasLen, 1)
+	violation := report.Regions[0]
+	c.Assert(violation.ID, Equals, uint64(1))
+	c.Assert(violation.Rule, Equals, "pd/default")
+	c.Assert(violation.IsolationLevel, Equals, "zone")
+	c.Assert(violation.Domains, DeepEquals, map[string][]uint64{"z1": {1, 2}})
+
+	// The violation keeps the time it starts.
+	stats.Observe(region1, placement.FitRegion(stores, region1, rules), stores)
+	c.Assert(stats.GetReport().Regions[0].Since, Equals, violation.Since)
+
+	// The trend is recorded by the interval.
+	stats.Collect()
+	stats.Collect()
+	report = stats.GetReport()
+	c.Assert(report.Trend, HasLen, 1)
+	c.Assert(report.Trend[0].Count, Equals, 1)
+	c.Assert(report.Trend[0].RuleCounts, DeepEquals, map[string]int{"pd/default": 1})
+
+	// The rule without isolation level is never violated.
+	rules[0].IsolationLevel = ""
+	stats.Observe(region1, placement.FitRegion(stores, region1, rules), stores)
+	c.Assert(stats.GetReport().Count, Equals, 0)
+
+	rules[0].IsolationLevel = "zone"
+	stats.Observe(region1, placement.FitRegion(stores, region1, rules), stores)
+	c.Assert(stats.GetReport().Count, Equals, 1)
+	// The placement rules are disabled.
+	stats.Observe(region1, nil, stores)
+	c.Assert(stats.GetReport().Count, Equals, 0)
+	stats.Observe(region1, placement.FitRegion(stores, region1, rules), stores)
+	stats.ClearDefunctRegion(1)
+	c.Assert(stats.GetReport().Count, Equals, 0)
+}
@@ -48,6 +48,22 @@ var (
 			Help:      "Status of the offline regions.",
 		}, []string{"type"})
 
+	lostQuorumStoreGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "regions",
+			Name:      "lost_quorum_store_region_count",
+			Help:      "The number of the regions which lose quorum with a lost voter on the store.",
+		}, []string{"store"})
+
+	isolationViolationGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "regions",
+			Name:      "isolation_violation_region_count",
+			Help:      "The number of the regions which violate the isolation level of the placement rule.",
+		}, []string{"rule"})
+
 	clusterStatusGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "pd",
@@ -169,6 +185,14 @@ var (
 			Name:      "flow_queue_status",
 			Help:      "Status of the hotspot flow queue.",
 		}, []string{"type"})
+
+	replicationLagGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pd",
+			Subsystem: "regions",
+			Name:      "replication_lag",
+			Help:      "Replication lag of the peers on each store.",
+		}, []string{"store", "type"})
 )
 
 var (
@@ -182,6 +206,8 @@ func init() {
 	prometheus.MustRegister(storeStatusGauge)
 	prometheus.MustRegister(regionStatusGauge)
 	prometheus.MustRegister(offlineRegionStatusGauge)
+	prometheus.MustRegister(lostQuorumStoreGauge)
+	prometheus.MustRegister(isolationViolationGauge)
 	prometheus.MustRegister(clusterStatusGauge)
 	prometheus.MustRegister(placementStatusGauge)
 	prometheus.MustRegister(configStatusGauge)
@@ -195,4 +221,5 @@ func init() {
 	prometheus.MustRegister(storeHeartbeatIntervalHist)
 	prometheus.MustRegister(regionAbnormalPeerDuration)
 	prometheus.MustRegister(hotCacheFlowQueueStatusGauge)
+	prometheus.MustRegister(replicationLagGauge)
 }
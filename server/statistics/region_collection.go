@@ -14,12 +14,16 @@
 package statistics
 
 import (
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/pingcap/log"
+	"github.com/tikv/pd/pkg/logutil"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/schedule/placement"
+	"go.uber.org/zap"
 )
 
 // RegionStatisticType represents the type of the region's status.
@@ -34,6 +38,11 @@ const (
 	OfflinePeer
 	LearnerPeer
 	EmptyRegion
+	// InvalidPeer means the region has peers on the tombstone or unknown stores.
+	InvalidPeer
+	// LostQuorum means the region has fewer live voters than the quorum, and it
+	// is unavailable until the lost voters are recovered.
+	LostQuorum
 )
 
 const nonIsolation = "none"
@@ -43,6 +52,21 @@ type RegionInfo struct {
 	*core.RegionInfo
 	startMissVoterPeerTS int64
 	startDownPeerTS      int64
+	// lostStores are the stores of the lost voters of the LostQuorum region.
+	lostStores      []uint64
+	lostQuorumSince time.Time
+}
+
+// LostQuorumRegion is a region which has fewer live voters than the quorum.
+type LostQuorumRegion struct {
+	ID       uint64 `json:"id"`
+	StartKey string `json:"start_key"`
+	EndKey   string `json:"end_key"`
+	// VoterStores are the stores of all the voters, and LostStores are the
+	// stores of the voters which are not alive.
+	VoterStores []uint64  `json:"voter_stores"`
+	LostStores  []uint64  `json:"lost_stores"`
+	Since       time.Time `json:"since"`
 }
 
 // RegionStatistics is used to record the status of regions.
@@ -70,6 +94,8 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 	r.stats[PendingPeer] = make(map[uint64]*RegionInfo)
 	r.stats[LearnerPeer] = make(map[uint64]*RegionInfo)
 	r.stats[EmptyRegion] = make(map[uint64]*RegionInfo)
+	r.stats[InvalidPeer] = make(map[uint64]*RegionInfo)
+	r.stats[LostQuorum] = make(map[uint64]*RegionInfo)
 
 	r.offlineStats[MissPeer] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[ExtraPeer] = make(map[uint64]*core.RegionInfo)
@@ -77,6 +103,8 @@ func NewRegionStatistics(opt *config.PersistOptions, ruleManager *placement.Rule
 	r.offlineStats[PendingPeer] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[LearnerPeer] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[EmptyRegion] = make(map[uint64]*core.RegionInfo)
+	r.offlineStats[InvalidPeer] = make(map[uint64]*core.RegionInfo)
+	r.offlineStats[LostQuorum] = make(map[uint64]*core.RegionInfo)
 	r.offlineStats[OfflinePeer] = make(map[uint64]*core.RegionInfo)
 	r.ruleManager = ruleManager
 	return r
@@ -100,6 +128,48 @@ func (r *RegionStatistics) GetOfflineRegionStatsByType(typ RegionStatisticType)
 	return res
 }
 
+// GetLostQuorumRegions gets the regions which have fewer live voters than the
+// quorum, sorted by the region ID.
+func (r *RegionStatistics) GetLostQuorumRegions() []*LostQuorumRegion {
+	res := make([]*LostQuorumRegion, 0, len(r.stats[LostQuorum]))
+	for _, info := range r.stats[LostQuorum] {
+		voters := info.GetVoters()
+		voterStores := make([]uint64, 0, len(voters))
+		for _, voter := range voters {
+			voterStores = append(voterStores, voter.GetStoreId())
+		}
+		sort.Slice(voterStores, func(i, j int) bool { return voterStores[i] < voterStores[j] })
+		res = append(res, &LostQuorumRegion{
+			ID:          info.GetID(),
+			StartKey:    core.HexRegionKeyStr(info.GetStartKey()),
+			EndKey:      core.HexRegionKeyStr(info.GetEndKey()),
+			VoterStores: voterStores,
+			LostStores:  info.lostStores,
+			Since:       info.lostQuorumSince,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// getLostVoterStores returns the stores of the voters which are not alive. A
+// voter is lost if it is down, or its store is down, tombstone or unknown.
+func (r *RegionStatistics) getLostVoterStores(region *core.RegionInfo, stores []*core.StoreInfo) []uint64 {
+	storeMap := make(map[uint64]*core.StoreInfo, len(stores))
+	for _, store := range stores {
+		storeMap[store.GetID()] = store
+	}
+	var lostStores []uint64
+	for _, voter := range region.GetVoters() {
+		store, ok := storeMap[voter.GetStoreId()]
+		if !ok || store.IsTombstone() || store.DownTime() > r.opt.GetMaxStoreDownTime() || region.GetDownPeer(voter.GetId()) != nil {
+			lostStores = append(lostStores, voter.GetStoreId())
+		}
+	}
+	sort.Slice(lostStores, func(i, j int) bool { return lostStores[i] < lostStores[j] })
+	return lostStores
+}
+
 func (r *RegionStatistics) deleteEntry(deleteIndex RegionStatisticType, regionID uint64) {
 	for typ := RegionStatisticType(1); typ <= deleteIndex; typ <<= 1 {
 		if deleteIndex&typ != 0 {
@@ -127,6 +197,9 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 	)
 	desiredReplicas := r.opt.GetMaxReplicas()
 	desiredVoters := desiredReplicas
+	// maxReplicas is the upper bound of the peers, the rules with max count
+	// accept more peers than the desired ones.
+	maxReplicas := desiredReplicas
 	if r.opt.IsPlacementRulesEnabled() {
 		if !r.ruleManager.IsInitialized() {
 			log.Warn("ruleManager haven't been initialized")
@@ -134,9 +207,11 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 		}
 		desiredReplicas = 0
 		desiredVoters = 0
+		maxReplicas = 0
 		rules := r.ruleManager.GetRulesForApplyRegion(region)
 		for _, rule := range rules {
 			desiredReplicas += rule.Count
+			maxReplicas += rule.GetMaxCount()
 			if rule.Role != placement.Learner {
 				desiredVoters += rule.Count
 			}
@@ -155,13 +230,27 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 		}
 	}
 
+	// The unknown stores are not in the stores.
+	hasInvalidPeer := len(stores) < len(region.GetPeers())
+	for _, store := range stores {
+		if store.IsTombstone() {
+			hasInvalidPeer = true
+			break
+		}
+	}
+
+	lostStores := r.getLostVoterStores(region, stores)
+	voters := len(region.GetVoters())
+
 	conditions := map[RegionStatisticType]bool{
 		MissPeer:    len(region.GetPeers()) < desiredReplicas,
-		ExtraPeer:   len(region.GetPeers()) > desiredReplicas,
+		ExtraPeer:   len(region.GetPeers()) > maxReplicas,
 		DownPeer:    len(region.GetDownPeers()) > 0,
 		PendingPeer: len(region.GetPendingPeers()) > 0,
 		LearnerPeer: len(region.GetLearners()) > 0,
 		EmptyRegion: region.GetApproximateSize() <= core.EmptyRegionApproximateSize,
+		InvalidPeer: hasInvalidPeer,
+		LostQuorum:  voters > 0 && voters-len(lostStores) < voters/2+1,
 	}
 
 	for typ, c := range conditions {
@@ -176,7 +265,18 @@ func (r *RegionStatistics) Observe(region *core.RegionInfo, stores []*core.Store
 					RegionInfo: region,
 				}
 			}
-			if typ == DownPeer {
+			if typ == LostQuorum {
+				if info.lostQuorumSince.IsZero() {
+					info.lostQuorumSince = time.Now()
+					log.Warn("region lost quorum",
+						zap.Uint64("region-id", regionID),
+						zap.Uint64s("lost-stores", lostStores),
+						logutil.ZapRedactString("start-key", core.HexRegionKeyStr(region.GetStartKey())),
+						logutil.ZapRedactString("end-key", core.HexRegionKeyStr(region.GetEndKey())))
+				}
+				info.RegionInfo = region
+				info.lostStores = lostStores
+			} else if typ == DownPeer {
 				if info.startDownPeerTS != 0 {
 					regionDownPeerDuration.Observe(float64(time.Now().Unix() - info.startDownPeerTS))
 				} else {
@@ -231,6 +331,8 @@ func (r *RegionStatistics) Collect() {
 	regionStatusGauge.WithLabelValues("pending-peer-region-count").Set(float64(len(r.stats[PendingPeer])))
 	regionStatusGauge.WithLabelValues("learner-peer-region-count").Set(float64(len(r.stats[LearnerPeer])))
 	regionStatusGauge.WithLabelValues("empty-region-count").Set(float64(len(r.stats[EmptyRegion])))
+	regionStatusGauge.WithLabelValues("invalid-peer-region-count").Set(float64(len(r.stats[InvalidPeer])))
+	regionStatusGauge.WithLabelValues("lost-quorum-region-count").Set(float64(len(r.stats[LostQuorum])))
 
 	offlineRegionStatusGauge.WithLabelValues("miss-peer-region-count").Set(float64(len(r.offlineStats[MissPeer])))
 	offlineRegionStatusGauge.WithLabelValues("extra-peer-region-count").Set(float64(len(r.offlineStats[ExtraPeer])))
@@ -238,13 +340,28 @@ func (r *RegionStatistics) Collect() {
 	offlineRegionStatusGauge.WithLabelValues("pending-peer-region-count").Set(float64(len(r.offlineStats[PendingPeer])))
 	offlineRegionStatusGauge.WithLabelValues("learner-peer-region-count").Set(float64(len(r.offlineStats[LearnerPeer])))
 	offlineRegionStatusGauge.WithLabelValues("empty-region-count").Set(float64(len(r.offlineStats[EmptyRegion])))
+	offlineRegionStatusGauge.WithLabelValues("invalid-peer-region-count").Set(float64(len(r.offlineStats[InvalidPeer])))
+	offlineRegionStatusGauge.WithLabelValues("lost-quorum-region-count").Set(float64(len(r.offlineStats[LostQuorum])))
 	offlineRegionStatusGauge.WithLabelValues("offline-peer-region-count").Set(float64(len(r.offlineStats[OfflinePeer])))
+
+	lostQuorumStoreRegions := make(map[uint64]int)
+	for _, info := range r.stats[LostQuorum] {
+		for _, storeID := range info.lostStores {
+			lostQuorumStoreRegions[storeID]++
+		}
+	}
+	// Reset the gauge to remove the stores which are recovered.
+	lostQuorumStoreGauge.Reset()
+	for storeID, count := range lostQuorumStoreRegions {
+		lostQuorumStoreGauge.WithLabelValues(strconv.FormatUint(storeID, 10)).Set(float64(count))
+	}
 }
 
 // Reset resets the metrics of the regions' status.
 func (r *RegionStatistics) Reset() {
 	regionStatusGauge.Reset()
 	offlineRegionStatusGauge.Reset()
+	lostQuorumStoreGauge.Reset()
 }
 
 // LabelStatistics is the statistics of the level of labels.
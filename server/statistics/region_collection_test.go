@@ -15,6 +15,7 @@ package statistics
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
@@ -140,6 +141,65 @@ func (t *testRegionStatisticsSuite) TestRegionStatistics(c *C) {
 	stores[3] = store3
 	regionStats.Observe(region1, stores)
 	c.Assert(len(regionStats.stats[OfflinePeer]), Equals, 0)
+	c.Assert(len(regionStats.stats[InvalidPeer]), Equals, 0)
+
+	// the store of peer 4 is unknown
+	regionStats.Observe(region1, stores[0:2])
+	c.Assert(len(regionStats.stats[InvalidPeer]), Equals, 1)
+	regionStats.Observe(region1, stores[0:3])
+	c.Assert(len(regionStats.stats[InvalidPeer]), Equals, 0)
+	// the store of peer 4 is tombstone
+	regionStats.Observe(region1, []*core.StoreInfo{stores[0], stores[1], stores[2].Clone(core.TombstoneStore())})
+	c.Assert(len(regionStats.stats[InvalidPeer]), Equals, 1)
+}
+
+func (t *testRegionStatisticsSuite) TestRegionLostQuorum(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(false)
+	peers := []*metapb.Peer{
+		{Id: 5, StoreId: 1},
+		{Id: 6, StoreId: 2},
+		{Id: 4, StoreId: 3},
+	}
+	stores := make([]*core.StoreInfo, 0, len(peers))
+	for _, peer := range peers {
+		store := core.NewStoreInfo(&metapb.Store{Id: peer.GetStoreId()}, core.SetLastHeartbeatTS(time.Now()))
+		stores = append(stores, store)
+	}
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers, StartKey: []byte("a"), EndKey: []byte("b")}, peers[0])
+	regionStats := NewRegionStatistics(opt, t.manager)
+
+	// One lost voter does not lose the quorum.
+	region = region.Clone(core.WithDownPeers([]*pdpb.PeerStats{{Peer: peers[1], DownSeconds: 3600}}))
+	regionStats.Observe(region, stores)
+	c.Assert(regionStats.stats[LostQuorum], HasLen, 0)
+	c.Assert(regionStats.GetLostQuorumRegions(), HasLen, 0)
+
+	// The store of peer 4 is down.
+	downStore := stores[2].Clone(core.SetLastHeartbeatTS(time.Now().Add(-2 * opt.GetMaxStoreDownTime())))
+	regionStats.Observe(region, []*core.StoreInfo{stores[0], stores[1], downStore})
+	c.Assert(regionStats.stats[LostQuorum], HasLen, 1)
+	regions := regionStats.GetLostQuorumRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].ID, Equals, uint64(1))
+	c.Assert(regions[0].StartKey, Equals, "61")
+	c.Assert(regions[0].EndKey, Equals, "62")
+	c.Assert(regions[0].VoterStores, DeepEquals, []uint64{1, 2, 3})
+	c.Assert(regions[0].LostStores, DeepEquals, []uint64{2, 3})
+	since := regions[0].Since
+	c.Assert(since.IsZero(), IsFalse)
+
+	// The store of peer 4 is unknown.
+	regionStats.Observe(region, stores[0:2])
+	regions = regionStats.GetLostQuorumRegions()
+	c.Assert(regions, HasLen, 1)
+	c.Assert(regions[0].LostStores, DeepEquals, []uint64{2, 3})
+	c.Assert(regions[0].Since, Equals, since)
+
+	// The down peer is recovered.
+	region = region.Clone(core.WithDownPeers(nil))
+	regionStats.Observe(region, stores[0:2])
+	c.Assert(regionStats.stats[LostQuorum], HasLen, 0)
 }
 
 func (t *testRegionStatisticsSuite) TestRegionStatisticsWithPlacementRule(c *C) {
@@ -283,3 +343,37 @@ func (t *testRegionStatisticsSuite) TestRegionLabelIsolationLevel(c *C) {
 		c.Assert(labelLevelStats.labelCounter[i], Equals, res)
 	}
 }
+
+func (t *testRegionStatisticsSuite) TestRegionStatisticsWithMaxCount(c *C) {
+	opt := config.NewTestOptions()
+	opt.SetPlacementRuleEnabled(true)
+	err := t.manager.SetRule(&placement.Rule{
+		GroupID:  "pd",
+		ID:       "default",
+		Role:     placement.Voter,
+		Count:    3,
+		MaxCount: 4,
+	})
+	c.Assert(err, IsNil)
+	peers := []*metapb.Peer{
+		{Id: 5, StoreId: 1},
+		{Id: 6, StoreId: 2},
+		{Id: 4, StoreId: 3},
+		{Id: 8, StoreId: 7},
+		{Id: 9, StoreId: 8},
+	}
+	stores := make([]*core.StoreInfo, 0, len(peers))
+	for _, peer := range peers {
+		stores = append(stores, core.NewStoreInfo(&metapb.Store{Id: peer.GetStoreId()}))
+	}
+	regionStats := NewRegionStatistics(opt, t.manager)
+	// The region within the max count of the rule has no extra peer.
+	region := core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers[0:4]}, peers[0])
+	regionStats.Observe(region, stores)
+	c.Assert(regionStats.stats[ExtraPeer], HasLen, 0)
+	c.Assert(regionStats.stats[MissPeer], HasLen, 0)
+	// The region beyond the max count of the rule has extra peer.
+	region = core.NewRegionInfo(&metapb.Region{Id: 1, Peers: peers}, peers[0])
+	regionStats.Observe(region, stores)
+	c.Assert(regionStats.stats[ExtraPeer], HasLen, 1)
+}
@@ -24,4 +24,7 @@ type RegionStatInformer interface {
 	// RegionReadStats return the storeID -> read stat of peers on this store.
 	// The result only includes peers that are hot enough.
 	RegionReadStats() map[uint64][]*HotPeerStat
+	// RegionBucketsStat returns the bucket statistics of the region, nil if the
+	// region doesn't report buckets or the buckets are out of date.
+	RegionBucketsStat(region *core.RegionInfo) *BucketsStat
 }
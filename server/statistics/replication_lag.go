@@ -0,0 +1,201 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/tikv/pd/server/core"
+)
+
+const (
+	// DefaultReplicationLagThreshold is the default number of log entries a
+	// peer falls behind to be considered as lagging.
+	DefaultReplicationLagThreshold = 1000
+	// replicationLagTTL is the time after which the apply states of a region
+	// are considered out of date if it doesn't report again.
+	replicationLagTTL = 10 * time.Minute
+)
+
+// PeerApplyState is the apply progress of a peer.
+type PeerApplyState struct {
+	PeerID       uint64 `json:"peer_id"`
+	AppliedIndex uint64 `json:"applied_index"`
+	AppliedTerm  uint64 `json:"applied_term"`
+}
+
+// ApplyStateReport is the apply progress of the peers of a region reported
+// by the leader. The region heartbeat can't carry it, so it is reported
+// separately. It is optional, and the leader may only report a part of the peers.
+type ApplyStateReport struct {
+	RegionID uint64           `json:"region_id"`
+	Peers    []PeerApplyState `json:"peers"`
+}
+
+// PeerReplicationLag is the replication lag of a peer, which is the number
+// of log entries it falls behind the most advanced peer of the region.
+type PeerReplicationLag struct {
+	RegionID     uint64    `json:"region_id"`
+	PeerID       uint64    `json:"peer_id"`
+	StoreID      uint64    `json:"store_id"`
+	AppliedIndex uint64    `json:"applied_index"`
+	AppliedTerm  uint64    `json:"applied_term"`
+	Lag          uint64    `json:"lag"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+// StoreReplicationLag is the replication lag of the peers on a store.
+type StoreReplicationLag struct {
+	StoreID          uint64 `json:"store_id"`
+	PeerCount        int    `json:"peer_count"`
+	LaggingPeerCount int    `json:"lagging_peer_count"`
+	MaxLag           uint64 `json:"max_lag"`
+	TotalLag         uint64 `json:"total_lag"`
+}
+
+// ReplicationLagStats holds the replication lag of the regions which report
+// the apply states of their peers.
+type ReplicationLagStats struct {
+	sync.RWMutex
+	regions map[uint64][]*PeerReplicationLag
+}
+
+// NewReplicationLagStats creates a new ReplicationLagStats.
+func NewReplicationLagStats() *ReplicationLagStats {
+	return &ReplicationLagStats{
+		regions: make(map[uint64][]*PeerReplicationLag),
+	}
+}
+
+// Observe updates the replication lag of the region according to the report.
+func (s *ReplicationLagStats) Observe(region *core.RegionInfo, report *ApplyStateReport) error {
+	if region.GetID() != report.RegionID {
+		return errors.Errorf("the report of region %d mismatches region %d", report.RegionID, region.GetID())
+	}
+	if len(report.Peers) == 0 {
+		return nil
+	}
+	now := time.Now()
+	lags := make([]*PeerReplicationLag, 0, len(report.Peers))
+	var maxIndex uint64
+	for _, state := range report.Peers {
+		peer := region.GetPeer(state.PeerID)
+		if peer == nil {
+			return errors.Errorf("peer %d not found in region %d", state.PeerID, report.RegionID)
+		}
+		if state.AppliedIndex > maxIndex {
+			maxIndex = state.AppliedIndex
+		}
+		lags = append(lags, &PeerReplicationLag{
+			RegionID:     report.RegionID,
+			PeerID:       state.PeerID,
+			StoreID:      peer.GetStoreId(),
+			AppliedIndex: state.AppliedIndex,
+			AppliedTerm:  state.AppliedTerm,
+			UpdateTime:   now,
+		})
+	}
+	for _, lag := range lags {
+		lag.Lag = maxIndex - lag.AppliedIndex
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.regions[report.RegionID] = lags
+	return nil
+}
+
+// ClearDefunctRegion removes the replication lag of the region.
+func (s *ReplicationLagStats) ClearDefunctRegion(regionID uint64) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.regions, regionID)
+}
+
+// GetLaggingPeers returns the peers whose lag is not less than the threshold,
+// sorted by the lag in descending order.
+func (s *ReplicationLagStats) GetLaggingPeers(threshold uint64) []*PeerReplicationLag {
+	s.Lock()
+	defer s.Unlock()
+	s.removeExpired(time.Now())
+	var res []*PeerReplicationLag
+	for _, lags := range s.regions {
+		for _, lag := range lags {
+			if lag.Lag >= threshold && lag.Lag > 0 {
+				copied := *lag
+				res = append(res, &copied)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Lag != res[j].Lag {
+			return res[i].Lag > res[j].Lag
+		}
+		return res[i].RegionID < res[j].RegionID
+	})
+	return res
+}
+
+// GetStoreLags returns the replication lag of each store. A peer is counted
+// as lagging if its lag is not less than the threshold.
+func (s *ReplicationLagStats) GetStoreLags(threshold uint64) map[uint64]*StoreReplicationLag {
+	s.Lock()
+	defer s.Unlock()
+	s.removeExpired(time.Now())
+	res := make(map[uint64]*StoreReplicationLag)
+	for _, lags := range s.regions {
+		for _, lag := range lags {
+			stat, ok := res[lag.StoreID]
+			if !ok {
+				stat = &StoreReplicationLag{StoreID: lag.StoreID}
+				res[lag.StoreID] = stat
+			}
+			stat.PeerCount++
+			stat.TotalLag += lag.Lag
+			if lag.Lag > stat.MaxLag {
+				stat.MaxLag = lag.Lag
+			}
+			if lag.Lag >= threshold && lag.Lag > 0 {
+				stat.LaggingPeerCount++
+			}
+		}
+	}
+	return res
+}
+
+func (s *ReplicationLagStats) removeExpired(now time.Time) {
+	for regionID, lags := range s.regions {
+		if len(lags) == 0 || now.Sub(lags[0].UpdateTime) > replicationLagTTL {
+			delete(s.regions, regionID)
+		}
+	}
+}
+
+// Collect collects the metrics of the replication lag.
+func (s *ReplicationLagStats) Collect() {
+	replicationLagGauge.Reset()
+	for storeID, stat := range s.GetStoreLags(DefaultReplicationLagThreshold) {
+		store := strconv.FormatUint(storeID, 10)
+		replicationLagGauge.WithLabelValues(store, "max_lag").Set(float64(stat.MaxLag))
+		replicationLagGauge.WithLabelValues(store, "lagging_peer_count").Set(float64(stat.LaggingPeerCount))
+	}
+}
+
+// Reset resets the metrics of the replication lag.
+func (s *ReplicationLagStats) Reset() {
+	replicationLagGauge.Reset()
+}
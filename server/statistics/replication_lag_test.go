@@ -0,0 +1,87 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+)
+
+var _ = Suite(&testReplicationLagSuite{})
+
+type testReplicationLagSuite struct{}
+
+func newTestLagRegion(id uint64, storeIDs ...uint64) *core.RegionInfo {
+	peers := make([]*metapb.Peer, 0, len(storeIDs))
+	for _, storeID := range storeIDs {
+		peers = append(peers, &metapb.Peer{Id: id*10 + storeID, StoreId: storeID})
+	}
+	return core.NewRegionInfo(&metapb.Region{Id: id, Peers: peers}, peers[0])
+}
+
+func (t *testReplicationLagSuite) TestObserve(c *C) {
+	stats := NewReplicationLagStats()
+	region1 := newTestLagRegion(1, 1, 2, 3)
+	region2 := newTestLagRegion(2, 1, 2, 3)
+
+	c.Assert(stats.Observe(region1, &ApplyStateReport{
+		RegionID: 1,
+		Peers: []PeerApplyState{
+			{PeerID: 11, AppliedIndex: 5000, AppliedTerm: 6},
+			{PeerID: 12, AppliedIndex: 4990, AppliedTerm: 6},
+			{PeerID: 13, AppliedIndex: 2000, AppliedTerm: 5},
+		},
+	}), IsNil)
+	c.Assert(stats.Observe(region2, &ApplyStateReport{
+		RegionID: 2,
+		Peers: []PeerApplyState{
+			{PeerID: 21, AppliedIndex: 300, AppliedTerm: 6},
+			{PeerID: 23, AppliedIndex: 100, AppliedTerm: 6},
+		},
+	}), IsNil)
+	// The peer doesn't belong to the region.
+	c.Assert(stats.Observe(region2, &ApplyStateReport{
+		RegionID: 2,
+		Peers:    []PeerApplyState{{PeerID: 14, AppliedIndex: 100}},
+	}), NotNil)
+	// The report mismatches the region.
+	c.Assert(stats.Observe(region2, &ApplyStateReport{RegionID: 1}), NotNil)
+
+	peers := stats.GetLaggingPeers(DefaultReplicationLagThreshold)
+	c.Assert(peers, HasLen, 1)
+	c.Assert(peers[0].PeerID, Equals, uint64(13))
+	c.Assert(peers[0].StoreID, Equals, uint64(3))
+	c.Assert(peers[0].Lag, Equals, uint64(3000))
+
+	peers = stats.GetLaggingPeers(1)
+	c.Assert(peers, HasLen, 3)
+	c.Assert(peers[1].PeerID, Equals, uint64(23))
+	c.Assert(peers[2].PeerID, Equals, uint64(12))
+
+	storeLags := stats.GetStoreLags(DefaultReplicationLagThreshold)
+	c.Assert(storeLags, HasLen, 3)
+	c.Assert(*storeLags[1], DeepEquals, StoreReplicationLag{StoreID: 1, PeerCount: 2})
+	c.Assert(*storeLags[2], DeepEquals, StoreReplicationLag{StoreID: 2, PeerCount: 1, MaxLag: 10, TotalLag: 10})
+	c.Assert(*storeLags[3], DeepEquals, StoreReplicationLag{StoreID: 3, PeerCount: 2, LaggingPeerCount: 1, MaxLag: 3000, TotalLag: 3200})
+
+	stats.ClearDefunctRegion(1)
+	c.Assert(stats.GetLaggingPeers(DefaultReplicationLagThreshold), HasLen, 0)
+
+	// The out of date reports are removed.
+	stats.regions[2][0].UpdateTime = time.Now().Add(-2 * replicationLagTTL)
+	c.Assert(stats.GetStoreLags(DefaultReplicationLagThreshold), HasLen, 0)
+}
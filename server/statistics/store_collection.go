@@ -104,6 +104,10 @@ func (s *storeStatistics) Observe(store *core.StoreInfo, stats *StoresStats) {
 	storeStatusGauge.WithLabelValues(storeAddress, id, "store_capacity").Set(float64(store.GetCapacity()))
 	storeStatusGauge.WithLabelValues(storeAddress, id, "store_available_avg").Set(float64(store.GetAvgAvailable()))
 	storeStatusGauge.WithLabelValues(storeAddress, id, "store_available_deviation").Set(float64(store.GetAvailableDeviation()))
+	storeStatusGauge.WithLabelValues(storeAddress, id, "store_sending_snap_count").Set(float64(store.GetSendingSnapCount()))
+	storeStatusGauge.WithLabelValues(storeAddress, id, "store_receiving_snap_count").Set(float64(store.GetReceivingSnapCount()))
+	storeStatusGauge.WithLabelValues(storeAddress, id, "store_applying_snap_count").Set(float64(store.GetApplyingSnapCount()))
+	storeStatusGauge.WithLabelValues(storeAddress, id, "store_applying_snap_duration").Set(store.GetApplyingSnapDuration().Seconds())
 
 	// Store flows.
 	storeFlowStats := stats.GetRollingStoreStats(store.GetID())
@@ -209,6 +213,10 @@ func (s *storeStatistics) resetStoreStatistics(storeAddress string, id string) {
 		"store_available",
 		"store_used",
 		"store_capacity",
+		"store_sending_snap_count",
+		"store_receiving_snap_count",
+		"store_applying_snap_count",
+		"store_applying_snap_duration",
 		"store_write_rate_bytes",
 		"store_read_rate_bytes",
 		"store_write_rate_keys",
@@ -0,0 +1,112 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertSeverity is the severity of an alert.
+type AlertSeverity string
+
+const (
+	// AlertWarning means the TSO allocation may be affected, e.g. the clock
+	// offset is too large.
+	AlertWarning AlertSeverity = "warning"
+	// AlertCritical means the TSO allocation fails, e.g. the time window fails
+	// to be saved into etcd.
+	AlertCritical AlertSeverity = "critical"
+)
+
+// The types of the alerts.
+const (
+	alertSaveFailed     = "save-failed"
+	alertClockOffset    = "clock-offset"
+	alertSystemTimeSlow = "system-time-slow"
+)
+
+const (
+	maxAlertCount = 128
+	alertTTL      = time.Hour
+)
+
+// Alert is an abnormal event of updating the TSO. The events with the same
+// type and dc-location are merged into one alert.
+type Alert struct {
+	Type       string        `json:"type"`
+	Severity   AlertSeverity `json:"severity"`
+	DCLocation string        `json:"dc_location"`
+	Message    string        `json:"message"`
+	Count      int           `json:"count"`
+	FirstSeen  time.Time     `json:"first_seen"`
+	LastSeen   time.Time     `json:"last_seen"`
+}
+
+// alertBuffer keeps the recent alerts in memory, the alerts which are not seen
+// in alertTTL are dropped.
+type alertBuffer struct {
+	sync.Mutex
+	alerts []*Alert // sorted by LastSeen
+}
+
+func newAlertBuffer() *alertBuffer {
+	return &alertBuffer{}
+}
+
+func (b *alertBuffer) put(typ string, severity AlertSeverity, dcLocation, message string) {
+	// The timestampOracle may be created without an alertBuffer in tests.
+	if b == nil {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+	now := time.Now()
+	b.gc(now)
+	alert := &Alert{Type: typ, DCLocation: dcLocation, FirstSeen: now}
+	for i, a := range b.alerts {
+		if a.Type == typ && a.DCLocation == dcLocation {
+			alert = a
+			b.alerts = append(b.alerts[:i], b.alerts[i+1:]...)
+			break
+		}
+	}
+	alert.Severity, alert.Message, alert.LastSeen = severity, message, now
+	alert.Count++
+	b.alerts = append(b.alerts, alert)
+	if len(b.alerts) > maxAlertCount {
+		b.alerts = b.alerts[len(b.alerts)-maxAlertCount:]
+	}
+}
+
+// getAll returns the alerts, the latest one comes first.
+func (b *alertBuffer) getAll() []Alert {
+	b.Lock()
+	defer b.Unlock()
+	b.gc(time.Now())
+	alerts := make([]Alert, 0, len(b.alerts))
+	for _, a := range b.alerts {
+		alerts = append(alerts, *a)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].LastSeen.After(alerts[j].LastSeen) })
+	return alerts
+}
+
+func (b *alertBuffer) gc(now time.Time) {
+	i := 0
+	for ; i < len(b.alerts) && now.Sub(b.alerts[i].LastSeen) > alertTTL; i++ {
+	}
+	b.alerts = b.alerts[i:]
+}
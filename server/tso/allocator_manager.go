@@ -118,6 +118,10 @@ type AllocatorManager struct {
 	updatePhysicalInterval time.Duration
 	maxResetTSGap          func() time.Duration
 	securityConfig         *grpcutil.TLSConfig
+	// alerts of all the allocators
+	alerts *alertBuffer
+	// quotas of the TSO requests of each dc-location
+	quotas *quotaLimiter
 	// for gRPC use
 	localAllocatorConn struct {
 		sync.RWMutex
@@ -140,6 +144,8 @@ func NewAllocatorManager(
 		updatePhysicalInterval: cfg.TSOUpdatePhysicalInterval.Duration,
 		maxResetTSGap:          maxResetTSGap,
 		securityConfig:         &cfg.Security.TLSConfig,
+		alerts:                 newAlertBuffer(),
+		quotas:                 newQuotaLimiter(cfg.TSOQuotas),
 	}
 	allocatorManager.mu.allocatorGroups = make(map[string]*allocatorGroup)
 	allocatorManager.mu.clusterDCLocations = make(map[string]*DCLocationInfo)
@@ -147,6 +153,12 @@ func NewAllocatorManager(
 	return allocatorManager
 }
 
+// GetAlerts returns the recent alerts of updating the TSO, the latest one
+// comes first.
+func (am *AllocatorManager) GetAlerts() []Alert {
+	return am.alerts.getAll()
+}
+
 // SetLocalTSOConfig receives the zone label of this PD server and write it into etcd as dc-location
 // to make the whole cluster know the DC-level topology for later Local TSO Allocator campaign.
 func (am *AllocatorManager) SetLocalTSOConfig(dcLocation string) error {
@@ -653,13 +665,16 @@ func (am *AllocatorManager) ClusterDCLocationChecker() {
 	newDCLocations := make([]string, 0)
 	// Update the new dc-locations
 	for dcLocation, serverIDs := range newClusterDCLocations {
-		if _, ok := am.mu.clusterDCLocations[dcLocation]; !ok {
-			am.mu.clusterDCLocations[dcLocation] = &DCLocationInfo{
-				ServerIDs: serverIDs,
-				Suffix:    -1,
-			}
-			newDCLocations = append(newDCLocations, dcLocation)
+		if info, ok := am.mu.clusterDCLocations[dcLocation]; ok {
+			// The members may join or leave the existing dc-locations.
+			info.ServerIDs = serverIDs
+			continue
+		}
+		am.mu.clusterDCLocations[dcLocation] = &DCLocationInfo{
+			ServerIDs: serverIDs,
+			Suffix:    -1,
 		}
+		newDCLocations = append(newDCLocations, dcLocation)
 	}
 	// Only leader can write the TSO suffix to etcd in order to make it consistent in the cluster
 	if am.member.IsLeader() {
@@ -925,6 +940,17 @@ func (am *AllocatorManager) HandleTSORequest(dcLocation string, count uint32) (p
 	return allocatorGroup.allocator.GenerateTSO(count)
 }
 
+// TakeTSOQuota takes the quota for count timestamps of the dc-location for the
+// caller. It doesn't wait for the quota, and returns ErrTSOQuotaExceeded if the
+// quota is exceeded. Only the TSO requests of the clients take the quota, the
+// internal ones are not limited.
+func (am *AllocatorManager) TakeTSOQuota(dcLocation, caller string, count uint32) error {
+	if dcLocation == "" {
+		dcLocation = GlobalDCLocation
+	}
+	return am.quotas.take(dcLocation, caller, count)
+}
+
 // ResetAllocatorGroup will reset the allocator's leadership and TSO initialized in memory.
 // It usually should be called before re-triggering an Allocator leader campaign.
 func (am *AllocatorManager) ResetAllocatorGroup(dcLocation string) {
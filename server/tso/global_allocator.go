@@ -82,6 +82,7 @@ func NewGlobalTSOAllocator(
 			maxResetTSGap:          am.maxResetTSGap,
 			dcLocation:             GlobalDCLocation,
 			tsoMux:                 &tsoObject{},
+			alerts:                 am.alerts,
 		},
 	}
 	return gta
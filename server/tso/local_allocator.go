@@ -63,6 +63,7 @@ func NewLocalTSOAllocator(
 			maxResetTSGap:          am.maxResetTSGap,
 			dcLocation:             dcLocation,
 			tsoMux:                 &tsoObject{},
+			alerts:                 am.alerts,
 		},
 		rootPath: leadership.GetLeaderKey(),
 	}
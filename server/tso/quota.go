@@ -0,0 +1,156 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tikv/pd/pkg/errs"
+)
+
+const (
+	// tenantIdleTimeout is the duration after which a tenant without requests
+	// no longer takes a share of the quota.
+	tenantIdleTimeout = 10 * time.Second
+	// tenantGCInterval is the interval to remove the idle tenants.
+	tenantGCInterval = time.Second
+)
+
+// quotaLimiter limits the count of the timestamps allocated per second for
+// each dc-location. Every dc-location has its own budget, so the workload of
+// a dc-location cannot exhaust the budget of the others.
+//
+// The budget of a dc-location is shared fairly by its tenants, i.e. the
+// callers requesting its timestamps. Every active tenant is guaranteed an
+// equal share of the quota, and the quota left unused by the others can be
+// used by any tenant. The limiter never waits: a request exceeding the quota
+// is rejected at once, so the caller can retry it later.
+type quotaLimiter struct {
+	// The buckets are created once and the map is never modified, so no lock
+	// is needed to access it.
+	buckets map[string]*quotaBucket
+}
+
+// newQuotaLimiter creates a quotaLimiter with the quotas of the dc-locations,
+// the dc-locations without a positive quota are unlimited.
+func newQuotaLimiter(quotas map[string]uint64) *quotaLimiter {
+	buckets := make(map[string]*quotaBucket, len(quotas))
+	for dcLocation, quota := range quotas {
+		if quota == 0 {
+			continue
+		}
+		buckets[dcLocation] = newQuotaBucket(float64(quota), time.Now())
+	}
+	return &quotaLimiter{buckets: buckets}
+}
+
+// take takes the quota for count timestamps of the dc-location for the
+// tenant, and returns an error if the quota is exceeded.
+func (l *quotaLimiter) take(dcLocation, tenant string, count uint32) error {
+	bucket, ok := l.buckets[dcLocation]
+	if !ok {
+		return nil
+	}
+	if !bucket.take(tenant, float64(count), time.Now()) {
+		tsoCounter.WithLabelValues("quota_exceeded", dcLocation).Inc()
+		return errs.ErrTSOQuotaExceeded.FastGenByArgs(dcLocation)
+	}
+	return nil
+}
+
+// quotaBucket is the budget of a dc-location. The capacity of all the token
+// buckets is the quota of a second to allow bursts.
+type quotaBucket struct {
+	sync.Mutex
+	quota   float64
+	shared  tokenBucket
+	tenants map[string]*tenantBucket
+	lastGC  time.Time
+}
+
+type tenantBucket struct {
+	tokenBucket
+	lastSeen time.Time
+}
+
+func newQuotaBucket(quota float64, now time.Time) *quotaBucket {
+	return &quotaBucket{
+		quota:   quota,
+		shared:  tokenBucket{tokens: quota, last: now},
+		tenants: make(map[string]*tenantBucket),
+		lastGC:  now,
+	}
+}
+
+func (b *quotaBucket) take(tenant string, count float64, now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+	b.gcTenants(now)
+	t, ok := b.tenants[tenant]
+	if !ok {
+		t = &tenantBucket{tokenBucket: tokenBucket{last: now}}
+		b.tenants[tenant] = t
+	}
+	t.lastSeen = now
+	share := b.quota / float64(len(b.tenants))
+	if !ok {
+		t.tokens = share
+	}
+	b.shared.refill(now, b.quota)
+	t.refill(now, share)
+	// The request within the share of the tenant is always admitted. It is
+	// still counted in the shared bucket, which may go negative, so that the
+	// other tenants cannot use the share of the tenant.
+	if t.tokens >= count {
+		t.tokens -= count
+		b.shared.tokens -= count
+		return true
+	}
+	// The tenant exceeding its share can only use the unused quota.
+	if b.shared.tokens >= count {
+		b.shared.tokens -= count
+		return true
+	}
+	return false
+}
+
+func (b *quotaBucket) gcTenants(now time.Time) {
+	if now.Sub(b.lastGC) < tenantGCInterval {
+		return
+	}
+	b.lastGC = now
+	for tenant, t := range b.tenants {
+		if now.Sub(t.lastSeen) > tenantIdleTimeout {
+			delete(b.tenants, tenant)
+		}
+	}
+}
+
+// tokenBucket is a token bucket whose rate can be changed between refills.
+// The capacity of the bucket equals to its rate.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time, rate float64) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		b.last = now
+	}
+	if b.tokens > rate {
+		b.tokens = rate
+	}
+}
@@ -67,6 +67,8 @@ type timestampOracle struct {
 	lastSavedTime atomic.Value // stored as time.Time
 	suffix        int
 	dcLocation    string
+	// alerts collects the abnormal events when updating the TSO.
+	alerts *alertBuffer
 }
 
 func (t *timestampOracle) setTSOPhysical(next time.Time) {
@@ -312,10 +314,14 @@ func (t *timestampOracle) UpdateTimestamp(leadership *election.Leadership) error
 	if jetLag > 3*t.updatePhysicalInterval {
 		log.Warn("clock offset", zap.Duration("jet-lag", jetLag), zap.Time("prev-physical", prevPhysical), zap.Time("now", now), zap.Duration("update-physical-interval", t.updatePhysicalInterval))
 		tsoCounter.WithLabelValues("slow_save", t.dcLocation).Inc()
+		t.alerts.put(alertClockOffset, AlertWarning, t.dcLocation,
+			fmt.Sprintf("the gap between the physical time %s and now is %s, which is larger than %s", prevPhysical.Format(time.RFC3339Nano), jetLag, 3*t.updatePhysicalInterval))
 	}
 
 	if jetLag < 0 {
 		tsoCounter.WithLabelValues("system_time_slow", t.dcLocation).Inc()
+		t.alerts.put(alertSystemTimeSlow, AlertWarning, t.dcLocation,
+			fmt.Sprintf("the system time is %s behind the physical time %s", -jetLag, prevPhysical.Format(time.RFC3339Nano)))
 	}
 
 	var next time.Time
@@ -339,6 +345,8 @@ func (t *timestampOracle) UpdateTimestamp(leadership *election.Leadership) error
 		save := next.Add(t.saveInterval)
 		if err := t.saveTimestamp(leadership, save); err != nil {
 			tsoCounter.WithLabelValues("err_save_update_ts", t.dcLocation).Inc()
+			t.alerts.put(alertSaveFailed, AlertCritical, t.dcLocation,
+				fmt.Sprintf("failed to save the time window %s into etcd: %v", save.Format(time.RFC3339Nano), err))
 			return err
 		}
 	}
@@ -0,0 +1,157 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tikv/pd/pkg/typeutil"
+)
+
+// TSOStats is the statistics of the TSO requests handled by the server since it
+// starts, which helps to tune the batching of the clients.
+type TSOStats struct {
+	Streams    int    `json:"streams"`
+	Requests   uint64 `json:"requests"`
+	Timestamps uint64 `json:"timestamps"`
+	// AvgBatchSize is the average number of the timestamps per request.
+	AvgBatchSize float64 `json:"avg_batch_size"`
+	// AvgWaitTime is the average time between sending a response and
+	// receiving the next request on the same stream, which includes the time
+	// the client waits to collect the next batch.
+	AvgWaitTime   typeutil.Duration `json:"avg_wait_time"`
+	AvgHandleTime typeutil.Duration `json:"avg_handle_time"`
+	// Connections are the connections with the TSO streams.
+	Connections []*TSOConnectionStats `json:"connections"`
+}
+
+// TSOConnectionStats is the statistics of the TSO streams of a connection.
+type TSOConnectionStats struct {
+	Address         string    `json:"address"`
+	Streams         int       `json:"streams"`
+	Requests        uint64    `json:"requests"`
+	AvgBatchSize    float64   `json:"avg_batch_size"`
+	LastRequestTime time.Time `json:"last_request_time"`
+}
+
+// tsoStatsRecorder records the TSO requests handled by the gRPC Tso handler.
+// The counters are updated atomically, and the lock is only held when the
+// streams are opened and closed, so it doesn't slow down the TSO requests.
+type tsoStatsRecorder struct {
+	requests    uint64
+	timestamps  uint64
+	waits       uint64
+	waitNanos   uint64
+	handleNanos uint64
+
+	mu    sync.Mutex
+	conns map[string]*tsoConnStats
+}
+
+type tsoConnStats struct {
+	requests    uint64
+	timestamps  uint64
+	lastRequest int64
+
+	// streams is protected by the lock of the recorder.
+	streams int
+}
+
+func newTSOStatsRecorder() *tsoStatsRecorder {
+	return &tsoStatsRecorder{conns: make(map[string]*tsoConnStats)}
+}
+
+// openStream records a TSO stream of the connection, the stream should be
+// closed by closeStream.
+func (r *tsoStatsRecorder) openStream(addr string) *tsoConnStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.conns[addr]
+	if !ok {
+		conn = &tsoConnStats{}
+		r.conns[addr] = conn
+	}
+	conn.streams++
+	tsoStreamGauge.Inc()
+	return conn
+}
+
+func (r *tsoStatsRecorder) closeStream(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conn, ok := r.conns[addr]
+	if !ok {
+		return
+	}
+	conn.streams--
+	tsoStreamGauge.Dec()
+	if conn.streams == 0 {
+		delete(r.conns, addr)
+	}
+}
+
+// observe records a TSO request. The wait is 0 for the first request of a
+// stream, which has no previous response.
+func (r *tsoStatsRecorder) observe(conn *tsoConnStats, count uint32, wait, handle time.Duration, now time.Time) {
+	atomic.AddUint64(&r.requests, 1)
+	atomic.AddUint64(&r.timestamps, uint64(count))
+	atomic.AddUint64(&r.handleNanos, uint64(handle))
+	atomic.AddUint64(&conn.requests, 1)
+	atomic.AddUint64(&conn.timestamps, uint64(count))
+	atomic.StoreInt64(&conn.lastRequest, now.UnixNano())
+	tsoBatchSize.Observe(float64(count))
+	if wait > 0 {
+		atomic.AddUint64(&r.waits, 1)
+		atomic.AddUint64(&r.waitNanos, uint64(wait))
+		tsoWaitDuration.Observe(wait.Seconds())
+	}
+}
+
+func (r *tsoStatsRecorder) getStats() *TSOStats {
+	stats := &TSOStats{
+		Requests:   atomic.LoadUint64(&r.requests),
+		Timestamps: atomic.LoadUint64(&r.timestamps),
+	}
+	if stats.Requests > 0 {
+		stats.AvgBatchSize = float64(stats.Timestamps) / float64(stats.Requests)
+		stats.AvgHandleTime.Duration = time.Duration(atomic.LoadUint64(&r.handleNanos) / stats.Requests)
+	}
+	if waits := atomic.LoadUint64(&r.waits); waits > 0 {
+		stats.AvgWaitTime.Duration = time.Duration(atomic.LoadUint64(&r.waitNanos) / waits)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats.Connections = make([]*TSOConnectionStats, 0, len(r.conns))
+	for addr, conn := range r.conns {
+		c := &TSOConnectionStats{
+			Address:  addr,
+			Streams:  conn.streams,
+			Requests: atomic.LoadUint64(&conn.requests),
+		}
+		if c.Requests > 0 {
+			c.AvgBatchSize = float64(atomic.LoadUint64(&conn.timestamps)) / float64(c.Requests)
+			c.LastRequestTime = time.Unix(0, atomic.LoadInt64(&conn.lastRequest))
+		}
+		stats.Streams += conn.streams
+		stats.Connections = append(stats.Connections, c)
+	}
+	sort.Slice(stats.Connections, func(i, j int) bool {
+		return stats.Connections[i].Address < stats.Connections[j].Address
+	})
+	return stats
+}
@@ -17,7 +17,10 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"net"
+	"net/url"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -34,6 +37,9 @@ import (
 
 const (
 	requestTimeout = etcdutil.DefaultRequestTimeout
+	// advertiseDialTimeout is the timeout to dial an advertise url to check
+	// whether it is reachable.
+	advertiseDialTimeout = 3 * time.Second
 )
 
 // LogPDInfo prints the PD version information.
@@ -83,6 +89,31 @@ func CheckPDVersion(opt *config.PersistOptions) {
 	}
 }
 
+// checkAdvertiseUrls dials the advertise urls to check whether they are
+// reachable from the local host, it is best-effort and only logs the urls
+// which are unreachable, because they may be reachable from the others, e.g.
+// behind a NAT.
+func checkAdvertiseUrls(cfg *config.Config) {
+	for name, urls := range map[string]string{
+		"advertise-client-urls": cfg.AdvertiseClientUrls,
+		"advertise-peer-urls":   cfg.AdvertisePeerUrls,
+	} {
+		for _, item := range strings.Split(urls, ",") {
+			u, err := url.Parse(item)
+			if err != nil || u.Port() == "" {
+				continue
+			}
+			conn, err := net.DialTimeout("tcp", u.Host, advertiseDialTimeout)
+			if err != nil {
+				log.Warn("the advertise url is unreachable from the local host, the other members and the clients may fail to connect to it",
+					zap.String("name", name), zap.String("url", item), errs.ZapError(err))
+				continue
+			}
+			conn.Close()
+		}
+	}
+}
+
 func initOrGetClusterID(c *clientv3.Client, key string) (uint64, error) {
 	ctx, cancel := context.WithTimeout(c.Ctx(), requestTimeout)
 	defer cancel()
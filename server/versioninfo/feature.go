@@ -55,6 +55,13 @@ var featuresDict = map[Feature]string{
 	JointConsensus: "5.0.0",
 }
 
+// featureNames are the names of the feature gates exposed to the clients.
+var featureNames = map[Feature]string{
+	RegionMerge:    "region-merge",
+	BatchSplit:     "batch-split",
+	JointConsensus: "joint-consensus",
+}
+
 // MinSupportedVersion returns the minimum support version for the specified feature.
 func MinSupportedVersion(v Feature) *semver.Version {
 	target, ok := featuresDict[v]
@@ -64,3 +71,25 @@ func MinSupportedVersion(v Feature) *semver.Version {
 	version := MustParseVersion(target)
 	return version
 }
+
+// IsFeatureSupported checks if the feature is supported by the cluster version.
+func IsFeatureSupported(clusterVersion semver.Version, f Feature) bool {
+	minSupportVersion := *MinSupportedVersion(f)
+	// For features before version 5.0 (such as BatchSplit), strict version checks are performed according to the
+	// original logic. But according to Semantic Versioning, specify a version MAJOR.MINOR.PATCH, PATCH is used when you
+	// make backwards compatible bug fixes. In version 5.0 and later, we need to strictly comply.
+	if IsCompatible(minSupportVersion, *MinSupportedVersion(Version4_0)) {
+		return !clusterVersion.LessThan(minSupportVersion)
+	}
+	return IsCompatible(minSupportVersion, clusterVersion)
+}
+
+// FeatureGates returns whether each named feature is enabled by the cluster
+// version, keyed by the feature name.
+func FeatureGates(clusterVersion semver.Version) map[string]bool {
+	gates := make(map[string]bool, len(featureNames))
+	for f, name := range featureNames {
+		gates[name] = IsFeatureSupported(clusterVersion, f)
+	}
+	return gates
+}
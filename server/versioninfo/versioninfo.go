@@ -22,6 +22,10 @@ import (
 const (
 	// CommunityEdition is the default edition for building.
 	CommunityEdition = "Community"
+	// APICompatibilityLevel is the compatibility level of the HTTP and gRPC
+	// APIs, it is increased when an incompatible change is made to them, so
+	// the clients can tell whether they can talk to the server.
+	APICompatibilityLevel = 1
 )
 
 // Version information.
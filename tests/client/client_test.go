@@ -21,6 +21,7 @@ import (
 	"math"
 	"path"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -38,6 +39,7 @@ import (
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/core"
 	"github.com/tikv/pd/server/tso"
+	"github.com/tikv/pd/server/versioninfo"
 	"github.com/tikv/pd/tests"
 	"go.uber.org/goleak"
 )
@@ -76,6 +78,7 @@ type client interface {
 	ScheduleCheckLeader()
 	GetURLs() []string
 	GetAllocatorLeaderURLs() map[string]string
+	GetLocalFollowerAddr() []string
 }
 
 func (s *clientTestSuite) TestClientLeaderChange(c *C) {
@@ -324,6 +327,97 @@ func (s *clientTestSuite) TestGlobalAndLocalTSO(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *clientTestSuite) TestLocalTSOFallbackPolicy(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+
+	// Fail fast without retrying.
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithLocalTSOFallbackPolicy(pd.LocalTSOFailFast))
+	c.Assert(err, IsNil)
+	start := time.Now()
+	future := cli.GetLocalTSAsync(context.TODO(), "nonexistent-dc")
+	_, _, err = future.Wait()
+	c.Assert(err, NotNil)
+	c.Assert(pd.IsGlobalTSOFallback(future), IsFalse)
+	c.Assert(time.Since(start), Less, 50*time.Millisecond)
+	cli.Close()
+
+	// Retry with backoff: 50ms + 100ms + 200ms.
+	cli, err = pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithLocalTSOMaxRetry(3))
+	c.Assert(err, IsNil)
+	start = time.Now()
+	_, _, err = cli.GetLocalTS(context.TODO(), "nonexistent-dc")
+	c.Assert(err, NotNil)
+	c.Assert(time.Since(start), GreaterEqual, 350*time.Millisecond)
+	cli.Close()
+
+	// Fall back to the Global TSO.
+	cli, err = pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithLocalTSOFallbackPolicy(pd.LocalTSOFallbackToGlobal))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	physical1, logical1, err := cli.GetTS(context.TODO())
+	c.Assert(err, IsNil)
+	future = cli.GetLocalTSAsync(context.TODO(), "nonexistent-dc")
+	physical2, logical2, err := future.Wait()
+	c.Assert(err, IsNil)
+	c.Assert(pd.IsGlobalTSOFallback(future), IsTrue)
+	c.Assert(tsoutil.ComposeTS(physical1, logical1), Less, tsoutil.ComposeTS(physical2, logical2))
+	// The request of the Global TSO is not affected.
+	future = cli.GetTSAsync(context.TODO())
+	_, _, err = future.Wait()
+	c.Assert(err, IsNil)
+	c.Assert(pd.IsGlobalTSOFallback(future), IsFalse)
+}
+
+func (s *clientTestSuite) TestLocalTSOFallbackAfterAllocatorDown(c *C) {
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	dcLocationNum := len(dcLocationConfig)
+	cluster, err := tests.NewTestCluster(s.ctx, dcLocationNum, func(conf *config.Config, serverName string) {
+		conf.EnableLocalTSO = true
+		conf.Labels[config.ZoneLabel] = dcLocationConfig[serverName]
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	cluster.WaitAllLeaders(c, dcLocationConfig)
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithLocalTSOFallbackPolicy(pd.LocalTSOFallbackToGlobal))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	// Pick a dc-location whose Local TSO allocator leader is not the PD leader.
+	leader := cluster.GetLeader()
+	var dcLocation, pdName string
+	for _, dc := range dcLocationConfig {
+		if name := cluster.WaitAllocatorLeader(dc); len(name) > 0 && name != leader {
+			dcLocation, pdName = dc, name
+			break
+		}
+	}
+	c.Assert(pdName, Not(Equals), "")
+	future := cli.GetLocalTSAsync(context.TODO(), dcLocation)
+	_, _, err = future.Wait()
+	c.Assert(err, IsNil)
+	c.Assert(pd.IsGlobalTSOFallback(future), IsFalse)
+
+	// The tso stream of the dc-location fails after its allocator leader is down.
+	c.Assert(cluster.GetServer(pdName).Stop(), IsNil)
+	physical1, logical1, err := cli.GetTS(context.TODO())
+	c.Assert(err, IsNil)
+	future = cli.GetLocalTSAsync(context.TODO(), dcLocation)
+	physical2, logical2, err := future.Wait()
+	c.Assert(err, IsNil)
+	c.Assert(pd.IsGlobalTSOFallback(future), IsTrue)
+	c.Assert(tsoutil.ComposeTS(physical1, logical1), Less, tsoutil.ComposeTS(physical2, logical2))
+}
+
 func (s *clientTestSuite) TestCustomTimeout(c *C) {
 	cluster, err := tests.NewTestCluster(s.ctx, 1)
 	c.Assert(err, IsNil)
@@ -342,6 +436,136 @@ func (s *clientTestSuite) TestCustomTimeout(c *C) {
 	c.Assert(time.Since(start), Less, 2*time.Second)
 }
 
+func (s *clientTestSuite) TestClusterFeatures(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithClusterFeaturesWatchInterval(100*time.Millisecond))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	svr := cluster.GetServer(cluster.GetLeader()).GetServer()
+	c.Assert(svr.SetClusterVersion("4.0.0"), IsNil)
+	features, err := cli.GetClusterFeatures(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(features.ClusterVersion, Equals, "4.0.0")
+	c.Assert(features.IsEnabled(pd.FeatureBatchSplit), IsTrue)
+	c.Assert(features.IsEnabled(pd.FeatureJointConsensus), IsFalse)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := cli.WatchClusterFeatures(ctx)
+	c.Assert(err, IsNil)
+	features = <-ch
+	c.Assert(features.ClusterVersion, Equals, "4.0.0")
+	c.Assert(svr.SetClusterVersion("5.0.0"), IsNil)
+	select {
+	case features = <-ch:
+		c.Assert(features.ClusterVersion, Equals, "5.0.0")
+		c.Assert(features.IsEnabled(pd.FeatureJointConsensus), IsTrue)
+	case <-time.After(3 * time.Second):
+		c.Fatal("cluster features change is not watched")
+	}
+	cancel()
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, ok := <-ch
+		return !ok
+	})
+}
+
+func (s *clientTestSuite) TestServiceVersions(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+	defer cli.Close()
+
+	svr := cluster.GetServer(cluster.GetLeader()).GetServer()
+	c.Assert(svr.SetClusterVersion("5.0.0"), IsNil)
+	versions, err := cli.GetServiceVersions(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(versions.ClusterVersion, Equals, "5.0.0")
+	c.Assert(versions.Features[pd.FeatureJointConsensus], IsTrue)
+	c.Assert(versions.APICompatibilityLevel, Equals, versioninfo.APICompatibilityLevel)
+}
+
+func (s *clientTestSuite) TestConnPool(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 2)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	pool := pd.NewConnPool()
+	cli1, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithConnPool(pool))
+	c.Assert(err, IsNil)
+	// The members dialed by the first client are shared with the second one.
+	conns := pool.ConnCount()
+	c.Assert(conns, Greater, 0)
+	cli2, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{}, pd.WithConnPool(pool))
+	c.Assert(err, IsNil)
+	c.Assert(pool.ConnCount(), Equals, conns)
+
+	for _, cli := range []pd.Client{cli1, cli2} {
+		_, _, err = cli.GetTS(context.Background())
+		c.Assert(err, IsNil)
+	}
+	// The clients share the TSO dispatcher of the global dc-location.
+	c.Assert(pool.TSODispatcherCount(), Equals, 1)
+	// The connections and the dispatcher are kept until all the clients are
+	// closed, and the dispatcher is taken over by the client left.
+	cli1.Close()
+	c.Assert(pool.ConnCount(), Equals, conns)
+	c.Assert(pool.TSODispatcherCount(), Equals, 1)
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, _, err = cli2.GetTS(context.Background())
+		return err == nil
+	})
+	cli2.Close()
+	c.Assert(pool.ConnCount(), Equals, 0)
+	c.Assert(pool.TSODispatcherCount(), Equals, 0)
+}
+
+func (s *clientTestSuite) TestKeyspace(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	cli := s.setupCli(c, endpoints, false)
+	defer cli.Close()
+
+	ctx := context.Background()
+	ks1, err := cli.CreateKeyspace(ctx, "ks1", map[string]string{"gc_life_time": "10m"})
+	c.Assert(err, IsNil)
+	c.Assert(ks1.ID, Equals, uint32(1))
+	c.Assert(ks1.State, Equals, pd.KeyspaceStateEnabled)
+	_, err = cli.CreateKeyspace(ctx, "ks1", nil)
+	c.Assert(err, NotNil)
+	ks2, err := cli.CreateKeyspace(ctx, "ks2", nil)
+	c.Assert(err, IsNil)
+	c.Assert(ks2.ID, Equals, uint32(2))
+
+	keyspaces, err := cli.GetAllKeyspaces(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(keyspaces, DeepEquals, []*pd.KeyspaceMeta{ks1, ks2})
+	ks, err := cli.DisableKeyspace(ctx, "ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.State, Equals, pd.KeyspaceStateDisabled)
+	ks, err = cli.GetKeyspace(ctx, "ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.ID, Equals, uint32(1))
+	c.Assert(ks.State, Equals, pd.KeyspaceStateDisabled)
+	ks, err = cli.EnableKeyspace(ctx, "ks1")
+	c.Assert(err, IsNil)
+	c.Assert(ks.State, Equals, pd.KeyspaceStateEnabled)
+	_, err = cli.GetKeyspace(ctx, "ks3")
+	c.Assert(err, NotNil)
+}
+
 func (s *clientTestSuite) TestGetRegionFromFollowerClient(c *C) {
 	pd.LeaderHealthCheckInterval = 100 * time.Millisecond
 	cluster, err := tests.NewTestCluster(s.ctx, 3)
@@ -364,6 +588,73 @@ func (s *clientTestSuite) TestGetRegionFromFollowerClient(c *C) {
 	c.Assert(r, NotNil)
 }
 
+func (s *clientTestSuite) TestLocalRegionRead(c *C) {
+	dcLocationConfig := map[string]string{
+		"pd1": "dc-1",
+		"pd2": "dc-2",
+		"pd3": "dc-3",
+	}
+	cluster, err := tests.NewTestCluster(s.ctx, len(dcLocationConfig), func(conf *config.Config, serverName string) {
+		conf.EnableLocalTSO = true
+		conf.Labels[config.ZoneLabel] = dcLocationConfig[serverName]
+	})
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	endpoints := s.runServer(c, cluster)
+	cluster.WaitAllLeaders(c, dcLocationConfig)
+	leader := cluster.GetServer(cluster.GetLeader())
+	var follower *tests.TestServer
+	for _, svr := range cluster.GetServers() {
+		if svr != leader {
+			follower = svr
+			break
+		}
+	}
+	// The region is only known by the follower, so that it can be got only if
+	// the lookup is served by the follower.
+	regionID := regionIDAllocator.alloc()
+	region := &metapb.Region{
+		Id:          regionID,
+		RegionEpoch: &metapb.RegionEpoch{ConfVer: 1, Version: 1},
+		Peers:       peers,
+	}
+	follower.GetServer().GetBasicCluster().PutRegion(core.NewRegionInfo(region, peers[0]))
+
+	cli, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{},
+		pd.WithLocalRegionRead(dcLocationConfig[follower.GetConfig().Name]))
+	c.Assert(err, IsNil)
+	defer cli.Close()
+	testutil.WaitUntil(c, func(c *C) bool {
+		cli.(client).ScheduleCheckLeader()
+		addrs := cli.(client).GetLocalFollowerAddr()
+		return len(addrs) == 1 && addrs[0] == follower.GetConfig().AdvertiseClientUrls
+	})
+	members, err := cli.GetAllMembers(context.Background())
+	c.Assert(err, IsNil)
+	for _, m := range members {
+		c.Assert(m.GetDcLocation(), Equals, dcLocationConfig[m.GetName()])
+	}
+	r, err := cli.GetRegionByID(context.Background(), regionID)
+	c.Assert(err, IsNil)
+	c.Assert(r, NotNil)
+	c.Assert(r.Meta, DeepEquals, region)
+	// The lookup which is not found in the follower falls back to the leader.
+	r, err = cli.GetRegion(context.Background(), []byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(r, NotNil)
+
+	// The client in the dc-location of the leader sends the lookups to the leader.
+	cli2, err := pd.NewClientWithContext(s.ctx, endpoints, pd.SecurityOption{},
+		pd.WithLocalRegionRead(dcLocationConfig[leader.GetConfig().Name]))
+	c.Assert(err, IsNil)
+	defer cli2.Close()
+	c.Assert(cli2.(client).GetLocalFollowerAddr(), HasLen, 0)
+	r, err = cli2.GetRegionByID(context.Background(), regionID)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsNil)
+}
+
 // case 1: unreachable -> normal
 func (s *clientTestSuite) TestGetTsoFromFollowerClient1(c *C) {
 	pd.LeaderHealthCheckInterval = 100 * time.Millisecond
@@ -753,6 +1044,17 @@ func (s *testClientSuite) TestScanRegions(c *C) {
 	check([]byte{100}, nil, 1, nil)
 	check([]byte{1}, []byte{6}, 0, regions[1:6])
 	check([]byte{1}, []byte{6}, 2, regions[1:3])
+
+	// The truncated responses are iterated by the client.
+	cfg := s.srv.GetPDServerConfig()
+	maxLimit := cfg.MaxScanRegionsLimit
+	cfg.MaxScanRegionsLimit = 3
+	c.Assert(s.srv.SetPDServerConfig(*cfg), IsNil)
+	check([]byte{0}, nil, 10, regions)
+	check([]byte{1}, nil, 5, regions[1:6])
+	check([]byte{1}, []byte{6}, 0, regions[1:6])
+	cfg.MaxScanRegionsLimit = maxLimit
+	c.Assert(s.srv.SetPDServerConfig(*cfg), IsNil)
 }
 
 func (s *testClientSuite) TestGetRegionByID(c *C) {
@@ -783,6 +1085,65 @@ func (s *testClientSuite) TestGetRegionByID(c *C) {
 			c.Check(r.Leader, DeepEquals, peers[0])
 	})
 	c.Succeed()
+
+	// The response cached by the server is the same.
+	r, err := s.client.GetRegionByID(context.Background(), regionID)
+	c.Assert(err, IsNil)
+	c.Assert(r.Meta, DeepEquals, region)
+	c.Assert(r.Leader, DeepEquals, peers[0])
+}
+
+func (s *testClientSuite) TestGetRegionsByIDs(c *C) {
+	regionIDs := make([]uint64, 0, 3)
+	for i := 0; i < 3; i++ {
+		regionID := regionIDAllocator.alloc()
+		region := &metapb.Region{
+			Id: regionID,
+			RegionEpoch: &metapb.RegionEpoch{
+				ConfVer: 1,
+				Version: 1,
+			},
+			StartKey: []byte{0xf0, byte(i)},
+			EndKey:   []byte{0xf0, byte(i + 1)},
+			Peers:    peers,
+		}
+		req := &pdpb.RegionHeartbeatRequest{
+			Header: newHeader(s.srv),
+			Region: region,
+			Leader: peers[0],
+		}
+		c.Assert(s.regionHeartbeat.Send(req), IsNil)
+		regionIDs = append(regionIDs, regionID)
+	}
+	// The region which does not exist.
+	ids := append([]uint64{regionIDAllocator.alloc()}, regionIDs...)
+
+	testutil.WaitUntil(c, func(c *C) bool {
+		results := s.client.GetRegionsByIDs(context.Background(), ids)
+		c.Assert(results, HasLen, len(ids))
+		for i, r := range results {
+			c.Assert(r.Err, IsNil)
+			c.Assert(r.RegionID, Equals, ids[i])
+			if i == 0 {
+				c.Assert(r.Region, IsNil)
+				continue
+			}
+			if r.Region == nil {
+				return false
+			}
+			c.Assert(r.Region.Meta.GetId(), Equals, ids[i])
+			c.Assert(r.Region.Leader, DeepEquals, peers[0])
+		}
+		return true
+	})
+
+	// The ids are failed if the context is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range s.client.GetRegionsByIDs(ctx, ids) {
+		c.Assert(r.Region, IsNil)
+		c.Assert(r.Err, NotNil)
+	}
 }
 
 func (s *testClientSuite) TestGetStore(c *C) {
@@ -799,6 +1160,28 @@ func (s *testClientSuite) TestGetStore(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(stores, DeepEquals, stores)
 
+	// The truncated responses are iterated by the client.
+	cfg := s.srv.GetPDServerConfig()
+	maxSize := cfg.MaxResponseSize
+	cfg.MaxResponseSize = 1
+	c.Assert(s.srv.SetPDServerConfig(*cfg), IsNil)
+	pagedStores, err := s.client.GetAllStores(context.Background())
+	c.Assert(err, IsNil)
+	c.Assert(pagedStores, HasLen, len(stores))
+	storeIDs := make(map[uint64]struct{}, len(stores))
+	for _, store := range stores {
+		storeIDs[store.GetId()] = struct{}{}
+	}
+	for i, store := range pagedStores {
+		_, ok := storeIDs[store.GetId()]
+		c.Assert(ok, IsTrue)
+		if i > 0 {
+			c.Assert(store.GetId(), Greater, pagedStores[i-1].GetId())
+		}
+	}
+	cfg.MaxResponseSize = maxSize
+	c.Assert(s.srv.SetPDServerConfig(*cfg), IsNil)
+
 	// Mark the store as offline.
 	err = cluster.RemoveStore(store.GetId(), false)
 	c.Assert(err, IsNil)
@@ -1071,6 +1454,42 @@ func (s *testClientSuite) TestScatterRegion(c *C) {
 	c.Succeed()
 }
 
+func (s *testClientSuite) TestIdempotencyKey(c *C) {
+	regionID := regionIDAllocator.alloc()
+	region := &metapb.Region{
+		Id: regionID,
+		RegionEpoch: &metapb.RegionEpoch{
+			ConfVer: 1,
+			Version: 1,
+		},
+		Peers:    peers,
+		StartKey: []byte("hhh"),
+		EndKey:   []byte("iii"),
+	}
+	req := &pdpb.RegionHeartbeatRequest{
+		Header: newHeader(s.srv),
+		Region: region,
+		Leader: peers[0],
+	}
+	c.Assert(s.regionHeartbeat.Send(req), IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		r, err := s.client.GetRegionByID(context.Background(), regionID)
+		return c.Check(err, IsNil) && c.Check(r, NotNil)
+	})
+
+	regionsID := []uint64{regionID}
+	resp, err := s.client.ScatterRegions(context.Background(), regionsID, pd.WithIdempotencyKey("scatter-1"))
+	c.Assert(err, IsNil)
+	// The retry gets the response of the first request.
+	retryResp, err := s.client.ScatterRegions(context.Background(), regionsID, pd.WithIdempotencyKey("scatter-1"))
+	c.Assert(err, IsNil)
+	c.Assert(retryResp, DeepEquals, resp)
+	// The key can't be reused by the different request.
+	_, err = s.client.ScatterRegions(context.Background(), []uint64{regionID, regionID + 1}, pd.WithIdempotencyKey("scatter-1"))
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "ErrIdempotencyKeyReused"), IsTrue)
+}
+
 type testConfigTTLSuite struct {
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -0,0 +1,158 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	pd "github.com/tikv/pd/client"
+	pdhttp "github.com/tikv/pd/client/http"
+	"github.com/tikv/pd/tests"
+	"github.com/tikv/pd/tests/pdctl"
+)
+
+var _ = Suite(&httpClientTestSuite{})
+
+type httpClientTestSuite struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	cluster *tests.TestCluster
+	client  pdhttp.Client
+}
+
+func (s *httpClientTestSuite) SetUpSuite(c *C) {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	s.cluster = cluster
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.BootstrapCluster(), IsNil)
+
+	for _, id := range []uint64{1, 2} {
+		pdctl.MustPutStore(c, leader.GetServer(), &metapb.Store{Id: id, State: metapb.StoreState_Up, LastHeartbeat: time.Now().UnixNano()})
+	}
+	pdctl.MustPutRegion(c, cluster, 10, 1, []byte("a"), []byte("b"))
+
+	s.client, err = pdhttp.NewClient([]string{leader.GetAddr()}, pd.SecurityOption{})
+	c.Assert(err, IsNil)
+}
+
+func (s *httpClientTestSuite) TearDownSuite(c *C) {
+	s.client.Close()
+	s.cluster.Destroy()
+	s.cancel()
+}
+
+func (s *httpClientTestSuite) TestStores(c *C) {
+	stores, err := s.client.GetStores(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(stores.Count, Equals, 2)
+	store, err := s.client.GetStore(s.ctx, 1)
+	c.Assert(err, IsNil)
+	c.Assert(store.Store.GetId(), Equals, uint64(1))
+
+	c.Assert(s.client.SetStoreLabels(s.ctx, 2, map[string]string{"zone": "z1"}), IsNil)
+	store, err = s.client.GetStore(s.ctx, 2)
+	c.Assert(err, IsNil)
+	c.Assert(store.Store.Labels, HasLen, 1)
+	c.Assert(store.Store.Labels[0].GetValue(), Equals, "z1")
+
+	_, err = s.client.GetStore(s.ctx, 100)
+	c.Assert(err, NotNil)
+}
+
+func (s *httpClientTestSuite) TestRegions(c *C) {
+	region, err := s.client.GetRegionByID(s.ctx, 10)
+	c.Assert(err, IsNil)
+	c.Assert(region.ID, Equals, uint64(10))
+	region, err = s.client.GetRegionByKey(s.ctx, []byte("a1"))
+	c.Assert(err, IsNil)
+	c.Assert(region.ID, Equals, uint64(10))
+	region, err = s.client.GetRegionByID(s.ctx, 100)
+	c.Assert(err, IsNil)
+	c.Assert(region, IsNil)
+
+	regions, err := s.client.GetRegions(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	regions, err = s.client.GetRegionsByStoreID(s.ctx, 1)
+	c.Assert(err, IsNil)
+	c.Assert(regions.Count, Equals, 1)
+	regions, err = s.client.GetRegionsByStoreID(s.ctx, 2)
+	c.Assert(err, IsNil)
+	c.Assert(regions.Count, Equals, 0)
+}
+
+func (s *httpClientTestSuite) TestConfig(c *C) {
+	c.Assert(s.client.SetConfig(s.ctx, map[string]interface{}{"leader-schedule-limit": 100}), IsNil)
+	config, err := s.client.GetScheduleConfig(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(config["leader-schedule-limit"], Equals, float64(100))
+	config, err = s.client.GetConfig(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(config["schedule"], NotNil)
+	_, err = s.client.GetReplicateConfig(s.ctx)
+	c.Assert(err, IsNil)
+
+	c.Assert(s.client.SetConfig(s.ctx, map[string]interface{}{"unknown-config": 1}), NotNil)
+}
+
+func (s *httpClientTestSuite) TestSchedulers(c *C) {
+	c.Assert(s.client.CreateScheduler(s.ctx, "evict-leader-scheduler", 1), IsNil)
+	schedulers, err := s.client.GetSchedulers(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(containsString(schedulers, "evict-leader-scheduler"), IsTrue)
+	c.Assert(s.client.PauseScheduler(s.ctx, "evict-leader-scheduler", 0), IsNil)
+	c.Assert(s.client.DeleteScheduler(s.ctx, "evict-leader-scheduler"), IsNil)
+	schedulers, err = s.client.GetSchedulers(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(containsString(schedulers, "evict-leader-scheduler"), IsFalse)
+	c.Assert(s.client.CreateScheduler(s.ctx, "unknown-scheduler", 0), NotNil)
+}
+
+func (s *httpClientTestSuite) TestOperators(c *C) {
+	c.Assert(s.client.CreateOperator(s.ctx, map[string]interface{}{
+		"name":      "add-peer",
+		"region_id": 10,
+		"store_id":  2,
+	}), IsNil)
+	operators, err := s.client.GetOperators(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(operators, HasLen, 1)
+	op, err := s.client.GetOperatorByRegionID(s.ctx, 10)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(op, "status: RUNNING"), IsTrue)
+	c.Assert(s.client.DeleteOperatorByRegionID(s.ctx, 10), IsNil)
+	// The status of the canceled operator is kept for a while.
+	op, err = s.client.GetOperatorByRegionID(s.ctx, 10)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(op, "status: CANCEL"), IsTrue)
+	operators, err = s.client.GetOperators(s.ctx)
+	c.Assert(err, IsNil)
+	c.Assert(operators, HasLen, 0)
+}
+
+func containsString(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
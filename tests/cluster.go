@@ -66,8 +66,20 @@ type TestServer struct {
 
 var zapLogOnce sync.Once
 
+// ServerOption is used to customize the server in test, it is applied before
+// the server runs.
+type ServerOption func(svr *server.Server)
+
+// WithGRPCInterceptors sets the extra interceptors of the PD gRPC service, which
+// can be used to inject latency or simulate authentication failures.
+func WithGRPCInterceptors(interceptors *server.GRPCInterceptors) ServerOption {
+	return func(svr *server.Server) {
+		svr.SetGRPCInterceptors(interceptors)
+	}
+}
+
 // NewTestServer creates a new TestServer.
-func NewTestServer(ctx context.Context, cfg *config.Config) (*TestServer, error) {
+func NewTestServer(ctx context.Context, cfg *config.Config, opts ...ServerOption) (*TestServer, error) {
 	err := cfg.SetupLogger()
 	if err != nil {
 		return nil, err
@@ -85,6 +97,9 @@ func NewTestServer(ctx context.Context, cfg *config.Config) (*TestServer, error)
 	if err != nil {
 		return nil, err
 	}
+	for _, opt := range opts {
+		opt(svr)
+	}
 	return &TestServer{
 		server: svr,
 		state:  Initial,
@@ -383,6 +398,8 @@ func (s *TestServer) GetTSOAllocatorManager() *tso.AllocatorManager {
 type TestCluster struct {
 	config  *clusterConfig
 	servers map[string]*TestServer
+	// serverOpts are applied to all the servers, including the joined ones.
+	serverOpts []ServerOption
 	// tsPool is used to check the TSO uniqueness among the test cluster
 	tsPool struct {
 		sync.Mutex
@@ -398,6 +415,12 @@ type ConfigOption func(conf *config.Config, serverName string)
 
 // NewTestCluster creates a new TestCluster.
 func NewTestCluster(ctx context.Context, initialServerCount int, opts ...ConfigOption) (*TestCluster, error) {
+	return NewTestClusterWithServerOptions(ctx, initialServerCount, nil, opts...)
+}
+
+// NewTestClusterWithServerOptions creates a new TestCluster whose servers are
+// customized by serverOpts.
+func NewTestClusterWithServerOptions(ctx context.Context, initialServerCount int, serverOpts []ServerOption, opts ...ConfigOption) (*TestCluster, error) {
 	config := newClusterConfig(initialServerCount)
 	servers := make(map[string]*TestServer)
 	for _, conf := range config.InitialServers {
@@ -405,15 +428,16 @@ func NewTestCluster(ctx context.Context, initialServerCount int, opts ...ConfigO
 		if err != nil {
 			return nil, err
 		}
-		s, err := NewTestServer(ctx, serverConf)
+		s, err := NewTestServer(ctx, serverConf, serverOpts...)
 		if err != nil {
 			return nil, err
 		}
 		servers[conf.Name] = s
 	}
 	return &TestCluster{
-		config:  config,
-		servers: servers,
+		config:     config,
+		servers:    servers,
+		serverOpts: serverOpts,
 		tsPool: struct {
 			sync.Mutex
 			pool map[uint64]struct{}
@@ -628,7 +652,7 @@ func (c *TestCluster) Join(ctx context.Context, opts ...ConfigOption) (*TestServ
 	if err != nil {
 		return nil, err
 	}
-	s, err := NewTestServer(ctx, conf)
+	s, err := NewTestServer(ctx, conf, c.serverOpts...)
 	if err != nil {
 		return nil, err
 	}
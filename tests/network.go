@@ -0,0 +1,106 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/failpoint"
+	"go.etcd.io/etcd/pkg/types"
+)
+
+// The failpoints of the PD client to simulate the unreachable network to the leader.
+const (
+	clientUnreachableLeaderFailpoint       = "github.com/tikv/pd/client/unreachableNetwork"
+	clientUnreachableLeaderHealthFailpoint = "github.com/tikv/pd/client/unreachableNetwork1"
+)
+
+// CutPeer drops the etcd raft messages sent from this server to the peer.
+func (s *TestServer) CutPeer(peerID uint64) {
+	s.RLock()
+	defer s.RUnlock()
+	s.server.GetMember().Etcd().Server.CutPeer(types.ID(peerID))
+}
+
+// MendPeer recovers the etcd raft messages sent from this server to the peer.
+func (s *TestServer) MendPeer(peerID uint64) {
+	s.RLock()
+	defer s.RUnlock()
+	s.server.GetMember().Etcd().Server.MendPeer(types.ID(peerID))
+}
+
+// PartitionMembers simulates a network partition which splits the members into
+// the given groups. The members in different groups can't exchange the etcd raft
+// messages, so the group without quorum loses the etcd and PD leadership. The
+// members not in any group are not affected.
+func (c *TestCluster) PartitionMembers(groups ...[]string) error {
+	groupOf := make(map[string]int)
+	for i, group := range groups {
+		for _, name := range group {
+			if _, ok := c.servers[name]; !ok {
+				return errors.Errorf("server %s not found", name)
+			}
+			groupOf[name] = i
+		}
+	}
+	for name, i := range groupOf {
+		for peerName, j := range groupOf {
+			if i != j {
+				c.servers[name].CutPeer(c.servers[peerName].GetServerID())
+			}
+		}
+	}
+	return nil
+}
+
+// IsolateMember simulates a network partition between the member and all the
+// other members.
+func (c *TestCluster) IsolateMember(name string) error {
+	others := make([]string, 0, len(c.servers))
+	for peerName := range c.servers {
+		if peerName != name {
+			others = append(others, peerName)
+		}
+	}
+	return c.PartitionMembers([]string{name}, others)
+}
+
+// HealPartitions recovers all the network partitions between the members.
+func (c *TestCluster) HealPartitions() {
+	for name, s := range c.servers {
+		for peerName, peer := range c.servers {
+			if name != peerName {
+				s.MendPeer(peer.GetServerID())
+			}
+		}
+	}
+}
+
+// PartitionClientFromLeader simulates a network partition between the PD clients
+// and the PD leader, the clients with forwarding enabled send the requests to the
+// followers which forward them to the leader. It requires the failpoints enabled.
+func PartitionClientFromLeader() error {
+	if err := failpoint.Enable(clientUnreachableLeaderHealthFailpoint, "return(true)"); err != nil {
+		return err
+	}
+	return failpoint.Enable(clientUnreachableLeaderFailpoint, "return(true)")
+}
+
+// HealClientPartition recovers the network partition between the PD clients and
+// the PD leader.
+func HealClientPartition() error {
+	if err := failpoint.Disable(clientUnreachableLeaderHealthFailpoint); err != nil {
+		return err
+	}
+	return failpoint.Disable(clientUnreachableLeaderFailpoint)
+}
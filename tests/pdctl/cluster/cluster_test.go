@@ -16,6 +16,8 @@ package cluster_test
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +25,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/tikv/pd/server"
+	"github.com/tikv/pd/server/api"
 	clusterpkg "github.com/tikv/pd/server/cluster"
 	"github.com/tikv/pd/tests"
 	"github.com/tikv/pd/tests/pdctl"
@@ -99,3 +102,67 @@ func (s *clusterTestSuite) TestClusterAndPing(c *C) {
 	_, err = pdctl.ExecuteCommand(cmd, args...)
 	c.Assert(err, ErrorMatches, ".*no such file or directory.*")
 }
+
+func (s *clusterTestSuite) TestClusterDump(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+	defer cluster.Destroy()
+
+	pdctl.MustPutStore(c, leaderServer.GetServer(), &metapb.Store{
+		Id:            2,
+		State:         metapb.StoreState_Up,
+		LastHeartbeat: time.Now().UnixNano(),
+	})
+	pdctl.MustPutRegion(c, cluster, 10, 1, []byte(""), []byte("b"))
+	pdctl.MustPutRegion(c, cluster, 11, 2, []byte("b"), []byte("d"))
+	pdctl.MustPutRegion(c, cluster, 12, 1, []byte("d"), []byte(""))
+
+	dir := c.MkDir()
+	args := []string{"-u", pdAddr, "cluster", "dump", "--out", dir}
+	output, err := pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "region: 3"), IsTrue)
+
+	var stores []*api.StoreInfo
+	data, err := os.ReadFile(filepath.Join(dir, "stores.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &stores), IsNil)
+	c.Assert(stores, HasLen, 2)
+	var regions []*api.RegionInfo
+	data, err = os.ReadFile(filepath.Join(dir, "regions.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &regions), IsNil)
+	c.Assert(regions, HasLen, 3)
+	c.Assert(regions[0].ID, Equals, uint64(10))
+	var operators []json.RawMessage
+	data, err = os.ReadFile(filepath.Join(dir, "operators.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &operators), IsNil)
+	data, err = os.ReadFile(filepath.Join(dir, "config.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Valid(data), IsTrue)
+
+	// Dump the sampled regions.
+	args = []string{"-u", pdAddr, "cluster", "dump", "--out", dir, "--sample", "3"}
+	_, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	data, err = os.ReadFile(filepath.Join(dir, "regions.json"))
+	c.Assert(err, IsNil)
+	c.Assert(json.Unmarshal(data, &regions), IsNil)
+	c.Assert(regions, HasLen, 1)
+
+	// The output directory is required. The flags are kept by the command, so
+	// a new one is used.
+	output, err = pdctl.ExecuteCommand(pdctlCmd.GetRootCmd(), "-u", pdAddr, "cluster", "dump")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "--out"), IsTrue)
+}
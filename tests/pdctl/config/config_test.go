@@ -17,6 +17,7 @@ import (
 	"context"
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -325,6 +326,78 @@ func (s *configTestSuite) TestPlacementRules(c *C) {
 	c.Assert(rules[0].Key(), Equals, [2]string{"pd", "test1"})
 }
 
+func (s *configTestSuite) TestCheckDrift(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster, err := tests.NewTestCluster(ctx, 1)
+	c.Assert(err, IsNil)
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	pdAddr := cluster.GetConfig().GetClientURL()
+	cmd := pdctlCmd.GetRootCmd()
+
+	store := &metapb.Store{
+		Id:            1,
+		State:         metapb.StoreState_Up,
+		LastHeartbeat: time.Now().UnixNano(),
+	}
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	c.Assert(leaderServer.BootstrapCluster(), IsNil)
+	svr := leaderServer.GetServer()
+	pdctl.MustPutStore(c, svr, store)
+	defer cluster.Destroy()
+
+	dir := c.MkDir()
+	checkDrift := func(name, content string) (string, error) {
+		fname := filepath.Join(dir, name)
+		c.Assert(os.WriteFile(fname, []byte(content), 0644), IsNil)
+		output, err := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "config", "check", "drift", fname)
+		return string(output), err
+	}
+
+	// no drift, the durations are compared by the values.
+	output, err := checkDrift("golden.json", `{
+		"schedule": {"leader-schedule-limit": 4, "max-store-down-time": "1800s"},
+		"replication": {"max-replicas": 3},
+		"rules": [{"group_id": "pd", "id": "default", "role": "voter", "count": 3}]
+	}`)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(output, "No config drift is found."), IsTrue)
+	output, err = checkDrift("golden.toml", `
+[schedule]
+leader-schedule-limit = 4
+[replication]
+max-replicas = 3
+`)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(output, "No config drift is found."), IsTrue)
+
+	// drift
+	output, err = checkDrift("drift.json", `{
+		"schedule": {"leader-schedule-limit": 8},
+		"rules": [{"group_id": "pd", "id": "default", "role": "voter", "count": 5},
+			{"group_id": "pd", "id": "test", "role": "learner", "count": 1}]
+	}`)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(output, "schedule.leader-schedule-limit: expected 8, got 4"), IsTrue)
+	c.Assert(strings.Contains(output, "rules.pd/default.count: expected 5, got 3"), IsTrue)
+	c.Assert(strings.Contains(output, "rules.pd/test: expected"), IsTrue)
+	c.Assert(strings.Contains(output, "got <missing>"), IsTrue)
+
+	// the unexpected rules are drifts.
+	c.Assert(svr.GetRaftCluster().GetRuleManager().SetRule(&placement.Rule{GroupID: "pd", ID: "extra", Role: "voter", Count: 1}), IsNil)
+	output, err = checkDrift("golden.json", `{
+		"rules": [{"group_id": "pd", "id": "default", "role": "voter", "count": 3}]
+	}`)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(output, "rules.pd/extra: expected <missing>"), IsTrue)
+
+	// invalid golden file
+	_, err = checkDrift("invalid.json", "{")
+	c.Assert(err, NotNil)
+}
+
 func (s *configTestSuite) TestPlacementRuleGroups(c *C) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -142,6 +142,13 @@ func (s *operatorTestSuite) TestOperator(c *C) {
 			expect: "remove peer on store 2",
 			reset:  []string{"-u", pdAddr, "operator", "remove", "1"},
 		},
+		{
+			// operator add transfer-peer <region_id> <from_store_id> <to_store_id> --force
+			cmd:    []string{"-u", pdAddr, "operator", "add", "transfer-peer", "1", "2", "3", "--force"},
+			show:   []string{"-u", pdAddr, "operator", "show"},
+			expect: ", force",
+			reset:  []string{"-u", pdAddr, "operator", "remove", "1"},
+		},
 		{
 			// operator add split-region <region_id> [--policy=scan|approximate]
 			cmd:    []string{"-u", pdAddr, "operator", "add", "split-region", "3", "--policy=scan"},
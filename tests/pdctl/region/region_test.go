@@ -180,4 +180,19 @@ func (s *regionTestSuite) TestRegion(c *C) {
 		c.Assert(json.Unmarshal(output, region), IsNil)
 		pdctl.CheckRegionInfo(c, region, testCase.expect)
 	}
+
+	// region check-gaps command
+	output, e := pdctl.ExecuteCommand(cmd, "-u", pdAddr, "region", "check-gaps")
+	c.Assert(e, IsNil)
+	anomalies := &api.RegionRangeAnomalies{}
+	c.Assert(json.Unmarshal(output, anomalies), IsNil)
+	c.Assert(anomalies.Count, Equals, 2)
+	c.Assert(anomalies.Anomalies[0].Kind, Equals, core.RegionRangeGap)
+	c.Assert(anomalies.Anomalies[0].StartKey, Equals, "")
+	c.Assert(anomalies.Anomalies[0].EndKey, Equals, core.HexRegionKeyStr([]byte("a")))
+	pdctl.CheckRegionsInfo(c, &api.RegionsInfo{Count: 1, Regions: anomalies.Anomalies[0].Regions}, []*core.RegionInfo{r1})
+	c.Assert(anomalies.Anomalies[1].Kind, Equals, core.RegionRangeGap)
+	c.Assert(anomalies.Anomalies[1].StartKey, Equals, core.HexRegionKeyStr([]byte("e")))
+	c.Assert(anomalies.Anomalies[1].EndKey, Equals, "")
+	pdctl.CheckRegionsInfo(c, &api.RegionsInfo{Count: 1, Regions: anomalies.Anomalies[1].Regions}, []*core.RegionInfo{r4})
 }
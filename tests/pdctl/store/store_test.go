@@ -344,4 +344,16 @@ func (s *storeTestSuite) TestStore(c *C) {
 	err = json.Unmarshal(output, scene)
 	c.Assert(err, IsNil)
 	c.Assert(scene.Idle, Equals, 100)
+
+	// store --watch
+	args = []string{"-u", pdAddr, "store", "--watch", "--interval", "100ms", "--count", "3"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	updates := strings.Count(string(output), "Every 100ms, updated at")
+	c.Assert(updates >= 1 && updates <= 3, IsTrue)
+	c.Assert(strings.Contains(string(output), `"stores"`), IsTrue)
+	args = []string{"-u", pdAddr, "store", "--watch", "--interval", "1ms", "--count", "3"}
+	output, err = pdctl.ExecuteCommand(cmd, args...)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(output), "The interval should not be less than"), IsTrue)
 }
@@ -17,6 +17,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/pdpb"
@@ -190,3 +191,70 @@ func (s *testAllocIDSuite) TestPDRestart(c *C) {
 		last = id
 	}
 }
+
+func (s *testAllocIDSuite) TestEnsureAbove(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	alloc := cluster.GetServer(cluster.GetLeader()).GetAllocator()
+
+	last, err := alloc.Alloc()
+	c.Assert(err, IsNil)
+	rollback, err := alloc.EnsureAbove(last)
+	c.Assert(err, IsNil)
+	c.Assert(rollback, IsFalse)
+
+	// Simulate that the metadata contains the IDs beyond the persisted window.
+	observed := last + 3*allocStep
+	rollback, err = alloc.EnsureAbove(observed)
+	c.Assert(err, IsNil)
+	c.Assert(rollback, IsTrue)
+	id, err := alloc.Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Greater, observed)
+
+	// The bumped window is persisted.
+	c.Assert(alloc.Rebase(), IsNil)
+	id, err = alloc.Alloc()
+	c.Assert(err, IsNil)
+	c.Assert(id, Greater, observed+allocStep)
+}
+
+func (s *testAllocIDSuite) TestEnsureAboveAsync(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	c.Assert(err, IsNil)
+	defer cluster.Destroy()
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	alloc := cluster.GetServer(cluster.GetLeader()).GetAllocator()
+
+	last, err := alloc.Alloc()
+	c.Assert(err, IsNil)
+	observed := last + 3*allocStep
+	release := make(chan struct{})
+	alloc.EnsureAboveAsync(func() uint64 {
+		<-release
+		return observed
+	})
+
+	// The allocation waits for the observed ID.
+	ids := make(chan uint64, 1)
+	go func() {
+		id, err := alloc.Alloc()
+		c.Assert(err, IsNil)
+		ids <- id
+	}()
+	select {
+	case <-ids:
+		c.Fatal("id is allocated before the observed id is got")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	c.Assert(<-ids, Greater, observed)
+}
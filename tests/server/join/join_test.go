@@ -17,6 +17,7 @@ import (
 	"context"
 	"os"
 	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -176,3 +177,46 @@ func (s *joinTestSuite) TestFailedPDJoinsPreviousCluster(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(join.PrepareJoinCluster(pd2.GetConfig()), NotNil)
 }
+
+// A deleted PD with stale data joins the previous cluster with force-new.
+func (s *joinTestSuite) TestDeletedPDJoinsWithForceNew(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+
+	// Join the second PD.
+	pd2, err := cluster.Join(s.ctx)
+	c.Assert(err, IsNil)
+	err = pd2.Run()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+	// Wait for all nodes becoming healthy.
+	time.Sleep(time.Second * 5)
+
+	client := cluster.GetServer("pd1").GetEtcdClient()
+	_, err = client.MemberRemove(context.TODO(), pd2.GetServerID())
+	c.Assert(err, IsNil)
+	err = pd2.Stop()
+	c.Assert(err, IsNil)
+
+	// The data of pd2 is stale, which is only checked with force-new.
+	cfg := pd2.GetConfig()
+	c.Assert(join.PrepareJoinCluster(cfg), IsNil)
+	c.Assert(cfg.InitialCluster, Not(Equals), "")
+	archives, err := filepath.Glob(path.Join(cfg.DataDir, "archive-*"))
+	c.Assert(err, IsNil)
+	c.Assert(archives, HasLen, 0)
+
+	cfg.ForceNew = true
+	c.Assert(join.PrepareJoinCluster(cfg), IsNil)
+	archives, err = filepath.Glob(path.Join(cfg.DataDir, "archive-*", "member"))
+	c.Assert(err, IsNil)
+	c.Assert(archives, HasLen, 1)
+	members, err := etcdutil.ListEtcdMembers(client)
+	c.Assert(err, IsNil)
+	c.Assert(members.Members, HasLen, 2)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/member"
 	"github.com/tikv/pd/tests"
 	"go.uber.org/goleak"
 )
@@ -190,6 +191,93 @@ func (s *memberTestSuite) TestLeaderPriority(c *C) {
 	})
 }
 
+func (s *memberTestSuite) TestLeaderFitness(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3)
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+
+	leader, err := cluster.GetServer("pd1").GetEtcdLeader()
+	c.Assert(err, IsNil)
+	leaderServer := cluster.GetServer(leader)
+	var follower *tests.TestServer
+	for _, svr := range cluster.GetServers() {
+		if svr.GetServer().Name() != leader {
+			follower = svr
+			break
+		}
+	}
+	followerMember := follower.GetServer().GetMember()
+
+	// Each member reports its fitness.
+	for _, svr := range cluster.GetServers() {
+		fitness, err := svr.GetServer().GetMember().UpdateLeaderFitness(s.ctx)
+		c.Assert(err, IsNil)
+		c.Assert(fitness.Score, Greater, 0.0)
+		c.Assert(fitness.Score, LessEqual, 100.0)
+		// The peers are probed instead of timing out.
+		c.Assert(fitness.PeerLatency.Duration, Greater, time.Duration(0))
+		c.Assert(fitness.PeerLatency.Duration < time.Second, IsTrue)
+	}
+	res, err := http.Get(leaderServer.GetAddr() + "/pd/api/v1/members/fitness")
+	c.Assert(err, IsNil)
+	var fitness []*member.LeaderFitness
+	err = json.NewDecoder(res.Body).Decode(&fitness)
+	res.Body.Close()
+	c.Assert(err, IsNil)
+	c.Assert(fitness, HasLen, 3)
+	for _, f := range fitness {
+		c.Assert(f.Score, Greater, 0.0)
+		c.Assert(f.UpdateTime.IsZero(), IsFalse)
+	}
+
+	// The leadership is not transferred if it is not enabled.
+	leaderID, err := leaderServer.GetEtcdLeaderID()
+	c.Assert(err, IsNil)
+	cfg := follower.GetServer().GetPersistOptions().GetPDServerConfig().Clone()
+	cfg.LeaderFitnessTransferDuration.Duration = 0
+	// Simulate that the leader becomes slow.
+	degraded := &member.LeaderFitness{MemberID: leaderID, Name: leader, Score: 1, UpdateTime: time.Now()}
+	data, err := json.Marshal(degraded)
+	c.Assert(err, IsNil)
+	key := fmt.Sprintf("/pd/%d/member/%d/leader_fitness", leaderServer.GetClusterID(), leaderID)
+	_, err = follower.GetEtcdClient().Put(s.ctx, key, string(data))
+	c.Assert(err, IsNil)
+	followerMember.CheckLeaderFitness(s.ctx, cfg)
+	c.Assert(followerMember.GetEtcdLeader(), Equals, leaderID)
+
+	// The leadership is not transferred to the member with lower priority.
+	cfg.EnableLeaderFitnessTransfer = true
+	cfg.LeaderFitnessTransferThreshold = 10
+	cfg.LeaderFitnessTransferCooldown.Duration = 0
+	c.Assert(leaderServer.GetServer().GetMember().SetMemberLeaderPriority(leaderID, 10), IsNil)
+	_, err = follower.GetEtcdClient().Put(s.ctx, key, string(data))
+	c.Assert(err, IsNil)
+	followerMember.CheckLeaderFitness(s.ctx, cfg)
+	c.Assert(followerMember.GetEtcdLeader(), Equals, leaderID)
+	c.Assert(leaderServer.GetServer().GetMember().DeleteMemberLeaderPriority(leaderID), IsNil)
+
+	// The leadership is not transferred within the cooldown.
+	cfg.LeaderFitnessTransferCooldown.Duration = time.Hour
+	_, err = follower.GetEtcdClient().Put(s.ctx, key, string(data))
+	c.Assert(err, IsNil)
+	followerMember.CheckLeaderFitness(s.ctx, cfg)
+	c.Assert(followerMember.GetEtcdLeader(), Equals, leaderID)
+
+	// The leadership is transferred to the fitter member.
+	cfg.LeaderFitnessTransferCooldown.Duration = 0
+	_, err = follower.GetEtcdClient().Put(s.ctx, key, string(data))
+	c.Assert(err, IsNil)
+	followerMember.CheckLeaderFitness(s.ctx, cfg)
+	c.Assert(s.waitEtcdLeaderChange(c, follower, leader), Equals, follower.GetServer().Name())
+	testutil.WaitUntil(c, func(c *C) bool {
+		return cluster.GetLeader() == follower.GetServer().Name()
+	})
+}
+
 func (s *memberTestSuite) post(c *C, url string, body string) {
 	testutil.WaitUntil(c, func(c *C) bool {
 		res, err := http.Post(url, "", bytes.NewBufferString(body))
@@ -259,6 +347,29 @@ func (s *memberTestSuite) TestLeaderResignWithBlock(c *C) {
 	c.Assert(leader2, Not(Equals), leader1)
 }
 
+func (s *memberTestSuite) TestIsolateLeader(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 3)
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+
+	leader1 := cluster.WaitLeader()
+	c.Assert(cluster.IsolateMember("unknown"), NotNil)
+	c.Assert(cluster.IsolateMember(leader1), IsNil)
+	leader2 := s.waitLeaderChange(c, cluster, leader1)
+	c.Assert(leader2, Not(Equals), leader1)
+
+	// The old leader rejoins as a follower after healing.
+	cluster.HealPartitions()
+	testutil.WaitUntil(c, func(c *C) bool {
+		etcdLeader, err := cluster.GetServer(leader1).GetEtcdLeader()
+		return err == nil && etcdLeader != leader1 && etcdLeader != ""
+	})
+	c.Assert(cluster.GetServer(leader1).IsLeader(), IsFalse)
+}
+
 func (s *memberTestSuite) waitLeaderChange(c *C, cluster *tests.TestCluster, old string) string {
 	var leader string
 	testutil.WaitUntil(c, func(c *C) bool {
@@ -15,15 +15,25 @@ package server_test
 
 import (
 	"context"
+	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/protoc-gen-gogo/descriptor"
 	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/tikv/pd/pkg/tempurl"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/tests"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 
 	// Register schedulers.
 	_ "github.com/tikv/pd/server/schedulers"
@@ -142,3 +152,138 @@ func (s *serverTestSuite) TestLeader(c *C) {
 		return leader != leader1
 	})
 }
+
+func (s *serverTestSuite) TestGRPCInterceptors(c *C) {
+	var unaryCount, streamCount int32
+	interceptors := &server.GRPCInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				atomic.AddInt32(&unaryCount, 1)
+				// Simulate the authentication failure of a method.
+				if info.FullMethod == "/pdpb.PD/GetAllStores" {
+					return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+				}
+				return handler(ctx, req)
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if info.FullMethod == "/pdpb.PD/Tso" {
+					atomic.AddInt32(&streamCount, 1)
+				}
+				return handler(srv, stream)
+			},
+		},
+	}
+	cluster, err := tests.NewTestClusterWithServerOptions(s.ctx, 1, []tests.ServerOption{tests.WithGRPCInterceptors(interceptors)})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	header := testutil.NewRequestHeader(leaderServer.GetClusterID())
+
+	resp, err := grpcPDClient.GetMembers(s.ctx, &pdpb.GetMembersRequest{Header: header})
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetLeader().GetName(), Equals, leaderServer.GetServer().Name())
+	c.Assert(atomic.LoadInt32(&unaryCount), Equals, int32(1))
+
+	_, err = grpcPDClient.GetAllStores(s.ctx, &pdpb.GetAllStoresRequest{Header: header})
+	c.Assert(status.Code(err), Equals, codes.Unauthenticated)
+	c.Assert(atomic.LoadInt32(&unaryCount), Equals, int32(2))
+
+	stream, err := grpcPDClient.Tso(s.ctx)
+	c.Assert(err, IsNil)
+	defer stream.CloseSend()
+	c.Assert(stream.Send(&pdpb.TsoRequest{Header: header, Count: 1, DcLocation: tso.GlobalDCLocation}), IsNil)
+	tsoResp, err := stream.Recv()
+	c.Assert(err, IsNil)
+	c.Assert(tsoResp.GetCount(), Equals, uint32(1))
+	c.Assert(atomic.LoadInt32(&streamCount), Equals, int32(1))
+}
+
+func (s *serverTestSuite) TestGRPCReflection(c *C) {
+	newReflectionStream := func(enabled bool) (rpb.ServerReflection_ServerReflectionInfoClient, func()) {
+		cluster, err := tests.NewTestCluster(s.ctx, 1, func(cfg *config.Config, name string) { cfg.EnableGRPCReflection = enabled })
+		c.Assert(err, IsNil)
+		c.Assert(cluster.RunInitialServers(), IsNil)
+		leaderServer := cluster.GetServer(cluster.WaitLeader())
+		conn, err := grpc.Dial(strings.TrimPrefix(leaderServer.GetAddr(), "http://"), grpc.WithInsecure())
+		c.Assert(err, IsNil)
+		stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(s.ctx)
+		c.Assert(err, IsNil)
+		return stream, func() {
+			stream.CloseSend()
+			conn.Close()
+			cluster.Destroy()
+		}
+	}
+	listServices := func(stream rpb.ServerReflection_ServerReflectionInfoClient) ([]string, error) {
+		if err := stream.Send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}}); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		var services []string
+		for _, service := range resp.GetListServicesResponse().GetService() {
+			services = append(services, service.GetName())
+		}
+		return services, nil
+	}
+	// requestFiles returns the file descriptors in the response of the request
+	// by their names.
+	requestFiles := func(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest) map[string]*descriptor.FileDescriptorProto {
+		c.Assert(stream.Send(req), IsNil)
+		resp, err := stream.Recv()
+		c.Assert(err, IsNil)
+		c.Assert(resp.GetErrorResponse(), IsNil)
+		files := make(map[string]*descriptor.FileDescriptorProto)
+		for _, b := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := new(descriptor.FileDescriptorProto)
+			c.Assert(proto.Unmarshal(b, file), IsNil)
+			files[file.GetName()] = file
+		}
+		return files
+	}
+
+	stream, cleanup := newReflectionStream(false)
+	_, err := listServices(stream)
+	c.Assert(status.Code(err), Equals, codes.Unimplemented)
+	cleanup()
+
+	stream, cleanup = newReflectionStream(true)
+	defer cleanup()
+	services, err := listServices(stream)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Join(services, ","), Matches, ".*pdpb.PD.*")
+
+	// The file of the service and all its dependencies must be resolvable to
+	// decode the messages.
+	files := requestFiles(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "pdpb.PD"},
+	})
+	pdpbFile, ok := files["pdpb.proto"]
+	c.Assert(ok, IsTrue)
+	c.Assert(pdpbFile.GetPackage(), Equals, "pdpb")
+	c.Assert(pdpbFile.GetService()[0].GetName(), Equals, "PD")
+	c.Assert(strings.Join(pdpbFile.GetDependency(), ","), Matches, ".*gogoproto/gogo.proto.*")
+	resolved := map[string]*descriptor.FileDescriptorProto{"pdpb.proto": pdpbFile}
+	pending := append([]string(nil), pdpbFile.GetDependency()...)
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := resolved[name]; ok {
+			continue
+		}
+		files = requestFiles(stream, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+		})
+		file, ok := files[name]
+		c.Assert(ok, IsTrue, Commentf("file %s is not resolved", name))
+		resolved[name] = file
+		pending = append(pending, file.GetDependency()...)
+	}
+	c.Assert(resolved["gogoproto/gogo.proto"].GetPackage(), Equals, "gogoproto")
+}
@@ -136,6 +136,53 @@ func (s *testNormalGlobalTSOSuite) testGetNormalGlobalTimestamp(c *C, pdCli pdpb
 	return res
 }
 
+func (s *testNormalGlobalTSOSuite) TestTSOStats(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+
+	err = cluster.RunInitialServers()
+	c.Assert(err, IsNil)
+	cluster.WaitLeader()
+
+	leaderServer := cluster.GetServer(cluster.GetLeader())
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tsoClient, err := grpcPDClient.Tso(ctx)
+	c.Assert(err, IsNil)
+	for _, count := range []uint32{1, 3} {
+		req := &pdpb.TsoRequest{
+			Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+			Count:      count,
+			DcLocation: tso.GlobalDCLocation,
+		}
+		c.Assert(tsoClient.Send(req), IsNil)
+		_, err = tsoClient.Recv()
+		c.Assert(err, IsNil)
+	}
+
+	stats := leaderServer.GetServer().GetTSOStats()
+	c.Assert(stats.Streams, Equals, 1)
+	c.Assert(stats.Requests, Equals, uint64(2))
+	c.Assert(stats.Timestamps, Equals, uint64(4))
+	c.Assert(stats.AvgBatchSize, Equals, float64(2))
+	c.Assert(stats.AvgWaitTime.Duration, Greater, time.Duration(0))
+	c.Assert(stats.Connections, HasLen, 1)
+	c.Assert(stats.Connections[0].Streams, Equals, 1)
+	c.Assert(stats.Connections[0].Requests, Equals, uint64(2))
+
+	// The connection is removed after its streams are closed.
+	c.Assert(tsoClient.CloseSend(), IsNil)
+	_, err = tsoClient.Recv()
+	c.Assert(err, NotNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		stats = leaderServer.GetServer().GetTSOStats()
+		return stats.Streams == 0 && len(stats.Connections) == 0
+	})
+	c.Assert(stats.Requests, Equals, uint64(2))
+}
+
 func (s *testNormalGlobalTSOSuite) TestConcurrentlyReset(c *C) {
 	cluster, err := tests.NewTestCluster(s.ctx, 1)
 	defer cluster.Destroy()
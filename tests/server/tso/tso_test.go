@@ -15,14 +15,18 @@ package tso_test
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/kvproto/pkg/pdpb"
+	"github.com/tikv/pd/pkg/errs"
 	"github.com/tikv/pd/pkg/grpcutil"
 	"github.com/tikv/pd/pkg/testutil"
 	"github.com/tikv/pd/server"
 	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/tso"
 	"github.com/tikv/pd/tests"
 )
 
@@ -85,6 +89,102 @@ func (s *testTSOSuite) TestLoadTimestamp(c *C) {
 	failpoint.Disable("github.com/tikv/pd/server/tso/systemTimeSlow")
 }
 
+func (s *testTSOSuite) TestAlerts(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1)
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leader := cluster.GetServer(cluster.WaitLeader())
+	c.Assert(leader.GetServer().GetTSOAllocatorManager().GetAlerts(), HasLen, 0)
+
+	c.Assert(failpoint.Enable("github.com/tikv/pd/server/tso/systemTimeSlow", `return(true)`), IsNil)
+	testutil.WaitUntil(c, func(c *C) bool {
+		return len(leader.GetServer().GetTSOAllocatorManager().GetAlerts()) > 0
+	})
+	failpoint.Disable("github.com/tikv/pd/server/tso/systemTimeSlow")
+
+	getAlerts := func(query string) (int, []tso.Alert) {
+		resp, err := http.Get(leader.GetAddr() + "/pd/api/v1/alerts" + query)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		var alerts []tso.Alert
+		if resp.StatusCode == http.StatusOK {
+			c.Assert(json.NewDecoder(resp.Body).Decode(&alerts), IsNil)
+		}
+		return resp.StatusCode, alerts
+	}
+	code, alerts := getAlerts("")
+	c.Assert(code, Equals, http.StatusOK)
+	// The physical time is not advanced when the system time is slow, so there
+	// may be a clock offset alert after the system time recovers.
+	var found bool
+	for _, alert := range alerts {
+		c.Assert(alert.Severity, Equals, tso.AlertWarning)
+		c.Assert(alert.DCLocation, Equals, tso.GlobalDCLocation)
+		c.Assert(alert.Count > 0, IsTrue)
+		found = found || alert.Type == "system-time-slow"
+	}
+	c.Assert(found, IsTrue)
+	code, alerts = getAlerts("?severity=critical")
+	c.Assert(code, Equals, http.StatusOK)
+	c.Assert(alerts, HasLen, 0)
+	code, _ = getAlerts("?severity=foo")
+	c.Assert(code, Equals, http.StatusBadRequest)
+}
+
+func (s *testTSOSuite) TestQuota(c *C) {
+	cluster, err := tests.NewTestCluster(s.ctx, 1, func(conf *config.Config, serverName string) {
+		conf.TSOQuotas = map[string]uint64{tso.GlobalDCLocation: 100}
+	})
+	defer cluster.Destroy()
+	c.Assert(err, IsNil)
+	c.Assert(cluster.RunInitialServers(), IsNil)
+	leaderServer := cluster.GetServer(cluster.WaitLeader())
+	am := leaderServer.GetServer().GetTSOAllocatorManager()
+	testutil.WaitUntil(c, func(c *C) bool {
+		_, err := am.HandleTSORequest(tso.GlobalDCLocation, 1)
+		return err == nil
+	})
+
+	// The internal requests are not limited.
+	_, err = am.HandleTSORequest(tso.GlobalDCLocation, 200)
+	c.Assert(err, IsNil)
+	// The requests exceeding the quota are rejected.
+	c.Assert(am.TakeTSOQuota(tso.GlobalDCLocation, "tenant-1", 200), NotNil)
+	c.Assert(am.TakeTSOQuota(tso.GlobalDCLocation, "tenant-1", 100), IsNil)
+	err = am.TakeTSOQuota(tso.GlobalDCLocation, "tenant-1", 10)
+	c.Assert(errs.ErrTSOQuotaExceeded.Equal(err), IsTrue)
+	// The new tenant gets its fair share even if the quota is used up.
+	c.Assert(am.TakeTSOQuota(tso.GlobalDCLocation, "tenant-2", 40), IsNil)
+	c.Assert(am.TakeTSOQuota(tso.GlobalDCLocation, "tenant-1", 10), NotNil)
+	// The dc-locations without a quota are unlimited.
+	c.Assert(am.TakeTSOQuota("dc-1", "tenant-1", 1000), IsNil)
+
+	// Only the rejected request fails, and the stream can still be used.
+	grpcPDClient := testutil.MustNewGrpcClient(c, leaderServer.GetAddr())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tsoClient, err := grpcPDClient.Tso(ctx)
+	c.Assert(err, IsNil)
+	defer tsoClient.CloseSend()
+	req := &pdpb.TsoRequest{
+		Header:     testutil.NewRequestHeader(leaderServer.GetClusterID()),
+		Count:      200,
+		DcLocation: tso.GlobalDCLocation,
+	}
+	c.Assert(tsoClient.Send(req), IsNil)
+	resp, err := tsoClient.Recv()
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetCount(), Equals, uint32(0))
+	c.Assert(resp.GetHeader().GetError().GetMessage(), Matches, ".*"+errs.TSOQuotaExceededErr+".*")
+	req.Count = 1
+	c.Assert(tsoClient.Send(req), IsNil)
+	resp, err = tsoClient.Recv()
+	c.Assert(err, IsNil)
+	c.Assert(resp.GetHeader().GetError(), IsNil)
+	c.Assert(resp.GetCount(), Equals, uint32(1))
+}
+
 func requestLocalTSOs(c *C, cluster *tests.TestCluster, dcLocationConfig map[string]string) map[string]*pdpb.Timestamp {
 	dcClientMap := make(map[string]pdpb.PDClient)
 	tsMap := make(map[string]*pdpb.Timestamp)
@@ -21,6 +21,7 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -144,8 +145,30 @@ func currentTime(layout string) func(content string) (time.Time, error) {
 	}
 }
 
-// ParseLog is to parse log for transfer counter.
-func (c *TransferCounter) ParseLog(filename, start, end, layout string, r *regexp.Regexp) error {
+// CompileRegexes is to provide regexps for transfer counter with the operators
+// separated by commas, the regexps are keyed by the operators.
+func (c *TransferCounter) CompileRegexes(operators string) (map[string]*regexp.Regexp, error) {
+	rs := make(map[string]*regexp.Regexp)
+	for _, operator := range strings.Split(operators, ",") {
+		operator = strings.TrimSpace(operator)
+		if operator == "" {
+			continue
+		}
+		r, err := c.CompileRegex(operator)
+		if err != nil {
+			return nil, err
+		}
+		rs[operator] = r
+	}
+	if len(rs) == 0 {
+		return nil, errors.New("no operator is specified. ")
+	}
+	return rs, nil
+}
+
+// ParseLog is to parse log for transfer counter. The regexps are keyed by the
+// operators, each line is counted for the first operator which matches it.
+func (c *TransferCounter) ParseLog(filename, start, end, layout string, rs map[string]*regexp.Regexp) error {
 	afterStart := isExpectTime(start, layout, false)
 	beforeEnd := isExpectTime(end, layout, true)
 	getCurrent := currentTime(layout)
@@ -157,14 +180,18 @@ func (c *TransferCounter) ParseLog(filename, start, end, layout string, r *regex
 		}
 		// if current line time between start and end
 		if afterStart(current) && beforeEnd(current) {
-			results, err := c.parseLine(content, r)
-			if err != nil {
-				return err
-			}
-			if len(results) == 3 {
-				regionID, sourceID, targetID := results[0], results[1], results[2]
-				GetTransferCounter().AddTarget(regionID, targetID)
-				GetTransferCounter().AddSource(regionID, sourceID)
+			for operator, r := range rs {
+				results, err := c.parseLine(content, r)
+				if err != nil {
+					return err
+				}
+				if len(results) == 3 {
+					regionID, sourceID, targetID := results[0], results[1], results[2]
+					GetTransferCounter().AddTarget(regionID, targetID)
+					GetTransferCounter().AddSource(regionID, sourceID)
+					GetTransferCounter().AddTransfer(operator, sourceID, targetID, current)
+					break
+				}
 			}
 		}
 		return nil
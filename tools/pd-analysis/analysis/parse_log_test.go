@@ -14,6 +14,9 @@
 package analysis
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -112,3 +115,37 @@ func (t *testParseLog) TestCurrentTime(c *C) {
 	expect, _ := time.Parse(DefaultLayout, "2019/09/05 14:19:15")
 	c.Assert(current, Equals, expect)
 }
+
+func (t *testParseLog) TestParseLogWithOperators(c *C) {
+	lines := []string{
+		"[2019/09/05 14:05:42.811 +08:00] [INFO] [operator_controller.go:119] [\"operator finish\"] [region-id=94] [operator=\"\"transfer-hot-write-leader {transfer leader: store 2 to 1} (kind:leader,hot-region, region:94(1,1), createAt:2019-09-05 14:05:42.676394689 +0800 CST m=+14.955640307, startAt:2019-09-05 14:05:42.676589507 +0800 CST m=+14.955835051, currentStep:1, steps:[transfer leader from store 2 to store 1]) finished\"\"]",
+		"[2019/09/05 14:05:54.311 +08:00] [INFO] [operator_controller.go:119] [\"operator finish\"] [region-id=98] [operator=\"\"move-hot-write-region {mv peer: store [2] to [10]} (kind:region,hot-region, region:98(1,1), createAt:2019-09-05 14:05:49.718201432 +0800 CST m=+21.997446945, startAt:2019-09-05 14:05:49.718336308 +0800 CST m=+21.997581822, currentStep:3, steps:[add learner peer 2048 on store 10, promote learner peer 2048 on store 10 to voter, remove peer on store 2]) finished\"\"]",
+		"[2019/09/05 14:16:38.758 +08:00] [INFO] [operator_controller.go:119] [\"operator finish\"] [region-id=85] [operator=\"\"transfer-hot-write-leader {transfer leader: store 1 to 5} (kind:leader,hot-region, region:85(1,1), createAt:2019-09-05 14:16:38.567463945 +0800 CST m=+29.117453011, startAt:2019-09-05 14:16:38.567603515 +0800 CST m=+29.117592496, currentStep:1, steps:[transfer leader from store 1 to store 5]) finished\"\"]",
+	}
+	filename := filepath.Join(c.MkDir(), "pd.log")
+	c.Assert(os.WriteFile(filename, []byte(strings.Join(lines, "\n")), 0600), IsNil)
+
+	counter := GetTransferCounter()
+	counter.Init(0, 0)
+	rs, err := counter.CompileRegexes("transfer-hot-write-leader, move-hot-write-region")
+	c.Assert(err, IsNil)
+	c.Assert(rs, HasLen, 2)
+	c.Assert(counter.ParseLog(filename, "", "", DefaultLayout, rs), IsNil)
+
+	c.Assert(counter.operatorMap, DeepEquals, map[string]uint64{
+		"transfer-hot-write-leader": 2,
+		"move-hot-write-region":     1,
+	})
+	c.Assert(counter.transferMap[2][1], Equals, uint64(1))
+	c.Assert(counter.transferMap[2][10], Equals, uint64(1))
+	c.Assert(counter.transferMap[1][5], Equals, uint64(1))
+	bucket1, _ := time.Parse(DefaultLayout, "2019/09/05 14:00:00")
+	bucket2, _ := time.Parse(DefaultLayout, "2019/09/05 14:10:00")
+	c.Assert(counter.seriesMap, DeepEquals, map[time.Time]map[string]uint64{
+		bucket1: {"transfer-hot-write-leader": 1, "move-hot-write-region": 1},
+		bucket2: {"transfer-hot-write-leader": 1},
+	})
+
+	_, err = counter.CompileRegexes("balance-leader,unknown")
+	c.Assert(err, NotNil)
+}
@@ -21,8 +21,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
+// DefaultBucketSize is the default size of the time buckets of the transfer
+// series.
+const DefaultBucketSize = 10 * time.Minute
+
 // TransferCounter is to count transfer schedule for judging whether redundant
 type TransferCounter struct {
 	storeNum          int
@@ -41,6 +46,15 @@ type TransferCounter struct {
 	mutex             sync.Mutex
 	loopResultPath    [][]int
 	loopResultCount   []uint64
+	// transferMap is the count of the transfers from the source store to the
+	// target store, which is not changed by the redundant loop solving.
+	transferMap map[uint64]map[uint64]uint64
+	// operatorMap is the count of the transfers of each operator.
+	operatorMap map[string]uint64
+	// seriesMap is the count of the transfers of each operator in each time
+	// bucket.
+	seriesMap  map[time.Time]map[string]uint64
+	bucketSize time.Duration
 }
 
 var once sync.Once
@@ -68,6 +82,19 @@ func (c *TransferCounter) Init(storeNum, regionNum int) {
 	c.graphMap = make(map[uint64]map[uint64]uint64)
 	c.loopResultPath = c.loopResultPath[:0]
 	c.loopResultCount = c.loopResultCount[:0]
+	c.transferMap = make(map[uint64]map[uint64]uint64)
+	c.operatorMap = make(map[string]uint64)
+	c.seriesMap = make(map[time.Time]map[string]uint64)
+	c.bucketSize = DefaultBucketSize
+}
+
+// SetBucketSize sets the size of the time buckets of the transfer series.
+func (c *TransferCounter) SetBucketSize(bucketSize time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if bucketSize > 0 {
+		c.bucketSize = bucketSize
+	}
 }
 
 // AddTarget is be used to add target of edge in graph mat.
@@ -97,6 +124,27 @@ func (c *TransferCounter) AddSource(regionID, sourceStoreID uint64) {
 	}
 }
 
+// AddTransfer is used to record a transfer from the source store to the target
+// store, which is made by the operator at the given time.
+func (c *TransferCounter) AddTransfer(operator string, sourceStoreID, targetStoreID uint64, current time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	edge, ok := c.transferMap[sourceStoreID]
+	if !ok {
+		edge = make(map[uint64]uint64)
+		c.transferMap[sourceStoreID] = edge
+	}
+	edge[targetStoreID]++
+	c.operatorMap[operator]++
+	bucket := current.Truncate(c.bucketSize)
+	counts, ok := c.seriesMap[bucket]
+	if !ok {
+		counts = make(map[string]uint64)
+		c.seriesMap[bucket] = counts
+	}
+	counts[operator]++
+}
+
 // prepare is to change sparse map to dense mat.
 func (c *TransferCounter) prepare() {
 	c.IsReady = true
@@ -213,6 +261,93 @@ func (c *TransferCounter) printGraph() {
 	}
 }
 
+// PrintMatrix will print the source x target store transfer matrix, with the
+// total count of the transfers out of and into each store.
+func (c *TransferCounter) PrintMatrix() {
+	set := make(map[uint64]struct{})
+	for sourceID, edge := range c.transferMap {
+		set[sourceID] = struct{}{}
+		for targetID := range edge {
+			set[targetID] = struct{}{}
+		}
+	}
+	storeIDs := make([]uint64, 0, len(set))
+	for storeID := range set {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+
+	log.Println("Transfer Matrix (source x target): ")
+	fmt.Print("\t")
+	for _, storeID := range storeIDs {
+		fmt.Print(storeID, "\t")
+	}
+	fmt.Println("out")
+	in := make([]uint64, len(storeIDs))
+	for _, sourceID := range storeIDs {
+		fmt.Print(sourceID, "\t")
+		var out uint64
+		for i, targetID := range storeIDs {
+			flow := c.transferMap[sourceID][targetID]
+			out += flow
+			in[i] += flow
+			fmt.Print(flow, "\t")
+		}
+		fmt.Println(out)
+	}
+	fmt.Print("in\t")
+	for _, flow := range in {
+		fmt.Print(flow, "\t")
+	}
+	fmt.Println()
+}
+
+// sortedOperators returns the operators which make transfers in order.
+func (c *TransferCounter) sortedOperators() []string {
+	operators := make([]string, 0, len(c.operatorMap))
+	for operator := range c.operatorMap {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+	return operators
+}
+
+// PrintOperators will print the count of the transfers made by each operator.
+func (c *TransferCounter) PrintOperators() {
+	log.Println("Transfers By Operator: ")
+	for _, operator := range c.sortedOperators() {
+		fmt.Printf("%s\t%d\n", operator, c.operatorMap[operator])
+	}
+}
+
+// PrintSeries will print the count of the transfers made by each operator in
+// each time bucket.
+func (c *TransferCounter) PrintSeries() {
+	buckets := make([]time.Time, 0, len(c.seriesMap))
+	for bucket := range c.seriesMap {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Before(buckets[j]) })
+	operators := c.sortedOperators()
+
+	log.Println("Transfer Series (per", c.bucketSize.String()+"): ")
+	fmt.Print("time\t")
+	for _, operator := range operators {
+		fmt.Print(operator, "\t")
+	}
+	fmt.Println("total")
+	for _, bucket := range buckets {
+		fmt.Print(bucket.Format(DefaultLayout), "\t")
+		var total uint64
+		for _, operator := range operators {
+			count := c.seriesMap[bucket][operator]
+			total += count
+			fmt.Print(count, "\t")
+		}
+		fmt.Println(total)
+	}
+}
+
 // PrintResult will print result to log and csv file.
 func (c *TransferCounter) PrintResult() {
 	c.prepare()
@@ -27,9 +27,13 @@ var (
 	output   = flag.String("output", "", "output file, default output to stdout")
 	logLevel = flag.String("logLevel", "info", "log level, default info")
 	style    = flag.String("style", "", "analysis style, e.g. transfer-counter")
-	operator = flag.String("operator", "", "operator style, e.g. balance-region, balance-leader, transfer-hot-read-leader, move-hot-read-region, transfer-hot-write-leader, move-hot-write-region")
+	operator = flag.String("operator", "", "operator style, multiple operators are separated by commas, e.g. balance-region, balance-leader, transfer-hot-read-leader, move-hot-read-region, transfer-hot-write-leader, move-hot-write-region")
 	start    = flag.String("start", "", "start time, e.g. 2019/09/10 12:20:07, default: total file")
 	end      = flag.String("end", "", "end time, e.g. 2019/09/10 14:20:07, default: total file")
+	matrix   = flag.Bool("matrix", false, "output the source x target store transfer matrix")
+	series   = flag.Bool("series", false, "output the transfer count of each operator in each time bucket")
+	bucket   = flag.Duration("bucket", analysis.DefaultBucketSize, "time bucket size of the transfer series")
+	byOp     = flag.Bool("by-operator", false, "output the transfer count of each operator")
 )
 
 // Logger is the global logger used for simulator.
@@ -64,14 +68,24 @@ func main() {
 			if *operator == "" {
 				Logger.Fatal("Need to specify one operator.")
 			}
-			r, err := analysis.GetTransferCounter().CompileRegex(*operator)
+			rs, err := analysis.GetTransferCounter().CompileRegexes(*operator)
 			if err != nil {
 				Logger.Fatal(err.Error())
 			}
-			err = analysis.GetTransferCounter().ParseLog(*input, *start, *end, analysis.DefaultLayout, r)
+			analysis.GetTransferCounter().SetBucketSize(*bucket)
+			err = analysis.GetTransferCounter().ParseLog(*input, *start, *end, analysis.DefaultLayout, rs)
 			if err != nil {
 				Logger.Fatal(err.Error())
 			}
+			if *matrix {
+				analysis.GetTransferCounter().PrintMatrix()
+			}
+			if *byOp {
+				analysis.GetTransferCounter().PrintOperators()
+			}
+			if *series {
+				analysis.GetTransferCounter().PrintSeries()
+			}
 			analysis.GetTransferCounter().PrintResult()
 			break
 		}
@@ -14,13 +14,22 @@
 package command
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 )
 
 const clusterPrefix = "pd/api/v1/cluster"
 const clusterStatusPrefix = "pd/api/v1/cluster/status"
+const clusterDumpPrefix = "pd/api/v1/cluster/dump"
 
 // NewClusterCommand return a cluster subcommand of rootCmd
 func NewClusterCommand() *cobra.Command {
@@ -30,9 +39,22 @@ func NewClusterCommand() *cobra.Command {
 		Run:   showClusterCommandFunc,
 	}
 	cmd.AddCommand(NewClusterStatusCommand())
+	cmd.AddCommand(NewClusterDumpCommand())
 	return cmd
 }
 
+// NewClusterDumpCommand return a cluster dump subcommand of clusterCmd
+func NewClusterDumpCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "dump --out <dir> [--sample <n>]",
+		Short: "dump the stores, regions, config, rules, schedulers and operators into a directory of JSON files for offline analysis",
+		Run:   dumpClusterCommandFunc,
+	}
+	r.Flags().String("out", "", "the directory to write the JSON files into")
+	r.Flags().Int("sample", 1, "only dump one of every sample regions")
+	return r
+}
+
 // NewClusterStatusCommand return a cluster status subcommand of clusterCmd
 func NewClusterStatusCommand() *cobra.Command {
 	r := &cobra.Command{
@@ -60,3 +82,129 @@ func showClusterStatusCommandFunc(cmd *cobra.Command, args []string) {
 	}
 	cmd.Println(r)
 }
+
+// The record kinds which are written as JSON arrays, and the end record which
+// marks the dump is complete.
+var dumpArrayKinds = []string{"store", "rule", "scheduler", "operator", "region"}
+
+const (
+	dumpConfigKind = "config"
+	dumpEndKind    = "end"
+)
+
+type dumpRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+func dumpClusterCommandFunc(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("out")
+	if dir == "" {
+		cmd.Println("the output directory should be specified by --out")
+		return
+	}
+	sample, _ := cmd.Flags().GetInt("sample")
+	if sample < 1 {
+		cmd.Println("sample should be a positive integer")
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cmd.Printf("Failed to create the output directory: %s\n", err)
+		return
+	}
+	var counts map[string]int
+	err := tryURLs(cmd, getEndpoints(cmd), func(endpoint string) error {
+		resp, err := dialClient.Get(fmt.Sprintf("%s/%s?sample=%d", endpoint, clusterDumpPrefix, sample))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			msg, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			return errors.Errorf("[%d] %s", resp.StatusCode, msg)
+		}
+		counts, err = writeDump(dir, resp.Body)
+		return err
+	})
+	if err != nil {
+		cmd.Printf("Failed to dump the cluster: %s\n", err)
+		return
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		cmd.Printf("%s: %d\n", kind, counts[kind])
+	}
+	cmd.Printf("The cluster is dumped to %s\n", dir)
+}
+
+// writeDump writes the config into config.json and the records of the other
+// kinds into <kind>s.json as JSON arrays, such as stores.json. It returns the
+// count of the records of each kind.
+func writeDump(dir string, r io.Reader) (map[string]int, error) {
+	writers := make(map[string]*bufio.Writer, len(dumpArrayKinds))
+	for _, kind := range dumpArrayKinds {
+		f, err := os.Create(filepath.Join(dir, kind+"s.json"))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		w := bufio.NewWriter(f)
+		if _, err := w.WriteString("["); err != nil {
+			return nil, err
+		}
+		writers[kind] = w
+	}
+	counts := make(map[string]int)
+	dec := json.NewDecoder(r)
+	for {
+		var record dumpRecord
+		if err := dec.Decode(&record); err != nil {
+			if err == io.EOF {
+				return nil, errors.New("the dump is incomplete")
+			}
+			return nil, err
+		}
+		if record.Kind == dumpEndKind {
+			break
+		}
+		counts[record.Kind]++
+		if record.Kind == dumpConfigKind {
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), append(record.Data, '\n'), 0644); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		w, ok := writers[record.Kind]
+		if !ok {
+			// Ignore the kinds unknown to this version of pd-ctl.
+			continue
+		}
+		sep := ",\n"
+		if counts[record.Kind] == 1 {
+			sep = "\n"
+		}
+		if _, err := w.WriteString(sep); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(record.Data); err != nil {
+			return nil, err
+		}
+	}
+	for _, kind := range dumpArrayKinds {
+		w := writers[kind]
+		if _, err := w.WriteString("\n]\n"); err != nil {
+			return nil, err
+		}
+		if err := w.Flush(); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
@@ -16,14 +16,19 @@ package command
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
 	"github.com/spf13/cobra"
 	"github.com/tikv/pd/server/config"
 	"github.com/tikv/pd/server/schedule/placement"
@@ -54,6 +59,7 @@ func NewConfigCommand() *cobra.Command {
 	conf.AddCommand(NewSetConfigCommand())
 	conf.AddCommand(NewDeleteConfigCommand())
 	conf.AddCommand(NewPlacementRulesCommand())
+	conf.AddCommand(NewCheckConfigCommand())
 	return conf
 }
 
@@ -553,7 +559,13 @@ func getPlacementRulesFunc(cmd *cobra.Command, args []string) {
 		cmd.Println(`"region" should not be specified with "group" or "id" at the same time`)
 		return
 	}
-	res, err := doRequest(cmd, reqPath, http.MethodGet)
+	var res string
+	var err error
+	if respIsList {
+		res, err = doListRequest(cmd, reqPath)
+	} else {
+		res, err = doRequest(cmd, reqPath, http.MethodGet)
+	}
 	if err != nil {
 		cmd.Println(err)
 		return
@@ -799,3 +811,190 @@ func saveRuleBundle(cmd *cobra.Command, args []string) {
 
 	cmd.Println(res)
 }
+
+// NewCheckConfigCommand returns a check subcommand of configCmd.
+func NewCheckConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "check <subcommand>",
+		Short: "check the config of PD",
+	}
+	c.AddCommand(&cobra.Command{
+		Use:   "drift <golden_file>",
+		Short: "check whether the config and the placement rules drift from the golden file",
+		Long: "check whether the config and the placement rules drift from the golden file, which is in JSON, or in TOML if the file name ends with \".toml\". " +
+			"The golden file is in the format of \"config show all\", and only the items in it are checked, e.g. {\"schedule\": {\"leader-schedule-limit\": 4}, \"rules\": [...]}. " +
+			"The rules are matched by the group_id and the id, and the rules not in the golden file are regarded as drifts if \"rules\" is set. " +
+			"The command fails if any drift is found.",
+		RunE: checkConfigDriftCommandFunc,
+	})
+	return c
+}
+
+func checkConfigDriftCommandFunc(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		cmd.Println(cmd.UsageString())
+		return nil
+	}
+	golden, err := loadGoldenConfig(args[0])
+	if err != nil {
+		return errors.Annotate(err, "failed to load the golden file")
+	}
+	var actual map[string]interface{}
+	if err := getConfigJSON(cmd, configPrefix, &actual); err != nil {
+		return errors.Annotate(err, "failed to get config")
+	}
+	var drifts []string
+	for key, expected := range golden {
+		if key != "rules" {
+			drifts = append(drifts, diffConfig(key, expected, actual[key])...)
+		}
+	}
+	if expected, ok := golden["rules"]; ok {
+		var actual interface{}
+		if err := getConfigJSON(cmd, rulesPrefix, &actual); err != nil {
+			return errors.Annotate(err, "failed to get placement rules")
+		}
+		ruleDrifts, err := diffRules(expected, actual)
+		if err != nil {
+			return err
+		}
+		drifts = append(drifts, ruleDrifts...)
+	}
+	if len(drifts) == 0 {
+		cmd.Println("No config drift is found.")
+		return nil
+	}
+	sort.Strings(drifts)
+	for _, drift := range drifts {
+		cmd.Println(drift)
+	}
+	return errors.Errorf("%d config drifts are found", len(drifts))
+}
+
+// loadGoldenConfig loads the golden file, and the items are normalized as
+// the decoded JSON values to compare with the config got by the API.
+func loadGoldenConfig(file string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var golden map[string]interface{}
+	if strings.HasSuffix(file, ".toml") {
+		if _, err := toml.Decode(string(content), &golden); err != nil {
+			return nil, err
+		}
+		if content, err = json.Marshal(golden); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(content, &golden); err != nil {
+		return nil, err
+	}
+	return golden, nil
+}
+
+func getConfigJSON(cmd *cobra.Command, prefix string, v interface{}) error {
+	var (
+		r   string
+		err error
+	)
+	if prefix == rulesPrefix {
+		r, err = doListRequest(cmd, prefix)
+	} else {
+		r, err = doRequest(cmd, prefix, http.MethodGet)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(r), v)
+}
+
+// diffConfig returns the items in expected which are different from actual.
+// The items only in actual are ignored.
+func diffConfig(key string, expected, actual interface{}) []string {
+	if e, ok := expected.(map[string]interface{}); ok {
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{formatDrift(key, expected, actual)}
+		}
+		var drifts []string
+		for k, v := range e {
+			drifts = append(drifts, diffConfig(key+"."+k, v, a[k])...)
+		}
+		return drifts
+	}
+	if isConfigValueEqual(expected, actual) {
+		return nil
+	}
+	return []string{formatDrift(key, expected, actual)}
+}
+
+func isConfigValueEqual(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	// The durations are compared by the values, e.g. "1h" equals to "1h0m0s".
+	e, ok1 := expected.(string)
+	a, ok2 := actual.(string)
+	if ok1 && ok2 {
+		ed, err1 := time.ParseDuration(e)
+		ad, err2 := time.ParseDuration(a)
+		return err1 == nil && err2 == nil && ed == ad
+	}
+	return false
+}
+
+func diffRules(expected, actual interface{}) ([]string, error) {
+	expectedRules, err := ruleMap(expected)
+	if err != nil {
+		return nil, errors.Annotate(err, "invalid rules in the golden file")
+	}
+	actualRules, err := ruleMap(actual)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []string
+	for key, rule := range expectedRules {
+		drifts = append(drifts, diffConfig("rules."+key, rule, actualRules[key])...)
+	}
+	for key, rule := range actualRules {
+		if _, ok := expectedRules[key]; !ok {
+			drifts = append(drifts, formatDrift("rules."+key, nil, rule))
+		}
+	}
+	return drifts, nil
+}
+
+// ruleMap returns the rules keyed by "<group_id>/<id>".
+func ruleMap(rules interface{}) (map[string]interface{}, error) {
+	if rules == nil {
+		return map[string]interface{}{}, nil
+	}
+	list, ok := rules.([]interface{})
+	if !ok {
+		return nil, errors.New("rules should be a list")
+	}
+	res := make(map[string]interface{}, len(list))
+	for _, r := range list {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("rule should be an object")
+		}
+		res[fmt.Sprintf("%v/%v", rule["group_id"], rule["id"])] = rule
+	}
+	return res, nil
+}
+
+func formatDrift(key string, expected, actual interface{}) string {
+	format := func(v interface{}) string {
+		if v == nil {
+			return "<missing>"
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%s: expected %s, got %s", key, format(expected), format(actual))
+}
@@ -60,7 +60,7 @@ func deleteSSP(cmd *cobra.Command, args []string) {
 		return
 	}
 	serviceID := args[0]
-	deleteURL := serviceGCSafepointPrefix + "/" + serviceID
+	deleteURL := serviceGCSafepointPrefix + "/" + serviceID + "?confirm=true"
 	r, err := doRequest(cmd, deleteURL, http.MethodDelete)
 	if err != nil {
 		cmd.Printf("Failed to delete service GC safepoint: %s\n", err)
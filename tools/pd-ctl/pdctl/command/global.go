@@ -16,10 +16,12 @@ package command
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/pingcap/errors"
@@ -56,6 +58,7 @@ func InitHTTPSClient(CAPath, CertPath, KeyPath string) error {
 type bodyOption struct {
 	contentType string
 	body        io.Reader
+	respHeader  *http.Header
 }
 
 // BodyOption sets the type and content of the body
@@ -69,6 +72,14 @@ func WithBody(contentType string, body io.Reader) BodyOption {
 	}
 }
 
+// WithResponseHeader returns a BodyOption which receives the header of the
+// response.
+func WithResponseHeader(header *http.Header) BodyOption {
+	return func(bo *bodyOption) {
+		bo.respHeader = header
+	}
+}
+
 func doRequest(cmd *cobra.Command, prefix string, method string,
 	opts ...BodyOption) (string, error) {
 	b := &bodyOption{}
@@ -94,35 +105,82 @@ func doRequest(cmd *cobra.Command, prefix string, method string,
 			req.Header.Set("Content-Type", b.contentType)
 		}
 		// the resp would be returned by the outer function
-		resp, err = dial(req)
+		var header http.Header
+		resp, header, err = dial(req)
 		if err != nil {
 			return err
 		}
+		if b.respHeader != nil {
+			*b.respHeader = header
+		}
 		return nil
 	})
 	return resp, err
 }
 
-func dial(req *http.Request) (string, error) {
-	resp, err := dialClient.Do(req)
+// listPageLimit is the page size used to get a list page by page.
+const listPageLimit = 1000
+
+// doListRequest gets a list page by page with the offset and limit query
+// parameters, so that a large list is not returned in a single response. The
+// pages are merged into a JSON array.
+func doListRequest(cmd *cobra.Command, prefix string) (string, error) {
+	sep := "?"
+	if strings.Contains(prefix, "?") {
+		sep = "&"
+	}
+	var items []json.RawMessage
+	for offset := 0; ; offset += listPageLimit {
+		var header http.Header
+		r, err := doRequest(cmd, fmt.Sprintf("%s%soffset=%d&limit=%d", prefix, sep, offset, listPageLimit),
+			http.MethodGet, WithResponseHeader(&header))
+		if err != nil {
+			return "", err
+		}
+		total, err := strconv.Atoi(header.Get("X-Total-Count"))
+		if err != nil {
+			// The server does not support pagination and returns the whole list.
+			return r, nil
+		}
+		if offset == 0 && total <= listPageLimit {
+			return r, nil
+		}
+		var page []json.RawMessage
+		if err := json.Unmarshal([]byte(r), &page); err != nil {
+			return "", err
+		}
+		items = append(items, page...)
+		if len(page) == 0 || offset+listPageLimit >= total {
+			break
+		}
+	}
+	content, err := json.MarshalIndent(items, "", "  ")
 	if err != nil {
 		return "", err
 	}
+	return string(content), nil
+}
+
+func dial(req *http.Request) (string, http.Header, error) {
+	resp, err := dialClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		var msg []byte
 		msg, err = io.ReadAll(resp.Body)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
-		return "", errors.Errorf("[%d] %s", resp.StatusCode, msg)
+		return "", nil, errors.Errorf("[%d] %s", resp.StatusCode, msg)
 	}
 
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return string(content), nil
+	return string(content), resp.Header, nil
 }
 
 // DoFunc receives an endpoint which you can issue request to
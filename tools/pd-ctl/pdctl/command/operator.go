@@ -50,8 +50,9 @@ func NewCheckOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "check [region_id]",
 		Short: "checks the status of operator",
-		Run:   checkOperatorCommandFunc,
+		Run:   withWatch(checkOperatorCommandFunc),
 	}
+	addWatchFlags(c)
 	return c
 }
 
@@ -60,8 +61,9 @@ func NewShowOperatorCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "show [kind]",
 		Short: "show operators",
-		Run:   showOperatorCommandFunc,
+		Run:   withWatch(showOperatorCommandFunc),
 	}
+	addWatchFlags(c)
 	return c
 }
 
@@ -76,7 +78,7 @@ func showOperatorCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	r, err := doRequest(cmd, path, http.MethodGet)
+	r, err := doListRequest(cmd, path)
 	if err != nil {
 		cmd.Println(err)
 		return
@@ -157,6 +159,7 @@ func NewTransferRegionCommand() *cobra.Command {
 		Short: "transfer a region's peers to the specified stores",
 		Run:   transferRegionCommandFunc,
 	}
+	addForceFlag(c)
 	return c
 }
 
@@ -184,6 +187,7 @@ func transferRegionCommandFunc(cmd *cobra.Command, args []string) {
 	if len(roles) > 0 {
 		input["peer_roles"] = roles
 	}
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -194,6 +198,7 @@ func NewTransferPeerCommand() *cobra.Command {
 		Short: "transfer a region's peer from the specified store to another store",
 		Run:   transferPeerCommandFunc,
 	}
+	addForceFlag(c)
 	return c
 }
 
@@ -214,6 +219,7 @@ func transferPeerCommandFunc(cmd *cobra.Command, args []string) {
 	input["region_id"] = ids[0]
 	input["from_store_id"] = ids[1]
 	input["to_store_id"] = ids[2]
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -224,6 +230,7 @@ func NewAddPeerCommand() *cobra.Command {
 		Short: "add a region peer on specified store",
 		Run:   addPeerCommandFunc,
 	}
+	addForceFlag(c)
 	return c
 }
 
@@ -243,6 +250,7 @@ func addPeerCommandFunc(cmd *cobra.Command, args []string) {
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -253,6 +261,7 @@ func NewAddLearnerCommand() *cobra.Command {
 		Short: "add a region learner on specified store",
 		Run:   addLearnerCommandFunc,
 	}
+	addForceFlag(c)
 	return c
 }
 
@@ -272,6 +281,7 @@ func addLearnerCommandFunc(cmd *cobra.Command, args []string) {
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -280,8 +290,10 @@ func NewMergeRegionCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:   "merge-region <source_region_id> <target_region_id>",
 		Short: "merge source region into target region",
+		Long:  "merge source region into target region, the regions should be adjacent, and the peers of the source region are moved to the stores of the target region first if they don't match",
 		Run:   mergeRegionCommandFunc,
 	}
+	c.Flags().Bool("force", false, "merge the source region even if it exceeds the max merge region size or keys, and bypass the store limit")
 	return c
 }
 
@@ -301,6 +313,7 @@ func mergeRegionCommandFunc(cmd *cobra.Command, args []string) {
 	input["name"] = cmd.Name()
 	input["source_region_id"] = ids[0]
 	input["target_region_id"] = ids[1]
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -311,6 +324,7 @@ func NewRemovePeerCommand() *cobra.Command {
 		Short: "remove a region peer on specified store",
 		Run:   removePeerCommandFunc,
 	}
+	addForceFlag(c)
 	return c
 }
 
@@ -330,6 +344,7 @@ func removePeerCommandFunc(cmd *cobra.Command, args []string) {
 	input["name"] = cmd.Name()
 	input["region_id"] = ids[0]
 	input["store_id"] = ids[1]
+	setForce(cmd, input)
 	postJSON(cmd, operatorsPrefix, input)
 }
 
@@ -426,6 +441,17 @@ func removeOperatorCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println("Success!")
 }
 
+// addForceFlag adds the flag to make the operator bypass the store limit.
+func addForceFlag(c *cobra.Command) {
+	c.Flags().Bool("force", false, "bypass the store limit for the urgent repairs")
+}
+
+func setForce(cmd *cobra.Command, input map[string]interface{}) {
+	if force, _ := cmd.Flags().GetBool("force"); force {
+		input["force"] = true
+	}
+}
+
 func parseUint64s(args []string) ([]uint64, error) {
 	results := make([]uint64, 0, len(args))
 	for _, arg := range args {
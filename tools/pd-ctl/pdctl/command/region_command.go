@@ -41,6 +41,7 @@ var (
 	regionsSizePrefix      = "pd/api/v1/regions/size"
 	regionsKeyPrefix       = "pd/api/v1/regions/key"
 	regionsSiblingPrefix   = "pd/api/v1/regions/sibling"
+	regionsCheckGapsPrefix = "pd/api/v1/regions/check-gaps"
 	regionIDPrefix         = "pd/api/v1/region/id"
 	regionKeyPrefix        = "pd/api/v1/region/key"
 )
@@ -50,11 +51,12 @@ func NewRegionCommand() *cobra.Command {
 	r := &cobra.Command{
 		Use:   `region <region_id> [-jq="<query string>"]`,
 		Short: "show the region status",
-		Run:   showRegionCommandFunc,
+		Run:   withWatch(showRegionCommandFunc),
 	}
 	r.AddCommand(NewRegionWithKeyCommand())
 	r.AddCommand(NewRegionWithCheckCommand())
 	r.AddCommand(NewRegionWithSiblingCommand())
+	r.AddCommand(NewRegionCheckGapsCommand())
 	r.AddCommand(NewRegionWithStoreCommand())
 	r.AddCommand(NewRegionsWithStartKeyCommand())
 
@@ -107,6 +109,7 @@ func NewRegionCommand() *cobra.Command {
 	r.AddCommand(scanRegion)
 
 	r.Flags().String("jq", "", "jq query")
+	addWatchFlags(r)
 
 	return r
 }
@@ -414,7 +417,7 @@ func showRegionsFromStartKeyCommandFunc(cmd *cobra.Command, args []string) {
 // NewRegionWithCheckCommand returns a region with check subcommand of regionCmd
 func NewRegionWithCheckCommand() *cobra.Command {
 	r := &cobra.Command{
-		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|hist-size|hist-keys]",
+		Use:   "check [miss-peer|extra-peer|down-peer|learner-peer|pending-peer|offline-peer|empty-region|invalid-peer|lost-quorum|isolation-violation|hist-size|hist-keys]",
 		Short: "show the region with check specific status",
 		Run:   showRegionWithCheckCommandFunc,
 	}
@@ -482,6 +485,29 @@ func showRegionWithSiblingCommandFunc(cmd *cobra.Command, args []string) {
 	cmd.Println(r)
 }
 
+// NewRegionCheckGapsCommand returns a check-gaps subcommand of regionCmd
+func NewRegionCheckGapsCommand() *cobra.Command {
+	r := &cobra.Command{
+		Use:   "check-gaps",
+		Short: "check the key ranges which are covered by no region or more than one region",
+		Run:   checkRegionGapsCommandFunc,
+	}
+	return r
+}
+
+func checkRegionGapsCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	r, err := doRequest(cmd, regionsCheckGapsPrefix, http.MethodGet)
+	if err != nil {
+		cmd.Printf("Failed to check region gaps: %s\n", err)
+		return
+	}
+	cmd.Println(r)
+}
+
 // NewRegionWithStoreCommand returns regions with store subcommand of regionCmd
 func NewRegionWithStoreCommand() *cobra.Command {
 	r := &cobra.Command{
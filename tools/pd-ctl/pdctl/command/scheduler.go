@@ -109,7 +109,7 @@ func showSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 	if flag := cmd.Flag("status"); flag != nil && flag.Value.String() != "" {
 		url = fmt.Sprintf("%s?status=%s", url, flag.Value.String())
 	}
-	r, err := doRequest(cmd, url, http.MethodGet)
+	r, err := doListRequest(cmd, url)
 	if err != nil {
 		cmd.Println(err)
 		return
@@ -128,6 +128,7 @@ func NewAddSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewShuffleLeaderSchedulerCommand())
 	c.AddCommand(NewShuffleRegionSchedulerCommand())
 	c.AddCommand(NewShuffleHotRegionSchedulerCommand())
+	c.AddCommand(NewGrantHotLeaderSchedulerCommand())
 	c.AddCommand(NewScatterRangeSchedulerCommand())
 	c.AddCommand(NewBalanceLeaderSchedulerCommand())
 	c.AddCommand(NewBalanceRegionSchedulerCommand())
@@ -158,7 +159,7 @@ func NewEvictLeaderSchedulerCommand() *cobra.Command {
 }
 
 func checkSchedulerExist(cmd *cobra.Command, schedulerName string) (bool, error) {
-	r, err := doRequest(cmd, schedulersPrefix, http.MethodGet)
+	r, err := doListRequest(cmd, schedulersPrefix)
 	if err != nil {
 		cmd.Println(err)
 		return false, err
@@ -256,6 +257,35 @@ func addSchedulerForShuffleHotRegionCommandFunc(cmd *cobra.Command, args []strin
 	postJSON(cmd, schedulersPrefix, input)
 }
 
+// NewGrantHotLeaderSchedulerCommand returns a command to add a grant-hot-leader-scheduler.
+func NewGrantHotLeaderSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "grant-hot-leader-scheduler <label-key>=<label-value>[,<label-key>=<label-value>] [hot-region-count]",
+		Short: "add a scheduler to grant the leaders of the hottest regions to the stores with the labels",
+		Run:   addSchedulerForGrantHotLeaderCommandFunc,
+	}
+	return c
+}
+
+func addSchedulerForGrantHotLeaderCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		cmd.Println(cmd.UsageString())
+		return
+	}
+	input := make(map[string]interface{})
+	input["name"] = cmd.Name()
+	input["store_labels"] = args[0]
+	if len(args) == 2 {
+		count, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			cmd.Println("Error: ", err)
+			return
+		}
+		input["hot_region_count"] = count
+	}
+	postJSON(cmd, schedulersPrefix, input)
+}
+
 // NewBalanceLeaderSchedulerCommand returns a command to add a balance-leader-scheduler.
 func NewBalanceLeaderSchedulerCommand() *cobra.Command {
 	c := &cobra.Command{
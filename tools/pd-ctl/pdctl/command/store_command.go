@@ -36,7 +36,7 @@ func NewStoreCommand() *cobra.Command {
 	s := &cobra.Command{
 		Use:   `store [command] [flags]`,
 		Short: "manipulate or query stores",
-		Run:   showStoreCommandFunc,
+		Run:   withWatch(showStoreCommandFunc),
 	}
 	s.AddCommand(NewDeleteStoreCommand())
 	s.AddCommand(NewLabelStoreCommand())
@@ -47,6 +47,7 @@ func NewStoreCommand() *cobra.Command {
 	s.AddCommand(NewStoreCheckCommand())
 	s.Flags().String("jq", "", "jq query")
 	s.Flags().StringSlice("state", nil, "state filter")
+	addWatchFlags(s)
 	return s
 }
 
@@ -87,6 +88,7 @@ func NewSetStoreWeightCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "weight <store_id> <leader_weight> <region_weight>",
 		Short: "set a store's leader and region balance weight",
+		Long:  "set a store's leader and region balance weight, the store with leader_weight 0 doesn't accept new leaders from balance-leader",
 		Run:   setStoreWeightCommandFunc,
 	}
 }
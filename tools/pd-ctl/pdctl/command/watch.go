@@ -0,0 +1,81 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultWatchInterval = 2 * time.Second
+	minWatchInterval     = 100 * time.Millisecond
+)
+
+// addWatchFlags adds the flags to watch the output of a show command.
+func addWatchFlags(c *cobra.Command) {
+	c.Flags().BoolP("watch", "w", false, "refresh the output periodically and print it when it changes")
+	c.Flags().Duration("interval", defaultWatchInterval, "the refresh interval of watch")
+	c.Flags().Int("count", 0, "the times to refresh in watch, 0 means until interrupted")
+}
+
+// withWatch wraps a show command to run it repeatedly if the watch flag is set.
+// The output is printed with a timestamp only when it differs from the last one,
+// so the changes can be observed without re-running the command.
+func withWatch(run func(cmd *cobra.Command, args []string)) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		watch, err := flags.GetBool("watch")
+		if err != nil || !watch {
+			run(cmd, args)
+			return
+		}
+		interval, err := flags.GetDuration("interval")
+		if err != nil {
+			cmd.Printf("Failed to get interval: %s\n", err)
+			return
+		}
+		if interval < minWatchInterval {
+			cmd.Printf("The interval should not be less than %v\n", minWatchInterval)
+			return
+		}
+		count, err := flags.GetInt("count")
+		if err != nil {
+			cmd.Printf("Failed to get count: %s\n", err)
+			return
+		}
+
+		// The output of the sub commands inherits from the root command, so it is
+		// reset to nil after capturing.
+		defer cmd.SetOut(nil)
+		var last []byte
+		for i := 0; count <= 0 || i < count; i++ {
+			if i > 0 {
+				time.Sleep(interval)
+			}
+			buf := new(bytes.Buffer)
+			cmd.SetOut(buf)
+			run(cmd, args)
+			cmd.SetOut(nil)
+			if i > 0 && bytes.Equal(buf.Bytes(), last) {
+				continue
+			}
+			last = buf.Bytes()
+			cmd.Printf("Every %v, updated at %s\n", interval, time.Now().Format("2006-01-02 15:04:05"))
+			cmd.Print(buf.String())
+		}
+	}
+}
@@ -35,6 +35,8 @@ var (
 	regionUpdateRatio = flag.Float64("region-update-ratio", 0.05, "ratio of the region need to update")
 	sample            = flag.Bool("sample", false, "sample per second")
 	heartbeatRounds   = flag.Int("heartbeat-rounds", 5, "total rounds of heartbeat")
+	storeHeartbeat    = flag.Bool("store-heartbeat", true, "send store heartbeats at the beginning of each round")
+	processTimeout    = flag.Duration("process-timeout", time.Minute, "the max time to wait for PD to process the heartbeats of a round")
 )
 
 var clusterID uint64
@@ -126,13 +128,53 @@ func newEndKey(id uint64) []byte {
 	return k
 }
 
+// round is a round of the benchmark.
+type round struct {
+	regionReport report.Report
+	storeReport  report.Report
+}
+
 // Store simulates a TiKV to heartbeat.
 type Store struct {
 	id uint64
 }
 
+// heartbeatStore sends a store heartbeat with the region count of the store.
+func (s *Store) heartbeatStore(cli pdpb.PDClient, r report.Report) {
+	reqStart := time.Now()
+	_, err := cli.StoreHeartbeat(context.TODO(), &pdpb.StoreHeartbeatRequest{
+		Header: header(),
+		Stats: &pdpb.StoreStats{
+			StoreId:     s.id,
+			RegionCount: uint32(*regionCount * uint64(*replica) / uint64(*storeCount)),
+			Capacity:    1 << 40,
+			Available:   1 << 39,
+		},
+	})
+	r.Results() <- report.Result{Start: reqStart, End: time.Now(), Err: err}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// waitProcessed waits until PD has processed the heartbeat of the region with
+// the given version. Since the heartbeats of a stream are processed in order,
+// it means all the heartbeats sent before are processed.
+func (s *Store) waitProcessed(cli pdpb.PDClient, regionID, version uint64) time.Time {
+	deadline := time.Now().Add(*processTimeout)
+	for time.Now().Before(deadline) {
+		resp, err := cli.GetRegionByID(context.TODO(), &pdpb.GetRegionByIDRequest{Header: header(), RegionId: regionID})
+		if err == nil && resp.GetRegion().GetRegionEpoch().GetVersion() >= version {
+			return time.Now()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Printf("store %v wait for heartbeats processed timeout", s.id)
+	return time.Now()
+}
+
 // Run runs the store.
-func (s *Store) Run(startNotifier chan report.Report, endNotifier chan struct{}) {
+func (s *Store) Run(startNotifier chan round, endNotifier chan time.Time) {
 	cli := newClient()
 	stream, err := cli.RegionHeartbeat(context.TODO())
 	if err != nil {
@@ -149,8 +191,13 @@ func (s *Store) Run(startNotifier chan report.Report, endNotifier chan struct{})
 
 	count := 1
 	for r := range startNotifier {
+		if *storeHeartbeat {
+			s.heartbeatStore(cli, r.storeReport)
+		}
 		startTime := time.Now()
+		var lastRegionID uint64
 		for regionID := s.id; regionID <= *regionCount+uint64(*storeCount); regionID += uint64(*storeCount) {
+			lastRegionID = regionID
 			updateRegionCount := uint64(float64(*regionCount) * (*regionUpdateRatio) / float64(*storeCount))
 			storeUpdateRegionMaxID := s.id + updateRegionCount*uint64(*storeCount)
 			meta := &metapb.Region{
@@ -160,7 +207,9 @@ func (s *Store) Run(startNotifier chan report.Report, endNotifier chan struct{})
 				StartKey:    newStartKey(regionID),
 				EndKey:      newEndKey(regionID),
 			}
-			if regionID < storeUpdateRegionMaxID {
+			// The last region is always updated to know when PD has processed the
+			// heartbeats of this round.
+			if regionID < storeUpdateRegionMaxID || regionID+uint64(*storeCount) > *regionCount+uint64(*storeCount) {
 				meta.RegionEpoch.Version = uint64(count)
 			}
 			reqStart := time.Now()
@@ -170,14 +219,15 @@ func (s *Store) Run(startNotifier chan report.Report, endNotifier chan struct{})
 				Leader: peers[0],
 			})
 
-			r.Results() <- report.Result{Start: reqStart, End: time.Now(), Err: err}
+			r.regionReport.Results() <- report.Result{Start: reqStart, End: time.Now(), Err: err}
 			if err != nil {
 				log.Fatal(err)
 			}
 		}
 		log.Printf("store %v finish heartbeat, cost time: %v", s.id, time.Since(startTime))
+		processed := s.waitProcessed(cli, lastRegionID, uint64(count))
 		count++
-		endNotifier <- struct{}{}
+		endNotifier <- processed
 	}
 }
 
@@ -191,12 +241,12 @@ func main() {
 	putStores(cli)
 
 	log.Println("finish put stores")
-	groupStartNotify := make([]chan report.Report, *storeCount+1)
-	groupEndNotify := make([]chan struct{}, *storeCount+1)
+	groupStartNotify := make([]chan round, *storeCount+1)
+	groupEndNotify := make([]chan time.Time, *storeCount+1)
 	for i := 1; i <= *storeCount; i++ {
 		s := Store{id: uint64(i)}
-		startNotifier := make(chan report.Report)
-		endNotifier := make(chan struct{})
+		startNotifier := make(chan round)
+		endNotifier := make(chan time.Time)
 		groupStartNotify[i] = startNotifier
 		groupEndNotify[i] = endNotifier
 		go s.Run(startNotifier, endNotifier)
@@ -204,19 +254,34 @@ func main() {
 
 	for i := 0; i < *heartbeatRounds; i++ {
 		log.Printf("\n--------- Bench heartbeat (Round %d) ----------\n", i+1)
-		report := newReport()
-		rs := report.Run()
+		r := round{regionReport: newReport(), storeReport: newReport()}
+		regionResults, storeResults := r.regionReport.Run(), r.storeReport.Run()
+		startTime := time.Now()
 		// All stores start heartbeat.
 		for storeID := 1; storeID <= *storeCount; storeID++ {
 			startNotifier := groupStartNotify[storeID]
-			startNotifier <- report
+			startNotifier <- r
 		}
-		// All stores finished heartbeat once.
+		// All stores finished heartbeat once and PD has processed them.
+		var endTime time.Time
 		for storeID := 1; storeID <= *storeCount; storeID++ {
-			<-groupEndNotify[storeID]
+			if processed := <-groupEndNotify[storeID]; processed.After(endTime) {
+				endTime = processed
+			}
 		}
 
-		close(report.Results())
-		log.Println(<-rs)
+		close(r.regionReport.Results())
+		close(r.storeReport.Results())
+		log.Println("Region heartbeat sent:")
+		log.Println(<-regionResults)
+		if *storeHeartbeat {
+			log.Println("Store heartbeat:")
+			log.Println(<-storeResults)
+		} else {
+			<-storeResults
+		}
+		cost := endTime.Sub(startTime)
+		log.Printf("PD processed %d region heartbeats in %v, %.2f heartbeats/s\n",
+			*regionCount, cost, float64(*regionCount)/cost.Seconds())
 	}
 }
@@ -49,13 +49,14 @@ var (
 	regionNum                   = flag.Int("regionNum", 0, "regionNum of one store")
 	storeNum                    = flag.Int("storeNum", 0, "storeNum")
 	enableTransferRegionCounter = flag.Bool("enableTransferRegionCounter", false, "enableTransferRegionCounter")
+	tracePath                   = flag.String("trace", "", "the trace replayed by the trace-replay case, a JSON file, a directory of CSV files or a cluster dump directory")
 )
 
 func main() {
 	flag.Parse()
 
 	simutil.InitLogger(*simLogLevel, *simLogFile)
-	simutil.InitCaseConfig(*storeNum, *regionNum, *enableTransferRegionCounter)
+	simutil.InitCaseConfig(*storeNum, *regionNum, *enableTransferRegionCounter, *tracePath)
 	statistics.Denoising = false
 	if simutil.CaseConfigure.EnableTransferRegionCounter {
 		analysis.GetTransferCounter().Init(simutil.CaseConfigure.StoreNum, simutil.CaseConfigure.RegionNum)
@@ -77,6 +78,16 @@ func run(simCase string) {
 	simConfig := simulator.NewSimConfig(*serverLogLevel)
 	var meta toml.MetaData
 	var err error
+	if simCase == cases.TraceReplayCaseName && cases.IsClusterDump(*tracePath) {
+		// Start with the config of the dumped cluster, which can be overridden
+		// by the config file.
+		cfg, err := cases.LoadClusterDumpConfig(*tracePath)
+		if err != nil {
+			simutil.Logger.Fatal("failed to load the config of the cluster dump", zap.Error(err))
+		}
+		simConfig.ServerConfig.Schedule = cfg.Schedule
+		simConfig.ServerConfig.Replication = cfg.Replication
+	}
 	if *configFile != "" {
 		if meta, err = toml.DecodeFile(*configFile, simConfig); err != nil {
 			simutil.Logger.Fatal("failed to decode file ", zap.Error(err))
@@ -109,6 +109,9 @@ func NewCase(name string) *Case {
 	if f, ok := CaseMap[name]; ok {
 		return f()
 	}
+	if name == TraceReplayCaseName {
+		return newTraceReplay()
+	}
 	return nil
 }
 
@@ -0,0 +1,123 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/api"
+	"github.com/tikv/pd/server/config"
+	"github.com/tikv/pd/server/statistics"
+)
+
+// The files of a cluster dump directory written by `pd-ctl cluster dump`.
+const (
+	dumpStoresFile  = "stores.json"
+	dumpRegionsFile = "regions.json"
+	dumpConfigFile  = "config.json"
+)
+
+// IsClusterDump checks if the path is a cluster dump directory.
+func IsClusterDump(path string) bool {
+	info, err := os.Stat(filepath.Join(path, dumpStoresFile))
+	return err == nil && !info.IsDir()
+}
+
+// LoadClusterDump loads a trace from a cluster dump directory, so that the
+// topology and the region distribution of a real cluster can be reproduced.
+// The regions are kept in the order of keys and the tombstone stores are
+// skipped. The flows of the regions are converted to bytes per second.
+func LoadClusterDump(dir string) (*Trace, error) {
+	var stores []*api.StoreInfo
+	if err := readDumpFile(filepath.Join(dir, dumpStoresFile), &stores); err != nil {
+		return nil, err
+	}
+	var regions []*api.RegionInfo
+	if err := readDumpFile(filepath.Join(dir, dumpRegionsFile), &regions); err != nil {
+		return nil, err
+	}
+
+	trace := &Trace{}
+	tombstones := make(map[uint64]struct{})
+	for _, s := range stores {
+		if s.Store == nil || s.Store.Store == nil {
+			return nil, errors.New("store meta is missing in the dump")
+		}
+		if s.Store.GetState() == metapb.StoreState_Tombstone {
+			tombstones[s.Store.GetId()] = struct{}{}
+			continue
+		}
+		store := TraceStore{
+			ID:      s.Store.GetId(),
+			State:   s.Store.GetState().String(),
+			Version: s.Store.GetVersion(),
+		}
+		if s.Status != nil {
+			store.Capacity = uint64(s.Status.Capacity)
+			store.Available = uint64(s.Status.Available)
+			store.LeaderWeight = float32(s.Status.LeaderWeight)
+			store.RegionWeight = float32(s.Status.RegionWeight)
+		}
+		for _, label := range s.Store.GetLabels() {
+			if store.Labels == nil {
+				store.Labels = make(map[string]string)
+			}
+			store.Labels[label.GetKey()] = label.GetValue()
+		}
+		trace.Stores = append(trace.Stores, store)
+	}
+
+	for _, r := range regions {
+		region := TraceRegion{
+			ID:         r.ID,
+			Leader:     r.Leader.GetStoreId(),
+			Size:       r.ApproximateSize * MB,
+			Keys:       r.ApproximateKeys,
+			WriteBytes: int64(r.WrittenBytes / statistics.RegionHeartBeatReportInterval),
+			ReadBytes:  int64(r.ReadBytes / statistics.RegionHeartBeatReportInterval),
+		}
+		for _, peer := range r.Peers {
+			if _, ok := tombstones[peer.GetStoreId()]; !ok {
+				region.Peers = append(region.Peers, peer.GetStoreId())
+			}
+		}
+		if len(region.Peers) == 0 {
+			continue
+		}
+		trace.Regions = append(trace.Regions, region)
+	}
+	return trace, nil
+}
+
+// LoadClusterDumpConfig loads the config of the cluster from the dump, which
+// is used by the PD started by the simulator to reproduce the scheduling.
+func LoadClusterDumpConfig(dir string) (*config.Config, error) {
+	cfg := &config.Config{}
+	if err := readDumpFile(filepath.Join(dir, dumpConfigFile), cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func readDumpFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Annotatef(json.Unmarshal(data, v), "failed to parse %s", path)
+}
@@ -0,0 +1,274 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+)
+
+// Trace is the workload of a real cluster, e.g. exported from a production PD,
+// which can be replayed by the trace-replay case.
+type Trace struct {
+	Stores  []TraceStore  `json:"stores"`
+	Regions []TraceRegion `json:"regions"`
+	// Flows are the samples of the region flows. Each of them takes effect from
+	// its tick until the next sample of the same region.
+	Flows []TraceFlow `json:"flows"`
+}
+
+// TraceStore is a store in the trace.
+type TraceStore struct {
+	ID uint64 `json:"id"`
+	// State is the name of the store state, e.g. "Offline", empty means "Up".
+	State        string            `json:"state,omitempty"`
+	Capacity     uint64            `json:"capacity"`
+	Available    uint64            `json:"available"`
+	Labels       map[string]string `json:"labels"`
+	LeaderWeight float32           `json:"leader_weight"`
+	RegionWeight float32           `json:"region_weight"`
+	Version      string            `json:"version"`
+}
+
+// TraceRegion is a region in the trace, the flows are the bytes written or
+// read in a tick.
+type TraceRegion struct {
+	ID uint64 `json:"id"`
+	// Peers are the store IDs of the peers.
+	Peers []uint64 `json:"peers"`
+	// Leader is the store ID of the leader, 0 means the first peer.
+	Leader     uint64 `json:"leader"`
+	Size       int64  `json:"size"`
+	Keys       int64  `json:"keys"`
+	WriteBytes int64  `json:"write_bytes"`
+	ReadBytes  int64  `json:"read_bytes"`
+}
+
+// TraceFlow is a sample of the flows of a region since a tick.
+type TraceFlow struct {
+	Tick       int64  `json:"tick"`
+	RegionID   uint64 `json:"region_id"`
+	WriteBytes int64  `json:"write_bytes"`
+	ReadBytes  int64  `json:"read_bytes"`
+}
+
+// The CSV files of a trace directory, the first line of each file is the header
+// which names the columns:
+//
+//	stores.csv:  id,capacity,available,labels,leader_weight,region_weight,version
+//	regions.csv: id,peers,leader,size,keys,write_bytes,read_bytes
+//	flows.csv:   tick,region_id,write_bytes,read_bytes
+//
+// The labels are in the format of "k1=v1;k2=v2" and the peers are the store IDs
+// separated by ";". flows.csv is optional.
+const (
+	traceStoresFile  = "stores.csv"
+	traceRegionsFile = "regions.csv"
+	traceFlowsFile   = "flows.csv"
+)
+
+// LoadTrace loads a trace from a JSON file, a directory of CSV files or a
+// cluster dump directory.
+func LoadTrace(path string) (*Trace, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if info.IsDir() && IsClusterDump(path) {
+		return LoadClusterDump(path)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		trace := &Trace{}
+		if err := json.Unmarshal(data, trace); err != nil {
+			return nil, errors.Annotatef(err, "failed to parse trace %s", path)
+		}
+		return trace, nil
+	}
+
+	trace := &Trace{}
+	if err := readTraceCSV(filepath.Join(path, traceStoresFile), false, func(row traceRow) error {
+		store := TraceStore{Version: row.str("version")}
+		var err error
+		if store.ID, err = row.uint("id"); err != nil {
+			return err
+		}
+		if store.Capacity, err = row.uint("capacity"); err != nil {
+			return err
+		}
+		if store.Available, err = row.uint("available"); err != nil {
+			return err
+		}
+		if store.LeaderWeight, err = row.float("leader_weight"); err != nil {
+			return err
+		}
+		if store.RegionWeight, err = row.float("region_weight"); err != nil {
+			return err
+		}
+		if labels := row.str("labels"); labels != "" {
+			store.Labels = make(map[string]string)
+			for _, label := range strings.Split(labels, ";") {
+				kv := strings.SplitN(label, "=", 2)
+				if len(kv) != 2 {
+					return errors.Errorf("invalid label %s", label)
+				}
+				store.Labels[kv[0]] = kv[1]
+			}
+		}
+		trace.Stores = append(trace.Stores, store)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := readTraceCSV(filepath.Join(path, traceRegionsFile), false, func(row traceRow) error {
+		var region TraceRegion
+		var err error
+		if region.ID, err = row.uint("id"); err != nil {
+			return err
+		}
+		for _, peer := range strings.Split(row.str("peers"), ";") {
+			storeID, err := strconv.ParseUint(peer, 10, 64)
+			if err != nil {
+				return errors.Errorf("invalid peers %s", row.str("peers"))
+			}
+			region.Peers = append(region.Peers, storeID)
+		}
+		if region.Leader, err = row.uint("leader"); err != nil {
+			return err
+		}
+		if region.Size, err = row.int("size"); err != nil {
+			return err
+		}
+		if region.Keys, err = row.int("keys"); err != nil {
+			return err
+		}
+		if region.WriteBytes, err = row.int("write_bytes"); err != nil {
+			return err
+		}
+		if region.ReadBytes, err = row.int("read_bytes"); err != nil {
+			return err
+		}
+		trace.Regions = append(trace.Regions, region)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := readTraceCSV(filepath.Join(path, traceFlowsFile), true, func(row traceRow) error {
+		var flow TraceFlow
+		var err error
+		if flow.Tick, err = row.int("tick"); err != nil {
+			return err
+		}
+		if flow.RegionID, err = row.uint("region_id"); err != nil {
+			return err
+		}
+		if flow.WriteBytes, err = row.int("write_bytes"); err != nil {
+			return err
+		}
+		if flow.ReadBytes, err = row.int("read_bytes"); err != nil {
+			return err
+		}
+		trace.Flows = append(trace.Flows, flow)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// traceRow is a row of a CSV file, the missing columns are treated as empty.
+type traceRow struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r traceRow) str(name string) string {
+	if i, ok := r.columns[name]; ok && i < len(r.record) {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
+
+func (r traceRow) uint(name string) (uint64, error) {
+	s := r.str(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	return v, errors.Annotatef(err, "invalid %s", name)
+}
+
+func (r traceRow) int(name string) (int64, error) {
+	s := r.str(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, errors.Annotatef(err, "invalid %s", name)
+}
+
+func (r traceRow) float(name string) (float32, error) {
+	s := r.str(name)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 32)
+	return float32(v), errors.Annotatef(err, "invalid %s", name)
+}
+
+func readTraceCSV(path string, optional bool, f func(row traceRow) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return errors.Annotatef(err, "failed to read the header of %s", path)
+	}
+	row := traceRow{columns: make(map[string]int, len(header))}
+	for i, name := range header {
+		row.columns[strings.TrimSpace(name)] = i
+	}
+	for {
+		row.record, err = reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Annotatef(err, "failed to read %s", path)
+		}
+		if err := f(row); err != nil {
+			return errors.Annotatef(err, "failed to parse %s", path)
+		}
+	}
+}
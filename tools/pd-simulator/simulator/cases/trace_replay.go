@@ -0,0 +1,217 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/tikv/pd/server/core"
+	"github.com/tikv/pd/tools/pd-simulator/simulator/info"
+	"github.com/tikv/pd/tools/pd-simulator/simulator/simutil"
+	"go.uber.org/zap"
+)
+
+// TraceReplayCaseName is the name of the case which replays a trace. It is not
+// in CaseMap since it needs a trace to run.
+const TraceReplayCaseName = "trace-replay"
+
+func newTraceReplay() *Case {
+	path := simutil.CaseConfigure.TracePath
+	if path == "" {
+		simutil.Logger.Fatal("the trace is required by the trace-replay case")
+	}
+	trace, err := LoadTrace(path)
+	if err != nil {
+		simutil.Logger.Fatal("failed to load trace", zap.String("path", path), zap.Error(err))
+	}
+	simCase, err := newTraceCase(trace)
+	if err != nil {
+		simutil.Logger.Fatal("invalid trace", zap.String("path", path), zap.Error(err))
+	}
+	return simCase
+}
+
+func newTraceCase(trace *Trace) (*Case, error) {
+	var simCase Case
+	if len(trace.Stores) == 0 {
+		return nil, errors.New("no store in the trace")
+	}
+
+	// The IDs of the stores and regions come from the trace, so the peer IDs
+	// are allocated after them.
+	stores := make(map[uint64]struct{}, len(trace.Stores))
+	for _, s := range trace.Stores {
+		if s.ID == 0 {
+			return nil, errors.New("store ID should not be 0")
+		}
+		if IDAllocator.id < s.ID {
+			IDAllocator.id = s.ID
+		}
+		stores[s.ID] = struct{}{}
+	}
+	for _, r := range trace.Regions {
+		if IDAllocator.id < r.ID {
+			IDAllocator.id = r.ID
+		}
+	}
+
+	for _, s := range trace.Stores {
+		status := metapb.StoreState_Up
+		if s.State != "" {
+			state, ok := metapb.StoreState_value[s.State]
+			if !ok || metapb.StoreState(state) == metapb.StoreState_Tombstone {
+				return nil, errors.Errorf("invalid state %s of store %d", s.State, s.ID)
+			}
+			status = metapb.StoreState(state)
+		}
+		store := &Store{
+			ID:           s.ID,
+			Status:       status,
+			Capacity:     s.Capacity,
+			Available:    s.Available,
+			LeaderWeight: s.LeaderWeight,
+			RegionWeight: s.RegionWeight,
+			Version:      s.Version,
+		}
+		if store.LeaderWeight == 0 {
+			store.LeaderWeight = 1
+		}
+		if store.RegionWeight == 0 {
+			store.RegionWeight = 1
+		}
+		if store.Version == "" {
+			store.Version = "2.1.0"
+		}
+		for k, v := range s.Labels {
+			store.Labels = append(store.Labels, &metapb.StoreLabel{Key: k, Value: v})
+		}
+		sort.Slice(store.Labels, func(i, j int) bool { return store.Labels[i].Key < store.Labels[j].Key })
+		simCase.Stores = append(simCase.Stores, store)
+	}
+
+	regions := make(map[uint64]struct{}, len(trace.Regions))
+	for _, r := range trace.Regions {
+		if len(r.Peers) == 0 {
+			return nil, errors.Errorf("region %d has no peer", r.ID)
+		}
+		region := Region{ID: r.ID, Size: r.Size, Keys: r.Keys}
+		for _, storeID := range r.Peers {
+			if _, ok := stores[storeID]; !ok {
+				return nil, errors.Errorf("store %d of region %d is not found", storeID, r.ID)
+			}
+			peer := &metapb.Peer{Id: IDAllocator.nextID(), StoreId: storeID}
+			region.Peers = append(region.Peers, peer)
+			if storeID == r.Leader {
+				region.Leader = peer
+			}
+		}
+		if region.Leader == nil {
+			region.Leader = region.Peers[0]
+		}
+		simCase.Regions = append(simCase.Regions, region)
+		regions[r.ID] = struct{}{}
+	}
+	for _, f := range trace.Flows {
+		if _, ok := regions[f.RegionID]; !ok {
+			return nil, errors.Errorf("region %d of flow is not found", f.RegionID)
+		}
+	}
+
+	replayer := newFlowReplayer(trace)
+	simCase.Events = []EventDescriptor{
+		&WriteFlowOnRegionDescriptor{Step: func(tick int64) map[uint64]int64 {
+			replayer.advance(tick)
+			return replayer.writeFlows
+		}},
+		&ReadFlowOnRegionDescriptor{Step: func(tick int64) map[uint64]int64 {
+			replayer.advance(tick)
+			return replayer.readFlows
+		}},
+	}
+
+	// The case finishes after all the flows are replayed, or the regions are
+	// balanced if there is no flow.
+	var checkCount int64
+	simCase.Checker = func(regions *core.RegionsInfo, stats []info.StoreStats) bool {
+		checkCount++
+		if len(trace.Flows) > 0 {
+			return checkCount > replayer.lastTick()
+		}
+		var total int
+		for _, s := range trace.Stores {
+			total += regions.GetStoreRegionCount(s.ID)
+		}
+		mean := total / len(trace.Stores)
+		for _, s := range trace.Stores {
+			count := regions.GetStoreRegionCount(s.ID)
+			simutil.Logger.Info("current counts", zap.Uint64("store-id", s.ID), zap.Int("region", count))
+			if !isUniform(count, mean, 0.05) {
+				return false
+			}
+		}
+		return true
+	}
+	return &simCase, nil
+}
+
+// flowReplayer replays the flows of the regions in the order of ticks.
+type flowReplayer struct {
+	flows      []TraceFlow
+	next       int
+	writeFlows map[uint64]int64
+	readFlows  map[uint64]int64
+}
+
+func newFlowReplayer(trace *Trace) *flowReplayer {
+	r := &flowReplayer{
+		flows:      append([]TraceFlow{}, trace.Flows...),
+		writeFlows: make(map[uint64]int64),
+		readFlows:  make(map[uint64]int64),
+	}
+	sort.SliceStable(r.flows, func(i, j int) bool { return r.flows[i].Tick < r.flows[j].Tick })
+	for _, region := range trace.Regions {
+		r.set(region.ID, region.WriteBytes, region.ReadBytes)
+	}
+	return r
+}
+
+func (r *flowReplayer) set(regionID uint64, writeBytes, readBytes int64) {
+	if writeBytes > 0 {
+		r.writeFlows[regionID] = writeBytes
+	} else {
+		delete(r.writeFlows, regionID)
+	}
+	if readBytes > 0 {
+		r.readFlows[regionID] = readBytes
+	} else {
+		delete(r.readFlows, regionID)
+	}
+}
+
+// advance applies the flows whose tick is not after the given tick.
+func (r *flowReplayer) advance(tick int64) {
+	for ; r.next < len(r.flows) && r.flows[r.next].Tick <= tick; r.next++ {
+		f := r.flows[r.next]
+		r.set(f.RegionID, f.WriteBytes, f.ReadBytes)
+	}
+}
+
+func (r *flowReplayer) lastTick() int64 {
+	if len(r.flows) == 0 {
+		return 0
+	}
+	return r.flows[len(r.flows)-1].Tick
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 TiKV Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cases
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/kvproto/pkg/metapb"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTraceSuite{})
+
+type testTraceSuite struct{}
+
+func (s *testTraceSuite) TearDownTest(c *C) {
+	IDAllocator.ResetID()
+}
+
+func (s *testTraceSuite) TestLoadCSVTrace(c *C) {
+	dir := c.MkDir()
+	files := map[string]string{
+		traceStoresFile:  "id,capacity,available,labels\n1,100,50,zone=z1;host=h1\n2,100,60,zone=z2\n3,200,150,\n",
+		traceRegionsFile: "id,peers,leader,size,keys,write_bytes\n10,1;2;3,2,96,1000,1024\n11,3;2;1,,64,500,\n",
+		traceFlowsFile:   "tick,region_id,write_bytes,read_bytes\n20,11,2048,512\n10,10,0,\n",
+	}
+	for name, content := range files {
+		c.Assert(os.WriteFile(filepath.Join(dir, name), []byte(content), 0600), IsNil)
+	}
+	trace, err := LoadTrace(dir)
+	c.Assert(err, IsNil)
+	c.Assert(trace.Stores, DeepEquals, []TraceStore{
+		{ID: 1, Capacity: 100, Available: 50, Labels: map[string]string{"zone": "z1", "host": "h1"}},
+		{ID: 2, Capacity: 100, Available: 60, Labels: map[string]string{"zone": "z2"}},
+		{ID: 3, Capacity: 200, Available: 150},
+	})
+	c.Assert(trace.Regions, DeepEquals, []TraceRegion{
+		{ID: 10, Peers: []uint64{1, 2, 3}, Leader: 2, Size: 96, Keys: 1000, WriteBytes: 1024},
+		{ID: 11, Peers: []uint64{3, 2, 1}, Size: 64, Keys: 500},
+	})
+	c.Assert(trace.Flows, HasLen, 2)
+
+	simCase, err := newTraceCase(trace)
+	c.Assert(err, IsNil)
+	c.Assert(simCase.Stores, HasLen, 3)
+	c.Assert(simCase.Stores[0].Labels, HasLen, 2)
+	c.Assert(simCase.Regions, HasLen, 2)
+	c.Assert(simCase.Regions[0].Leader.GetStoreId(), Equals, uint64(2))
+	c.Assert(simCase.Regions[1].Leader.GetStoreId(), Equals, uint64(3))
+	// The peer IDs are allocated after the IDs in the trace.
+	for _, region := range simCase.Regions {
+		for _, peer := range region.Peers {
+			c.Assert(peer.GetId() > 11, IsTrue)
+		}
+	}
+
+	// A region is not found.
+	trace.Flows = append(trace.Flows, TraceFlow{Tick: 1, RegionID: 12})
+	_, err = newTraceCase(trace)
+	c.Assert(err, NotNil)
+}
+
+func (s *testTraceSuite) TestLoadJSONTrace(c *C) {
+	path := filepath.Join(c.MkDir(), "trace.json")
+	content := `{"stores": [{"id": 1, "capacity": 100}], "regions": [{"id": 2, "peers": [1], "read_bytes": 10}]}`
+	c.Assert(os.WriteFile(path, []byte(content), 0600), IsNil)
+	trace, err := LoadTrace(path)
+	c.Assert(err, IsNil)
+	c.Assert(trace.Stores, DeepEquals, []TraceStore{{ID: 1, Capacity: 100}})
+	c.Assert(trace.Regions, DeepEquals, []TraceRegion{{ID: 2, Peers: []uint64{1}, ReadBytes: 10}})
+
+	c.Assert(os.WriteFile(path, []byte("{"), 0600), IsNil)
+	_, err = LoadTrace(path)
+	c.Assert(err, NotNil)
+}
+
+func (s *testTraceSuite) TestFlowReplayer(c *C) {
+	trace := &Trace{
+		Regions: []TraceRegion{
+			{ID: 1, WriteBytes: 100},
+			{ID: 2, ReadBytes: 200},
+		},
+		Flows: []TraceFlow{
+			{Tick: 20, RegionID: 1, WriteBytes: 0, ReadBytes: 50},
+			{Tick: 10, RegionID: 2, WriteBytes: 300, ReadBytes: 200},
+		},
+	}
+	r := newFlowReplayer(trace)
+	c.Assert(r.lastTick(), Equals, int64(20))
+	r.advance(1)
+	c.Assert(r.writeFlows, DeepEquals, map[uint64]int64{1: 100})
+	c.Assert(r.readFlows, DeepEquals, map[uint64]int64{2: 200})
+	r.advance(10)
+	c.Assert(r.writeFlows, DeepEquals, map[uint64]int64{1: 100, 2: 300})
+	r.advance(30)
+	c.Assert(r.writeFlows, DeepEquals, map[uint64]int64{2: 300})
+	c.Assert(r.readFlows, DeepEquals, map[uint64]int64{1: 50, 2: 200})
+}
+
+func (s *testTraceSuite) TestLoadClusterDump(c *C) {
+	dir := c.MkDir()
+	c.Assert(IsClusterDump(dir), IsFalse)
+	files := map[string]string{
+		dumpStoresFile: `[
+{"store": {"id": 1, "version": "5.1.0", "labels": [{"key": "zone", "value": "z1"}]}, "status": {"capacity": "1GiB", "available": "512MiB", "leader_weight": 1, "region_weight": 2}},
+{"store": {"id": 2, "state": 1}, "status": {"capacity": "1GiB", "available": "1GiB"}},
+{"store": {"id": 3, "state": 2}}]`,
+		dumpRegionsFile: `[
+{"id": 10, "peers": [{"id": 11, "store_id": 1}, {"id": 12, "store_id": 2}, {"id": 13, "store_id": 3}], "leader": {"id": 12, "store_id": 2}, "approximate_size": 96, "approximate_keys": 1000, "written_bytes": 600, "read_bytes": 60},
+{"id": 20, "peers": [{"id": 21, "store_id": 3}]}]`,
+		dumpConfigFile: `{"schedule": {"leader-schedule-limit": 8}, "replication": {"max-replicas": 5}}`,
+	}
+	for name, content := range files {
+		c.Assert(os.WriteFile(filepath.Join(dir, name), []byte(content), 0600), IsNil)
+	}
+	c.Assert(IsClusterDump(dir), IsTrue)
+
+	trace, err := LoadTrace(dir)
+	c.Assert(err, IsNil)
+	c.Assert(trace.Stores, DeepEquals, []TraceStore{
+		{ID: 1, State: "Up", Capacity: 1 << 30, Available: 512 << 20, LeaderWeight: 1, RegionWeight: 2, Labels: map[string]string{"zone": "z1"}, Version: "5.1.0"},
+		{ID: 2, State: "Offline", Capacity: 1 << 30, Available: 1 << 30},
+	})
+	// The peers on the tombstone store are dropped.
+	c.Assert(trace.Regions, DeepEquals, []TraceRegion{
+		{ID: 10, Peers: []uint64{1, 2}, Leader: 2, Size: 96 * MB, Keys: 1000, WriteBytes: 10, ReadBytes: 1},
+	})
+
+	simCase, err := newTraceCase(trace)
+	c.Assert(err, IsNil)
+	c.Assert(simCase.Stores, HasLen, 2)
+	c.Assert(simCase.Stores[1].Status, Equals, metapb.StoreState_Offline)
+
+	cfg, err := LoadClusterDumpConfig(dir)
+	c.Assert(err, IsNil)
+	c.Assert(cfg.Schedule.LeaderScheduleLimit, Equals, uint64(8))
+	c.Assert(cfg.Replication.MaxReplicas, Equals, uint64(5))
+}
@@ -18,16 +18,19 @@ type CaseConfig struct {
 	StoreNum                    int
 	RegionNum                   int
 	EnableTransferRegionCounter bool
+	// TracePath is the path of the trace replayed by the trace-replay case.
+	TracePath string
 }
 
 // CaseConfigure is an global instance for CaseConfig
 var CaseConfigure *CaseConfig
 
 // InitCaseConfig is to init caseConfigure
-func InitCaseConfig(StoreNum, RegionNum int, EnableTransferRegionCounter bool) {
+func InitCaseConfig(StoreNum, RegionNum int, EnableTransferRegionCounter bool, TracePath string) {
 	CaseConfigure = &CaseConfig{
 		StoreNum:                    StoreNum,
 		RegionNum:                   RegionNum,
 		EnableTransferRegionCounter: EnableTransferRegionCounter,
+		TracePath:                   TracePath,
 	}
 }
@@ -22,6 +22,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -39,7 +41,9 @@ var (
 	concurrency  = flag.Int("c", 1000, "concurrency")
 	count        = flag.Int("count", 1, "the count number that the test will run")
 	duration     = flag.Duration("duration", 60*time.Second, "how many seconds the test will last")
-	dcLocation   = flag.String("dc", "global", "which dc-location this bench will request")
+	dcLocation   = flag.String("dc", "global", "which dc-locations this bench will request, separated by commas")
+	batchSize    = flag.Int("batch", 1, "the number of TSO requests each worker sends at once")
+	errBackoff   = flag.Duration("err-backoff", 100*time.Millisecond, "the time to wait before retrying after a TSO request failed")
 	verbose      = flag.Bool("v", false, "output statistics info every interval and output metrics info at the end")
 	interval     = flag.Duration("interval", time.Second, "interval to output the statistics")
 	caPath       = flag.String("cacert", "", "path of file that contains list of trusted SSL CAs")
@@ -60,6 +64,9 @@ func collectMetrics(server *httptest.Server) string {
 
 func main() {
 	flag.Parse()
+	if *batchSize < 1 {
+		log.Fatal("the batch size should be positive", zap.Int("batch", *batchSize))
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	sc := make(chan os.Signal, 1)
@@ -98,25 +105,30 @@ func bench(mainCtx context.Context) {
 	}
 
 	ctx, cancel := context.WithCancel(mainCtx)
+	dcLocations := strings.Split(*dcLocation, ",")
 	// To avoid the first time high latency.
 	for idx, pdCli := range pdClients {
-		_, _, err := pdCli.GetLocalTS(ctx, *dcLocation)
-		if err != nil {
-			log.Fatal("get first time tso failed", zap.Int("client-number", idx), zap.Error(err))
+		for _, dc := range dcLocations {
+			_, _, err := pdCli.GetLocalTS(ctx, dc)
+			if err != nil {
+				log.Fatal("get first time tso failed", zap.Int("client-number", idx), zap.String("dc-location", dc), zap.Error(err))
+			}
 		}
 	}
 
-	durCh := make(chan time.Duration, 2*(*concurrency)*(*clientNumber))
+	durCh := make(chan time.Duration, 2*(*concurrency)*(*clientNumber)*(*batchSize))
+	errCh := make(chan error, 2*(*concurrency)*(*clientNumber)*(*batchSize))
 
 	wg.Add((*concurrency) * (*clientNumber))
 	for _, pdCli := range pdClients {
 		for i := 0; i < *concurrency; i++ {
-			go reqWorker(ctx, pdCli, durCh)
+			// The workers request the dc-locations in turn.
+			go reqWorker(ctx, pdCli, dcLocations[i%len(dcLocations)], durCh, errCh)
 		}
 	}
 
 	wg.Add(1)
-	go showStats(ctx, durCh)
+	go showStats(ctx, durCh, errCh)
 
 	timer := time.NewTimer(*duration)
 	defer timer.Stop()
@@ -134,7 +146,7 @@ func bench(mainCtx context.Context) {
 	}
 }
 
-func showStats(ctx context.Context, durCh chan time.Duration) {
+func showStats(ctx context.Context, durCh chan time.Duration, errCh chan error) {
 	defer wg.Done()
 
 	statCtx, cancel := context.WithCancel(ctx)
@@ -156,6 +168,11 @@ func showStats(ctx context.Context, durCh chan time.Duration) {
 			s = newStats()
 		case d := <-durCh:
 			s.update(d)
+		case err := <-errCh:
+			s.errCount++
+			if *verbose {
+				log.Warn("get tso failed", zap.Error(err))
+			}
 		case <-statCtx.Done():
 			fmt.Println("\nTotal:")
 			fmt.Println(total.Counter())
@@ -181,6 +198,16 @@ const (
 	oneThousandDur  = time.Millisecond * 1000
 )
 
+// latencyBuckets are the upper bounds of the buckets used to calculate the
+// latency percentiles, which grow exponentially by 5% from 10us to about 10s.
+var latencyBuckets = func() []time.Duration {
+	var buckets []time.Duration
+	for b := 10 * float64(time.Microsecond); b < float64(10*time.Second); b *= 1.05 {
+		buckets = append(buckets, time.Duration(b))
+	}
+	return buckets
+}()
+
 type stats struct {
 	maxDur          time.Duration
 	minDur          time.Duration
@@ -197,18 +224,24 @@ type stats struct {
 	fourHundredCnt  int
 	eightHundredCnt int
 	oneThousandCnt  int
+	errCount        int
+	// buckets counts the latencies by latencyBuckets, the last one is for
+	// the latencies exceeding all the buckets.
+	buckets []int
 }
 
 func newStats() *stats {
 	return &stats{
-		minDur: time.Hour,
-		maxDur: 0,
+		minDur:  time.Hour,
+		maxDur:  0,
+		buckets: make([]int, len(latencyBuckets)+1),
 	}
 }
 
 func (s *stats) update(dur time.Duration) {
 	s.count++
 	s.totalDur += dur
+	s.buckets[sort.Search(len(latencyBuckets), func(i int) bool { return dur <= latencyBuckets[i] })]++
 
 	if dur > s.maxDur {
 		s.maxDur = dur
@@ -294,49 +327,112 @@ func (s *stats) merge(other *stats) {
 	s.fourHundredCnt += other.fourHundredCnt
 	s.eightHundredCnt += other.eightHundredCnt
 	s.oneThousandCnt += other.oneThousandCnt
+	s.errCount += other.errCount
+	for i := range s.buckets {
+		s.buckets[i] += other.buckets[i]
+	}
+}
+
+// percentile returns the estimated latency at the given percentile, which is
+// the upper bound of the bucket that the percentile falls in.
+func (s *stats) percentile(p float64) time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+	target := int(float64(s.count)*p + 0.5)
+	if target < 1 {
+		target = 1
+	}
+	var cnt int
+	for i, c := range s.buckets {
+		cnt += c
+		if cnt >= target {
+			if i < len(latencyBuckets) && latencyBuckets[i] < s.maxDur {
+				return latencyBuckets[i]
+			}
+			return s.maxDur
+		}
+	}
+	return s.maxDur
+}
+
+func toMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
 func (s *stats) Counter() string {
+	var avgDur time.Duration
+	if s.count > 0 {
+		avgDur = s.totalDur / time.Duration(s.count)
+	}
 	return fmt.Sprintf(
-		"count:%d, max:%d, min:%d, avg:%d, >1ms:%d, >2ms:%d, >5ms:%d, >10ms:%d, >30ms:%d >50ms:%d >100ms:%d >200ms:%d >400ms:%d >800ms:%d >1s:%d",
-		s.count, s.maxDur.Nanoseconds()/int64(time.Millisecond), s.minDur.Nanoseconds()/int64(time.Millisecond), s.totalDur.Nanoseconds()/int64(s.count)/int64(time.Millisecond),
+		"count:%d, errors:%d, max:%d, min:%d, avg:%d, p50:%.2f, p99:%.2f, p999:%.2f, >1ms:%d, >2ms:%d, >5ms:%d, >10ms:%d, >30ms:%d >50ms:%d >100ms:%d >200ms:%d >400ms:%d >800ms:%d >1s:%d",
+		s.count, s.errCount, s.maxDur.Nanoseconds()/int64(time.Millisecond), s.minDur.Nanoseconds()/int64(time.Millisecond), avgDur.Nanoseconds()/int64(time.Millisecond),
+		toMilliseconds(s.percentile(0.5)), toMilliseconds(s.percentile(0.99)), toMilliseconds(s.percentile(0.999)),
 		s.milliCnt, s.twoMilliCnt, s.fiveMilliCnt, s.tenMSCnt, s.thirtyCnt, s.fiftyCnt, s.oneHundredCnt, s.twoHundredCnt, s.fourHundredCnt,
 		s.eightHundredCnt, s.oneThousandCnt)
 }
 
 func (s *stats) Percentage() string {
+	var errRate float64
+	if total := s.count + s.errCount; total > 0 {
+		errRate = float64(s.errCount) * 100 / float64(total)
+	}
 	return fmt.Sprintf(
-		"count:%d, >1ms:%2.2f%%, >2ms:%2.2f%%, >5ms:%2.2f%%, >10ms:%2.2f%%, >30ms:%2.2f%% >50ms:%2.2f%% >100ms:%2.2f%% >200ms:%2.2f%% >400ms:%2.2f%% >800ms:%2.2f%% >1s:%2.2f%%", s.count,
+		"count:%d, error rate:%2.2f%%, >1ms:%2.2f%%, >2ms:%2.2f%%, >5ms:%2.2f%%, >10ms:%2.2f%%, >30ms:%2.2f%% >50ms:%2.2f%% >100ms:%2.2f%% >200ms:%2.2f%% >400ms:%2.2f%% >800ms:%2.2f%% >1s:%2.2f%%", s.count, errRate,
 		s.calculate(s.milliCnt), s.calculate(s.twoMilliCnt), s.calculate(s.fiveMilliCnt), s.calculate(s.tenMSCnt), s.calculate(s.thirtyCnt), s.calculate(s.fiftyCnt),
 		s.calculate(s.oneHundredCnt), s.calculate(s.twoHundredCnt), s.calculate(s.fourHundredCnt), s.calculate(s.eightHundredCnt), s.calculate(s.oneThousandCnt))
 }
 
 func (s *stats) calculate(count int) float64 {
+	if s.count == 0 {
+		return 0
+	}
 	return float64(count) * 100 / float64(s.count)
 }
 
-func reqWorker(ctx context.Context, pdCli pd.Client, durCh chan time.Duration) {
+func reqWorker(ctx context.Context, pdCli pd.Client, dcLocation string, durCh chan time.Duration, errCh chan error) {
 	defer wg.Done()
 
 	reqCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	futures := make([]pd.TSFuture, *batchSize)
 	for {
 		start := time.Now()
-		_, _, err := pdCli.GetLocalTS(reqCtx, *dcLocation)
-		if errors.Cause(err) == context.Canceled {
-			return
+		for i := range futures {
+			futures[i] = pdCli.GetLocalTSAsync(reqCtx, dcLocation)
 		}
+		var failed bool
+		for _, future := range futures {
+			_, _, err := future.Wait()
+			if errors.Cause(err) == context.Canceled {
+				return
+			}
 
-		if err != nil {
-			log.Fatal(fmt.Sprintf("%v", err))
-		}
-		dur := time.Since(start)
+			if err != nil {
+				failed = true
+				select {
+				case <-reqCtx.Done():
+					return
+				case errCh <- err:
+				}
+				continue
+			}
+			dur := time.Since(start)
 
-		select {
-		case <-reqCtx.Done():
-			return
-		case durCh <- dur:
+			select {
+			case <-reqCtx.Done():
+				return
+			case durCh <- dur:
+			}
+		}
+		if failed {
+			select {
+			case <-reqCtx.Done():
+				return
+			case <-time.After(*errBackoff):
+			}
 		}
 	}
 }